@@ -38,8 +38,20 @@ func main() {
 		&sysModel.SysAuthorityMenu{},
 		&sysModel.SysApiToken{},
 		&sysModel.SysApiTokenApi{},
+		&sysModel.SysApiTokenScope{},
+		&sysModel.SysApiTokenUsage{},
+		&sysModel.SysOauthClient{},
 		&sysModel.JwtBlacklist{},
+		&sysModel.SysRefreshToken{},
+		&sysModel.SysSession{},
+		&sysModel.SysUserRecoveryCode{},
+		&sysModel.SysUserIdentity{},
+		&sysModel.SysUserPasskey{},
+		&sysModel.SysUserPasswordHistory{},
+		&sysModel.SysPasswordResetToken{},
+		&sysModel.SysEmailVerifyToken{},
 		&sysModel.SysOperationLog{},
+		&sysModel.SysLoginLog{},
 		&sysModel.SysUser{},
 		&sysModel.SysUserAuthority{},
 		&sysModel.SysNotice{},
@@ -12,13 +12,25 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/CIPFZ/gowebframe/internal/core/cache"
 	"github.com/CIPFZ/gowebframe/internal/core/claims"
 	"github.com/CIPFZ/gowebframe/internal/core/db"
 	"github.com/CIPFZ/gowebframe/internal/core/file"
+	"github.com/CIPFZ/gowebframe/internal/core/geoip"
 	"github.com/CIPFZ/gowebframe/internal/core/jwt"
+	"github.com/CIPFZ/gowebframe/internal/core/ldapauth"
+	"github.com/CIPFZ/gowebframe/internal/core/mailer"
+	"github.com/CIPFZ/gowebframe/internal/core/oauthclient"
+	"github.com/CIPFZ/gowebframe/internal/core/oidc"
+	"github.com/CIPFZ/gowebframe/internal/core/passkey"
+	"github.com/CIPFZ/gowebframe/internal/core/session"
+	coretoken "github.com/CIPFZ/gowebframe/internal/core/token"
+	"github.com/CIPFZ/gowebframe/internal/core/tokencache"
+	"github.com/CIPFZ/gowebframe/internal/core/tokenexpiry"
 	"github.com/CIPFZ/gowebframe/pkg/utils"
 	"go.opentelemetry.io/otel"
 
+	"github.com/CIPFZ/gowebframe/internal/core/apiusage"
 	"github.com/CIPFZ/gowebframe/internal/core/audit"
 	"github.com/CIPFZ/gowebframe/internal/core/config"
 	"github.com/CIPFZ/gowebframe/internal/core/i18n"
@@ -186,7 +198,7 @@ func initializeSystem(path string, serviceCtx *svc.ServiceContext) ([]utils.Shut
 			return serviceCtx.Redis.Close()
 		})
 	} else {
-		serviceCtx.Logger.Warn("redis disabled by config; token blacklist is disabled")
+		serviceCtx.Logger.Info("redis disabled by config; token blacklist and sessions fall back to database")
 	}
 
 	// Mongo
@@ -206,15 +218,72 @@ func initializeSystem(path string, serviceCtx *svc.ServiceContext) ([]utils.Shut
 	serviceCtx.CasbinEnforcer = claims.InitCasbin(serviceCtx.DB)
 	serviceCtx.Logger.Info("Casbin 初始化完成")
 
-	// Step 7: JWT (pkg/utils)
-	// 依赖 Redis (ServiceContext已持有)
-	serviceCtx.JWT = jwt.NewJWT(serviceCtx.Config.JWT, serviceCtx.Logger, serviceCtx.Redis)
+	// Step 7: JWT (core/jwt)
+	// 依赖 Redis / DB (ServiceContext已持有)，黑名单优先使用 Redis
+	serviceCtx.JWT, err = jwt.NewJWT(serviceCtx.Config.JWT, serviceCtx.Logger, serviceCtx.Redis, serviceCtx.DB)
+	if err != nil {
+		return nil, fmt.Errorf("jwt init failed: %w", err)
+	}
+	shutdowns = append(shutdowns, serviceCtx.JWT.Close)
+	// 登录会话: 启用 Redis 时存 Redis，否则落库
+	serviceCtx.Sessions = session.NewStore(serviceCtx.DB, serviceCtx.Redis)
+	// 短期数据 (验证码、登录失败计数等): 启用 Redis 时存 Redis，否则存内存
+	serviceCtx.Cache = cache.New(serviceCtx.Redis)
+	if mem, ok := serviceCtx.Cache.(*cache.MemoryStore); ok {
+		shutdowns = append(shutdowns, mem.Close)
+	}
+	// API Token 并发上限与请求配额: 启用 Redis 时多实例共享，否则按实例计数
+	serviceCtx.APITokenLimiter = coretoken.NewLimiter(serviceCtx.Redis)
+	serviceCtx.APITokenQuota = coretoken.NewQuota(serviceCtx.Redis)
+	// API Token 解析缓存: 令牌变更时通过 Redis pub/sub 通知各实例失效
+	serviceCtx.APITokenCache = tokencache.New(serviceCtx.DB, serviceCtx.Redis, serviceCtx.Logger)
+	shutdowns = append(shutdowns, serviceCtx.APITokenCache.Close)
+	// OAuth2 客户端解析缓存: 客户端变更时通过 Redis pub/sub 通知各实例失效
+	serviceCtx.OAuthClients = oauthclient.New(serviceCtx.DB, serviceCtx.Redis, serviceCtx.Logger)
+	shutdowns = append(shutdowns, serviceCtx.OAuthClients.Close)
+	// 单点登录 (OIDC)：授权状态与登录票据存放在 Cache，多实例部署需启用 Redis
+	if len(serviceCtx.Config.OIDC.Providers) > 0 {
+		serviceCtx.OIDC = oidc.NewManager(serviceCtx.Config.OIDC, serviceCtx.Cache)
+	}
+	// 通行密钥 (WebAuthn)：注册与登录状态存放在 Cache，多实例部署需启用 Redis
+	if serviceCtx.Config.Passkey.Enabled() {
+		passkeyCfg := serviceCtx.Config.Passkey
+		if passkeyCfg.RPDisplayName == "" {
+			passkeyCfg.RPDisplayName = serviceCtx.Config.System.Name
+		}
+		serviceCtx.Passkey, err = passkey.NewManager(passkeyCfg, serviceCtx.Cache)
+		if err != nil {
+			return nil, fmt.Errorf("passkey init failed: %w", err)
+		}
+	}
+	// 目录认证 (LDAP / AD)：作为本地密码之后的认证方式，按 sync_interval 定时同步角色
+	if serviceCtx.Config.LDAP.Enabled {
+		serviceCtx.LDAP = ldapauth.New(serviceCtx.Config.LDAP, serviceCtx.Logger)
+		shutdowns = append(shutdowns, serviceCtx.LDAP.Close)
+	}
+	// 邮件 (SMTP)：找回密码等通知
+	if serviceCtx.Config.Email.Host != "" {
+		serviceCtx.Mailer = mailer.New(serviceCtx.Config.Email)
+	}
+	// IP 归属地 (离线 GeoIP 库)：补充操作日志与登录日志，并用于角色的登录地区限制
+	if serviceCtx.Config.GeoIP.Enabled() {
+		serviceCtx.GeoIP, err = geoip.New(serviceCtx.Config.GeoIP, serviceCtx.Logger)
+		if err != nil {
+			return nil, fmt.Errorf("geoip init failed: %w", err)
+		}
+		shutdowns = append(shutdowns, serviceCtx.GeoIP.Close)
+	}
 
 	// Step 8: 审计日志 (core/audit)
 	// ✨ 关键：使用 core 层的 AuditRecorder，解耦循环依赖
 	auditRecorder := audit.NewAuditRecorder(serviceCtx.DB, serviceCtx.Logger)
 	serviceCtx.AuditRecorder = auditRecorder
 	shutdowns = append(shutdowns, auditRecorder.Close)
+	// API Token 用量统计复用同样的异步聚合模型
+	serviceCtx.ApiTokenUsage = apiusage.NewRecorder(serviceCtx.DB, serviceCtx.Cache, serviceCtx.Config.ApiTokenUsage, serviceCtx.Logger)
+	shutdowns = append(shutdowns, serviceCtx.ApiTokenUsage.Close)
+	// API Token 到期提醒：到期前 N 天向创建人发送站内通知
+	shutdowns = append(shutdowns, tokenexpiry.NewNotifier(serviceCtx.DB, serviceCtx.Cache, serviceCtx.Config.ApiTokenRotation, serviceCtx.Logger).Close)
 
 	// Step 9: 初始化 OSS
 	serviceCtx.OSS = file.NewFileService(serviceCtx.Config.File, serviceCtx.Logger)
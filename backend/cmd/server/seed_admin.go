@@ -184,9 +184,11 @@ func ensureBaseMenus(tx *gorm.DB) (map[string]uint, error) {
 		{Key: "sys_menu", ParentKey: "sys_root", Path: "/sys/menu", Name: "menu.system.menu", Component: "sys/menu", Icon: "MenuOutlined", Sort: 3, Locale: "menu.system.menu"},
 		{Key: "sys_api", ParentKey: "sys_root", Path: "/sys/api", Name: "menu.system.api", Component: "sys/api", Icon: "ApiOutlined", Sort: 4, Locale: "menu.system.api"},
 		{Key: "sys_api_token", ParentKey: "sys_root", Path: "/sys/api-token", Name: "menu.system.apiToken", Component: "sys/api-token", Icon: "KeyOutlined", Sort: 5, Locale: "menu.system.apiToken"},
-		{Key: "sys_operation", ParentKey: "sys_root", Path: "/sys/operation", Name: "menu.system.operation", Component: "sys/operation", Icon: "HistoryOutlined", Sort: 6, Locale: "menu.system.operation"},
-		{Key: "sys_notice", ParentKey: "sys_root", Path: "/sys/notice", Name: "menu.system.notice", Component: "sys/notice", Icon: "NotificationOutlined", Sort: 7, Locale: "menu.system.notice"},
-		{Key: "sys_plugin_master", ParentKey: "sys_root", Path: "/sys/plugin-master", Name: "menu.system.pluginMaster", Component: "sys/plugin-master", Icon: "DatabaseOutlined", Sort: 8, Locale: "menu.system.pluginMaster"},
+		{Key: "sys_oauth_client", ParentKey: "sys_root", Path: "/sys/oauth-client", Name: "menu.system.oauthClient", Component: "sys/oauth-client", Icon: "SafetyCertificateOutlined", Sort: 6, Locale: "menu.system.oauthClient"},
+		{Key: "sys_operation", ParentKey: "sys_root", Path: "/sys/operation", Name: "menu.system.operation", Component: "sys/operation", Icon: "HistoryOutlined", Sort: 7, Locale: "menu.system.operation"},
+		{Key: "sys_login_log", ParentKey: "sys_root", Path: "/sys/login-log", Name: "menu.system.loginLog", Component: "sys/login-log", Icon: "LoginOutlined", Sort: 8, Locale: "menu.system.loginLog"},
+		{Key: "sys_notice", ParentKey: "sys_root", Path: "/sys/notice", Name: "menu.system.notice", Component: "sys/notice", Icon: "NotificationOutlined", Sort: 9, Locale: "menu.system.notice"},
+		{Key: "sys_plugin_master", ParentKey: "sys_root", Path: "/sys/plugin-master", Name: "menu.system.pluginMaster", Component: "sys/plugin-master", Icon: "DatabaseOutlined", Sort: 10, Locale: "menu.system.pluginMaster"},
 		{Key: "plugin_root", Path: "/plugin", Name: "menu.plugin", Component: "components/RouterLayout", Icon: "AppstoreAddOutlined", Sort: 15, Locale: "menu.plugin"},
 		{Key: "plugin_project_management", ParentKey: "plugin_root", Path: "/plugin/project-management", Name: "menu.plugin.projectManagement", Component: "plugin/project-management", Icon: "FolderOpenOutlined", Sort: 1, Locale: "menu.plugin.projectManagement"},
 		{Key: "plugin_project_detail", ParentKey: "plugin_root", Path: "/plugin/project/:id", Name: "menu.plugin.projectDetail", Component: "plugin/project-detail", Icon: "ProfileOutlined", Sort: 2, Locale: "menu.plugin.projectDetail", HideInMenu: true},
@@ -253,10 +255,10 @@ func ensureBaseMenus(tx *gorm.DB) (map[string]uint, error) {
 
 func bindAuthorityMenus(tx *gorm.DB, menuIDs map[string]uint, adminAuthorityID uint) error {
 	roleMenuKeys := map[uint][]string{
-		adminAuthorityID: {"dashboard", "state", "about", "sys_root", "sys_user", "sys_authority", "sys_menu", "sys_api", "sys_api_token", "sys_operation", "sys_notice", "sys_plugin_master", "plugin_root", "plugin_project_management", "plugin_project_detail", "plugin_work_order_pool", "poetry_root", "poetry_dynasty", "poetry_genre", "poetry_author", "poetry_poem", "account_settings"},
+		adminAuthorityID: {"dashboard", "state", "about", "sys_root", "sys_user", "sys_authority", "sys_menu", "sys_api", "sys_api_token", "sys_oauth_client", "sys_operation", "sys_login_log", "sys_notice", "sys_plugin_master", "plugin_root", "plugin_project_management", "plugin_project_detail", "plugin_work_order_pool", "poetry_root", "poetry_dynasty", "poetry_genre", "poetry_author", "poetry_poem", "account_settings"},
 		10010:            {"dashboard", "about", "plugin_root", "plugin_project_management", "plugin_project_detail", "account_settings"},
 		10013:            {"dashboard", "about", "plugin_root", "plugin_project_detail", "plugin_work_order_pool", "account_settings"},
-		9528:             {"dashboard", "state", "about", "sys_root", "sys_user", "sys_authority", "sys_menu", "sys_api", "sys_api_token", "sys_operation", "sys_notice", "sys_plugin_master", "plugin_root", "plugin_project_management", "plugin_project_detail", "plugin_work_order_pool", "poetry_root", "poetry_dynasty", "poetry_genre", "poetry_author", "poetry_poem", "account_settings"},
+		9528:             {"dashboard", "state", "about", "sys_root", "sys_user", "sys_authority", "sys_menu", "sys_api", "sys_api_token", "sys_oauth_client", "sys_operation", "sys_login_log", "sys_notice", "sys_plugin_master", "plugin_root", "plugin_project_management", "plugin_project_detail", "plugin_work_order_pool", "poetry_root", "poetry_dynasty", "poetry_genre", "poetry_author", "poetry_poem", "account_settings"},
 		888:              {"dashboard", "state", "about", "poetry_root", "poetry_dynasty", "poetry_genre", "poetry_author", "poetry_poem", "account_settings"},
 		8881:             {"dashboard", "about", "account_settings"},
 	}
@@ -290,6 +292,26 @@ func ensureBaseApis(tx *gorm.DB) (map[string]uint, error) {
 		{Path: "/api/v1/sys/user/updateUser", Method: "PUT", ApiGroup: "system-user", Description: "Update user"},
 		{Path: "/api/v1/sys/user/deleteUser", Method: "DELETE", ApiGroup: "system-user", Description: "Delete user"},
 		{Path: "/api/v1/sys/user/resetPassword", Method: "POST", ApiGroup: "system-user", Description: "Reset password"},
+		{Path: "/api/v1/sys/user/sessions", Method: "GET", ApiGroup: "system-user", Description: "Get my sessions"},
+		{Path: "/api/v1/sys/user/kickSession", Method: "POST", ApiGroup: "system-user", Description: "Kick my session"},
+		{Path: "/api/v1/sys/user/getUserSessions", Method: "POST", ApiGroup: "system-user", Description: "Get user sessions"},
+		{Path: "/api/v1/sys/user/kickUserSession", Method: "POST", ApiGroup: "system-user", Description: "Kick user session"},
+		{Path: "/api/v1/sys/user/mfa/setup", Method: "POST", ApiGroup: "system-user", Description: "Setup my MFA"},
+		{Path: "/api/v1/sys/user/mfa/enable", Method: "POST", ApiGroup: "system-user", Description: "Enable my MFA"},
+		{Path: "/api/v1/sys/user/mfa/disable", Method: "POST", ApiGroup: "system-user", Description: "Disable my MFA"},
+		{Path: "/api/v1/sys/user/mfa/recoveryCodes", Method: "POST", ApiGroup: "system-user", Description: "Regenerate my MFA recovery codes"},
+		{Path: "/api/v1/sys/user/passkeys", Method: "GET", ApiGroup: "system-user", Description: "Get my passkeys"},
+		{Path: "/api/v1/sys/user/passkey/options", Method: "POST", ApiGroup: "system-user", Description: "Begin passkey registration"},
+		{Path: "/api/v1/sys/user/passkey/register", Method: "POST", ApiGroup: "system-user", Description: "Register my passkey"},
+		{Path: "/api/v1/sys/user/passkey/revoke", Method: "POST", ApiGroup: "system-user", Description: "Delete my passkey"},
+		{Path: "/api/v1/sys/user/getUserPasskeys", Method: "POST", ApiGroup: "system-user", Description: "Get user passkeys"},
+		{Path: "/api/v1/sys/user/revokeUserPasskey", Method: "POST", ApiGroup: "system-user", Description: "Revoke user passkey"},
+		{Path: "/api/v1/sys/user/unlockUser", Method: "POST", ApiGroup: "system-user", Description: "Unlock user login"},
+		{Path: "/api/v1/sys/user/changePassword", Method: "POST", ApiGroup: "system-user", Description: "Change my password"},
+		{Path: "/api/v1/sys/user/getRegistrationList", Method: "POST", ApiGroup: "system-user", Description: "注册申请列表"},
+		{Path: "/api/v1/sys/user/approveRegistration", Method: "POST", ApiGroup: "system-user", Description: "审核通过注册申请"},
+		{Path: "/api/v1/sys/user/rejectRegistration", Method: "POST", ApiGroup: "system-user", Description: "拒绝注册申请"},
+		{Path: "/api/v1/sys/user/impersonate", Method: "POST", ApiGroup: "system-user", Description: "模拟登录 (以指定用户身份操作)"},
 
 		{Path: "/api/v1/sys/menu/getMenu", Method: "GET", ApiGroup: "system-menu", Description: "Get current menu"},
 		{Path: "/api/v1/sys/menu/getMenuList", Method: "POST", ApiGroup: "system-menu", Description: "Get menu list"},
@@ -311,18 +333,29 @@ func ensureBaseApis(tx *gorm.DB) (map[string]uint, error) {
 
 		{Path: "/api/v1/sys/api-token/getApiTokenList", Method: "POST", ApiGroup: "system-api-token", Description: "Get API token list"},
 		{Path: "/api/v1/sys/api-token/detail", Method: "GET", ApiGroup: "system-api-token", Description: "Get API token detail"},
+		{Path: "/api/v1/sys/api-token/usage", Method: "GET", ApiGroup: "system-api-token", Description: "Get API token usage"},
 		{Path: "/api/v1/sys/api-token/create", Method: "POST", ApiGroup: "system-api-token", Description: "Create API token"},
 		{Path: "/api/v1/sys/api-token/update", Method: "PUT", ApiGroup: "system-api-token", Description: "Update API token"},
 		{Path: "/api/v1/sys/api-token/delete", Method: "DELETE", ApiGroup: "system-api-token", Description: "Delete API token"},
 		{Path: "/api/v1/sys/api-token/reset", Method: "POST", ApiGroup: "system-api-token", Description: "Reset API token"},
+		{Path: "/api/v1/sys/api-token/rotate", Method: "POST", ApiGroup: "system-api-token", Description: "Rotate API token with grace period"},
 		{Path: "/api/v1/sys/api-token/enable", Method: "POST", ApiGroup: "system-api-token", Description: "Enable API token"},
 		{Path: "/api/v1/sys/api-token/disable", Method: "POST", ApiGroup: "system-api-token", Description: "Disable API token"},
+		{Path: "/api/v1/sys/oauth-client/getOauthClientList", Method: "POST", ApiGroup: "system-oauth-client", Description: "Get OAuth client list"},
+		{Path: "/api/v1/sys/oauth-client/create", Method: "POST", ApiGroup: "system-oauth-client", Description: "Create OAuth client"},
+		{Path: "/api/v1/sys/oauth-client/update", Method: "PUT", ApiGroup: "system-oauth-client", Description: "Update OAuth client"},
+		{Path: "/api/v1/sys/oauth-client/delete", Method: "DELETE", ApiGroup: "system-oauth-client", Description: "Delete OAuth client"},
+		{Path: "/api/v1/sys/oauth-client/reset", Method: "POST", ApiGroup: "system-oauth-client", Description: "Reset OAuth client secret"},
+		{Path: "/api/v1/sys/oauth-client/enable", Method: "POST", ApiGroup: "system-oauth-client", Description: "Enable OAuth client"},
+		{Path: "/api/v1/sys/oauth-client/disable", Method: "POST", ApiGroup: "system-oauth-client", Description: "Disable OAuth client"},
 
 		{Path: "/api/v1/sys/casbin/getPolicyPathByAuthorityId", Method: "POST", ApiGroup: "system-casbin", Description: "Get casbin policy list"},
 		{Path: "/api/v1/sys/casbin/updateCasbin", Method: "POST", ApiGroup: "system-casbin", Description: "Update casbin policy"},
 
 		{Path: "/api/v1/sys/operationLog/getOperationLogList", Method: "POST", ApiGroup: "system-operation", Description: "Get operation logs"},
 		{Path: "/api/v1/sys/operationLog/deleteOperationLogByIds", Method: "DELETE", ApiGroup: "system-operation", Description: "Delete operation logs"},
+		{Path: "/api/v1/sys/loginLog/getLoginLogList", Method: "POST", ApiGroup: "system-login-log", Description: "登录日志列表"},
+		{Path: "/api/v1/sys/loginLog/getSelfLoginLogList", Method: "POST", ApiGroup: "system-login-log", Description: "本人登录日志"},
 		{Path: "/api/v1/sys/file/upload", Method: "POST", ApiGroup: "system-file", Description: "Upload file"},
 		{Path: "/api/v1/sys/system/getServerInfo", Method: "POST", ApiGroup: "system-state", Description: "Get server state"},
 		{Path: "/api/v1/sys/notice/createNotice", Method: "POST", ApiGroup: "system-notice", Description: "Create notice"},
@@ -407,6 +440,26 @@ func bindAuthorityApis(tx *gorm.DB, apiIDs map[string]uint, adminAuthorityID uin
 		apiSign("PUT", "/api/v1/sys/user/updateUser"),
 		apiSign("DELETE", "/api/v1/sys/user/deleteUser"),
 		apiSign("POST", "/api/v1/sys/user/resetPassword"),
+		apiSign("GET", "/api/v1/sys/user/sessions"),
+		apiSign("POST", "/api/v1/sys/user/kickSession"),
+		apiSign("POST", "/api/v1/sys/user/getUserSessions"),
+		apiSign("POST", "/api/v1/sys/user/kickUserSession"),
+		apiSign("POST", "/api/v1/sys/user/mfa/setup"),
+		apiSign("POST", "/api/v1/sys/user/mfa/enable"),
+		apiSign("POST", "/api/v1/sys/user/mfa/disable"),
+		apiSign("POST", "/api/v1/sys/user/mfa/recoveryCodes"),
+		apiSign("GET", "/api/v1/sys/user/passkeys"),
+		apiSign("POST", "/api/v1/sys/user/passkey/options"),
+		apiSign("POST", "/api/v1/sys/user/passkey/register"),
+		apiSign("POST", "/api/v1/sys/user/passkey/revoke"),
+		apiSign("POST", "/api/v1/sys/user/getUserPasskeys"),
+		apiSign("POST", "/api/v1/sys/user/revokeUserPasskey"),
+		apiSign("POST", "/api/v1/sys/user/unlockUser"),
+		apiSign("POST", "/api/v1/sys/user/changePassword"),
+		apiSign("POST", "/api/v1/sys/user/getRegistrationList"),
+		apiSign("POST", "/api/v1/sys/user/approveRegistration"),
+		apiSign("POST", "/api/v1/sys/user/rejectRegistration"),
+		apiSign("POST", "/api/v1/sys/user/impersonate"),
 		apiSign("GET", "/api/v1/sys/menu/getMenu"),
 		apiSign("POST", "/api/v1/sys/menu/getMenuList"),
 		apiSign("POST", "/api/v1/sys/menu/getMenuAuthority"),
@@ -424,16 +477,27 @@ func bindAuthorityApis(tx *gorm.DB, apiIDs map[string]uint, adminAuthorityID uin
 		apiSign("DELETE", "/api/v1/sys/api/deleteApi"),
 		apiSign("POST", "/api/v1/sys/api-token/getApiTokenList"),
 		apiSign("GET", "/api/v1/sys/api-token/detail"),
+		apiSign("GET", "/api/v1/sys/api-token/usage"),
 		apiSign("POST", "/api/v1/sys/api-token/create"),
 		apiSign("PUT", "/api/v1/sys/api-token/update"),
 		apiSign("DELETE", "/api/v1/sys/api-token/delete"),
 		apiSign("POST", "/api/v1/sys/api-token/reset"),
+		apiSign("POST", "/api/v1/sys/api-token/rotate"),
 		apiSign("POST", "/api/v1/sys/api-token/enable"),
 		apiSign("POST", "/api/v1/sys/api-token/disable"),
+		apiSign("POST", "/api/v1/sys/oauth-client/getOauthClientList"),
+		apiSign("POST", "/api/v1/sys/oauth-client/create"),
+		apiSign("PUT", "/api/v1/sys/oauth-client/update"),
+		apiSign("DELETE", "/api/v1/sys/oauth-client/delete"),
+		apiSign("POST", "/api/v1/sys/oauth-client/reset"),
+		apiSign("POST", "/api/v1/sys/oauth-client/enable"),
+		apiSign("POST", "/api/v1/sys/oauth-client/disable"),
 		apiSign("POST", "/api/v1/sys/casbin/getPolicyPathByAuthorityId"),
 		apiSign("POST", "/api/v1/sys/casbin/updateCasbin"),
 		apiSign("POST", "/api/v1/sys/operationLog/getOperationLogList"),
 		apiSign("DELETE", "/api/v1/sys/operationLog/deleteOperationLogByIds"),
+		apiSign("POST", "/api/v1/sys/loginLog/getLoginLogList"),
+		apiSign("POST", "/api/v1/sys/loginLog/getSelfLoginLogList"),
 		apiSign("POST", "/api/v1/sys/file/upload"),
 		apiSign("POST", "/api/v1/sys/system/getServerInfo"),
 		apiSign("POST", "/api/v1/sys/notice/createNotice"),
@@ -485,6 +549,18 @@ func bindAuthorityApis(tx *gorm.DB, apiIDs map[string]uint, adminAuthorityID uin
 		apiSign("PUT", "/api/v1/sys/user/info"),
 		apiSign("PUT", "/api/v1/sys/user/ui-config"),
 		apiSign("POST", "/api/v1/sys/user/avatar"),
+		apiSign("GET", "/api/v1/sys/user/sessions"),
+		apiSign("POST", "/api/v1/sys/loginLog/getSelfLoginLogList"),
+		apiSign("POST", "/api/v1/sys/user/kickSession"),
+		apiSign("POST", "/api/v1/sys/user/mfa/setup"),
+		apiSign("POST", "/api/v1/sys/user/mfa/enable"),
+		apiSign("POST", "/api/v1/sys/user/mfa/disable"),
+		apiSign("POST", "/api/v1/sys/user/mfa/recoveryCodes"),
+		apiSign("GET", "/api/v1/sys/user/passkeys"),
+		apiSign("POST", "/api/v1/sys/user/passkey/options"),
+		apiSign("POST", "/api/v1/sys/user/passkey/register"),
+		apiSign("POST", "/api/v1/sys/user/passkey/revoke"),
+		apiSign("POST", "/api/v1/sys/user/changePassword"),
 		apiSign("GET", "/api/v1/sys/menu/getMenu"),
 		apiSign("POST", "/api/v1/sys/system/getServerInfo"),
 		apiSign("GET", "/api/v1/sys/notice/getMyNotices"),
@@ -561,6 +637,26 @@ func ensureCasbinPolicies(tx *gorm.DB, apiIDs map[string]uint, adminAuthorityID
 		{"PUT", "/api/v1/sys/user/updateUser"},
 		{"DELETE", "/api/v1/sys/user/deleteUser"},
 		{"POST", "/api/v1/sys/user/resetPassword"},
+		{"GET", "/api/v1/sys/user/sessions"},
+		{"POST", "/api/v1/sys/user/kickSession"},
+		{"POST", "/api/v1/sys/user/getUserSessions"},
+		{"POST", "/api/v1/sys/user/kickUserSession"},
+		{"POST", "/api/v1/sys/user/mfa/setup"},
+		{"POST", "/api/v1/sys/user/mfa/enable"},
+		{"POST", "/api/v1/sys/user/mfa/disable"},
+		{"POST", "/api/v1/sys/user/mfa/recoveryCodes"},
+		{"GET", "/api/v1/sys/user/passkeys"},
+		{"POST", "/api/v1/sys/user/passkey/options"},
+		{"POST", "/api/v1/sys/user/passkey/register"},
+		{"POST", "/api/v1/sys/user/passkey/revoke"},
+		{"POST", "/api/v1/sys/user/getUserPasskeys"},
+		{"POST", "/api/v1/sys/user/revokeUserPasskey"},
+		{"POST", "/api/v1/sys/user/unlockUser"},
+		{"POST", "/api/v1/sys/user/changePassword"},
+		{"POST", "/api/v1/sys/user/getRegistrationList"},
+		{"POST", "/api/v1/sys/user/approveRegistration"},
+		{"POST", "/api/v1/sys/user/rejectRegistration"},
+		{"POST", "/api/v1/sys/user/impersonate"},
 		{"GET", "/api/v1/sys/menu/getMenu"},
 		{"POST", "/api/v1/sys/menu/getMenuList"},
 		{"POST", "/api/v1/sys/menu/getMenuAuthority"},
@@ -578,16 +674,27 @@ func ensureCasbinPolicies(tx *gorm.DB, apiIDs map[string]uint, adminAuthorityID
 		{"DELETE", "/api/v1/sys/api/deleteApi"},
 		{"POST", "/api/v1/sys/api-token/getApiTokenList"},
 		{"GET", "/api/v1/sys/api-token/detail"},
+		{"GET", "/api/v1/sys/api-token/usage"},
 		{"POST", "/api/v1/sys/api-token/create"},
 		{"PUT", "/api/v1/sys/api-token/update"},
 		{"DELETE", "/api/v1/sys/api-token/delete"},
 		{"POST", "/api/v1/sys/api-token/reset"},
+		{"POST", "/api/v1/sys/api-token/rotate"},
 		{"POST", "/api/v1/sys/api-token/enable"},
 		{"POST", "/api/v1/sys/api-token/disable"},
+		{"POST", "/api/v1/sys/oauth-client/getOauthClientList"},
+		{"POST", "/api/v1/sys/oauth-client/create"},
+		{"PUT", "/api/v1/sys/oauth-client/update"},
+		{"DELETE", "/api/v1/sys/oauth-client/delete"},
+		{"POST", "/api/v1/sys/oauth-client/reset"},
+		{"POST", "/api/v1/sys/oauth-client/enable"},
+		{"POST", "/api/v1/sys/oauth-client/disable"},
 		{"POST", "/api/v1/sys/casbin/getPolicyPathByAuthorityId"},
 		{"POST", "/api/v1/sys/casbin/updateCasbin"},
 		{"POST", "/api/v1/sys/operationLog/getOperationLogList"},
 		{"DELETE", "/api/v1/sys/operationLog/deleteOperationLogByIds"},
+		{"POST", "/api/v1/sys/loginLog/getLoginLogList"},
+		{"POST", "/api/v1/sys/loginLog/getSelfLoginLogList"},
 		{"POST", "/api/v1/sys/file/upload"},
 		{"POST", "/api/v1/sys/system/getServerInfo"},
 		{"POST", "/api/v1/sys/notice/createNotice"},
@@ -639,6 +746,18 @@ func ensureCasbinPolicies(tx *gorm.DB, apiIDs map[string]uint, adminAuthorityID
 		{"PUT", "/api/v1/sys/user/info"},
 		{"PUT", "/api/v1/sys/user/ui-config"},
 		{"POST", "/api/v1/sys/user/avatar"},
+		{"GET", "/api/v1/sys/user/sessions"},
+		{"POST", "/api/v1/sys/loginLog/getSelfLoginLogList"},
+		{"POST", "/api/v1/sys/user/kickSession"},
+		{"POST", "/api/v1/sys/user/mfa/setup"},
+		{"POST", "/api/v1/sys/user/mfa/enable"},
+		{"POST", "/api/v1/sys/user/mfa/disable"},
+		{"POST", "/api/v1/sys/user/mfa/recoveryCodes"},
+		{"GET", "/api/v1/sys/user/passkeys"},
+		{"POST", "/api/v1/sys/user/passkey/options"},
+		{"POST", "/api/v1/sys/user/passkey/register"},
+		{"POST", "/api/v1/sys/user/passkey/revoke"},
+		{"POST", "/api/v1/sys/user/changePassword"},
 		{"GET", "/api/v1/sys/menu/getMenu"},
 		{"POST", "/api/v1/sys/system/getServerInfo"},
 		{"GET", "/api/v1/sys/notice/getMyNotices"},
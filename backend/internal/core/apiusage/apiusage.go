@@ -0,0 +1,334 @@
+package apiusage
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/CIPFZ/gowebframe/internal/core/cache"
+	"github.com/CIPFZ/gowebframe/internal/core/config"
+	"github.com/CIPFZ/gowebframe/internal/modules/system/model"
+	"github.com/CIPFZ/gowebframe/pkg/utils"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	eventChanCapacity = 10000            // 待聚合请求的缓冲容量
+	maxPendingRows    = 1000             // 内存中聚合的行数达到阈值时提前写库
+	flushInterval     = 10 * time.Second // 定时写库间隔
+	rollupInterval    = time.Hour        // 小时数据合并为天数据的检查间隔
+	rollupBatchSize   = 1000
+
+	defaultHourlyRetention = 7 * 24 * time.Hour
+	// 多实例部署时同一时间只有一个实例执行合并
+	rollupLockKey = "api_token_usage_rollup"
+)
+
+// Event 一次 API Token 请求
+type Event struct {
+	TokenID  uint
+	Method   string
+	Path     string // 路由模板，与 sys_apis.path 一致
+	Failed   bool
+	Latency  time.Duration
+	BytesIn  int64
+	BytesOut int64
+	At       time.Time
+	// PreviousSecret 使用轮换前的旧密钥，同时更新令牌的 previous_token_last_used_at
+	PreviousSecret bool
+}
+
+type rowKey struct {
+	tokenID uint
+	hour    time.Time
+	method  string
+	path    string
+}
+
+// Recorder 在内存中按令牌、接口、小时聚合请求，定时批量写库 (与 audit.AuditRecorder 相同的异步模型)
+// 同时负责把超过保留期的小时数据合并为天数据
+type Recorder struct {
+	db     *gorm.DB
+	cache  cache.Store
+	logger *zap.Logger
+	now    func() time.Time
+
+	hourlyRetention time.Duration
+	dailyRetention  time.Duration // 0 代表永久保留
+
+	events   chan Event
+	stop     chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+// NewRecorder 启动聚合与定时合并；cache 用于多实例间的合并锁，可为 nil
+func NewRecorder(db *gorm.DB, store cache.Store, cfg config.ApiTokenUsage, logger *zap.Logger) *Recorder {
+	r := &Recorder{
+		db:     db,
+		cache:  store,
+		logger: logger,
+		now:    time.Now,
+		events: make(chan Event, eventChanCapacity),
+		stop:   make(chan struct{}),
+
+		hourlyRetention: utils.ParseDurationOr(cfg.HourlyRetention, defaultHourlyRetention),
+		dailyRetention:  utils.ParseDurationOr(cfg.DailyRetention, 0),
+	}
+	r.wg.Add(2)
+	go r.startWorker()
+	go r.startRollup()
+	return r
+}
+
+// Push 非阻塞，队列已满时丢弃并记录警告
+func (r *Recorder) Push(e Event) {
+	select {
+	case r.events <- e:
+	default:
+		r.logger.Warn("api_token_usage_dropped_queue_full", zap.Uint("tokenID", e.TokenID), zap.String("path", e.Path))
+	}
+}
+
+func (r *Recorder) startWorker() {
+	defer r.wg.Done()
+
+	pending := make(map[rowKey]*model.SysApiTokenUsage)
+	lastUsed := make(map[uint]time.Time)
+	previousUsed := make(map[uint]time.Time)
+	flush := func() {
+		if len(pending) == 0 {
+			return
+		}
+		r.flush(pending, lastUsed, previousUsed)
+		pending = make(map[rowKey]*model.SysApiTokenUsage)
+		lastUsed = make(map[uint]time.Time)
+		previousUsed = make(map[uint]time.Time)
+	}
+
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case e, ok := <-r.events:
+			if !ok {
+				flush()
+				return
+			}
+			hour := truncateHour(e.At)
+			key := rowKey{tokenID: e.TokenID, hour: hour, method: e.Method, path: e.Path}
+			row := pending[key]
+			if row == nil {
+				row = &model.SysApiTokenUsage{
+					ApiTokenID:  e.TokenID,
+					Granularity: model.UsageGranularityHour,
+					PeriodStart: hour,
+					Method:      e.Method,
+					Path:        e.Path,
+				}
+				pending[key] = row
+			}
+			row.Calls++
+			if e.Failed {
+				row.Errors++
+			}
+			row.BytesIn += e.BytesIn
+			row.BytesOut += e.BytesOut
+			row.Histogram = row.Histogram.Observe(e.Latency)
+			if e.At.After(lastUsed[e.TokenID]) {
+				lastUsed[e.TokenID] = e.At
+			}
+			if e.PreviousSecret && e.At.After(previousUsed[e.TokenID]) {
+				previousUsed[e.TokenID] = e.At
+			}
+			if len(pending) >= maxPendingRows {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+// flush 把内存中的聚合合并进库中同一小时的行，并更新令牌 (及轮换前旧密钥) 的最近使用时间
+func (r *Recorder) flush(pending map[rowKey]*model.SysApiTokenUsage, lastUsed, previousUsed map[uint]time.Time) {
+	ctx := context.Background()
+	for _, row := range pending {
+		row.P50Ms = row.Histogram.Percentile(0.5)
+		row.P95Ms = row.Histogram.Percentile(0.95)
+		if err := r.mergeRow(ctx, row); err != nil {
+			r.logger.Error("flush_api_token_usage_failed", zap.Uint("tokenID", row.ApiTokenID), zap.String("path", row.Path), zap.Error(err))
+		}
+	}
+	for tokenID, at := range lastUsed {
+		if err := r.db.WithContext(ctx).Model(&model.SysApiToken{}).
+			Where("id = ? AND (last_used_at IS NULL OR last_used_at < ?)", tokenID, at).
+			Update("last_used_at", at).Error; err != nil {
+			r.logger.Error("touch_api_token_failed", zap.Uint("tokenID", tokenID), zap.Error(err))
+		}
+	}
+	for tokenID, at := range previousUsed {
+		if err := r.db.WithContext(ctx).Model(&model.SysApiToken{}).
+			Where("id = ? AND (previous_token_last_used_at IS NULL OR previous_token_last_used_at < ?)", tokenID, at).
+			Update("previous_token_last_used_at", at).Error; err != nil {
+			r.logger.Error("touch_previous_api_token_failed", zap.Uint("tokenID", tokenID), zap.Error(err))
+		}
+	}
+}
+
+// mergeRow 累加到同一周期的已有行；多个实例同时新建时唯一索引冲突，重试一次即可合并
+func (r *Recorder) mergeRow(ctx context.Context, row *model.SysApiTokenUsage) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return mergeInto(tx, row)
+	})
+	if err != nil {
+		err = r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			return mergeInto(tx, row)
+		})
+	}
+	return err
+}
+
+func mergeInto(tx *gorm.DB, row *model.SysApiTokenUsage) error {
+	var existing model.SysApiTokenUsage
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("api_token_id = ? AND granularity = ? AND period_start = ? AND method = ? AND path = ?",
+			row.ApiTokenID, row.Granularity, row.PeriodStart, row.Method, row.Path).
+		Take(&existing).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		created := *row
+		created.ID = 0
+		return tx.Create(&created).Error
+	}
+	if err != nil {
+		return err
+	}
+	existing.Merge(row)
+	return tx.Save(&existing).Error
+}
+
+func (r *Recorder) startRollup() {
+	defer r.wg.Done()
+	ticker := time.NewTicker(rollupInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := r.Rollup(context.Background()); err != nil {
+				r.logger.Error("api_token_usage_rollup_failed", zap.Error(err))
+			}
+		case <-r.stop:
+			return
+		}
+	}
+}
+
+// Rollup 把超过保留期的小时数据合并为天数据，并删除超过保留期的天数据
+func (r *Recorder) Rollup(ctx context.Context) error {
+	if r.cache != nil {
+		ok, err := r.cache.SetNX(ctx, rollupLockKey, "1", rollupInterval/2)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return nil
+		}
+	}
+
+	// 按天对齐，同一天的小时数据一起合并
+	now := r.now()
+	cutoff := truncateDay(now.Add(-r.hourlyRetention))
+	for {
+		var hourly []model.SysApiTokenUsage
+		if err := r.db.WithContext(ctx).
+			Where("granularity = ? AND period_start < ?", model.UsageGranularityHour, cutoff).
+			Order("id").Limit(rollupBatchSize).
+			Find(&hourly).Error; err != nil {
+			return err
+		}
+		if len(hourly) == 0 {
+			break
+		}
+		if err := r.rollupBatch(ctx, hourly); err != nil {
+			return err
+		}
+	}
+
+	if r.dailyRetention > 0 {
+		expired := truncateDay(now.Add(-r.dailyRetention))
+		if err := r.db.WithContext(ctx).
+			Where("granularity = ? AND period_start < ?", model.UsageGranularityDay, expired).
+			Delete(&model.SysApiTokenUsage{}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rollupBatch 在同一事务中写入天数据并删除对应的小时数据，失败时整批回滚
+func (r *Recorder) rollupBatch(ctx context.Context, hourly []model.SysApiTokenUsage) error {
+	daily := make(map[rowKey]*model.SysApiTokenUsage)
+	ids := make([]uint, 0, len(hourly))
+	for i := range hourly {
+		h := &hourly[i]
+		ids = append(ids, h.ID)
+		day := truncateDay(h.PeriodStart)
+		key := rowKey{tokenID: h.ApiTokenID, hour: day, method: h.Method, path: h.Path}
+		row := daily[key]
+		if row == nil {
+			row = &model.SysApiTokenUsage{
+				ApiTokenID:  h.ApiTokenID,
+				Granularity: model.UsageGranularityDay,
+				PeriodStart: day,
+				Method:      h.Method,
+				Path:        h.Path,
+			}
+			daily[key] = row
+		}
+		row.Merge(h)
+	}
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, row := range daily {
+			if err := mergeInto(tx, row); err != nil {
+				return err
+			}
+		}
+		return tx.Where("id IN ?", ids).Delete(&model.SysApiTokenUsage{}).Error
+	})
+}
+
+// Close 停止定时合并，并在退出前写入内存中剩余的统计
+func (r *Recorder) Close(ctx context.Context) error {
+	r.stopOnce.Do(func() {
+		close(r.stop)
+		close(r.events)
+	})
+	done := make(chan struct{})
+	go func() {
+		r.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		r.logger.Warn("api_token_usage_close_timeout", zap.Error(ctx.Err()))
+		return ctx.Err()
+	}
+}
+
+// truncateHour / truncateDay 按服务器本地时区取周期开始时间
+// 库中读出的时间可能带有其他时区，统一转换后再比较
+func truncateHour(t time.Time) time.Time {
+	t = t.Local()
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, time.Local)
+}
+
+func truncateDay(t time.Time) time.Time {
+	t = t.Local()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local)
+}
@@ -0,0 +1,149 @@
+package apiusage
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/CIPFZ/gowebframe/internal/core/config"
+	"github.com/CIPFZ/gowebframe/internal/core/db"
+	"github.com/CIPFZ/gowebframe/internal/modules/system/model"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+func newUsageTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	gormDB, err := db.InitDatabase(config.Database{
+		Driver: "sqlite3",
+		SQLite: config.SQLite{
+			Path:         filepath.Join(t.TempDir(), "apiusage.db"),
+			MaxIdleConns: 1,
+			MaxOpenConns: 1,
+		},
+	}, zap.NewNop())
+	if err != nil {
+		t.Fatalf("InitDatabase() error = %v", err)
+	}
+	if err := gormDB.AutoMigrate(&model.SysApiToken{}, &model.SysApiTokenUsage{}); err != nil {
+		t.Fatalf("AutoMigrate() error = %v", err)
+	}
+	sqlDB, err := gormDB.DB()
+	if err != nil {
+		t.Fatalf("DB() error = %v", err)
+	}
+	t.Cleanup(func() {
+		_ = sqlDB.Close()
+	})
+	return gormDB
+}
+
+func TestRecorderAggregatesAndMergesAcrossFlushes(t *testing.T) {
+	gormDB := newUsageTestDB(t)
+	token := model.SysApiToken{TokenHash: "hash", Name: "reader", Enabled: true}
+	if err := gormDB.Create(&token).Error; err != nil {
+		t.Fatalf("create token error = %v", err)
+	}
+
+	at := time.Date(2024, 5, 1, 10, 15, 0, 0, time.Local)
+	push := func(r *Recorder, n int, failed bool, latency time.Duration) {
+		for i := 0; i < n; i++ {
+			r.Push(Event{
+				TokenID: token.ID, Method: "GET", Path: "/api/v1/poetry/poem/list",
+				Failed: failed, Latency: latency, BytesIn: 10, BytesOut: 100,
+				At: at.Add(time.Duration(i) * time.Second),
+			})
+		}
+	}
+
+	// 两个实例写入同一小时，应合并为一行
+	first := NewRecorder(gormDB, nil, config.ApiTokenUsage{}, zap.NewNop())
+	push(first, 9, false, 8*time.Millisecond)
+	if err := first.Close(context.Background()); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	second := NewRecorder(gormDB, nil, config.ApiTokenUsage{}, zap.NewNop())
+	push(second, 1, true, 800*time.Millisecond)
+	// 下一小时单独一行
+	second.Push(Event{TokenID: token.ID, Method: "GET", Path: "/api/v1/poetry/poem/list", At: at.Add(time.Hour)})
+	if err := second.Close(context.Background()); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	var rows []model.SysApiTokenUsage
+	if err := gormDB.Order("period_start").Find(&rows).Error; err != nil {
+		t.Fatalf("load usage error = %v", err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("usage rows = %d, want 2", len(rows))
+	}
+	row := rows[0]
+	if row.Granularity != model.UsageGranularityHour || !row.PeriodStart.Equal(truncateHour(at)) {
+		t.Fatalf("usage row period = %s %v, want hour %v", row.Granularity, row.PeriodStart, truncateHour(at))
+	}
+	if row.Calls != 10 || row.Errors != 1 || row.BytesIn != 100 || row.BytesOut != 1000 {
+		t.Fatalf("usage row = %+v, want 10 calls, 1 error, 100/1000 bytes", row)
+	}
+	if row.P50Ms <= 5 || row.P50Ms > 10 {
+		t.Fatalf("usage p50 = %v, want within the 5-10ms bucket", row.P50Ms)
+	}
+	if row.P95Ms <= 750 || row.P95Ms > 1000 {
+		t.Fatalf("usage p95 = %v, want within the 750-1000ms bucket", row.P95Ms)
+	}
+
+	var stored model.SysApiToken
+	if err := gormDB.First(&stored, token.ID).Error; err != nil {
+		t.Fatalf("load token error = %v", err)
+	}
+	if stored.LastUsedAt == nil || !stored.LastUsedAt.Equal(at.Add(time.Hour)) {
+		t.Fatalf("last_used_at = %v, want %v", stored.LastUsedAt, at.Add(time.Hour))
+	}
+}
+
+func TestRecorderRollupMergesHourlyIntoDaily(t *testing.T) {
+	gormDB := newUsageTestDB(t)
+	now := time.Date(2024, 5, 20, 3, 0, 0, 0, time.Local)
+	old := time.Date(2024, 5, 10, 0, 0, 0, 0, time.Local)
+
+	seed := []model.SysApiTokenUsage{
+		{ApiTokenID: 1, Granularity: model.UsageGranularityHour, PeriodStart: old.Add(2 * time.Hour), Method: "GET", Path: "/a", Calls: 3, Errors: 1,
+			Histogram: model.LatencyHistogram{}.Observe(time.Millisecond).Observe(time.Millisecond).Observe(time.Millisecond)},
+		{ApiTokenID: 1, Granularity: model.UsageGranularityHour, PeriodStart: old.Add(20 * time.Hour), Method: "GET", Path: "/a", Calls: 2,
+			Histogram: model.LatencyHistogram{}.Observe(time.Second).Observe(time.Second)},
+		// 仍在保留期内
+		{ApiTokenID: 1, Granularity: model.UsageGranularityHour, PeriodStart: now.Add(-time.Hour), Method: "GET", Path: "/a", Calls: 1},
+		// 超过天数据保留期
+		{ApiTokenID: 1, Granularity: model.UsageGranularityDay, PeriodStart: now.AddDate(-2, 0, 0), Method: "GET", Path: "/a", Calls: 1},
+	}
+	if err := gormDB.Create(&seed).Error; err != nil {
+		t.Fatalf("seed usage error = %v", err)
+	}
+
+	r := NewRecorder(gormDB, nil, config.ApiTokenUsage{HourlyRetention: "7d", DailyRetention: "365d"}, zap.NewNop())
+	r.now = func() time.Time { return now }
+	defer r.Close(context.Background())
+	if err := r.Rollup(context.Background()); err != nil {
+		t.Fatalf("Rollup() error = %v", err)
+	}
+
+	var rows []model.SysApiTokenUsage
+	if err := gormDB.Order("period_start").Find(&rows).Error; err != nil {
+		t.Fatalf("load usage error = %v", err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("usage rows = %+v, want one daily and one hourly row", rows)
+	}
+	daily := rows[0]
+	if daily.Granularity != model.UsageGranularityDay || !daily.PeriodStart.Equal(old) || daily.Calls != 5 || daily.Errors != 1 {
+		t.Fatalf("daily row = %+v, want 5 calls on %v", daily, old)
+	}
+	// 分位数按合并后的分布重新计算
+	if daily.P95Ms <= 750 || daily.P95Ms > 1000 {
+		t.Fatalf("daily p95 = %v, want within the 750-1000ms bucket", daily.P95Ms)
+	}
+	if rows[1].Granularity != model.UsageGranularityHour {
+		t.Fatalf("recent row = %+v, want kept as hourly", rows[1])
+	}
+}
@@ -0,0 +1,36 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var ErrNotFound = errors.New("cache: key not found")
+
+// Store 带过期时间的键值存储，用于验证码、计数器等短期数据
+// 启用 Redis 时多实例共享；否则退化为进程内存储
+type Store interface {
+	// Get 读取键值，不存在或已过期时返回 ErrNotFound
+	Get(ctx context.Context, key string) (string, error)
+	// GetDel 读取并删除 (一次性数据，如验证码)，不存在时返回 ErrNotFound
+	GetDel(ctx context.Context, key string) (string, error)
+	Set(ctx context.Context, key, value string, ttl time.Duration) error
+	// SetNX 键不存在时才写入，返回是否写入成功
+	SetNX(ctx context.Context, key, value string, ttl time.Duration) (bool, error)
+	// Incr 计数加一并返回新值；键新建时设置过期时间，已存在时不续期 (固定窗口)
+	Incr(ctx context.Context, key string, ttl time.Duration) (int64, error)
+	// TTL 剩余有效期，不存在时返回 ErrNotFound
+	TTL(ctx context.Context, key string) (time.Duration, error)
+	Del(ctx context.Context, keys ...string) error
+}
+
+// New 启用 Redis 时使用 Redis，否则使用内存存储
+func New(rdb redis.UniversalClient) Store {
+	if rdb != nil {
+		return NewRedisStore(rdb)
+	}
+	return NewMemoryStore()
+}
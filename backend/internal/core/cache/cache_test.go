@@ -0,0 +1,75 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestStores(t *testing.T) {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = rdb.Close() })
+
+	mem := NewMemoryStore()
+	t.Cleanup(func() { _ = mem.Close(context.Background()) })
+
+	for name, store := range map[string]Store{"memory": mem, "redis": NewRedisStore(rdb)} {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+
+			if _, err := store.Get(ctx, "missing"); !errors.Is(err, ErrNotFound) {
+				t.Fatalf("Get() missing error = %v, want %v", err, ErrNotFound)
+			}
+
+			if err := store.Set(ctx, "k", "v", time.Minute); err != nil {
+				t.Fatalf("Set() error = %v", err)
+			}
+			if ok, err := store.SetNX(ctx, "k", "other", time.Minute); err != nil || ok {
+				t.Fatalf("SetNX() existing = %v, %v, want false", ok, err)
+			}
+			if v, err := store.GetDel(ctx, "k"); err != nil || v != "v" {
+				t.Fatalf("GetDel() = %q, %v, want v", v, err)
+			}
+			if _, err := store.GetDel(ctx, "k"); !errors.Is(err, ErrNotFound) {
+				t.Fatalf("GetDel() second error = %v, want %v", err, ErrNotFound)
+			}
+
+			for want := int64(1); want <= 3; want++ {
+				n, err := store.Incr(ctx, "counter", time.Minute)
+				if err != nil || n != want {
+					t.Fatalf("Incr() = %d, %v, want %d", n, err, want)
+				}
+			}
+			ttl, err := store.TTL(ctx, "counter")
+			if err != nil || ttl <= 0 || ttl > time.Minute {
+				t.Fatalf("TTL() = %v, %v, want within a minute", ttl, err)
+			}
+
+			if err := store.Del(ctx, "counter"); err != nil {
+				t.Fatalf("Del() error = %v", err)
+			}
+			if _, err := store.TTL(ctx, "counter"); !errors.Is(err, ErrNotFound) {
+				t.Fatalf("TTL() after Del error = %v, want %v", err, ErrNotFound)
+			}
+		})
+	}
+}
+
+func TestMemoryStoreExpires(t *testing.T) {
+	store := NewMemoryStore()
+	t.Cleanup(func() { _ = store.Close(context.Background()) })
+	ctx := context.Background()
+
+	if err := store.Set(ctx, "k", "v", 10*time.Millisecond); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if _, err := store.Get(ctx, "k"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get() expired error = %v, want %v", err, ErrNotFound)
+	}
+}
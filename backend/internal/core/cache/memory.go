@@ -0,0 +1,164 @@
+package cache
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const memorySweepInterval = time.Minute
+
+type memoryItem struct {
+	value     string
+	expiresAt time.Time // 零值表示永不过期
+}
+
+func (i memoryItem) expired(now time.Time) bool {
+	return !i.expiresAt.IsZero() && !now.Before(i.expiresAt)
+}
+
+// MemoryStore 进程内实现，过期键在访问时惰性删除，并由后台任务定期清理
+// 仅适用于单实例部署
+type MemoryStore struct {
+	mu    sync.Mutex
+	items map[string]memoryItem
+
+	stop chan struct{}
+	once sync.Once
+}
+
+func NewMemoryStore() *MemoryStore {
+	s := &MemoryStore{
+		items: make(map[string]memoryItem),
+		stop:  make(chan struct{}),
+	}
+	go s.sweeper()
+	return s
+}
+
+// Close 停止后台清理任务
+func (s *MemoryStore) Close(ctx context.Context) error {
+	s.once.Do(func() { close(s.stop) })
+	return nil
+}
+
+func (s *MemoryStore) Get(ctx context.Context, key string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	item, ok := s.load(key, time.Now())
+	if !ok {
+		return "", ErrNotFound
+	}
+	return item.value, nil
+}
+
+func (s *MemoryStore) GetDel(ctx context.Context, key string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	item, ok := s.load(key, time.Now())
+	if !ok {
+		return "", ErrNotFound
+	}
+	delete(s.items, key)
+	return item.value, nil
+}
+
+func (s *MemoryStore) Set(ctx context.Context, key, value string, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.items[key] = memoryItem{value: value, expiresAt: expiresAt(time.Now(), ttl)}
+	return nil
+}
+
+func (s *MemoryStore) SetNX(ctx context.Context, key, value string, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	if _, ok := s.load(key, now); ok {
+		return false, nil
+	}
+	s.items[key] = memoryItem{value: value, expiresAt: expiresAt(now, ttl)}
+	return true, nil
+}
+
+func (s *MemoryStore) Incr(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	item, ok := s.load(key, now)
+	if !ok {
+		item = memoryItem{value: "0", expiresAt: expiresAt(now, ttl)}
+	}
+	n, err := strconv.ParseInt(item.value, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	n++
+	item.value = strconv.FormatInt(n, 10)
+	s.items[key] = item
+	return n, nil
+}
+
+func (s *MemoryStore) TTL(ctx context.Context, key string) (time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	item, ok := s.load(key, now)
+	if !ok {
+		return 0, ErrNotFound
+	}
+	if item.expiresAt.IsZero() {
+		return -1, nil
+	}
+	return item.expiresAt.Sub(now), nil
+}
+
+func (s *MemoryStore) Del(ctx context.Context, keys ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, key := range keys {
+		delete(s.items, key)
+	}
+	return nil
+}
+
+// load 读取未过期的键，已过期的顺便删除 (调用方需持有锁)
+func (s *MemoryStore) load(key string, now time.Time) (memoryItem, bool) {
+	item, ok := s.items[key]
+	if !ok {
+		return memoryItem{}, false
+	}
+	if item.expired(now) {
+		delete(s.items, key)
+		return memoryItem{}, false
+	}
+	return item, true
+}
+
+func (s *MemoryStore) sweeper() {
+	ticker := time.NewTicker(memorySweepInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			now := time.Now()
+			s.mu.Lock()
+			for key, item := range s.items {
+				if item.expired(now) {
+					delete(s.items, key)
+				}
+			}
+			s.mu.Unlock()
+		case <-s.stop:
+			return
+		}
+	}
+}
+
+func expiresAt(now time.Time, ttl time.Duration) time.Time {
+	if ttl <= 0 {
+		return time.Time{}
+	}
+	return now.Add(ttl)
+}
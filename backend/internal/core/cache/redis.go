@@ -0,0 +1,82 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// incrScript 计数加一，首次创建时设置过期时间 (兼容不支持 EXPIRE NX 的 Redis 版本)
+var incrScript = redis.NewScript(`
+local n = redis.call('INCR', KEYS[1])
+if n == 1 and tonumber(ARGV[1]) > 0 then
+	redis.call('PEXPIRE', KEYS[1], ARGV[1])
+end
+return n
+`)
+
+// RedisStore 基于 Redis 的实现
+type RedisStore struct {
+	rdb redis.UniversalClient
+}
+
+func NewRedisStore(rdb redis.UniversalClient) *RedisStore {
+	return &RedisStore{rdb: rdb}
+}
+
+func (s *RedisStore) Get(ctx context.Context, key string) (string, error) {
+	val, err := s.rdb.Get(ctx, key).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", ErrNotFound
+	}
+	return val, err
+}
+
+func (s *RedisStore) GetDel(ctx context.Context, key string) (string, error) {
+	// 使用事务代替 GETDEL，兼容 Redis 6.2 以下版本
+	var get *redis.StringCmd
+	_, err := s.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		get = pipe.Get(ctx, key)
+		pipe.Del(ctx, key)
+		return nil
+	})
+	if errors.Is(err, redis.Nil) {
+		return "", ErrNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	return get.Val(), nil
+}
+
+func (s *RedisStore) Set(ctx context.Context, key, value string, ttl time.Duration) error {
+	return s.rdb.Set(ctx, key, value, ttl).Err()
+}
+
+func (s *RedisStore) SetNX(ctx context.Context, key, value string, ttl time.Duration) (bool, error) {
+	return s.rdb.SetNX(ctx, key, value, ttl).Result()
+}
+
+func (s *RedisStore) Incr(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	return incrScript.Run(ctx, s.rdb, []string{key}, ttl.Milliseconds()).Int64()
+}
+
+func (s *RedisStore) TTL(ctx context.Context, key string) (time.Duration, error) {
+	ttl, err := s.rdb.TTL(ctx, key).Result()
+	if err != nil {
+		return 0, err
+	}
+	if ttl == -2 {
+		return 0, ErrNotFound
+	}
+	return ttl, nil
+}
+
+func (s *RedisStore) Del(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	return s.rdb.Del(ctx, keys...).Err()
+}
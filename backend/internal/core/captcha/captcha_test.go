@@ -0,0 +1,66 @@
+package captcha
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/CIPFZ/gowebframe/internal/core/cache"
+	"github.com/CIPFZ/gowebframe/internal/core/config"
+)
+
+func TestCaptchaGenerateAndVerifyOnce(t *testing.T) {
+	store := cache.NewMemoryStore()
+	t.Cleanup(func() { _ = store.Close(context.Background()) })
+	c := New(store, config.Captcha{KeyLong: 4, ImgWidth: 120, ImgHeight: 40})
+	ctx := context.Background()
+
+	id, pic, err := c.Generate(ctx)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(pic, "data:image/png;base64,"))
+	if err != nil {
+		t.Fatalf("decode picture error = %v", err)
+	}
+	img, err := png.Decode(bytes.NewReader(raw))
+	if err != nil {
+		t.Fatalf("png.Decode() error = %v", err)
+	}
+	if b := img.Bounds(); b.Dx() != 120 || b.Dy() != 40 {
+		t.Fatalf("image size = %v, want 120x40", b)
+	}
+
+	answer, err := store.Get(ctx, keyPrefix+id)
+	if err != nil || len(answer) != 4 {
+		t.Fatalf("stored answer = %q, %v", answer, err)
+	}
+	if !c.Verify(ctx, id, answer) {
+		t.Fatal("Verify() = false, want true for correct answer")
+	}
+	if c.Verify(ctx, id, answer) {
+		t.Fatal("Verify() = true on second use, want captcha to be single-use")
+	}
+}
+
+func TestCaptchaWrongAnswerInvalidates(t *testing.T) {
+	store := cache.NewMemoryStore()
+	t.Cleanup(func() { _ = store.Close(context.Background()) })
+	c := New(store, config.Captcha{})
+	ctx := context.Background()
+
+	id, _, err := c.Generate(ctx)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	answer, _ := store.Get(ctx, keyPrefix+id)
+	if c.Verify(ctx, id, "x") {
+		t.Fatal("Verify() = true for wrong answer")
+	}
+	if c.Verify(ctx, id, answer) {
+		t.Fatal("Verify() = true after a failed attempt, want captcha invalidated")
+	}
+}
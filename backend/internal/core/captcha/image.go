@@ -0,0 +1,129 @@
+package captcha
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"math/rand/v2"
+)
+
+// digitFont 5x7 点阵数字字形，每行低 5 位有效
+var digitFont = [10][7]uint8{
+	{0x0e, 0x11, 0x13, 0x15, 0x19, 0x11, 0x0e}, // 0
+	{0x04, 0x0c, 0x04, 0x04, 0x04, 0x04, 0x0e}, // 1
+	{0x0e, 0x11, 0x01, 0x02, 0x04, 0x08, 0x1f}, // 2
+	{0x1f, 0x02, 0x04, 0x02, 0x01, 0x11, 0x0e}, // 3
+	{0x02, 0x06, 0x0a, 0x12, 0x1f, 0x02, 0x02}, // 4
+	{0x1f, 0x10, 0x1e, 0x01, 0x01, 0x11, 0x0e}, // 5
+	{0x06, 0x08, 0x10, 0x1e, 0x11, 0x11, 0x0e}, // 6
+	{0x1f, 0x01, 0x02, 0x04, 0x08, 0x08, 0x08}, // 7
+	{0x0e, 0x11, 0x11, 0x0e, 0x11, 0x11, 0x0e}, // 8
+	{0x0e, 0x11, 0x11, 0x0f, 0x01, 0x02, 0x0c}, // 9
+}
+
+const (
+	glyphCols = 5
+	glyphRows = 7
+)
+
+// drawDigits 把数字串渲染成带干扰的 PNG
+// 每个数字随机颜色、随机上下偏移，并叠加干扰线和噪点
+func drawDigits(digits string, width, height int) ([]byte, error) {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	bg := color.NRGBA{R: uint8(230 + rand.IntN(26)), G: uint8(230 + rand.IntN(26)), B: uint8(230 + rand.IntN(26)), A: 0xff}
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.SetNRGBA(x, y, bg)
+		}
+	}
+
+	n := len(digits)
+	cellW := width / (n + 1)
+	scale := min(cellW/(glyphCols+1), height*2/3/glyphRows)
+	if scale < 1 {
+		scale = 1
+	}
+	glyphH := glyphRows * scale
+	for i := 0; i < n; i++ {
+		d := digits[i] - '0'
+		if d > 9 {
+			continue
+		}
+		fg := randomDark()
+		x0 := cellW/2 + i*cellW + rand.IntN(max(cellW-glyphCols*scale, 1))
+		y0 := (height-glyphH)/2 + rand.IntN(max(height/5, 1)) - height/10
+		for row := 0; row < glyphRows; row++ {
+			bits := digitFont[d][row]
+			for col := 0; col < glyphCols; col++ {
+				if bits&(1<<(glyphCols-1-col)) == 0 {
+					continue
+				}
+				// 每行加一点水平错位，模拟倾斜
+				shift := (glyphRows - row) * scale / 4
+				fillRect(img, x0+col*scale+shift, y0+row*scale, scale, scale, fg)
+			}
+		}
+	}
+
+	for i := 0; i < 4; i++ {
+		drawLine(img, rand.IntN(width), rand.IntN(height), rand.IntN(width), rand.IntN(height), randomDark())
+	}
+	for i := 0; i < width*height/30; i++ {
+		img.SetNRGBA(rand.IntN(width), rand.IntN(height), randomDark())
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func randomDark() color.NRGBA {
+	return color.NRGBA{R: uint8(rand.IntN(150)), G: uint8(rand.IntN(150)), B: uint8(rand.IntN(150)), A: 0xff}
+}
+
+func fillRect(img *image.NRGBA, x, y, w, h int, c color.NRGBA) {
+	r := image.Rect(x, y, x+w, y+h).Intersect(img.Bounds())
+	for py := r.Min.Y; py < r.Max.Y; py++ {
+		for px := r.Min.X; px < r.Max.X; px++ {
+			img.SetNRGBA(px, py, c)
+		}
+	}
+}
+
+// drawLine Bresenham 画线
+func drawLine(img *image.NRGBA, x0, y0, x1, y1 int, c color.NRGBA) {
+	dx, dy := abs(x1-x0), -abs(y1-y0)
+	sx, sy := 1, 1
+	if x0 > x1 {
+		sx = -1
+	}
+	if y0 > y1 {
+		sy = -1
+	}
+	e := dx + dy
+	for {
+		img.SetNRGBA(x0, y0, c)
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+		e2 := 2 * e
+		if e2 >= dy {
+			e += dy
+			x0 += sx
+		}
+		if e2 <= dx {
+			e += dx
+			y0 += sy
+		}
+	}
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
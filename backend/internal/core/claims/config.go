@@ -9,6 +9,5 @@ import (
 // CustomClaims 自定义荷载
 type CustomClaims struct {
 	dto.BaseClaims
-	BufferTime int64 `json:"bufferTime"` // 缓冲时间 (秒)
 	jwt.RegisteredClaims
 }
@@ -0,0 +1,59 @@
+package claims
+
+import "context"
+
+type PrincipalKind string
+
+const (
+	PrincipalUser        PrincipalKind = "user"
+	PrincipalAPIToken    PrincipalKind = "api_token"
+	PrincipalOAuthClient PrincipalKind = "oauth_client"
+	PrincipalAnonymous   PrincipalKind = "anonymous"
+)
+
+// Scopes API Token 与 OAuth2 客户端的授权范围，path 为路由模板
+type Scopes interface {
+	Allows(method, path string) bool
+}
+
+// Principal 认证链识别出的调用方，写入 gin.Context 与请求的 context.Context
+// 用户登录与 API Token 调用统一为同一结构，Casbin 校验与业务代码不必关心凭证类型
+type Principal struct {
+	Kind PrincipalKind
+	// UserID / AuthorityID 仅用户有效
+	UserID      uint
+	AuthorityID uint
+	// TokenID 仅 API Token 有效
+	TokenID uint
+	// ClientID 仅 OAuth2 客户端有效
+	ClientID string
+	// Claims JWT 荷载，仅用户有效
+	Claims *CustomClaims
+	// Scopes 可访问的接口，仅 API Token 与 OAuth2 客户端有效
+	Scopes Scopes
+}
+
+func (p *Principal) IsUser() bool {
+	return p != nil && p.Kind == PrincipalUser
+}
+
+func (p *Principal) IsAPIToken() bool {
+	return p != nil && p.Kind == PrincipalAPIToken
+}
+
+func (p *Principal) IsOAuthClient() bool {
+	return p != nil && p.Kind == PrincipalOAuthClient
+}
+
+type principalKey struct{}
+
+// WithPrincipal 把调用方写入 context，供 service 层读取
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// PrincipalFromContext 读取认证链写入的调用方，未经过认证链时返回 false
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(*Principal)
+	return p, ok && p != nil
+}
@@ -0,0 +1,8 @@
+package config
+
+// ApiTokenRotation API Token 轮换与到期提醒，时长格式同 jwt.expires_time (如 24h、7d)
+// 轮换后旧密钥在 grace 内仍可使用；令牌到期前 expiry_notice 内向创建人发送站内通知
+type ApiTokenRotation struct {
+	Grace        string `mapstructure:"grace" json:"grace" yaml:"grace"`                         // 默认 24h，最长 720h，轮换时可单独指定
+	ExpiryNotice string `mapstructure:"expiry_notice" json:"expiry_notice" yaml:"expiry_notice"` // 为空或 0 代表不发送到期提醒
+}
@@ -0,0 +1,10 @@
+package config
+
+// ApiTokenSign API Token 签名请求的校验参数
+// 请求时间戳与服务器时间相差超过 clock_skew 时拒绝；nonce 在该窗口内只能使用一次
+type ApiTokenSign struct {
+	// Secret 派生各令牌签名密钥的服务端密钥，为空时不接受签名请求；修改后已下发的签名密钥全部失效
+	Secret        string `mapstructure:"secret" json:"secret" yaml:"secret"`
+	ClockSkew     string `mapstructure:"clock_skew" json:"clock_skew" yaml:"clock_skew"`                   // 如 5m，默认 5m
+	MaxBodySizeMB int    `mapstructure:"max_body_size_mb" json:"max_body_size_mb" yaml:"max_body_size_mb"` // 签名请求体上限，超出返回 413，默认 1
+}
@@ -0,0 +1,8 @@
+package config
+
+// ApiTokenUsage API Token 用量统计的保留策略，时长格式同 jwt.expires_time (如 7d、36h)
+// 小时数据超过 hourly_retention 后按天合并为天数据，天数据超过 daily_retention 后删除
+type ApiTokenUsage struct {
+	HourlyRetention string `mapstructure:"hourly_retention" json:"hourly_retention" yaml:"hourly_retention"` // 默认 7d
+	DailyRetention  string `mapstructure:"daily_retention" json:"daily_retention" yaml:"daily_retention"`    // 为空或 0 代表永久保留
+}
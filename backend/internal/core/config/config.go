@@ -6,20 +6,31 @@ import (
 
 // Config 全局配置
 type Config struct {
-	System     System          `mapstructure:"system" json:"system" yaml:"system"`
-	Logger     Logger          `mapstructure:"logger" json:"logger" yaml:"logger"`
-	I18n       I18n            `mapstructure:"i18n" json:"i18n" yaml:"i18n"`
-	JWT        JWT             `mapstructure:"jwt" json:"jwt" yaml:"jwt"`
-	Database   Database        `mapstructure:"database" json:"database" yaml:"database"`
-	Mysql      MySQL           `mapstructure:"mysql" json:"mysql" yaml:"mysql"`
-	Mongo      Mongo           `mapstructure:"mongo" json:"mongo" yaml:"mongo"`
-	Redis      Redis           `mapstructure:"redis" json:"redis" yaml:"redis"`
-	File       FileConfig      `mapstructure:"file" json:"file" yaml:"file"`
-	Email      Email           `mapstructure:"email" json:"email" yaml:"email"`
-	Captcha    Captcha         `mapstructure:"captcha" json:"captcha" yaml:"captcha"`
-	Cors       CORS            `mapstructure:"cors" json:"cors" yaml:"cors"`
-	Observable Observability   `mapstructure:"observable" json:"observable" yaml:"observable"`
-	RateLimit  RateLimitConfig `mapstructure:"rate_limit" json:"rate_limit" yaml:"rate_limit"`
+	System           System           `mapstructure:"system" json:"system" yaml:"system"`
+	Logger           Logger           `mapstructure:"logger" json:"logger" yaml:"logger"`
+	I18n             I18n             `mapstructure:"i18n" json:"i18n" yaml:"i18n"`
+	JWT              JWT              `mapstructure:"jwt" json:"jwt" yaml:"jwt"`
+	Database         Database         `mapstructure:"database" json:"database" yaml:"database"`
+	Mysql            MySQL            `mapstructure:"mysql" json:"mysql" yaml:"mysql"`
+	Mongo            Mongo            `mapstructure:"mongo" json:"mongo" yaml:"mongo"`
+	Redis            Redis            `mapstructure:"redis" json:"redis" yaml:"redis"`
+	File             FileConfig       `mapstructure:"file" json:"file" yaml:"file"`
+	Email            Email            `mapstructure:"email" json:"email" yaml:"email"`
+	Captcha          Captcha          `mapstructure:"captcha" json:"captcha" yaml:"captcha"`
+	OIDC             OIDC             `mapstructure:"oidc" json:"oidc" yaml:"oidc"`
+	LDAP             LDAP             `mapstructure:"ldap" json:"ldap" yaml:"ldap"`
+	Password         PasswordPolicy   `mapstructure:"password_policy" json:"password_policy" yaml:"password_policy"`
+	PasswordReset    PasswordReset    `mapstructure:"password_reset" json:"password_reset" yaml:"password_reset"`
+	Registration     Registration     `mapstructure:"registration" json:"registration" yaml:"registration"`
+	GeoIP            GeoIP            `mapstructure:"geoip" json:"geoip" yaml:"geoip"`
+	Passkey          Passkey          `mapstructure:"passkey" json:"passkey" yaml:"passkey"`
+	ApiTokenUsage    ApiTokenUsage    `mapstructure:"api_token_usage" json:"api_token_usage" yaml:"api_token_usage"`
+	ApiTokenSign     ApiTokenSign     `mapstructure:"api_token_sign" json:"api_token_sign" yaml:"api_token_sign"`
+	ApiTokenRotation ApiTokenRotation `mapstructure:"api_token_rotation" json:"api_token_rotation" yaml:"api_token_rotation"`
+	OAuth            OAuth            `mapstructure:"oauth" json:"oauth" yaml:"oauth"`
+	Cors             CORS             `mapstructure:"cors" json:"cors" yaml:"cors"`
+	Observable       Observability    `mapstructure:"observable" json:"observable" yaml:"observable"`
+	RateLimit        RateLimitConfig  `mapstructure:"rate_limit" json:"rate_limit" yaml:"rate_limit"`
 }
 
 type Database struct {
@@ -39,14 +50,29 @@ type System struct {
 	DbType        string `mapstructure:"db_type" json:"db_type" yaml:"db_type"`                      // 数据库类型:mysql(默认)|sqlite|postgresql
 	UseRedis      bool   `mapstructure:"use_redis" json:"use_redis" yaml:"use_redis"`                // 使用redis
 	UseMongo      bool   `mapstructure:"use_mongo" json:"use_mongo" yaml:"use_mongo"`                // 使用mongo
-	UseMultipoint bool   `mapstructure:"use_multipoint" json:"use_multipoint" yaml:"use_multipoint"` // 多点登录拦截
+	UseMultipoint bool   `mapstructure:"use_multipoint" json:"use_multipoint" yaml:"use_multipoint"` // 允许多点登录；关闭时新登录会踢掉旧会话
+	// 可信反向代理 (IP 或 CIDR)，只采信来自这些地址的 X-Forwarded-For / X-Real-IP；留空时一律取连接地址
+	TrustedProxies []string `mapstructure:"trusted_proxies" json:"trusted_proxies" yaml:"trusted_proxies"`
 }
 
 type JWT struct {
-	SigningKey  string `mapstructure:"signing_key" json:"signing_key" yaml:"signing_key"`    // jwt签名
-	ExpiresTime string `mapstructure:"expires_time" json:"expires_time" yaml:"expires_time"` // 过期时间
-	BufferTime  string `mapstructure:"buffer_time" json:"buffer_time" yaml:"buffer_time"`    // 缓冲时间
-	Issuer      string `mapstructure:"issuer" json:"issuer" yaml:"issuer"`                   // 签发者
+	SigningKey          string   `mapstructure:"signing_key" json:"signing_key" yaml:"signing_key"`                                  // HS256 签名密钥 (未配置 active_kid 时使用)
+	SigningKeyRetiredAt string   `mapstructure:"signing_key_retired_at" json:"signing_key_retired_at" yaml:"signing_key_retired_at"` // 切换到 active_kid 的时间 (RFC3339)，之后 signing_key 仅在令牌有效期内继续校验；配置 active_kid 且保留 signing_key 时必填
+	ExpiresTime         string   `mapstructure:"expires_time" json:"expires_time" yaml:"expires_time"`                               // access token 过期时间 (建议分钟级)
+	RefreshExpiresTime  string   `mapstructure:"refresh_expires_time" json:"refresh_expires_time" yaml:"refresh_expires_time"`       // refresh token 过期时间
+	ImpersonationTime   string   `mapstructure:"impersonation_time" json:"impersonation_time" yaml:"impersonation_time"`             // 模拟登录令牌有效期 (不可续期)
+	Issuer              string   `mapstructure:"issuer" json:"issuer" yaml:"issuer"`                                                 // 签发者
+	ActiveKid           string   `mapstructure:"active_kid" json:"active_kid" yaml:"active_kid"`                                     // 当前签发使用的非对称密钥 kid
+	Keys                []JWTKey `mapstructure:"keys" json:"keys" yaml:"keys"`                                                       // 非对称密钥列表 (含已退役密钥)
+}
+
+// JWTKey 非对称签名密钥
+type JWTKey struct {
+	Kid            string `mapstructure:"kid" json:"kid" yaml:"kid"`
+	Algorithm      string `mapstructure:"algorithm" json:"algorithm" yaml:"algorithm"`                      // RS256 | EdDSA
+	PrivateKeyFile string `mapstructure:"private_key_file" json:"private_key_file" yaml:"private_key_file"` // PEM 私钥，active key 必填
+	PublicKeyFile  string `mapstructure:"public_key_file" json:"public_key_file" yaml:"public_key_file"`    // PEM 公钥，未配置私钥时用于校验
+	RetiredAt      string `mapstructure:"retired_at" json:"retired_at" yaml:"retired_at"`                   // 退役时间 (RFC3339)，之后仅在其签发的令牌有效期内继续校验
 }
 
 type Logger struct {
@@ -124,6 +150,8 @@ type Captcha struct {
 	ImgHeight          int `mapstructure:"img_height" json:"img_height" yaml:"img_height"`                               // 验证码高度
 	OpenCaptcha        int `mapstructure:"open_captcha" json:"open_captcha" yaml:"open_captcha"`                         // 防爆破验证码开启此数，0代表每次登录都需要验证码，其他数字代表错误密码次数，如3代表错误三次后出现验证码
 	OpenCaptchaTimeOut int `mapstructure:"open_captcha_timeout" json:"open_captcha_timeout" yaml:"open_captcha_timeout"` // 防爆破验证码超时时间，单位：s(秒)
+	LockThreshold      int `mapstructure:"lock_threshold" json:"lock_threshold" yaml:"lock_threshold"`                   // 同一账号连续错误密码达到此数后锁定，0 代表不锁定
+	LockDuration       int `mapstructure:"lock_duration" json:"lock_duration" yaml:"lock_duration"`                      // 账号锁定时长，单位：s(秒)
 }
 
 type CORS struct {
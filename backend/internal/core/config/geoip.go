@@ -0,0 +1,14 @@
+package config
+
+// GeoIP 离线 IP 归属地 (MaxMind .mmdb 格式，如 GeoLite2-City / GeoLite2-Country / GeoLite2-ASN)
+// 两个路径都为空时关闭；文件被替换或改写后自动重新加载
+type GeoIP struct {
+	DBPath    string `mapstructure:"db_path" json:"db_path" yaml:"db_path"`             // 国家与地区库
+	ASNDBPath string `mapstructure:"asn_db_path" json:"asn_db_path" yaml:"asn_db_path"` // ASN 库，可选；合并库可与 db_path 相同
+	Language  string `mapstructure:"language" json:"language" yaml:"language"`          // 地区名称语言，如 zh-CN，默认 en
+}
+
+// Enabled 是否配置了 GeoIP 库
+func (g GeoIP) Enabled() bool {
+	return g.DBPath != "" || g.ASNDBPath != ""
+}
@@ -0,0 +1,29 @@
+package config
+
+// LDAP 目录认证 (LDAP / Active Directory)
+// 过滤器中 {username} 替换为登录名，{dn} 替换为用户 DN (均已转义)
+type LDAP struct {
+	Enabled            bool   `mapstructure:"enabled" json:"enabled" yaml:"enabled"`
+	URL                string `mapstructure:"url" json:"url" yaml:"url"`                                                    // ldap://host:389 或 ldaps://host:636
+	StartTLS           bool   `mapstructure:"start_tls" json:"start_tls" yaml:"start_tls"`                                  // ldap:// 连接后升级 TLS
+	InsecureSkipVerify bool   `mapstructure:"insecure_skip_verify" json:"insecure_skip_verify" yaml:"insecure_skip_verify"` // 跳过证书校验 (仅测试环境)
+	BindDN             string `mapstructure:"bind_dn" json:"bind_dn" yaml:"bind_dn"`                                        // 查询用户与组使用的服务账号，为空时匿名查询
+	BindPassword       string `mapstructure:"bind_password" json:"bind_password" yaml:"bind_password"`
+	Timeout            int    `mapstructure:"timeout" json:"timeout" yaml:"timeout"` // 连接与查询超时，单位：s(秒)，默认 5
+
+	BaseDN          string `mapstructure:"base_dn" json:"base_dn" yaml:"base_dn"`                               // 用户搜索起点
+	UserFilter      string `mapstructure:"user_filter" json:"user_filter" yaml:"user_filter"`                   // 默认 (uid={username})，AD 使用 (sAMAccountName={username})
+	UsernameAttr    string `mapstructure:"username_attr" json:"username_attr" yaml:"username_attr"`             // 默认 uid
+	EmailAttr       string `mapstructure:"email_attr" json:"email_attr" yaml:"email_attr"`                      // 默认 mail
+	DisplayNameAttr string `mapstructure:"display_name_attr" json:"display_name_attr" yaml:"display_name_attr"` // 默认 cn
+
+	GroupBaseDN   string `mapstructure:"group_base_dn" json:"group_base_dn" yaml:"group_base_dn"`       // 组搜索起点，默认同 base_dn
+	GroupFilter   string `mapstructure:"group_filter" json:"group_filter" yaml:"group_filter"`          // 如 (member={dn})；为空时读取用户的 member_of_attr 属性
+	GroupNameAttr string `mapstructure:"group_name_attr" json:"group_name_attr" yaml:"group_name_attr"` // 默认 cn
+	MemberOfAttr  string `mapstructure:"member_of_attr" json:"member_of_attr" yaml:"member_of_attr"`    // 默认 memberOf
+
+	// SyncInterval 定时按目录中的组同步已绑定用户的角色，单位：s(秒)，0 代表不同步
+	SyncInterval int `mapstructure:"sync_interval" json:"sync_interval" yaml:"sync_interval"`
+
+	Provisioning `mapstructure:",squash" yaml:",inline"`
+}
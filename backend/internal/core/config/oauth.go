@@ -0,0 +1,7 @@
+package config
+
+// OAuth OAuth2 客户端凭证模式 (client_credentials) 的参数
+// 客户端未单独设置有效期时使用 access_token_ttl，时长格式同 jwt.expires_time (如 1h、30m)
+type OAuth struct {
+	AccessTokenTTL string `mapstructure:"access_token_ttl" json:"access_token_ttl" yaml:"access_token_ttl"` // 默认 1h
+}
@@ -0,0 +1,24 @@
+package config
+
+// OIDC 单点登录配置
+type OIDC struct {
+	// FrontendRedirect 回调处理完成后浏览器跳转的前端页面，附带 ticket 或 ssoError 参数，默认 /user/login
+	FrontendRedirect string         `mapstructure:"frontend_redirect" json:"frontend_redirect" yaml:"frontend_redirect"`
+	Providers        []OIDCProvider `mapstructure:"providers" json:"providers" yaml:"providers"`
+}
+
+// OIDCProvider 一个 OpenID Connect 身份提供方 (IdP)
+type OIDCProvider struct {
+	Name         string   `mapstructure:"name" json:"name" yaml:"name"`                         // 唯一标识，出现在回调地址中
+	DisplayName  string   `mapstructure:"display_name" json:"display_name" yaml:"display_name"` // 登录页按钮文案
+	Issuer       string   `mapstructure:"issuer" json:"issuer" yaml:"issuer"`                   // 用于发现 (.well-known/openid-configuration)
+	ClientID     string   `mapstructure:"client_id" json:"client_id" yaml:"client_id"`
+	ClientSecret string   `mapstructure:"client_secret" json:"client_secret" yaml:"client_secret"`
+	RedirectURL  string   `mapstructure:"redirect_url" json:"redirect_url" yaml:"redirect_url"` // 在 IdP 注册的回调地址，指向 /user/oidc/{name}/callback
+	Scopes       []string `mapstructure:"scopes" json:"scopes" yaml:"scopes"`                   // 默认 openid profile email
+
+	UsernameClaim string `mapstructure:"username_claim" json:"username_claim" yaml:"username_claim"` // 默认 preferred_username，缺失时使用 email
+	GroupsClaim   string `mapstructure:"groups_claim" json:"groups_claim" yaml:"groups_claim"`       // 默认 groups
+
+	Provisioning `mapstructure:",squash" yaml:",inline"`
+}
@@ -0,0 +1,14 @@
+package config
+
+// Passkey WebAuthn 通行密钥登录；rp_id 或 rp_origins 为空时关闭
+type Passkey struct {
+	RPID          string   `mapstructure:"rp_id" json:"rp_id" yaml:"rp_id"`                               // 依赖方 ID，即前端站点域名 (不含协议与端口)，如 admin.example.com
+	RPDisplayName string   `mapstructure:"rp_display_name" json:"rp_display_name" yaml:"rp_display_name"` // 浏览器弹窗中展示的站点名称，默认 system.name
+	RPOrigins     []string `mapstructure:"rp_origins" json:"rp_origins" yaml:"rp_origins"`                // 允许发起验证的前端来源，如 https://admin.example.com
+	Timeout       int      `mapstructure:"timeout" json:"timeout" yaml:"timeout"`                         // 单次注册或登录的有效期，单位：s(秒)，默认 300
+}
+
+// Enabled 是否配置了通行密钥
+func (p Passkey) Enabled() bool {
+	return p.RPID != "" && len(p.RPOrigins) > 0
+}
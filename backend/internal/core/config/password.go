@@ -0,0 +1,28 @@
+package config
+
+// PasswordPolicy 密码策略，在注册、新增用户、重置与修改密码时校验
+type PasswordPolicy struct {
+	MinLength     int  `mapstructure:"min_length" json:"min_length" yaml:"min_length"`             // 最小长度，默认 8
+	MaxLength     int  `mapstructure:"max_length" json:"max_length" yaml:"max_length"`             // 最大长度，默认 72 (bcrypt 上限)
+	RequireUpper  bool `mapstructure:"require_upper" json:"require_upper" yaml:"require_upper"`    // 必须包含大写字母
+	RequireLower  bool `mapstructure:"require_lower" json:"require_lower" yaml:"require_lower"`    // 必须包含小写字母
+	RequireDigit  bool `mapstructure:"require_digit" json:"require_digit" yaml:"require_digit"`    // 必须包含数字
+	RequireSymbol bool `mapstructure:"require_symbol" json:"require_symbol" yaml:"require_symbol"` // 必须包含特殊字符
+	MinClasses    int  `mapstructure:"min_classes" json:"min_classes" yaml:"min_classes"`          // 至少包含几类字符 (大写/小写/数字/特殊字符)，0 代表不限制
+	CheckUsername bool `mapstructure:"check_username" json:"check_username" yaml:"check_username"` // 禁止包含用户名或与用户名过于相似
+
+	// BlockedPasswords 禁用的弱密码，不区分大小写
+	BlockedPasswords []string `mapstructure:"blocked_passwords" json:"blocked_passwords" yaml:"blocked_passwords"`
+
+	HistoryCount int `mapstructure:"history_count" json:"history_count" yaml:"history_count"` // 禁止复用最近 N 次使用过的密码，0 代表不限制
+	MaxAgeDays   int `mapstructure:"max_age_days" json:"max_age_days" yaml:"max_age_days"`    // 密码有效期，单位：天，过期后下次登录必须修改，0 代表永不过期
+}
+
+// PasswordReset 邮件找回密码，依赖 email 配置
+type PasswordReset struct {
+	Enabled    bool   `mapstructure:"enabled" json:"enabled" yaml:"enabled"`
+	LinkURL    string `mapstructure:"link_url" json:"link_url" yaml:"link_url"`          // 前端重置密码页地址，令牌以 token 查询参数追加，如 http://localhost:8000/user/reset-password
+	TokenTTL   string `mapstructure:"token_ttl" json:"token_ttl" yaml:"token_ttl"`       // 重置链接有效期，如 30m，默认 30m
+	RateLimit  int    `mapstructure:"rate_limit" json:"rate_limit" yaml:"rate_limit"`    // 同一账号或邮箱在窗口内最多请求次数，默认 3
+	RateWindow string `mapstructure:"rate_window" json:"rate_window" yaml:"rate_window"` // 限流窗口，如 1h，默认 1h
+}
@@ -0,0 +1,18 @@
+package config
+
+// Provisioning 外部身份源 (OIDC、LDAP) 的用户开通与角色映射配置
+type Provisioning struct {
+	// AutoCreate 首次登录时自动创建 SysUser (JIT)，关闭时只允许已绑定的账号登录
+	AutoCreate bool `mapstructure:"auto_create" json:"auto_create" yaml:"auto_create"`
+	// GroupMappings 外部组到角色的映射；组命中映射时每次登录都会按组同步用户角色
+	GroupMappings []GroupMapping `mapstructure:"group_mappings" json:"group_mappings" yaml:"group_mappings"`
+	// DefaultAuthorityID 自动开通时没有任何组命中映射所授予的角色，0 表示拒绝登录
+	DefaultAuthorityID uint `mapstructure:"default_authority_id" json:"default_authority_id" yaml:"default_authority_id"`
+}
+
+// GroupMapping 外部组 -> 角色ID
+// 使用列表而非 map，避免 viper 把组名转成小写
+type GroupMapping struct {
+	Group       string `mapstructure:"group" json:"group" yaml:"group"`
+	AuthorityID uint   `mapstructure:"authority_id" json:"authority_id" yaml:"authority_id"`
+}
@@ -0,0 +1,17 @@
+package config
+
+// 自助注册模式
+const (
+	RegistrationClosed   = "closed"   // 关闭自助注册
+	RegistrationOpen     = "open"     // 注册后立即可用 (默认)
+	RegistrationEmail    = "email"    // 点击邮件中的验证链接后可用，依赖 email 配置
+	RegistrationApproval = "approval" // 管理员审核通过后可用
+)
+
+// Registration 自助注册 (/user/register)
+type Registration struct {
+	Mode                 string `mapstructure:"mode" json:"mode" yaml:"mode"`                                                       // closed | open | email | approval，默认 open
+	VerifyURL            string `mapstructure:"verify_url" json:"verify_url" yaml:"verify_url"`                                     // 前端邮箱验证页地址，令牌以 token 参数追加
+	VerifyTTL            string `mapstructure:"verify_ttl" json:"verify_ttl" yaml:"verify_ttl"`                                     // 验证链接有效期，如 24h，默认 24h
+	ApproverAuthorityIDs []uint `mapstructure:"approver_authority_ids" json:"approver_authority_ids" yaml:"approver_authority_ids"` // 接收待审核、审核结果通知的角色，默认 [1]
+}
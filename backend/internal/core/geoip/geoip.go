@@ -0,0 +1,255 @@
+package geoip
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/CIPFZ/gowebframe/internal/core/config"
+	"github.com/fsnotify/fsnotify"
+	"github.com/oschwald/maxminddb-golang"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultLanguage = "en"
+	// 下载工具通常分多次写入文件，等写入平静后再加载
+	reloadDelay = time.Second
+)
+
+// Location IP 归属地，未配置库或查不到时各字段为空
+type Location struct {
+	Country string // ISO 3166-1 二位代码，如 CN
+	Region  string // 一级行政区名称
+	ASN     uint
+	ASOrg   string
+}
+
+// record 同时兼容 City / Country / ASN 库的字段
+type record struct {
+	Country struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"country"`
+	Subdivisions []struct {
+		ISOCode string            `maxminddb:"iso_code"`
+		Names   map[string]string `maxminddb:"names"`
+	} `maxminddb:"subdivisions"`
+	ASN   uint   `maxminddb:"autonomous_system_number"`
+	ASOrg string `maxminddb:"autonomous_system_organization"`
+}
+
+// Resolver 读取本地 .mmdb 文件解析 IP 归属地，监听文件变化自动重新加载
+// 为 nil 时所有查询返回空结果，调用方无需判断是否启用
+type Resolver struct {
+	cfg    config.GeoIP
+	logger *zap.Logger
+
+	mu  sync.RWMutex
+	geo *maxminddb.Reader
+	asn *maxminddb.Reader
+
+	watcher  *fsnotify.Watcher
+	stop     chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+// New 加载配置的库文件；文件不存在或格式错误时返回错误
+func New(cfg config.GeoIP, logger *zap.Logger) (*Resolver, error) {
+	if cfg.Language == "" {
+		cfg.Language = defaultLanguage
+	}
+	r := &Resolver{
+		cfg:    cfg,
+		logger: logger,
+		stop:   make(chan struct{}),
+	}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+	r.watch()
+	return r, nil
+}
+
+// Reload 重新读取库文件；某个文件加载失败时继续使用它的旧版本
+func (r *Resolver) Reload() error {
+	geo, geoErr := open(r.cfg.DBPath)
+	asn, asnErr := open(r.cfg.ASNDBPath)
+
+	r.mu.Lock()
+	// 整个文件读入内存而不是 mmap，文件被原地改写也不影响正在进行的查询
+	if geoErr == nil {
+		r.geo = geo
+	}
+	if asnErr == nil {
+		r.asn = asn
+	}
+	r.mu.Unlock()
+
+	if geoErr != nil {
+		return geoErr
+	}
+	return asnErr
+}
+
+func open(path string) (*maxminddb.Reader, error) {
+	if path == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("geoip read %s: %w", path, err)
+	}
+	reader, err := maxminddb.FromBytes(data)
+	if err != nil {
+		return nil, fmt.Errorf("geoip open %s: %w", path, err)
+	}
+	return reader, nil
+}
+
+// Lookup 查询 IP 归属地；内网地址与无法解析的输入返回空结果
+func (r *Resolver) Lookup(ip string) Location {
+	var loc Location
+	if r == nil {
+		return loc
+	}
+	parsed := net.ParseIP(ip)
+	if parsed == nil || IsInternal(ip) {
+		return loc
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.geo != nil {
+		var rec record
+		if err := r.geo.Lookup(parsed, &rec); err == nil {
+			loc.Country = rec.Country.ISOCode
+			loc.Region = r.regionName(rec)
+			loc.ASN, loc.ASOrg = rec.ASN, rec.ASOrg
+		}
+	}
+	if r.asn != nil {
+		var rec record
+		if err := r.asn.Lookup(parsed, &rec); err == nil && rec.ASN != 0 {
+			loc.ASN, loc.ASOrg = rec.ASN, rec.ASOrg
+		}
+	}
+	return loc
+}
+
+// regionName 按配置语言取一级行政区名称，缺失时回退英文与 ISO 代码
+func (r *Resolver) regionName(rec record) string {
+	if len(rec.Subdivisions) == 0 {
+		return ""
+	}
+	sub := rec.Subdivisions[0]
+	if name := sub.Names[r.cfg.Language]; name != "" {
+		return name
+	}
+	if name := sub.Names[defaultLanguage]; name != "" {
+		return name
+	}
+	return sub.ISOCode
+}
+
+// IsInternal 回环、内网与链路本地地址没有归属地
+func IsInternal(ip string) bool {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	return addr.IsLoopback() || addr.IsPrivate() || addr.IsLinkLocalUnicast() || addr.IsUnspecified()
+}
+
+// watch 监听库文件所在目录 (更新工具通常以重命名的方式替换文件)
+func (r *Resolver) watch() {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		r.logger.Error("geoip_watch_failed", zap.Error(err))
+		return
+	}
+	files := make(map[string]bool)
+	for _, path := range []string{r.cfg.DBPath, r.cfg.ASNDBPath} {
+		if path == "" {
+			continue
+		}
+		path = filepath.Clean(path)
+		files[path] = true
+		if err := watcher.Add(filepath.Dir(path)); err != nil {
+			r.logger.Error("geoip_watch_failed", zap.String("path", path), zap.Error(err))
+		}
+	}
+	r.watcher = watcher
+
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		var timer *time.Timer
+		reload := make(chan struct{}, 1)
+		defer func() {
+			if timer != nil {
+				timer.Stop()
+			}
+		}()
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if !files[filepath.Clean(event.Name)] || event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename) == 0 {
+					continue
+				}
+				if timer == nil {
+					timer = time.AfterFunc(reloadDelay, func() {
+						select {
+						case reload <- struct{}{}:
+						default:
+						}
+					})
+				} else {
+					timer.Reset(reloadDelay)
+				}
+			case <-reload:
+				if err := r.Reload(); err != nil {
+					r.logger.Error("geoip_reload_failed", zap.Error(err))
+					continue
+				}
+				r.logger.Info("geoip database reloaded")
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				r.logger.Error("geoip_watch_error", zap.Error(err))
+			case <-r.stop:
+				return
+			}
+		}
+	}()
+}
+
+func (r *Resolver) Close(ctx context.Context) error {
+	r.stopOnce.Do(func() {
+		close(r.stop)
+		if r.watcher != nil {
+			_ = r.watcher.Close()
+		}
+	})
+	done := make(chan struct{})
+	go func() {
+		r.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,109 @@
+package geoip
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/CIPFZ/gowebframe/internal/core/config"
+	"github.com/CIPFZ/gowebframe/internal/core/geoip/geoiptest"
+	"go.uber.org/zap"
+)
+
+func TestResolverLookup(t *testing.T) {
+	dir := t.TempDir()
+	cityPath := filepath.Join(dir, "city.mmdb")
+	asnPath := filepath.Join(dir, "asn.mmdb")
+	if err := geoiptest.Write(cityPath,
+		geoiptest.Network{CIDR: "1.2.3.0/24", Country: "CN", RegionCode: "ZJ", Region: map[string]string{"en": "Zhejiang", "zh-CN": "浙江省"}},
+		geoiptest.Network{CIDR: "2001:db8::/32", Country: "JP", RegionCode: "13"},
+	); err != nil {
+		t.Fatalf("Write(city) error = %v", err)
+	}
+	if err := geoiptest.Write(asnPath, geoiptest.Network{CIDR: "1.2.0.0/16", ASN: 4134, ASOrg: "CHINANET"}); err != nil {
+		t.Fatalf("Write(asn) error = %v", err)
+	}
+
+	r, err := New(config.GeoIP{DBPath: cityPath, ASNDBPath: asnPath, Language: "zh-CN"}, zap.NewNop())
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	t.Cleanup(func() { _ = r.Close(context.Background()) })
+
+	tests := []struct {
+		ip   string
+		want Location
+	}{
+		{"1.2.3.4", Location{Country: "CN", Region: "浙江省", ASN: 4134, ASOrg: "CHINANET"}},
+		{"1.2.9.9", Location{ASN: 4134, ASOrg: "CHINANET"}},
+		{"2001:db8::1", Location{Country: "JP", Region: "13"}},
+		{"8.8.8.8", Location{}},
+		{"10.0.0.1", Location{}},
+		{"not-an-ip", Location{}},
+	}
+	for _, tt := range tests {
+		if got := r.Lookup(tt.ip); got != tt.want {
+			t.Fatalf("Lookup(%q) = %+v, want %+v", tt.ip, got, tt.want)
+		}
+	}
+
+	var disabled *Resolver
+	if got := disabled.Lookup("1.2.3.4"); got != (Location{}) {
+		t.Fatalf("nil Lookup() = %+v", got)
+	}
+	if _, err := New(config.GeoIP{DBPath: filepath.Join(dir, "missing.mmdb")}, zap.NewNop()); err == nil {
+		t.Fatal("New() with missing file error = nil")
+	}
+}
+
+func TestResolverReloadsReplacedFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "city.mmdb")
+	if err := geoiptest.Write(path, geoiptest.Network{CIDR: "1.2.3.0/24", Country: "CN"}); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	r, err := New(config.GeoIP{DBPath: path}, zap.NewNop())
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	t.Cleanup(func() { _ = r.Close(context.Background()) })
+
+	// 损坏的文件不会替换正在使用的库
+	if err := os.WriteFile(path, []byte("broken"), 0o644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	if err := r.Reload(); err == nil {
+		t.Fatal("Reload() with broken file error = nil")
+	}
+	if got := r.Lookup("1.2.3.4").Country; got != "CN" {
+		t.Fatalf("Lookup() after broken reload = %q, want CN", got)
+	}
+
+	if err := geoiptest.Write(path, geoiptest.Network{CIDR: "1.2.3.0/24", Country: "US"}); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for r.Lookup("1.2.3.4").Country != "US" {
+		if time.Now().After(deadline) {
+			t.Fatalf("Lookup() = %q after file replaced, want US", r.Lookup("1.2.3.4").Country)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+func TestIsInternal(t *testing.T) {
+	for ip, want := range map[string]bool{
+		"127.0.0.1":       true,
+		"192.168.1.1":     true,
+		"::ffff:10.1.1.1": true,
+		"fe80::1":         true,
+		"1.2.3.4":         false,
+		"2001:4860::8888": false,
+		"not-an-ip":       false,
+	} {
+		if got := IsInternal(ip); got != want {
+			t.Fatalf("IsInternal(%q) = %v, want %v", ip, got, want)
+		}
+	}
+}
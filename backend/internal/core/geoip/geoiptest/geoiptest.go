@@ -0,0 +1,78 @@
+// Package geoiptest 按给定网段生成 MaxMind 格式的 .mmdb 文件，用于测试归属地解析
+package geoiptest
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+
+	"github.com/maxmind/mmdbwriter"
+	"github.com/maxmind/mmdbwriter/mmdbtype"
+)
+
+// Network 一个网段的归属地，字段与 GeoLite2-City / GeoLite2-ASN 一致
+type Network struct {
+	CIDR       string
+	Country    string            // ISO 代码
+	Region     map[string]string // 语言 -> 一级行政区名称
+	RegionCode string
+	ASN        uint32
+	ASOrg      string
+}
+
+// Write 生成 .mmdb 文件；先写临时文件再重命名，模拟库文件的原子替换
+func Write(path string, networks ...Network) error {
+	tree, err := mmdbwriter.New(mmdbwriter.Options{
+		DatabaseType:            "GeoIP2-City",
+		RecordSize:              28,
+		IncludeReservedNetworks: true,
+	})
+	if err != nil {
+		return err
+	}
+	for _, n := range networks {
+		_, ipNet, err := net.ParseCIDR(n.CIDR)
+		if err != nil {
+			return err
+		}
+		if err := tree.Insert(ipNet, n.record()); err != nil {
+			return err
+		}
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".geoip-*.mmdb")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tree.WriteTo(tmp); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func (n Network) record() mmdbtype.Map {
+	rec := mmdbtype.Map{}
+	if n.Country != "" {
+		rec["country"] = mmdbtype.Map{"iso_code": mmdbtype.String(n.Country)}
+	}
+	if len(n.Region) > 0 || n.RegionCode != "" {
+		names := mmdbtype.Map{}
+		for lang, name := range n.Region {
+			names[mmdbtype.String(lang)] = mmdbtype.String(name)
+		}
+		rec["subdivisions"] = mmdbtype.Slice{mmdbtype.Map{
+			"iso_code": mmdbtype.String(n.RegionCode),
+			"names":    names,
+		}}
+	}
+	if n.ASN != 0 {
+		rec["autonomous_system_number"] = mmdbtype.Uint32(n.ASN)
+		rec["autonomous_system_organization"] = mmdbtype.String(n.ASOrg)
+	}
+	return rec
+}
@@ -0,0 +1,229 @@
+package jwt
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/CIPFZ/gowebframe/internal/modules/system/model"
+	lru "github.com/hashicorp/golang-lru/v2"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	blacklistPurgeInterval = time.Minute // 清理过期记录并重建过滤器的间隔
+	blacklistCacheSize     = 4096        // 命中黑名单的 token 缓存数量
+	bloomFalsePositiveRate = 0.01
+	bloomMinCapacity       = 1024
+)
+
+// dbBlacklist 基于数据库的 token 黑名单 (未启用 Redis 时使用)
+//
+// 绝大多数请求携带的是正常 token，先由布隆过滤器挡掉，避免每次请求都查库；
+// 命中过滤器后再查库，确认在黑名单的结果放入 LRU。
+// 过滤器只包含本实例写入及最近一次重建时库中的记录，多实例部署时
+// 其它实例的注销最多延迟一个清理周期可见 (会话校验会先一步拦截)。
+type dbBlacklist struct {
+	db     *gorm.DB
+	logger *zap.Logger
+	cache  *lru.Cache[string, time.Time]
+
+	mu    sync.RWMutex
+	bloom *bloomFilter
+	// rebuilding 重建过滤器期间新写入的记录可能不在快照中，先记入 pending，替换前补回新过滤器
+	rebuilding bool
+	pending    []string
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+func newDBBlacklist(db *gorm.DB, logger *zap.Logger) *dbBlacklist {
+	cache, _ := lru.New[string, time.Time](blacklistCacheSize)
+	b := &dbBlacklist{
+		db:     db,
+		logger: logger,
+		cache:  cache,
+		bloom:  newBloomFilter(bloomMinCapacity),
+		stop:   make(chan struct{}),
+	}
+	b.purge(context.Background())
+
+	b.wg.Add(1)
+	go b.startPurger()
+	return b
+}
+
+func (b *dbBlacklist) Add(ctx context.Context, token string, expiration time.Duration) error {
+	hash := hashToken(token)
+	expiresAt := time.Now().Add(expiration)
+	err := b.db.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&model.JwtBlacklist{TokenHash: hash, ExpiresAt: expiresAt}).
+		Error
+	if err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	b.bloom.Add(hash)
+	if b.rebuilding {
+		b.pending = append(b.pending, hash)
+	}
+	b.mu.Unlock()
+	b.cache.Add(hash, expiresAt)
+	return nil
+}
+
+func (b *dbBlacklist) Contains(ctx context.Context, token string) bool {
+	hash := hashToken(token)
+	if expiresAt, ok := b.cache.Get(hash); ok {
+		return time.Now().Before(expiresAt)
+	}
+
+	b.mu.RLock()
+	maybe := b.bloom.Test(hash)
+	b.mu.RUnlock()
+	if !maybe {
+		return false
+	}
+
+	var row model.JwtBlacklist
+	err := b.db.WithContext(ctx).
+		Select("expires_at").
+		Where("token_hash = ? AND expires_at > ?", hash, time.Now()).
+		Take(&row).Error
+	if err != nil {
+		return false
+	}
+	b.cache.Add(hash, row.ExpiresAt)
+	return true
+}
+
+func (b *dbBlacklist) startPurger() {
+	defer b.wg.Done()
+	ticker := time.NewTicker(blacklistPurgeInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			b.purge(context.Background())
+		case <-b.stop:
+			return
+		}
+	}
+}
+
+// purge 删除已过期的记录，并用库中剩余记录重建布隆过滤器
+func (b *dbBlacklist) purge(ctx context.Context) {
+	now := time.Now()
+	if err := b.db.WithContext(ctx).Unscoped().
+		Where("expires_at <= ?", now).
+		Delete(&model.JwtBlacklist{}).Error; err != nil {
+		b.logger.Error("jwt_blacklist_purge_failed", zap.Error(err))
+		return
+	}
+
+	// 先标记重建再读取快照：快照之后写入的记录一定会进入 pending
+	b.mu.Lock()
+	b.rebuilding = true
+	b.pending = nil
+	b.mu.Unlock()
+
+	var hashes []string
+	if err := b.db.WithContext(ctx).
+		Model(&model.JwtBlacklist{}).
+		Where("expires_at > ?", now).
+		Pluck("token_hash", &hashes).Error; err != nil {
+		b.logger.Error("jwt_blacklist_reload_failed", zap.Error(err))
+		b.mu.Lock()
+		b.rebuilding = false
+		b.pending = nil
+		b.mu.Unlock()
+		return
+	}
+
+	bloom := newBloomFilter(len(hashes) * 2)
+	for _, h := range hashes {
+		bloom.Add(h)
+	}
+	b.mu.Lock()
+	for _, h := range b.pending {
+		bloom.Add(h)
+	}
+	b.bloom = bloom
+	b.rebuilding = false
+	b.pending = nil
+	b.mu.Unlock()
+}
+
+func (b *dbBlacklist) Close(ctx context.Context) error {
+	close(b.stop)
+	done := make(chan struct{})
+	go func() {
+		b.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// bloomFilter 布隆过滤器，输入已是 sha256 十六进制串，直接用双重哈希派生 k 个位置
+type bloomFilter struct {
+	bits []uint64
+	m    uint64
+	k    uint64
+}
+
+func newBloomFilter(capacity int) *bloomFilter {
+	if capacity < bloomMinCapacity {
+		capacity = bloomMinCapacity
+	}
+	m := uint64(math.Ceil(-float64(capacity) * math.Log(bloomFalsePositiveRate) / (math.Ln2 * math.Ln2)))
+	k := uint64(math.Round(float64(m) / float64(capacity) * math.Ln2))
+	return &bloomFilter{bits: make([]uint64, (m+63)/64), m: m, k: k}
+}
+
+func (f *bloomFilter) locations(hash string) (uint64, uint64) {
+	raw, err := hex.DecodeString(hash)
+	if err != nil || len(raw) < 16 {
+		sum := sha256.Sum256([]byte(hash))
+		raw = sum[:]
+	}
+	return binary.BigEndian.Uint64(raw[:8]), binary.BigEndian.Uint64(raw[8:16]) | 1
+}
+
+func (f *bloomFilter) Add(hash string) {
+	h1, h2 := f.locations(hash)
+	for i := uint64(0); i < f.k; i++ {
+		pos := (h1 + i*h2) % f.m
+		f.bits[pos/64] |= 1 << (pos % 64)
+	}
+}
+
+func (f *bloomFilter) Test(hash string) bool {
+	h1, h2 := f.locations(hash)
+	for i := uint64(0); i < f.k; i++ {
+		pos := (h1 + i*h2) % f.m
+		if f.bits[pos/64]&(1<<(pos%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
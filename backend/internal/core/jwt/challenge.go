@@ -0,0 +1,75 @@
+package jwt
+
+import (
+	"errors"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// 二次验证挑战令牌的用途
+const (
+	ChallengeMfaVerify = "mfa_verify" // 已绑定 MFA，提交验证码即可
+	ChallengeMfaEnroll = "mfa_enroll" // 角色要求 MFA 但尚未绑定，需先完成绑定
+)
+
+const challengeExpiresTime = 5 * time.Minute
+
+var ErrChallengeInvalid = errors.New("二次验证已失效，请重新登录")
+
+// ChallengeClaims 密码校验通过后签发的短期挑战令牌
+// 使用独立的 audience，不能被当作 access token 使用
+type ChallengeClaims struct {
+	UserID  uint   `json:"userId"`
+	Purpose string `json:"purpose"`
+	Method  string `json:"method,omitempty"` // 首个因子的认证方式，完成二次验证后记入登录日志
+	jwt.RegisteredClaims
+}
+
+func (j *JWT) challengeAudience() string {
+	return j.issuer + "#mfa"
+}
+
+// CreateChallengeToken 签发挑战令牌
+func (j *JWT) CreateChallengeToken(userID uint, purpose, method string) (string, time.Time, error) {
+	now := time.Now()
+	expiresAt := now.Add(challengeExpiresTime)
+	claims := ChallengeClaims{
+		UserID:  userID,
+		Purpose: purpose,
+		Method:  method,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
+			Audience:  jwt.ClaimStrings{j.challengeAudience()},
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now.Add(-1 * time.Second)),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			Issuer:    j.issuer,
+		},
+	}
+
+	active := j.keys.active
+	token := jwt.NewWithClaims(active.method, claims)
+	if active.kid != "" {
+		token.Header["kid"] = active.kid
+	}
+	signed, err := token.SignedString(active.signKey)
+	return signed, expiresAt, err
+}
+
+// ParseChallengeToken 解析挑战令牌
+func (j *JWT) ParseChallengeToken(tokenString string) (*ChallengeClaims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &ChallengeClaims{}, j.keyFunc,
+		jwt.WithAudience(j.challengeAudience()),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return nil, ErrChallengeInvalid
+	}
+	claims, ok := token.Claims.(*ChallengeClaims)
+	if !ok || !token.Valid || claims.UserID == 0 || claims.Purpose == "" {
+		return nil, ErrChallengeInvalid
+	}
+	return claims, nil
+}
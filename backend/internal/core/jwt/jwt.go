@@ -3,6 +3,7 @@ package jwt
 import (
 	"context"
 	"errors"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -13,7 +14,7 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/redis/go-redis/v9"
 	"go.uber.org/zap"
-	"golang.org/x/sync/singleflight"
+	"gorm.io/gorm"
 )
 
 var (
@@ -23,41 +24,91 @@ var (
 	ErrTokenInvalid     = errors.New("无法处理此token")
 )
 
+const (
+	defaultExpiresTime        = 15 * time.Minute
+	defaultRefreshExpiresTime = 7 * 24 * time.Hour
+	defaultImpersonationTime  = 30 * time.Minute
+)
+
 type JWT struct {
-	cfg         config.JWT
-	logger      *zap.Logger
-	redis       redis.UniversalClient
-	signingKey  []byte
-	issuer      string
-	expiresTime time.Duration
-	bufferTime  time.Duration
-	sf          *singleflight.Group
+	cfg                config.JWT
+	logger             *zap.Logger
+	redis              redis.UniversalClient
+	blacklist          *dbBlacklist
+	keys               *keyring
+	issuer             string
+	expiresTime        time.Duration
+	refreshExpiresTime time.Duration
+	impersonationTime  time.Duration
+	maxTokenAge        time.Duration // 本组件签发的各类令牌的最长有效期，决定退役密钥的校验窗口
 }
 
-func NewJWT(cfg config.JWT, logger *zap.Logger, redis redis.UniversalClient) *JWT {
-	ep, _ := parseDuration(cfg.ExpiresTime)
-	bf, _ := parseDuration(cfg.BufferTime)
+// NewJWT 创建 JWT 组件
+// 启用 Redis 时黑名单存 Redis；否则在 db 不为空时使用数据库黑名单，并启动后台清理任务 (需调用 Close 停止)
+func NewJWT(cfg config.JWT, logger *zap.Logger, redis redis.UniversalClient, db *gorm.DB) (*JWT, error) {
+	// access token 的 audience 即签发者，解析时据此与挑战令牌、客户端令牌等区分
+	if cfg.Issuer == "" {
+		return nil, errors.New("jwt issuer is required")
+	}
+	keys, err := newKeyring(cfg)
+	if err != nil {
+		return nil, err
+	}
 
-	return &JWT{
-		cfg:         cfg,
-		logger:      logger,
-		redis:       redis,
-		signingKey:  []byte(cfg.SigningKey),
-		issuer:      cfg.Issuer,
-		expiresTime: ep,
-		bufferTime:  bf,
-		sf:          &singleflight.Group{},
+	ep, err := parseDuration(cfg.ExpiresTime)
+	if err != nil || ep <= 0 {
+		ep = defaultExpiresTime
+	}
+	rp, err := parseDuration(cfg.RefreshExpiresTime)
+	if err != nil || rp <= 0 {
+		rp = defaultRefreshExpiresTime
 	}
+	ip, err := parseDuration(cfg.ImpersonationTime)
+	if err != nil || ip <= 0 {
+		ip = defaultImpersonationTime
+	}
+
+	j := &JWT{
+		cfg:                cfg,
+		logger:             logger,
+		redis:              redis,
+		keys:               keys,
+		issuer:             cfg.Issuer,
+		expiresTime:        ep,
+		refreshExpiresTime: rp,
+		impersonationTime:  ip,
+		maxTokenAge:        max(ep, ip, challengeExpiresTime, MaxClientTokenTTL),
+	}
+	if redis == nil && db != nil {
+		j.blacklist = newDBBlacklist(db, logger)
+	}
+	return j, nil
 }
 
-func (j *JWT) CreateClaims(baseClaims dto.BaseClaims) claims.CustomClaims {
-	bfSeconds := int64(j.bufferTime / time.Second)
+// Close 停止数据库黑名单的后台清理任务
+func (j *JWT) Close(ctx context.Context) error {
+	if j.blacklist == nil {
+		return nil
+	}
+	return j.blacklist.Close(ctx)
+}
+
+// ExpiresTime access token 有效期
+func (j *JWT) ExpiresTime() time.Duration {
+	return j.expiresTime
+}
 
+// RefreshExpiresTime refresh token 有效期
+func (j *JWT) RefreshExpiresTime() time.Duration {
+	return j.refreshExpiresTime
+}
+
+func (j *JWT) CreateClaims(baseClaims dto.BaseClaims) claims.CustomClaims {
 	return claims.CustomClaims{
 		BaseClaims: baseClaims,
-		BufferTime: bfSeconds,
 		RegisteredClaims: jwt.RegisteredClaims{
-			Audience:  jwt.ClaimStrings{j.cfg.Issuer},
+			Audience:  jwt.ClaimStrings{j.issuer},
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			NotBefore: jwt.NewNumericDate(time.Now().Add(-1 * time.Second)),
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(j.expiresTime)),
 			Issuer:    j.issuer,
@@ -65,15 +116,25 @@ func (j *JWT) CreateClaims(baseClaims dto.BaseClaims) claims.CustomClaims {
 	}
 }
 
+// CreateImpersonationClaims 模拟登录令牌使用单独的有效期
+func (j *JWT) CreateImpersonationClaims(baseClaims dto.BaseClaims) claims.CustomClaims {
+	c := j.CreateClaims(baseClaims)
+	c.ExpiresAt = jwt.NewNumericDate(time.Now().Add(j.impersonationTime))
+	return c
+}
+
 func (j *JWT) CreateToken(claims claims.CustomClaims) (string, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(j.signingKey)
+	active := j.keys.active
+	token := jwt.NewWithClaims(active.method, claims)
+	if active.kid != "" {
+		token.Header["kid"] = active.kid
+	}
+	return token.SignedString(active.signKey)
 }
 
 func (j *JWT) ParseToken(tokenString string) (*claims.CustomClaims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &claims.CustomClaims{}, func(token *jwt.Token) (interface{}, error) {
-		return j.signingKey, nil
-	})
+	// 挑战令牌、客户端访问令牌等与 access token 使用同一套密钥，只接受 audience 为签发者的令牌
+	token, err := jwt.ParseWithClaims(tokenString, &claims.CustomClaims{}, j.keyFunc, jwt.WithAudience(j.issuer))
 	if err != nil {
 		return nil, err
 	}
@@ -85,41 +146,40 @@ func (j *JWT) ParseToken(tokenString string) (*claims.CustomClaims, error) {
 	return nil, ErrTokenInvalid
 }
 
-func (j *JWT) ResolveToken(ctx context.Context, oldToken string, claims *claims.CustomClaims) (string, *claims.CustomClaims, error) {
-	if claims.ExpiresAt.Unix()-time.Now().Unix() > claims.BufferTime {
-		return oldToken, claims, nil
+// keyFunc 按 kid 选择校验密钥，并要求签名算法与密钥一致，防止算法混淆
+func (j *JWT) keyFunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	key, ok := j.keys.keys[kid]
+	if !ok || !key.acceptable(time.Now(), j.maxTokenAge) {
+		return nil, ErrUnknownKid
 	}
-
-	newToken, err, _ := j.sf.Do("JWT:"+oldToken, func() (interface{}, error) {
-		return j.createTokenByOldToken(ctx, oldToken, *claims)
-	})
-	if err != nil {
-		return "", nil, err
+	if token.Method.Alg() != key.method.Alg() {
+		return nil, ErrTokenInvalid
 	}
-
-	newClaims, err := j.ParseToken(newToken.(string))
-	return newToken.(string), newClaims, err
+	return key.verifyKey, nil
 }
 
-func (j *JWT) createTokenByOldToken(ctx context.Context, oldToken string, c claims.CustomClaims) (string, error) {
-	if j.redis != nil {
-		isBlack, _ := j.redis.Get(ctx, "jwt_black:"+oldToken).Result()
-		if isBlack != "" {
-			return "", errors.New("token已被注销")
+// JWKS 返回当前仍可用于校验的公钥集合，供其他服务离线校验
+func (j *JWT) JWKS() JWKSet {
+	set := JWKSet{Keys: []JWK{}}
+	now := time.Now()
+	for _, key := range j.keys.keys {
+		if !key.acceptable(now, j.maxTokenAge) {
+			continue
+		}
+		if jwk, ok := key.jwk(); ok {
+			set.Keys = append(set.Keys, jwk)
 		}
 	}
-
-	newClaims := j.CreateClaims(c.BaseClaims)
-	newToken, err := j.CreateToken(newClaims)
-	if err != nil {
-		return "", err
-	}
-
-	return newToken, nil
+	sort.Slice(set.Keys, func(a, b int) bool { return set.Keys[a].Kid < set.Keys[b].Kid })
+	return set
 }
 
 func (j *JWT) SetBlacklist(ctx context.Context, token string, expiration time.Duration) error {
 	if j.redis == nil {
+		if j.blacklist != nil {
+			return j.blacklist.Add(ctx, token, expiration)
+		}
 		return nil
 	}
 	return j.redis.Set(ctx, "jwt_black:"+token, "1", expiration).Err()
@@ -127,6 +187,9 @@ func (j *JWT) SetBlacklist(ctx context.Context, token string, expiration time.Du
 
 func (j *JWT) IsBlacklist(ctx context.Context, token string) bool {
 	if j.redis == nil {
+		if j.blacklist != nil {
+			return j.blacklist.Contains(ctx, token)
+		}
 		return false
 	}
 	val, _ := j.redis.Get(ctx, "jwt_black:"+token).Result()
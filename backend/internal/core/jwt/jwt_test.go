@@ -2,19 +2,130 @@ package jwt
 
 import (
 	"context"
+	"path/filepath"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/CIPFZ/gowebframe/internal/core/config"
+	"github.com/CIPFZ/gowebframe/internal/core/db"
+	"github.com/CIPFZ/gowebframe/internal/modules/system/dto"
+	"github.com/CIPFZ/gowebframe/internal/modules/system/model"
+	"github.com/golang-jwt/jwt/v5"
 	"go.uber.org/zap"
+	"gorm.io/gorm"
 )
 
+func TestDatabaseBlacklistWithoutRedis(t *testing.T) {
+	gormDB := newBlacklistTestDB(t)
+	j, err := NewJWT(config.JWT{SigningKey: "test", Issuer: "test"}, zap.NewNop(), nil, gormDB)
+	if err != nil {
+		t.Fatalf("NewJWT() error = %v", err)
+	}
+	t.Cleanup(func() {
+		_ = j.Close(context.Background())
+	})
+
+	ctx := context.Background()
+	if j.IsBlacklist(ctx, "token") {
+		t.Fatal("IsBlacklist() = true before SetBlacklist")
+	}
+	if err := j.SetBlacklist(ctx, "token", time.Minute); err != nil {
+		t.Fatalf("SetBlacklist() error = %v", err)
+	}
+	if !j.IsBlacklist(ctx, "token") {
+		t.Fatal("IsBlacklist() = false, want true after SetBlacklist")
+	}
+
+	// 其它实例写入的记录在过滤器重建后可见
+	other := newDBBlacklist(gormDB, zap.NewNop())
+	t.Cleanup(func() {
+		_ = other.Close(context.Background())
+	})
+	if !other.Contains(ctx, "token") {
+		t.Fatal("Contains() on another instance = false, want true")
+	}
+
+	var stored model.JwtBlacklist
+	if err := gormDB.First(&stored).Error; err != nil {
+		t.Fatalf("load blacklist row error = %v", err)
+	}
+	if stored.TokenHash == "token" || stored.TokenHash != hashToken("token") {
+		t.Fatalf("stored TokenHash = %q, want sha256 of token", stored.TokenHash)
+	}
+}
+
+func TestDatabaseBlacklistPurgesExpiredEntries(t *testing.T) {
+	gormDB := newBlacklistTestDB(t)
+	b := newDBBlacklist(gormDB, zap.NewNop())
+	t.Cleanup(func() {
+		_ = b.Close(context.Background())
+	})
+
+	ctx := context.Background()
+	if err := b.Add(ctx, "expired", -time.Second); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if err := b.Add(ctx, "alive", time.Hour); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if b.Contains(ctx, "expired") {
+		t.Fatal("Contains() = true for expired entry")
+	}
+
+	b.purge(ctx)
+
+	var count int64
+	if err := gormDB.Unscoped().Model(&model.JwtBlacklist{}).Count(&count).Error; err != nil {
+		t.Fatalf("count blacklist rows error = %v", err)
+	}
+	if count != 1 {
+		t.Fatalf("blacklist rows after purge = %d, want 1", count)
+	}
+	if !b.Contains(ctx, "alive") {
+		t.Fatal("Contains() = false for live entry after purge")
+	}
+}
+
+func TestDatabaseBlacklistKeepsEntriesAddedDuringPurge(t *testing.T) {
+	gormDB := newBlacklistTestDB(t)
+	b := newDBBlacklist(gormDB, zap.NewNop())
+	t.Cleanup(func() {
+		_ = b.Close(context.Background())
+	})
+	ctx := context.Background()
+
+	// 在重建读取快照之后、替换过滤器之前写入一条记录
+	var armed atomic.Bool
+	err := gormDB.Callback().Query().After("gorm:query").Register("test:add_during_purge", func(tx *gorm.DB) {
+		if tx.Statement.Table == "jwt_blacklists" && armed.CompareAndSwap(true, false) {
+			if err := b.Add(ctx, "added-during-purge", time.Hour); err != nil {
+				t.Errorf("Add() error = %v", err)
+			}
+		}
+	})
+	if err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	armed.Store(true)
+	b.purge(ctx)
+	if armed.Load() {
+		t.Fatal("purge() did not reload the blacklist")
+	}
+
+	// 清空 LRU，强制走布隆过滤器
+	b.cache.Purge()
+	if !b.Contains(ctx, "added-during-purge") {
+		t.Fatal("Contains() = false for entry added during purge, want true")
+	}
+}
+
 func TestIsBlacklistWithoutRedisReturnsFalse(t *testing.T) {
-	j := NewJWT(config.JWT{
+	j := newTestJWT(t, config.JWT{
 		SigningKey:  "test",
 		ExpiresTime: "1h",
-		BufferTime:  "10m",
 		Issuer:      "test",
-	}, zap.NewNop(), nil)
+	})
 
 	if got := j.IsBlacklist(context.Background(), "token"); got {
 		t.Fatal("IsBlacklist() = true, want false when redis is disabled")
@@ -22,14 +133,128 @@ func TestIsBlacklistWithoutRedisReturnsFalse(t *testing.T) {
 }
 
 func TestSetBlacklistWithoutRedisIsNoop(t *testing.T) {
-	j := NewJWT(config.JWT{
+	j := newTestJWT(t, config.JWT{
 		SigningKey:  "test",
 		ExpiresTime: "1h",
-		BufferTime:  "10m",
 		Issuer:      "test",
-	}, zap.NewNop(), nil)
+	})
 
 	if err := j.SetBlacklist(context.Background(), "token", 0); err != nil {
 		t.Fatalf("SetBlacklist() error = %v, want nil when redis is disabled", err)
 	}
 }
+
+func TestNewRefreshTokenHashesRawValue(t *testing.T) {
+	raw, hash, err := NewRefreshToken()
+	if err != nil {
+		t.Fatalf("NewRefreshToken() error = %v", err)
+	}
+	if raw == "" || raw == hash {
+		t.Fatalf("NewRefreshToken() raw = %q, hash = %q, want distinct non-empty values", raw, hash)
+	}
+	if got := HashRefreshToken(raw); got != hash {
+		t.Fatalf("HashRefreshToken() = %q, want %q", got, hash)
+	}
+
+	other, _, err := NewRefreshToken()
+	if err != nil {
+		t.Fatalf("NewRefreshToken() error = %v", err)
+	}
+	if other == raw {
+		t.Fatal("NewRefreshToken() returned the same token twice")
+	}
+}
+
+func TestNewJWTFallsBackToDefaultDurations(t *testing.T) {
+	j := newTestJWT(t, config.JWT{SigningKey: "test", Issuer: "test"})
+
+	if got := j.ExpiresTime(); got != defaultExpiresTime {
+		t.Fatalf("ExpiresTime() = %v, want %v", got, defaultExpiresTime)
+	}
+	if got := j.RefreshExpiresTime(); got != defaultRefreshExpiresTime {
+		t.Fatalf("RefreshExpiresTime() = %v, want %v", got, defaultRefreshExpiresTime)
+	}
+}
+
+func TestClientTokenIsNotAcceptedAsLoginToken(t *testing.T) {
+	j := newTestJWT(t, config.JWT{SigningKey: "test", Issuer: "test"})
+
+	token, issued, err := j.CreateClientToken("ci-runner", "poetry", time.Minute)
+	if err != nil {
+		t.Fatalf("CreateClientToken() error = %v", err)
+	}
+	parsed, err := j.ParseClientToken(token)
+	if err != nil {
+		t.Fatalf("ParseClientToken() error = %v", err)
+	}
+	if parsed.Subject != "ci-runner" || parsed.Scope != "poetry" || parsed.ID != issued.ID {
+		t.Fatalf("ParseClientToken() = %+v, want subject ci-runner with scope poetry", parsed)
+	}
+	if _, err := j.ParseToken(token); err == nil {
+		t.Fatal("ParseToken() accepted a client access token")
+	}
+
+	loginToken, err := j.CreateToken(j.CreateClaims(dto.BaseClaims{UserID: 1}))
+	if err != nil {
+		t.Fatalf("CreateToken() error = %v", err)
+	}
+	if _, err := j.ParseClientToken(loginToken); err == nil {
+		t.Fatal("ParseClientToken() accepted a login token")
+	}
+}
+
+func TestParseTokenRequiresIssuerAudience(t *testing.T) {
+	j := newTestJWT(t, config.JWT{SigningKey: "test", Issuer: "test"})
+
+	// 以后新增的其他 audience 默认不能当作 access token 使用
+	for _, aud := range []jwt.ClaimStrings{{"test#other"}, nil} {
+		c := j.CreateClaims(dto.BaseClaims{UserID: 1})
+		c.Audience = aud
+		token, err := j.CreateToken(c)
+		if err != nil {
+			t.Fatalf("CreateToken() error = %v", err)
+		}
+		if _, err := j.ParseToken(token); err == nil {
+			t.Fatalf("ParseToken() accepted a token with audience %v", aud)
+		}
+	}
+
+	if _, err := NewJWT(config.JWT{SigningKey: "test"}, zap.NewNop(), nil, nil); err == nil {
+		t.Fatal("NewJWT() error = nil, want error for empty issuer")
+	}
+}
+
+func newTestJWT(t *testing.T, cfg config.JWT) *JWT {
+	t.Helper()
+	j, err := NewJWT(cfg, zap.NewNop(), nil, nil)
+	if err != nil {
+		t.Fatalf("NewJWT() error = %v", err)
+	}
+	return j
+}
+
+func newBlacklistTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	gormDB, err := db.InitDatabase(config.Database{
+		Driver: "sqlite3",
+		SQLite: config.SQLite{
+			Path:         filepath.Join(t.TempDir(), "blacklist.db"),
+			MaxIdleConns: 1,
+			MaxOpenConns: 1,
+		},
+	}, zap.NewNop())
+	if err != nil {
+		t.Fatalf("InitDatabase() error = %v", err)
+	}
+	if err := gormDB.AutoMigrate(&model.JwtBlacklist{}); err != nil {
+		t.Fatalf("AutoMigrate() error = %v", err)
+	}
+	sqlDB, err := gormDB.DB()
+	if err != nil {
+		t.Fatalf("DB() error = %v", err)
+	}
+	t.Cleanup(func() {
+		_ = sqlDB.Close()
+	})
+	return gormDB
+}
@@ -0,0 +1,207 @@
+package jwt
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/CIPFZ/gowebframe/internal/core/config"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var ErrUnknownKid = errors.New("未知的签名密钥")
+
+// signingKey 单个签名密钥
+// 对称模式下 kid 为空，signKey 与 verifyKey 均为 []byte
+type signingKey struct {
+	kid       string
+	method    jwt.SigningMethod
+	signKey   interface{}
+	verifyKey interface{}
+	retiredAt time.Time
+}
+
+// acceptable 退役密钥只在其签发的令牌全部过期前继续用于校验，maxTokenAge 取各类令牌有效期的最大值
+func (k *signingKey) acceptable(now time.Time, maxTokenAge time.Duration) bool {
+	return k.retiredAt.IsZero() || now.Before(k.retiredAt.Add(maxTokenAge))
+}
+
+// keyring 签发密钥 + 可校验密钥集合
+type keyring struct {
+	active *signingKey
+	keys   map[string]*signingKey
+}
+
+func newKeyring(cfg config.JWT) (*keyring, error) {
+	// 未配置 active_kid 时沿用 HS256 + signing_key
+	if cfg.ActiveKid == "" {
+		key := &signingKey{
+			method:    jwt.SigningMethodHS256,
+			signKey:   []byte(cfg.SigningKey),
+			verifyKey: []byte(cfg.SigningKey),
+		}
+		return &keyring{active: key, keys: map[string]*signingKey{"": key}}, nil
+	}
+
+	ring := &keyring{keys: make(map[string]*signingKey, len(cfg.Keys)+1)}
+	// 切换到 kid 轮换后，旧的 HS256 signing_key 作为无 kid 的只校验密钥退役，
+	// 避免切换瞬间所有在线令牌失效；删除 signing_key 即彻底停用
+	// 退役时间必须显式配置：若取启动时间，每次重启都会让共享密钥再多有效一个令牌周期
+	if cfg.SigningKey != "" {
+		if cfg.SigningKeyRetiredAt == "" {
+			return nil, errors.New("jwt signing_key_retired_at is required when signing_key is kept with active_kid")
+		}
+		retiredAt, err := time.Parse(time.RFC3339, cfg.SigningKeyRetiredAt)
+		if err != nil {
+			return nil, fmt.Errorf("jwt signing_key_retired_at: %w", err)
+		}
+		ring.keys[""] = &signingKey{
+			method:    jwt.SigningMethodHS256,
+			verifyKey: []byte(cfg.SigningKey),
+			retiredAt: retiredAt,
+		}
+	}
+	for _, kc := range cfg.Keys {
+		key, err := loadSigningKey(kc)
+		if err != nil {
+			return nil, fmt.Errorf("jwt key %q: %w", kc.Kid, err)
+		}
+		if _, dup := ring.keys[key.kid]; dup {
+			return nil, fmt.Errorf("jwt key %q: duplicated kid", key.kid)
+		}
+		ring.keys[key.kid] = key
+	}
+
+	active, ok := ring.keys[cfg.ActiveKid]
+	if !ok {
+		return nil, fmt.Errorf("jwt active_kid %q not found in keys", cfg.ActiveKid)
+	}
+	if active.signKey == nil {
+		return nil, fmt.Errorf("jwt active key %q has no private key", cfg.ActiveKid)
+	}
+	if !active.retiredAt.IsZero() {
+		return nil, fmt.Errorf("jwt active key %q is retired", cfg.ActiveKid)
+	}
+	ring.active = active
+	return ring, nil
+}
+
+func loadSigningKey(kc config.JWTKey) (*signingKey, error) {
+	if kc.Kid == "" {
+		return nil, errors.New("kid is required")
+	}
+	key := &signingKey{kid: kc.Kid}
+
+	if kc.RetiredAt != "" {
+		t, err := time.Parse(time.RFC3339, kc.RetiredAt)
+		if err != nil {
+			return nil, fmt.Errorf("invalid retired_at: %w", err)
+		}
+		key.retiredAt = t
+	}
+
+	var privPEM, pubPEM []byte
+	var err error
+	if kc.PrivateKeyFile != "" {
+		if privPEM, err = os.ReadFile(kc.PrivateKeyFile); err != nil {
+			return nil, err
+		}
+	}
+	if kc.PublicKeyFile != "" {
+		if pubPEM, err = os.ReadFile(kc.PublicKeyFile); err != nil {
+			return nil, err
+		}
+	}
+	if privPEM == nil && pubPEM == nil {
+		return nil, errors.New("private_key_file or public_key_file is required")
+	}
+
+	switch strings.ToUpper(kc.Algorithm) {
+	case "RS256":
+		key.method = jwt.SigningMethodRS256
+		if privPEM != nil {
+			priv, err := jwt.ParseRSAPrivateKeyFromPEM(privPEM)
+			if err != nil {
+				return nil, err
+			}
+			key.signKey, key.verifyKey = priv, &priv.PublicKey
+		} else {
+			pub, err := jwt.ParseRSAPublicKeyFromPEM(pubPEM)
+			if err != nil {
+				return nil, err
+			}
+			key.verifyKey = pub
+		}
+	case "EDDSA":
+		key.method = jwt.SigningMethodEdDSA
+		if privPEM != nil {
+			priv, err := jwt.ParseEdPrivateKeyFromPEM(privPEM)
+			if err != nil {
+				return nil, err
+			}
+			edPriv, ok := priv.(ed25519.PrivateKey)
+			if !ok {
+				return nil, errors.New("not an ed25519 private key")
+			}
+			key.signKey, key.verifyKey = edPriv, edPriv.Public()
+		} else {
+			pub, err := jwt.ParseEdPublicKeyFromPEM(pubPEM)
+			if err != nil {
+				return nil, err
+			}
+			key.verifyKey = pub
+		}
+	default:
+		return nil, fmt.Errorf("unsupported algorithm %q", kc.Algorithm)
+	}
+	return key, nil
+}
+
+// JWK RFC 7517 公钥描述
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// JWKSet /.well-known/jwks.json 响应体
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+func (k *signingKey) jwk() (JWK, bool) {
+	switch pub := k.verifyKey.(type) {
+	case *rsa.PublicKey:
+		return JWK{
+			Kty: "RSA",
+			Kid: k.kid,
+			Use: "sig",
+			Alg: k.method.Alg(),
+			N:   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+		}, true
+	case ed25519.PublicKey:
+		return JWK{
+			Kty: "OKP",
+			Kid: k.kid,
+			Use: "sig",
+			Alg: k.method.Alg(),
+			Crv: "Ed25519",
+			X:   base64.RawURLEncoding.EncodeToString(pub),
+		}, true
+	default:
+		// 对称密钥不对外公开
+		return JWK{}, false
+	}
+}
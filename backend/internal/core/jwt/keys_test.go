@@ -0,0 +1,227 @@
+package jwt
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/CIPFZ/gowebframe/internal/core/config"
+	"github.com/CIPFZ/gowebframe/internal/modules/system/dto"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestAsymmetricKeysSignWithActiveKid(t *testing.T) {
+	dir := t.TempDir()
+	rsaFile := writeRSAKey(t, dir, "rsa.pem")
+	edFile := writeEdKey(t, dir, "ed.pem")
+
+	for _, tc := range []struct {
+		alg  string
+		file string
+	}{
+		{alg: "RS256", file: rsaFile},
+		{alg: "EdDSA", file: edFile},
+	} {
+		j := newTestJWT(t, config.JWT{
+			Issuer:    "test",
+			ActiveKid: "k1",
+			Keys:      []config.JWTKey{{Kid: "k1", Algorithm: tc.alg, PrivateKeyFile: tc.file}},
+		})
+
+		token, err := j.CreateToken(j.CreateClaims(dto.BaseClaims{UserID: 7}))
+		if err != nil {
+			t.Fatalf("%s CreateToken() error = %v", tc.alg, err)
+		}
+		parsed, err := j.ParseToken(token)
+		if err != nil {
+			t.Fatalf("%s ParseToken() error = %v", tc.alg, err)
+		}
+		if parsed.UserID != 7 {
+			t.Fatalf("%s ParseToken() userID = %d, want 7", tc.alg, parsed.UserID)
+		}
+
+		header, _, err := jwt.NewParser().ParseUnverified(token, &jwt.RegisteredClaims{})
+		if err != nil {
+			t.Fatalf("ParseUnverified() error = %v", err)
+		}
+		if header.Header["kid"] != "k1" || header.Method.Alg() != tc.alg {
+			t.Fatalf("token header = %v, want kid k1 alg %s", header.Header, tc.alg)
+		}
+	}
+}
+
+func TestRetiredKeyAcceptedUntilTokensExpire(t *testing.T) {
+	dir := t.TempDir()
+	oldFile := writeRSAKey(t, dir, "old.pem")
+	newFile := writeEdKey(t, dir, "new.pem")
+
+	oldJWT := newTestJWT(t, config.JWT{
+		Issuer:    "test",
+		ActiveKid: "old",
+		Keys:      []config.JWTKey{{Kid: "old", Algorithm: "RS256", PrivateKeyFile: oldFile}},
+	})
+	token, err := oldJWT.CreateToken(oldJWT.CreateClaims(dto.BaseClaims{UserID: 1}))
+	if err != nil {
+		t.Fatalf("CreateToken() error = %v", err)
+	}
+
+	rotated := func(retiredAt time.Time) *JWT {
+		return newTestJWT(t, config.JWT{
+			Issuer:      "test",
+			ExpiresTime: "15m",
+			ActiveKid:   "new",
+			Keys: []config.JWTKey{
+				{Kid: "old", Algorithm: "RS256", PrivateKeyFile: oldFile, RetiredAt: retiredAt.Format(time.RFC3339)},
+				{Kid: "new", Algorithm: "EdDSA", PrivateKeyFile: newFile},
+			},
+		})
+	}
+
+	recent := rotated(time.Now().Add(-time.Minute))
+	if _, err := recent.ParseToken(token); err != nil {
+		t.Fatalf("ParseToken() with recently retired key error = %v", err)
+	}
+	if got := len(recent.JWKS().Keys); got != 2 {
+		t.Fatalf("JWKS() keys = %d, want 2", got)
+	}
+
+	// 退役窗口取各类令牌的最长有效期 (客户端访问令牌最长 24 小时)，而不仅是 access token 的 15 分钟
+	stale := rotated(time.Now().Add(-MaxClientTokenTTL - time.Minute))
+	if _, err := stale.ParseToken(token); !errors.Is(err, ErrUnknownKid) {
+		t.Fatalf("ParseToken() with stale retired key error = %v, want %v", err, ErrUnknownKid)
+	}
+	set := stale.JWKS()
+	if len(set.Keys) != 1 || set.Keys[0].Kid != "new" || set.Keys[0].Kty != "OKP" {
+		t.Fatalf("JWKS() = %#v, want only the active ed25519 key", set)
+	}
+}
+
+func TestRetiredKeyOutlivesAccessTokenTTL(t *testing.T) {
+	dir := t.TempDir()
+	oldFile := writeRSAKey(t, dir, "old.pem")
+	newFile := writeEdKey(t, dir, "new.pem")
+
+	oldJWT := newTestJWT(t, config.JWT{
+		Issuer:    "test",
+		ActiveKid: "old",
+		Keys:      []config.JWTKey{{Kid: "old", Algorithm: "RS256", PrivateKeyFile: oldFile}},
+	})
+	token, err := oldJWT.CreateToken(oldJWT.CreateImpersonationClaims(dto.BaseClaims{UserID: 1, ImpersonatorID: 2}))
+	if err != nil {
+		t.Fatalf("CreateToken() error = %v", err)
+	}
+
+	// 退役已超过 access token 有效期，但模拟登录令牌 (30 分钟) 仍未过期
+	rotated := newTestJWT(t, config.JWT{
+		Issuer:      "test",
+		ExpiresTime: "15m",
+		ActiveKid:   "new",
+		Keys: []config.JWTKey{
+			{Kid: "old", Algorithm: "RS256", PrivateKeyFile: oldFile, RetiredAt: time.Now().Add(-20 * time.Minute).Format(time.RFC3339)},
+			{Kid: "new", Algorithm: "EdDSA", PrivateKeyFile: newFile},
+		},
+	})
+	if _, err := rotated.ParseToken(token); err != nil {
+		t.Fatalf("ParseToken() impersonation token with retired key error = %v", err)
+	}
+}
+
+func TestLegacySigningKeyVerifiesAfterSwitchingToKid(t *testing.T) {
+	legacy := newTestJWT(t, config.JWT{SigningKey: "legacy", Issuer: "test"})
+	token, err := legacy.CreateToken(legacy.CreateClaims(dto.BaseClaims{UserID: 3}))
+	if err != nil {
+		t.Fatalf("CreateToken() error = %v", err)
+	}
+
+	switched := func(retiredAt string) *JWT {
+		return newTestJWT(t, config.JWT{
+			SigningKey:          "legacy",
+			SigningKeyRetiredAt: retiredAt,
+			Issuer:              "test",
+			ActiveKid:           "k1",
+			Keys:                []config.JWTKey{{Kid: "k1", Algorithm: "EdDSA", PrivateKeyFile: writeEdKey(t, t.TempDir(), "k1.pem")}},
+		})
+	}
+
+	if _, err := NewJWT(config.JWT{
+		SigningKey: "legacy",
+		Issuer:     "test",
+		ActiveKid:  "k1",
+		Keys:       []config.JWTKey{{Kid: "k1", Algorithm: "EdDSA", PrivateKeyFile: writeEdKey(t, t.TempDir(), "k1.pem")}},
+	}, nil, nil, nil); err == nil {
+		t.Fatal("NewJWT() error = nil, want error when signing_key is kept without signing_key_retired_at")
+	}
+
+	j := switched(time.Now().Format(time.RFC3339))
+	if parsed, err := j.ParseToken(token); err != nil || parsed.UserID != 3 {
+		t.Fatalf("ParseToken() legacy token after switch = %v, %v, want userID 3", parsed, err)
+	}
+	// 新令牌由 active key 签发，旧密钥不再用于签发
+	fresh, err := j.CreateToken(j.CreateClaims(dto.BaseClaims{UserID: 4}))
+	if err != nil {
+		t.Fatalf("CreateToken() error = %v", err)
+	}
+	header, _, err := jwt.NewParser().ParseUnverified(fresh, &jwt.RegisteredClaims{})
+	if err != nil || header.Header["kid"] != "k1" {
+		t.Fatalf("new token header = %v, %v, want kid k1", header, err)
+	}
+	if got := len(j.JWKS().Keys); got != 1 {
+		t.Fatalf("JWKS() keys = %d, want only the active key", got)
+	}
+
+	stale := switched(time.Now().Add(-MaxClientTokenTTL - time.Minute).Format(time.RFC3339))
+	if _, err := stale.ParseToken(token); !errors.Is(err, ErrUnknownKid) {
+		t.Fatalf("ParseToken() legacy token after retirement error = %v, want %v", err, ErrUnknownKid)
+	}
+}
+
+func TestSymmetricModeExposesNoJWKS(t *testing.T) {
+	j := newTestJWT(t, config.JWT{SigningKey: "test", Issuer: "test"})
+	if got := len(j.JWKS().Keys); got != 0 {
+		t.Fatalf("JWKS() keys = %d, want 0 in HS256 mode", got)
+	}
+}
+
+func TestNewJWTRejectsMissingActiveKey(t *testing.T) {
+	_, err := NewJWT(config.JWT{Issuer: "test", ActiveKid: "missing"}, nil, nil, nil)
+	if err == nil {
+		t.Fatal("NewJWT() error = nil, want error for unknown active_kid")
+	}
+}
+
+func writeRSAKey(t *testing.T, dir, name string) string {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("rsa.GenerateKey() error = %v", err)
+	}
+	return writePEM(t, filepath.Join(dir, name), "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(key))
+}
+
+func writeEdKey(t *testing.T, dir, name string) string {
+	t.Helper()
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("ed25519.GenerateKey() error = %v", err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("MarshalPKCS8PrivateKey() error = %v", err)
+	}
+	return writePEM(t, filepath.Join(dir, name), "PRIVATE KEY", der)
+}
+
+func writePEM(t *testing.T, path, typ string, der []byte) string {
+	t.Helper()
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: der}), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	return path
+}
@@ -0,0 +1,36 @@
+package jwt
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+)
+
+// refreshTokenBytes refresh token 随机字节数 (256 bit)
+const refreshTokenBytes = 32
+
+var (
+	ErrRefreshTokenInvalid = errors.New("refresh token 无效")
+	ErrRefreshTokenExpired = errors.New("refresh token 已过期")
+	ErrRefreshTokenReused  = errors.New("refresh token 已被使用，登录态已全部吊销")
+)
+
+// NewRefreshToken 生成不透明的 refresh token
+// 返回值: 明文 (只下发给客户端一次) 与其哈希 (入库)
+func NewRefreshToken() (string, string, error) {
+	buf := make([]byte, refreshTokenBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", err
+	}
+	raw := base64.RawURLEncoding.EncodeToString(buf)
+	return raw, HashRefreshToken(raw), nil
+}
+
+// HashRefreshToken 计算 refresh token 的存储哈希
+// token 本身是高熵随机串，sha256 即可，无需 bcrypt
+func HashRefreshToken(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,295 @@
+package ldapauth
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/CIPFZ/gowebframe/internal/core/config"
+	"github.com/go-ldap/ldap/v3"
+	"go.uber.org/zap"
+)
+
+// Provider 目录用户绑定到本地账号时使用的身份提供方名称
+const Provider = "ldap"
+
+const (
+	defaultTimeout       = 5 * time.Second
+	defaultUserFilter    = "(uid={username})"
+	defaultUsernameAttr  = "uid"
+	defaultEmailAttr     = "mail"
+	defaultNameAttr      = "cn"
+	defaultGroupNameAttr = "cn"
+	defaultMemberOfAttr  = "memberOf"
+)
+
+var (
+	ErrInvalidCredentials = errors.New("ldap: 用户名或密码错误")
+	ErrUserNotFound       = errors.New("ldap: 用户不存在")
+)
+
+// Entry 目录中的用户
+type Entry struct {
+	DN          string
+	Username    string
+	Email       string
+	DisplayName string
+	Groups      []string // 组名 (group_name_attr 或 memberOf 中 DN 的第一个 RDN 值)
+}
+
+// Client LDAP / Active Directory 认证与用户查询，每次操作使用独立连接
+type Client struct {
+	cfg     config.LDAP
+	logger  *zap.Logger
+	timeout time.Duration
+
+	stop     chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+func New(cfg config.LDAP, logger *zap.Logger) *Client {
+	timeout := time.Duration(cfg.Timeout) * time.Second
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	return &Client{
+		cfg:     cfg,
+		logger:  logger,
+		timeout: timeout,
+		stop:    make(chan struct{}),
+	}
+}
+
+// Config 返回目录配置
+func (c *Client) Config() config.LDAP {
+	return c.cfg
+}
+
+// Authenticate 使用服务账号查找用户，再以用户 DN 与密码绑定校验
+func (c *Client) Authenticate(ctx context.Context, username, password string) (*Entry, error) {
+	// 空密码会被多数目录当作匿名绑定而成功，必须拒绝
+	if username == "" || password == "" {
+		return nil, ErrInvalidCredentials
+	}
+	conn, err := c.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	entry, err := c.findUser(conn, username)
+	if err != nil {
+		if errors.Is(err, ErrUserNotFound) {
+			return nil, ErrInvalidCredentials
+		}
+		return nil, err
+	}
+
+	if err := conn.Bind(entry.DN, password); err != nil {
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultInvalidCredentials) {
+			return nil, ErrInvalidCredentials
+		}
+		return nil, fmt.Errorf("ldap bind user: %w", err)
+	}
+	// 组查询使用服务账号权限，普通用户通常无权读取组
+	if err := c.bindService(conn); err != nil {
+		return nil, err
+	}
+	if err := c.loadGroups(conn, entry); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// Lookup 使用服务账号查询用户及其所属组 (用于定时同步)
+func (c *Client) Lookup(ctx context.Context, username string) (*Entry, error) {
+	conn, err := c.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	entry, err := c.findUser(conn, username)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.loadGroups(conn, entry); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// StartSync 按 sync_interval 周期执行同步任务，Close 时停止
+func (c *Client) StartSync(job func(ctx context.Context) error) {
+	interval := time.Duration(c.cfg.SyncInterval) * time.Second
+	if interval <= 0 {
+		return
+	}
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := job(context.Background()); err != nil {
+					c.logger.Error("ldap_sync_failed", zap.Error(err))
+				}
+			case <-c.stop:
+				return
+			}
+		}
+	}()
+}
+
+func (c *Client) Close(ctx context.Context) error {
+	c.stopOnce.Do(func() { close(c.stop) })
+	done := make(chan struct{})
+	go func() {
+		c.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// connect 建立连接并以服务账号绑定
+func (c *Client) connect(ctx context.Context) (*ldap.Conn, error) {
+	dialer := &net.Dialer{Timeout: c.timeout}
+	if deadline, ok := ctx.Deadline(); ok {
+		dialer.Deadline = deadline
+	}
+	// insecure_skip_verify 仅用于自签证书的测试环境
+	tlsConfig := &tls.Config{InsecureSkipVerify: c.cfg.InsecureSkipVerify}
+	conn, err := ldap.DialURL(c.cfg.URL, ldap.DialWithDialer(dialer), ldap.DialWithTLSConfig(tlsConfig))
+	if err != nil {
+		return nil, fmt.Errorf("ldap dial: %w", err)
+	}
+	conn.SetTimeout(c.timeout)
+
+	if c.cfg.StartTLS {
+		if err := conn.StartTLS(tlsConfig); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("ldap start tls: %w", err)
+		}
+	}
+	if err := c.bindService(conn); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+func (c *Client) bindService(conn *ldap.Conn) error {
+	if c.cfg.BindDN == "" {
+		return nil
+	}
+	if err := conn.Bind(c.cfg.BindDN, c.cfg.BindPassword); err != nil {
+		return fmt.Errorf("ldap bind service account: %w", err)
+	}
+	return nil
+}
+
+func (c *Client) findUser(conn *ldap.Conn, username string) (*Entry, error) {
+	usernameAttr := withDefault(c.cfg.UsernameAttr, defaultUsernameAttr)
+	emailAttr := withDefault(c.cfg.EmailAttr, defaultEmailAttr)
+	nameAttr := withDefault(c.cfg.DisplayNameAttr, defaultNameAttr)
+	memberOfAttr := withDefault(c.cfg.MemberOfAttr, defaultMemberOfAttr)
+
+	filter := expandFilter(withDefault(c.cfg.UserFilter, defaultUserFilter), username, "")
+	res, err := conn.Search(ldap.NewSearchRequest(
+		c.cfg.BaseDN, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases,
+		2, int(c.timeout.Seconds()), false,
+		filter,
+		[]string{usernameAttr, emailAttr, nameAttr, memberOfAttr},
+		nil,
+	))
+	if err != nil {
+		// 过滤器匹配到多个条目 (超出 sizeLimit) 时无法确定用户，按不存在处理
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultNoSuchObject) || ldap.IsErrorWithCode(err, ldap.LDAPResultSizeLimitExceeded) {
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("ldap search user: %w", err)
+	}
+	if len(res.Entries) != 1 {
+		return nil, ErrUserNotFound
+	}
+
+	e := res.Entries[0]
+	entry := &Entry{
+		DN:          e.DN,
+		Username:    e.GetAttributeValue(usernameAttr),
+		Email:       e.GetAttributeValue(emailAttr),
+		DisplayName: e.GetAttributeValue(nameAttr),
+	}
+	if entry.Username == "" {
+		entry.Username = username
+	}
+	if c.cfg.GroupFilter == "" {
+		for _, dn := range e.GetAttributeValues(memberOfAttr) {
+			if name := firstRDNValue(dn); name != "" {
+				entry.Groups = append(entry.Groups, name)
+			}
+		}
+	}
+	return entry, nil
+}
+
+// loadGroups 配置了 group_filter 时搜索组条目
+func (c *Client) loadGroups(conn *ldap.Conn, entry *Entry) error {
+	if c.cfg.GroupFilter == "" {
+		return nil
+	}
+	groupNameAttr := withDefault(c.cfg.GroupNameAttr, defaultGroupNameAttr)
+	res, err := conn.Search(ldap.NewSearchRequest(
+		withDefault(c.cfg.GroupBaseDN, c.cfg.BaseDN), ldap.ScopeWholeSubtree, ldap.NeverDerefAliases,
+		0, int(c.timeout.Seconds()), false,
+		expandFilter(c.cfg.GroupFilter, entry.Username, entry.DN),
+		[]string{groupNameAttr},
+		nil,
+	))
+	if err != nil {
+		return fmt.Errorf("ldap search groups: %w", err)
+	}
+	entry.Groups = entry.Groups[:0]
+	for _, g := range res.Entries {
+		if name := g.GetAttributeValue(groupNameAttr); name != "" {
+			entry.Groups = append(entry.Groups, name)
+		}
+	}
+	return nil
+}
+
+// expandFilter 替换过滤器占位符，值经过转义防止 LDAP 注入
+func expandFilter(filter, username, dn string) string {
+	return strings.NewReplacer(
+		"{username}", ldap.EscapeFilter(username),
+		"{dn}", ldap.EscapeFilter(dn),
+	).Replace(filter)
+}
+
+func firstRDNValue(dn string) string {
+	parsed, err := ldap.ParseDN(dn)
+	if err != nil || len(parsed.RDNs) == 0 || len(parsed.RDNs[0].Attributes) == 0 {
+		return ""
+	}
+	return parsed.RDNs[0].Attributes[0].Value
+}
+
+func withDefault(v, def string) string {
+	if v == "" {
+		return def
+	}
+	return v
+}
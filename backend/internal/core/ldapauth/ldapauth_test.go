@@ -0,0 +1,110 @@
+package ldapauth_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/CIPFZ/gowebframe/internal/core/config"
+	"github.com/CIPFZ/gowebframe/internal/core/ldapauth"
+	"github.com/CIPFZ/gowebframe/internal/core/ldapauth/ldaptest"
+	"go.uber.org/zap"
+)
+
+const (
+	baseDN    = "dc=example,dc=org"
+	serviceDN = "cn=svc,dc=example,dc=org"
+	bobDN     = "uid=bob,ou=people,dc=example,dc=org"
+)
+
+func newDirectory() *ldaptest.Server {
+	return ldaptest.NewServer(
+		&ldaptest.Entry{DN: baseDN},
+		&ldaptest.Entry{DN: serviceDN, Password: "svc-secret"},
+		&ldaptest.Entry{
+			DN:       bobDN,
+			Password: "bob-secret",
+			Attributes: map[string][]string{
+				"objectClass": {"inetOrgPerson"},
+				"uid":         {"bob"},
+				"cn":          {"Bob Builder"},
+				"mail":        {"bob@example.org"},
+				"memberOf":    {"cn=admins,ou=groups,dc=example,dc=org"},
+			},
+		},
+		&ldaptest.Entry{
+			DN: "cn=devs,ou=groups,dc=example,dc=org",
+			Attributes: map[string][]string{
+				"objectClass": {"groupOfNames"},
+				"cn":          {"devs"},
+				"member":      {bobDN},
+			},
+		},
+	)
+}
+
+func newClient(dir *ldaptest.Server, groupFilter string) *ldapauth.Client {
+	return ldapauth.New(config.LDAP{
+		Enabled:      true,
+		URL:          dir.URL(),
+		BindDN:       serviceDN,
+		BindPassword: "svc-secret",
+		BaseDN:       baseDN,
+		UserFilter:   "(&(objectClass=inetOrgPerson)(uid={username}))",
+		GroupFilter:  groupFilter,
+	}, zap.NewNop())
+}
+
+func TestClientAuthenticate(t *testing.T) {
+	dir := newDirectory()
+	defer dir.Close()
+	ctx := context.Background()
+
+	client := newClient(dir, "")
+	entry, err := client.Authenticate(ctx, "bob", "bob-secret")
+	if err != nil {
+		t.Fatalf("Authenticate() error = %v", err)
+	}
+	if entry.DN != bobDN || entry.Username != "bob" || entry.Email != "bob@example.org" || entry.DisplayName != "Bob Builder" {
+		t.Fatalf("Authenticate() entry = %+v", entry)
+	}
+	if len(entry.Groups) != 1 || entry.Groups[0] != "admins" {
+		t.Fatalf("Authenticate() groups = %v, want [admins] from memberOf", entry.Groups)
+	}
+
+	for name, tc := range map[string][2]string{
+		"wrong password": {"bob", "wrong"},
+		"empty password": {"bob", ""},
+		"unknown user":   {"nobody", "bob-secret"},
+		"filter inject":  {"*", "bob-secret"},
+	} {
+		if _, err := client.Authenticate(ctx, tc[0], tc[1]); !errors.Is(err, ldapauth.ErrInvalidCredentials) {
+			t.Errorf("Authenticate() %s error = %v, want %v", name, err, ldapauth.ErrInvalidCredentials)
+		}
+	}
+}
+
+func TestClientLookupWithGroupFilter(t *testing.T) {
+	dir := newDirectory()
+	defer dir.Close()
+	ctx := context.Background()
+
+	client := newClient(dir, "(&(objectClass=groupOfNames)(member={dn}))")
+	entry, err := client.Lookup(ctx, "bob")
+	if err != nil {
+		t.Fatalf("Lookup() error = %v", err)
+	}
+	if len(entry.Groups) != 1 || entry.Groups[0] != "devs" {
+		t.Fatalf("Lookup() groups = %v, want [devs] from group search", entry.Groups)
+	}
+
+	if _, err := client.Lookup(ctx, "nobody"); !errors.Is(err, ldapauth.ErrUserNotFound) {
+		t.Fatalf("Lookup() unknown error = %v, want %v", err, ldapauth.ErrUserNotFound)
+	}
+
+	// 服务账号密码错误时返回连接错误而非凭据错误
+	bad := ldapauth.New(config.LDAP{URL: dir.URL(), BindDN: serviceDN, BindPassword: "x", BaseDN: baseDN}, zap.NewNop())
+	if _, err := bad.Authenticate(ctx, "bob", "bob-secret"); err == nil || errors.Is(err, ldapauth.ErrInvalidCredentials) {
+		t.Fatalf("Authenticate() with bad service account error = %v", err)
+	}
+}
@@ -0,0 +1,364 @@
+// Package ldaptest 提供进程内的最小 LDAP v3 服务端 (Bind / Search / Unbind)，用于测试目录认证
+package ldaptest
+
+import (
+	"net"
+	"strings"
+	"sync"
+
+	ber "github.com/go-asn1-ber/asn1-ber"
+)
+
+// LDAP 协议操作 (RFC 4511 APPLICATION 标签)
+const (
+	appBindRequest    = 0
+	appBindResponse   = 1
+	appUnbindRequest  = 2
+	appSearchRequest  = 3
+	appSearchEntry    = 4
+	appSearchDone     = 5
+	appExtendedReq    = 23
+	appExtendedResp   = 24
+	resultSuccess     = 0
+	resultNoSuchObj   = 32
+	resultInvalidCred = 49
+	resultUnwilling   = 53
+	resultProtocolErr = 2
+)
+
+// 过滤器 (RFC 4511 CONTEXT 标签)
+const (
+	filterAnd        = 0
+	filterOr         = 1
+	filterNot        = 2
+	filterEquality   = 3
+	filterSubstrings = 4
+	filterPresent    = 7
+)
+
+// Entry 目录条目；Password 非空时可以该条目 DN 绑定
+type Entry struct {
+	DN         string
+	Password   string
+	Attributes map[string][]string
+}
+
+// Server 内存目录，只支持测试所需的简单绑定与搜索
+type Server struct {
+	listener net.Listener
+
+	mu      sync.RWMutex
+	entries []*Entry
+	wg      sync.WaitGroup
+}
+
+// NewServer 在随机端口启动服务端，调用方负责 Close
+func NewServer(entries ...*Entry) *Server {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		panic(err)
+	}
+	s := &Server{listener: l, entries: entries}
+	s.wg.Add(1)
+	go s.serve()
+	return s
+}
+
+// URL 返回 ldap:// 地址
+func (s *Server) URL() string {
+	return "ldap://" + s.listener.Addr().String()
+}
+
+// Add 添加或替换条目
+func (s *Server) Add(entry *Entry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, e := range s.entries {
+		if strings.EqualFold(e.DN, entry.DN) {
+			s.entries[i] = entry
+			return
+		}
+	}
+	s.entries = append(s.entries, entry)
+}
+
+// Remove 删除条目
+func (s *Server) Remove(dn string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, e := range s.entries {
+		if strings.EqualFold(e.DN, dn) {
+			s.entries = append(s.entries[:i], s.entries[i+1:]...)
+			return
+		}
+	}
+}
+
+func (s *Server) Close() {
+	_ = s.listener.Close()
+	s.wg.Wait()
+}
+
+func (s *Server) serve() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.handle(conn)
+		}()
+	}
+}
+
+func (s *Server) handle(conn net.Conn) {
+	defer conn.Close()
+	for {
+		packet, err := ber.ReadPacket(conn)
+		if err != nil || len(packet.Children) < 2 {
+			return
+		}
+		msgID, _ := packet.Children[0].Value.(int64)
+		op := packet.Children[1]
+		if op.ClassType != ber.ClassApplication {
+			return
+		}
+
+		var replies []*ber.Packet
+		switch op.Tag {
+		case appBindRequest:
+			replies = append(replies, s.bind(op))
+		case appSearchRequest:
+			replies = s.search(op)
+		case appUnbindRequest:
+			return
+		case appExtendedReq:
+			// 不支持 StartTLS 等扩展操作
+			replies = append(replies, result(appExtendedResp, resultUnwilling))
+		default:
+			return
+		}
+		for _, reply := range replies {
+			if _, err := conn.Write(envelope(msgID, reply).Bytes()); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (s *Server) bind(op *ber.Packet) *ber.Packet {
+	if len(op.Children) < 3 {
+		return result(appBindResponse, resultProtocolErr)
+	}
+	dn := str(op.Children[1])
+	password := str(op.Children[2])
+	// 匿名绑定
+	if dn == "" && password == "" {
+		return result(appBindResponse, resultSuccess)
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, e := range s.entries {
+		if strings.EqualFold(e.DN, dn) && e.Password != "" && e.Password == password {
+			return result(appBindResponse, resultSuccess)
+		}
+	}
+	return result(appBindResponse, resultInvalidCred)
+}
+
+func (s *Server) search(op *ber.Packet) []*ber.Packet {
+	if len(op.Children) < 8 {
+		return []*ber.Packet{result(appSearchDone, resultProtocolErr)}
+	}
+	baseDN := normalizeDN(str(op.Children[0]))
+	scope, _ := op.Children[1].Value.(int64)
+	sizeLimit, _ := op.Children[3].Value.(int64)
+	filter := op.Children[6]
+	var attrs []string
+	for _, a := range op.Children[7].Children {
+		attrs = append(attrs, str(a))
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	baseFound := baseDN == ""
+	var replies []*ber.Packet
+	for _, e := range s.entries {
+		dn := normalizeDN(e.DN)
+		if dn == baseDN {
+			baseFound = true
+		}
+		if !inScope(dn, baseDN, scope) || !match(filter, e) {
+			continue
+		}
+		if sizeLimit > 0 && int64(len(replies)) >= sizeLimit {
+			// sizeLimitExceeded
+			return append(replies, result(appSearchDone, 4))
+		}
+		replies = append(replies, searchEntry(e, attrs))
+	}
+	if !baseFound {
+		return []*ber.Packet{result(appSearchDone, resultNoSuchObj)}
+	}
+	return append(replies, result(appSearchDone, resultSuccess))
+}
+
+func inScope(dn, base string, scope int64) bool {
+	switch scope {
+	case 0: // baseObject
+		return dn == base
+	case 1: // singleLevel
+		i := strings.Index(dn, ",")
+		return i >= 0 && dn[i+1:] == base
+	default: // wholeSubtree
+		return base == "" || dn == base || strings.HasSuffix(dn, ","+base)
+	}
+}
+
+// match 计算过滤器，属性名与值均不区分大小写
+func match(f *ber.Packet, e *Entry) bool {
+	switch f.Tag {
+	case filterAnd:
+		for _, c := range f.Children {
+			if !match(c, e) {
+				return false
+			}
+		}
+		return true
+	case filterOr:
+		for _, c := range f.Children {
+			if match(c, e) {
+				return true
+			}
+		}
+		return false
+	case filterNot:
+		return len(f.Children) == 1 && !match(f.Children[0], e)
+	case filterEquality:
+		if len(f.Children) != 2 {
+			return false
+		}
+		want := str(f.Children[1])
+		for _, v := range values(e, str(f.Children[0])) {
+			if strings.EqualFold(v, want) {
+				return true
+			}
+		}
+		return false
+	case filterSubstrings:
+		if len(f.Children) != 2 {
+			return false
+		}
+		for _, v := range values(e, str(f.Children[0])) {
+			if matchSubstrings(strings.ToLower(v), f.Children[1].Children) {
+				return true
+			}
+		}
+		return false
+	case filterPresent:
+		return len(values(e, str(f))) > 0
+	}
+	return false
+}
+
+func matchSubstrings(v string, parts []*ber.Packet) bool {
+	for _, p := range parts {
+		sub := strings.ToLower(str(p))
+		switch p.Tag {
+		case 0: // initial
+			if !strings.HasPrefix(v, sub) {
+				return false
+			}
+			v = v[len(sub):]
+		case 1: // any
+			i := strings.Index(v, sub)
+			if i < 0 {
+				return false
+			}
+			v = v[i+len(sub):]
+		case 2: // final
+			if !strings.HasSuffix(v, sub) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func values(e *Entry, attr string) []string {
+	if strings.EqualFold(attr, "objectClass") && len(e.Attributes["objectClass"]) == 0 {
+		return []string{"top"}
+	}
+	for k, v := range e.Attributes {
+		if strings.EqualFold(k, attr) {
+			return v
+		}
+	}
+	return nil
+}
+
+func searchEntry(e *Entry, attrs []string) *ber.Packet {
+	op := ber.Encode(ber.ClassApplication, ber.TypeConstructed, appSearchEntry, nil, "Search Result Entry")
+	op.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, e.DN, "objectName"))
+	list := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "attributes")
+	for name, vals := range e.Attributes {
+		if !wanted(name, attrs) {
+			continue
+		}
+		attr := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "attribute")
+		attr.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, name, "type"))
+		set := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSet, nil, "vals")
+		for _, v := range vals {
+			set.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, v, "value"))
+		}
+		attr.AppendChild(set)
+		list.AppendChild(attr)
+	}
+	op.AppendChild(list)
+	return op
+}
+
+func wanted(name string, attrs []string) bool {
+	if len(attrs) == 0 {
+		return true
+	}
+	for _, a := range attrs {
+		if a == "*" || strings.EqualFold(a, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func result(tag ber.Tag, code int64) *ber.Packet {
+	op := ber.Encode(ber.ClassApplication, ber.TypeConstructed, tag, nil, "Result")
+	op.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagEnumerated, code, "resultCode"))
+	op.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", "matchedDN"))
+	op.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", "diagnosticMessage"))
+	return op
+}
+
+func envelope(msgID int64, op *ber.Packet) *ber.Packet {
+	p := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "LDAP Message")
+	p.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, msgID, "messageID"))
+	p.AppendChild(op)
+	return p
+}
+
+// str 读取 OCTET STRING 或上下文标签的原始内容
+func str(p *ber.Packet) string {
+	return p.Data.String()
+}
+
+func normalizeDN(dn string) string {
+	parts := strings.Split(dn, ",")
+	for i, p := range parts {
+		parts[i] = strings.ToLower(strings.TrimSpace(p))
+	}
+	return strings.Join(parts, ",")
+}
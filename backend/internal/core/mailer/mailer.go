@@ -0,0 +1,163 @@
+// Package mailer 基于 config.Email 的 SMTP 发信
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/CIPFZ/gowebframe/internal/core/config"
+)
+
+const defaultTimeout = 10 * time.Second
+
+// Message 纯文本邮件
+type Message struct {
+	To      []string
+	Subject string
+	Body    string
+}
+
+// Mailer 发信接口，便于测试替换
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// SMTPMailer 每次发信建立独立连接
+// is_ssl 为 true 时直接建立 TLS 连接 (465)，否则服务端支持时升级 STARTTLS
+type SMTPMailer struct {
+	cfg     config.Email
+	timeout time.Duration
+}
+
+func New(cfg config.Email) *SMTPMailer {
+	return &SMTPMailer{cfg: cfg, timeout: defaultTimeout}
+}
+
+func (m *SMTPMailer) Send(ctx context.Context, msg Message) error {
+	if len(msg.To) == 0 {
+		return errors.New("mailer: no recipient")
+	}
+	ctx, cancel := context.WithTimeout(ctx, m.timeout)
+	defer cancel()
+
+	addr := net.JoinHostPort(m.cfg.Host, strconv.Itoa(m.cfg.Port))
+	dialer := &net.Dialer{}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return fmt.Errorf("mailer dial: %w", err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	tlsConfig := &tls.Config{ServerName: m.cfg.Host}
+	if m.cfg.IsSSL {
+		conn = tls.Client(conn, tlsConfig)
+	}
+
+	client, err := smtp.NewClient(conn, m.cfg.Host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("mailer handshake: %w", err)
+	}
+	defer client.Close()
+
+	if !m.cfg.IsSSL {
+		if ok, _ := client.Extension("STARTTLS"); ok {
+			if err := client.StartTLS(tlsConfig); err != nil {
+				return fmt.Errorf("mailer starttls: %w", err)
+			}
+		}
+	}
+	if m.cfg.Secret != "" {
+		var auth smtp.Auth
+		if m.cfg.IsLoginAuth {
+			auth = &loginAuth{username: m.cfg.From, password: m.cfg.Secret}
+		} else {
+			auth = smtp.PlainAuth("", m.cfg.From, m.cfg.Secret, m.cfg.Host)
+		}
+		if err := client.Auth(auth); err != nil {
+			return fmt.Errorf("mailer auth: %w", err)
+		}
+	}
+
+	if err := client.Mail(m.cfg.From); err != nil {
+		return fmt.Errorf("mailer mail from: %w", err)
+	}
+	for _, to := range msg.To {
+		if err := client.Rcpt(to); err != nil {
+			return fmt.Errorf("mailer rcpt to: %w", err)
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("mailer data: %w", err)
+	}
+	if _, err := w.Write(m.build(msg)); err != nil {
+		return fmt.Errorf("mailer write: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("mailer data: %w", err)
+	}
+	return client.Quit()
+}
+
+// build 组装 MIME 报文，主题与正文使用 UTF-8 + base64 编码
+func (m *SMTPMailer) build(msg Message) []byte {
+	from := mail.Address{Name: m.cfg.Nickname, Address: m.cfg.From}
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", from.String())
+	fmt.Fprintf(&buf, "To: %s\r\n", strings.Join(msg.To, ", "))
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.BEncoding.Encode("UTF-8", msg.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: base64\r\n\r\n")
+
+	encoded := base64.StdEncoding.EncodeToString([]byte(msg.Body))
+	for len(encoded) > 76 {
+		buf.WriteString(encoded[:76] + "\r\n")
+		encoded = encoded[76:]
+	}
+	buf.WriteString(encoded + "\r\n")
+	return buf.Bytes()
+}
+
+// loginAuth AUTH LOGIN (IBM、微软等邮箱服务器不支持 PLAIN)
+type loginAuth struct {
+	username, password string
+}
+
+func (a *loginAuth) Start(server *smtp.ServerInfo) (string, []byte, error) {
+	if !server.TLS && !isLocalhost(server.Name) {
+		return "", nil, errors.New("mailer: unencrypted connection")
+	}
+	return "LOGIN", nil, nil
+}
+
+func (a *loginAuth) Next(fromServer []byte, more bool) ([]byte, error) {
+	if !more {
+		return nil, nil
+	}
+	switch strings.ToLower(strings.TrimSpace(string(fromServer))) {
+	case "username:":
+		return []byte(a.username), nil
+	case "password:":
+		return []byte(a.password), nil
+	}
+	return nil, fmt.Errorf("mailer: unexpected server challenge %q", fromServer)
+}
+
+func isLocalhost(host string) bool {
+	return host == "localhost" || host == "127.0.0.1" || host == "::1"
+}
@@ -0,0 +1,48 @@
+package mailer_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/CIPFZ/gowebframe/internal/core/config"
+	"github.com/CIPFZ/gowebframe/internal/core/mailer"
+	"github.com/CIPFZ/gowebframe/internal/core/mailer/mailertest"
+)
+
+func TestSMTPMailerSend(t *testing.T) {
+	srv := mailertest.NewServer()
+	srv.Password = "secret"
+	defer srv.Close()
+
+	for _, loginAuth := range []bool{false, true} {
+		m := mailer.New(config.Email{
+			From:        "noreply@example.com",
+			Nickname:    "Go Web Frame",
+			Host:        srv.Host(),
+			Port:        srv.Port(),
+			Secret:      "secret",
+			IsLoginAuth: loginAuth,
+		})
+		body := "你好，\n" + strings.Repeat("长正文", 40)
+		if err := m.Send(context.Background(), mailer.Message{To: []string{"alice@example.com"}, Subject: "重置密码", Body: body}); err != nil {
+			t.Fatalf("Send() loginAuth=%v error = %v", loginAuth, err)
+		}
+		got, ok := srv.Wait(time.Second)
+		if !ok {
+			t.Fatalf("Send() loginAuth=%v: no message received", loginAuth)
+		}
+		if got.Auth != "noreply@example.com" || got.From != "noreply@example.com" || len(got.To) != 1 || got.To[0] != "alice@example.com" {
+			t.Fatalf("envelope = %+v", got)
+		}
+		if got.Subject() != "重置密码" || got.Body() != body {
+			t.Fatalf("message subject = %q body = %q", got.Subject(), got.Body())
+		}
+	}
+
+	bad := mailer.New(config.Email{From: "noreply@example.com", Host: srv.Host(), Port: srv.Port(), Secret: "wrong"})
+	if err := bad.Send(context.Background(), mailer.Message{To: []string{"alice@example.com"}, Subject: "x", Body: "x"}); err == nil {
+		t.Fatal("Send() with wrong secret error = nil")
+	}
+}
@@ -0,0 +1,252 @@
+// Package mailertest 提供进程内的最小 SMTP 服务端，记录收到的邮件，用于测试发信流程
+package mailertest
+
+import (
+	"bufio"
+	"encoding/base64"
+	"io"
+	"mime"
+	"net"
+	"net/mail"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Message 收到的邮件
+type Message struct {
+	From string
+	To   []string
+	Data string // 原始报文 (不含结束符)
+	Auth string // 认证用户名，未认证时为空
+}
+
+// Subject 解码后的主题
+func (m Message) Subject() string {
+	msg, err := mail.ReadMessage(strings.NewReader(m.Data))
+	if err != nil {
+		return ""
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil {
+		return ""
+	}
+	return subject
+}
+
+// Body 解码后的正文 (支持 base64 传输编码)
+func (m Message) Body() string {
+	msg, err := mail.ReadMessage(strings.NewReader(m.Data))
+	if err != nil {
+		return ""
+	}
+	raw, _ := io.ReadAll(msg.Body)
+	if strings.EqualFold(msg.Header.Get("Content-Transfer-Encoding"), "base64") {
+		decoded, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(string(raw)), ""))
+		if err != nil {
+			return ""
+		}
+		return string(decoded)
+	}
+	return string(raw)
+}
+
+// Server 接受任意凭据 (可通过 Password 限定)，不支持 TLS
+type Server struct {
+	// Password 非空时 AUTH 必须使用该密码
+	Password string
+
+	listener net.Listener
+	mu       sync.Mutex
+	messages []Message
+	received chan Message
+	wg       sync.WaitGroup
+}
+
+// NewServer 在随机端口启动服务端，调用方负责 Close
+func NewServer() *Server {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		panic(err)
+	}
+	s := &Server{listener: l, received: make(chan Message, 64)}
+	s.wg.Add(1)
+	go s.serve()
+	return s
+}
+
+// Host 监听地址
+func (s *Server) Host() string {
+	host, _, _ := net.SplitHostPort(s.listener.Addr().String())
+	return host
+}
+
+// Port 监听端口
+func (s *Server) Port() int {
+	_, port, _ := net.SplitHostPort(s.listener.Addr().String())
+	n, _ := strconv.Atoi(port)
+	return n
+}
+
+// Messages 已收到的全部邮件
+func (s *Server) Messages() []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Message(nil), s.messages...)
+}
+
+// Wait 等待下一封邮件，超时返回 false
+func (s *Server) Wait(timeout time.Duration) (Message, bool) {
+	select {
+	case m := <-s.received:
+		return m, true
+	case <-time.After(timeout):
+		return Message{}, false
+	}
+}
+
+func (s *Server) Close() {
+	_ = s.listener.Close()
+	s.wg.Wait()
+}
+
+func (s *Server) serve() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.handle(conn)
+		}()
+	}
+}
+
+func (s *Server) handle(conn net.Conn) {
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(30 * time.Second))
+	r := bufio.NewReader(conn)
+	reply := func(line string) bool {
+		_, err := conn.Write([]byte(line + "\r\n"))
+		return err == nil
+	}
+	readLine := func() (string, bool) {
+		line, err := r.ReadString('\n')
+		return strings.TrimRight(line, "\r\n"), err == nil
+	}
+
+	var cur Message
+	reply("220 mailertest ESMTP")
+	for {
+		line, ok := readLine()
+		if !ok {
+			return
+		}
+		cmd, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(cmd) {
+		case "EHLO":
+			reply("250-mailertest")
+			reply("250-AUTH PLAIN LOGIN")
+			reply("250 8BITMIME")
+		case "HELO":
+			reply("250 mailertest")
+		case "AUTH":
+			user, ok := s.auth(arg, reply, readLine)
+			if !ok {
+				reply("535 authentication failed")
+				continue
+			}
+			cur.Auth = user
+			reply("235 authenticated")
+		case "MAIL":
+			cur.From = addrArg(arg)
+			cur.To = nil
+			reply("250 ok")
+		case "RCPT":
+			cur.To = append(cur.To, addrArg(arg))
+			reply("250 ok")
+		case "DATA":
+			reply("354 end data with <CR><LF>.<CR><LF>")
+			var data []string
+			for {
+				l, ok := readLine()
+				if !ok {
+					return
+				}
+				if l == "." {
+					break
+				}
+				data = append(data, strings.TrimPrefix(l, "."))
+			}
+			cur.Data = strings.Join(data, "\r\n")
+			s.mu.Lock()
+			s.messages = append(s.messages, cur)
+			s.mu.Unlock()
+			select {
+			case s.received <- cur:
+			default:
+			}
+			cur = Message{Auth: cur.Auth}
+			reply("250 queued")
+		case "RSET":
+			cur = Message{Auth: cur.Auth}
+			reply("250 ok")
+		case "NOOP":
+			reply("250 ok")
+		case "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("502 command not implemented")
+		}
+	}
+}
+
+// auth 处理 AUTH PLAIN / AUTH LOGIN，返回用户名
+func (s *Server) auth(arg string, reply func(string) bool, readLine func() (string, bool)) (string, bool) {
+	mech, initial, _ := strings.Cut(arg, " ")
+	decode := func(v string) string {
+		b, _ := base64.StdEncoding.DecodeString(v)
+		return string(b)
+	}
+	var user, pass string
+	switch strings.ToUpper(mech) {
+	case "PLAIN":
+		if initial == "" {
+			reply("334 ")
+			initial, _ = readLine()
+		}
+		parts := strings.Split(decode(initial), "\x00")
+		if len(parts) != 3 {
+			return "", false
+		}
+		user, pass = parts[1], parts[2]
+	case "LOGIN":
+		reply("334 " + base64.StdEncoding.EncodeToString([]byte("Username:")))
+		line, _ := readLine()
+		user = decode(line)
+		reply("334 " + base64.StdEncoding.EncodeToString([]byte("Password:")))
+		line, _ = readLine()
+		pass = decode(line)
+	default:
+		return "", false
+	}
+	if s.Password != "" && pass != s.Password {
+		return "", false
+	}
+	return user, true
+}
+
+// addrArg 解析 "FROM:<a@b>" / "TO:<a@b>"
+func addrArg(arg string) string {
+	_, v, _ := strings.Cut(arg, ":")
+	v = strings.TrimSpace(v)
+	if i := strings.Index(v, ">"); i >= 0 {
+		v = v[:i]
+	}
+	return strings.TrimPrefix(v, "<")
+}
@@ -0,0 +1,61 @@
+package mfa
+
+import (
+	"crypto/rand"
+	"strings"
+	"unicode"
+)
+
+const (
+	RecoveryCodeCount = 10
+	// 去掉易混淆字符 (0/O、1/I/L)
+	recoveryAlphabet = "23456789ABCDEFGHJKMNPQRSTUVWXYZ"
+	recoveryHalfLen  = 5
+	// 随机字节不小于该值时丢弃重取 (31 的整数倍)，避免取模偏差
+	recoveryByteLimit = 256 - 256%len(recoveryAlphabet)
+)
+
+// GenerateRecoveryCodes 生成一组一次性恢复码，格式 XXXXX-XXXXX
+// 短横线只为便于阅读，保存与校验前由 NormalizeRecoveryCode 去掉
+func GenerateRecoveryCodes() ([]string, error) {
+	codes := make([]string, 0, RecoveryCodeCount)
+	for len(codes) < RecoveryCodeCount {
+		chars, err := randomRecoveryChars(recoveryHalfLen * 2)
+		if err != nil {
+			return nil, err
+		}
+		codes = append(codes, chars[:recoveryHalfLen]+"-"+chars[recoveryHalfLen:])
+	}
+	return codes, nil
+}
+
+// randomRecoveryChars 以拒绝采样从字母表中均匀取 n 个字符
+func randomRecoveryChars(n int) (string, error) {
+	var sb strings.Builder
+	buf := make([]byte, n)
+	for sb.Len() < n {
+		if _, err := rand.Read(buf); err != nil {
+			return "", err
+		}
+		for _, b := range buf {
+			if int(b) >= recoveryByteLimit {
+				continue
+			}
+			sb.WriteByte(recoveryAlphabet[int(b)%len(recoveryAlphabet)])
+			if sb.Len() == n {
+				break
+			}
+		}
+	}
+	return sb.String(), nil
+}
+
+// NormalizeRecoveryCode 统一大小写并去掉空白与各种短横线，方便用户手动输入
+func NormalizeRecoveryCode(code string) string {
+	return strings.ToUpper(strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) || unicode.Is(unicode.Pd, r) || r == '−' {
+			return -1
+		}
+		return r
+	}, code))
+}
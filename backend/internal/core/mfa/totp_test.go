@@ -0,0 +1,86 @@
+package mfa
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+)
+
+// RFC 6238 附录 B 的 SHA1 测试向量 (取后 6 位)
+func TestCodeMatchesRFC6238Vectors(t *testing.T) {
+	secret := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+	cases := map[int64]string{
+		59:          "287082",
+		1111111109:  "081804",
+		1111111111:  "050471",
+		1234567890:  "005924",
+		2000000000:  "279037",
+		20000000000: "353130",
+	}
+	for ts, want := range cases {
+		got, err := Code(secret, time.Unix(ts, 0))
+		if err != nil {
+			t.Fatalf("Code() error = %v", err)
+		}
+		if got != want {
+			t.Fatalf("Code(%d) = %s, want %s", ts, got, want)
+		}
+	}
+}
+
+func TestValidateAcceptsSkewAndRejectsReplay(t *testing.T) {
+	secret, err := GenerateSecret()
+	if err != nil {
+		t.Fatalf("GenerateSecret() error = %v", err)
+	}
+	now := time.Unix(1700000000, 0)
+
+	prev, _ := Code(secret, now.Add(-30*time.Second))
+	step, ok := Validate(secret, prev, now, 0)
+	if !ok {
+		t.Fatal("Validate() rejected code from previous window")
+	}
+	if _, ok := Validate(secret, prev, now, step); ok {
+		t.Fatal("Validate() accepted a replayed code")
+	}
+
+	old, _ := Code(secret, now.Add(-2*time.Minute))
+	if _, ok := Validate(secret, old, now, 0); ok {
+		t.Fatal("Validate() accepted a code outside the skew window")
+	}
+}
+
+func TestProvisioningURI(t *testing.T) {
+	uri := ProvisioningURI("GWF", "alice", "JBSWY3DPEHPK3PXP")
+	for _, want := range []string{"otpauth://totp/GWF:alice?", "secret=JBSWY3DPEHPK3PXP", "issuer=GWF", "digits=6", "period=30"} {
+		if !strings.Contains(uri, want) {
+			t.Fatalf("ProvisioningURI() = %q, want it to contain %q", uri, want)
+		}
+	}
+}
+
+func TestGenerateRecoveryCodes(t *testing.T) {
+	codes, err := GenerateRecoveryCodes()
+	if err != nil {
+		t.Fatalf("GenerateRecoveryCodes() error = %v", err)
+	}
+	if len(codes) != RecoveryCodeCount {
+		t.Fatalf("GenerateRecoveryCodes() len = %d, want %d", len(codes), RecoveryCodeCount)
+	}
+	seen := make(map[string]bool)
+	for _, code := range codes {
+		if len(code) != 11 || code[5] != '-' {
+			t.Fatalf("recovery code %q has unexpected format", code)
+		}
+		if seen[code] {
+			t.Fatalf("duplicated recovery code %q", code)
+		}
+		seen[code] = true
+	}
+	for _, input := range []string{" abcde-fghij ", "ABCDEFGHIJ", "abcde–fghij", "ABCDE − FGHIJ"} {
+		if got := NormalizeRecoveryCode(input); got != "ABCDEFGHIJ" {
+			t.Fatalf("NormalizeRecoveryCode(%q) = %q, want ABCDEFGHIJ", input, got)
+		}
+	}
+}
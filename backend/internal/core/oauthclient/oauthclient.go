@@ -0,0 +1,171 @@
+package oauthclient
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/CIPFZ/gowebframe/internal/modules/system/model"
+	"github.com/hashicorp/golang-lru/v2/expirable"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultSize = 1024
+	// 分组内新增接口等不会主动失效的变化，最多延迟一个 TTL 生效
+	defaultTTL = 30 * time.Second
+	// 多实例间广播失效的频道，消息体为 client_id
+	invalidateChannel = "oauth_client_invalidate"
+)
+
+// Entry 解析后的客户端，供鉴权中间件只读使用
+type Entry struct {
+	Client model.SysOauthClient
+	// groups 当前允许的分组，allowed 为这些分组内已登记的接口 ("METHOD path" -> 分组)
+	groups  map[string]bool
+	allowed map[string]string
+}
+
+// Grant 访问令牌申请的分组与客户端当前允许的分组取交集，客户端缩小授权范围后已签发的令牌随之收窄
+func (e *Entry) Grant(scope string) *Grant {
+	g := &Grant{entry: e, groups: make(map[string]bool)}
+	for _, group := range strings.Fields(scope) {
+		if e.groups[group] {
+			g.groups[group] = true
+		}
+	}
+	return g
+}
+
+// Grant 一个访问令牌实际可访问的接口，实现 claims.Scopes
+type Grant struct {
+	entry  *Entry
+	groups map[string]bool
+}
+
+// Allows 判断能否访问该路由 (路由模板)
+func (g *Grant) Allows(method, path string) bool {
+	group, ok := g.entry.allowed[strings.ToUpper(method)+" "+path]
+	return ok && g.groups[group]
+}
+
+// Cache 按 client_id 缓存客户端及其分组展开后的接口
+// 客户端被修改、禁用或删除时由 OauthClientService 调用 Invalidate；启用 Redis 时通过 pub/sub 通知其他实例
+type Cache struct {
+	db     *gorm.DB
+	rdb    redis.UniversalClient
+	logger *zap.Logger
+
+	entries *expirable.LRU[string, *Entry]
+	// generation 每次失效递增，加载期间发生失效时不写入缓存，避免旧数据覆盖
+	generation atomic.Uint64
+
+	sub       *redis.PubSub
+	closeOnce sync.Once
+	done      chan struct{}
+}
+
+// New rdb 为 nil 时只在本实例内失效
+func New(db *gorm.DB, rdb redis.UniversalClient, logger *zap.Logger) *Cache {
+	c := &Cache{
+		db:      db,
+		rdb:     rdb,
+		logger:  logger,
+		entries: expirable.NewLRU[string, *Entry](defaultSize, nil, defaultTTL),
+		done:    make(chan struct{}),
+	}
+	if rdb == nil {
+		close(c.done)
+		return c
+	}
+	c.sub = rdb.Subscribe(context.Background(), invalidateChannel)
+	// 等待订阅确认，之后发布的失效通知不会丢失
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := c.sub.Receive(ctx); err != nil {
+		logger.Warn("oauth_client_invalidate_subscribe_failed", zap.Error(err))
+	}
+	go c.listen(c.sub.Channel())
+	return c
+}
+
+// Resolve 客户端不存在时返回 gorm.ErrRecordNotFound (不缓存)
+func (c *Cache) Resolve(ctx context.Context, clientID string) (*Entry, error) {
+	if entry, ok := c.entries.Get(clientID); ok {
+		return entry, nil
+	}
+
+	generation := c.generation.Load()
+	var client model.SysOauthClient
+	if err := c.db.WithContext(ctx).Where("client_id = ?", clientID).First(&client).Error; err != nil {
+		return nil, err
+	}
+	entry := &Entry{Client: client, groups: make(map[string]bool), allowed: make(map[string]string)}
+	groups := client.ScopeList()
+	for _, group := range groups {
+		entry.groups[group] = true
+	}
+	if len(groups) > 0 {
+		var apis []model.SysApi
+		if err := c.db.WithContext(ctx).Select("method", "path", "api_group").Where("api_group IN ?", groups).Find(&apis).Error; err != nil {
+			return nil, err
+		}
+		for _, api := range apis {
+			entry.allowed[strings.ToUpper(api.Method)+" "+api.Path] = api.ApiGroup
+		}
+	}
+	if c.generation.Load() == generation {
+		c.entries.Add(clientID, entry)
+	}
+	return entry, nil
+}
+
+// Invalidate 移除本实例的缓存并通知其他实例
+func (c *Cache) Invalidate(ctx context.Context, clientIDs ...string) error {
+	c.removeLocal(clientIDs...)
+	if c.rdb == nil {
+		return nil
+	}
+	var errs []error
+	for _, id := range clientIDs {
+		if err := c.rdb.Publish(ctx, invalidateChannel, id).Err(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (c *Cache) removeLocal(clientIDs ...string) {
+	c.generation.Add(1)
+	for _, id := range clientIDs {
+		c.entries.Remove(id)
+	}
+}
+
+func (c *Cache) listen(ch <-chan *redis.Message) {
+	defer close(c.done)
+	for msg := range ch {
+		c.removeLocal(msg.Payload)
+	}
+}
+
+// Close 停止订阅失效通知
+func (c *Cache) Close(ctx context.Context) error {
+	var err error
+	c.closeOnce.Do(func() {
+		if c.sub != nil {
+			err = c.sub.Close()
+		}
+	})
+	select {
+	case <-c.done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return err
+}
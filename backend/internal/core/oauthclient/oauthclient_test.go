@@ -0,0 +1,121 @@
+package oauthclient
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/CIPFZ/gowebframe/internal/core/config"
+	"github.com/CIPFZ/gowebframe/internal/core/db"
+	"github.com/CIPFZ/gowebframe/internal/modules/system/model"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+func newCacheTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	gormDB, err := db.InitDatabase(config.Database{
+		Driver: "sqlite3",
+		SQLite: config.SQLite{
+			Path:         filepath.Join(t.TempDir(), "oauthclient.db"),
+			MaxIdleConns: 1,
+			MaxOpenConns: 1,
+		},
+	}, zap.NewNop())
+	if err != nil {
+		t.Fatalf("InitDatabase() error = %v", err)
+	}
+	if err := gormDB.AutoMigrate(&model.SysApi{}, &model.SysOauthClient{}); err != nil {
+		t.Fatalf("AutoMigrate() error = %v", err)
+	}
+	sqlDB, err := gormDB.DB()
+	if err != nil {
+		t.Fatalf("DB() error = %v", err)
+	}
+	t.Cleanup(func() {
+		_ = sqlDB.Close()
+	})
+
+	if err := gormDB.Create(&[]model.SysApi{
+		{Path: "/api/v1/poetry/poem/list", Method: "GET", ApiGroup: "poetry"},
+		{Path: "/api/v1/sys/user/list", Method: "POST", ApiGroup: "user"},
+	}).Error; err != nil {
+		t.Fatalf("seed sys apis error = %v", err)
+	}
+	if err := gormDB.Create(&model.SysOauthClient{ClientID: "gwc_test", Name: "test", Scopes: "poetry", Enabled: true}).Error; err != nil {
+		t.Fatalf("seed oauth client error = %v", err)
+	}
+	return gormDB
+}
+
+func TestCacheResolveGrant(t *testing.T) {
+	gormDB := newCacheTestDB(t)
+	cache := New(gormDB, nil, zap.NewNop())
+	ctx := context.Background()
+
+	entry, err := cache.Resolve(ctx, "gwc_test")
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+	grant := entry.Grant("poetry user")
+	if !grant.Allows("get", "/api/v1/poetry/poem/list") {
+		t.Fatal("Allows() = false for granted group, want true")
+	}
+	// 申请的分组超出客户端允许的范围时取交集
+	if grant.Allows("POST", "/api/v1/sys/user/list") {
+		t.Fatal("Allows() = true for group outside client scopes, want false")
+	}
+
+	if _, err := cache.Resolve(ctx, "gwc_missing"); err == nil {
+		t.Fatal("Resolve() missing client error = nil, want not found")
+	}
+}
+
+func TestCacheInvalidatesAcrossInstances(t *testing.T) {
+	gormDB := newCacheTestDB(t)
+
+	mr := miniredis.RunT(t)
+	newClient := func() redis.UniversalClient {
+		rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+		t.Cleanup(func() { _ = rdb.Close() })
+		return rdb
+	}
+	local := New(gormDB, newClient(), zap.NewNop())
+	remote := New(gormDB, newClient(), zap.NewNop())
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		_ = local.Close(ctx)
+		_ = remote.Close(ctx)
+	})
+	ctx := context.Background()
+
+	if _, err := remote.Resolve(ctx, "gwc_test"); err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+	if err := gormDB.Model(&model.SysOauthClient{}).Where("client_id = ?", "gwc_test").Update("enabled", false).Error; err != nil {
+		t.Fatalf("disable client error = %v", err)
+	}
+	if err := local.Invalidate(ctx, "gwc_test"); err != nil {
+		t.Fatalf("Invalidate() error = %v", err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		entry, err := remote.Resolve(ctx, "gwc_test")
+		if err != nil {
+			t.Fatalf("Resolve() error = %v", err)
+		}
+		if !entry.Client.Enabled {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("remote cache still returns the enabled client after invalidation")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
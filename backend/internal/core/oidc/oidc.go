@@ -0,0 +1,307 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/CIPFZ/gowebframe/internal/core/cache"
+	"github.com/CIPFZ/gowebframe/internal/core/config"
+	gooidc "github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+)
+
+const (
+	stateKeyPrefix = "oidc_state:"
+	httpTimeout    = 10 * time.Second
+
+	defaultUsernameClaim = "preferred_username"
+	defaultGroupsClaim   = "groups"
+)
+
+// StateExpiresTime 授权请求的有效期，绑定 state 的浏览器 Cookie 使用相同的有效期
+const StateExpiresTime = 10 * time.Minute
+
+var (
+	ErrUnknownProvider = errors.New("未知的登录方式")
+	ErrStateInvalid    = errors.New("登录状态已失效，请重新登录")
+	ErrNonceMismatch   = errors.New("id_token nonce 不匹配")
+)
+
+// Identity 从 IdP 的 id_token 中提取的用户身份
+type Identity struct {
+	Provider string
+	Subject  string
+	Username string
+	Email    string
+	Name     string
+	Groups   []string
+}
+
+// ProviderInfo 登录页展示的身份提供方
+type ProviderInfo struct {
+	Name        string `json:"name"`
+	DisplayName string `json:"displayName"`
+}
+
+// authState 授权请求的一次性上下文，以 state 为键存放在 cache 中
+type authState struct {
+	Provider string `json:"provider"`
+	Verifier string `json:"verifier"` // PKCE code_verifier
+	Nonce    string `json:"nonce"`
+	Redirect string `json:"redirect"` // 登录后前端跳转的站内路径
+}
+
+// Manager 管理已配置的 OIDC 身份提供方，实现授权码 + PKCE 流程
+type Manager struct {
+	store     cache.Store
+	client    *http.Client
+	providers map[string]*provider
+	order     []string
+}
+
+type provider struct {
+	cfg config.OIDCProvider
+
+	mu sync.Mutex
+	op *gooidc.Provider // 首次使用时通过发现文档初始化，IdP 暂时不可用不影响服务启动
+}
+
+func NewManager(cfg config.OIDC, store cache.Store) *Manager {
+	m := &Manager{
+		store:     store,
+		client:    &http.Client{Timeout: httpTimeout},
+		providers: make(map[string]*provider, len(cfg.Providers)),
+	}
+	for _, p := range cfg.Providers {
+		if p.Name == "" || p.Issuer == "" || p.ClientID == "" {
+			continue
+		}
+		m.providers[p.Name] = &provider{cfg: p}
+		m.order = append(m.order, p.Name)
+	}
+	return m
+}
+
+// Providers 返回已配置的身份提供方 (按配置顺序)
+func (m *Manager) Providers() []ProviderInfo {
+	list := make([]ProviderInfo, 0, len(m.order))
+	for _, name := range m.order {
+		p := m.providers[name]
+		display := p.cfg.DisplayName
+		if display == "" {
+			display = name
+		}
+		list = append(list, ProviderInfo{Name: name, DisplayName: display})
+	}
+	return list
+}
+
+// Provider 返回身份提供方配置
+func (m *Manager) Provider(name string) (config.OIDCProvider, bool) {
+	p, ok := m.providers[name]
+	if !ok {
+		return config.OIDCProvider{}, false
+	}
+	return p.cfg, true
+}
+
+// AuthURL 生成跳转到 IdP 的授权地址，state、nonce、PKCE verifier 存入 cache
+// 返回的 state 由调用方写入发起登录的浏览器，回调时交给 Exchange 比对
+func (m *Manager) AuthURL(ctx context.Context, name, redirect string) (string, string, error) {
+	p, ok := m.providers[name]
+	if !ok {
+		return "", "", ErrUnknownProvider
+	}
+	op, err := m.discover(ctx, p)
+	if err != nil {
+		return "", "", err
+	}
+
+	state, err := randomString()
+	if err != nil {
+		return "", "", err
+	}
+	nonce, err := randomString()
+	if err != nil {
+		return "", "", err
+	}
+	st := authState{
+		Provider: name,
+		Verifier: oauth2.GenerateVerifier(),
+		Nonce:    nonce,
+		Redirect: SafeRedirect(redirect),
+	}
+	raw, err := json.Marshal(st)
+	if err != nil {
+		return "", "", err
+	}
+	if err := m.store.Set(ctx, stateKeyPrefix+state, string(raw), StateExpiresTime); err != nil {
+		return "", "", err
+	}
+
+	return m.oauth2Config(p, op).AuthCodeURL(state,
+		oauth2.S256ChallengeOption(st.Verifier),
+		gooidc.Nonce(nonce),
+	), state, nil
+}
+
+// Exchange 处理回调：校验 state，用授权码 + PKCE verifier 换取 token，并校验 id_token
+// boundState 为发起登录的浏览器保存的 state，必须与回调参数一致，防止登录 CSRF
+// 返回身份信息与发起登录时指定的前端跳转路径
+func (m *Manager) Exchange(ctx context.Context, name, code, state, boundState string) (*Identity, string, error) {
+	if state == "" || code == "" {
+		return nil, "", ErrStateInvalid
+	}
+	if subtle.ConstantTimeCompare([]byte(state), []byte(boundState)) != 1 {
+		return nil, "", ErrStateInvalid
+	}
+	// state 一次性使用，防止回调被重放
+	raw, err := m.store.GetDel(ctx, stateKeyPrefix+state)
+	if err != nil {
+		if errors.Is(err, cache.ErrNotFound) {
+			return nil, "", ErrStateInvalid
+		}
+		return nil, "", err
+	}
+	var st authState
+	if err := json.Unmarshal([]byte(raw), &st); err != nil || st.Provider != name {
+		return nil, "", ErrStateInvalid
+	}
+
+	p, ok := m.providers[name]
+	if !ok {
+		return nil, "", ErrUnknownProvider
+	}
+	op, err := m.discover(ctx, p)
+	if err != nil {
+		return nil, "", err
+	}
+
+	httpCtx := gooidc.ClientContext(ctx, m.client)
+	token, err := m.oauth2Config(p, op).Exchange(httpCtx, code, oauth2.VerifierOption(st.Verifier))
+	if err != nil {
+		return nil, "", fmt.Errorf("oidc exchange code: %w", err)
+	}
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok || rawIDToken == "" {
+		return nil, "", errors.New("oidc: token response missing id_token")
+	}
+	idToken, err := op.Verifier(&gooidc.Config{ClientID: p.cfg.ClientID}).Verify(httpCtx, rawIDToken)
+	if err != nil {
+		return nil, "", fmt.Errorf("oidc verify id_token: %w", err)
+	}
+	if idToken.Nonce != st.Nonce {
+		return nil, "", ErrNonceMismatch
+	}
+
+	var claims map[string]any
+	if err := idToken.Claims(&claims); err != nil {
+		return nil, "", err
+	}
+	return identityFromClaims(p.cfg, idToken.Subject, claims), st.Redirect, nil
+}
+
+// discover 懒加载 IdP 发现文档，失败时下次请求重试
+func (m *Manager) discover(ctx context.Context, p *provider) (*gooidc.Provider, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.op != nil {
+		return p.op, nil
+	}
+	// Provider 会保留该 ctx 用于后续拉取 JWKS，不能随请求取消
+	discoverCtx := gooidc.ClientContext(context.WithoutCancel(ctx), m.client)
+	op, err := gooidc.NewProvider(discoverCtx, p.cfg.Issuer)
+	if err != nil {
+		return nil, fmt.Errorf("oidc discovery %s: %w", p.cfg.Name, err)
+	}
+	p.op = op
+	return op, nil
+}
+
+func (m *Manager) oauth2Config(p *provider, op *gooidc.Provider) *oauth2.Config {
+	scopes := p.cfg.Scopes
+	if len(scopes) == 0 {
+		scopes = []string{gooidc.ScopeOpenID, "profile", "email"}
+	}
+	return &oauth2.Config{
+		ClientID:     p.cfg.ClientID,
+		ClientSecret: p.cfg.ClientSecret,
+		RedirectURL:  p.cfg.RedirectURL,
+		Endpoint:     op.Endpoint(),
+		Scopes:       scopes,
+	}
+}
+
+func identityFromClaims(cfg config.OIDCProvider, subject string, claims map[string]any) *Identity {
+	usernameClaim := cfg.UsernameClaim
+	if usernameClaim == "" {
+		usernameClaim = defaultUsernameClaim
+	}
+	groupsClaim := cfg.GroupsClaim
+	if groupsClaim == "" {
+		groupsClaim = defaultGroupsClaim
+	}
+
+	id := &Identity{
+		Provider: cfg.Name,
+		Subject:  subject,
+		Username: stringClaim(claims, usernameClaim),
+		Email:    stringClaim(claims, "email"),
+		Name:     stringClaim(claims, "name"),
+		Groups:   stringsClaim(claims, groupsClaim),
+	}
+	if id.Username == "" {
+		id.Username = id.Email
+	}
+	if id.Username == "" {
+		id.Username = subject
+	}
+	return id
+}
+
+func stringClaim(claims map[string]any, key string) string {
+	v, _ := claims[key].(string)
+	return strings.TrimSpace(v)
+}
+
+// stringsClaim 兼容数组与空格分隔字符串两种组声明格式
+func stringsClaim(claims map[string]any, key string) []string {
+	switch v := claims[key].(type) {
+	case []any:
+		list := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok && s != "" {
+				list = append(list, s)
+			}
+		}
+		return list
+	case string:
+		return strings.Fields(v)
+	}
+	return nil
+}
+
+// SafeRedirect 只允许站内相对路径，防止开放重定向
+func SafeRedirect(redirect string) string {
+	if !strings.HasPrefix(redirect, "/") || strings.HasPrefix(redirect, "//") || strings.Contains(redirect, "\\") {
+		return "/"
+	}
+	return redirect
+}
+
+func randomString() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
@@ -0,0 +1,146 @@
+package oidc_test
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/CIPFZ/gowebframe/internal/core/cache"
+	"github.com/CIPFZ/gowebframe/internal/core/config"
+	"github.com/CIPFZ/gowebframe/internal/core/oidc"
+	"github.com/CIPFZ/gowebframe/internal/core/oidc/oidctest"
+)
+
+const callbackURL = "http://app.local/api/v1/user/oidc/mock/callback"
+
+func newTestManager(t *testing.T, idp *oidctest.Server) *oidc.Manager {
+	t.Helper()
+	store := cache.NewMemoryStore()
+	t.Cleanup(func() { _ = store.Close(context.Background()) })
+	return oidc.NewManager(config.OIDC{Providers: []config.OIDCProvider{{
+		Name:         "mock",
+		DisplayName:  "Mock IdP",
+		Issuer:       idp.Issuer(),
+		ClientID:     oidctest.ClientID,
+		ClientSecret: oidctest.ClientSecret,
+		RedirectURL:  callbackURL,
+		GroupsClaim:  "roles",
+	}}}, store)
+}
+
+func authorize(t *testing.T, m *oidc.Manager, idp *oidctest.Server, redirect string) (code, state string) {
+	t.Helper()
+	authURL, bound, err := m.AuthURL(context.Background(), "mock", redirect)
+	if err != nil {
+		t.Fatalf("AuthURL() error = %v", err)
+	}
+	u, _ := url.Parse(authURL)
+	if u.Query().Get("code_challenge_method") != "S256" || u.Query().Get("nonce") == "" {
+		t.Fatalf("AuthURL() = %s, want PKCE S256 and nonce", authURL)
+	}
+	loc, err := idp.Authorize(authURL)
+	if err != nil {
+		t.Fatalf("Authorize() error = %v", err)
+	}
+	if !strings.HasPrefix(loc.String(), callbackURL) {
+		t.Fatalf("Authorize() redirect = %s, want %s", loc, callbackURL)
+	}
+	if loc.Query().Get("state") != bound {
+		t.Fatalf("Authorize() state = %q, want %q", loc.Query().Get("state"), bound)
+	}
+	return loc.Query().Get("code"), bound
+}
+
+func TestManagerExchange(t *testing.T) {
+	idp := oidctest.NewServer()
+	defer idp.Close()
+	idp.SetUser(map[string]any{
+		"sub":                "u-42",
+		"preferred_username": "bob",
+		"email":              "bob@example.com",
+		"roles":              []string{"admins", "dev"},
+	})
+	m := newTestManager(t, idp)
+
+	if got := m.Providers(); len(got) != 1 || got[0].DisplayName != "Mock IdP" {
+		t.Fatalf("Providers() = %+v", got)
+	}
+
+	code, state := authorize(t, m, idp, "/dashboard")
+	id, redirect, err := m.Exchange(context.Background(), "mock", code, state, state)
+	if err != nil {
+		t.Fatalf("Exchange() error = %v", err)
+	}
+	if id.Subject != "u-42" || id.Username != "bob" || id.Email != "bob@example.com" {
+		t.Fatalf("Exchange() identity = %+v", id)
+	}
+	if len(id.Groups) != 2 || id.Groups[0] != "admins" {
+		t.Fatalf("Exchange() groups = %v, want [admins dev]", id.Groups)
+	}
+	if redirect != "/dashboard" {
+		t.Fatalf("Exchange() redirect = %q, want /dashboard", redirect)
+	}
+
+	// state 只能使用一次
+	if _, _, err := m.Exchange(context.Background(), "mock", code, state, state); !errors.Is(err, oidc.ErrStateInvalid) {
+		t.Fatalf("Exchange() replay error = %v, want %v", err, oidc.ErrStateInvalid)
+	}
+}
+
+func TestManagerRejectsInvalidRequests(t *testing.T) {
+	idp := oidctest.NewServer()
+	defer idp.Close()
+	m := newTestManager(t, idp)
+
+	if _, _, err := m.AuthURL(context.Background(), "unknown", "/"); !errors.Is(err, oidc.ErrUnknownProvider) {
+		t.Fatalf("AuthURL() unknown error = %v, want %v", err, oidc.ErrUnknownProvider)
+	}
+	if _, _, err := m.Exchange(context.Background(), "mock", "code", "forged", "forged"); !errors.Is(err, oidc.ErrStateInvalid) {
+		t.Fatalf("Exchange() forged state error = %v, want %v", err, oidc.ErrStateInvalid)
+	}
+
+	// 授权码被篡改时令牌端点拒绝
+	_, state := authorize(t, m, idp, "/")
+	if _, _, err := m.Exchange(context.Background(), "mock", "tampered", state, state); err == nil {
+		t.Fatal("Exchange() with tampered code error = nil")
+	}
+}
+
+func TestManagerBindsStateToBrowser(t *testing.T) {
+	idp := oidctest.NewServer()
+	defer idp.Close()
+	idp.SetUser(map[string]any{"sub": "attacker"})
+	m := newTestManager(t, idp)
+
+	// 攻击者把自己的回调地址发给受害者：受害者浏览器中没有该 state 或持有另一个 state
+	code, state := authorize(t, m, idp, "/")
+	_, victimState := authorize(t, m, idp, "/")
+	for _, bound := range []string{"", victimState} {
+		if _, _, err := m.Exchange(context.Background(), "mock", code, state, bound); !errors.Is(err, oidc.ErrStateInvalid) {
+			t.Fatalf("Exchange() bound state %q error = %v, want %v", bound, err, oidc.ErrStateInvalid)
+		}
+	}
+
+	// 被拒绝的回调不会消耗 state，发起登录的浏览器仍可完成登录
+	if _, _, err := m.Exchange(context.Background(), "mock", code, state, state); err != nil {
+		t.Fatalf("Exchange() error = %v", err)
+	}
+}
+
+func TestSafeRedirect(t *testing.T) {
+	cases := map[string]string{
+		"/welcome":             "/welcome",
+		"":                     "/",
+		"https://evil.example": "/",
+		"//evil.example":       "/",
+		"/\\evil.example":      "/",
+		"/system/user?page=1":  "/system/user?page=1",
+	}
+	for in, want := range cases {
+		if got := oidc.SafeRedirect(in); got != want {
+			t.Errorf("SafeRedirect(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
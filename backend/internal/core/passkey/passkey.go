@@ -0,0 +1,226 @@
+package passkey
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/CIPFZ/gowebframe/internal/core/cache"
+	"github.com/CIPFZ/gowebframe/internal/core/config"
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+)
+
+const (
+	ceremonyKeyPrefix = "passkey_ceremony:"
+	defaultTimeout    = 5 * time.Minute
+
+	kindRegistration = "registration"
+	kindLogin        = "login"
+)
+
+var (
+	ErrCeremonyInvalid = errors.New("通行密钥验证已失效，请重试")
+	ErrVerifyFailed    = errors.New("通行密钥验证失败")
+	// ErrCloneDetected 签名计数器回退，说明凭证私钥可能被复制
+	ErrCloneDetected = errors.New("通行密钥签名计数异常，可能已被复制，请删除后重新注册")
+)
+
+// UserLoader 按 user handle 加载用户及其全部凭证
+type UserLoader func(userHandle []byte) (webauthn.User, error)
+
+// ceremony 一次注册或登录的服务端状态，以随机 ID 为键存放在 cache 中
+type ceremony struct {
+	Kind    string               `json:"kind"`
+	Session webauthn.SessionData `json:"session"`
+}
+
+// Manager 封装 WebAuthn 注册与登录流程 (ceremony)，挑战一次性使用
+type Manager struct {
+	wa      *webauthn.WebAuthn
+	store   cache.Store
+	timeout time.Duration
+}
+
+func NewManager(cfg config.Passkey, store cache.Store) (*Manager, error) {
+	timeout := time.Duration(cfg.Timeout) * time.Second
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	name := cfg.RPDisplayName
+	if name == "" {
+		name = cfg.RPID
+	}
+	wa, err := webauthn.New(&webauthn.Config{
+		RPID:          cfg.RPID,
+		RPDisplayName: name,
+		RPOrigins:     cfg.RPOrigins,
+		// 只接受 none 证明，不校验验证器型号
+		AttestationPreference: protocol.PreferNoAttestation,
+		Timeouts: webauthn.TimeoutsConfig{
+			Login:        webauthn.TimeoutConfig{Enforce: true, Timeout: timeout, TimeoutUVD: timeout},
+			Registration: webauthn.TimeoutConfig{Enforce: true, Timeout: timeout, TimeoutUVD: timeout},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("passkey config: %w", err)
+	}
+	return &Manager{wa: wa, store: store, timeout: timeout}, nil
+}
+
+// BeginRegistration 生成注册参数；排除用户已注册的凭证，避免同一验证器重复注册
+// 要求可发现凭证 (resident key)，注册后可免输账号直接登录
+func (m *Manager) BeginRegistration(ctx context.Context, user webauthn.User) (*protocol.CredentialCreation, string, error) {
+	requireResidentKey := true
+	creation, session, err := m.wa.BeginRegistration(user,
+		webauthn.WithExclusions(webauthn.Credentials(user.WebAuthnCredentials()).CredentialDescriptors()),
+		webauthn.WithAuthenticatorSelection(protocol.AuthenticatorSelection{
+			RequireResidentKey: &requireResidentKey,
+			ResidentKey:        protocol.ResidentKeyRequirementRequired,
+			UserVerification:   protocol.VerificationPreferred,
+		}),
+	)
+	if err != nil {
+		return nil, "", err
+	}
+	id, err := m.saveCeremony(ctx, kindRegistration, session)
+	if err != nil {
+		return nil, "", err
+	}
+	return creation, id, nil
+}
+
+// FinishRegistration 校验浏览器返回的注册结果，返回待保存的凭证
+func (m *Manager) FinishRegistration(ctx context.Context, user webauthn.User, ceremonyID string, response []byte) (*webauthn.Credential, error) {
+	session, err := m.takeCeremony(ctx, kindRegistration, ceremonyID)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(session.UserID, user.WebAuthnID()) {
+		return nil, ErrCeremonyInvalid
+	}
+	parsed, err := protocol.ParseCredentialCreationResponseBytes(response)
+	if err != nil {
+		return nil, verifyError(err)
+	}
+	credential, err := m.wa.CreateCredential(user, *session, parsed)
+	if err != nil {
+		return nil, verifyError(err)
+	}
+	return credential, nil
+}
+
+// BeginLogin 生成登录参数
+// user 为空时发起免账号登录 (由验证器选择凭证)，要求验证用户身份 (生物识别或 PIN)，可单独作为登录凭据；
+// 否则只允许该用户已注册的凭证，用于密码之后的二次验证
+func (m *Manager) BeginLogin(ctx context.Context, user webauthn.User) (*protocol.CredentialAssertion, string, error) {
+	var (
+		assertion *protocol.CredentialAssertion
+		session   *webauthn.SessionData
+		err       error
+	)
+	if user == nil {
+		assertion, session, err = m.wa.BeginDiscoverableLogin(webauthn.WithUserVerification(protocol.VerificationRequired))
+	} else {
+		assertion, session, err = m.wa.BeginLogin(user, webauthn.WithUserVerification(protocol.VerificationPreferred))
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	id, err := m.saveCeremony(ctx, kindLogin, session)
+	if err != nil {
+		return nil, "", err
+	}
+	return assertion, id, nil
+}
+
+// FinishLogin 校验浏览器返回的签名，返回登录用户与更新了签名计数的凭证
+// 签名计数回退时返回 ErrCloneDetected
+func (m *Manager) FinishLogin(ctx context.Context, ceremonyID string, response []byte, load UserLoader) (webauthn.User, *webauthn.Credential, error) {
+	session, err := m.takeCeremony(ctx, kindLogin, ceremonyID)
+	if err != nil {
+		return nil, nil, err
+	}
+	parsed, err := protocol.ParseCredentialRequestResponseBytes(response)
+	if err != nil {
+		return nil, nil, verifyError(err)
+	}
+
+	var (
+		user       webauthn.User
+		credential *webauthn.Credential
+	)
+	if len(session.UserID) == 0 {
+		user, credential, err = m.wa.ValidatePasskeyLogin(func(_, userHandle []byte) (webauthn.User, error) {
+			return load(userHandle)
+		}, *session, parsed)
+	} else {
+		if user, err = load(session.UserID); err != nil {
+			return nil, nil, verifyError(err)
+		}
+		credential, err = m.wa.ValidateLogin(user, *session, parsed)
+	}
+	if err != nil {
+		return nil, nil, verifyError(err)
+	}
+	if credential.Authenticator.CloneWarning {
+		return user, credential, ErrCloneDetected
+	}
+	return user, credential, nil
+}
+
+func (m *Manager) saveCeremony(ctx context.Context, kind string, session *webauthn.SessionData) (string, error) {
+	id, err := randomString()
+	if err != nil {
+		return "", err
+	}
+	raw, err := json.Marshal(ceremony{Kind: kind, Session: *session})
+	if err != nil {
+		return "", err
+	}
+	if err := m.store.Set(ctx, ceremonyKeyPrefix+id, string(raw), m.timeout); err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+// takeCeremony 读取并删除 ceremony，挑战只能使用一次
+func (m *Manager) takeCeremony(ctx context.Context, kind, id string) (*webauthn.SessionData, error) {
+	if id == "" {
+		return nil, ErrCeremonyInvalid
+	}
+	raw, err := m.store.GetDel(ctx, ceremonyKeyPrefix+id)
+	if err != nil {
+		if errors.Is(err, cache.ErrNotFound) {
+			return nil, ErrCeremonyInvalid
+		}
+		return nil, err
+	}
+	var c ceremony
+	if err := json.Unmarshal([]byte(raw), &c); err != nil || c.Kind != kind {
+		return nil, ErrCeremonyInvalid
+	}
+	return &c.Session, nil
+}
+
+// verifyError 保留协议层的错误详情便于排查，调用方用 errors.Is(err, ErrVerifyFailed) 判断
+func verifyError(err error) error {
+	var protoErr *protocol.Error
+	if errors.As(err, &protoErr) && protoErr.DevInfo != "" {
+		return fmt.Errorf("%w: %s (%s)", ErrVerifyFailed, protoErr.Details, protoErr.DevInfo)
+	}
+	return fmt.Errorf("%w: %v", ErrVerifyFailed, err)
+}
+
+func randomString() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
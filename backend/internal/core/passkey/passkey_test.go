@@ -0,0 +1,178 @@
+package passkey_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/CIPFZ/gowebframe/internal/core/cache"
+	"github.com/CIPFZ/gowebframe/internal/core/config"
+	"github.com/CIPFZ/gowebframe/internal/core/passkey"
+	"github.com/CIPFZ/gowebframe/internal/core/passkey/passkeytest"
+	"github.com/go-webauthn/webauthn/webauthn"
+)
+
+const origin = "https://app.local"
+
+type testUser struct {
+	id          []byte
+	credentials []webauthn.Credential
+}
+
+func (u *testUser) WebAuthnID() []byte                         { return u.id }
+func (u *testUser) WebAuthnName() string                       { return "alice" }
+func (u *testUser) WebAuthnDisplayName() string                { return "Alice" }
+func (u *testUser) WebAuthnCredentials() []webauthn.Credential { return u.credentials }
+
+func newTestManager(t *testing.T) *passkey.Manager {
+	t.Helper()
+	store := cache.NewMemoryStore()
+	t.Cleanup(func() { _ = store.Close(context.Background()) })
+	m, err := passkey.NewManager(config.Passkey{RPID: "app.local", RPOrigins: []string{origin}}, store)
+	if err != nil {
+		t.Fatalf("NewManager() error = %v", err)
+	}
+	return m
+}
+
+func register(t *testing.T, m *passkey.Manager, auth *passkeytest.Authenticator, user *testUser) {
+	t.Helper()
+	ctx := context.Background()
+	creation, id, err := m.BeginRegistration(ctx, user)
+	if err != nil {
+		t.Fatalf("BeginRegistration() error = %v", err)
+	}
+	body, err := auth.Register(creation)
+	if err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	cred, err := m.FinishRegistration(ctx, user, id, body)
+	if err != nil {
+		t.Fatalf("FinishRegistration() error = %v", err)
+	}
+	user.credentials = append(user.credentials, *cred)
+}
+
+func login(m *passkey.Manager, auth *passkeytest.Authenticator, user *testUser, discoverable bool) (*webauthn.Credential, error) {
+	ctx := context.Background()
+	var target webauthn.User
+	if !discoverable {
+		target = user
+	}
+	assertion, id, err := m.BeginLogin(ctx, target)
+	if err != nil {
+		return nil, err
+	}
+	body, err := auth.Login(assertion)
+	if err != nil {
+		return nil, err
+	}
+	_, cred, err := m.FinishLogin(ctx, id, body, func(handle []byte) (webauthn.User, error) {
+		if string(handle) != string(user.id) {
+			return nil, fmt.Errorf("unknown user handle %x", handle)
+		}
+		return user, nil
+	})
+	return cred, err
+}
+
+func TestManagerRegisterAndLogin(t *testing.T) {
+	m := newTestManager(t)
+	auth := passkeytest.New(origin)
+	user := &testUser{id: []byte("user-1")}
+	register(t, m, auth, user)
+
+	// 同一验证器不能重复注册
+	creation, _, err := m.BeginRegistration(context.Background(), user)
+	if err != nil {
+		t.Fatalf("BeginRegistration() error = %v", err)
+	}
+	if len(creation.Response.CredentialExcludeList) != 1 {
+		t.Fatalf("excludeCredentials = %d, want 1", len(creation.Response.CredentialExcludeList))
+	}
+	if _, err := auth.Register(creation); err == nil {
+		t.Fatal("Register() with excluded credential succeeded")
+	}
+
+	for _, discoverable := range []bool{true, false} {
+		cred, err := login(m, auth, user, discoverable)
+		if err != nil {
+			t.Fatalf("login(discoverable=%v) error = %v", discoverable, err)
+		}
+		if !cred.Flags.UserVerified || cred.Authenticator.SignCount == 0 {
+			t.Fatalf("login(discoverable=%v) credential = %+v", discoverable, cred)
+		}
+		user.credentials[0] = *cred
+	}
+	if got := user.credentials[0].Authenticator.SignCount; got != 2 {
+		t.Fatalf("sign count = %d, want 2", got)
+	}
+}
+
+func TestManagerRejectsInvalidAssertions(t *testing.T) {
+	m := newTestManager(t)
+	ctx := context.Background()
+	auth := passkeytest.New(origin)
+	user := &testUser{id: []byte("user-1")}
+	register(t, m, auth, user)
+
+	// 挑战只能使用一次
+	assertion, id, err := m.BeginLogin(ctx, nil)
+	if err != nil {
+		t.Fatalf("BeginLogin() error = %v", err)
+	}
+	body, _ := auth.Login(assertion)
+	load := func([]byte) (webauthn.User, error) { return user, nil }
+	if _, _, err := m.FinishLogin(ctx, id, body, load); err != nil {
+		t.Fatalf("FinishLogin() error = %v", err)
+	}
+	if _, _, err := m.FinishLogin(ctx, id, body, load); !errors.Is(err, passkey.ErrCeremonyInvalid) {
+		t.Fatalf("FinishLogin() replay error = %v, want %v", err, passkey.ErrCeremonyInvalid)
+	}
+
+	// 注册流程的 ID 不能用于登录
+	_, regID, err := m.BeginRegistration(ctx, &testUser{id: []byte("user-2")})
+	if err != nil {
+		t.Fatalf("BeginRegistration() error = %v", err)
+	}
+	if _, _, err := m.FinishLogin(ctx, regID, body, load); !errors.Is(err, passkey.ErrCeremonyInvalid) {
+		t.Fatalf("FinishLogin(registration id) error = %v, want %v", err, passkey.ErrCeremonyInvalid)
+	}
+
+	// 来源不在 rp_origins 中
+	phishing := auth.Clone()
+	phishing.Origin = "https://app.local.evil"
+	if _, err := login(m, phishing, user, true); !errors.Is(err, passkey.ErrVerifyFailed) {
+		t.Fatalf("login(foreign origin) error = %v, want %v", err, passkey.ErrVerifyFailed)
+	}
+
+	// 免账号登录要求验证用户身份
+	presenceOnly := auth.Clone()
+	presenceOnly.UserVerified = false
+	if _, err := login(m, presenceOnly, user, true); !errors.Is(err, passkey.ErrVerifyFailed) {
+		t.Fatalf("login(no user verification) error = %v, want %v", err, passkey.ErrVerifyFailed)
+	}
+	if _, err := login(m, presenceOnly, user, false); err != nil {
+		t.Fatalf("second factor login without user verification error = %v", err)
+	}
+}
+
+func TestManagerDetectsClonedAuthenticator(t *testing.T) {
+	m := newTestManager(t)
+	auth := passkeytest.New(origin)
+	user := &testUser{id: []byte("user-1")}
+	register(t, m, auth, user)
+	clone := auth.Clone()
+
+	cred, err := login(m, auth, user, true)
+	if err != nil {
+		t.Fatalf("login() error = %v", err)
+	}
+	user.credentials[0] = *cred
+
+	// 复制出的验证器计数器落后，签名计数不再递增
+	if _, err := login(m, clone, user, true); !errors.Is(err, passkey.ErrCloneDetected) {
+		t.Fatalf("login(clone) error = %v, want %v", err, passkey.ErrCloneDetected)
+	}
+}
@@ -0,0 +1,217 @@
+// Package passkeytest 软件实现的 WebAuthn 验证器，供单元测试模拟浏览器与安全密钥
+package passkeytest
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/protocol/webauthncbor"
+	"github.com/go-webauthn/webauthn/protocol/webauthncose"
+)
+
+// authenticator data 标志位
+const (
+	flagUserPresent  = 0x01
+	flagUserVerified = 0x04
+	flagAttestedData = 0x40
+)
+
+var b64 = base64.RawURLEncoding
+
+// Authenticator 使用 ES256 密钥与 none 证明的可发现凭证验证器
+type Authenticator struct {
+	// Origin 模拟的浏览器来源，写入 clientDataJSON
+	Origin string
+	// UserVerified 是否声明已验证用户身份 (生物识别或 PIN)
+	UserVerified bool
+
+	credentials []*credential
+}
+
+type credential struct {
+	id         []byte
+	rpID       string
+	userHandle []byte
+	key        *ecdsa.PrivateKey
+	counter    uint32
+}
+
+func New(origin string) *Authenticator {
+	return &Authenticator{Origin: origin, UserVerified: true}
+}
+
+// Clone 复制验证器及其全部凭证 (包括签名计数)，模拟私钥被导出到另一台设备
+func (a *Authenticator) Clone() *Authenticator {
+	c := &Authenticator{Origin: a.Origin, UserVerified: a.UserVerified}
+	for _, cred := range a.credentials {
+		cp := *cred
+		c.credentials = append(c.credentials, &cp)
+	}
+	return c
+}
+
+// Register 响应 navigator.credentials.create()，返回提交给服务端的 JSON
+func (a *Authenticator) Register(creation *protocol.CredentialCreation) ([]byte, error) {
+	opts := creation.Response
+	userHandle, err := userID(opts.User.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, excluded := range opts.CredentialExcludeList {
+		if a.find(opts.RelyingParty.ID, excluded.CredentialID) != nil {
+			return nil, errors.New("passkeytest: credential already registered")
+		}
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	cred := &credential{id: make([]byte, 32), rpID: opts.RelyingParty.ID, userHandle: userHandle, key: key}
+	if _, err := rand.Read(cred.id); err != nil {
+		return nil, err
+	}
+
+	publicKey, err := webauthncbor.Marshal(webauthncose.EC2PublicKeyData{
+		PublicKeyData: webauthncose.PublicKeyData{
+			KeyType:   int64(webauthncose.EllipticKey),
+			Algorithm: int64(webauthncose.AlgES256),
+		},
+		Curve:  int64(webauthncose.P256),
+		XCoord: key.X.FillBytes(make([]byte, 32)),
+		YCoord: key.Y.FillBytes(make([]byte, 32)),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// attestedCredentialData: aaguid(16) | credentialIdLength(2) | credentialId | credentialPublicKey
+	var attested bytes.Buffer
+	attested.Write(make([]byte, 16))
+	_ = binary.Write(&attested, binary.BigEndian, uint16(len(cred.id)))
+	attested.Write(cred.id)
+	attested.Write(publicKey)
+
+	authData := a.authData(cred, flagAttestedData, attested.Bytes())
+	attestation, err := webauthncbor.Marshal(map[string]any{
+		"fmt":      "none",
+		"attStmt":  map[string]any{},
+		"authData": authData,
+	})
+	if err != nil {
+		return nil, err
+	}
+	a.credentials = append(a.credentials, cred)
+
+	return json.Marshal(map[string]any{
+		"id":                      b64.EncodeToString(cred.id),
+		"rawId":                   b64.EncodeToString(cred.id),
+		"type":                    "public-key",
+		"authenticatorAttachment": "platform",
+		"response": map[string]any{
+			"clientDataJSON":    b64.EncodeToString(a.clientData("webauthn.create", opts.Challenge)),
+			"attestationObject": b64.EncodeToString(attestation),
+			"transports":        []string{"internal"},
+		},
+	})
+}
+
+// Login 响应 navigator.credentials.get()，返回提交给服务端的 JSON
+// 未指定 allowCredentials 时使用该站点最近注册的凭证
+func (a *Authenticator) Login(assertion *protocol.CredentialAssertion) ([]byte, error) {
+	opts := assertion.Response
+	var cred *credential
+	if len(opts.AllowedCredentials) == 0 {
+		for i := len(a.credentials) - 1; i >= 0; i-- {
+			if a.credentials[i].rpID == opts.RelyingPartyID {
+				cred = a.credentials[i]
+				break
+			}
+		}
+	} else {
+		for _, allowed := range opts.AllowedCredentials {
+			if cred = a.find(opts.RelyingPartyID, allowed.CredentialID); cred != nil {
+				break
+			}
+		}
+	}
+	if cred == nil {
+		return nil, errors.New("passkeytest: no credential for relying party")
+	}
+
+	cred.counter++
+	authData := a.authData(cred, 0, nil)
+	clientData := a.clientData("webauthn.get", opts.Challenge)
+	clientHash := sha256.Sum256(clientData)
+	digest := sha256.Sum256(append(append([]byte{}, authData...), clientHash[:]...))
+	signature, err := ecdsa.SignASN1(rand.Reader, cred.key, digest[:])
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(map[string]any{
+		"id":                      b64.EncodeToString(cred.id),
+		"rawId":                   b64.EncodeToString(cred.id),
+		"type":                    "public-key",
+		"authenticatorAttachment": "platform",
+		"response": map[string]any{
+			"clientDataJSON":    b64.EncodeToString(clientData),
+			"authenticatorData": b64.EncodeToString(authData),
+			"signature":         b64.EncodeToString(signature),
+			"userHandle":        b64.EncodeToString(cred.userHandle),
+		},
+	})
+}
+
+func (a *Authenticator) find(rpID string, id []byte) *credential {
+	for _, cred := range a.credentials {
+		if cred.rpID == rpID && bytes.Equal(cred.id, id) {
+			return cred
+		}
+	}
+	return nil
+}
+
+// authData: rpIdHash(32) | flags(1) | signCount(4) | attestedCredentialData
+func (a *Authenticator) authData(cred *credential, flags byte, attested []byte) []byte {
+	rpHash := sha256.Sum256([]byte(cred.rpID))
+	flags |= flagUserPresent
+	if a.UserVerified {
+		flags |= flagUserVerified
+	}
+	data := append(rpHash[:], flags)
+	data = binary.BigEndian.AppendUint32(data, cred.counter)
+	return append(data, attested...)
+}
+
+func (a *Authenticator) clientData(ceremony string, challenge protocol.URLEncodedBase64) []byte {
+	raw, _ := json.Marshal(map[string]any{
+		"type":        ceremony,
+		"challenge":   b64.EncodeToString(challenge),
+		"origin":      a.Origin,
+		"crossOrigin": false,
+	})
+	return raw
+}
+
+func userID(id any) ([]byte, error) {
+	switch v := id.(type) {
+	case protocol.URLEncodedBase64:
+		return v, nil
+	case []byte:
+		return v, nil
+	case string:
+		return b64.DecodeString(v)
+	default:
+		return nil, fmt.Errorf("passkeytest: unsupported user id %T", id)
+	}
+}
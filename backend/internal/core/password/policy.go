@@ -0,0 +1,189 @@
+// Package password 实现可配置的密码策略校验
+package password
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/CIPFZ/gowebframe/internal/core/config"
+)
+
+const (
+	defaultMinLength = 8
+	// bcrypt 只使用前 72 字节，更长的密码会被静默截断
+	defaultMaxLength = 72
+	// 与用户名的编辑距离不超过该值视为过于相似
+	similarityDistance = 2
+)
+
+var ErrWeakPassword = errors.New("密码不符合安全策略")
+
+// PolicyError 记录全部未满足的规则，便于前端一次性提示
+type PolicyError struct {
+	Reasons []string
+}
+
+func (e *PolicyError) Error() string {
+	return ErrWeakPassword.Error() + ": " + strings.Join(e.Reasons, "；")
+}
+
+func (e *PolicyError) Unwrap() error {
+	return ErrWeakPassword
+}
+
+// Policy 密码策略
+type Policy struct {
+	cfg     config.PasswordPolicy
+	blocked map[string]struct{}
+}
+
+func New(cfg config.PasswordPolicy) *Policy {
+	if cfg.MinLength <= 0 {
+		cfg.MinLength = defaultMinLength
+	}
+	if cfg.MaxLength <= 0 {
+		cfg.MaxLength = defaultMaxLength
+	}
+	blocked := make(map[string]struct{}, len(cfg.BlockedPasswords))
+	for _, p := range cfg.BlockedPasswords {
+		if p = strings.ToLower(strings.TrimSpace(p)); p != "" {
+			blocked[p] = struct{}{}
+		}
+	}
+	return &Policy{cfg: cfg, blocked: blocked}
+}
+
+// HistoryCount 禁止复用的历史密码个数
+func (p *Policy) HistoryCount() int {
+	return p.cfg.HistoryCount
+}
+
+// Validate 校验密码是否满足策略，不满足时返回 *PolicyError
+func (p *Policy) Validate(password, username string) error {
+	var reasons []string
+	length := utf8.RuneCountInString(password)
+	if length < p.cfg.MinLength {
+		reasons = append(reasons, fmt.Sprintf("长度至少 %d 位", p.cfg.MinLength))
+	}
+	if length > p.cfg.MaxLength || len(password) > defaultMaxLength {
+		reasons = append(reasons, fmt.Sprintf("长度不能超过 %d 位", min(p.cfg.MaxLength, defaultMaxLength)))
+	}
+
+	var upper, lower, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		case !unicode.IsSpace(r):
+			symbol = true
+		}
+	}
+	if p.cfg.RequireUpper && !upper {
+		reasons = append(reasons, "必须包含大写字母")
+	}
+	if p.cfg.RequireLower && !lower {
+		reasons = append(reasons, "必须包含小写字母")
+	}
+	if p.cfg.RequireDigit && !digit {
+		reasons = append(reasons, "必须包含数字")
+	}
+	if p.cfg.RequireSymbol && !symbol {
+		reasons = append(reasons, "必须包含特殊字符")
+	}
+	if classes := countTrue(upper, lower, digit, symbol); classes < p.cfg.MinClasses {
+		reasons = append(reasons, fmt.Sprintf("至少包含大写字母、小写字母、数字、特殊字符中的 %d 类", p.cfg.MinClasses))
+	}
+
+	if p.isBlocked(password) {
+		reasons = append(reasons, "密码过于常见")
+	}
+	if p.cfg.CheckUsername && similarToUsername(password, username) {
+		reasons = append(reasons, "不能包含用户名或与用户名相似")
+	}
+
+	if len(reasons) > 0 {
+		return &PolicyError{Reasons: reasons}
+	}
+	return nil
+}
+
+// Expired 判断密码是否超过有效期；changedAt 为零值时视为未过期
+func (p *Policy) Expired(changedAt, now time.Time) bool {
+	if p.cfg.MaxAgeDays <= 0 || changedAt.IsZero() {
+		return false
+	}
+	return now.Sub(changedAt) > time.Duration(p.cfg.MaxAgeDays)*24*time.Hour
+}
+
+// isBlocked 同时比较去掉首尾数字与符号后的词根，拦截 Password123! 这类变体
+func (p *Policy) isBlocked(password string) bool {
+	if len(p.blocked) == 0 {
+		return false
+	}
+	lowered := strings.ToLower(password)
+	if _, ok := p.blocked[lowered]; ok {
+		return true
+	}
+	root := strings.TrimFunc(lowered, func(r rune) bool { return !unicode.IsLetter(r) })
+	_, ok := p.blocked[root]
+	return ok && root != ""
+}
+
+func similarToUsername(password, username string) bool {
+	pw := strings.ToLower(password)
+	name := strings.ToLower(strings.TrimSpace(username))
+	if utf8.RuneCountInString(name) < 3 {
+		return false
+	}
+	if strings.Contains(pw, name) || strings.Contains(pw, reverse(name)) {
+		return true
+	}
+	return levenshtein(pw, name) <= similarityDistance
+}
+
+func countTrue(values ...bool) int {
+	n := 0
+	for _, v := range values {
+		if v {
+			n++
+		}
+	}
+	return n
+}
+
+func reverse(s string) string {
+	r := []rune(s)
+	for i, j := 0, len(r)-1; i < j; i, j = i+1, j-1 {
+		r[i], r[j] = r[j], r[i]
+	}
+	return string(r)
+}
+
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
@@ -0,0 +1,78 @@
+package password
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/CIPFZ/gowebframe/internal/core/config"
+)
+
+func TestPolicyValidate(t *testing.T) {
+	policy := New(config.PasswordPolicy{
+		MinLength:        10,
+		RequireDigit:     true,
+		MinClasses:       3,
+		CheckUsername:    true,
+		BlockedPasswords: []string{"password", "Qwerty"},
+	})
+
+	tests := []struct {
+		name     string
+		password string
+		reasons  []string
+	}{
+		{name: "valid", password: "Tr0ub4dor&3x"},
+		{name: "too short", password: "Ab1!", reasons: []string{"长度至少 10 位"}},
+		{name: "too long", password: "Aa1!" + strings.Repeat("x", 80), reasons: []string{"长度不能超过 72 位"}},
+		{name: "missing classes", password: "abcdefghijk", reasons: []string{"必须包含数字", "中的 3 类"}},
+		{name: "blocked exact", password: "qwerty", reasons: []string{"密码过于常见"}},
+		{name: "blocked variant", password: "Password123!", reasons: []string{"密码过于常见"}},
+		{name: "contains username", password: "xAlice2024!", reasons: []string{"用户名"}},
+		{name: "reversed username", password: "Ecila#2024x", reasons: []string{"用户名"}},
+		{name: "similar username", password: "al1ce", reasons: []string{"用户名"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := policy.Validate(tt.password, "alice")
+			if len(tt.reasons) == 0 {
+				if err != nil {
+					t.Fatalf("Validate() error = %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrWeakPassword) {
+				t.Fatalf("Validate() error = %v, want %v", err, ErrWeakPassword)
+			}
+			for _, reason := range tt.reasons {
+				if !strings.Contains(err.Error(), reason) {
+					t.Errorf("Validate() error = %q, want reason %q", err, reason)
+				}
+			}
+		})
+	}
+}
+
+func TestPolicyDefaultsAndExpiry(t *testing.T) {
+	policy := New(config.PasswordPolicy{})
+	if err := policy.Validate("1234567", "bob"); !errors.Is(err, ErrWeakPassword) {
+		t.Fatalf("Validate() short password error = %v, want default min length", err)
+	}
+	// 未开启用户名检查时不拦截
+	if err := policy.Validate("alice123", "alice"); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+
+	now := time.Now()
+	if policy.Expired(now.AddDate(-10, 0, 0), now) {
+		t.Fatal("Expired() = true without max_age_days")
+	}
+	policy = New(config.PasswordPolicy{MaxAgeDays: 90})
+	if !policy.Expired(now.AddDate(0, 0, -91), now) || policy.Expired(now.AddDate(0, 0, -89), now) {
+		t.Fatal("Expired() does not honour max_age_days")
+	}
+	if policy.Expired(time.Time{}, now) {
+		t.Fatal("Expired() = true for unknown change time")
+	}
+}
@@ -1,6 +1,7 @@
 package server
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"strings"
@@ -25,10 +26,16 @@ import (
 	"github.com/gin-gonic/gin"
 	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 	"go.opentelemetry.io/otel"
+	"go.uber.org/zap"
 )
 
 func InitRouters(svcCtx *svc.ServiceContext) *gin.Engine {
 	r := gin.New()
+	// 只采信可信代理转发的客户端 IP；配置有误时退回到不采信任何代理
+	if err := r.SetTrustedProxies(svcCtx.Config.System.TrustedProxies); err != nil {
+		svcCtx.Logger.Error("invalid trusted proxies, falling back to remote address", zap.Error(err))
+		_ = r.SetTrustedProxies(nil)
+	}
 	r.Use(gin.Recovery())
 
 	registerGlobalMiddleware(r, svcCtx)
@@ -37,14 +44,20 @@ func InitRouters(svcCtx *svc.ServiceContext) *gin.Engine {
 	routerPrefix := svcCtx.Config.System.RouterPrefix
 	publicGroup := r.Group(routerPrefix)
 	privateGroup := r.Group(routerPrefix)
+	// 登录用户、API Token (明文或签名请求) 与 OAuth2 客户端均可访问的接口
 	apiTokenGroup := r.Group(routerPrefix)
 	privateGroup.Use(middleware.JWTAuth(svcCtx), middleware.CasbinHandler(svcCtx))
-	apiTokenGroup.Use(middleware.ApiTokenAuth(svcCtx))
+	apiTokenGroup.Use(middleware.Authenticate(
+		middleware.NewJWTAuthenticator(svcCtx),
+		middleware.NewAPITokenAuthenticator(svcCtx),
+		middleware.NewHMACAuthenticator(svcCtx),
+		middleware.NewOAuthAuthenticator(svcCtx),
+	), middleware.CasbinHandler(svcCtx))
 
 	sysRouter := wireSystemModule(svcCtx)
 	sysRouter.InitSystemRoutes(privateGroup, publicGroup)
 	wirePluginModule(svcCtx).InitPluginRoutes(privateGroup, publicGroup)
-	wirePoetryModule(svcCtx).InitPoetryRoutes(privateGroup, publicGroup, apiTokenGroup)
+	wirePoetryModule(svcCtx).InitPoetryRoutes(privateGroup, apiTokenGroup)
 
 	svcCtx.Routers = r.Routes()
 	svcCtx.Logger.Info("all routes initialized")
@@ -90,6 +103,12 @@ func registerBaseRoutes(r *gin.Engine, svcCtx *svc.ServiceContext) {
 	r.GET("/health", func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{"status": "ok", "time": time.Now().Unix()})
 	})
+
+	// 公开 JWT 校验公钥，兄弟服务可据此离线校验 access token
+	r.GET("/.well-known/jwks.json", func(c *gin.Context) {
+		c.Header("Cache-Control", "public, max-age=300")
+		c.JSON(http.StatusOK, svcCtx.JWT.JWKS())
+	})
 }
 
 func swaggerIndexHTML(docURL string) string {
@@ -122,18 +141,35 @@ func wireSystemModule(svcCtx *svc.ServiceContext) *systemRouter.SystemRouter {
 	authRepo := systemRepo.NewAuthorityRepository(svcCtx.DB)
 	apiRepo := systemRepo.NewApiRepository(svcCtx.DB)
 	apiTokenRepo := systemRepo.NewApiTokenRepository(svcCtx.DB)
+	oauthClientRepo := systemRepo.NewOauthClientRepository(svcCtx.DB)
 	casbinRepo := systemRepo.NewCasbinRepository(svcCtx.CasbinEnforcer)
 	opLogRepo := systemRepo.NewOperationLogRepository(svcCtx.DB)
 	noticeRepo := systemRepo.NewNoticeRepository(svcCtx.DB)
+	refreshRepo := systemRepo.NewRefreshTokenRepository(svcCtx.DB)
+	mfaRepo := systemRepo.NewMfaRepository(svcCtx.DB)
+	identityRepo := systemRepo.NewUserIdentityRepository(svcCtx.DB)
+	resetRepo := systemRepo.NewPasswordResetRepository(svcCtx.DB)
+	regRepo := systemRepo.NewRegistrationRepository(svcCtx.DB)
+	loginLogRepo := systemRepo.NewLoginLogRepository(svcCtx.DB)
+	passkeyRepo := systemRepo.NewPasskeyRepository(svcCtx.DB)
 
 	opLogService := systemService.NewOperationLogService(svcCtx, opLogRepo)
-	userService := systemService.NewUserService(svcCtx, userRepo)
+	userService := systemService.NewUserService(svcCtx, userRepo, refreshRepo, mfaRepo, identityRepo, resetRepo, regRepo, noticeRepo, loginLogRepo, passkeyRepo)
 	menuService := systemService.NewMenuService(svcCtx, menuRepo)
 	authService := systemService.NewAuthorityService(svcCtx, authRepo)
 	apiService := systemService.NewApiService(svcCtx, apiRepo)
 	apiTokenService := systemService.NewApiTokenService(svcCtx, apiTokenRepo)
+	oauthClientService := systemService.NewOauthClientService(svcCtx, oauthClientRepo)
 	casbinService := systemService.NewCasbinService(svcCtx, casbinRepo)
 	noticeService := systemService.NewNoticeService(svcCtx, noticeRepo)
+	loginLogService := systemService.NewLoginLogService(svcCtx, loginLogRepo)
+
+	if svcCtx.LDAP != nil {
+		svcCtx.LDAP.StartSync(func(ctx context.Context) error {
+			_, err := userService.SyncLdapGroups(ctx)
+			return err
+		})
+	}
 
 	apis := &systemRouter.SystemApis{
 		UserApi:      systemApi.NewUserApi(svcCtx, userService),
@@ -141,11 +177,13 @@ func wireSystemModule(svcCtx *svc.ServiceContext) *systemRouter.SystemRouter {
 		AuthorityApi: systemApi.NewAuthorityApi(svcCtx, authService),
 		SysApiApi:    systemApi.NewSysApiApi(svcCtx, apiService),
 		ApiTokenApi:  systemApi.NewApiTokenApi(svcCtx, apiTokenService),
+		OauthApi:     systemApi.NewOauthClientApi(svcCtx, oauthClientService),
 		CasbinApi:    systemApi.NewCasbinApi(svcCtx, casbinService),
 		OpLogApi:     systemApi.NewOperationLogApi(svcCtx, opLogService),
 		FileApi:      systemApi.NewFileApi(svcCtx),
 		StateApi:     systemApi.NewStateApi(svcCtx),
 		NoticeApi:    systemApi.NewNoticeApi(svcCtx, noticeService),
+		LoginLogApi:  systemApi.NewLoginLogApi(svcCtx, loginLogService),
 	}
 
 	return systemRouter.NewSystemRouter(svcCtx, apis)
@@ -0,0 +1,103 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/CIPFZ/gowebframe/internal/modules/system/model"
+	"gorm.io/gorm"
+)
+
+// GormStore 基于数据库的会话存储
+type GormStore struct {
+	db *gorm.DB
+}
+
+func NewGormStore(db *gorm.DB) *GormStore {
+	return &GormStore{db: db}
+}
+
+func (s *GormStore) Save(ctx context.Context, sess *Session) error {
+	db := s.db.WithContext(ctx)
+	// 顺带清理该用户已过期的会话，避免表无限增长
+	if err := db.Where("user_id = ? AND expires_at < ?", sess.UserID, time.Now()).
+		Delete(&model.SysSession{}).Error; err != nil {
+		return err
+	}
+	return db.Save(toModel(sess)).Error
+}
+
+func (s *GormStore) Get(ctx context.Context, id string) (*Session, error) {
+	var row model.SysSession
+	err := s.db.WithContext(ctx).
+		Where("session_id = ? AND expires_at > ?", id, time.Now()).
+		First(&row).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSessionNotFound
+		}
+		return nil, err
+	}
+	return fromModel(&row), nil
+}
+
+func (s *GormStore) ListByUser(ctx context.Context, userID uint) ([]Session, error) {
+	var rows []model.SysSession
+	err := s.db.WithContext(ctx).
+		Where("user_id = ? AND expires_at > ?", userID, time.Now()).
+		Order("last_seen_at DESC").
+		Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	list := make([]Session, 0, len(rows))
+	for i := range rows {
+		list = append(list, *fromModel(&rows[i]))
+	}
+	return list, nil
+}
+
+func (s *GormStore) Touch(ctx context.Context, id string, at time.Time) error {
+	return s.db.WithContext(ctx).
+		Model(&model.SysSession{}).
+		Where("session_id = ?", id).
+		Update("last_seen_at", at).Error
+}
+
+func (s *GormStore) Delete(ctx context.Context, ids ...string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return s.db.WithContext(ctx).Where("session_id IN ?", ids).Delete(&model.SysSession{}).Error
+}
+
+func toModel(s *Session) *model.SysSession {
+	return &model.SysSession{
+		SessionID:  s.ID,
+		UserID:     s.UserID,
+		Device:     s.Device,
+		IP:         s.IP,
+		UserAgent:  s.UserAgent,
+		CreatedAt:  s.CreatedAt,
+		LastSeenAt: s.LastSeenAt,
+		ExpiresAt:  s.ExpiresAt,
+
+		ImpersonatorID: s.ImpersonatorID,
+	}
+}
+
+func fromModel(m *model.SysSession) *Session {
+	return &Session{
+		ID:         m.SessionID,
+		UserID:     m.UserID,
+		Device:     m.Device,
+		IP:         m.IP,
+		UserAgent:  m.UserAgent,
+		CreatedAt:  m.CreatedAt,
+		LastSeenAt: m.LastSeenAt,
+		ExpiresAt:  m.ExpiresAt,
+
+		ImpersonatorID: m.ImpersonatorID,
+	}
+}
@@ -0,0 +1,141 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	redisSessionPrefix = "session:"
+	redisUserPrefix    = "session_user:"
+)
+
+// RedisStore 基于 Redis 的会话存储
+// session:<id>        -> 会话 JSON，TTL 与会话过期时间一致
+// session_user:<uid>  -> 用户的会话 ID 集合
+type RedisStore struct {
+	rdb redis.UniversalClient
+}
+
+func NewRedisStore(rdb redis.UniversalClient) *RedisStore {
+	return &RedisStore{rdb: rdb}
+}
+
+func (s *RedisStore) Save(ctx context.Context, sess *Session) error {
+	ttl := time.Until(sess.ExpiresAt)
+	if ttl <= 0 {
+		return nil
+	}
+	data, err := json.Marshal(sess)
+	if err != nil {
+		return err
+	}
+
+	userKey := userSetKey(sess.UserID)
+	// 模拟登录会话有效期较短，集合过期时间只延长不缩短，避免提前丢失正常会话的索引
+	current, err := s.rdb.TTL(ctx, userKey).Result()
+	if err != nil {
+		return err
+	}
+	pipe := s.rdb.TxPipeline()
+	pipe.Set(ctx, redisSessionPrefix+sess.ID, data, ttl)
+	pipe.SAdd(ctx, userKey, sess.ID)
+	if current < ttl {
+		pipe.Expire(ctx, userKey, ttl)
+	}
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+func (s *RedisStore) Get(ctx context.Context, id string) (*Session, error) {
+	data, err := s.rdb.Get(ctx, redisSessionPrefix+id).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, ErrSessionNotFound
+		}
+		return nil, err
+	}
+	var sess Session
+	if err := json.Unmarshal(data, &sess); err != nil {
+		return nil, err
+	}
+	return &sess, nil
+}
+
+func (s *RedisStore) ListByUser(ctx context.Context, userID uint) ([]Session, error) {
+	userKey := userSetKey(userID)
+	ids, err := s.rdb.SMembers(ctx, userKey).Result()
+	if err != nil || len(ids) == 0 {
+		return []Session{}, err
+	}
+
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = redisSessionPrefix + id
+	}
+	values, err := s.rdb.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]Session, 0, len(values))
+	var stale []interface{}
+	for i, v := range values {
+		str, ok := v.(string)
+		if !ok {
+			stale = append(stale, ids[i])
+			continue
+		}
+		var sess Session
+		if err := json.Unmarshal([]byte(str), &sess); err != nil {
+			stale = append(stale, ids[i])
+			continue
+		}
+		list = append(list, sess)
+	}
+	if len(stale) > 0 {
+		_ = s.rdb.SRem(ctx, userKey, stale...).Err()
+	}
+	return list, nil
+}
+
+func (s *RedisStore) Touch(ctx context.Context, id string, at time.Time) error {
+	sess, err := s.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	sess.LastSeenAt = at
+	data, err := json.Marshal(sess)
+	if err != nil {
+		return err
+	}
+	return s.rdb.SetArgs(ctx, redisSessionPrefix+id, data, redis.SetArgs{KeepTTL: true, Mode: "XX"}).Err()
+}
+
+func (s *RedisStore) Delete(ctx context.Context, ids ...string) error {
+	for _, id := range ids {
+		sess, err := s.Get(ctx, id)
+		if errors.Is(err, ErrSessionNotFound) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		pipe := s.rdb.TxPipeline()
+		pipe.Del(ctx, redisSessionPrefix+id)
+		pipe.SRem(ctx, userSetKey(sess.UserID), id)
+		if _, err := pipe.Exec(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func userSetKey(userID uint) string {
+	return redisUserPrefix + strconv.FormatUint(uint64(userID), 10)
+}
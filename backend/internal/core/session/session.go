@@ -0,0 +1,119 @@
+package session
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+var ErrSessionNotFound = errors.New("会话不存在或已失效")
+
+// Session 一次登录对应的服务端会话
+// ID 与该次登录的 refresh token 令牌族 ID 一致，吊销会话时同时吊销令牌族
+type Session struct {
+	ID         string    `json:"id"`
+	UserID     uint      `json:"userId"`
+	Device     string    `json:"device"`
+	IP         string    `json:"ip"`
+	UserAgent  string    `json:"userAgent"`
+	CreatedAt  time.Time `json:"createdAt"`
+	LastSeenAt time.Time `json:"lastSeenAt"`
+	ExpiresAt  time.Time `json:"expiresAt"`
+	// ImpersonatorID 管理员模拟登录产生的会话记录发起人，普通登录为 0
+	ImpersonatorID uint `json:"impersonatorId,omitempty"`
+}
+
+// Store 会话存储
+type Store interface {
+	// Save 新建或覆盖会话
+	Save(ctx context.Context, s *Session) error
+	// Get 获取未过期的会话，不存在时返回 ErrSessionNotFound
+	Get(ctx context.Context, id string) (*Session, error)
+	// ListByUser 列出用户所有未过期会话
+	ListByUser(ctx context.Context, userID uint) ([]Session, error)
+	// Touch 更新最后活跃时间
+	Touch(ctx context.Context, id string, at time.Time) error
+	// Delete 删除 (吊销) 会话
+	Delete(ctx context.Context, ids ...string) error
+}
+
+// NewStore 启用 Redis 时使用 Redis 存储，否则落库
+func NewStore(db *gorm.DB, rdb redis.UniversalClient) Store {
+	if rdb != nil {
+		return NewRedisStore(rdb)
+	}
+	return NewGormStore(db)
+}
+
+// DeviceFromUserAgent 从 User-Agent 中粗略提取 "浏览器 on 系统" 形式的设备描述
+func DeviceFromUserAgent(ua string) string {
+	if ua == "" {
+		return "Unknown"
+	}
+
+	var os string
+	switch {
+	case strings.Contains(ua, "Windows"):
+		os = "Windows"
+	case strings.Contains(ua, "iPhone"), strings.Contains(ua, "iPad"):
+		os = "iOS"
+	case strings.Contains(ua, "Mac OS X"), strings.Contains(ua, "Macintosh"):
+		os = "macOS"
+	case strings.Contains(ua, "Android"):
+		os = "Android"
+	case strings.Contains(ua, "Linux"):
+		os = "Linux"
+	}
+
+	var browser string
+	switch {
+	case strings.Contains(ua, "Edg/"):
+		browser = "Edge"
+	case strings.Contains(ua, "Firefox/"):
+		browser = "Firefox"
+	case strings.Contains(ua, "Chrome/"):
+		browser = "Chrome"
+	case strings.Contains(ua, "Safari/"):
+		browser = "Safari"
+	}
+
+	switch {
+	case browser != "" && os != "":
+		return browser + " on " + os
+	case browser != "":
+		return browser
+	case os != "":
+		return os
+	}
+
+	// 非浏览器客户端 (curl、SDK 等) 取产品名
+	if i := strings.IndexAny(ua, "/ "); i > 0 {
+		return ua[:i]
+	}
+	return ua
+}
+
+// DeviceFingerprint 设备指纹：按解析出的 "浏览器 on 系统" 计算，浏览器小版本升级不会产生新指纹
+func DeviceFingerprint(ua string) string {
+	sum := sha256.Sum256([]byte(DeviceFromUserAgent(ua)))
+	return hex.EncodeToString(sum[:8])
+}
+
+// IPRange 返回 IP 所在网段 (IPv4 /24、IPv6 /64)，用于判断是否为常用登录网络；无法解析时原样返回
+func IPRange(ip string) string {
+	parsed := net.ParseIP(strings.TrimSpace(ip))
+	if parsed == nil {
+		return ip
+	}
+	if v4 := parsed.To4(); v4 != nil {
+		return (&net.IPNet{IP: v4.Mask(net.CIDRMask(24, 32)), Mask: net.CIDRMask(24, 32)}).String()
+	}
+	return (&net.IPNet{IP: parsed.Mask(net.CIDRMask(64, 128)), Mask: net.CIDRMask(64, 128)}).String()
+}
@@ -0,0 +1,153 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/CIPFZ/gowebframe/internal/core/config"
+	"github.com/CIPFZ/gowebframe/internal/core/db"
+	"github.com/CIPFZ/gowebframe/internal/modules/system/model"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+func TestStores(t *testing.T) {
+	for name, newStore := range map[string]func(t *testing.T) Store{
+		"gorm":  newTestGormStore,
+		"redis": newTestRedisStore,
+	} {
+		t.Run(name, func(t *testing.T) {
+			exerciseStore(t, newStore(t))
+		})
+	}
+}
+
+func exerciseStore(t *testing.T, store Store) {
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Second)
+
+	for _, id := range []string{"s1", "s2"} {
+		if err := store.Save(ctx, &Session{
+			ID:         id,
+			UserID:     1,
+			Device:     "Chrome on Linux",
+			IP:         "127.0.0.1",
+			CreatedAt:  now,
+			LastSeenAt: now,
+			ExpiresAt:  now.Add(time.Hour),
+		}); err != nil {
+			t.Fatalf("Save() error = %v", err)
+		}
+	}
+
+	got, err := store.Get(ctx, "s1")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if got.UserID != 1 || got.Device != "Chrome on Linux" {
+		t.Fatalf("Get() = %#v, want saved session", got)
+	}
+
+	later := now.Add(5 * time.Minute)
+	if err := store.Touch(ctx, "s1", later); err != nil {
+		t.Fatalf("Touch() error = %v", err)
+	}
+	if got, _ = store.Get(ctx, "s1"); !got.LastSeenAt.Equal(later) {
+		t.Fatalf("Get().LastSeenAt = %v, want %v", got.LastSeenAt, later)
+	}
+
+	list, err := store.ListByUser(ctx, 1)
+	if err != nil {
+		t.Fatalf("ListByUser() error = %v", err)
+	}
+	if len(list) != 2 {
+		t.Fatalf("ListByUser() len = %d, want 2", len(list))
+	}
+
+	if err := store.Delete(ctx, "s1"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := store.Get(ctx, "s1"); !errors.Is(err, ErrSessionNotFound) {
+		t.Fatalf("Get() after Delete error = %v, want %v", err, ErrSessionNotFound)
+	}
+	if list, _ = store.ListByUser(ctx, 1); len(list) != 1 || list[0].ID != "s2" {
+		t.Fatalf("ListByUser() after Delete = %#v, want only s2", list)
+	}
+}
+
+func TestDeviceFromUserAgent(t *testing.T) {
+	cases := map[string]string{
+		"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0 Safari/537.36": "Chrome on Windows",
+		"Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X) AppleWebKit/605.1.15 Version/17.0 Safari/604.1":       "Safari on iOS",
+		"curl/8.4.0": "curl",
+		"":           "Unknown",
+	}
+	for ua, want := range cases {
+		if got := DeviceFromUserAgent(ua); got != want {
+			t.Fatalf("DeviceFromUserAgent(%q) = %q, want %q", ua, got, want)
+		}
+	}
+}
+
+func TestDeviceFingerprintAndIPRange(t *testing.T) {
+	chrome120 := "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0 Safari/537.36"
+	chrome121 := "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/121.0 Safari/537.36"
+	if DeviceFingerprint(chrome120) != DeviceFingerprint(chrome121) {
+		t.Fatal("DeviceFingerprint() changed after browser upgrade")
+	}
+	if DeviceFingerprint(chrome120) == DeviceFingerprint("curl/8.4.0") {
+		t.Fatal("DeviceFingerprint() collided for different devices")
+	}
+
+	cases := map[string]string{
+		"192.168.1.23":      "192.168.1.0/24",
+		"2001:db8:1:2:3::4": "2001:db8:1:2::/64",
+		"::ffff:10.0.0.8":   "10.0.0.0/24",
+		"not-an-ip":         "not-an-ip",
+	}
+	for ip, want := range cases {
+		if got := IPRange(ip); got != want {
+			t.Fatalf("IPRange(%q) = %q, want %q", ip, got, want)
+		}
+	}
+}
+
+func newTestGormStore(t *testing.T) Store {
+	t.Helper()
+	gormDB, err := db.InitDatabase(config.Database{
+		Driver: "sqlite3",
+		SQLite: config.SQLite{
+			Path:         filepath.Join(t.TempDir(), "session.db"),
+			MaxIdleConns: 1,
+			MaxOpenConns: 1,
+		},
+	}, zap.NewNop())
+	if err != nil {
+		t.Fatalf("InitDatabase() error = %v", err)
+	}
+	if err := gormDB.AutoMigrate(&model.SysSession{}); err != nil {
+		t.Fatalf("AutoMigrate() error = %v", err)
+	}
+	sqlDB, err := gormDB.DB()
+	if err != nil {
+		t.Fatalf("DB() error = %v", err)
+	}
+	t.Cleanup(func() {
+		_ = sqlDB.Close()
+	})
+	return NewGormStore(gormDB)
+}
+
+func newTestRedisStore(t *testing.T) Store {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() {
+		_ = rdb.Close()
+	})
+	return NewRedisStore(rdb)
+}
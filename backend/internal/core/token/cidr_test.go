@@ -0,0 +1,37 @@
+package token
+
+import "testing"
+
+func TestParseAllowedIPsAndMatch(t *testing.T) {
+	prefixes, err := ParseAllowedIPs([]string{" 10.1.2.3/8 ", "192.0.2.7", "2001:db8::/32", ""})
+	if err != nil {
+		t.Fatalf("ParseAllowedIPs() error = %v", err)
+	}
+	if len(prefixes) != 3 || prefixes[0].String() != "10.0.0.0/8" || prefixes[1].String() != "192.0.2.7/32" {
+		t.Fatalf("ParseAllowedIPs() = %v, want normalized prefixes", prefixes)
+	}
+
+	for ip, want := range map[string]bool{
+		"10.200.0.1":      true,
+		"::ffff:10.0.0.1": true,
+		"192.0.2.7":       true,
+		"192.0.2.8":       false,
+		"2001:db8::1":     true,
+		"2001:db9::1":     false,
+		"not-an-ip":       false,
+	} {
+		if got := IPAllowed(prefixes, ip); got != want {
+			t.Errorf("IPAllowed(%q) = %v, want %v", ip, got, want)
+		}
+	}
+	if !IPAllowed(nil, "203.0.113.1") {
+		t.Fatal("IPAllowed() without ranges = false, want true")
+	}
+
+	if _, err := ParseAllowedIPs([]string{"10.0.0.0/33"}); err == nil {
+		t.Fatal("ParseAllowedIPs() with invalid cidr error = nil, want error")
+	}
+	if _, err := ParseAllowedIPs([]string{"example.com"}); err == nil {
+		t.Fatal("ParseAllowedIPs() with hostname error = nil, want error")
+	}
+}
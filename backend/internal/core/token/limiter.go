@@ -1,7 +1,28 @@
 package token
 
-import "sync"
+import (
+	"context"
+	"sync"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Limiter 按 API Token 限制同时处理中的请求数 (SysApiToken.MaxConcurrency)
+type Limiter interface {
+	// Acquire 占用一个并发名额；已达上限时 ok 为 false
+	// 成功时返回的 release 必须在请求结束后调用，且只能调用一次
+	Acquire(ctx context.Context, tokenID uint, maxConcurrency int) (release func(), ok bool, err error)
+}
+
+// NewLimiter 启用 Redis 时多实例共享并发名额，否则只在进程内计数
+func NewLimiter(rdb redis.UniversalClient) Limiter {
+	if rdb != nil {
+		return NewRedisLimiter(rdb)
+	}
+	return NewInMemoryLimiter()
+}
 
+// InMemoryLimiter 进程内计数，多实例部署时每个实例各自计算上限
 type InMemoryLimiter struct {
 	mu     sync.Mutex
 	counts map[uint]int
@@ -13,7 +34,7 @@ func NewInMemoryLimiter() *InMemoryLimiter {
 	}
 }
 
-func (l *InMemoryLimiter) Acquire(tokenID uint, maxConcurrency int) bool {
+func (l *InMemoryLimiter) Acquire(_ context.Context, tokenID uint, maxConcurrency int) (func(), bool, error) {
 	if maxConcurrency <= 0 {
 		maxConcurrency = 1
 	}
@@ -22,13 +43,14 @@ func (l *InMemoryLimiter) Acquire(tokenID uint, maxConcurrency int) bool {
 	defer l.mu.Unlock()
 
 	if l.counts[tokenID] >= maxConcurrency {
-		return false
+		return nil, false, nil
 	}
 	l.counts[tokenID]++
-	return true
+	var once sync.Once
+	return func() { once.Do(func() { l.release(tokenID) }) }, true, nil
 }
 
-func (l *InMemoryLimiter) Release(tokenID uint) {
+func (l *InMemoryLimiter) release(tokenID uint) {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
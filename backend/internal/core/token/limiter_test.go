@@ -0,0 +1,136 @@
+package token
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func newTestRedis(t *testing.T) redis.UniversalClient {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = rdb.Close() })
+	return rdb
+}
+
+func TestLimiters(t *testing.T) {
+	for name, limiter := range map[string]Limiter{
+		"memory": NewInMemoryLimiter(),
+		"redis":  NewRedisLimiter(newTestRedis(t)),
+	} {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+
+			first, ok, err := limiter.Acquire(ctx, 1, 2)
+			if err != nil || !ok {
+				t.Fatalf("Acquire() first = %v, %v, want ok", ok, err)
+			}
+			second, ok, err := limiter.Acquire(ctx, 1, 2)
+			if err != nil || !ok {
+				t.Fatalf("Acquire() second = %v, %v, want ok", ok, err)
+			}
+			if _, ok, err := limiter.Acquire(ctx, 1, 2); err != nil || ok {
+				t.Fatalf("Acquire() over limit = %v, %v, want rejected", ok, err)
+			}
+			// 名额按令牌分别计算
+			other, ok, err := limiter.Acquire(ctx, 2, 1)
+			if err != nil || !ok {
+				t.Fatalf("Acquire() other token = %v, %v, want ok", ok, err)
+			}
+			other()
+
+			// 重复释放不会多归还名额
+			first()
+			first()
+			third, ok, err := limiter.Acquire(ctx, 1, 2)
+			if err != nil || !ok {
+				t.Fatalf("Acquire() after release = %v, %v, want ok", ok, err)
+			}
+			if _, ok, err := limiter.Acquire(ctx, 1, 2); err != nil || ok {
+				t.Fatalf("Acquire() after double release = %v, %v, want rejected", ok, err)
+			}
+			second()
+			third()
+
+			// 未设置上限时按 1 处理
+			release, ok, err := limiter.Acquire(ctx, 3, 0)
+			if err != nil || !ok {
+				t.Fatalf("Acquire() zero limit = %v, %v, want ok", ok, err)
+			}
+			if _, ok, _ := limiter.Acquire(ctx, 3, 0); ok {
+				t.Fatal("Acquire() zero limit second = true, want rejected")
+			}
+			release()
+		})
+	}
+}
+
+func TestRedisLimiterSharedAcrossInstances(t *testing.T) {
+	rdb := newTestRedis(t)
+	podA, podB := NewRedisLimiter(rdb), NewRedisLimiter(rdb)
+	ctx := context.Background()
+
+	release, ok, err := podA.Acquire(ctx, 1, 1)
+	if err != nil || !ok {
+		t.Fatalf("podA.Acquire() = %v, %v, want ok", ok, err)
+	}
+	if _, ok, err := podB.Acquire(ctx, 1, 1); err != nil || ok {
+		t.Fatalf("podB.Acquire() = %v, %v, want rejected while podA holds the slot", ok, err)
+	}
+	release()
+	releaseB, ok, err := podB.Acquire(ctx, 1, 1)
+	if err != nil || !ok {
+		t.Fatalf("podB.Acquire() after release = %v, %v, want ok", ok, err)
+	}
+	releaseB()
+}
+
+func TestRedisLimiterLeaseExpiry(t *testing.T) {
+	rdb := newTestRedis(t)
+	ctx := context.Background()
+
+	// 实例崩溃：租约既不续期也不释放
+	crashed := NewRedisLimiter(rdb)
+	if _, ok, err := crashed.Acquire(ctx, 1, 1); err != nil || !ok {
+		t.Fatalf("crashed.Acquire() = %v, %v, want ok", ok, err)
+	}
+
+	later := NewRedisLimiter(rdb)
+	if _, ok, _ := later.Acquire(ctx, 1, 1); ok {
+		t.Fatal("Acquire() before lease expiry = true, want rejected")
+	}
+	later.now = func() time.Time { return time.Now().Add(defaultLeaseTTL + time.Second) }
+	release, ok, err := later.Acquire(ctx, 1, 1)
+	if err != nil || !ok {
+		t.Fatalf("Acquire() after lease expiry = %v, %v, want ok", ok, err)
+	}
+	release()
+}
+
+func TestRedisLimiterRenewsLongRequests(t *testing.T) {
+	rdb := newTestRedis(t)
+	ctx := context.Background()
+
+	holder := NewRedisLimiter(rdb)
+	holder.leaseTTL = 90 * time.Millisecond
+	release, ok, err := holder.Acquire(ctx, 1, 1)
+	if err != nil || !ok {
+		t.Fatalf("Acquire() = %v, %v, want ok", ok, err)
+	}
+
+	// 处理时间超过租约有效期，续期后名额仍被占用
+	time.Sleep(250 * time.Millisecond)
+	other := NewRedisLimiter(rdb)
+	if _, ok, _ := other.Acquire(ctx, 1, 1); ok {
+		t.Fatal("Acquire() while lease is renewed = true, want rejected")
+	}
+
+	release()
+	if n := rdb.ZCard(ctx, limiterKeyPrefix+"1").Val(); n != 0 {
+		t.Fatalf("leases after release = %d, want 0", n)
+	}
+}
@@ -0,0 +1,154 @@
+package token
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const quotaKeyPrefix = "api_token_quota:"
+
+// QuotaLimits 每个 API Token 的请求配额，0 表示不限制
+type QuotaLimits struct {
+	PerMinute int
+	PerDay    int
+	PerMonth  int
+}
+
+// QuotaResult 本次请求计数后的配额状态，用于 X-RateLimit-* 响应头
+// 同时设置了多个窗口时取剩余最少的窗口；被拒绝时取最晚恢复的已用尽窗口
+type QuotaResult struct {
+	Allowed   bool
+	Limit     int // 为 0 时未设置配额
+	Remaining int
+	Reset     time.Time // 窗口重置时间
+}
+
+// Quota 按自然分钟、自然日、自然月计数 (固定窗口，按服务器本地时区)
+type Quota interface {
+	// Consume 所有窗口都有余量时计数并放行，否则不计数直接拒绝
+	Consume(ctx context.Context, tokenID uint, limits QuotaLimits) (QuotaResult, error)
+}
+
+// NewQuota 启用 Redis 时多实例共享计数，否则只在进程内计数
+func NewQuota(rdb redis.UniversalClient) Quota {
+	if rdb != nil {
+		return NewRedisQuota(rdb)
+	}
+	return NewInMemoryQuota()
+}
+
+// quotaWindow 一个已设置配额的计数窗口
+type quotaWindow struct {
+	key   string
+	limit int
+	reset time.Time
+}
+
+// quotaWindows 按当前时间计算各窗口的计数键与重置时间，未设置的窗口跳过
+func quotaWindows(tokenID uint, limits QuotaLimits, now time.Time) []quotaWindow {
+	// 同一令牌的键使用相同的 hash tag，Redis Cluster 下脚本才能同时操作
+	prefix := quotaKeyPrefix + "{" + strconv.FormatUint(uint64(tokenID), 10) + "}:"
+	year, month, day := now.Date()
+	minute := now.Truncate(time.Minute)
+	today := time.Date(year, month, day, 0, 0, 0, 0, now.Location())
+	thisMonth := time.Date(year, month, 1, 0, 0, 0, 0, now.Location())
+
+	var windows []quotaWindow
+	if limits.PerMinute > 0 {
+		windows = append(windows, quotaWindow{prefix + "m:" + minute.Format("200601021504"), limits.PerMinute, minute.Add(time.Minute)})
+	}
+	if limits.PerDay > 0 {
+		windows = append(windows, quotaWindow{prefix + "d:" + today.Format("20060102"), limits.PerDay, today.AddDate(0, 0, 1)})
+	}
+	if limits.PerMonth > 0 {
+		windows = append(windows, quotaWindow{prefix + "M:" + thisMonth.Format("200601"), limits.PerMonth, thisMonth.AddDate(0, 1, 0)})
+	}
+	return windows
+}
+
+// quotaResult 根据各窗口的已用次数生成结果
+func quotaResult(windows []quotaWindow, used []int64, allowed bool) QuotaResult {
+	result := QuotaResult{Allowed: allowed}
+	for i, w := range windows {
+		remaining := max(w.limit-int(used[i]), 0)
+		if allowed {
+			// 放行时展示剩余最少的窗口
+			if result.Limit == 0 || remaining < result.Remaining {
+				result.Limit, result.Remaining, result.Reset = w.limit, remaining, w.reset
+			}
+		} else if remaining == 0 && w.reset.After(result.Reset) {
+			// 所有已用尽的窗口都恢复后才能再次请求
+			result.Limit, result.Remaining, result.Reset = w.limit, remaining, w.reset
+		}
+	}
+	return result
+}
+
+// InMemoryQuota 进程内计数，多实例部署时每个实例各自计算配额
+type InMemoryQuota struct {
+	mu        sync.Mutex
+	counts    map[string]*quotaCount
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+type quotaCount struct {
+	n       int64
+	expires time.Time
+}
+
+func NewInMemoryQuota() *InMemoryQuota {
+	return &InMemoryQuota{counts: make(map[string]*quotaCount), now: time.Now}
+}
+
+func (q *InMemoryQuota) Consume(_ context.Context, tokenID uint, limits QuotaLimits) (QuotaResult, error) {
+	now := q.now()
+	windows := quotaWindows(tokenID, limits, now)
+	if len(windows) == 0 {
+		return QuotaResult{Allowed: true}, nil
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.sweep(now)
+
+	used := make([]int64, len(windows))
+	allowed := true
+	for i, w := range windows {
+		if c := q.counts[w.key]; c != nil && now.Before(c.expires) {
+			used[i] = c.n
+		}
+		if used[i] >= int64(w.limit) {
+			allowed = false
+		}
+	}
+	if allowed {
+		for i, w := range windows {
+			c := q.counts[w.key]
+			if c == nil || !now.Before(c.expires) {
+				c = &quotaCount{expires: w.reset}
+				q.counts[w.key] = c
+			}
+			c.n++
+			used[i] = c.n
+		}
+	}
+	return quotaResult(windows, used, allowed), nil
+}
+
+// sweep 每分钟最多清理一次已过期的窗口
+func (q *InMemoryQuota) sweep(now time.Time) {
+	if now.Sub(q.lastSweep) < time.Minute {
+		return
+	}
+	q.lastSweep = now
+	for key, c := range q.counts {
+		if !now.Before(c.expires) {
+			delete(q.counts, key)
+		}
+	}
+}
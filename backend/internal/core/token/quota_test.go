@@ -0,0 +1,68 @@
+package token
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestQuotas(t *testing.T) {
+	now := time.Date(2024, 1, 31, 23, 59, 30, 0, time.Local)
+	clock := func() time.Time { return now }
+
+	mem := NewInMemoryQuota()
+	mem.now = clock
+	redisQuota := NewRedisQuota(newTestRedis(t))
+	redisQuota.now = clock
+
+	for name, quota := range map[string]Quota{"memory": mem, "redis": redisQuota} {
+		t.Run(name, func(t *testing.T) {
+			now = time.Date(2024, 1, 31, 23, 59, 30, 0, time.Local)
+			ctx := context.Background()
+			limits := QuotaLimits{PerMinute: 2, PerDay: 3, PerMonth: 10}
+
+			if res, err := quota.Consume(ctx, 9, QuotaLimits{}); err != nil || !res.Allowed || res.Limit != 0 {
+				t.Fatalf("Consume() without limits = %+v, %v, want allowed without limit", res, err)
+			}
+
+			res, err := quota.Consume(ctx, 1, limits)
+			if err != nil || !res.Allowed {
+				t.Fatalf("Consume() first = %+v, %v, want allowed", res, err)
+			}
+			// 剩余最少的是分钟窗口
+			if res.Limit != 2 || res.Remaining != 1 || !res.Reset.Equal(time.Date(2024, 2, 1, 0, 0, 0, 0, time.Local)) {
+				t.Fatalf("Consume() first = %+v, want minute window with 1 remaining", res)
+			}
+			if res, _ := quota.Consume(ctx, 1, limits); !res.Allowed || res.Remaining != 0 {
+				t.Fatalf("Consume() second = %+v, want allowed with 0 remaining", res)
+			}
+			res, err = quota.Consume(ctx, 1, limits)
+			if err != nil || res.Allowed || res.Limit != 2 || res.Remaining != 0 {
+				t.Fatalf("Consume() over minute quota = %+v, %v, want rejected by minute window", res, err)
+			}
+			// 其他令牌单独计数
+			if res, _ := quota.Consume(ctx, 2, limits); !res.Allowed {
+				t.Fatalf("Consume() other token = %+v, want allowed", res)
+			}
+
+			// 新的一分钟同时也是新的一天与新的一月
+			now = now.Add(time.Minute)
+			for i := 0; i < 2; i++ {
+				if res, _ := quota.Consume(ctx, 1, limits); !res.Allowed {
+					t.Fatalf("Consume() in new window #%d = %+v, want allowed", i, res)
+				}
+			}
+
+			// 被拒绝的请求不计数：下一分钟只剩日配额的 1 次
+			now = now.Add(time.Minute)
+			if res, _ := quota.Consume(ctx, 1, limits); !res.Allowed || res.Limit != 3 || res.Remaining != 0 {
+				t.Fatalf("Consume() last of the day = %+v, want day window with 0 remaining", res)
+			}
+			now = now.Add(time.Minute)
+			res, _ = quota.Consume(ctx, 1, limits)
+			if res.Allowed || res.Limit != 3 || !res.Reset.Equal(time.Date(2024, 2, 2, 0, 0, 0, 0, time.Local)) {
+				t.Fatalf("Consume() over day quota = %+v, want rejected until next day", res)
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package token
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	limiterKeyPrefix = "api_token_concurrency:"
+	// 实例崩溃后未释放的名额最多占用这么久
+	defaultLeaseTTL = 30 * time.Second
+	releaseTimeout  = 3 * time.Second
+)
+
+// acquireScript 清理过期租约后判断名额，有空余时登记新租约
+// 每个令牌一个有序集合：成员为租约 ID，分值为到期时间 (毫秒)
+var acquireScript = redis.NewScript(`
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', ARGV[1])
+if redis.call('ZCARD', KEYS[1]) >= tonumber(ARGV[3]) then
+	return 0
+end
+redis.call('ZADD', KEYS[1], ARGV[2], ARGV[4])
+redis.call('PEXPIRE', KEYS[1], ARGV[5])
+return 1
+`)
+
+// renewScript 续期仍然有效的租约；租约已被清理时不再登记
+var renewScript = redis.NewScript(`
+if not redis.call('ZSCORE', KEYS[1], ARGV[2]) then
+	return 0
+end
+redis.call('ZADD', KEYS[1], ARGV[1], ARGV[2])
+redis.call('PEXPIRE', KEYS[1], ARGV[3])
+return 1
+`)
+
+// RedisLimiter 多实例共享的并发名额
+// 每个请求持有一个带到期时间的租约，处理期间定时续期；实例崩溃后租约到期自动回收
+// 到期时间取各实例本地时钟，实例间的时钟偏差需远小于租约有效期
+type RedisLimiter struct {
+	rdb      redis.UniversalClient
+	leaseTTL time.Duration
+	now      func() time.Time
+}
+
+func NewRedisLimiter(rdb redis.UniversalClient) *RedisLimiter {
+	return &RedisLimiter{rdb: rdb, leaseTTL: defaultLeaseTTL, now: time.Now}
+}
+
+func (l *RedisLimiter) Acquire(ctx context.Context, tokenID uint, maxConcurrency int) (func(), bool, error) {
+	if maxConcurrency <= 0 {
+		maxConcurrency = 1
+	}
+	key := limiterKeyPrefix + strconv.FormatUint(uint64(tokenID), 10)
+	lease := newLeaseID()
+
+	now := l.now()
+	ok, err := acquireScript.Run(ctx, l.rdb, []string{key},
+		now.UnixMilli(), now.Add(l.leaseTTL).UnixMilli(), maxConcurrency, lease, l.leaseTTL.Milliseconds(),
+	).Bool()
+	if err != nil || !ok {
+		return nil, false, err
+	}
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go l.renew(key, lease, stop, done)
+
+	var once sync.Once
+	release := func() {
+		once.Do(func() {
+			close(stop)
+			<-done
+			// 请求的 ctx 可能已取消，释放使用独立的超时
+			ctx, cancel := context.WithTimeout(context.Background(), releaseTimeout)
+			defer cancel()
+			_ = l.rdb.ZRem(ctx, key, lease).Err()
+		})
+	}
+	return release, true, nil
+}
+
+// renew 请求处理时间超过租约有效期时按 1/3 周期续期
+func (l *RedisLimiter) renew(key, lease string, stop <-chan struct{}, done chan<- struct{}) {
+	defer close(done)
+	ticker := time.NewTicker(l.leaseTTL / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), releaseTimeout)
+			_ = renewScript.Run(ctx, l.rdb, []string{key},
+				l.now().Add(l.leaseTTL).UnixMilli(), lease, l.leaseTTL.Milliseconds(),
+			).Err()
+			cancel()
+		}
+	}
+}
+
+func newLeaseID() string {
+	buf := make([]byte, 16)
+	_, _ = rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
@@ -0,0 +1,72 @@
+package token
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// consumeScript 所有窗口都有余量时一起计数，否则都不计数
+// KEYS 为各窗口的计数键；ARGV 依次为各窗口的上限与距重置的毫秒数
+// 返回 {是否放行, 各窗口已用次数...}
+var consumeScript = redis.NewScript(`
+local n = #KEYS
+local used = {}
+local allowed = 1
+for i = 1, n do
+	used[i] = tonumber(redis.call('GET', KEYS[i]) or '0')
+	if used[i] >= tonumber(ARGV[i]) then
+		allowed = 0
+	end
+end
+if allowed == 1 then
+	for i = 1, n do
+		used[i] = redis.call('INCR', KEYS[i])
+		if used[i] == 1 then
+			redis.call('PEXPIRE', KEYS[i], ARGV[n + i])
+		end
+	end
+end
+local result = {allowed}
+for i = 1, n do
+	result[i + 1] = used[i]
+end
+return result
+`)
+
+// RedisQuota 多实例共享的请求配额计数
+// 窗口边界取各实例本地时钟，各实例需使用相同时区
+type RedisQuota struct {
+	rdb redis.UniversalClient
+	now func() time.Time
+}
+
+func NewRedisQuota(rdb redis.UniversalClient) *RedisQuota {
+	return &RedisQuota{rdb: rdb, now: time.Now}
+}
+
+func (q *RedisQuota) Consume(ctx context.Context, tokenID uint, limits QuotaLimits) (QuotaResult, error) {
+	now := q.now()
+	windows := quotaWindows(tokenID, limits, now)
+	if len(windows) == 0 {
+		return QuotaResult{Allowed: true}, nil
+	}
+
+	keys := make([]string, len(windows))
+	args := make([]any, 2*len(windows))
+	for i, w := range windows {
+		keys[i] = w.key
+		args[i] = w.limit
+		args[len(windows)+i] = w.reset.Sub(now).Milliseconds()
+	}
+	values, err := consumeScript.Run(ctx, q.rdb, keys, args...).Int64Slice()
+	if err != nil {
+		return QuotaResult{}, err
+	}
+	if len(values) != len(windows)+1 {
+		return QuotaResult{}, fmt.Errorf("api token quota: unexpected script result %v", values)
+	}
+	return quotaResult(windows, values[1:], values[0] == 1), nil
+}
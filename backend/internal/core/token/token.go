@@ -1,6 +1,7 @@
 package token
 
 import (
+	"crypto/hmac"
 	"crypto/rand"
 	"crypto/sha256"
 	"encoding/base64"
@@ -26,3 +27,14 @@ func HashToken(rawToken string) string {
 func VerifyToken(rawToken, storedHash string) bool {
 	return HashToken(rawToken) == storedHash
 }
+
+// SigningSecret 由服务端密钥 (api_token_sign.secret) 与令牌哈希派生签名请求的密钥
+// 库中只保存哈希，仅凭数据库内容无法得到签名密钥；secret 为空时返回空串，表示未开启签名请求
+func SigningSecret(secret, tokenHash string) string {
+	if secret == "" || tokenHash == "" {
+		return ""
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("api-token-sign\n" + tokenHash))
+	return hex.EncodeToString(mac.Sum(nil))
+}
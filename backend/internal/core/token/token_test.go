@@ -40,3 +40,21 @@ func TestVerifyTokenMatchesHash(t *testing.T) {
 		t.Fatal("VerifyToken() = true for mismatched token, want false")
 	}
 }
+
+func TestSigningSecretRequiresServerSecret(t *testing.T) {
+	hash := HashToken("cms_sign_token")
+
+	if got := SigningSecret("", hash); got != "" {
+		t.Fatalf("SigningSecret() without server secret = %q, want empty", got)
+	}
+	secret := SigningSecret("server-secret", hash)
+	if secret == "" || secret == hash {
+		t.Fatalf("SigningSecret() = %q, want a key different from the stored hash", secret)
+	}
+	if SigningSecret("server-secret", hash) != secret {
+		t.Fatal("SigningSecret() is not stable")
+	}
+	if SigningSecret("other-secret", hash) == secret {
+		t.Fatal("SigningSecret() does not depend on the server secret")
+	}
+}
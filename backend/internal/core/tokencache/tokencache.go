@@ -0,0 +1,220 @@
+package tokencache
+
+import (
+	"context"
+	"errors"
+	"net/netip"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	tokenCore "github.com/CIPFZ/gowebframe/internal/core/token"
+	"github.com/CIPFZ/gowebframe/internal/modules/system/model"
+	"github.com/hashicorp/golang-lru/v2/expirable"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+	"golang.org/x/sync/singleflight"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultSize = 4096
+	// 分组内新增接口等不会主动失效的变化，最多延迟一个 TTL 生效
+	defaultTTL = 30 * time.Second
+	// 多实例间广播失效的频道，消息体为令牌 ID
+	invalidateChannel = "api_token_invalidate"
+)
+
+// Entry 解析后的令牌，供鉴权中间件只读使用
+type Entry struct {
+	Token model.SysApiToken
+	// AllowedIPs 已解析的来源地址范围，为空表示不限制
+	AllowedIPs []netip.Prefix
+	// allowed 勾选的接口与分组内已登记的接口，键为 "METHOD path"
+	allowed map[string]bool
+}
+
+// Allows 判断令牌能否访问该路由 (路由模板)
+// 分组授权按加载时已登记的接口展开；路径规则在请求时匹配，未登记的路由也能命中
+func (e *Entry) Allows(method, path string) bool {
+	if e.allowed[strings.ToUpper(method)+" "+path] {
+		return true
+	}
+	for _, scope := range e.Token.Scopes {
+		if scope.Kind == model.ApiTokenScopePath && scope.Matches(method, path, "") {
+			return true
+		}
+	}
+	return false
+}
+
+// Cache 按令牌哈希 (或签名请求的令牌 ID) 缓存解析结果，避免每个请求都查询令牌及其授权
+// 令牌被修改、禁用、重置或删除时由 ApiTokenService 调用 Invalidate；启用 Redis 时通过 pub/sub 通知其他实例
+type Cache struct {
+	db     *gorm.DB
+	rdb    redis.UniversalClient
+	logger *zap.Logger
+
+	entries *expirable.LRU[string, *Entry]
+	loads   singleflight.Group
+	// generation 每次失效递增，加载期间发生失效时不写入缓存，避免旧数据覆盖
+	generation atomic.Uint64
+
+	sub       *redis.PubSub
+	closeOnce sync.Once
+	done      chan struct{}
+}
+
+// New rdb 为 nil 时只在本实例内失效
+func New(db *gorm.DB, rdb redis.UniversalClient, logger *zap.Logger) *Cache {
+	c := &Cache{
+		db:      db,
+		rdb:     rdb,
+		logger:  logger,
+		entries: expirable.NewLRU[string, *Entry](defaultSize, nil, defaultTTL),
+		done:    make(chan struct{}),
+	}
+	if rdb == nil {
+		close(c.done)
+		return c
+	}
+	c.sub = rdb.Subscribe(context.Background(), invalidateChannel)
+	// 等待订阅确认，之后发布的失效通知不会丢失
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := c.sub.Receive(ctx); err != nil {
+		logger.Warn("api_token_invalidate_subscribe_failed", zap.Error(err))
+	}
+	go c.listen(c.sub.Channel())
+	return c
+}
+
+// Resolve 按令牌哈希返回解析结果；令牌不存在时返回 gorm.ErrRecordNotFound (不缓存)
+// 也会匹配轮换前的旧哈希，调用方需比较 Token.TokenHash 并检查宽限期
+func (c *Cache) Resolve(ctx context.Context, hash string) (*Entry, error) {
+	return c.resolve(ctx, hash, "token_hash = ? OR previous_token_hash = ?", hash, hash)
+}
+
+// ResolveID 按令牌 ID 返回解析结果，用于签名请求 (请求中只携带 key id)
+func (c *Cache) ResolveID(ctx context.Context, id uint) (*Entry, error) {
+	// 哈希为十六进制串，不会与 "id:" 前缀冲突
+	return c.resolve(ctx, "id:"+strconv.FormatUint(uint64(id), 10), "id = ?", id)
+}
+
+func (c *Cache) resolve(ctx context.Context, key, query string, args ...any) (*Entry, error) {
+	if entry, ok := c.entries.Get(key); ok {
+		return entry, nil
+	}
+
+	v, err, _ := c.loads.Do(key, func() (any, error) {
+		generation := c.generation.Load()
+		entry, err := load(ctx, c.db, query, args...)
+		if err != nil {
+			return nil, err
+		}
+		if c.generation.Load() == generation {
+			c.entries.Add(key, entry)
+		}
+		return entry, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.(*Entry), nil
+}
+
+// Invalidate 移除本实例的缓存并通知其他实例
+func (c *Cache) Invalidate(ctx context.Context, ids ...uint) error {
+	c.removeLocal(ids...)
+	if c.rdb == nil {
+		return nil
+	}
+	var errs []error
+	for _, id := range ids {
+		if err := c.rdb.Publish(ctx, invalidateChannel, strconv.FormatUint(uint64(id), 10)).Err(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (c *Cache) removeLocal(ids ...uint) {
+	c.generation.Add(1)
+	targets := make(map[uint]bool, len(ids))
+	for _, id := range ids {
+		targets[id] = true
+	}
+	for _, hash := range c.entries.Keys() {
+		if entry, ok := c.entries.Peek(hash); ok && targets[entry.Token.ID] {
+			c.entries.Remove(hash)
+		}
+	}
+}
+
+func (c *Cache) listen(ch <-chan *redis.Message) {
+	defer close(c.done)
+	for msg := range ch {
+		id, err := strconv.ParseUint(msg.Payload, 10, 64)
+		if err != nil {
+			c.logger.Warn("api_token_invalidate_bad_message", zap.String("payload", msg.Payload))
+			continue
+		}
+		c.removeLocal(uint(id))
+	}
+}
+
+// Close 停止订阅失效通知
+func (c *Cache) Close(ctx context.Context) error {
+	var err error
+	c.closeOnce.Do(func() {
+		if c.sub != nil {
+			err = c.sub.Close()
+		}
+	})
+	select {
+	case <-c.done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return err
+}
+
+// load 从数据库解析令牌：预加载勾选的接口与授权范围，并展开分组授权
+func load(ctx context.Context, db *gorm.DB, query string, args ...any) (*Entry, error) {
+	var token model.SysApiToken
+	if err := db.WithContext(ctx).
+		Preload("Apis").
+		Preload("Scopes").
+		Where(query, args...).
+		First(&token).Error; err != nil {
+		return nil, err
+	}
+
+	prefixes, err := tokenCore.ParseAllowedIPs(token.AllowedIPList())
+	if err != nil {
+		return nil, err
+	}
+
+	entry := &Entry{Token: token, AllowedIPs: prefixes, allowed: make(map[string]bool, len(token.Apis))}
+	for _, api := range token.Apis {
+		entry.allowed[strings.ToUpper(api.Method)+" "+api.Path] = true
+	}
+	var groups []string
+	for _, scope := range token.Scopes {
+		if scope.Kind == model.ApiTokenScopeGroup {
+			groups = append(groups, scope.Value)
+		}
+	}
+	if len(groups) > 0 {
+		var apis []model.SysApi
+		if err := db.WithContext(ctx).Select("method", "path").Where("api_group IN ?", groups).Find(&apis).Error; err != nil {
+			return nil, err
+		}
+		for _, api := range apis {
+			entry.allowed[strings.ToUpper(api.Method)+" "+api.Path] = true
+		}
+	}
+	return entry, nil
+}
@@ -0,0 +1,181 @@
+package tokencache
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/CIPFZ/gowebframe/internal/core/config"
+	"github.com/CIPFZ/gowebframe/internal/core/db"
+	"github.com/CIPFZ/gowebframe/internal/modules/system/model"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+func newCacheTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	gormDB, err := db.InitDatabase(config.Database{
+		Driver: "sqlite3",
+		SQLite: config.SQLite{
+			Path:         filepath.Join(t.TempDir(), "tokencache.db"),
+			MaxIdleConns: 1,
+			MaxOpenConns: 1,
+		},
+	}, zap.NewNop())
+	if err != nil {
+		t.Fatalf("InitDatabase() error = %v", err)
+	}
+	if err := gormDB.AutoMigrate(
+		&model.SysApi{},
+		&model.SysApiToken{},
+		&model.SysApiTokenApi{},
+		&model.SysApiTokenScope{},
+	); err != nil {
+		t.Fatalf("AutoMigrate() error = %v", err)
+	}
+	sqlDB, err := gormDB.DB()
+	if err != nil {
+		t.Fatalf("DB() error = %v", err)
+	}
+	t.Cleanup(func() {
+		_ = sqlDB.Close()
+	})
+	return gormDB
+}
+
+func seedToken(t *testing.T, gormDB *gorm.DB) *model.SysApiToken {
+	t.Helper()
+
+	apis := []model.SysApi{
+		{Path: "/api/v1/poetry/poem/list", Method: "GET", ApiGroup: "poetry"},
+		{Path: "/api/v1/poetry/author/list", Method: "GET", ApiGroup: "poetry"},
+		{Path: "/api/v1/sys/user/list", Method: "POST", ApiGroup: "user"},
+	}
+	if err := gormDB.Create(&apis).Error; err != nil {
+		t.Fatalf("seed sys apis error = %v", err)
+	}
+	token := &model.SysApiToken{
+		TokenHash:   "hash-1",
+		TokenPrefix: "gwf_abcd",
+		Name:        "reader",
+		Enabled:     true,
+		AllowedIPs:  "10.0.0.0/8",
+		Apis:        []model.SysApi{apis[2]},
+		Scopes: []model.SysApiTokenScope{
+			{Kind: model.ApiTokenScopeGroup, Value: "poetry"},
+			{Kind: model.ApiTokenScopePath, Method: "GET", Value: "/api/v1/report/*"},
+		},
+	}
+	if err := gormDB.Create(token).Error; err != nil {
+		t.Fatalf("seed token error = %v", err)
+	}
+	return token
+}
+
+func TestCacheResolve(t *testing.T) {
+	gormDB := newCacheTestDB(t)
+	token := seedToken(t, gormDB)
+	cache := New(gormDB, nil, zap.NewNop())
+	ctx := context.Background()
+
+	entry, err := cache.Resolve(ctx, token.TokenHash)
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+	if entry.Token.ID != token.ID || len(entry.AllowedIPs) != 1 {
+		t.Fatalf("Resolve() = %+v, want token %d with one ip range", entry.Token, token.ID)
+	}
+	for _, tc := range []struct {
+		method, path string
+		want         bool
+	}{
+		{"POST", "/api/v1/sys/user/list", true},
+		{"get", "/api/v1/poetry/author/list", true},
+		{"GET", "/api/v1/report/daily", true},
+		{"POST", "/api/v1/report/daily", false},
+		{"GET", "/api/v1/sys/user/list", false},
+	} {
+		if got := entry.Allows(tc.method, tc.path); got != tc.want {
+			t.Errorf("Allows(%s %s) = %v, want %v", tc.method, tc.path, got, tc.want)
+		}
+	}
+
+	// 命中缓存后不再读库
+	if err := gormDB.Model(&model.SysApiToken{}).Where("id = ?", token.ID).Update("enabled", false).Error; err != nil {
+		t.Fatalf("disable token error = %v", err)
+	}
+	if entry, err = cache.Resolve(ctx, token.TokenHash); err != nil || !entry.Token.Enabled {
+		t.Fatalf("Resolve() cached = %+v, %v, want enabled entry", entry, err)
+	}
+
+	if err := cache.Invalidate(ctx, token.ID); err != nil {
+		t.Fatalf("Invalidate() error = %v", err)
+	}
+	if entry, err = cache.Resolve(ctx, token.TokenHash); err != nil || entry.Token.Enabled {
+		t.Fatalf("Resolve() after invalidate = %+v, %v, want disabled entry", entry, err)
+	}
+}
+
+func TestCacheDoesNotCacheMissingToken(t *testing.T) {
+	gormDB := newCacheTestDB(t)
+	cache := New(gormDB, nil, zap.NewNop())
+	ctx := context.Background()
+
+	if _, err := cache.Resolve(ctx, "hash-1"); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("Resolve() missing error = %v, want ErrRecordNotFound", err)
+	}
+	token := seedToken(t, gormDB)
+	if entry, err := cache.Resolve(ctx, "hash-1"); err != nil || entry.Token.ID != token.ID {
+		t.Fatalf("Resolve() after create = %+v, %v, want token %d", entry, err, token.ID)
+	}
+}
+
+func TestCacheInvalidatesAcrossInstances(t *testing.T) {
+	gormDB := newCacheTestDB(t)
+	token := seedToken(t, gormDB)
+	mr := miniredis.RunT(t)
+	newRedis := func() redis.UniversalClient {
+		rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+		t.Cleanup(func() { _ = rdb.Close() })
+		return rdb
+	}
+	local := New(gormDB, newRedis(), zap.NewNop())
+	remote := New(gormDB, newRedis(), zap.NewNop())
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		_ = local.Close(ctx)
+		_ = remote.Close(ctx)
+	})
+	ctx := context.Background()
+
+	if _, err := remote.Resolve(ctx, token.TokenHash); err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+	if err := gormDB.Model(&model.SysApiToken{}).Where("id = ?", token.ID).Update("enabled", false).Error; err != nil {
+		t.Fatalf("disable token error = %v", err)
+	}
+	if err := local.Invalidate(ctx, token.ID); err != nil {
+		t.Fatalf("Invalidate() error = %v", err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		entry, err := remote.Resolve(ctx, token.TokenHash)
+		if err != nil {
+			t.Fatalf("Resolve() error = %v", err)
+		}
+		if !entry.Token.Enabled {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("remote cache still returns the enabled token after invalidation")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
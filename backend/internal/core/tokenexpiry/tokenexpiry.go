@@ -0,0 +1,158 @@
+package tokenexpiry
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/CIPFZ/gowebframe/internal/core/cache"
+	"github.com/CIPFZ/gowebframe/internal/core/config"
+	"github.com/CIPFZ/gowebframe/internal/modules/system/model"
+	"github.com/CIPFZ/gowebframe/pkg/utils"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	checkInterval = time.Hour
+	// 多实例部署时同一时间只有一个实例发送提醒
+	checkLockKey = "api_token_expiry_notice"
+)
+
+// Notifier 定时检查即将到期的 API Token，向创建人发送站内通知 (SysNotice)
+// 每个令牌只提醒一次，修改过期时间后 ApiTokenService 会清空发送记录
+type Notifier struct {
+	db     *gorm.DB
+	cache  cache.Store
+	logger *zap.Logger
+	window time.Duration
+	now    func() time.Time
+
+	stop     chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+// NewNotifier 配置了 expiry_notice 时启动定时检查；cache 用于多实例间的检查锁，可为 nil
+func NewNotifier(db *gorm.DB, store cache.Store, cfg config.ApiTokenRotation, logger *zap.Logger) *Notifier {
+	n := &Notifier{
+		db:     db,
+		cache:  store,
+		logger: logger,
+		window: utils.ParseDurationOr(cfg.ExpiryNotice, 0),
+		now:    time.Now,
+		stop:   make(chan struct{}),
+	}
+	if n.window > 0 {
+		n.wg.Add(1)
+		go n.start()
+	}
+	return n
+}
+
+func (n *Notifier) start() {
+	defer n.wg.Done()
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if _, err := n.Notify(context.Background()); err != nil {
+				n.logger.Error("api_token_expiry_notice_failed", zap.Error(err))
+			}
+		case <-n.stop:
+			return
+		}
+	}
+}
+
+// Notify 为 expiry_notice 内到期、尚未提醒过的启用令牌发送通知，返回发送数量
+func (n *Notifier) Notify(ctx context.Context) (int, error) {
+	if n.window <= 0 {
+		return 0, nil
+	}
+	if n.cache != nil {
+		ok, err := n.cache.SetNX(ctx, checkLockKey, "1", checkInterval/2)
+		if err != nil {
+			return 0, err
+		}
+		if !ok {
+			return 0, nil
+		}
+	}
+
+	now := n.now()
+	var tokens []model.SysApiToken
+	if err := n.db.WithContext(ctx).
+		Where("enabled = ? AND created_by <> 0 AND expiry_notice_sent_at IS NULL", true).
+		Where("expires_at > ? AND expires_at <= ?", now, now.Add(n.window)).
+		Find(&tokens).Error; err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for i := range tokens {
+		ok, err := n.notifyToken(ctx, &tokens[i], now)
+		if err != nil {
+			n.logger.Error("api_token_expiry_notice_failed", zap.Uint("tokenID", tokens[i].ID), zap.Error(err))
+			continue
+		}
+		if ok {
+			sent++
+		}
+	}
+	return sent, nil
+}
+
+// notifyToken 在同一事务中写入通知并记录发送时间，避免重复提醒
+func (n *Notifier) notifyToken(ctx context.Context, token *model.SysApiToken, now time.Time) (bool, error) {
+	days := int(token.ExpiresAt.Sub(now).Hours()/24) + 1
+	notice := &model.SysNotice{
+		Title: fmt.Sprintf("API Token「%s」即将到期", token.Name),
+		Content: fmt.Sprintf("您创建的 API Token「%s」(前缀 %s) 将于 %s 到期 (约 %d 天后)，到期后调用方将无法访问。请及时延长有效期或轮换新的 Token。",
+			token.Name, token.TokenPrefix, token.ExpiresAt.Local().Format("2006-01-02 15:04"), days),
+		Level:      model.NoticeLevelWarning,
+		TargetType: model.NoticeTargetUsers,
+	}
+	sent := false
+	err := n.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 条件更新抢占发送权，并发检查时只有一个能成功
+		res := tx.Model(&model.SysApiToken{}).
+			Where("id = ? AND expiry_notice_sent_at IS NULL", token.ID).
+			Update("expiry_notice_sent_at", now)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return nil
+		}
+		if err := tx.Create(notice).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(&model.SysNoticeReceiver{NoticeID: notice.ID, UserID: token.CreatedBy}).Error; err != nil {
+			return err
+		}
+		sent = true
+		return nil
+	})
+	return sent, err
+}
+
+// Close 停止定时检查
+func (n *Notifier) Close(ctx context.Context) error {
+	n.stopOnce.Do(func() {
+		close(n.stop)
+	})
+	done := make(chan struct{})
+	go func() {
+		n.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,93 @@
+package tokenexpiry
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/CIPFZ/gowebframe/internal/core/config"
+	"github.com/CIPFZ/gowebframe/internal/core/db"
+	"github.com/CIPFZ/gowebframe/internal/modules/system/model"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+func newExpiryTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	gormDB, err := db.InitDatabase(config.Database{
+		Driver: "sqlite3",
+		SQLite: config.SQLite{
+			Path:         filepath.Join(t.TempDir(), "tokenexpiry.db"),
+			MaxIdleConns: 1,
+			MaxOpenConns: 1,
+		},
+	}, zap.NewNop())
+	if err != nil {
+		t.Fatalf("InitDatabase() error = %v", err)
+	}
+	if err := gormDB.AutoMigrate(&model.SysApiToken{}, &model.SysNotice{}, &model.SysNoticeReceiver{}); err != nil {
+		t.Fatalf("AutoMigrate() error = %v", err)
+	}
+	sqlDB, err := gormDB.DB()
+	if err != nil {
+		t.Fatalf("DB() error = %v", err)
+	}
+	t.Cleanup(func() {
+		_ = sqlDB.Close()
+	})
+	return gormDB
+}
+
+func TestNotifierSendsExpiryNoticeOnce(t *testing.T) {
+	gormDB := newExpiryTestDB(t)
+	now := time.Now()
+	at := func(d time.Duration) *time.Time {
+		v := now.Add(d)
+		return &v
+	}
+	tokens := []model.SysApiToken{
+		{TokenHash: "soon", TokenPrefix: "cms_soon", Name: "soon", Enabled: true, CreatedBy: 7, ExpiresAt: at(72 * time.Hour)},
+		{TokenHash: "later", TokenPrefix: "cms_late", Name: "later", Enabled: true, CreatedBy: 7, ExpiresAt: at(30 * 24 * time.Hour)},
+		{TokenHash: "expired", TokenPrefix: "cms_expi", Name: "expired", Enabled: true, CreatedBy: 7, ExpiresAt: at(-time.Hour)},
+		{TokenHash: "never", TokenPrefix: "cms_neve", Name: "never", Enabled: true, CreatedBy: 7},
+	}
+	if err := gormDB.Create(&tokens).Error; err != nil {
+		t.Fatalf("seed tokens error = %v", err)
+	}
+	// 停用的令牌不提醒
+	if err := gormDB.Create(&model.SysApiToken{TokenHash: "disabled", Name: "disabled", CreatedBy: 7, ExpiresAt: at(time.Hour)}).Error; err != nil {
+		t.Fatalf("seed disabled token error = %v", err)
+	}
+	if err := gormDB.Model(&model.SysApiToken{}).Where("token_hash = ?", "disabled").Update("enabled", false).Error; err != nil {
+		t.Fatalf("disable token error = %v", err)
+	}
+
+	notifier := NewNotifier(gormDB, nil, config.ApiTokenRotation{ExpiryNotice: "7d"}, zap.NewNop())
+	t.Cleanup(func() { _ = notifier.Close(context.Background()) })
+	notifier.now = func() time.Time { return now }
+
+	sent, err := notifier.Notify(context.Background())
+	if err != nil || sent != 1 {
+		t.Fatalf("Notify() = %d, %v, want 1 notice", sent, err)
+	}
+	if sent, err = notifier.Notify(context.Background()); err != nil || sent != 0 {
+		t.Fatalf("Notify() again = %d, %v, want no duplicate notice", sent, err)
+	}
+
+	var receivers []model.SysNoticeReceiver
+	if err := gormDB.Find(&receivers).Error; err != nil {
+		t.Fatalf("load receivers error = %v", err)
+	}
+	if len(receivers) != 1 || receivers[0].UserID != 7 {
+		t.Fatalf("receivers = %+v, want creator 7", receivers)
+	}
+	var notice model.SysNotice
+	if err := gormDB.First(&notice, receivers[0].NoticeID).Error; err != nil {
+		t.Fatalf("load notice error = %v", err)
+	}
+	if notice.Level != model.NoticeLevelWarning || notice.TargetType != model.NoticeTargetUsers {
+		t.Fatalf("notice = %+v, want warning for users", notice)
+	}
+}
@@ -1,97 +1,332 @@
 package middleware
 
 import (
+	"bytes"
+	"crypto/hmac"
 	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/CIPFZ/gowebframe/internal/core/apiusage"
+	"github.com/CIPFZ/gowebframe/internal/core/claims"
+	"github.com/CIPFZ/gowebframe/internal/core/config"
+	logger "github.com/CIPFZ/gowebframe/internal/core/log"
 	tokenCore "github.com/CIPFZ/gowebframe/internal/core/token"
+	"github.com/CIPFZ/gowebframe/internal/core/tokencache"
 	"github.com/CIPFZ/gowebframe/internal/modules/system/model"
 	"github.com/CIPFZ/gowebframe/internal/svc"
+	"github.com/CIPFZ/gowebframe/pkg/apisign"
 	"github.com/CIPFZ/gowebframe/pkg/errcode"
 	"github.com/CIPFZ/gowebframe/pkg/response"
+	"github.com/CIPFZ/gowebframe/pkg/utils"
 	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
 	"gorm.io/gorm"
 )
 
 const (
 	CtxKeyAPITokenID = "apiTokenId"
+	// ctxKeyAPITokenPreviousSecret 本次请求使用的是轮换前的旧密钥
+	ctxKeyAPITokenPreviousSecret = "apiTokenPreviousSecret"
 )
 
+// ApiTokenAuth 仅接受 API Token (明文或签名请求) 的路由组
 func ApiTokenAuth(svcCtx *svc.ServiceContext) gin.HandlerFunc {
-	if svcCtx.APITokenLimiter == nil {
-		svcCtx.APITokenLimiter = tokenCore.NewInMemoryLimiter()
+	return Authenticate(NewAPITokenAuthenticator(svcCtx), NewHMACAuthenticator(svcCtx))
+}
+
+type apiTokenAuthenticator struct {
+	apiTokenAdmission
+}
+
+// NewAPITokenAuthenticator X-API-Token 明文令牌认证，要求签名的令牌拒绝明文请求
+// 依赖 svcCtx 中的 APITokenCache、APITokenLimiter、APITokenQuota 与 ApiTokenUsage，由启动流程初始化
+func NewAPITokenAuthenticator(svcCtx *svc.ServiceContext) Authenticator {
+	return &apiTokenAuthenticator{apiTokenAdmission{svcCtx: svcCtx}}
+}
+
+func (a *apiTokenAuthenticator) Authenticate(c *gin.Context) (*claims.Principal, error) {
+	rawToken := strings.TrimSpace(c.GetHeader("X-API-Token"))
+	if rawToken == "" {
+		return nil, ErrNoCredentials
+	}
+	hash := tokenCore.HashToken(rawToken)
+	entry, err := a.svcCtx.APITokenCache.Resolve(c.Request.Context(), hash)
+	if err != nil {
+		return nil, apiTokenResolveError(err)
 	}
+	if entry.Token.RequireSignature {
+		return nil, errcode.Unauthorized.WithDetails("token 要求签名请求")
+	}
+	return apiTokenPrincipal(c, entry, entry.Token.TokenHash != hash)
+}
 
-	return func(c *gin.Context) {
-		rawToken := strings.TrimSpace(c.GetHeader("X-API-Token"))
-		if rawToken == "" {
-			response.FailWithCode(errcode.Unauthorized, c)
-			c.Abort()
-			return
-		}
+type hmacAuthenticator struct {
+	apiTokenAdmission
+}
 
-		var token model.SysApiToken
-		err := svcCtx.DB.WithContext(c.Request.Context()).
-			Preload("Apis").
-			Where("token_hash = ?", tokenCore.HashToken(rawToken)).
-			First(&token).
-			Error
-		if err != nil {
-			if errors.Is(err, gorm.ErrRecordNotFound) {
-				response.FailWithCode(errcode.Unauthorized, c)
-				c.Abort()
-				return
-			}
-			response.FailWithCode(errcode.ServerError, c)
-			c.Abort()
-			return
-		}
+// NewHMACAuthenticator API Token 签名请求认证，请求头见 pkg/apisign
+// 除明文认证的依赖外，nonce 防重放还依赖 svcCtx.Cache
+func NewHMACAuthenticator(svcCtx *svc.ServiceContext) Authenticator {
+	return &hmacAuthenticator{apiTokenAdmission{svcCtx: svcCtx}}
+}
 
-		if !token.Enabled {
-			response.FailWithCode(errcode.Unauthorized.WithDetails("token 已禁用"), c)
-			c.Abort()
-			return
-		}
-		if token.ExpiresAt != nil && token.ExpiresAt.Before(time.Now()) {
-			response.FailWithCode(errcode.Unauthorized.WithDetails("token 已过期"), c)
-			c.Abort()
-			return
-		}
+func (a *hmacAuthenticator) Authenticate(c *gin.Context) (*claims.Principal, error) {
+	if c.GetHeader(apisign.HeaderSignature) == "" {
+		return nil, ErrNoCredentials
+	}
+	entry, previous, err := resolveSignedAPIToken(a.svcCtx, c)
+	if err != nil {
+		return nil, apiTokenResolveError(err)
+	}
+	return apiTokenPrincipal(c, entry, previous)
+}
 
-		path := c.FullPath()
-		if path == "" {
-			path = c.Request.URL.Path
-		}
-		if !apiTokenAllowsRequest(&token, c.Request.Method, path) {
-			response.FailWithCode(errcode.AssessDenied.WithDetails("token 无权访问该 API"), c)
-			c.Abort()
-			return
-		}
+// apiTokenResolveError 令牌不存在返回 401，其余错误 (存储故障等) 原样返回
+func apiTokenResolveError(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return errcode.Unauthorized
+	}
+	return err
+}
 
-		if !svcCtx.APITokenLimiter.Acquire(token.ID, token.MaxConcurrency) {
-			response.FailWithCode(errcode.AssessDenied.WithDetails("token 并发已达上限"), c)
-			c.Abort()
-			return
+// apiTokenPrincipal 令牌已禁用或已过期时返回 401
+// 轮换宽限期内的旧密钥仍可使用，响应带 X-API-Token-Grace-Until 提示调用方尽快更换
+func apiTokenPrincipal(c *gin.Context, entry *tokencache.Entry, previous bool) (*claims.Principal, error) {
+	token := &entry.Token
+	if !token.Enabled {
+		return nil, errcode.Unauthorized.WithDetails("token 已禁用")
+	}
+	if token.ExpiresAt != nil && token.ExpiresAt.Before(time.Now()) {
+		return nil, errcode.Unauthorized.WithDetails("token 已过期")
+	}
+	if previous {
+		if !token.PreviousSecretValid(time.Now()) {
+			return nil, errcode.Unauthorized.WithDetails("token 已轮换，旧密钥已失效")
 		}
-		defer svcCtx.APITokenLimiter.Release(token.ID)
+		c.Header("X-API-Token-Grace-Until", token.PreviousTokenExpiresAt.Format(time.RFC3339))
+		c.Set(ctxKeyAPITokenPreviousSecret, true)
+	}
+	return &claims.Principal{
+		Kind:    claims.PrincipalAPIToken,
+		TokenID: token.ID,
+		Scopes:  entry,
+	}, nil
+}
 
-		c.Set(CtxKeyAPITokenID, token.ID)
-		c.Next()
+// apiTokenAdmission 令牌认证通过后依次检查来源 IP、授权范围、配额与并发，请求结束后记录用量
+type apiTokenAdmission struct {
+	svcCtx *svc.ServiceContext
+}
+
+func (a apiTokenAdmission) admit(c *gin.Context, p *claims.Principal) (func(), bool) {
+	entry := p.Scopes.(*tokencache.Entry)
+	token := &entry.Token
+
+	path := c.FullPath()
+	if path == "" {
+		path = c.Request.URL.Path
+	}
+	if !checkAPITokenIP(a.svcCtx, c, entry) {
+		return nil, false
+	}
+	if !entry.Allows(c.Request.Method, path) {
+		response.FailWithCode(errcode.AssessDenied.WithDetails("token 无权访问该 API"), c)
+		c.Abort()
+		return nil, false
+	}
 
-		_ = svcCtx.DB.WithContext(c.Request.Context()).
-			Model(&model.SysApiToken{}).
-			Where("id = ?", token.ID).
-			Update("last_used_at", time.Now()).
-			Error
+	// 先占并发名额再扣配额，因并发超限被拒绝的请求不计入配额
+	release, ok, err := a.svcCtx.APITokenLimiter.Acquire(c.Request.Context(), token.ID, token.MaxConcurrency)
+	if err != nil {
+		logger.GetLogger(c).Error("api_token_limiter_failed", zap.Uint("tokenID", token.ID), zap.Error(err))
+		response.FailWithCode(errcode.ServerError, c)
+		c.Abort()
+		return nil, false
+	}
+	if !ok {
+		response.FailWithCode(errcode.AssessDenied.WithDetails("token 并发已达上限"), c)
+		c.Abort()
+		return nil, false
+	}
+	if !checkAPITokenQuota(a.svcCtx, c, token) {
+		release()
+		return nil, false
 	}
+
+	start := time.Now()
+	return func() {
+		release()
+		recordAPITokenUsage(a.svcCtx, c, token.ID, path, start)
+	}, true
 }
 
-func apiTokenAllowsRequest(token *model.SysApiToken, method, path string) bool {
-	for _, api := range token.Apis {
-		if strings.EqualFold(api.Method, method) && api.Path == path {
-			return true
+const (
+	defaultAPITokenClockSkew = 5 * time.Minute
+	// 签名请求体默认上限 1MB，读取发生在校验签名之前
+	defaultAPITokenMaxBodySizeMB = 1
+	// nonce 最长 64 个字符，避免占用过多缓存
+	maxAPITokenNonceLength = 64
+)
+
+// resolveSignedAPIToken 校验签名请求：时间戳在允许的偏差内、签名正确，且 nonce 在窗口内未使用过
+// 签名通过后才登记 nonce，伪造的请求无法占用合法客户端的 nonce；previous 表示由轮换前的旧密钥签名
+func resolveSignedAPIToken(svcCtx *svc.ServiceContext, c *gin.Context) (entry *tokencache.Entry, previous bool, err error) {
+	keyID := c.GetHeader(apisign.HeaderKeyID)
+	timestamp := c.GetHeader(apisign.HeaderTimestamp)
+	nonce := c.GetHeader(apisign.HeaderNonce)
+	signature := c.GetHeader(apisign.HeaderSignature)
+	if keyID == "" || timestamp == "" || nonce == "" || len(nonce) > maxAPITokenNonceLength {
+		return nil, false, errcode.Unauthorized.WithDetails("签名请求头不完整")
+	}
+
+	var cfg config.ApiTokenSign
+	if svcCtx.Config != nil {
+		cfg = svcCtx.Config.ApiTokenSign
+	}
+	if cfg.Secret == "" {
+		return nil, false, errcode.Unauthorized.WithDetails("未开启签名请求")
+	}
+	skew := utils.ParseDurationOr(cfg.ClockSkew, defaultAPITokenClockSkew)
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return nil, false, errcode.Unauthorized.WithDetails("签名时间戳无效")
+	}
+	if d := time.Since(time.Unix(unix, 0)); d > skew || d < -skew {
+		return nil, false, errcode.Unauthorized.WithDetails("签名已过期")
+	}
+
+	id, err := strconv.ParseUint(keyID, 10, 64)
+	if err != nil {
+		return nil, false, gorm.ErrRecordNotFound
+	}
+	entry, err = svcCtx.APITokenCache.ResolveID(c.Request.Context(), uint(id))
+	if err != nil {
+		return nil, false, err
+	}
+
+	var body []byte
+	if c.Request.Body != nil {
+		maxBody := int64(cfg.MaxBodySizeMB)
+		if maxBody <= 0 {
+			maxBody = defaultAPITokenMaxBodySizeMB
+		}
+		reader := http.MaxBytesReader(c.Writer, c.Request.Body, maxBody<<20)
+		if body, err = io.ReadAll(reader); err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				return nil, false, &statusError{status: http.StatusRequestEntityTooLarge, err: errcode.RequestTooLarge}
+			}
+			return nil, false, errcode.Unauthorized.WithDetails("读取请求体失败")
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	stringToSign := apisign.StringToSign(c.Request.Method, c.Request.URL.EscapedPath(), c.Request.URL.RawQuery, timestamp, nonce, apisign.BodyHash(body))
+	signature = strings.ToLower(signature)
+	// 签名密钥由服务端密钥派生，不直接使用库中保存的哈希
+	if !hmac.Equal([]byte(apisign.Signature(tokenCore.SigningSecret(cfg.Secret, entry.Token.TokenHash), stringToSign)), []byte(signature)) {
+		// 宽限期内也接受旧密钥的签名，是否仍在宽限期由调用方检查
+		if entry.Token.PreviousTokenHash == "" ||
+			!hmac.Equal([]byte(apisign.Signature(tokenCore.SigningSecret(cfg.Secret, entry.Token.PreviousTokenHash), stringToSign)), []byte(signature)) {
+			return nil, false, errcode.Unauthorized.WithDetails("签名无效")
 		}
+		previous = true
 	}
+
+	// 时间戳最多比当前时间晚 skew，nonce 需保留到该请求过期为止
+	fresh, err := svcCtx.Cache.SetNX(c.Request.Context(), fmt.Sprintf("api_token_nonce:%d:%s", entry.Token.ID, nonce), "1", 2*skew)
+	if err != nil {
+		return nil, false, err
+	}
+	if !fresh {
+		return nil, false, errcode.Unauthorized.WithDetails("签名请求已使用过")
+	}
+	return entry, previous, nil
+}
+
+// checkAPITokenIP 校验请求来源是否在令牌允许的 IP 范围内
+// 客户端地址取 c.ClientIP()，只有来自 system.trusted_proxies 的请求才采信 X-Forwarded-For
+// 拒绝的请求写入操作日志，便于令牌负责人发现泄露后的滥用
+func checkAPITokenIP(svcCtx *svc.ServiceContext, c *gin.Context, entry *tokencache.Entry) bool {
+	ip := c.ClientIP()
+	if tokenCore.IPAllowed(entry.AllowedIPs, ip) {
+		return true
+	}
+	token := &entry.Token
+
+	logger.GetLogger(c).Warn("api_token_ip_denied", zap.Uint("tokenID", token.ID), zap.String("ip", ip))
+	if svcCtx.AuditRecorder != nil {
+		loc := svcCtx.GeoIP.Lookup(ip)
+		svcCtx.AuditRecorder.Push(model.SysOperationLog{
+			Ip:          ip,
+			Method:      c.Request.Method,
+			Path:        c.Request.URL.Path,
+			Agent:       c.Request.UserAgent(),
+			Status:      http.StatusForbidden,
+			Module:      "api-token",
+			Remark:      "API Token 来源 IP 不在允许范围",
+			ErrorMsg:    fmt.Sprintf("api token %d (%s, prefix %s) denied for ip %s", token.ID, token.Name, token.TokenPrefix, ip),
+			GeoLocation: model.GeoLocation{Country: loc.Country, Region: loc.Region, ASN: loc.ASN, ASOrg: loc.ASOrg},
+		})
+	}
+	response.FailWithCode(errcode.AssessDenied.WithDetails("token 不允许从该 IP 访问"), c)
+	c.Abort()
 	return false
 }
+
+// checkAPITokenQuota 按令牌的分钟/日/月配额计数并写入 X-RateLimit-* 响应头，超出时返回 429
+func checkAPITokenQuota(svcCtx *svc.ServiceContext, c *gin.Context, token *model.SysApiToken) bool {
+	result, err := svcCtx.APITokenQuota.Consume(c.Request.Context(), token.ID, tokenCore.QuotaLimits{
+		PerMinute: token.QuotaPerMinute,
+		PerDay:    token.QuotaPerDay,
+		PerMonth:  token.QuotaPerMonth,
+	})
+	if err != nil {
+		logger.GetLogger(c).Error("api_token_quota_failed", zap.Uint("tokenID", token.ID), zap.Error(err))
+		response.FailWithCode(errcode.ServerError, c)
+		c.Abort()
+		return false
+	}
+	if result.Limit == 0 {
+		return true
+	}
+
+	// Reset 与 Retry-After 均为距窗口重置的秒数
+	reset := strconv.Itoa(int(math.Ceil(time.Until(result.Reset).Seconds())))
+	c.Header("X-RateLimit-Limit", strconv.Itoa(result.Limit))
+	c.Header("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	c.Header("X-RateLimit-Reset", reset)
+	if !result.Allowed {
+		c.Header("Retry-After", reset)
+		e := errcode.TooManyRequests.WithDetails("token 请求配额已用尽")
+		c.AbortWithStatusJSON(http.StatusTooManyRequests, response.Response{Code: e.Code, Msg: e.Msg})
+		return false
+	}
+	return true
+}
+
+// recordAPITokenUsage 记录一次调用的用量统计 (异步聚合，同时批量更新最近使用时间)
+func recordAPITokenUsage(svcCtx *svc.ServiceContext, c *gin.Context, tokenID uint, path string, start time.Time) {
+	now := time.Now()
+	failed := c.Writer.Status() >= http.StatusBadRequest
+	if code, ok := response.CodeFromContext(c); ok && code != errcode.Success.Code {
+		failed = true
+	}
+	svcCtx.ApiTokenUsage.Push(apiusage.Event{
+		TokenID:  tokenID,
+		Method:   c.Request.Method,
+		Path:     path,
+		Failed:   failed,
+		Latency:  now.Sub(start),
+		BytesIn:  max(c.Request.ContentLength, 0),
+		BytesOut: int64(max(c.Writer.Size(), 0)),
+		At:       now,
+		// 轮换宽限期内仍在使用旧密钥的调用方，记录到令牌上便于排查
+		PreviousSecret: c.GetBool(ctxKeyAPITokenPreviousSecret),
+	})
+}
@@ -1,18 +1,30 @@
 package middleware
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"path/filepath"
+	"strconv"
 	"testing"
 	"time"
 
+	"github.com/CIPFZ/gowebframe/internal/core/apiusage"
+	"github.com/CIPFZ/gowebframe/internal/core/audit"
+	"github.com/CIPFZ/gowebframe/internal/core/cache"
 	"github.com/CIPFZ/gowebframe/internal/core/config"
 	"github.com/CIPFZ/gowebframe/internal/core/db"
+	"github.com/CIPFZ/gowebframe/internal/core/oauthclient"
 	tokenCore "github.com/CIPFZ/gowebframe/internal/core/token"
+	"github.com/CIPFZ/gowebframe/internal/core/tokencache"
 	"github.com/CIPFZ/gowebframe/internal/modules/system/model"
 	"github.com/CIPFZ/gowebframe/internal/svc"
+	"github.com/CIPFZ/gowebframe/pkg/apisign"
+	"github.com/CIPFZ/gowebframe/pkg/errcode"
+	"github.com/CIPFZ/gowebframe/pkg/response"
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 )
@@ -89,6 +101,415 @@ func TestApiTokenAuthRejectsUnauthorizedRoute(t *testing.T) {
 	}
 }
 
+func TestApiTokenAuthAllowsScopedRoutes(t *testing.T) {
+	rawToken := "cms_forbidden_token"
+	engine, svcCtx := newAPITokenMiddlewareTestEngine(t, func(group *gin.RouterGroup) {
+		ok := func(c *gin.Context) { c.JSON(http.StatusOK, gin.H{"code": 0}) }
+		group.GET("poetry/dynasty/list", ok)
+		group.GET("poetry/poem/:id", ok)
+		group.DELETE("poetry/poem/:id", ok)
+		group.GET("poetry/genre/list", ok)
+	})
+	var token model.SysApiToken
+	if err := svcCtx.DB.Where("token_hash = ?", tokenCore.HashToken(rawToken)).First(&token).Error; err != nil {
+		t.Fatalf("load api token error = %v", err)
+	}
+	// dynasty/list 登记在 poetry 分组；poem/:id 只有路径规则；genre/list 未登记
+	if err := svcCtx.DB.Create(&[]model.SysApiTokenScope{
+		{ApiTokenID: token.ID, Kind: model.ApiTokenScopeGroup, Value: "poetry"},
+		{ApiTokenID: token.ID, Kind: model.ApiTokenScopePath, Method: "GET", Value: "/api/v1/poetry/poem/*"},
+	}).Error; err != nil {
+		t.Fatalf("create scopes error = %v", err)
+	}
+
+	for _, tc := range []struct {
+		method, path string
+		code         int
+	}{
+		{http.MethodGet, "/api/v1/poetry/dynasty/list", 0},
+		{http.MethodGet, "/api/v1/poetry/poem/42", 0},
+		{http.MethodDelete, "/api/v1/poetry/poem/42", 1004},
+		{http.MethodGet, "/api/v1/poetry/genre/list", 1004},
+	} {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(tc.method, tc.path, nil)
+		req.Header.Set("X-API-Token", rawToken)
+		engine.ServeHTTP(rec, req)
+
+		var body map[string]any
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatalf("unmarshal response error = %v", err)
+		}
+		if int(body["code"].(float64)) != tc.code {
+			t.Fatalf("%s %s response code = %v, want %d", tc.method, tc.path, body["code"], tc.code)
+		}
+	}
+}
+
+func TestApiTokenAuthEnforcesAllowedIPs(t *testing.T) {
+	rawToken := "cms_allow_token"
+	engine, svcCtx := newAPITokenMiddlewareTestEngine(t, func(group *gin.RouterGroup) {
+		group.GET("poetry/dynasty/list", func(c *gin.Context) {
+			c.JSON(http.StatusOK, gin.H{"code": 0})
+		})
+	})
+	if err := svcCtx.DB.AutoMigrate(&model.SysOperationLog{}); err != nil {
+		t.Fatalf("AutoMigrate() error = %v", err)
+	}
+	svcCtx.AuditRecorder = audit.NewAuditRecorder(svcCtx.DB, zap.NewNop())
+	if err := svcCtx.DB.Model(&model.SysApiToken{}).
+		Where("token_hash = ?", tokenCore.HashToken(rawToken)).
+		Update("allowed_ips", "10.0.0.0/8,192.0.2.7/32").Error; err != nil {
+		t.Fatalf("update allowed ips error = %v", err)
+	}
+
+	serve := func(remoteAddr, forwardedFor string) float64 {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/poetry/dynasty/list", nil)
+		req.RemoteAddr = remoteAddr
+		req.Header.Set("X-API-Token", rawToken)
+		if forwardedFor != "" {
+			req.Header.Set("X-Forwarded-For", forwardedFor)
+		}
+		engine.ServeHTTP(rec, req)
+		var body map[string]any
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatalf("unmarshal response error = %v", err)
+		}
+		return body["code"].(float64)
+	}
+
+	if err := engine.SetTrustedProxies([]string{"192.0.2.1"}); err != nil {
+		t.Fatalf("SetTrustedProxies() error = %v", err)
+	}
+	if code := serve("192.0.2.7:5000", ""); code != 0 {
+		t.Fatalf("allowed remote address code = %v, want 0", code)
+	}
+	// 可信代理转发的客户端地址
+	if code := serve("192.0.2.1:5000", "10.1.2.3"); code != 0 {
+		t.Fatalf("forwarded allowed address code = %v, want 0", code)
+	}
+	// 不可信来源伪造的 X-Forwarded-For 不被采信
+	if code := serve("203.0.113.9:5000", "10.1.2.3"); code != 1004 {
+		t.Fatalf("spoofed forwarded address code = %v, want 1004", code)
+	}
+
+	if err := svcCtx.AuditRecorder.Close(context.Background()); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	var logs []model.SysOperationLog
+	if err := svcCtx.DB.Find(&logs).Error; err != nil {
+		t.Fatalf("load operation logs error = %v", err)
+	}
+	if len(logs) != 1 || logs[0].Ip != "203.0.113.9" || logs[0].Status != http.StatusForbidden || logs[0].Module != "api-token" {
+		t.Fatalf("operation logs = %+v, want one denied attempt from 203.0.113.9", logs)
+	}
+}
+
+func TestApiTokenAuthVerifiesSignedRequests(t *testing.T) {
+	rawToken := "cms_allow_token"
+	engine, svcCtx := newAPITokenMiddlewareTestEngine(t, func(group *gin.RouterGroup) {
+		ok := func(c *gin.Context) {
+			c.JSON(http.StatusOK, gin.H{"code": 0})
+		}
+		group.GET("poetry/dynasty/list", ok)
+		group.POST("poetry/dynasty/list", ok)
+	})
+	var token model.SysApiToken
+	if err := svcCtx.DB.Where("token_hash = ?", tokenCore.HashToken(rawToken)).First(&token).Error; err != nil {
+		t.Fatalf("load api token error = %v", err)
+	}
+	if err := svcCtx.DB.Model(&token).Update("require_signature", true).Error; err != nil {
+		t.Fatalf("require signature error = %v", err)
+	}
+	svcCtx.Config = &config.Config{ApiTokenSign: config.ApiTokenSign{Secret: "sign-secret", MaxBodySizeMB: 1}}
+	keyID := strconv.FormatUint(uint64(token.ID), 10)
+	signingSecret := tokenCore.SigningSecret("sign-secret", token.TokenHash)
+
+	serve := func(req *http.Request) float64 {
+		rec := httptest.NewRecorder()
+		engine.ServeHTTP(rec, req)
+		var body map[string]any
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatalf("unmarshal response error = %v", err)
+		}
+		return body["code"].(float64)
+	}
+	signedWith := func(method, target, secret string, body io.Reader) *http.Request {
+		req := httptest.NewRequest(method, target, body)
+		if err := apisign.Sign(req, keyID, secret); err != nil {
+			t.Fatalf("Sign() error = %v", err)
+		}
+		return req
+	}
+	signed := func(target string) *http.Request {
+		return signedWith(http.MethodGet, target, signingSecret, nil)
+	}
+
+	// 要求签名的令牌拒绝明文请求
+	bearer := httptest.NewRequest(http.MethodGet, "/api/v1/poetry/dynasty/list", nil)
+	bearer.Header.Set("X-API-Token", rawToken)
+	if code := serve(bearer); code != float64(errcode.Unauthorized.Code) {
+		t.Fatalf("bearer request code = %v, want %d", code, errcode.Unauthorized.Code)
+	}
+
+	req := signed("/api/v1/poetry/dynasty/list?b=2&a=1")
+	replay := req.Clone(context.Background())
+	if code := serve(req); code != 0 {
+		t.Fatalf("signed request code = %v, want 0", code)
+	}
+	if code := serve(replay); code != float64(errcode.Unauthorized.Code) {
+		t.Fatalf("replayed request code = %v, want %d", code, errcode.Unauthorized.Code)
+	}
+
+	tampered := signed("/api/v1/poetry/dynasty/list?a=1")
+	tampered.URL.RawQuery = "a=2"
+	if code := serve(tampered); code != float64(errcode.Unauthorized.Code) {
+		t.Fatalf("tampered request code = %v, want %d", code, errcode.Unauthorized.Code)
+	}
+
+	stale := signed("/api/v1/poetry/dynasty/list")
+	stale.Header.Set(apisign.HeaderTimestamp, strconv.FormatInt(time.Now().Add(-time.Hour).Unix(), 10))
+	if code := serve(stale); code != float64(errcode.Unauthorized.Code) {
+		t.Fatalf("stale request code = %v, want %d", code, errcode.Unauthorized.Code)
+	}
+
+	// 库中保存的哈希不能用作签名密钥
+	if code := serve(signedWith(http.MethodGet, "/api/v1/poetry/dynasty/list", token.TokenHash, nil)); code != float64(errcode.Unauthorized.Code) {
+		t.Fatalf("request signed with stored hash code = %v, want %d", code, errcode.Unauthorized.Code)
+	}
+
+	// 请求体超过 max_body_size_mb 时在校验签名前拒绝
+	rec := httptest.NewRecorder()
+	engine.ServeHTTP(rec, signedWith(http.MethodPost, "/api/v1/poetry/dynasty/list", signingSecret, bytes.NewReader(make([]byte, 1<<20+1))))
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("oversized request status = %d, want 413", rec.Code)
+	}
+
+	// 未配置服务端密钥时不接受签名请求
+	svcCtx.Config.ApiTokenSign.Secret = ""
+	if code := serve(signed("/api/v1/poetry/dynasty/list")); code != float64(errcode.Unauthorized.Code) {
+		t.Fatalf("signed request without server secret code = %v, want %d", code, errcode.Unauthorized.Code)
+	}
+}
+
+func TestApiTokenAuthAcceptsPreviousSecretDuringGrace(t *testing.T) {
+	engine, svcCtx := newAPITokenMiddlewareTestEngine(t, func(group *gin.RouterGroup) {
+		group.GET("poetry/dynasty/list", func(c *gin.Context) {
+			c.JSON(http.StatusOK, gin.H{"code": 0})
+		})
+	})
+	// 模拟轮换：cms_allow_token 成为旧密钥
+	graceUntil := time.Now().Add(time.Hour)
+	if err := svcCtx.DB.Model(&model.SysApiToken{}).
+		Where("token_hash = ?", tokenCore.HashToken("cms_allow_token")).
+		Updates(map[string]interface{}{
+			"token_hash":                tokenCore.HashToken("cms_rotated_token"),
+			"previous_token_hash":       tokenCore.HashToken("cms_allow_token"),
+			"previous_token_expires_at": graceUntil,
+		}).Error; err != nil {
+		t.Fatalf("rotate token error = %v", err)
+	}
+
+	serve := func(rawToken string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/poetry/dynasty/list", nil)
+		req.Header.Set("X-API-Token", rawToken)
+		engine.ServeHTTP(rec, req)
+		return rec
+	}
+	code := func(rec *httptest.ResponseRecorder) float64 {
+		var body map[string]any
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatalf("unmarshal response error = %v", err)
+		}
+		return body["code"].(float64)
+	}
+
+	if rec := serve("cms_rotated_token"); code(rec) != 0 || rec.Header().Get("X-API-Token-Grace-Until") != "" {
+		t.Fatalf("new secret code = %v, grace header = %q", code(rec), rec.Header().Get("X-API-Token-Grace-Until"))
+	}
+	rec := serve("cms_allow_token")
+	if code(rec) != 0 || rec.Header().Get("X-API-Token-Grace-Until") != graceUntil.Format(time.RFC3339) {
+		t.Fatalf("previous secret code = %v, grace header = %q", code(rec), rec.Header().Get("X-API-Token-Grace-Until"))
+	}
+	var token model.SysApiToken
+	if err := svcCtx.DB.Where("token_hash = ?", tokenCore.HashToken("cms_rotated_token")).First(&token).Error; err != nil {
+		t.Fatalf("load token error = %v", err)
+	}
+
+	// 宽限期结束后旧密钥失效
+	if err := svcCtx.DB.Model(&token).Update("previous_token_expires_at", time.Now().Add(-time.Minute)).Error; err != nil {
+		t.Fatalf("expire grace error = %v", err)
+	}
+	if err := svcCtx.APITokenCache.Invalidate(context.Background(), token.ID); err != nil {
+		t.Fatalf("Invalidate() error = %v", err)
+	}
+	if got := code(serve("cms_allow_token")); got != float64(errcode.Unauthorized.Code) {
+		t.Fatalf("expired previous secret code = %v, want %d", got, errcode.Unauthorized.Code)
+	}
+
+	// 最近使用时间由用量统计批量写库，关闭时写入剩余数据
+	if err := svcCtx.ApiTokenUsage.Close(context.Background()); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if err := svcCtx.DB.First(&token, token.ID).Error; err != nil {
+		t.Fatalf("load token error = %v", err)
+	}
+	if token.PreviousTokenLastUsedAt == nil {
+		t.Fatal("previous secret usage was not recorded")
+	}
+}
+
+func TestApiTokenAuthEnforcesQuota(t *testing.T) {
+	rawToken := "cms_allow_token"
+	engine, svcCtx := newAPITokenMiddlewareTestEngine(t, func(group *gin.RouterGroup) {
+		group.GET("poetry/dynasty/list", func(c *gin.Context) {
+			c.JSON(http.StatusOK, gin.H{"code": 0})
+		})
+	})
+	if err := svcCtx.DB.Model(&model.SysApiToken{}).
+		Where("token_hash = ?", tokenCore.HashToken(rawToken)).
+		Updates(map[string]any{"quota_per_minute": 2, "quota_per_day": 100}).Error; err != nil {
+		t.Fatalf("update quota error = %v", err)
+	}
+
+	serve := func() *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/poetry/dynasty/list", nil)
+		req.Header.Set("X-API-Token", rawToken)
+		engine.ServeHTTP(rec, req)
+		return rec
+	}
+
+	for _, remaining := range []string{"1", "0"} {
+		rec := serve()
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status = %d, want 200", rec.Code)
+		}
+		if rec.Header().Get("X-RateLimit-Limit") != "2" || rec.Header().Get("X-RateLimit-Remaining") != remaining {
+			t.Fatalf("rate limit headers = %v, want limit 2 remaining %s", rec.Header(), remaining)
+		}
+		if reset, err := strconv.Atoi(rec.Header().Get("X-RateLimit-Reset")); err != nil || reset < 0 || reset > 60 {
+			t.Fatalf("X-RateLimit-Reset = %q, want seconds within a minute", rec.Header().Get("X-RateLimit-Reset"))
+		}
+	}
+
+	rec := serve()
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("status = %d, want 429", rec.Code)
+	}
+	if rec.Header().Get("Retry-After") == "" || rec.Header().Get("X-RateLimit-Remaining") != "0" {
+		t.Fatalf("headers = %v, want Retry-After", rec.Header())
+	}
+	var body map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("unmarshal response error = %v", err)
+	}
+	if int(body["code"].(float64)) != 1010 {
+		t.Fatalf("response code = %v, want 1010", body["code"])
+	}
+}
+
+func TestApiTokenAuthConcurrencyRejectionSkipsQuota(t *testing.T) {
+	rawToken := "cms_allow_token"
+	entered := make(chan struct{})
+	unblock := make(chan struct{})
+	engine, svcCtx := newAPITokenMiddlewareTestEngine(t, func(group *gin.RouterGroup) {
+		group.GET("poetry/dynasty/list", func(c *gin.Context) {
+			if c.Query("block") != "" {
+				close(entered)
+				<-unblock
+			}
+			c.JSON(http.StatusOK, gin.H{"code": 0})
+		})
+	})
+	if err := svcCtx.DB.Model(&model.SysApiToken{}).
+		Where("token_hash = ?", tokenCore.HashToken(rawToken)).
+		Update("quota_per_minute", 2).Error; err != nil {
+		t.Fatalf("update quota error = %v", err)
+	}
+
+	serve := func(target string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		req.Header.Set("X-API-Token", rawToken)
+		engine.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// 第一个请求占住唯一的并发名额
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- serve("/api/v1/poetry/dynasty/list?block=1") }()
+	<-entered
+
+	rec := serve("/api/v1/poetry/dynasty/list")
+	var body map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("unmarshal response error = %v", err)
+	}
+	if int(body["code"].(float64)) != 1004 {
+		t.Fatalf("concurrent response code = %v, want 1004", body["code"])
+	}
+
+	close(unblock)
+	if first := <-done; first.Code != http.StatusOK || first.Header().Get("X-RateLimit-Remaining") != "1" {
+		t.Fatalf("first request status = %d headers = %v, want 200 remaining 1", first.Code, first.Header())
+	}
+	// 被并发上限拒绝的请求不计入配额
+	if rec := serve("/api/v1/poetry/dynasty/list"); rec.Code != http.StatusOK || rec.Header().Get("X-RateLimit-Remaining") != "0" {
+		t.Fatalf("status = %d headers = %v, want 200 remaining 0", rec.Code, rec.Header())
+	}
+}
+
+func TestApiTokenAuthRecordsUsage(t *testing.T) {
+	rawToken := "cms_allow_token"
+	failed := false
+	engine, svcCtx := newAPITokenMiddlewareTestEngine(t, func(group *gin.RouterGroup) {
+		group.GET("poetry/dynasty/list", func(c *gin.Context) {
+			if failed {
+				// 业务错误同样返回 HTTP 200
+				response.FailWithCode(errcode.ServerError, c)
+				return
+			}
+			response.Ok(c)
+		})
+	})
+
+	for _, fail := range []bool{false, false, true} {
+		failed = fail
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/poetry/dynasty/list", nil)
+		req.Header.Set("X-API-Token", rawToken)
+		engine.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status = %d, want 200", rec.Code)
+		}
+	}
+	if err := svcCtx.ApiTokenUsage.Close(context.Background()); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	var usage model.SysApiTokenUsage
+	if err := svcCtx.DB.First(&usage).Error; err != nil {
+		t.Fatalf("load usage error = %v", err)
+	}
+	if usage.Method != http.MethodGet || usage.Path != "/api/v1/poetry/dynasty/list" {
+		t.Fatalf("usage route = %s %s, want the route template", usage.Method, usage.Path)
+	}
+	if usage.Calls != 3 || usage.Errors != 1 || usage.BytesOut == 0 {
+		t.Fatalf("usage = %+v, want 3 calls with 1 error", usage)
+	}
+
+	var token model.SysApiToken
+	if err := svcCtx.DB.Where("token_hash = ?", tokenCore.HashToken(rawToken)).First(&token).Error; err != nil {
+		t.Fatalf("load api token error = %v", err)
+	}
+	if token.LastUsedAt == nil {
+		t.Fatal("last_used_at = nil, want updated by the usage recorder")
+	}
+}
+
 func newAPITokenMiddlewareTestEngine(t *testing.T, registerRoutes func(group *gin.RouterGroup)) (*gin.Engine, *svc.ServiceContext) {
 	t.Helper()
 
@@ -108,6 +529,8 @@ func newAPITokenMiddlewareTestEngine(t *testing.T, registerRoutes func(group *gi
 		&model.SysApi{},
 		&model.SysApiToken{},
 		&model.SysApiTokenApi{},
+		&model.SysApiTokenScope{},
+		&model.SysApiTokenUsage{},
 	); err != nil {
 		t.Fatalf("AutoMigrate() error = %v", err)
 	}
@@ -158,6 +581,7 @@ func newAPITokenMiddlewareTestEngine(t *testing.T, registerRoutes func(group *gi
 	svcCtx := svc.NewServiceContext()
 	svcCtx.DB = gormDB
 	svcCtx.Logger = zap.NewNop()
+	initAuthenticatorTestDeps(t, svcCtx)
 
 	engine := gin.New()
 	group := engine.Group("/api/v1")
@@ -165,3 +589,20 @@ func newAPITokenMiddlewareTestEngine(t *testing.T, registerRoutes func(group *gi
 	registerRoutes(group)
 	return engine, svcCtx
 }
+
+// initAuthenticatorTestDeps 按未启用 Redis 时的启动流程初始化认证器依赖
+func initAuthenticatorTestDeps(t *testing.T, svcCtx *svc.ServiceContext) {
+	t.Helper()
+	store := cache.NewMemoryStore()
+	svcCtx.Cache = store
+	svcCtx.APITokenCache = tokencache.New(svcCtx.DB, nil, svcCtx.Logger)
+	svcCtx.OAuthClients = oauthclient.New(svcCtx.DB, nil, svcCtx.Logger)
+	svcCtx.ApiTokenUsage = apiusage.NewRecorder(svcCtx.DB, svcCtx.Cache, config.ApiTokenUsage{}, svcCtx.Logger)
+	t.Cleanup(func() {
+		ctx := context.Background()
+		_ = svcCtx.ApiTokenUsage.Close(ctx)
+		_ = svcCtx.OAuthClients.Close(ctx)
+		_ = svcCtx.APITokenCache.Close(ctx)
+		_ = store.Close(ctx)
+	})
+}
@@ -0,0 +1,121 @@
+package middleware
+
+import (
+	"errors"
+
+	"github.com/CIPFZ/gowebframe/internal/core/claims"
+	logger "github.com/CIPFZ/gowebframe/internal/core/log"
+	"github.com/CIPFZ/gowebframe/pkg/errcode"
+	"github.com/CIPFZ/gowebframe/pkg/response"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+const CtxKeyPrincipal = "principal"
+
+// ErrNoCredentials 请求未携带该认证方式的凭证，认证链继续尝试下一个
+var ErrNoCredentials = errors.New("no credentials")
+
+// Authenticator 一种认证方式 (JWT、API Token、签名请求、OAuth2 客户端、匿名)
+// 未携带对应凭证时返回 ErrNoCredentials；凭证无效时返回 *errcode.Error，认证链直接拒绝，不再尝试后续方式
+type Authenticator interface {
+	Authenticate(c *gin.Context) (*claims.Principal, error)
+}
+
+// statusError 需要以指定 HTTP 状态码返回的认证错误，如签名请求体过大时返回 413
+type statusError struct {
+	status int
+	err    *errcode.Error
+}
+
+func (e *statusError) Error() string { return e.err.Error() }
+
+// admitter 认证通过后、进入业务处理前的准入检查，如 API Token 的来源 IP、授权范围、配额与并发
+// 不通过时自行写入响应并返回 false；done 在请求处理完成后调用
+type admitter interface {
+	admit(c *gin.Context, p *claims.Principal) (done func(), ok bool)
+}
+
+// Authenticate 按顺序尝试各认证方式，第一个识别出凭证的决定结果，都未携带凭证时返回 401
+// 识别出的调用方写入 gin.Context (GetPrincipal) 与请求的 context.Context (claims.PrincipalFromContext)，
+// 用户同时写入 claims/userId 等原有键，兼容现有的 utils.GetUserID 等读取方式
+//
+//	group.Use(middleware.Authenticate(
+//		middleware.NewJWTAuthenticator(svcCtx),
+//		middleware.NewAPITokenAuthenticator(svcCtx),
+//	), middleware.CasbinHandler(svcCtx))
+func Authenticate(authenticators ...Authenticator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		for _, a := range authenticators {
+			p, err := a.Authenticate(c)
+			if errors.Is(err, ErrNoCredentials) {
+				continue
+			}
+			if err != nil {
+				var se *statusError
+				var e *errcode.Error
+				if errors.As(err, &se) {
+					c.AbortWithStatusJSON(se.status, response.Response{Code: se.err.Code, Msg: se.err.Msg})
+					return
+				}
+				if errors.As(err, &e) {
+					response.FailWithCode(e, c)
+				} else {
+					logger.GetLogger(c).Error("authenticate_failed", zap.Error(err))
+					response.FailWithCode(errcode.ServerError, c)
+				}
+				c.Abort()
+				return
+			}
+
+			setPrincipalOnContext(c, p)
+			if ad, ok := a.(admitter); ok {
+				done, admitted := ad.admit(c, p)
+				if !admitted {
+					return
+				}
+				defer done()
+			}
+			c.Next()
+			return
+		}
+
+		response.FailWithCode(errcode.Unauthorized, c)
+		c.Abort()
+	}
+}
+
+// GetPrincipal 读取认证链识别出的调用方，未经过认证链时返回 nil
+func GetPrincipal(c *gin.Context) *claims.Principal {
+	if v, exists := c.Get(CtxKeyPrincipal); exists {
+		if p, ok := v.(*claims.Principal); ok {
+			return p
+		}
+	}
+	return nil
+}
+
+func setPrincipalOnContext(c *gin.Context, p *claims.Principal) {
+	c.Set(CtxKeyPrincipal, p)
+	c.Request = c.Request.WithContext(claims.WithPrincipal(c.Request.Context(), p))
+	switch p.Kind {
+	case claims.PrincipalUser:
+		setClaimsOnContext(c, p.Claims)
+	case claims.PrincipalAPIToken:
+		c.Set(CtxKeyAPITokenID, p.TokenID)
+	case claims.PrincipalOAuthClient:
+		c.Set(CtxKeyOAuthClientID, p.ClientID)
+	}
+}
+
+type anonymousAuthenticator struct{}
+
+// NewAnonymousAuthenticator 放在认证链末尾，未携带任何凭证的请求以匿名身份继续
+// 携带了无效凭证的请求仍由前面的认证方式拒绝，不会降级为匿名
+func NewAnonymousAuthenticator() Authenticator {
+	return anonymousAuthenticator{}
+}
+
+func (anonymousAuthenticator) Authenticate(*gin.Context) (*claims.Principal, error) {
+	return &claims.Principal{Kind: claims.PrincipalAnonymous}, nil
+}
@@ -0,0 +1,209 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/CIPFZ/gowebframe/internal/core/claims"
+	"github.com/CIPFZ/gowebframe/internal/core/config"
+	"github.com/CIPFZ/gowebframe/internal/core/db"
+	tokenCore "github.com/CIPFZ/gowebframe/internal/core/token"
+	"github.com/CIPFZ/gowebframe/internal/modules/system/model"
+	"github.com/CIPFZ/gowebframe/internal/svc"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+func TestAuthenticateAllowsApiTokenRequests(t *testing.T) {
+	rawToken := "cms_allow_token"
+	engine, _ := newAuthenticatorTestEngine(t, func(group *gin.RouterGroup, svcCtx *svc.ServiceContext) {
+		group.GET("poetry/dynasty/list", readChain(svcCtx), func(c *gin.Context) {
+			c.JSON(http.StatusOK, gin.H{"code": 0})
+		})
+	})
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/poetry/dynasty/list", nil)
+	req.Header.Set("X-API-Token", rawToken)
+	engine.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+
+	var body map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("unmarshal response error = %v", err)
+	}
+	if int(body["code"].(float64)) != 0 {
+		t.Fatalf("response code = %v, want 0", body["code"])
+	}
+}
+
+func TestAuthenticateRejectsRequestsWithoutCredentials(t *testing.T) {
+	engine, _ := newAuthenticatorTestEngine(t, func(group *gin.RouterGroup, svcCtx *svc.ServiceContext) {
+		group.GET("poetry/dynasty/list", readChain(svcCtx), func(c *gin.Context) {
+			c.JSON(http.StatusOK, gin.H{"code": 0})
+		})
+	})
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/poetry/dynasty/list", nil)
+	engine.ServeHTTP(rec, req)
+
+	var body map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("unmarshal response error = %v", err)
+	}
+	if int(body["code"].(float64)) != 1003 {
+		t.Fatalf("response code = %v, want 1003", body["code"])
+	}
+}
+
+func TestAuthenticateFallsBackToAnonymous(t *testing.T) {
+	engine, _ := newAuthenticatorTestEngine(t, func(group *gin.RouterGroup, svcCtx *svc.ServiceContext) {
+		group.GET("poetry/dynasty/list", Authenticate(NewAPITokenAuthenticator(svcCtx), NewAnonymousAuthenticator()), func(c *gin.Context) {
+			c.JSON(http.StatusOK, gin.H{"code": 0, "kind": GetPrincipal(c).Kind})
+		})
+	})
+
+	rec := httptest.NewRecorder()
+	engine.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/poetry/dynasty/list", nil))
+	var body map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("unmarshal response error = %v", err)
+	}
+	if body["kind"] != string(claims.PrincipalAnonymous) {
+		t.Fatalf("principal kind = %v, want anonymous", body["kind"])
+	}
+
+	// 携带了无效凭证时不降级为匿名
+	rec = httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/poetry/dynasty/list", nil)
+	req.Header.Set("X-API-Token", "cms_unknown_token")
+	engine.ServeHTTP(rec, req)
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("unmarshal response error = %v", err)
+	}
+	if int(body["code"].(float64)) != 1003 {
+		t.Fatalf("invalid token response code = %v, want 1003", body["code"])
+	}
+}
+
+func TestAuthenticateWritesPrincipalToRequestContext(t *testing.T) {
+	var got *claims.Principal
+	engine, _ := newAuthenticatorTestEngine(t, func(group *gin.RouterGroup, svcCtx *svc.ServiceContext) {
+		group.GET("poetry/dynasty/list", readChain(svcCtx), func(c *gin.Context) {
+			got, _ = claims.PrincipalFromContext(c.Request.Context())
+			c.JSON(http.StatusOK, gin.H{"code": 0})
+		})
+	})
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/poetry/dynasty/list", nil)
+	req.Header.Set("X-API-Token", "cms_allow_token")
+	engine.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+	if !got.IsAPIToken() || got.TokenID == 0 || got.Scopes == nil {
+		t.Fatalf("principal = %+v, want api token principal with scopes", got)
+	}
+	if !got.Scopes.Allows(http.MethodGet, "/api/v1/poetry/dynasty/list") {
+		t.Fatal("principal scopes should allow the granted api")
+	}
+}
+
+func TestCasbinHandlerRejectsAnonymousPrincipal(t *testing.T) {
+	engine, _ := newAuthenticatorTestEngine(t, func(group *gin.RouterGroup, svcCtx *svc.ServiceContext) {
+		group.GET("poetry/dynasty/list", Authenticate(NewAnonymousAuthenticator()), CasbinHandler(svcCtx), func(c *gin.Context) {
+			c.JSON(http.StatusOK, gin.H{"code": 0})
+		})
+	})
+
+	rec := httptest.NewRecorder()
+	engine.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/poetry/dynasty/list", nil))
+	var body map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("unmarshal response error = %v", err)
+	}
+	if int(body["code"].(float64)) != 1004 {
+		t.Fatalf("response code = %v, want 1004", body["code"])
+	}
+}
+
+// readChain 与 poetry 查询接口相同的认证链
+func readChain(svcCtx *svc.ServiceContext) gin.HandlerFunc {
+	return Authenticate(NewJWTAuthenticator(svcCtx), NewAPITokenAuthenticator(svcCtx), NewHMACAuthenticator(svcCtx))
+}
+
+func newAuthenticatorTestEngine(t *testing.T, registerRoutes func(group *gin.RouterGroup, svcCtx *svc.ServiceContext)) (*gin.Engine, *svc.ServiceContext) {
+	t.Helper()
+
+	gin.SetMode(gin.TestMode)
+	gormDB, err := db.InitDatabase(config.Database{
+		Driver: "sqlite3",
+		SQLite: config.SQLite{
+			Path:         filepath.Join(t.TempDir(), "authenticator.db"),
+			MaxIdleConns: 1,
+			MaxOpenConns: 1,
+		},
+	}, zap.NewNop())
+	if err != nil {
+		t.Fatalf("InitDatabase() error = %v", err)
+	}
+	if err := gormDB.AutoMigrate(
+		&model.SysApi{},
+		&model.SysApiToken{},
+		&model.SysApiTokenApi{},
+		&model.SysApiTokenScope{},
+	); err != nil {
+		t.Fatalf("AutoMigrate() error = %v", err)
+	}
+	sqlDB, err := gormDB.DB()
+	if err != nil {
+		t.Fatalf("DB() error = %v", err)
+	}
+	t.Cleanup(func() {
+		_ = sqlDB.Close()
+	})
+
+	api := model.SysApi{
+		Path:        "/api/v1/poetry/dynasty/list",
+		Method:      "GET",
+		ApiGroup:    "poetry",
+		Description: "List dynasty",
+	}
+	if err := gormDB.Create(&api).Error; err != nil {
+		t.Fatalf("create sys api error = %v", err)
+	}
+
+	expiresAt := time.Now().Add(time.Hour)
+	token := model.SysApiToken{
+		TokenHash:      tokenCore.HashToken("cms_allow_token"),
+		TokenPrefix:    "cms_allo",
+		Name:           "allowed",
+		MaxConcurrency: 1,
+		Enabled:        true,
+		ExpiresAt:      &expiresAt,
+		Apis:           []model.SysApi{api},
+	}
+	if err := gormDB.Create(&token).Error; err != nil {
+		t.Fatalf("create api token error = %v", err)
+	}
+
+	svcCtx := svc.NewServiceContext()
+	svcCtx.DB = gormDB
+	svcCtx.Logger = zap.NewNop()
+	initAuthenticatorTestDeps(t, svcCtx)
+
+	engine := gin.New()
+	group := engine.Group("/api/v1")
+	registerRoutes(group, svcCtx)
+	return engine, svcCtx
+}
@@ -1,39 +1,20 @@
 package middleware
 
 import (
-	"github.com/CIPFZ/gowebframe/pkg/errcode"
 	"strconv"
 
 	"github.com/CIPFZ/gowebframe/internal/core/claims"
 	"github.com/CIPFZ/gowebframe/internal/svc"
+	"github.com/CIPFZ/gowebframe/pkg/errcode"
 	"github.com/CIPFZ/gowebframe/pkg/response"
 	"github.com/gin-gonic/gin"
 )
 
+// CasbinHandler 需挂在认证链之后，按调用方类型校验权限：
+// 用户按角色查 Casbin 策略；API Token 与 OAuth2 客户端按授权范围；匿名调用一律拒绝
 func CasbinHandler(svcCtx *svc.ServiceContext) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// 1. 获取请求信息
-		waitUse, _ := c.Get("claims")
-		waitUseClaims := waitUse.(*claims.CustomClaims)
-
-		// 获取请求的 PATH 和 METHOD
-		obj := c.Request.URL.Path
-		act := c.Request.Method
-		// 获取用户的角色ID (Casbin 中通常存为字符串)
-		sub := strconv.Itoa(int(waitUseClaims.AuthorityId))
-
-		e := svcCtx.CasbinEnforcer
-
-		// 2. 判断权限
-		// 格式: Enforce(sub, obj, act) -> (角色ID, 路径, 方法)
-		success, _ := e.Enforce(sub, obj, act)
-
-		// 如果是超级管理员，直接放行
-		if waitUseClaims.AuthorityId == 1 {
-			success = true
-		}
-
-		if !success {
+		if !allowPrincipal(svcCtx, c, GetPrincipal(c)) {
 			response.FailWithError(errcode.AssessDenied, c)
 			c.Abort()
 			return
@@ -42,3 +23,31 @@ func CasbinHandler(svcCtx *svc.ServiceContext) gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+func allowPrincipal(svcCtx *svc.ServiceContext, c *gin.Context, p *claims.Principal) bool {
+	switch {
+	case p.IsUser():
+		// 如果是超级管理员，直接放行
+		if p.AuthorityID == 1 {
+			return true
+		}
+		e := svcCtx.CasbinEnforcer
+		if e == nil {
+			return false
+		}
+		// 格式: Enforce(sub, obj, act) -> (角色ID, 路径, 方法)
+		success, _ := e.Enforce(strconv.Itoa(int(p.AuthorityID)), c.Request.URL.Path, c.Request.Method)
+		return success
+	case p.IsAPIToken(), p.IsOAuthClient():
+		if p.Scopes == nil {
+			return false
+		}
+		path := c.FullPath()
+		if path == "" {
+			path = c.Request.URL.Path
+		}
+		return p.Scopes.Allows(c.Request.Method, path)
+	default:
+		return false
+	}
+}
@@ -0,0 +1,20 @@
+package middleware
+
+import (
+	"github.com/CIPFZ/gowebframe/pkg/errcode"
+	"github.com/CIPFZ/gowebframe/pkg/response"
+	"github.com/CIPFZ/gowebframe/pkg/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// DenyImpersonation 拒绝模拟登录令牌访问敏感接口 (修改密码、二次验证、签发令牌等)
+func DenyImpersonation() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if utils.GetImpersonatorID(c) != 0 {
+			response.FailWithCode(errcode.ImpersonationForbidden, c)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
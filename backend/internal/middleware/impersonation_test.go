@@ -0,0 +1,173 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/CIPFZ/gowebframe/internal/core/audit"
+	"github.com/CIPFZ/gowebframe/internal/core/config"
+	"github.com/CIPFZ/gowebframe/internal/core/db"
+	corejwt "github.com/CIPFZ/gowebframe/internal/core/jwt"
+	"github.com/CIPFZ/gowebframe/internal/modules/system/dto"
+	"github.com/CIPFZ/gowebframe/internal/modules/system/model"
+	"github.com/CIPFZ/gowebframe/internal/svc"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+func TestImpersonationRecordsEveryRequest(t *testing.T) {
+	engine, svcCtx, gormDB := newImpersonationTestEngine(t)
+	impersonated := impersonationTestToken(t, svcCtx, 9)
+	normal := impersonationTestToken(t, svcCtx, 0)
+
+	for _, tc := range []struct {
+		method, path string
+		token        string
+	}{
+		{http.MethodGet, "/api/v1/read", impersonated},                // 模拟登录：查询也记录
+		{http.MethodPost, "/api/v1/write", impersonated},              // 模拟登录：写操作只记录一次
+		{http.MethodGet, "/api/v1/read", normal},                      // 普通登录：查询不记录
+		{http.MethodPost, "/api/v1/write", normal},                    // 普通登录：写操作照常记录
+		{http.MethodPost, "/api/v1/secret", impersonated},             // 模拟登录：敏感接口被拒绝，同样记录
+		{http.MethodGet, "/api/v1/poetry/dynasty/list", impersonated}, // 模拟登录：混合认证的查询接口同样记录
+		{http.MethodGet, "/api/v1/poetry/dynasty/list", normal},       // 普通登录：混合认证的查询接口不记录
+	} {
+		req := httptest.NewRequest(tc.method, tc.path, nil)
+		req.Header.Set("x-token", tc.token)
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s %s status = %d, want 200", tc.method, tc.path, w.Code)
+		}
+	}
+	if err := svcCtx.AuditRecorder.Close(context.Background()); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	var logs []model.SysOperationLog
+	if err := gormDB.Order("id").Find(&logs).Error; err != nil {
+		t.Fatalf("find logs error = %v", err)
+	}
+	want := []struct {
+		method, path   string
+		impersonatorID uint
+	}{
+		{http.MethodGet, "/api/v1/read", 9},
+		{http.MethodPost, "/api/v1/write", 9},
+		{http.MethodPost, "/api/v1/write", 0},
+		{http.MethodPost, "/api/v1/secret", 9},
+		{http.MethodGet, "/api/v1/poetry/dynasty/list", 9},
+	}
+	if len(logs) != len(want) {
+		t.Fatalf("operation logs = %d, want %d", len(logs), len(want))
+	}
+	for i, w := range want {
+		got := logs[i]
+		if got.Method != w.method || got.Path != w.path || got.ImpersonatorID != w.impersonatorID || got.UserID != 1 {
+			t.Fatalf("log[%d] = %s %s user=%d impersonator=%d, want %s %s user=1 impersonator=%d",
+				i, got.Method, got.Path, got.UserID, got.ImpersonatorID, w.method, w.path, w.impersonatorID)
+		}
+	}
+}
+
+func TestDenyImpersonation(t *testing.T) {
+	engine, svcCtx, _ := newImpersonationTestEngine(t)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/secret", nil)
+	req.Header.Set("x-token", impersonationTestToken(t, svcCtx, 9))
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+
+	var body map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	if int(body["code"].(float64)) != 1015 {
+		t.Fatalf("response code = %v, want 1015", body["code"])
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/api/v1/secret", nil)
+	req.Header.Set("x-token", impersonationTestToken(t, svcCtx, 0))
+	w = httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	if w.Body.String() != "ok" {
+		t.Fatalf("response body = %q, want ok", w.Body.String())
+	}
+}
+
+// newImpersonationTestEngine 登录令牌路由组，以及与 apiTokenGroup 相同认证链的 poetry 查询接口
+func newImpersonationTestEngine(t *testing.T) (*gin.Engine, *svc.ServiceContext, *gorm.DB) {
+	t.Helper()
+
+	gin.SetMode(gin.TestMode)
+	gormDB, err := db.InitDatabase(config.Database{
+		Driver: "sqlite3",
+		SQLite: config.SQLite{
+			Path:         filepath.Join(t.TempDir(), "impersonation.db"),
+			MaxIdleConns: 1,
+			MaxOpenConns: 1,
+		},
+	}, zap.NewNop())
+	if err != nil {
+		t.Fatalf("InitDatabase() error = %v", err)
+	}
+	if err := gormDB.AutoMigrate(&model.SysOperationLog{}, &model.JwtBlacklist{}); err != nil {
+		t.Fatalf("AutoMigrate() error = %v", err)
+	}
+	sqlDB, err := gormDB.DB()
+	if err != nil {
+		t.Fatalf("DB() error = %v", err)
+	}
+	t.Cleanup(func() {
+		_ = sqlDB.Close()
+	})
+
+	j, err := corejwt.NewJWT(config.JWT{SigningKey: "test", Issuer: "test"}, zap.NewNop(), nil, gormDB)
+	if err != nil {
+		t.Fatalf("NewJWT() error = %v", err)
+	}
+	t.Cleanup(func() {
+		_ = j.Close(context.Background())
+	})
+
+	svcCtx := svc.NewServiceContext()
+	svcCtx.DB = gormDB
+	svcCtx.Logger = zap.NewNop()
+	initAuthenticatorTestDeps(t, svcCtx)
+	svcCtx.JWT = j
+	svcCtx.AuditRecorder = audit.NewAuditRecorder(gormDB, zap.NewNop())
+
+	engine := gin.New()
+	ok := func(c *gin.Context) { c.String(http.StatusOK, "ok") }
+	group := engine.Group("/api/v1", JWTAuth(svcCtx))
+	group.GET("read", ok)
+	group.POST("write", OperationRecord(svcCtx), ok)
+	group.POST("secret", OperationRecord(svcCtx), DenyImpersonation(), ok)
+	engine.Group("/api/v1", Authenticate(
+		NewJWTAuthenticator(svcCtx),
+		NewAPITokenAuthenticator(svcCtx),
+		NewHMACAuthenticator(svcCtx),
+		NewOAuthAuthenticator(svcCtx),
+	)).GET("poetry/dynasty/list", ok)
+	return engine, svcCtx, gormDB
+}
+
+// impersonationTestToken 为用户 1 签发登录令牌，impersonatorID 非 0 时为模拟登录令牌
+func impersonationTestToken(t *testing.T, svcCtx *svc.ServiceContext, impersonatorID uint) string {
+	t.Helper()
+	base := dto.BaseClaims{UserID: 1, Username: "alice", AuthorityId: 888, ImpersonatorID: impersonatorID}
+	c := svcCtx.JWT.CreateClaims(base)
+	if impersonatorID != 0 {
+		c = svcCtx.JWT.CreateImpersonationClaims(base)
+	}
+	token, err := svcCtx.JWT.CreateToken(c)
+	if err != nil {
+		t.Fatalf("CreateToken() error = %v", err)
+	}
+	return token
+}
@@ -5,10 +5,9 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/CIPFZ/gowebframe/internal/core/claims"
 	"github.com/CIPFZ/gowebframe/internal/svc"
 	"github.com/CIPFZ/gowebframe/pkg/errcode"
-	"github.com/CIPFZ/gowebframe/pkg/response"
-	"github.com/CIPFZ/gowebframe/pkg/utils"
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 )
@@ -18,64 +17,94 @@ const (
 	CtxKeyUserID      = "userId"
 	CtxKeyUserUUID    = "userUUID"
 	CtxKeyAuthorityId = "authorityId"
+	CtxKeySessionID   = "sessionId"
+
+	CtxKeyImpersonatorID = "impersonatorId"
 )
 
+// sessionTouchInterval 会话最后活跃时间的最小刷新间隔，避免每个请求都写存储
+const sessionTouchInterval = time.Minute
+
+// JWTAuth 仅接受登录令牌的路由组
 func JWTAuth(svcCtx *svc.ServiceContext) gin.HandlerFunc {
-	return func(c *gin.Context) {
-		token := c.Request.Header.Get("x-token")
-		if token == "" {
-			token, _ = c.Cookie("x-token")
-		}
+	return Authenticate(NewJWTAuthenticator(svcCtx))
+}
 
-		if token == "" {
-			response.FailWithCode(errcode.Unauthorized, c)
-			c.Abort()
-			return
-		}
+type jwtAuthenticator struct {
+	svcCtx *svc.ServiceContext
+}
 
-		if svcCtx.JWT.IsBlacklist(c.Request.Context(), token) {
-			response.FailWithCode(errcode.Unauthorized.WithDetails("令牌已失效"), c)
-			c.Abort()
-			return
-		}
+// NewJWTAuthenticator 登录令牌认证，令牌取自 x-token 请求头或同名 Cookie
+func NewJWTAuthenticator(svcCtx *svc.ServiceContext) Authenticator {
+	return &jwtAuthenticator{svcCtx: svcCtx}
+}
 
-		j := svcCtx.JWT
-		claims, err := j.ParseToken(token)
-		if err != nil {
-			if errors.Is(err, jwt.ErrTokenExpired) {
-				response.FailWithCode(errcode.Unauthorized.WithDetails("授权已过期"), c)
-				c.Abort()
-				return
-			}
-			response.FailWithCode(errcode.Unauthorized.WithDetails("无效的令牌"), c)
-			c.Abort()
-			return
-		}
+func (a *jwtAuthenticator) Authenticate(c *gin.Context) (*claims.Principal, error) {
+	token := c.Request.Header.Get("x-token")
+	if token == "" {
+		token, _ = c.Cookie("x-token")
+	}
+	if token == "" {
+		return nil, ErrNoCredentials
+	}
+
+	if a.svcCtx.JWT.IsBlacklist(c.Request.Context(), token) {
+		return nil, errcode.Unauthorized.WithDetails("令牌已失效")
+	}
 
-		if claims.ExpiresAt.Unix()-time.Now().Unix() < claims.BufferTime {
-			dr, _ := utils.ParseDuration(svcCtx.Config.JWT.ExpiresTime)
-			newToken, _, err := j.ResolveToken(c.Request.Context(), token, claims)
-			if err == nil && newToken != "" {
-				c.Header("new-token", newToken)
-				c.Header("x-token", newToken)
-				c.SetSameSite(http.SameSiteLaxMode)
-				c.SetCookie(
-					"x-token",
-					newToken,
-					int(dr.Seconds()),
-					"/",
-					"",
-					false,
-					true,
-				)
-			}
+	parsedClaims, err := a.svcCtx.JWT.ParseToken(token)
+	if err != nil {
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			return nil, errcode.Unauthorized.WithDetails("授权已过期")
 		}
+		return nil, errcode.Unauthorized.WithDetails("无效的令牌")
+	}
+
+	if !checkSession(a.svcCtx, c, parsedClaims) {
+		return nil, errcode.Unauthorized.WithDetails("会话已失效")
+	}
 
-		c.Set(CtxKeyClaims, claims)
-		c.Set(CtxKeyUserID, claims.UserID)
-		c.Set(CtxKeyUserUUID, claims.UUID)
-		c.Set(CtxKeyAuthorityId, claims.AuthorityId)
+	return &claims.Principal{
+		Kind:        claims.PrincipalUser,
+		UserID:      parsedClaims.UserID,
+		AuthorityID: parsedClaims.AuthorityId,
+		Claims:      parsedClaims,
+	}, nil
+}
+
+// admit 模拟登录期间的所有请求 (包括查询) 都写入操作日志，同时记录被模拟用户与发起人
+// 在认证步骤中记录，凡是接受登录令牌的路由组都会覆盖；普通登录请求由各写路由组上的 OperationRecord 负责记录
+func (a *jwtAuthenticator) admit(c *gin.Context, p *claims.Principal) (func(), bool) {
+	if p.Claims.ImpersonatorID == 0 || c.Request.Method == http.MethodOptions {
+		return func() {}, true
+	}
+	return beginOperationRecord(a.svcCtx, c), true
+}
 
-		c.Next()
+// checkSession 校验 token 所属会话仍然有效 (未被踢出/登出)，并刷新最后活跃时间
+func checkSession(svcCtx *svc.ServiceContext, c *gin.Context, parsedClaims *claims.CustomClaims) bool {
+	if parsedClaims.SessionID == "" || svcCtx.Sessions == nil {
+		return true
 	}
+
+	ctx := c.Request.Context()
+	sess, err := svcCtx.Sessions.Get(ctx, parsedClaims.SessionID)
+	if err != nil || sess.UserID != parsedClaims.UserID {
+		return false
+	}
+
+	now := time.Now()
+	if now.Sub(sess.LastSeenAt) > sessionTouchInterval {
+		_ = svcCtx.Sessions.Touch(ctx, sess.ID, now)
+	}
+	return true
+}
+
+func setClaimsOnContext(c *gin.Context, parsedClaims *claims.CustomClaims) {
+	c.Set(CtxKeyClaims, parsedClaims)
+	c.Set(CtxKeyUserID, parsedClaims.UserID)
+	c.Set(CtxKeyUserUUID, parsedClaims.UUID)
+	c.Set(CtxKeyAuthorityId, parsedClaims.AuthorityId)
+	c.Set(CtxKeySessionID, parsedClaims.SessionID)
+	c.Set(CtxKeyImpersonatorID, parsedClaims.ImpersonatorID)
 }
@@ -0,0 +1,151 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/CIPFZ/gowebframe/internal/core/config"
+	"github.com/CIPFZ/gowebframe/internal/core/db"
+	"github.com/CIPFZ/gowebframe/internal/core/jwt"
+	"github.com/CIPFZ/gowebframe/internal/modules/system/model"
+	"github.com/CIPFZ/gowebframe/internal/svc"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+func TestOAuthAuthenticatorAllowsGrantedGroup(t *testing.T) {
+	engine, svcCtx := newOAuthMiddlewareTestEngine(t)
+	token := issueTestClientToken(t, svcCtx, "poetry")
+
+	if code := serveOAuthRequest(engine, "/api/v1/poetry/dynasty/list", token); code != 0 {
+		t.Fatalf("response code = %d, want 0", code)
+	}
+	// 未登记到授权分组的接口
+	if code := serveOAuthRequest(engine, "/api/v1/poetry/author/list", token); code != 1004 {
+		t.Fatalf("out of scope response code = %d, want 1004", code)
+	}
+}
+
+func TestOAuthAuthenticatorIntersectsScopeWithClientGrant(t *testing.T) {
+	engine, svcCtx := newOAuthMiddlewareTestEngine(t)
+
+	// 令牌申请了客户端未被授权的分组，按交集处理
+	token := issueTestClientToken(t, svcCtx, "system-api")
+	if code := serveOAuthRequest(engine, "/api/v1/poetry/dynasty/list", token); code != 1004 {
+		t.Fatalf("response code = %d, want 1004", code)
+	}
+}
+
+func TestOAuthAuthenticatorRejectsRevokedAndDisabled(t *testing.T) {
+	engine, svcCtx := newOAuthMiddlewareTestEngine(t)
+
+	revoked := issueTestClientToken(t, svcCtx, "poetry")
+	if err := svcCtx.JWT.SetBlacklist(context.Background(), revoked, time.Minute); err != nil {
+		t.Fatalf("SetBlacklist() error = %v", err)
+	}
+	if code := serveOAuthRequest(engine, "/api/v1/poetry/dynasty/list", revoked); code != 1003 {
+		t.Fatalf("revoked token response code = %d, want 1003", code)
+	}
+
+	token := issueTestClientToken(t, svcCtx, "poetry")
+	if err := svcCtx.DB.Model(&model.SysOauthClient{}).Where("client_id = ?", "gwc_test").Update("enabled", false).Error; err != nil {
+		t.Fatalf("disable client error = %v", err)
+	}
+	_ = svcCtx.OAuthClients.Invalidate(context.Background(), "gwc_test")
+	if code := serveOAuthRequest(engine, "/api/v1/poetry/dynasty/list", token); code != 1003 {
+		t.Fatalf("disabled client response code = %d, want 1003", code)
+	}
+}
+
+func issueTestClientToken(t *testing.T, svcCtx *svc.ServiceContext, scope string) string {
+	t.Helper()
+	token, _, err := svcCtx.JWT.CreateClientToken("gwc_test", scope, time.Hour)
+	if err != nil {
+		t.Fatalf("CreateClientToken() error = %v", err)
+	}
+	return token
+}
+
+func serveOAuthRequest(engine *gin.Engine, path, token string) int {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	engine.ServeHTTP(rec, req)
+
+	var body struct {
+		Code int `json:"code"`
+	}
+	_ = json.Unmarshal(rec.Body.Bytes(), &body)
+	return body.Code
+}
+
+func newOAuthMiddlewareTestEngine(t *testing.T) (*gin.Engine, *svc.ServiceContext) {
+	t.Helper()
+
+	gin.SetMode(gin.TestMode)
+	gormDB, err := db.InitDatabase(config.Database{
+		Driver: "sqlite3",
+		SQLite: config.SQLite{
+			Path:         filepath.Join(t.TempDir(), "oauth.db"),
+			MaxIdleConns: 1,
+			MaxOpenConns: 1,
+		},
+	}, zap.NewNop())
+	if err != nil {
+		t.Fatalf("InitDatabase() error = %v", err)
+	}
+	if err := gormDB.AutoMigrate(&model.SysApi{}, &model.SysOauthClient{}, &model.JwtBlacklist{}); err != nil {
+		t.Fatalf("AutoMigrate() error = %v", err)
+	}
+	sqlDB, err := gormDB.DB()
+	if err != nil {
+		t.Fatalf("DB() error = %v", err)
+	}
+	t.Cleanup(func() {
+		_ = sqlDB.Close()
+	})
+
+	if err := gormDB.Create(&model.SysApi{
+		Path:     "/api/v1/poetry/dynasty/list",
+		Method:   "GET",
+		ApiGroup: "poetry",
+	}).Error; err != nil {
+		t.Fatalf("create sys api error = %v", err)
+	}
+	if err := gormDB.Create(&model.SysOauthClient{
+		ClientID: "gwc_test",
+		Name:     "test",
+		Scopes:   "poetry",
+		Enabled:  true,
+	}).Error; err != nil {
+		t.Fatalf("create oauth client error = %v", err)
+	}
+
+	j, err := jwt.NewJWT(config.JWT{SigningKey: "test", Issuer: "test"}, zap.NewNop(), nil, gormDB)
+	if err != nil {
+		t.Fatalf("NewJWT() error = %v", err)
+	}
+	t.Cleanup(func() {
+		_ = j.Close(context.Background())
+	})
+
+	svcCtx := svc.NewServiceContext()
+	svcCtx.DB = gormDB
+	svcCtx.Logger = zap.NewNop()
+	initAuthenticatorTestDeps(t, svcCtx)
+	svcCtx.JWT = j
+
+	engine := gin.New()
+	group := engine.Group("/api/v1", Authenticate(NewJWTAuthenticator(svcCtx), NewOAuthAuthenticator(svcCtx)), CasbinHandler(svcCtx))
+	for _, path := range []string{"poetry/dynasty/list", "poetry/author/list"} {
+		group.GET(path, func(c *gin.Context) {
+			c.JSON(http.StatusOK, gin.H{"code": 0})
+		})
+	}
+	return engine, svcCtx
+}
@@ -25,79 +25,104 @@ var bufferPool = sync.Pool{
 	},
 }
 
+// ctxKeyOperationRecorded 标记当前请求已由外层中间件记录，避免重复写日志
+const ctxKeyOperationRecorded = "operationRecorded"
+
 func OperationRecord(svcCtx *svc.ServiceContext) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// 1. 过滤非修改类请求
-		if c.Request.Method == http.MethodGet || c.Request.Method == http.MethodOptions {
+		// 1. 过滤非修改类请求以及已被记录的请求 (模拟登录期间由登录令牌认证统一记录)
+		if c.Request.Method == http.MethodGet || c.Request.Method == http.MethodOptions || c.GetBool(ctxKeyOperationRecorded) {
 			c.Next()
 			return
 		}
+		recordOperation(svcCtx, c)
+	}
+}
 
-		// 2. 读取 Request Body
-		var reqBody []byte
-		if c.Request.Body != nil {
-			// 这里的 ReadAll 是必须的，因为 binding 需要读
-			reqBody, _ = io.ReadAll(c.Request.Body)
-			c.Request.Body = io.NopCloser(bytes.NewBuffer(reqBody)) // 回写
-		}
+// recordOperation 执行后续处理链，并把请求与响应写入操作日志
+func recordOperation(svcCtx *svc.ServiceContext, c *gin.Context) {
+	finish := beginOperationRecord(svcCtx, c)
+	c.Next()
+	finish()
+}
 
-		// 3. 包装 ResponseWriter 以捕获响应
-		// 从池中获取 Buffer
-		respBuf := bufferPool.Get().(*bytes.Buffer)
-		respBuf.Reset()
-		defer bufferPool.Put(respBuf) // 归还 Buffer
+// beginOperationRecord 开始捕获请求与响应，返回的 finish 在后续处理链执行完毕后调用，写入操作日志
+func beginOperationRecord(svcCtx *svc.ServiceContext, c *gin.Context) (finish func()) {
+	c.Set(ctxKeyOperationRecorded, true)
 
-		writer := &responseBodyWriter{
-			ResponseWriter: c.Writer,
-			body:           respBuf,
-		}
-		c.Writer = writer
+	// 2. 读取 Request Body
+	var reqBody []byte
+	if c.Request.Body != nil {
+		// 这里的 ReadAll 是必须的，因为 binding 需要读
+		reqBody, _ = io.ReadAll(c.Request.Body)
+		c.Request.Body = io.NopCloser(bytes.NewBuffer(reqBody)) // 回写
+	}
+
+	// 3. 包装 ResponseWriter 以捕获响应
+	// 从池中获取 Buffer
+	respBuf := bufferPool.Get().(*bytes.Buffer)
+	respBuf.Reset()
 
-		// 4. 记录开始时间
-		start := time.Now()
+	writer := &responseBodyWriter{
+		ResponseWriter: c.Writer,
+		body:           respBuf,
+	}
+	c.Writer = writer
 
-		// --- 执行业务 ---
-		c.Next()
-		// -------------
+	// 4. 记录开始时间
+	start := time.Now()
 
-		// 5. 异步处理日志 (避免阻塞 API 响应)
-		// 注意：这里需要拷贝一份数据，因为 c.Request 在请求结束后会被 Gin 重置
-		// 为了性能，我们只拷贝需要的数据
+	return func() {
+		defer bufferPool.Put(respBuf) // 归还 Buffer
+		pushOperationRecord(svcCtx, c, reqBody, writer, start)
+	}
+}
 
-		// 提取 Trace 信息
-		var traceId, spanId string
-		span := trace.SpanFromContext(c.Request.Context())
-		if span.SpanContext().IsValid() {
-			traceId = span.SpanContext().TraceID().String()
-			spanId = span.SpanContext().SpanID().String()
-		}
+// pushOperationRecord 组装操作日志并推入异步队列
+func pushOperationRecord(svcCtx *svc.ServiceContext, c *gin.Context, reqBody []byte, writer *responseBodyWriter, start time.Time) {
+	// 5. 异步处理日志 (避免阻塞 API 响应)
+	// 注意：这里需要拷贝一份数据，因为 c.Request 在请求结束后会被 Gin 重置
+	// 为了性能，我们只拷贝需要的数据
+
+	// 提取 Trace 信息
+	var traceId, spanId string
+	span := trace.SpanFromContext(c.Request.Context())
+	if span.SpanContext().IsValid() {
+		traceId = span.SpanContext().TraceID().String()
+		spanId = span.SpanContext().SpanID().String()
+	}
 
-		// 截断 Body (保护内存)
-		reqBodyStr := truncateString(string(reqBody), maxBodyLogSize)
-		respBodyStr := truncateString(writer.body.String(), maxBodyLogSize)
-
-		// 解码 Path (处理中文路径)
-		path, _ := url.QueryUnescape(c.Request.URL.Path)
-
-		record := model.SysOperationLog{
-			Ip:       c.ClientIP(),
-			Method:   c.Request.Method,
-			Path:     path,
-			Agent:    c.Request.UserAgent(),
-			Body:     reqBodyStr,
-			Resp:     respBodyStr,
-			Status:   c.Writer.Status(),
-			Latency:  time.Since(start),
-			UserID:   utils.GetUserID(c), // 假设 utils 已经打磨好
-			TraceID:  traceId,
-			SpanID:   spanId,
-			ErrorMsg: c.Errors.String(), // 可选
-		}
+	// 截断 Body (保护内存)
+	reqBodyStr := truncateString(string(reqBody), maxBodyLogSize)
+	respBodyStr := truncateString(writer.body.String(), maxBodyLogSize)
+
+	// 解码 Path (处理中文路径)
+	path, _ := url.QueryUnescape(c.Request.URL.Path)
+
+	ip := c.ClientIP()
+	loc := svcCtx.GeoIP.Lookup(ip)
+
+	record := model.SysOperationLog{
+		Ip:       ip,
+		Method:   c.Request.Method,
+		Path:     path,
+		Agent:    c.Request.UserAgent(),
+		Body:     reqBodyStr,
+		Resp:     respBodyStr,
+		Status:   c.Writer.Status(),
+		Latency:  time.Since(start),
+		UserID:   utils.GetUserID(c), // 假设 utils 已经打磨好
+		TraceID:  traceId,
+		SpanID:   spanId,
+		ErrorMsg: c.Errors.String(), // 可选
+
+		ImpersonatorID: utils.GetImpersonatorID(c),
+		GeoLocation:    model.GeoLocation{Country: loc.Country, Region: loc.Region, ASN: loc.ASN, ASOrg: loc.ASOrg},
+	}
 
-		// 推入队列
-		if svcCtx.AuditRecorder != nil {
-			svcCtx.AuditRecorder.Push(record)
-		}
+	// 推入队列
+	if svcCtx.AuditRecorder != nil {
+		svcCtx.AuditRecorder.Push(record)
 	}
 }
 
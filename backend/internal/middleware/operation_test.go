@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/CIPFZ/gowebframe/internal/core/config"
+	"github.com/CIPFZ/gowebframe/internal/core/geoip"
+	"github.com/CIPFZ/gowebframe/internal/core/geoip/geoiptest"
+	"github.com/CIPFZ/gowebframe/internal/modules/system/model"
+	"go.uber.org/zap"
+)
+
+func TestOperationRecordGeoLocation(t *testing.T) {
+	engine, svcCtx, gormDB := newImpersonationTestEngine(t)
+	path := filepath.Join(t.TempDir(), "city.mmdb")
+	if err := geoiptest.Write(path, geoiptest.Network{
+		CIDR: "1.2.3.0/24", Country: "CN", Region: map[string]string{"en": "Zhejiang"}, ASN: 4134, ASOrg: "CHINANET",
+	}); err != nil {
+		t.Fatalf("geoiptest.Write() error = %v", err)
+	}
+	resolver, err := geoip.New(config.GeoIP{DBPath: path}, zap.NewNop())
+	if err != nil {
+		t.Fatalf("geoip.New() error = %v", err)
+	}
+	t.Cleanup(func() { _ = resolver.Close(context.Background()) })
+	svcCtx.GeoIP = resolver
+
+	for _, addr := range []string{"1.2.3.4:5000", "10.0.0.1:5000"} {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/write", nil)
+		req.RemoteAddr = addr
+		req.Header.Set("x-token", impersonationTestToken(t, svcCtx, 0))
+		engine.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	if err := svcCtx.AuditRecorder.Close(context.Background()); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	var logs []model.SysOperationLog
+	if err := gormDB.Order("id").Find(&logs).Error; err != nil {
+		t.Fatalf("find logs error = %v", err)
+	}
+	want := []model.GeoLocation{
+		{Country: "CN", Region: "Zhejiang", ASN: 4134, ASOrg: "CHINANET"},
+		{},
+	}
+	if len(logs) != len(want) {
+		t.Fatalf("operation logs = %d, want %d", len(logs), len(want))
+	}
+	for i, w := range want {
+		if logs[i].GeoLocation != w {
+			t.Fatalf("log[%d] location = %+v, want %+v", i, logs[i].GeoLocation, w)
+		}
+	}
+}
@@ -1,7 +1,6 @@
 package poetry
 
 import (
-	"github.com/CIPFZ/gowebframe/internal/middleware"
 	"github.com/CIPFZ/gowebframe/internal/modules/poetry/api"
 	"github.com/CIPFZ/gowebframe/internal/svc"
 
@@ -20,17 +19,15 @@ func NewPoetryRouter(svcCtx *svc.ServiceContext, apis *api.PoetryApi) *PoetryRou
 	}
 }
 
-func (r *PoetryRouter) InitPoetryRoutes(privateGroup *gin.RouterGroup, publicGroup *gin.RouterGroup, apiTokenGroup *gin.RouterGroup) {
-	_ = apiTokenGroup
-
+// InitPoetryRoutes 写接口仅登录用户可用；查询接口挂在 apiTokenGroup 上，登录用户、API Token 与 OAuth2 客户端均可访问
+func (r *PoetryRouter) InitPoetryRoutes(privateGroup *gin.RouterGroup, apiTokenGroup *gin.RouterGroup) {
 	writeGroup := privateGroup.Group("poetry")
 	r.initDynastyWriteRoutes(writeGroup)
 	r.initGenreWriteRoutes(writeGroup)
 	r.initAuthorWriteRoutes(writeGroup)
 	r.initPoemWriteRoutes(writeGroup)
 
-	readGroup := publicGroup.Group("poetry")
-	readGroup.Use(middleware.PoetryReadAuth(r.svcCtx))
+	readGroup := apiTokenGroup.Group("poetry")
 	r.initReadOnlyRoutes(readGroup)
 }
 
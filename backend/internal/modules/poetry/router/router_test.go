@@ -16,7 +16,6 @@ func TestInitPoetryRoutesRegistersReadRoutesOnce(t *testing.T) {
 	svcCtx := svc.NewServiceContext()
 	router := NewPoetryRouter(svcCtx, poetryApi.NewPoetryApi(svcCtx, nil))
 	engine := gin.New()
-	publicGroup := engine.Group("/api/v1")
 	privateGroup := engine.Group("/api/v1")
 	apiTokenGroup := engine.Group("/api/v1")
 
@@ -26,7 +25,7 @@ func TestInitPoetryRoutesRegistersReadRoutesOnce(t *testing.T) {
 		}
 	}()
 
-	router.InitPoetryRoutes(privateGroup, publicGroup, apiTokenGroup)
+	router.InitPoetryRoutes(privateGroup, apiTokenGroup)
 
 	var dynastyListGetCount int
 	for _, route := range engine.Routes() {
@@ -71,6 +71,22 @@ func (a *ApiTokenApi) GetApiTokenDetail(c *gin.Context) {
 	response.OkWithData(resp, c)
 }
 
+// GetApiTokenUsage 令牌用量时间序列与各接口汇总
+func (a *ApiTokenApi) GetApiTokenUsage(c *gin.Context) {
+	var req dto.ApiTokenUsageReq
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.FailWithMessage("参数校验失败: "+err.Error(), c)
+		return
+	}
+	resp, err := a.apiTokenService.GetApiTokenUsage(c.Request.Context(), req)
+	if err != nil {
+		logger.GetLogger(c).Error("get_api_token_usage_error", zap.Error(err))
+		response.FailWithMessage("获取失败: "+err.Error(), c)
+		return
+	}
+	response.OkWithData(resp, c)
+}
+
 func (a *ApiTokenApi) UpdateApiToken(c *gin.Context) {
 	var req dto.UpdateApiTokenReq
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -114,6 +130,21 @@ func (a *ApiTokenApi) ResetApiToken(c *gin.Context) {
 	response.OkWithData(resp, c)
 }
 
+func (a *ApiTokenApi) RotateApiToken(c *gin.Context) {
+	var req dto.RotateApiTokenReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage("参数校验失败: "+err.Error(), c)
+		return
+	}
+	resp, err := a.apiTokenService.RotateApiToken(c.Request.Context(), req)
+	if err != nil {
+		logger.GetLogger(c).Error("rotate_api_token_error", zap.Error(err))
+		response.FailWithMessage("轮换失败: "+err.Error(), c)
+		return
+	}
+	response.OkWithData(resp, c)
+}
+
 func (a *ApiTokenApi) EnableApiToken(c *gin.Context) {
 	a.toggleApiToken(c, true)
 }
@@ -0,0 +1,73 @@
+package api
+
+import (
+	logger "github.com/CIPFZ/gowebframe/internal/core/log"
+	"github.com/CIPFZ/gowebframe/internal/modules/common"
+	"github.com/CIPFZ/gowebframe/internal/modules/system/dto"
+	"github.com/CIPFZ/gowebframe/internal/modules/system/service"
+	"github.com/CIPFZ/gowebframe/internal/svc"
+	"github.com/CIPFZ/gowebframe/pkg/response"
+	"github.com/CIPFZ/gowebframe/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// LoginLogApi 登录日志查询接口
+type LoginLogApi struct {
+	svcCtx          *svc.ServiceContext
+	loginLogService service.ILoginLogService
+}
+
+func NewLoginLogApi(svcCtx *svc.ServiceContext, loginLogService service.ILoginLogService) *LoginLogApi {
+	return &LoginLogApi{
+		svcCtx:          svcCtx,
+		loginLogService: loginLogService,
+	}
+}
+
+// GetLoginLogList 管理员分页获取全部登录日志
+// @Tags LoginLog
+// @Summary 获取登录日志列表
+// @Security ApiKeyAuth
+// @Produce application/json
+// @Param data body dto.SearchLoginLogReq true "查询和分页参数"
+// @Success 200 {object} response.Response{data=common.PageResult{list=[]model.SysLoginLog}} "成功"
+// @Router /sys/loginLog/getLoginLogList [post]
+func (a *LoginLogApi) GetLoginLogList(c *gin.Context) {
+	var req dto.SearchLoginLogReq
+	_ = c.ShouldBindJSON(&req)
+
+	list, total, err := a.loginLogService.GetLoginLogList(c.Request.Context(), req)
+	a.respondList(c, req, list, total, err)
+}
+
+// GetSelfLoginLogList 分页获取本人的登录日志
+// @Tags LoginLog
+// @Summary 获取本人登录日志
+// @Security ApiKeyAuth
+// @Produce application/json
+// @Param data body dto.SearchLoginLogReq true "查询和分页参数"
+// @Success 200 {object} response.Response{data=common.PageResult{list=[]model.SysLoginLog}} "成功"
+// @Router /sys/loginLog/getSelfLoginLogList [post]
+func (a *LoginLogApi) GetSelfLoginLogList(c *gin.Context) {
+	var req dto.SearchLoginLogReq
+	_ = c.ShouldBindJSON(&req)
+
+	list, total, err := a.loginLogService.GetSelfLoginLogList(c.Request.Context(), utils.GetUserID(c), req)
+	a.respondList(c, req, list, total, err)
+}
+
+func (a *LoginLogApi) respondList(c *gin.Context, req dto.SearchLoginLogReq, list any, total int64, err error) {
+	if err != nil {
+		logger.GetLogger(c).Error("get_login_log_list_error", zap.Error(err))
+		response.FailWithMessage("获取失败", c)
+		return
+	}
+	response.OkWithDetailed(common.PageResult{
+		List:     list,
+		Total:    total,
+		Page:     req.Page,
+		PageSize: req.PageSize,
+	}, "获取成功", c)
+}
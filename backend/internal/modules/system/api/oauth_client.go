@@ -0,0 +1,224 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+
+	logger "github.com/CIPFZ/gowebframe/internal/core/log"
+	"github.com/CIPFZ/gowebframe/internal/middleware"
+	"github.com/CIPFZ/gowebframe/internal/modules/system/dto"
+	"github.com/CIPFZ/gowebframe/internal/modules/system/service"
+	"github.com/CIPFZ/gowebframe/internal/svc"
+	"github.com/CIPFZ/gowebframe/pkg/response"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type OauthClientApi struct {
+	svcCtx             *svc.ServiceContext
+	oauthClientService service.IOauthClientService
+}
+
+func NewOauthClientApi(svcCtx *svc.ServiceContext, oauthClientService service.IOauthClientService) *OauthClientApi {
+	return &OauthClientApi{
+		svcCtx:             svcCtx,
+		oauthClientService: oauthClientService,
+	}
+}
+
+func (a *OauthClientApi) GetOauthClientList(c *gin.Context) {
+	var req dto.SearchOauthClientReq
+	if err := c.ShouldBind(&req); err != nil {
+		response.FailWithMessage("参数绑定失败: "+err.Error(), c)
+		return
+	}
+
+	list, total, err := a.oauthClientService.GetOauthClientList(c.Request.Context(), req)
+	if err != nil {
+		logger.GetLogger(c).Error("get_oauth_client_list_error", zap.Error(err))
+		response.FailWithMessage("获取失败", c)
+		return
+	}
+	response.OkWithPage(list, total, req.Page, req.PageSize, c)
+}
+
+func (a *OauthClientApi) CreateOauthClient(c *gin.Context) {
+	var req dto.CreateOauthClientReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage("参数校验失败: "+err.Error(), c)
+		return
+	}
+
+	createdBy := c.GetUint(middleware.CtxKeyUserID)
+	resp, err := a.oauthClientService.CreateOauthClient(c.Request.Context(), createdBy, req)
+	if err != nil {
+		logger.GetLogger(c).Error("create_oauth_client_error", zap.Error(err))
+		response.FailWithMessage("创建失败: "+err.Error(), c)
+		return
+	}
+	response.OkWithData(resp, c)
+}
+
+func (a *OauthClientApi) UpdateOauthClient(c *gin.Context) {
+	var req dto.UpdateOauthClientReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage("参数校验失败: "+err.Error(), c)
+		return
+	}
+	if err := a.oauthClientService.UpdateOauthClient(c.Request.Context(), req); err != nil {
+		logger.GetLogger(c).Error("update_oauth_client_error", zap.Error(err))
+		response.FailWithMessage("更新失败: "+err.Error(), c)
+		return
+	}
+	response.OkWithMessage("更新成功", c)
+}
+
+func (a *OauthClientApi) DeleteOauthClient(c *gin.Context) {
+	var req dto.DeleteOauthClientReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage("参数校验失败: "+err.Error(), c)
+		return
+	}
+	if err := a.oauthClientService.DeleteOauthClient(c.Request.Context(), req); err != nil {
+		logger.GetLogger(c).Error("delete_oauth_client_error", zap.Error(err))
+		response.FailWithMessage("删除失败: "+err.Error(), c)
+		return
+	}
+	response.OkWithMessage("删除成功", c)
+}
+
+func (a *OauthClientApi) ResetOauthClientSecret(c *gin.Context) {
+	var req dto.ToggleOauthClientReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage("参数校验失败: "+err.Error(), c)
+		return
+	}
+	resp, err := a.oauthClientService.ResetOauthClientSecret(c.Request.Context(), req.ID)
+	if err != nil {
+		logger.GetLogger(c).Error("reset_oauth_client_secret_error", zap.Error(err))
+		response.FailWithMessage("重置失败: "+err.Error(), c)
+		return
+	}
+	response.OkWithData(resp, c)
+}
+
+func (a *OauthClientApi) EnableOauthClient(c *gin.Context) {
+	a.toggleOauthClient(c, true)
+}
+
+func (a *OauthClientApi) DisableOauthClient(c *gin.Context) {
+	a.toggleOauthClient(c, false)
+}
+
+func (a *OauthClientApi) toggleOauthClient(c *gin.Context, enabled bool) {
+	var req dto.ToggleOauthClientReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage("参数校验失败: "+err.Error(), c)
+		return
+	}
+
+	var err error
+	if enabled {
+		err = a.oauthClientService.EnableOauthClient(c.Request.Context(), req.ID)
+	} else {
+		err = a.oauthClientService.DisableOauthClient(c.Request.Context(), req.ID)
+	}
+	if err != nil {
+		logger.GetLogger(c).Error("toggle_oauth_client_error", zap.Bool("enabled", enabled), zap.Error(err))
+		response.FailWithMessage("操作失败: "+err.Error(), c)
+		return
+	}
+	response.Ok(c)
+}
+
+// Token 令牌端点 (RFC 6749 4.4)，仅支持 client_credentials
+func (a *OauthClientApi) Token(c *gin.Context) {
+	var req dto.OAuthTokenReq
+	if err := c.ShouldBind(&req); err != nil {
+		writeOAuthError(c, service.ErrOauthInvalidRequest, false)
+		return
+	}
+	basic := bindOAuthClientCredentials(c, &req.ClientID, &req.ClientSecret)
+
+	resp, err := a.oauthClientService.IssueToken(c.Request.Context(), req)
+	if err != nil {
+		writeOAuthError(c, err, basic)
+		return
+	}
+	c.Header("Cache-Control", "no-store")
+	c.Header("Pragma", "no-cache")
+	c.JSON(http.StatusOK, resp)
+}
+
+// Introspect 令牌内省端点 (RFC 7662)
+func (a *OauthClientApi) Introspect(c *gin.Context) {
+	var req dto.OAuthTokenActionReq
+	if err := c.ShouldBind(&req); err != nil {
+		writeOAuthError(c, service.ErrOauthInvalidRequest, false)
+		return
+	}
+	basic := bindOAuthClientCredentials(c, &req.ClientID, &req.ClientSecret)
+
+	resp, err := a.oauthClientService.IntrospectToken(c.Request.Context(), req)
+	if err != nil {
+		writeOAuthError(c, err, basic)
+		return
+	}
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, resp)
+}
+
+// Revoke 令牌吊销端点 (RFC 7009)，成功时返回空响应
+func (a *OauthClientApi) Revoke(c *gin.Context) {
+	var req dto.OAuthTokenActionReq
+	if err := c.ShouldBind(&req); err != nil {
+		writeOAuthError(c, service.ErrOauthInvalidRequest, false)
+		return
+	}
+	basic := bindOAuthClientCredentials(c, &req.ClientID, &req.ClientSecret)
+
+	if err := a.oauthClientService.RevokeToken(c.Request.Context(), req); err != nil {
+		writeOAuthError(c, err, basic)
+		return
+	}
+	c.Status(http.StatusOK)
+}
+
+// bindOAuthClientCredentials 优先使用 HTTP Basic 认证头中的客户端凭证 (按规范需先做表单解码)，返回是否来自 Basic
+func bindOAuthClientCredentials(c *gin.Context, clientID, clientSecret *string) bool {
+	user, pass, ok := c.Request.BasicAuth()
+	if !ok {
+		return false
+	}
+	if v, err := url.QueryUnescape(user); err == nil {
+		user = v
+	}
+	if v, err := url.QueryUnescape(pass); err == nil {
+		pass = v
+	}
+	*clientID, *clientSecret = user, pass
+	return true
+}
+
+// writeOAuthError 按 OAuth2 规范输出错误；客户端认证失败返回 401，使用 Basic 认证时带 WWW-Authenticate
+func writeOAuthError(c *gin.Context, err error, basic bool) {
+	status := http.StatusBadRequest
+	switch {
+	case errors.Is(err, service.ErrOauthInvalidClient):
+		status = http.StatusUnauthorized
+		if basic {
+			c.Header("WWW-Authenticate", `Basic realm="oauth"`)
+		}
+	case errors.Is(err, service.ErrOauthInvalidRequest),
+		errors.Is(err, service.ErrOauthUnsupportedGrantType),
+		errors.Is(err, service.ErrOauthInvalidScope),
+		errors.Is(err, service.ErrOauthUnauthorizedClient):
+	default:
+		logger.GetLogger(c).Error("oauth_endpoint_error", zap.String("path", c.FullPath()), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, dto.OAuthErrorResponse{Error: "server_error"})
+		return
+	}
+	c.Header("Cache-Control", "no-store")
+	c.JSON(status, dto.OAuthErrorResponse{Error: err.Error()})
+}
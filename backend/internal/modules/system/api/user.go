@@ -1,23 +1,36 @@
 package api
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
 	"path"
 	"time"
 
 	"github.com/CIPFZ/gowebframe/internal/core/file"
+	corejwt "github.com/CIPFZ/gowebframe/internal/core/jwt"
 	logger "github.com/CIPFZ/gowebframe/internal/core/log"
+	"github.com/CIPFZ/gowebframe/internal/core/oidc"
 	"github.com/CIPFZ/gowebframe/internal/modules/common"
 	"github.com/CIPFZ/gowebframe/internal/modules/system/dto"
+	"github.com/CIPFZ/gowebframe/internal/modules/system/model"
 	"github.com/CIPFZ/gowebframe/internal/modules/system/service"
 	"github.com/CIPFZ/gowebframe/internal/svc"
+	"github.com/CIPFZ/gowebframe/pkg/errcode"
 	"github.com/CIPFZ/gowebframe/pkg/response"
 	"github.com/CIPFZ/gowebframe/pkg/utils"
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 )
 
+// refreshTokenCookie refresh token 所在 Cookie，仅在接口前缀下发送
+const refreshTokenCookie = "x-refresh-token"
+
+// oidcStateCookie 单点登录授权请求的 state，回调时须与参数一致，确保回调来自发起登录的浏览器
+const oidcStateCookie = "x-oidc-state"
+
 type UserApi struct {
 	svcCtx      *svc.ServiceContext
 	userService service.IUserService
@@ -37,14 +50,47 @@ func (u *UserApi) Register(c *gin.Context) {
 		return
 	}
 
+	req.Lang = c.GetHeader("Accept-Language")
 	user, err := u.userService.Register(c.Request.Context(), req)
 	if err != nil {
+		var e *errcode.Error
+		if errors.As(err, &e) {
+			response.FailWithCode(e, c)
+			return
+		}
 		response.FailWithMessage(err.Error(), c)
 		return
 	}
 
 	user.Password = ""
-	response.OkWithData(user, c)
+	msg := "注册成功"
+	switch user.Status {
+	case model.UserPendingVerify:
+		msg = "注册成功，请查收邮件完成邮箱验证"
+	case model.UserPendingApproval:
+		msg = "注册成功，请等待管理员审核"
+	}
+	response.OkWithDetailed(user, msg, c)
+}
+
+// VerifyRegistration 注册邮箱验证
+func (u *UserApi) VerifyRegistration(c *gin.Context) {
+	var req dto.RegisterVerifyReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage("参数错误", c)
+		return
+	}
+
+	if err := u.userService.VerifyRegistration(c.Request.Context(), req); err != nil {
+		logger.GetLogger(c).Warn("verify_registration_failed", zap.Error(err))
+		if errors.Is(err, service.ErrRegisterTokenInvalid) {
+			response.FailWithMessage(err.Error(), c)
+			return
+		}
+		response.FailWithCode(errcode.ServerError, c)
+		return
+	}
+	response.OkWithMessage("邮箱验证成功，请登录", c)
 }
 
 func (u *UserApi) Login(c *gin.Context) {
@@ -55,20 +101,339 @@ func (u *UserApi) Login(c *gin.Context) {
 		return
 	}
 
+	req.IP = c.ClientIP()
+	req.UserAgent = c.Request.UserAgent()
 	resp, err := u.userService.Login(c, req)
 	if err != nil {
 		log.Error("login failed", zap.Error(err))
+		// 验证码、账号锁定等业务错误原样返回，前端据此展示验证码
+		var e *errcode.Error
+		if errors.As(err, &e) {
+			response.FailWithCode(e, c)
+			return
+		}
 		response.FailWithMessage("invalid username or password", c)
 		return
 	}
 
-	now := time.Now().Unix()
-	maxAge := int((resp.ExpiresAt / 1000) - now)
-	u.setTokenHelper(c, resp.Token, maxAge)
+	// 需要二次验证：只返回挑战令牌，前端提交验证码到 /user/login/mfa
+	if resp.MfaRequired {
+		response.OkWithDetailed(resp, "mfa required", c)
+		return
+	}
+
+	u.setTokenPairHelper(c, resp)
+
+	response.OkWithDetailed(resp, "login successful", c)
+}
+
+// LoginMfa 登录第二步：校验 TOTP 验证码或恢复码后签发 token
+func (u *UserApi) LoginMfa(c *gin.Context) {
+	var req dto.MfaLoginReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage("参数错误", c)
+		return
+	}
+
+	req.IP = c.ClientIP()
+	req.UserAgent = c.Request.UserAgent()
+	resp, err := u.userService.LoginMfa(c.Request.Context(), req)
+	if err != nil {
+		logger.GetLogger(c).Warn("login_mfa_failed", zap.Error(err))
+		if errors.Is(err, corejwt.ErrChallengeInvalid) {
+			response.FailWithCode(errcode.Unauthorized.WithDetails(err.Error()), c)
+			return
+		}
+		// 账号锁定等业务错误原样返回
+		var e *errcode.Error
+		if errors.As(err, &e) {
+			response.FailWithCode(e, c)
+			return
+		}
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+
+	u.setTokenPairHelper(c, resp)
+	response.OkWithDetailed(resp, "login successful", c)
+}
+
+// LoginPassword 密码过期或被要求修改时，设置新密码并完成登录
+func (u *UserApi) LoginPassword(c *gin.Context) {
+	var req dto.PasswordExpiredReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage("参数错误", c)
+		return
+	}
+
+	req.IP = c.ClientIP()
+	req.UserAgent = c.Request.UserAgent()
+	resp, err := u.userService.LoginWithNewPassword(c.Request.Context(), req)
+	if err != nil {
+		logger.GetLogger(c).Warn("login_password_change_failed", zap.Error(err))
+		var e *errcode.Error
+		if errors.As(err, &e) {
+			response.FailWithCode(e, c)
+			return
+		}
+		if errors.Is(err, service.ErrInvalidCredentials) {
+			response.FailWithMessage("invalid username or password", c)
+			return
+		}
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+
+	if resp.MfaRequired {
+		response.OkWithDetailed(resp, "mfa required", c)
+		return
+	}
+
+	u.setTokenPairHelper(c, resp)
+	response.OkWithDetailed(resp, "login successful", c)
+}
+
+// ForgotPassword 申请找回密码，无论账号是否存在都返回相同结果
+func (u *UserApi) ForgotPassword(c *gin.Context) {
+	var req dto.ForgotPasswordReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage("参数错误", c)
+		return
+	}
+
+	req.Lang = c.GetHeader("Accept-Language")
+	req.IP = c.ClientIP()
+	if err := u.userService.ForgotPassword(c.Request.Context(), req); err != nil {
+		logger.GetLogger(c).Warn("forgot_password_failed", zap.Error(err))
+		var e *errcode.Error
+		if errors.As(err, &e) {
+			response.FailWithCode(e, c)
+			return
+		}
+		if errors.Is(err, service.ErrPasswordResetDisabled) {
+			response.FailWithMessage(err.Error(), c)
+			return
+		}
+		response.FailWithCode(errcode.ServerError, c)
+		return
+	}
+	response.OkWithMessage("如果账号存在且已绑定邮箱，重置链接已发送", c)
+}
+
+// ResetPasswordByToken 使用邮件中的令牌设置新密码
+func (u *UserApi) ResetPasswordByToken(c *gin.Context) {
+	var req dto.ResetPasswordByTokenReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage("参数错误", c)
+		return
+	}
+
+	if err := u.userService.ResetPasswordByToken(c.Request.Context(), req); err != nil {
+		logger.GetLogger(c).Warn("reset_password_by_token_failed", zap.Error(err))
+		var e *errcode.Error
+		if errors.As(err, &e) {
+			response.FailWithCode(e, c)
+			return
+		}
+		if errors.Is(err, service.ErrPasswordResetTokenInvalid) || errors.Is(err, service.ErrPasswordResetDisabled) {
+			response.FailWithMessage(err.Error(), c)
+			return
+		}
+		response.FailWithCode(errcode.ServerError, c)
+		return
+	}
+	response.OkWithMessage("密码已重置，请重新登录", c)
+}
+
+// SetupLoginMfa 角色强制二次验证的首次登录：凭挑战令牌获取待绑定密钥
+func (u *UserApi) SetupLoginMfa(c *gin.Context) {
+	var req dto.MfaChallengeReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage("参数错误", c)
+		return
+	}
+
+	resp, err := u.userService.SetupMfaChallenge(c.Request.Context(), req.MfaToken)
+	if err != nil {
+		logger.GetLogger(c).Warn("setup_login_mfa_failed", zap.Error(err))
+		if errors.Is(err, corejwt.ErrChallengeInvalid) {
+			response.FailWithCode(errcode.Unauthorized.WithDetails(err.Error()), c)
+			return
+		}
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	response.OkWithData(resp, c)
+}
+
+// Captcha 生成登录验证码
+func (u *UserApi) Captcha(c *gin.Context) {
+	resp, err := u.userService.GenerateCaptcha(c.Request.Context(), c.ClientIP())
+	if err != nil {
+		logger.GetLogger(c).Error("generate_captcha_error", zap.Error(err))
+		response.FailWithMessage("验证码获取失败", c)
+		return
+	}
+	response.OkWithData(resp, c)
+}
+
+// OidcProviders 获取登录页可用的单点登录方式
+func (u *UserApi) OidcProviders(c *gin.Context) {
+	response.OkWithData(u.userService.OidcProviders(), c)
+}
+
+// OidcAuthorize 跳转到 IdP 授权页 (授权码 + PKCE)
+func (u *UserApi) OidcAuthorize(c *gin.Context) {
+	authURL, state, err := u.userService.OidcAuthURL(c.Request.Context(), c.Param("provider"), c.Query("redirect"))
+	if err != nil {
+		logger.GetLogger(c).Warn("oidc_authorize_failed", zap.Error(err))
+		u.redirectOidcResult(c, url.Values{"ssoError": {oidcError(err).Msg}})
+		return
+	}
+	u.setOidcStateCookie(c, state, int(oidc.StateExpiresTime.Seconds()))
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// OidcCallback IdP 授权回调：登录结果以一次性票据重定向回前端登录页
+func (u *UserApi) OidcCallback(c *gin.Context) {
+	var req dto.OidcCallbackReq
+	if err := c.ShouldBindUri(&req); err != nil {
+		response.FailWithMessage("参数错误", c)
+		return
+	}
+	_ = c.ShouldBindQuery(&req)
+	req.BoundState, _ = c.Cookie(oidcStateCookie)
+	req.IP = c.ClientIP()
+	req.UserAgent = c.Request.UserAgent()
+	// state 只用于本次回调
+	u.setOidcStateCookie(c, "", -1)
+
+	ticket, redirect, err := u.userService.OidcCallback(c.Request.Context(), req)
+	if err != nil {
+		logger.GetLogger(c).Warn("oidc_callback_failed", zap.Error(err))
+		u.redirectOidcResult(c, url.Values{"ssoError": {oidcError(err).Msg}})
+		return
+	}
+	u.redirectOidcResult(c, url.Values{"ticket": {ticket}, "redirect": {redirect}})
+}
+
+// OidcExchange 使用一次性票据换取登录结果 (需要二次验证时返回挑战令牌)
+func (u *UserApi) OidcExchange(c *gin.Context) {
+	var req dto.OidcExchangeReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage("参数错误", c)
+		return
+	}
 
+	resp, err := u.userService.OidcExchangeTicket(c.Request.Context(), req.Ticket)
+	if err != nil {
+		logger.GetLogger(c).Warn("oidc_exchange_failed", zap.Error(err))
+		response.FailWithCode(errcode.Unauthorized.WithDetails(err.Error()), c)
+		return
+	}
+	if resp.MfaRequired {
+		response.OkWithDetailed(resp, "mfa required", c)
+		return
+	}
+
+	u.setTokenPairHelper(c, resp)
 	response.OkWithDetailed(resp, "login successful", c)
 }
 
+// redirectOidcResult 重定向回前端登录页，默认 /user/login
+// setOidcStateCookie 将授权请求的 state 写入浏览器
+// IdP 回调是跨站的顶层跳转，必须使用 SameSite=Lax 才能携带该 Cookie
+func (u *UserApi) setOidcStateCookie(c *gin.Context, state string, maxAge int) {
+	isSecure := u.svcCtx.Config.System.Environment == "production"
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oidcStateCookie, state, maxAge, u.refreshCookiePath(), "", isSecure, true)
+}
+
+// oidcError 将单点登录错误映射为固定的提示信息，避免 IdP 响应、数据库错误等内部细节出现在跳转地址中
+func oidcError(err error) *errcode.Error {
+	var e *errcode.Error
+	switch {
+	case errors.As(err, &e):
+		return e
+	case errors.Is(err, service.ErrOidcDisabled), errors.Is(err, oidc.ErrUnknownProvider):
+		return errcode.SsoUnavailable
+	case errors.Is(err, oidc.ErrStateInvalid), errors.Is(err, oidc.ErrNonceMismatch):
+		return errcode.SsoStateInvalid
+	case errors.Is(err, service.ErrOidcProviderDenied):
+		return errcode.SsoProviderDenied
+	case errors.Is(err, service.ErrAccountNotProvisioned), errors.Is(err, service.ErrNoAuthorityMapped),
+		errors.Is(err, service.ErrUsernameTaken), errors.Is(err, service.ErrUserDisabled):
+		return errcode.SsoAccountUnavailable
+	default:
+		return errcode.SsoLoginFailed
+	}
+}
+
+func (u *UserApi) redirectOidcResult(c *gin.Context, params url.Values) {
+	target := u.svcCtx.Config.OIDC.FrontendRedirect
+	if target == "" {
+		target = "/user/login"
+	}
+	dest, err := url.Parse(target)
+	if err != nil {
+		dest = &url.URL{Path: "/user/login"}
+	}
+	query := dest.Query()
+	for k, v := range params {
+		query[k] = v
+	}
+	dest.RawQuery = query.Encode()
+	c.Redirect(http.StatusFound, dest.String())
+}
+
+// Refresh 使用 refresh token 换取新的 token 对 (refresh token 会同时轮换)
+func (u *UserApi) Refresh(c *gin.Context) {
+	var req dto.RefreshTokenReq
+	_ = c.ShouldBindJSON(&req)
+	if req.RefreshToken == "" {
+		req.RefreshToken, _ = c.Cookie(refreshTokenCookie)
+	}
+
+	resp, err := u.userService.RefreshToken(c.Request.Context(), req.RefreshToken)
+	if err != nil {
+		logger.GetLogger(c).Warn("refresh_token_failed", zap.Error(err))
+		u.clearRefreshCookie(c)
+		if errors.Is(err, corejwt.ErrRefreshTokenInvalid) || errors.Is(err, corejwt.ErrRefreshTokenExpired) || errors.Is(err, corejwt.ErrRefreshTokenReused) {
+			response.FailWithCode(errcode.Unauthorized.WithDetails(err.Error()), c)
+			return
+		}
+		response.FailWithMessage("刷新失败", c)
+		return
+	}
+
+	u.setTokenPairHelper(c, resp)
+	response.OkWithData(resp, c)
+}
+
+// setTokenPairHelper 同时下发 access token 与 refresh token
+func (u *UserApi) setTokenPairHelper(c *gin.Context, resp *dto.LoginResponse) {
+	now := time.Now().Unix()
+	u.setTokenHelper(c, resp.Token, int((resp.ExpiresAt/1000)-now))
+	if resp.RefreshToken == "" {
+		return
+	}
+
+	// refresh token 只用于刷新与登出，限定 Cookie 作用路径，且不暴露给 JS
+	isSecure := u.svcCtx.Config.System.Environment == "production"
+	c.SetSameSite(http.SameSiteStrictMode)
+	c.SetCookie(refreshTokenCookie, resp.RefreshToken, int((resp.RefreshExpiresAt/1000)-now), u.refreshCookiePath(), "", isSecure, true)
+}
+
+func (u *UserApi) refreshCookiePath() string {
+	return path.Join("/", u.svcCtx.Config.System.RouterPrefix)
+}
+
+func (u *UserApi) clearRefreshCookie(c *gin.Context) {
+	isSecure := u.svcCtx.Config.System.Environment == "production"
+	c.SetSameSite(http.SameSiteStrictMode)
+	c.SetCookie(refreshTokenCookie, "", -1, u.refreshCookiePath(), "", isSecure, true)
+}
+
 func (u *UserApi) setTokenHelper(c *gin.Context, token string, maxAge int) {
 	var domain string
 	if u.svcCtx.Config.System.Environment == "production" {
@@ -95,6 +460,14 @@ func (u *UserApi) GetSelfInfo(c *gin.Context) {
 		return
 	}
 
+	// 模拟登录时附带发起人信息，前端据此展示提示横幅
+	if impersonatorID := utils.GetImpersonatorID(c); impersonatorID != 0 {
+		response.OkWithData(dto.SelfInfo{
+			SysUser:       user,
+			Impersonation: u.userService.GetImpersonationInfo(c.Request.Context(), impersonatorID, utils.GetSessionID(c)),
+		}, c)
+		return
+	}
 	response.OkWithData(user, c)
 }
 
@@ -105,8 +478,15 @@ func (u *UserApi) Logout(c *gin.Context) {
 		return
 	}
 
+	// 退出模拟登录只结束模拟会话，Cookie 中的 refresh token 属于管理员本人，不能吊销
+	var refreshToken string
+	if utils.GetImpersonatorID(c) == 0 {
+		refreshToken, _ = c.Cookie(refreshTokenCookie)
+		u.clearRefreshCookie(c)
+	}
+
 	log := logger.GetLogger(c)
-	if err := u.userService.Logout(c.Request.Context(), token); err != nil {
+	if err := u.userService.Logout(c.Request.Context(), token, refreshToken); err != nil {
 		log.Error("logout_api_error", zap.Error(err))
 		response.FailWithMessage("注销失败，请重试", c)
 		return
@@ -149,6 +529,11 @@ func (u *UserApi) AddUser(c *gin.Context) {
 	log := logger.GetLogger(c)
 	if err := u.userService.AddUser(c.Request.Context(), req); err != nil {
 		log.Error("add_user_error", zap.Error(err))
+		var e *errcode.Error
+		if errors.As(err, &e) {
+			response.FailWithCode(e, c)
+			return
+		}
 		response.FailWithMessage("添加失败: "+err.Error(), c)
 		return
 	}
@@ -190,6 +575,102 @@ func (u *UserApi) DeleteUser(c *gin.Context) {
 	response.OkWithMessage("删除成功", c)
 }
 
+// UnlockUser 解除账号登录锁定
+func (u *UserApi) UnlockUser(c *gin.Context) {
+	var req common.GetByIdReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+
+	if err := u.userService.UnlockUser(c.Request.Context(), req.Uint(), utils.GetUserID(c), c.ClientIP()); err != nil {
+		logger.GetLogger(c).Error("unlock_user_error", zap.Error(err))
+		response.FailWithMessage("解锁失败: "+err.Error(), c)
+		return
+	}
+	response.OkWithMessage("解锁成功", c)
+}
+
+// Impersonate 管理员以指定用户身份登录 (模拟登录)，返回限时 access token
+// 令牌只在响应中返回，不写 Cookie，避免覆盖管理员自己的登录态
+func (u *UserApi) Impersonate(c *gin.Context) {
+	var req dto.ImpersonateReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+
+	resp, err := u.userService.Impersonate(c.Request.Context(), utils.GetUserID(c), req, c.ClientIP(), c.Request.UserAgent())
+	if err != nil {
+		var e *errcode.Error
+		if errors.As(err, &e) {
+			response.FailWithCode(e, c)
+			return
+		}
+		if errors.Is(err, service.ErrImpersonateSelf) || errors.Is(err, service.ErrImpersonateNotFound) ||
+			errors.Is(err, service.ErrImpersonateSuperAdmin) || errors.Is(err, service.ErrUserDisabled) {
+			response.FailWithMessage(err.Error(), c)
+			return
+		}
+		logger.GetLogger(c).Error("impersonate_error", zap.Error(err))
+		response.FailWithMessage("模拟登录失败", c)
+		return
+	}
+	response.OkWithData(resp, c)
+}
+
+// GetRegistrationList 注册申请列表 (默认待审核)
+func (u *UserApi) GetRegistrationList(c *gin.Context) {
+	var req dto.SearchRegistrationReq
+	_ = c.ShouldBindJSON(&req)
+
+	list, total, err := u.userService.GetRegistrationList(c.Request.Context(), req)
+	if err != nil {
+		logger.GetLogger(c).Error("get_registration_list_error", zap.Error(err))
+		response.FailWithCode(errcode.GetListFailed, c)
+		return
+	}
+	for i := range list {
+		list[i].Password = ""
+	}
+	response.OkWithDetailed(common.PageResult{
+		List:     list,
+		Total:    total,
+		Page:     req.Page,
+		PageSize: req.PageSize,
+	}, "获取成功", c)
+}
+
+// ApproveRegistration 审核通过注册申请
+func (u *UserApi) ApproveRegistration(c *gin.Context) {
+	u.reviewRegistration(c, u.userService.ApproveRegistration, "审核通过")
+}
+
+// RejectRegistration 拒绝注册申请
+func (u *UserApi) RejectRegistration(c *gin.Context) {
+	u.reviewRegistration(c, u.userService.RejectRegistration, "已拒绝")
+}
+
+func (u *UserApi) reviewRegistration(c *gin.Context, review func(context.Context, uint, dto.ReviewRegistrationReq) error, okMsg string) {
+	var req dto.ReviewRegistrationReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+
+	req.Lang = c.GetHeader("Accept-Language")
+	if err := review(c.Request.Context(), utils.GetUserID(c), req); err != nil {
+		logger.GetLogger(c).Error("review_registration_error", zap.Error(err))
+		if errors.Is(err, service.ErrRegistrationNotPending) {
+			response.FailWithMessage(err.Error(), c)
+			return
+		}
+		response.FailWithCode(errcode.UpdateFailed, c)
+		return
+	}
+	response.OkWithMessage(okMsg, c)
+}
+
 func (u *UserApi) ResetPassword(c *gin.Context) {
 	var req dto.ResetPasswordReq
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -200,6 +681,11 @@ func (u *UserApi) ResetPassword(c *gin.Context) {
 	log := logger.GetLogger(c)
 	if err := u.userService.ResetPassword(c.Request.Context(), req); err != nil {
 		log.Error("reset_password_error", zap.Error(err))
+		var e *errcode.Error
+		if errors.As(err, &e) {
+			response.FailWithCode(e, c)
+			return
+		}
 		response.FailWithMessage("重置失败", c)
 		return
 	}
@@ -207,6 +693,27 @@ func (u *UserApi) ResetPassword(c *gin.Context) {
 	response.OkWithMessage("重置成功", c)
 }
 
+// ChangePassword 修改本人密码
+func (u *UserApi) ChangePassword(c *gin.Context) {
+	var req dto.ChangePasswordReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage("参数错误", c)
+		return
+	}
+
+	if err := u.userService.ChangePassword(c.Request.Context(), utils.GetUserID(c), utils.GetSessionID(c), req); err != nil {
+		logger.GetLogger(c).Warn("change_password_error", zap.Error(err))
+		var e *errcode.Error
+		if errors.As(err, &e) {
+			response.FailWithCode(e, c)
+			return
+		}
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	response.OkWithMessage("修改成功", c)
+}
+
 func (u *UserApi) SwitchAuthority(c *gin.Context) {
 	type reqBody struct {
 		AuthorityID uint `json:"authorityId" binding:"required"`
@@ -219,7 +726,7 @@ func (u *UserApi) SwitchAuthority(c *gin.Context) {
 	}
 
 	userUUID := utils.GetUserUUID(c)
-	resp, err := u.userService.SwitchAuthority(c.Request.Context(), userUUID, req.AuthorityID)
+	resp, err := u.userService.SwitchAuthority(c.Request.Context(), userUUID, req.AuthorityID, utils.GetSessionID(c))
 	if err != nil {
 		response.FailWithMessage("切换失败: "+err.Error(), c)
 		return
@@ -300,3 +807,130 @@ func (u *UserApi) UploadAvatar(c *gin.Context) {
 
 	response.OkWithData(gin.H{"url": avatarURL}, c)
 }
+
+// GetSessions 获取自己的登录会话
+func (u *UserApi) GetSessions(c *gin.Context) {
+	list, err := u.userService.ListSessions(c.Request.Context(), utils.GetUserID(c), utils.GetSessionID(c))
+	if err != nil {
+		logger.GetLogger(c).Error("get_sessions_error", zap.Error(err))
+		response.FailWithMessage("获取失败", c)
+		return
+	}
+	response.OkWithData(list, c)
+}
+
+// KickSession 踢出自己的某个会话
+func (u *UserApi) KickSession(c *gin.Context) {
+	var req dto.KickSessionReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage("参数错误", c)
+		return
+	}
+
+	if err := u.userService.KickSession(c.Request.Context(), utils.GetUserID(c), req.SessionID); err != nil {
+		logger.GetLogger(c).Error("kick_session_error", zap.Error(err))
+		response.FailWithMessage("操作失败: "+err.Error(), c)
+		return
+	}
+	response.OkWithMessage("已下线", c)
+}
+
+// GetUserSessions 管理员获取指定用户的登录会话
+func (u *UserApi) GetUserSessions(c *gin.Context) {
+	var req dto.UserSessionReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage("参数错误", c)
+		return
+	}
+
+	list, err := u.userService.ListSessions(c.Request.Context(), req.UserID, utils.GetSessionID(c))
+	if err != nil {
+		logger.GetLogger(c).Error("get_user_sessions_error", zap.Error(err))
+		response.FailWithMessage("获取失败", c)
+		return
+	}
+	response.OkWithData(list, c)
+}
+
+// KickUserSession 管理员踢出指定用户的会话 (不指定会话时踢出全部)
+func (u *UserApi) KickUserSession(c *gin.Context) {
+	var req dto.KickUserSessionReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage("参数错误", c)
+		return
+	}
+
+	var err error
+	if req.SessionID == "" {
+		err = u.userService.KickAllSessions(c.Request.Context(), req.UserID)
+	} else {
+		err = u.userService.KickSession(c.Request.Context(), req.UserID, req.SessionID)
+	}
+	if err != nil {
+		logger.GetLogger(c).Error("kick_user_session_error", zap.Error(err))
+		response.FailWithMessage("操作失败: "+err.Error(), c)
+		return
+	}
+	response.OkWithMessage("已下线", c)
+}
+
+// SetupMfa 生成待绑定的 TOTP 密钥 (二维码地址)
+func (u *UserApi) SetupMfa(c *gin.Context) {
+	resp, err := u.userService.SetupMfa(c.Request.Context(), utils.GetUserID(c))
+	if err != nil {
+		logger.GetLogger(c).Error("setup_mfa_error", zap.Error(err))
+		response.FailWithMessage("操作失败: "+err.Error(), c)
+		return
+	}
+	response.OkWithData(resp, c)
+}
+
+// EnableMfa 校验验证码并启用二次验证，返回恢复码
+func (u *UserApi) EnableMfa(c *gin.Context) {
+	var req dto.MfaCodeReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage("参数错误", c)
+		return
+	}
+
+	codes, err := u.userService.EnableMfa(c.Request.Context(), utils.GetUserID(c), req.Code)
+	if err != nil {
+		logger.GetLogger(c).Warn("enable_mfa_error", zap.Error(err))
+		response.FailWithMessage("操作失败: "+err.Error(), c)
+		return
+	}
+	response.OkWithDetailed(dto.MfaRecoveryCodesResponse{RecoveryCodes: codes}, "已启用二次验证", c)
+}
+
+// DisableMfa 关闭二次验证
+func (u *UserApi) DisableMfa(c *gin.Context) {
+	var req dto.MfaCodeReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage("参数错误", c)
+		return
+	}
+
+	if err := u.userService.DisableMfa(c.Request.Context(), utils.GetUserID(c), req.Code); err != nil {
+		logger.GetLogger(c).Warn("disable_mfa_error", zap.Error(err))
+		response.FailWithMessage("操作失败: "+err.Error(), c)
+		return
+	}
+	response.OkWithMessage("已关闭二次验证", c)
+}
+
+// RegenerateRecoveryCodes 重新生成恢复码
+func (u *UserApi) RegenerateRecoveryCodes(c *gin.Context) {
+	var req dto.MfaCodeReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage("参数错误", c)
+		return
+	}
+
+	codes, err := u.userService.RegenerateRecoveryCodes(c.Request.Context(), utils.GetUserID(c), req.Code)
+	if err != nil {
+		logger.GetLogger(c).Warn("regenerate_recovery_codes_error", zap.Error(err))
+		response.FailWithMessage("操作失败: "+err.Error(), c)
+		return
+	}
+	response.OkWithData(dto.MfaRecoveryCodesResponse{RecoveryCodes: codes}, c)
+}
@@ -0,0 +1,168 @@
+package api
+
+import (
+	"errors"
+
+	corejwt "github.com/CIPFZ/gowebframe/internal/core/jwt"
+	logger "github.com/CIPFZ/gowebframe/internal/core/log"
+	"github.com/CIPFZ/gowebframe/internal/modules/common"
+	"github.com/CIPFZ/gowebframe/internal/modules/system/dto"
+	"github.com/CIPFZ/gowebframe/pkg/errcode"
+	"github.com/CIPFZ/gowebframe/pkg/response"
+	"github.com/CIPFZ/gowebframe/pkg/utils"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// BeginPasskeyLogin 通行密钥免密码登录：获取 navigator.credentials.get() 参数
+func (u *UserApi) BeginPasskeyLogin(c *gin.Context) {
+	resp, err := u.userService.BeginPasskeyLogin(c.Request.Context())
+	if err != nil {
+		logger.GetLogger(c).Warn("begin_passkey_login_failed", zap.Error(err))
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	response.OkWithData(resp, c)
+}
+
+// LoginPasskey 通行密钥免密码登录：校验签名后签发 token
+func (u *UserApi) LoginPasskey(c *gin.Context) {
+	var req dto.PasskeyLoginReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage("参数错误", c)
+		return
+	}
+
+	req.IP = c.ClientIP()
+	req.UserAgent = c.Request.UserAgent()
+	resp, err := u.userService.LoginPasskey(c.Request.Context(), req)
+	if err != nil {
+		logger.GetLogger(c).Warn("login_passkey_failed", zap.Error(err))
+		var e *errcode.Error
+		if errors.As(err, &e) {
+			response.FailWithCode(e, c)
+			return
+		}
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+
+	u.setTokenPairHelper(c, resp)
+	response.OkWithDetailed(resp, "login successful", c)
+}
+
+// BeginMfaPasskey 二次验证使用通行密钥：凭挑战令牌获取 navigator.credentials.get() 参数
+func (u *UserApi) BeginMfaPasskey(c *gin.Context) {
+	var req dto.MfaChallengeReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage("参数错误", c)
+		return
+	}
+
+	resp, err := u.userService.BeginMfaPasskey(c.Request.Context(), req.MfaToken)
+	if err != nil {
+		logger.GetLogger(c).Warn("begin_mfa_passkey_failed", zap.Error(err))
+		if errors.Is(err, corejwt.ErrChallengeInvalid) {
+			response.FailWithCode(errcode.Unauthorized.WithDetails(err.Error()), c)
+			return
+		}
+		// 账号锁定等业务错误原样返回
+		var e *errcode.Error
+		if errors.As(err, &e) {
+			response.FailWithCode(e, c)
+			return
+		}
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	response.OkWithData(resp, c)
+}
+
+// GetPasskeys 获取自己注册的通行密钥
+func (u *UserApi) GetPasskeys(c *gin.Context) {
+	list, err := u.userService.ListPasskeys(c.Request.Context(), utils.GetUserID(c))
+	if err != nil {
+		logger.GetLogger(c).Error("get_passkeys_error", zap.Error(err))
+		response.FailWithMessage("获取失败", c)
+		return
+	}
+	response.OkWithData(list, c)
+}
+
+// BeginPasskeyRegistration 注册通行密钥：获取 navigator.credentials.create() 参数
+func (u *UserApi) BeginPasskeyRegistration(c *gin.Context) {
+	resp, err := u.userService.BeginPasskeyRegistration(c.Request.Context(), utils.GetUserID(c))
+	if err != nil {
+		logger.GetLogger(c).Warn("begin_passkey_registration_error", zap.Error(err))
+		response.FailWithMessage("操作失败: "+err.Error(), c)
+		return
+	}
+	response.OkWithData(resp, c)
+}
+
+// FinishPasskeyRegistration 注册通行密钥：校验并保存浏览器返回的凭证
+func (u *UserApi) FinishPasskeyRegistration(c *gin.Context) {
+	var req dto.PasskeyRegisterReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage("参数错误", c)
+		return
+	}
+
+	req.UserAgent = c.Request.UserAgent()
+	item, err := u.userService.FinishPasskeyRegistration(c.Request.Context(), utils.GetUserID(c), req)
+	if err != nil {
+		logger.GetLogger(c).Warn("finish_passkey_registration_error", zap.Error(err))
+		response.FailWithMessage("操作失败: "+err.Error(), c)
+		return
+	}
+	response.OkWithDetailed(item, "已添加通行密钥", c)
+}
+
+// RevokePasskey 删除自己的通行密钥
+func (u *UserApi) RevokePasskey(c *gin.Context) {
+	var req common.GetByIdReq
+	if err := c.ShouldBindJSON(&req); err != nil || req.ID <= 0 {
+		response.FailWithMessage("参数错误", c)
+		return
+	}
+
+	if err := u.userService.RevokePasskey(c.Request.Context(), utils.GetUserID(c), req.Uint()); err != nil {
+		logger.GetLogger(c).Warn("revoke_passkey_error", zap.Error(err))
+		response.FailWithMessage("操作失败: "+err.Error(), c)
+		return
+	}
+	response.OkWithMessage("已删除", c)
+}
+
+// GetUserPasskeys 管理员获取指定用户的通行密钥
+func (u *UserApi) GetUserPasskeys(c *gin.Context) {
+	var req dto.UserPasskeyReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage("参数错误", c)
+		return
+	}
+
+	list, err := u.userService.ListPasskeys(c.Request.Context(), req.UserID)
+	if err != nil {
+		logger.GetLogger(c).Error("get_user_passkeys_error", zap.Error(err))
+		response.FailWithMessage("获取失败", c)
+		return
+	}
+	response.OkWithData(list, c)
+}
+
+// RevokeUserPasskey 管理员吊销指定用户的通行密钥 (如设备丢失)
+func (u *UserApi) RevokeUserPasskey(c *gin.Context) {
+	var req common.GetByIdReq
+	if err := c.ShouldBindJSON(&req); err != nil || req.ID <= 0 {
+		response.FailWithMessage("参数错误", c)
+		return
+	}
+
+	if err := u.userService.RevokeUserPasskey(c.Request.Context(), req.Uint()); err != nil {
+		logger.GetLogger(c).Warn("revoke_user_passkey_error", zap.Error(err))
+		response.FailWithMessage("操作失败: "+err.Error(), c)
+		return
+	}
+	response.OkWithMessage("已吊销", c)
+}
@@ -1,12 +1,19 @@
 package api
 
 import (
+	"errors"
+	"fmt"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/CIPFZ/gowebframe/internal/core/config"
+	"github.com/CIPFZ/gowebframe/internal/core/oidc"
+	"github.com/CIPFZ/gowebframe/internal/modules/system/dto"
+	"github.com/CIPFZ/gowebframe/internal/modules/system/service"
 	"github.com/CIPFZ/gowebframe/internal/svc"
+	"github.com/CIPFZ/gowebframe/pkg/errcode"
 	"github.com/gin-gonic/gin"
 )
 
@@ -33,3 +40,85 @@ func TestSetTokenHelperSetsSameSiteCookie(t *testing.T) {
 		t.Fatalf("Set-Cookie = %q, want SameSite=Lax", cookies[0])
 	}
 }
+
+func TestSetTokenPairHelperSetsHttpOnlyRefreshCookie(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	recorder := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(recorder)
+
+	api := &UserApi{
+		svcCtx: &svc.ServiceContext{
+			Config: &config.Config{
+				System: config.System{Environment: "dev", RouterPrefix: "/api/v1"},
+			},
+		},
+	}
+
+	now := time.Now()
+	api.setTokenPairHelper(ctx, &dto.LoginResponse{
+		Token:            "access-value",
+		ExpiresAt:        now.Add(time.Minute).UnixMilli(),
+		RefreshToken:     "refresh-value",
+		RefreshExpiresAt: now.Add(time.Hour).UnixMilli(),
+	})
+
+	var refreshCookie string
+	for _, cookie := range recorder.Header().Values("Set-Cookie") {
+		if strings.HasPrefix(cookie, refreshTokenCookie+"=") {
+			refreshCookie = cookie
+		}
+	}
+	if refreshCookie == "" {
+		t.Fatal("expected refresh token cookie to be written")
+	}
+	for _, want := range []string{"HttpOnly", "Path=/api/v1", "SameSite=Strict"} {
+		if !strings.Contains(refreshCookie, want) {
+			t.Fatalf("Set-Cookie = %q, want %s", refreshCookie, want)
+		}
+	}
+}
+
+func TestSetOidcStateCookieIsHttpOnlyAndLax(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	recorder := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(recorder)
+
+	api := &UserApi{
+		svcCtx: &svc.ServiceContext{
+			Config: &config.Config{
+				System: config.System{Environment: "dev", RouterPrefix: "/api/v1"},
+			},
+		},
+	}
+
+	api.setOidcStateCookie(ctx, "state-value", 600)
+
+	cookies := recorder.Header().Values("Set-Cookie")
+	if len(cookies) != 1 {
+		t.Fatalf("Set-Cookie = %v, want one cookie", cookies)
+	}
+	for _, want := range []string{oidcStateCookie + "=state-value", "Path=/api/v1", "HttpOnly", "SameSite=Lax"} {
+		if !strings.Contains(cookies[0], want) {
+			t.Fatalf("Set-Cookie = %q, want %s", cookies[0], want)
+		}
+	}
+}
+
+func TestOidcErrorHidesInternalDetails(t *testing.T) {
+	tests := []struct {
+		err  error
+		want *errcode.Error
+	}{
+		{oidc.ErrStateInvalid, errcode.SsoStateInvalid},
+		{fmt.Errorf("%w: access_denied <script>", service.ErrOidcProviderDenied), errcode.SsoProviderDenied},
+		{service.ErrOidcDisabled, errcode.SsoUnavailable},
+		{service.ErrAccountNotProvisioned, errcode.SsoAccountUnavailable},
+		{errcode.AccountLocked, errcode.AccountLocked},
+		{errors.New("oidc exchange code: dial tcp 10.0.0.8:443: connection refused"), errcode.SsoLoginFailed},
+	}
+	for _, tt := range tests {
+		if got := oidcError(tt.err); got != tt.want {
+			t.Errorf("oidcError(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
@@ -9,22 +9,44 @@ type SearchApiTokenReq struct {
 }
 
 type CreateApiTokenReq struct {
-	Name           string  `json:"name" binding:"required"`
-	Description    string  `json:"description"`
-	ExpiresAt      *string `json:"expiresAt"`
-	NeverExpire    bool    `json:"neverExpire"`
-	MaxConcurrency int     `json:"maxConcurrency"`
-	ApiIds         []uint  `json:"apiIds" binding:"required"`
+	Name           string              `json:"name" binding:"required"`
+	Description    string              `json:"description"`
+	ExpiresAt      *string             `json:"expiresAt"`
+	NeverExpire    bool                `json:"neverExpire"`
+	MaxConcurrency int                 `json:"maxConcurrency"`
+	QuotaPerMinute int                 `json:"quotaPerMinute" binding:"min=0"` // 0 表示不限制
+	QuotaPerDay    int                 `json:"quotaPerDay" binding:"min=0"`
+	QuotaPerMonth  int                 `json:"quotaPerMonth" binding:"min=0"`
+	ApiIds         []uint              `json:"apiIds"` // 与 ApiGroups、PathScopes 至少填写一项
+	ApiGroups      []string            `json:"apiGroups"`
+	PathScopes     []ApiTokenPathScope `json:"pathScopes" binding:"dive"`
+	AllowedIPs     []string            `json:"allowedIps"` // IP 或 CIDR，留空表示不限制来源
+	// RequireSignature 只接受 HMAC 签名请求 (见 pkg/apisign)
+	RequireSignature bool `json:"requireSignature"`
 }
 
 type UpdateApiTokenReq struct {
-	ID             uint    `json:"id" binding:"required"`
-	Name           string  `json:"name" binding:"required"`
-	Description    string  `json:"description"`
-	ExpiresAt      *string `json:"expiresAt"`
-	NeverExpire    bool    `json:"neverExpire"`
-	MaxConcurrency int     `json:"maxConcurrency"`
-	ApiIds         []uint  `json:"apiIds" binding:"required"`
+	ID             uint                `json:"id" binding:"required"`
+	Name           string              `json:"name" binding:"required"`
+	Description    string              `json:"description"`
+	ExpiresAt      *string             `json:"expiresAt"`
+	NeverExpire    bool                `json:"neverExpire"`
+	MaxConcurrency int                 `json:"maxConcurrency"`
+	QuotaPerMinute int                 `json:"quotaPerMinute" binding:"min=0"` // 0 表示不限制
+	QuotaPerDay    int                 `json:"quotaPerDay" binding:"min=0"`
+	QuotaPerMonth  int                 `json:"quotaPerMonth" binding:"min=0"`
+	ApiIds         []uint              `json:"apiIds"` // 与 ApiGroups、PathScopes 至少填写一项
+	ApiGroups      []string            `json:"apiGroups"`
+	PathScopes     []ApiTokenPathScope `json:"pathScopes" binding:"dive"`
+	AllowedIPs     []string            `json:"allowedIps"` // IP 或 CIDR，留空表示不限制来源
+	// RequireSignature 只接受 HMAC 签名请求 (见 pkg/apisign)
+	RequireSignature bool `json:"requireSignature"`
+}
+
+// ApiTokenPathScope keyMatch2 路径规则，如 /api/v1/poetry/*、/api/v1/poetry/poem/:id；Method 为 * 时匹配任意方法
+type ApiTokenPathScope struct {
+	Method string `json:"method" binding:"required"`
+	Path   string `json:"path" binding:"required"`
 }
 
 type DeleteApiTokenReq struct {
@@ -36,28 +58,50 @@ type ToggleApiTokenReq struct {
 	ID uint `json:"id" binding:"required"`
 }
 
+// RotateApiTokenReq 轮换密钥；Grace 为旧密钥的宽限期 (如 36h、2d，最长 720h)，为空表示使用 api_token_rotation.grace
+type RotateApiTokenReq struct {
+	ID    uint   `json:"id" binding:"required"`
+	Grace string `json:"grace"`
+}
+
 type ApiTokenDetailReq struct {
 	ID uint `json:"id" form:"id" binding:"required"`
 }
 
 type ApiTokenResponse struct {
-	ID             uint            `json:"ID"`
-	TokenPrefix    string          `json:"tokenPrefix"`
-	Name           string          `json:"name"`
-	Description    string          `json:"description"`
-	ExpiresAt      *string         `json:"expiresAt"`
-	NeverExpire    bool            `json:"neverExpire"`
-	MaxConcurrency int             `json:"maxConcurrency"`
-	Enabled        bool            `json:"enabled"`
-	LastUsedAt     *string         `json:"lastUsedAt"`
-	CreatedAt      string          `json:"createdAt"`
-	CreatedBy      uint            `json:"createdBy"`
-	Apis           []ApiSimpleItem `json:"apis"`
+	ID             uint     `json:"ID"`
+	TokenPrefix    string   `json:"tokenPrefix"`
+	Name           string   `json:"name"`
+	Description    string   `json:"description"`
+	ExpiresAt      *string  `json:"expiresAt"`
+	NeverExpire    bool     `json:"neverExpire"`
+	MaxConcurrency int      `json:"maxConcurrency"`
+	QuotaPerMinute int      `json:"quotaPerMinute"`
+	QuotaPerDay    int      `json:"quotaPerDay"`
+	QuotaPerMonth  int      `json:"quotaPerMonth"`
+	Enabled        bool     `json:"enabled"`
+	LastUsedAt     *string  `json:"lastUsedAt"`
+	AllowedIPs     []string `json:"allowedIps"`
+	// RequireSignature 只接受 HMAC 签名请求
+	RequireSignature bool                `json:"requireSignature"`
+	CreatedAt        string              `json:"createdAt"`
+	CreatedBy        uint                `json:"createdBy"`
+	Apis             []ApiSimpleItem     `json:"apis"`
+	ApiGroups        []string            `json:"apiGroups"`
+	PathScopes       []ApiTokenPathScope `json:"pathScopes"`
+	// 轮换宽限期内的旧密钥，宽限期结束后不再返回
+	PreviousTokenPrefix     string  `json:"previousTokenPrefix,omitempty"`
+	PreviousTokenExpiresAt  *string `json:"previousTokenExpiresAt,omitempty"`
+	PreviousTokenLastUsedAt *string `json:"previousTokenLastUsedAt,omitempty"`
+	// EffectiveApis 合并勾选接口、分组与路径规则后实际可访问的接口，仅详情返回
+	EffectiveApis []ApiSimpleItem `json:"effectiveApis,omitempty"`
 }
 
 type ApiTokenSecretResponse struct {
 	ApiTokenResponse
 	Token string `json:"token"`
+	// SigningSecret 签名请求的密钥 (见 pkg/apisign)，未配置 api_token_sign.secret 时为空
+	SigningSecret string `json:"signingSecret,omitempty"`
 }
 
 type ApiSimpleItem struct {
@@ -67,3 +111,40 @@ type ApiSimpleItem struct {
 	ApiGroup    string `json:"apiGroup"`
 	Description string `json:"description"`
 }
+
+// ApiTokenUsageReq 用量查询；时间为 RFC3339，缺省时小时粒度取最近 24 小时，天粒度取最近 30 天
+type ApiTokenUsageReq struct {
+	ID          uint   `json:"id" form:"id" binding:"required"`
+	Granularity string `json:"granularity" form:"granularity" binding:"omitempty,oneof=hour day"`
+	Start       string `json:"start" form:"start"`
+	End         string `json:"end" form:"end"`
+}
+
+type ApiTokenUsageStats struct {
+	Calls    int64   `json:"calls"`
+	Errors   int64   `json:"errors"`
+	BytesIn  int64   `json:"bytesIn"`
+	BytesOut int64   `json:"bytesOut"`
+	P50Ms    float64 `json:"p50Ms"`
+	P95Ms    float64 `json:"p95Ms"`
+}
+
+type ApiTokenUsagePoint struct {
+	Time string `json:"time"`
+	ApiTokenUsageStats
+}
+
+type ApiTokenUsageApiItem struct {
+	Method string `json:"method"`
+	Path   string `json:"path"`
+	ApiTokenUsageStats
+}
+
+type ApiTokenUsageResponse struct {
+	Granularity string                 `json:"granularity"`
+	Start       string                 `json:"start"`
+	End         string                 `json:"end"`
+	Total       ApiTokenUsageStats     `json:"total"`
+	Series      []ApiTokenUsagePoint   `json:"series"`
+	Apis        []ApiTokenUsageApiItem `json:"apis"`
+}
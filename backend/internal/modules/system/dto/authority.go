@@ -6,6 +6,9 @@ type CreateAuthorityReq struct {
 	AuthorityName string `json:"authorityName" binding:"required"`
 	ParentId      uint   `json:"parentId"`      // 父角色ID，0代表根角色
 	DefaultRouter string `json:"defaultRouter"` // 登录后的默认跳转路由
+	MfaRequired   bool   `json:"mfaRequired"`   // 是否强制该角色用户启用二次验证
+	// 允许登录的国家/地区 (ISO 3166-1 二位代码)，为空不限制
+	AllowedCountries []string `json:"allowedCountries"`
 }
 
 // UpdateAuthorityReq 更新角色
@@ -13,6 +16,9 @@ type UpdateAuthorityReq struct {
 	AuthorityId   uint   `json:"authorityId" binding:"required"` // 主键
 	AuthorityName string `json:"authorityName"`
 	DefaultRouter string `json:"defaultRouter"`
+	MfaRequired   bool   `json:"mfaRequired"`
+	// 允许登录的国家/地区 (ISO 3166-1 二位代码)，为空不限制
+	AllowedCountries []string `json:"allowedCountries"`
 	// 通常不建议修改 ParentId，因为涉及复杂的树结构变更检查
 }
 
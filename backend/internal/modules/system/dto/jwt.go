@@ -10,4 +10,7 @@ type BaseClaims struct {
 	Username    string    `json:"username"`
 	NickName    string    `json:"nickName"`
 	AuthorityId uint      `json:"authorityId"`
+	SessionID   string    `json:"sid,omitempty"` // 服务端会话ID
+	// ImpersonatorID 模拟登录发起人 (管理员) ID，非 0 表示该令牌由管理员以当前用户身份使用
+	ImpersonatorID uint `json:"impersonatorId,omitempty"`
 }
@@ -0,0 +1,21 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/CIPFZ/gowebframe/internal/modules/common"
+)
+
+// SearchLoginLogReq 登录日志搜索参数
+type SearchLoginLogReq struct {
+	common.PageInfo
+	UserID    uint       `json:"userId" form:"userId"`     // 按用户查询 (查询本人时由服务端填充)
+	Username  string     `json:"username" form:"username"` // 按登录账号查询
+	Ip        string     `json:"ip" form:"ip"`
+	Country   string     `json:"country" form:"country"` // IP归属国家/地区 (ISO 代码)
+	Method    string     `json:"method" form:"method"`
+	Success   *bool      `json:"success" form:"success"`     // 使用指针以允许查询失败记录
+	NewDevice *bool      `json:"newDevice" form:"newDevice"` // 只看新设备登录
+	StartDate *time.Time `json:"startDate" form:"startDate"`
+	EndDate   *time.Time `json:"endDate" form:"endDate"`
+}
@@ -13,6 +13,7 @@ type SearchOperationLogReq struct {
 	Status    *int       `json:"status" form:"status"`       // 使用指针以允许传 0
 	UserID    uint       `json:"userId" form:"userId"`       // 按操作人查询
 	Ip        string     `json:"ip" form:"ip"`               // 按IP查询
+	Country   string     `json:"country" form:"country"`     // 按IP归属国家/地区 (ISO 代码) 查询
 	TraceID   string     `json:"traceId" form:"traceId"`     // 按链路ID查询
 	StartDate *time.Time `json:"startDate" form:"startDate"` // 时间范围搜索
 	EndDate   *time.Time `json:"endDate" form:"endDate"`
@@ -1,6 +1,12 @@
 package dto
 
-import "github.com/CIPFZ/gowebframe/internal/modules/common"
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/CIPFZ/gowebframe/internal/modules/common"
+	"github.com/CIPFZ/gowebframe/internal/modules/system/model"
+)
 
 type RegisterReq struct {
 	Username string `json:"username" binding:"required"`
@@ -8,14 +14,126 @@ type RegisterReq struct {
 	NickName string `json:"nickName"`
 	Phone    string `json:"phone"`
 	Email    string `json:"email"`
+	Lang     string `json:"-"` // 验证邮件语言 (由接口层从 Accept-Language 填充)
+}
+
+// RegisterVerifyReq 使用邮件中的令牌验证注册邮箱
+type RegisterVerifyReq struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// SearchRegistrationReq 注册申请列表查询
+type SearchRegistrationReq struct {
+	common.PageInfo
+	Username string `json:"username"`
+	Status   int    `json:"status"` // 申请状态，默认 4 待审核
+}
+
+// ReviewRegistrationReq 审核注册申请
+type ReviewRegistrationReq struct {
+	ID     uint   `json:"id" binding:"required"`
+	Reason string `json:"reason" binding:"max=255"` // 审核意见，拒绝时随邮件告知申请人
+	Lang   string `json:"-"`
 }
 
 type LoginReq struct {
 	Type      string `json:"type"`                        // 登录类型
 	Username  string `json:"username" binding:"required"` // 用户名
 	Password  string `json:"password" binding:"required"` // 密码
-	Captcha   string `json:"captcha"`                     // 验证码 (预留)
-	CaptchaId string `json:"captchaId"`                   // 验证码ID (预留)
+	Captcha   string `json:"captcha"`                     // 验证码 (失败次数达到阈值后必填)
+	CaptchaId string `json:"captchaId"`                   // 验证码ID
+	IP        string `json:"-"`                           // 客户端IP (由接口层填充)
+	UserAgent string `json:"-"`                           // User-Agent (由接口层填充)
+}
+
+// PasswordExpiredReq 密码过期或被要求修改时，重新提交账号密码并设置新密码后完成登录
+type PasswordExpiredReq struct {
+	Username    string `json:"username" binding:"required"`
+	Password    string `json:"password" binding:"required"`    // 当前密码
+	NewPassword string `json:"newPassword" binding:"required"` // 新密码
+	Captcha     string `json:"captcha"`
+	CaptchaId   string `json:"captchaId"`
+	IP          string `json:"-"`
+	UserAgent   string `json:"-"`
+}
+
+// ChangePasswordReq 修改本人密码
+type ChangePasswordReq struct {
+	Password    string `json:"password" binding:"required"`    // 当前密码
+	NewPassword string `json:"newPassword" binding:"required"` // 新密码
+}
+
+// ForgotPasswordReq 申请找回密码，账号可以是用户名或邮箱
+type ForgotPasswordReq struct {
+	Account string `json:"account" binding:"required"`
+	Lang    string `json:"-"` // 邮件语言 (由接口层从 Accept-Language 填充)
+	IP      string `json:"-"`
+}
+
+// ResetPasswordByTokenReq 使用邮件中的令牌设置新密码
+type ResetPasswordByTokenReq struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"newPassword" binding:"required"`
+}
+
+// MfaLoginReq 登录第二步：提交挑战令牌与 TOTP 验证码、恢复码或通行密钥签名
+type MfaLoginReq struct {
+	MfaToken     string `json:"mfaToken" binding:"required"`
+	Code         string `json:"code"`         // TOTP 验证码
+	RecoveryCode string `json:"recoveryCode"` // 恢复码 (无法使用验证器时)
+	// 通行密钥：先调用 /user/login/mfa/passkey 获取参数，再提交浏览器返回的凭证
+	PasskeyCeremonyID string          `json:"passkeyCeremonyId"`
+	PasskeyCredential json.RawMessage `json:"passkeyCredential"`
+	IP                string          `json:"-"`
+	UserAgent         string          `json:"-"`
+}
+
+// MfaChallengeReq 使用挑战令牌获取待绑定密钥 (角色强制 MFA 的首次登录)
+type MfaChallengeReq struct {
+	MfaToken string `json:"mfaToken" binding:"required"`
+}
+
+// MfaCodeReq 提交 TOTP 验证码
+type MfaCodeReq struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// PasskeyRegisterReq 提交浏览器 navigator.credentials.create() 的结果
+type PasskeyRegisterReq struct {
+	CeremonyID string          `json:"ceremonyId" binding:"required"`
+	Name       string          `json:"name" binding:"max=64"` // 设备名称，为空时按 User-Agent 生成
+	Credential json.RawMessage `json:"credential" binding:"required"`
+	UserAgent  string          `json:"-"`
+}
+
+// PasskeyLoginReq 提交浏览器 navigator.credentials.get() 的结果，免密码登录
+type PasskeyLoginReq struct {
+	CeremonyID string          `json:"ceremonyId" binding:"required"`
+	Credential json.RawMessage `json:"credential" binding:"required"`
+	IP         string          `json:"-"`
+	UserAgent  string          `json:"-"`
+}
+
+// UserPasskeyReq 管理员查询指定用户的通行密钥
+type UserPasskeyReq struct {
+	UserID uint `json:"userId" binding:"required"`
+}
+
+// OidcCallbackReq IdP 授权回调参数
+type OidcCallbackReq struct {
+	Provider         string `uri:"provider" binding:"required"`
+	Code             string `form:"code"`
+	State            string `form:"state"`
+	Error            string `form:"error"`             // IdP 返回的错误 (如用户拒绝授权)
+	ErrorDescription string `form:"error_description"` // IdP 错误描述
+	BoundState       string `form:"-"`                 // 发起登录的浏览器 Cookie 中保存的 state
+	IP               string `form:"-"`
+	UserAgent        string `form:"-"`
+}
+
+// OidcExchangeReq 使用一次性票据换取登录结果
+type OidcExchangeReq struct {
+	Ticket string `json:"ticket" binding:"required"`
 }
 
 // SearchUserReq 用户列表查询
@@ -35,6 +153,8 @@ type AddUserReq struct {
 	Phone        string `json:"phone"`
 	Email        string `json:"email"`
 	Status       int    `json:"status"` // 1正常 2冻结
+	// RequirePasswordChange 首次登录必须修改密码
+	RequirePasswordChange bool `json:"requirePasswordChange"`
 }
 
 // UpdateUserReq 更新用户 (不包含密码)
@@ -45,12 +165,21 @@ type UpdateUserReq struct {
 	Phone        string `json:"phone"`
 	Email        string `json:"email"`
 	Status       int    `json:"status"`
+	// RequirePasswordChange 下次登录必须修改密码，不传时保持不变
+	RequirePasswordChange *bool `json:"requirePasswordChange"`
 }
 
 // ResetPasswordReq 重置密码
 type ResetPasswordReq struct {
 	ID       uint   `json:"id" binding:"required"`
 	Password string `json:"password" binding:"required"`
+	// RequirePasswordChange 重置后下次登录必须修改密码
+	RequirePasswordChange bool `json:"requirePasswordChange"`
+}
+
+// RefreshTokenReq 刷新令牌请求 (为空时从 Cookie 读取)
+type RefreshTokenReq struct {
+	RefreshToken string `json:"refreshToken"`
 }
 
 // UpdateSelfInfoReq 更新个人信息请求
@@ -71,6 +200,91 @@ type UpdateUiConfigReq struct {
 // ----------------
 
 type LoginResponse struct {
-	Token     string `json:"token"`
-	ExpiresAt int64  `json:"expiresAt"`
+	Token            string `json:"token"`
+	ExpiresAt        int64  `json:"expiresAt"`
+	RefreshToken     string `json:"refreshToken,omitempty"`
+	RefreshExpiresAt int64  `json:"refreshExpiresAt,omitempty"`
+
+	// 需要二次验证时不签发 token，只返回挑战令牌
+	MfaRequired       bool     `json:"mfaRequired,omitempty"`
+	MfaEnrollRequired bool     `json:"mfaEnrollRequired,omitempty"` // 角色要求 MFA 但用户尚未绑定
+	MfaToken          string   `json:"mfaToken,omitempty"`
+	MfaExpiresAt      int64    `json:"mfaExpiresAt,omitempty"`
+	MfaMethods        []string `json:"mfaMethods,omitempty"`    // 可用的二次验证方式：totp、passkey
+	RecoveryCodes     []string `json:"recoveryCodes,omitempty"` // 登录时完成绑定，返回一次性恢复码
+}
+
+// CaptchaResponse 登录验证码
+type CaptchaResponse struct {
+	CaptchaId     string `json:"captchaId"`
+	PicPath       string `json:"picPath"` // data URL 格式的 PNG 图片
+	CaptchaLength int    `json:"captchaLength"`
+	OpenCaptcha   bool   `json:"openCaptcha"` // 当前客户端登录是否需要验证码
+}
+
+// MfaSetupResponse 待绑定的 TOTP 密钥
+type MfaSetupResponse struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"` // otpauth:// 地址，前端渲染为二维码
+}
+
+// MfaRecoveryCodesResponse 新生成的恢复码，只展示这一次
+type MfaRecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recoveryCodes"`
+}
+
+// PasskeyOptionsResponse 通行密钥注册或登录参数
+// Options 为 {publicKey: ...}，前端解码其中的 base64url 字段后传给 navigator.credentials
+type PasskeyOptionsResponse struct {
+	CeremonyID string `json:"ceremonyId"` // 完成注册或登录时原样提交
+	Options    any    `json:"options"`
+}
+
+// SessionInfo 登录会话
+type SessionInfo struct {
+	ID         string    `json:"id"`
+	Device     string    `json:"device"`
+	IP         string    `json:"ip"`
+	UserAgent  string    `json:"userAgent"`
+	CreatedAt  time.Time `json:"createdAt"`
+	LastSeenAt time.Time `json:"lastSeenAt"`
+	ExpiresAt  time.Time `json:"expiresAt"`
+	Current    bool      `json:"current"` // 是否为当前请求所在会话
+	// ImpersonatorID 管理员模拟登录产生的会话，记录发起人 ID
+	ImpersonatorID uint `json:"impersonatorId,omitempty"`
+}
+
+// KickSessionReq 踢出自己的会话
+type KickSessionReq struct {
+	SessionID string `json:"sessionId" binding:"required"`
+}
+
+// UserSessionReq 管理员查询指定用户会话
+type UserSessionReq struct {
+	UserID uint `json:"userId" binding:"required"`
+}
+
+// KickUserSessionReq 管理员踢出指定用户会话 (SessionID 为空时踢出全部)
+type KickUserSessionReq struct {
+	UserID    uint   `json:"userId" binding:"required"`
+	SessionID string `json:"sessionId"`
+}
+
+// ImpersonateReq 管理员以指定用户身份登录
+type ImpersonateReq struct {
+	UserID uint   `json:"userId" binding:"required"`
+	Reason string `json:"reason" binding:"max=255"` // 模拟原因，写入审计日志
+}
+
+// ImpersonationInfo 当前令牌为模拟登录时返回，前端据此展示提示横幅
+type ImpersonationInfo struct {
+	ImpersonatorID   uint      `json:"impersonatorId"`
+	ImpersonatorName string    `json:"impersonatorName"`
+	ExpiresAt        time.Time `json:"expiresAt"`
+}
+
+// SelfInfo 当前登录用户信息，模拟登录时附带发起人信息
+type SelfInfo struct {
+	*model.SysUser
+	Impersonation *ImpersonationInfo `json:"impersonation,omitempty"`
 }
@@ -1,9 +1,11 @@
 package model
 
 import (
+	"strings"
 	"time"
 
 	"github.com/CIPFZ/gowebframe/internal/modules/common"
+	"github.com/casbin/casbin/v2/util"
 )
 
 type SysApiToken struct {
@@ -15,17 +17,46 @@ type SysApiToken struct {
 	Description    string     `json:"description" gorm:"type:varchar(255);comment:token description"`
 	ExpiresAt      *time.Time `json:"expiresAt" gorm:"comment:expires at"`
 	MaxConcurrency int        `json:"maxConcurrency" gorm:"default:5;comment:max concurrency"`
+	QuotaPerMinute int        `json:"quotaPerMinute" gorm:"default:0;comment:requests per minute, 0 means unlimited"`
+	QuotaPerDay    int        `json:"quotaPerDay" gorm:"default:0;comment:requests per day, 0 means unlimited"`
+	QuotaPerMonth  int        `json:"quotaPerMonth" gorm:"default:0;comment:requests per month, 0 means unlimited"`
 	Enabled        bool       `json:"enabled" gorm:"default:true;comment:enabled"`
 	LastUsedAt     *time.Time `json:"lastUsedAt" gorm:"comment:last used at"`
+	AllowedIPs     string     `json:"allowedIps" gorm:"type:varchar(1024);comment:allowed ip or cidr list, comma separated, empty means any"`
 	CreatedBy      uint       `json:"createdBy" gorm:"comment:created by"`
 
-	Apis []SysApi `json:"apis" gorm:"many2many:sys_api_token_apis;joinForeignKey:ApiTokenId;joinReferences:ApiId"`
+	// RequireSignature 为 true 时只接受 HMAC 签名请求，拒绝直接携带 X-API-Token 的请求
+	RequireSignature bool `json:"requireSignature" gorm:"default:false;comment:require hmac signed requests"`
+	// ExpiryNoticeSentAt 到期提醒的发送时间，修改过期时间后清空
+	ExpiryNoticeSentAt *time.Time `json:"-" gorm:"comment:expiry notice sent at"`
+
+	// 轮换后的旧密钥，在 PreviousTokenExpiresAt 之前仍可使用
+	PreviousTokenHash       string     `json:"-" gorm:"type:varchar(64);index;comment:previous token hash during rotation grace"`
+	PreviousTokenPrefix     string     `json:"previousTokenPrefix" gorm:"type:varchar(16);comment:previous token prefix"`
+	PreviousTokenExpiresAt  *time.Time `json:"previousTokenExpiresAt" gorm:"comment:previous token grace deadline"`
+	PreviousTokenLastUsedAt *time.Time `json:"previousTokenLastUsedAt" gorm:"comment:previous token last used at"`
+
+	Apis   []SysApi           `json:"apis" gorm:"many2many:sys_api_token_apis;joinForeignKey:ApiTokenId;joinReferences:ApiId"`
+	Scopes []SysApiTokenScope `json:"scopes" gorm:"foreignKey:ApiTokenID"`
 }
 
 func (SysApiToken) TableName() string {
 	return "sys_api_tokens"
 }
 
+// AllowedIPList 允许的来源地址列表，为空表示不限制
+func (t *SysApiToken) AllowedIPList() []string {
+	if t.AllowedIPs == "" {
+		return nil
+	}
+	return strings.Split(t.AllowedIPs, ",")
+}
+
+// PreviousSecretValid 轮换后的旧密钥是否仍在宽限期内
+func (t *SysApiToken) PreviousSecretValid(now time.Time) bool {
+	return t.PreviousTokenHash != "" && t.PreviousTokenExpiresAt != nil && now.Before(*t.PreviousTokenExpiresAt)
+}
+
 type SysApiTokenApi struct {
 	ApiTokenId uint `gorm:"column:api_token_id;primaryKey;comment:api token id"`
 	ApiId      uint `gorm:"column:api_id;primaryKey;comment:api id"`
@@ -34,3 +65,36 @@ type SysApiTokenApi struct {
 func (SysApiTokenApi) TableName() string {
 	return "sys_api_token_apis"
 }
+
+// API Token 授权范围的类型
+const (
+	ApiTokenScopeGroup = "group" // 按 sys_apis.api_group 授权，分组内新增的接口自动生效
+	ApiTokenScopePath  = "path"  // 按 keyMatch2 路径规则授权，语法与 Casbin 策略相同
+)
+
+// SysApiTokenScope 逐个勾选接口之外的授权范围
+type SysApiTokenScope struct {
+	ID         uint   `json:"-" gorm:"primarykey"`
+	ApiTokenID uint   `json:"-" gorm:"index;not null;comment:api token id"`
+	Kind       string `json:"kind" gorm:"type:varchar(8);not null;comment:scope kind, group or path"`
+	Method     string `json:"method" gorm:"type:varchar(16);comment:request method for path scopes, * means any"`
+	Value      string `json:"value" gorm:"type:varchar(255);not null;comment:api group or path pattern"`
+}
+
+func (SysApiTokenScope) TableName() string {
+	return "sys_api_token_scopes"
+}
+
+// Matches 判断接口是否在授权范围内；group 为接口所属分组，未登记的接口传空串
+func (s SysApiTokenScope) Matches(method, path, group string) bool {
+	switch s.Kind {
+	case ApiTokenScopeGroup:
+		return group != "" && s.Value == group
+	case ApiTokenScopePath:
+		if s.Method != "*" && !strings.EqualFold(s.Method, method) {
+			return false
+		}
+		return util.KeyMatch2(path, s.Value)
+	}
+	return false
+}
@@ -0,0 +1,142 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// API Token 用量的统计粒度
+const (
+	UsageGranularityHour = "hour"
+	UsageGranularityDay  = "day"
+)
+
+// SysApiTokenUsage 按令牌、接口、小时 (或天) 聚合的调用统计
+// 小时数据超过保留期后合并为天数据
+type SysApiTokenUsage struct {
+	ID          uint      `json:"-" gorm:"primarykey"`
+	ApiTokenID  uint      `json:"apiTokenId" gorm:"not null;uniqueIndex:idx_api_token_usage_period,priority:1;comment:api token id"`
+	Granularity string    `json:"granularity" gorm:"type:varchar(8);not null;uniqueIndex:idx_api_token_usage_period,priority:2;comment:granularity, hour or day"`
+	PeriodStart time.Time `json:"periodStart" gorm:"not null;uniqueIndex:idx_api_token_usage_period,priority:3;index;comment:period start"`
+	Method      string    `json:"method" gorm:"type:varchar(16);not null;uniqueIndex:idx_api_token_usage_period,priority:4;comment:request method"`
+	Path        string    `json:"path" gorm:"type:varchar(255);not null;uniqueIndex:idx_api_token_usage_period,priority:5;comment:route path"`
+
+	Calls     int64            `json:"calls" gorm:"not null;default:0;comment:calls"`
+	Errors    int64            `json:"errors" gorm:"not null;default:0;comment:failed calls"`
+	BytesIn   int64            `json:"bytesIn" gorm:"not null;default:0;comment:request bytes"`
+	BytesOut  int64            `json:"bytesOut" gorm:"not null;default:0;comment:response bytes"`
+	P50Ms     float64          `json:"p50Ms" gorm:"comment:p50 latency ms"`
+	P95Ms     float64          `json:"p95Ms" gorm:"comment:p95 latency ms"`
+	Histogram LatencyHistogram `json:"-" gorm:"type:text;comment:latency histogram"`
+	UpdatedAt time.Time        `json:"updatedAt"`
+}
+
+func (SysApiTokenUsage) TableName() string {
+	return "sys_api_token_usages"
+}
+
+// Merge 累加另一组统计并重新计算分位数
+func (u *SysApiTokenUsage) Merge(other *SysApiTokenUsage) {
+	u.Calls += other.Calls
+	u.Errors += other.Errors
+	u.BytesIn += other.BytesIn
+	u.BytesOut += other.BytesOut
+	u.Histogram = u.Histogram.Merge(other.Histogram)
+	u.P50Ms = u.Histogram.Percentile(0.5)
+	u.P95Ms = u.Histogram.Percentile(0.95)
+}
+
+// latencyBucketsMs 耗时分布的桶上界 (毫秒)，最后一个桶收录更慢的请求
+// 调整边界会使已有数据无法合并，只能在末尾追加
+var latencyBucketsMs = []float64{
+	1, 2, 5, 10, 20, 30, 50, 75, 100, 150, 200, 300, 500, 750,
+	1000, 1500, 2000, 3000, 5000, 10000, 30000, 60000,
+}
+
+// LatencyHistogram 固定桶的耗时分布，第 i 个元素为落在第 i 个桶的请求数
+// 分位数无法直接相加，按分布合并后再计算
+type LatencyHistogram []int64
+
+// Observe 记录一次请求耗时
+func (h LatencyHistogram) Observe(d time.Duration) LatencyHistogram {
+	if len(h) == 0 {
+		h = make(LatencyHistogram, len(latencyBucketsMs)+1)
+	}
+	ms := float64(d) / float64(time.Millisecond)
+	i := 0
+	for i < len(latencyBucketsMs) && ms > latencyBucketsMs[i] {
+		i++
+	}
+	h[i]++
+	return h
+}
+
+// Merge 返回两组分布之和
+func (h LatencyHistogram) Merge(other LatencyHistogram) LatencyHistogram {
+	merged := make(LatencyHistogram, max(len(h), len(other), len(latencyBucketsMs)+1))
+	for i, n := range h {
+		merged[i] += n
+	}
+	for i, n := range other {
+		merged[i] += n
+	}
+	return merged
+}
+
+// Percentile 估算分位数 (毫秒)：在目标所在的桶内线性插值
+func (h LatencyHistogram) Percentile(q float64) float64 {
+	var total int64
+	for _, n := range h {
+		total += n
+	}
+	if total == 0 {
+		return 0
+	}
+	rank := q * float64(total)
+	var seen int64
+	for i, n := range h {
+		if n == 0 || float64(seen+n) < rank {
+			seen += n
+			continue
+		}
+		lower := 0.0
+		if i > 0 {
+			lower = latencyBucketsMs[min(i, len(latencyBucketsMs))-1]
+		}
+		if i >= len(latencyBucketsMs) {
+			// 超出最后一个边界，无法估计上限
+			return lower
+		}
+		upper := latencyBucketsMs[i]
+		return lower + (upper-lower)*(rank-float64(seen))/float64(n)
+	}
+	return latencyBucketsMs[len(latencyBucketsMs)-1]
+}
+
+func (h LatencyHistogram) Value() (driver.Value, error) {
+	if len(h) == 0 {
+		return "", nil
+	}
+	b, err := json.Marshal([]int64(h))
+	return string(b), err
+}
+
+func (h *LatencyHistogram) Scan(value any) error {
+	var raw []byte
+	switch v := value.(type) {
+	case nil:
+	case string:
+		raw = []byte(v)
+	case []byte:
+		raw = v
+	default:
+		return fmt.Errorf("LatencyHistogram: unsupported type %T", value)
+	}
+	if len(raw) == 0 {
+		*h = nil
+		return nil
+	}
+	return json.Unmarshal(raw, (*[]int64)(h))
+}
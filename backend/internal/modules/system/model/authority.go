@@ -1,6 +1,12 @@
 package model
 
-import "time"
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"time"
+)
 
 type SysAuthority struct {
 	CreatedAt time.Time  `json:"createdAt"` // 创建时间
@@ -12,6 +18,9 @@ type SysAuthority struct {
 	AuthorityName string `json:"authorityName" gorm:"comment:角色名"`
 	ParentId      uint   `json:"parentId" gorm:"default:0;comment:父角色ID"` // 推荐使用 uint default 0 而非指针
 	DefaultRouter string `json:"defaultRouter" gorm:"comment:默认菜单;default:dashboard"`
+	MfaRequired   bool   `json:"mfaRequired" gorm:"default:false;comment:是否强制二次验证"`
+	// 允许登录的国家/地区 (ISO 代码)，为空不限制；依赖 geoip 配置
+	AllowedCountries CountryList `json:"allowedCountries" gorm:"type:varchar(255);comment:允许登录的国家/地区"`
 
 	// 数据权限 (多对多自关联)
 	DataAuthorityId []*SysAuthority `json:"dataAuthorityId" gorm:"many2many:sys_data_authority_id;"`
@@ -37,3 +46,37 @@ type SysAuthority struct {
 func (SysAuthority) TableName() string {
 	return "sys_authorities"
 }
+
+// CountryList 国家/地区 ISO 代码列表，以 JSON 数组存储
+type CountryList []string
+
+// Allows 列表为空表示不限制
+func (l CountryList) Allows(country string) bool {
+	return len(l) == 0 || slices.Contains(l, country)
+}
+
+func (l CountryList) Value() (driver.Value, error) {
+	if len(l) == 0 {
+		return "", nil
+	}
+	b, err := json.Marshal([]string(l))
+	return string(b), err
+}
+
+func (l *CountryList) Scan(value any) error {
+	var raw []byte
+	switch v := value.(type) {
+	case nil:
+	case string:
+		raw = []byte(v)
+	case []byte:
+		raw = v
+	default:
+		return fmt.Errorf("unsupported country list type %T", value)
+	}
+	if len(raw) == 0 {
+		*l = nil
+		return nil
+	}
+	return json.Unmarshal(raw, l)
+}
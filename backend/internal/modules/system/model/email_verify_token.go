@@ -0,0 +1,21 @@
+package model
+
+import (
+	"time"
+
+	"github.com/CIPFZ/gowebframe/internal/modules/common"
+)
+
+// SysEmailVerifyToken 注册邮箱验证令牌 (仅存 SHA-256 哈希，一次性使用)
+type SysEmailVerifyToken struct {
+	common.BaseModel
+	UserID    uint       `json:"userId" gorm:"index;not null;comment:用户ID"`
+	Email     string     `json:"email" gorm:"type:varchar(128);comment:待验证邮箱"`
+	TokenHash string     `json:"-" gorm:"type:varchar(64);uniqueIndex;not null;comment:令牌哈希"`
+	ExpiresAt time.Time  `json:"expiresAt" gorm:"index;comment:过期时间"`
+	UsedAt    *time.Time `json:"usedAt" gorm:"comment:使用时间"`
+}
+
+func (SysEmailVerifyToken) TableName() string {
+	return "sys_email_verify_tokens"
+}
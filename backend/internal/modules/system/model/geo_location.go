@@ -0,0 +1,9 @@
+package model
+
+// GeoLocation IP 归属地 (离线 GeoIP 库解析，未配置时为空)
+type GeoLocation struct {
+	Country string `json:"country" gorm:"type:varchar(8);index;comment:国家/地区 ISO 代码"`
+	Region  string `json:"region" gorm:"type:varchar(64);comment:一级行政区"`
+	ASN     uint   `json:"asn" gorm:"column:asn;comment:自治系统号"`
+	ASOrg   string `json:"asOrg" gorm:"column:as_org;type:varchar(128);comment:自治系统组织"`
+}
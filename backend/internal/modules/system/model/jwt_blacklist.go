@@ -1,8 +1,15 @@
 package model
 
-import "github.com/CIPFZ/gowebframe/internal/modules/common"
+import (
+	"time"
 
+	"github.com/CIPFZ/gowebframe/internal/modules/common"
+)
+
+// JwtBlacklist 已注销的 access token (未启用 Redis 时使用)
+// 只保存 token 的 sha256，过期后由清理任务删除
 type JwtBlacklist struct {
 	common.BaseModel
-	Jwt string `gorm:"type:text;comment:jwt"`
+	TokenHash string    `gorm:"type:varchar(64);uniqueIndex;comment:token hash"`
+	ExpiresAt time.Time `gorm:"index;comment:token 过期时间"`
 }
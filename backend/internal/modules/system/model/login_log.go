@@ -0,0 +1,35 @@
+package model
+
+import "github.com/CIPFZ/gowebframe/internal/modules/common"
+
+// 登录认证方式，OIDC 登录记录为 "oidc:<provider>"
+const (
+	LoginMethodPassword = "password"
+	LoginMethodOidc     = "oidc"
+	LoginMethodPasskey  = "passkey"
+)
+
+// SysLoginLog 登录日志 (成功与失败均记录)
+type SysLoginLog struct {
+	common.BaseModel
+
+	UserID   uint   `json:"userId" gorm:"index;comment:用户ID (账号不存在时为0)"`
+	Username string `json:"username" gorm:"type:varchar(64);index;comment:登录账号"`
+	Success  bool   `json:"success" gorm:"index;comment:是否成功"`
+	Method   string `json:"method" gorm:"type:varchar(64);comment:认证方式"`
+	Mfa      bool   `json:"mfa" gorm:"comment:是否通过二次验证"`
+	Reason   string `json:"reason" gorm:"type:varchar(255);comment:失败原因"`
+
+	// --- 来源 ---
+	Ip          string `json:"ip" gorm:"type:varchar(64);comment:登录IP"`
+	IPRange     string `json:"ipRange" gorm:"column:ip_range;type:varchar(64);comment:IP网段"`
+	Agent       string `json:"agent" gorm:"type:varchar(512);comment:User-Agent"`
+	Device      string `json:"device" gorm:"type:varchar(128);comment:设备"`
+	Fingerprint string `json:"fingerprint" gorm:"type:varchar(32);comment:设备指纹"`
+	NewDevice   bool   `json:"newDevice" gorm:"comment:是否为首次出现的设备或网段"`
+	GeoLocation `gorm:"embedded"`
+}
+
+func (SysLoginLog) TableName() string {
+	return "sys_login_logs"
+}
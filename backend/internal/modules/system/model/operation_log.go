@@ -22,6 +22,9 @@ type SysOperationLog struct {
 	Agent    string        `json:"agent" gorm:"type:text;comment:User-Agent"`
 	ErrorMsg string        `json:"errorMsg" gorm:"column:error_msg;type:text;comment:错误信息"`
 
+	// --- 请求IP归属地 ---
+	GeoLocation `gorm:"embedded"`
+
 	// --- 业务描述 ---
 	Module string `json:"module" gorm:"type:varchar(64);comment:所属模块"`
 	Remark string `json:"remark" gorm:"type:varchar(128);comment:操作描述"`
@@ -33,6 +36,10 @@ type SysOperationLog struct {
 	// --- 关联用户 ---
 	UserID uint    `json:"userId" gorm:"column:user_id;index;comment:用户ID"`
 	User   SysUser `json:"user" gorm:"foreignKey:UserID;references:ID"`
+
+	// --- 模拟登录发起人 (管理员以该用户身份操作时记录) ---
+	ImpersonatorID uint    `json:"impersonatorId" gorm:"column:impersonator_id;index;default:0;comment:模拟登录发起人ID"`
+	Impersonator   SysUser `json:"impersonator" gorm:"foreignKey:ImpersonatorID;references:ID"`
 }
 
 func (SysOperationLog) TableName() string {
@@ -0,0 +1,21 @@
+package model
+
+import (
+	"time"
+
+	"github.com/CIPFZ/gowebframe/internal/modules/common"
+)
+
+// SysPasswordResetToken 找回密码令牌 (仅存 SHA-256 哈希，一次性使用)
+type SysPasswordResetToken struct {
+	common.BaseModel
+	UserID    uint       `json:"userId" gorm:"index;not null;comment:用户ID"`
+	TokenHash string     `json:"-" gorm:"type:varchar(64);uniqueIndex;not null;comment:令牌哈希"`
+	ExpiresAt time.Time  `json:"expiresAt" gorm:"index;comment:过期时间"`
+	UsedAt    *time.Time `json:"usedAt" gorm:"comment:使用时间"`
+	IP        string     `json:"ip" gorm:"type:varchar(64);comment:申请IP"`
+}
+
+func (SysPasswordResetToken) TableName() string {
+	return "sys_password_reset_tokens"
+}
@@ -0,0 +1,24 @@
+package model
+
+import (
+	"time"
+
+	"github.com/CIPFZ/gowebframe/internal/modules/common"
+)
+
+// SysRefreshToken refresh token 记录
+// 同一次登录派生出的所有 refresh token 属于同一个令牌族 (FamilyID)，
+// 每次刷新都会轮换出新 token；旧 token 被重放时整族吊销。
+type SysRefreshToken struct {
+	common.BaseModel
+	UserID    uint       `json:"userId" gorm:"index;not null;comment:用户ID"`
+	FamilyID  string     `json:"familyId" gorm:"type:varchar(36);index;not null;comment:令牌族ID"`
+	TokenHash string     `json:"-" gorm:"type:varchar(64);uniqueIndex;not null;comment:token hash"`
+	ExpiresAt time.Time  `json:"expiresAt" gorm:"index;comment:过期时间"`
+	RotatedAt *time.Time `json:"rotatedAt" gorm:"comment:轮换时间 (已被使用)"`
+	RevokedAt *time.Time `json:"revokedAt" gorm:"comment:吊销时间"`
+}
+
+func (SysRefreshToken) TableName() string {
+	return "sys_refresh_tokens"
+}
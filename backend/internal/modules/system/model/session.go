@@ -0,0 +1,21 @@
+package model
+
+import "time"
+
+// SysSession 登录会话 (未启用 Redis 时的会话存储)
+type SysSession struct {
+	SessionID  string    `json:"id" gorm:"primaryKey;type:varchar(36);comment:会话ID (即令牌族ID)"`
+	UserID     uint      `json:"userId" gorm:"index;not null;comment:用户ID"`
+	Device     string    `json:"device" gorm:"type:varchar(128);comment:设备"`
+	IP         string    `json:"ip" gorm:"type:varchar(64);comment:登录IP"`
+	UserAgent  string    `json:"userAgent" gorm:"type:varchar(512);comment:User-Agent"`
+	CreatedAt  time.Time `json:"createdAt"`
+	LastSeenAt time.Time `json:"lastSeenAt" gorm:"comment:最后活跃时间"`
+	ExpiresAt  time.Time `json:"expiresAt" gorm:"index;comment:过期时间"`
+	// 模拟登录发起人，普通登录为 0
+	ImpersonatorID uint `json:"impersonatorId" gorm:"default:0;comment:模拟登录发起人ID"`
+}
+
+func (SysSession) TableName() string {
+	return "sys_sessions"
+}
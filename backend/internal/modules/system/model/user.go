@@ -1,6 +1,8 @@
 package model
 
 import (
+	"time"
+
 	"github.com/CIPFZ/gowebframe/internal/modules/common"
 	"github.com/google/uuid"
 	"gorm.io/datatypes"
@@ -9,6 +11,10 @@ import (
 const (
 	UserActive             = 1
 	UserInactive           = 0
+	UserFrozen             = 2
+	UserPendingVerify      = 3 // 自助注册，等待邮箱验证
+	UserPendingApproval    = 4 // 自助注册，等待管理员审核
+	UserRejected           = 5 // 注册申请被拒绝
 	DefaultUserAuthorityID = 2
 	DefaultUserAvatar      = "/default_avatar.jpg"
 )
@@ -21,6 +27,15 @@ type SysUser struct {
 	Username string    `json:"username" gorm:"type:varchar(64);uniqueIndex;comment:用户名"`
 	Password string    `json:"-" gorm:"type:varchar(128);comment:密码"` // JSON 隐藏
 
+	// --- 密码策略 ---
+	PasswordChangedAt     *time.Time `json:"passwordChangedAt" gorm:"comment:最近一次修改密码时间"`
+	RequirePasswordChange bool       `json:"requirePasswordChange" gorm:"default:false;comment:下次登录必须修改密码"`
+
+	// --- 二次验证 (TOTP) ---
+	MfaEnabled  bool   `json:"mfaEnabled" gorm:"default:false;comment:是否已启用二次验证"`
+	MfaSecret   string `json:"-" gorm:"type:varchar(64);comment:TOTP 密钥 (未启用时为待绑定密钥)"`
+	MfaLastStep int64  `json:"-" gorm:"default:0;comment:最近一次使用的 TOTP 时间步 (防重放)"`
+
 	// --- 个人信息 ---
 	NickName string `json:"nickName" gorm:"type:varchar(64);default:系统用户;comment:昵称"`
 	Avatar   string `json:"avatar" gorm:"type:varchar(255);default:https://gw.alipayobjects.com/zos/antfincdn/XAosXuNZyF/BiazfanxmamNRoxxVxka.png;comment:头像"`
@@ -29,7 +44,7 @@ type SysUser struct {
 	Bio      string `json:"bio" gorm:"type:varchar(255);comment:个人简介"`
 
 	// --- 状态与配置 ---
-	Status   int            `json:"status" gorm:"type:smallint;default:1;comment:用户状态 1正常 2冻结"`
+	Status   int            `json:"status" gorm:"type:smallint;default:1;comment:用户状态 0禁用 1正常 2冻结 3待验证 4待审核 5已拒绝"`
 	Settings datatypes.JSON `json:"settings" gorm:"type:json;comment:个性化设置"`
 
 	// --- 权限关联 ---
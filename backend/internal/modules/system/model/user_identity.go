@@ -0,0 +1,18 @@
+package model
+
+import (
+	"github.com/CIPFZ/gowebframe/internal/modules/common"
+)
+
+// SysUserIdentity 外部身份 (OIDC) 与本地用户的绑定关系
+type SysUserIdentity struct {
+	common.BaseModel
+	UserID   uint   `json:"userId" gorm:"index;not null;comment:用户ID"`
+	Provider string `json:"provider" gorm:"type:varchar(64);not null;uniqueIndex:idx_identity_provider_subject;comment:身份提供方"`
+	Subject  string `json:"subject" gorm:"type:varchar(255);not null;uniqueIndex:idx_identity_provider_subject;comment:IdP 用户标识 (sub)"`
+	Email    string `json:"email" gorm:"type:varchar(128);comment:IdP 邮箱"`
+}
+
+func (SysUserIdentity) TableName() string {
+	return "sys_user_identities"
+}
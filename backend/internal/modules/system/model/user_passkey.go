@@ -0,0 +1,27 @@
+package model
+
+import (
+	"time"
+
+	"github.com/CIPFZ/gowebframe/internal/modules/common"
+)
+
+// SysUserPasskey 用户注册的通行密钥 (WebAuthn 凭证)，只保存公钥
+type SysUserPasskey struct {
+	common.BaseModel
+	UserID          uint       `json:"userId" gorm:"index;not null;comment:用户ID"`
+	Name            string     `json:"name" gorm:"type:varchar(64);not null;comment:设备名称"`
+	CredentialID    string     `json:"credentialId" gorm:"type:varchar(255);not null;uniqueIndex;comment:凭证ID (base64url)"`
+	PublicKey       []byte     `json:"-" gorm:"not null;comment:COSE 格式公钥"`
+	AttestationType string     `json:"-" gorm:"type:varchar(32);comment:证明格式"`
+	AAGUID          string     `json:"aaguid" gorm:"column:aaguid;type:varchar(36);comment:验证器型号标识"`
+	Transports      string     `json:"transports" gorm:"type:varchar(128);comment:传输方式，逗号分隔"`
+	SignCount       uint32     `json:"signCount" gorm:"default:0;comment:签名计数 (检测凭证被复制)"`
+	BackupEligible  bool       `json:"backupEligible" gorm:"comment:是否为可同步的多设备凭证"`
+	BackupState     bool       `json:"backupState" gorm:"comment:是否已同步备份"`
+	LastUsedAt      *time.Time `json:"lastUsedAt" gorm:"comment:最近使用时间"`
+}
+
+func (SysUserPasskey) TableName() string {
+	return "sys_user_passkeys"
+}
@@ -0,0 +1,16 @@
+package model
+
+import (
+	"github.com/CIPFZ/gowebframe/internal/modules/common"
+)
+
+// SysUserPasswordHistory 密码历史 (仅存 bcrypt 哈希)，用于禁止复用最近使用过的密码
+type SysUserPasswordHistory struct {
+	common.BaseModel
+	UserID   uint   `json:"userId" gorm:"index;not null;comment:用户ID"`
+	Password string `json:"-" gorm:"type:varchar(128);not null;comment:密码哈希"`
+}
+
+func (SysUserPasswordHistory) TableName() string {
+	return "sys_user_password_histories"
+}
@@ -0,0 +1,19 @@
+package model
+
+import (
+	"time"
+
+	"github.com/CIPFZ/gowebframe/internal/modules/common"
+)
+
+// SysUserRecoveryCode 二次验证恢复码 (仅存 bcrypt 哈希，每个只能使用一次)
+type SysUserRecoveryCode struct {
+	common.BaseModel
+	UserID   uint       `json:"userId" gorm:"index;not null;comment:用户ID"`
+	CodeHash string     `json:"-" gorm:"type:varchar(128);not null;comment:恢复码哈希"`
+	UsedAt   *time.Time `json:"usedAt" gorm:"comment:使用时间"`
+}
+
+func (SysUserRecoveryCode) TableName() string {
+	return "sys_user_recovery_codes"
+}
@@ -15,10 +15,12 @@ type IApiTokenRepository interface {
 	FindByID(ctx context.Context, id uint) (*model.SysApiToken, error)
 	FindByHash(ctx context.Context, hash string) (*model.SysApiToken, error)
 	LoadApisByIDs(ctx context.Context, ids []uint) ([]model.SysApi, error)
-	UpdateWithAPIs(ctx context.Context, token *model.SysApiToken, updates map[string]interface{}, apis []model.SysApi) error
+	ListAllApis(ctx context.Context) ([]model.SysApi, error)
+	UpdateWithAPIs(ctx context.Context, token *model.SysApiToken, updates map[string]interface{}, apis []model.SysApi, scopes []model.SysApiTokenScope) error
 	UpdateColumns(ctx context.Context, id uint, updates map[string]interface{}) error
 	DeleteByIDs(ctx context.Context, ids []uint) error
 	TouchLastUsedAt(ctx context.Context, id uint, usedAt time.Time) error
+	FindUsage(ctx context.Context, id uint, granularities []string, start, end time.Time) ([]model.SysApiTokenUsage, error)
 }
 
 type ApiTokenRepository struct {
@@ -49,7 +51,7 @@ func (r *ApiTokenRepository) GetList(ctx context.Context, req dto.SearchApiToken
 		return nil, 0, err
 	}
 
-	err := base.Preload("Apis").
+	err := base.Preload("Apis").Preload("Scopes").
 		Scopes(req.Paginate()).
 		Order("id desc").
 		Find(&list).Error
@@ -59,13 +61,13 @@ func (r *ApiTokenRepository) GetList(ctx context.Context, req dto.SearchApiToken
 
 func (r *ApiTokenRepository) FindByID(ctx context.Context, id uint) (*model.SysApiToken, error) {
 	var token model.SysApiToken
-	err := r.db.WithContext(ctx).Preload("Apis").First(&token, id).Error
+	err := r.db.WithContext(ctx).Preload("Apis").Preload("Scopes").First(&token, id).Error
 	return &token, err
 }
 
 func (r *ApiTokenRepository) FindByHash(ctx context.Context, hash string) (*model.SysApiToken, error) {
 	var token model.SysApiToken
-	err := r.db.WithContext(ctx).Preload("Apis").Where("token_hash = ?", hash).First(&token).Error
+	err := r.db.WithContext(ctx).Preload("Apis").Preload("Scopes").Where("token_hash = ?", hash).First(&token).Error
 	return &token, err
 }
 
@@ -77,14 +79,34 @@ func (r *ApiTokenRepository) LoadApisByIDs(ctx context.Context, ids []uint) ([]m
 	return apis, nil
 }
 
-func (r *ApiTokenRepository) UpdateWithAPIs(ctx context.Context, token *model.SysApiToken, updates map[string]interface{}, apis []model.SysApi) error {
+func (r *ApiTokenRepository) ListAllApis(ctx context.Context) ([]model.SysApi, error) {
+	var apis []model.SysApi
+	err := r.db.WithContext(ctx).Order("api_group, path, method").Find(&apis).Error
+	return apis, err
+}
+
+func (r *ApiTokenRepository) UpdateWithAPIs(ctx context.Context, token *model.SysApiToken, updates map[string]interface{}, apis []model.SysApi, scopes []model.SysApiTokenScope) error {
 	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		if len(updates) > 0 {
 			if err := tx.Model(token).Updates(updates).Error; err != nil {
 				return err
 			}
 		}
-		return tx.Model(token).Association("Apis").Replace(apis)
+		if err := tx.Model(token).Association("Apis").Replace(apis); err != nil {
+			return err
+		}
+		// 授权范围整体替换
+		if err := tx.Where("api_token_id = ?", token.ID).Delete(&model.SysApiTokenScope{}).Error; err != nil {
+			return err
+		}
+		if len(scopes) == 0 {
+			return nil
+		}
+		for i := range scopes {
+			scopes[i].ID = 0
+			scopes[i].ApiTokenID = token.ID
+		}
+		return tx.Create(&scopes).Error
 	})
 }
 
@@ -97,6 +119,12 @@ func (r *ApiTokenRepository) DeleteByIDs(ctx context.Context, ids []uint) error
 		if err := tx.Where("api_token_id IN ?", ids).Delete(&model.SysApiTokenApi{}).Error; err != nil {
 			return err
 		}
+		if err := tx.Where("api_token_id IN ?", ids).Delete(&model.SysApiTokenScope{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("api_token_id IN ?", ids).Delete(&model.SysApiTokenUsage{}).Error; err != nil {
+			return err
+		}
 		return tx.Delete(&model.SysApiToken{}, ids).Error
 	})
 }
@@ -108,3 +136,13 @@ func (r *ApiTokenRepository) TouchLastUsedAt(ctx context.Context, id uint, usedA
 		Update("last_used_at", usedAt).
 		Error
 }
+
+// FindUsage 查询 [start, end) 内指定粒度的用量统计
+func (r *ApiTokenRepository) FindUsage(ctx context.Context, id uint, granularities []string, start, end time.Time) ([]model.SysApiTokenUsage, error) {
+	var rows []model.SysApiTokenUsage
+	err := r.db.WithContext(ctx).
+		Where("api_token_id = ? AND granularity IN ? AND period_start >= ? AND period_start < ?", id, granularities, start, end).
+		Order("period_start").
+		Find(&rows).Error
+	return rows, err
+}
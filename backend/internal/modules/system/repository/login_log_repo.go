@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"context"
+	"strings"
+
+	"github.com/CIPFZ/gowebframe/internal/modules/system/dto"
+	"github.com/CIPFZ/gowebframe/internal/modules/system/model"
+
+	"gorm.io/gorm"
+)
+
+// LoginHistory 用户以往成功登录中是否出现过当前设备与网段
+type LoginHistory struct {
+	HasSuccess  bool // 是否有过成功登录 (首次登录不算新设备)
+	DeviceSeen  bool
+	IPRangeSeen bool
+}
+
+// ILoginLogRepository 登录日志数据仓库
+type ILoginLogRepository interface {
+	Create(ctx context.Context, log *model.SysLoginLog) error
+	// GetList 根据查询条件分页获取登录日志
+	GetList(ctx context.Context, req dto.SearchLoginLogReq) ([]model.SysLoginLog, int64, error)
+	// History 查询用户以往成功登录的设备与网段
+	History(ctx context.Context, userID uint, fingerprint, ipRange string) (LoginHistory, error)
+}
+
+type LoginLogRepository struct {
+	db *gorm.DB
+}
+
+func NewLoginLogRepository(db *gorm.DB) ILoginLogRepository {
+	return &LoginLogRepository{db: db}
+}
+
+func (r *LoginLogRepository) Create(ctx context.Context, log *model.SysLoginLog) error {
+	return r.db.WithContext(ctx).Create(log).Error
+}
+
+func (r *LoginLogRepository) GetList(ctx context.Context, req dto.SearchLoginLogReq) ([]model.SysLoginLog, int64, error) {
+	var list []model.SysLoginLog
+	var total int64
+	db := r.db.WithContext(ctx).Model(&model.SysLoginLog{})
+
+	if req.UserID != 0 {
+		db = db.Where("user_id = ?", req.UserID)
+	}
+	if req.Username != "" {
+		db = db.Where("username LIKE ?", "%"+req.Username+"%")
+	}
+	if req.Ip != "" {
+		db = db.Where("ip LIKE ?", "%"+req.Ip+"%")
+	}
+	if req.Country != "" {
+		db = db.Where("country = ?", strings.ToUpper(req.Country))
+	}
+	if req.Method != "" {
+		db = db.Where("method LIKE ?", req.Method+"%")
+	}
+	if req.Success != nil {
+		db = db.Where("success = ?", *req.Success)
+	}
+	if req.NewDevice != nil {
+		db = db.Where("new_device = ?", *req.NewDevice)
+	}
+	if req.StartDate != nil && req.EndDate != nil {
+		db = db.Where("created_at BETWEEN ? AND ?", req.StartDate, req.EndDate)
+	}
+
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err := db.Scopes(req.Paginate()).Order("id desc").Find(&list).Error
+	return list, total, err
+}
+
+func (r *LoginLogRepository) History(ctx context.Context, userID uint, fingerprint, ipRange string) (LoginHistory, error) {
+	var h LoginHistory
+	base := func() *gorm.DB {
+		return r.db.WithContext(ctx).Model(&model.SysLoginLog{}).
+			Where("user_id = ? AND success = ?", userID, true)
+	}
+	var n int64
+	if err := base().Count(&n).Error; err != nil {
+		return h, err
+	}
+	if h.HasSuccess = n > 0; !h.HasSuccess {
+		return h, nil
+	}
+	if err := base().Where("fingerprint = ?", fingerprint).Count(&n).Error; err != nil {
+		return h, err
+	}
+	h.DeviceSeen = n > 0
+	if err := base().Where("ip_range = ?", ipRange).Count(&n).Error; err != nil {
+		return h, err
+	}
+	h.IPRangeSeen = n > 0
+	return h, nil
+}
@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/CIPFZ/gowebframe/internal/modules/system/model"
+	"gorm.io/gorm"
+)
+
+// IMfaRepository 二次验证数据访问接口
+type IMfaRepository interface {
+	// ConsumeStep 原子地记录已使用的 TOTP 时间步，返回 false 表示该时间步已被使用 (重放)
+	ConsumeStep(ctx context.Context, userID uint, step int64) (bool, error)
+	// Enable 绑定并启用二次验证，同时替换恢复码
+	Enable(ctx context.Context, userID uint, step int64, codeHashes []string) error
+	Disable(ctx context.Context, userID uint) error
+	SavePendingSecret(ctx context.Context, userID uint, secret string) error
+
+	ListUnusedRecoveryCodes(ctx context.Context, userID uint) ([]model.SysUserRecoveryCode, error)
+	// UseRecoveryCode 原子地标记恢复码已使用，返回 false 表示已被使用
+	UseRecoveryCode(ctx context.Context, id uint, at time.Time) (bool, error)
+	ReplaceRecoveryCodes(ctx context.Context, userID uint, codeHashes []string) error
+}
+
+type MfaRepository struct {
+	db *gorm.DB
+}
+
+func NewMfaRepository(db *gorm.DB) IMfaRepository {
+	return &MfaRepository{db: db}
+}
+
+func (r *MfaRepository) ConsumeStep(ctx context.Context, userID uint, step int64) (bool, error) {
+	res := r.db.WithContext(ctx).
+		Model(&model.SysUser{}).
+		Where("id = ? AND mfa_last_step < ?", userID, step).
+		Update("mfa_last_step", step)
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected == 1, nil
+}
+
+func (r *MfaRepository) Enable(ctx context.Context, userID uint, step int64, codeHashes []string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.SysUser{}).
+			Where("id = ?", userID).
+			Updates(map[string]interface{}{"mfa_enabled": true, "mfa_last_step": step}).Error; err != nil {
+			return err
+		}
+		return replaceRecoveryCodes(tx, userID, codeHashes)
+	})
+}
+
+func (r *MfaRepository) Disable(ctx context.Context, userID uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.SysUser{}).
+			Where("id = ?", userID).
+			Updates(map[string]interface{}{"mfa_enabled": false, "mfa_secret": "", "mfa_last_step": 0}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", userID).Delete(&model.SysUserRecoveryCode{}).Error
+	})
+}
+
+func (r *MfaRepository) SavePendingSecret(ctx context.Context, userID uint, secret string) error {
+	return r.db.WithContext(ctx).
+		Model(&model.SysUser{}).
+		Where("id = ? AND mfa_enabled = ?", userID, false).
+		Update("mfa_secret", secret).
+		Error
+}
+
+func (r *MfaRepository) ListUnusedRecoveryCodes(ctx context.Context, userID uint) ([]model.SysUserRecoveryCode, error) {
+	var list []model.SysUserRecoveryCode
+	err := r.db.WithContext(ctx).Where("user_id = ? AND used_at IS NULL", userID).Find(&list).Error
+	return list, err
+}
+
+func (r *MfaRepository) UseRecoveryCode(ctx context.Context, id uint, at time.Time) (bool, error) {
+	res := r.db.WithContext(ctx).
+		Model(&model.SysUserRecoveryCode{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", at)
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected == 1, nil
+}
+
+func (r *MfaRepository) ReplaceRecoveryCodes(ctx context.Context, userID uint, codeHashes []string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return replaceRecoveryCodes(tx, userID, codeHashes)
+	})
+}
+
+func replaceRecoveryCodes(tx *gorm.DB, userID uint, codeHashes []string) error {
+	if err := tx.Where("user_id = ?", userID).Delete(&model.SysUserRecoveryCode{}).Error; err != nil {
+		return err
+	}
+	if len(codeHashes) == 0 {
+		return nil
+	}
+	codes := make([]model.SysUserRecoveryCode, 0, len(codeHashes))
+	for _, h := range codeHashes {
+		codes = append(codes, model.SysUserRecoveryCode{UserID: userID, CodeHash: h})
+	}
+	return tx.Create(&codes).Error
+}
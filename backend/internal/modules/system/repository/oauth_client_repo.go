@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/CIPFZ/gowebframe/internal/modules/system/dto"
+	"github.com/CIPFZ/gowebframe/internal/modules/system/model"
+	"gorm.io/gorm"
+)
+
+type IOauthClientRepository interface {
+	Create(ctx context.Context, client *model.SysOauthClient) error
+	GetList(ctx context.Context, req dto.SearchOauthClientReq) ([]model.SysOauthClient, int64, error)
+	FindByID(ctx context.Context, id uint) (*model.SysOauthClient, error)
+	FindByIDs(ctx context.Context, ids []uint) ([]model.SysOauthClient, error)
+	FindByClientID(ctx context.Context, clientID string) (*model.SysOauthClient, error)
+	ListApiGroups(ctx context.Context) ([]string, error)
+	UpdateColumns(ctx context.Context, id uint, updates map[string]interface{}) error
+	DeleteByIDs(ctx context.Context, ids []uint) error
+	TouchLastUsedAt(ctx context.Context, id uint, usedAt time.Time) error
+}
+
+type OauthClientRepository struct {
+	db *gorm.DB
+}
+
+func NewOauthClientRepository(db *gorm.DB) IOauthClientRepository {
+	return &OauthClientRepository{db: db}
+}
+
+func (r *OauthClientRepository) Create(ctx context.Context, client *model.SysOauthClient) error {
+	return r.db.WithContext(ctx).Create(client).Error
+}
+
+func (r *OauthClientRepository) GetList(ctx context.Context, req dto.SearchOauthClientReq) ([]model.SysOauthClient, int64, error) {
+	var list []model.SysOauthClient
+	var total int64
+
+	base := r.db.WithContext(ctx).Model(&model.SysOauthClient{})
+	if req.Name != "" {
+		base = base.Where("name LIKE ?", "%"+req.Name+"%")
+	}
+	if req.Enabled != nil {
+		base = base.Where("enabled = ?", *req.Enabled)
+	}
+
+	if err := base.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := base.Scopes(req.Paginate()).
+		Order("id desc").
+		Find(&list).Error
+
+	return list, total, err
+}
+
+func (r *OauthClientRepository) FindByID(ctx context.Context, id uint) (*model.SysOauthClient, error) {
+	var client model.SysOauthClient
+	err := r.db.WithContext(ctx).First(&client, id).Error
+	return &client, err
+}
+
+func (r *OauthClientRepository) FindByIDs(ctx context.Context, ids []uint) ([]model.SysOauthClient, error) {
+	var list []model.SysOauthClient
+	err := r.db.WithContext(ctx).Find(&list, ids).Error
+	return list, err
+}
+
+func (r *OauthClientRepository) FindByClientID(ctx context.Context, clientID string) (*model.SysOauthClient, error) {
+	var client model.SysOauthClient
+	err := r.db.WithContext(ctx).Where("client_id = ?", clientID).First(&client).Error
+	return &client, err
+}
+
+// ListApiGroups 已登记接口的全部分组，用于校验客户端的授权范围
+func (r *OauthClientRepository) ListApiGroups(ctx context.Context) ([]string, error) {
+	var groups []string
+	err := r.db.WithContext(ctx).Model(&model.SysApi{}).Distinct().Pluck("api_group", &groups).Error
+	return groups, err
+}
+
+func (r *OauthClientRepository) UpdateColumns(ctx context.Context, id uint, updates map[string]interface{}) error {
+	return r.db.WithContext(ctx).Model(&model.SysOauthClient{}).Where("id = ?", id).Updates(updates).Error
+}
+
+func (r *OauthClientRepository) DeleteByIDs(ctx context.Context, ids []uint) error {
+	return r.db.WithContext(ctx).Delete(&model.SysOauthClient{}, ids).Error
+}
+
+func (r *OauthClientRepository) TouchLastUsedAt(ctx context.Context, id uint, usedAt time.Time) error {
+	return r.db.WithContext(ctx).
+		Model(&model.SysOauthClient{}).
+		Where("id = ?", id).
+		Update("last_used_at", usedAt).
+		Error
+}
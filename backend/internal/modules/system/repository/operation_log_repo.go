@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"strings"
 
 	"github.com/CIPFZ/gowebframe/internal/modules/system/dto"
 	"github.com/CIPFZ/gowebframe/internal/modules/system/model"
@@ -49,6 +50,9 @@ func (r *OperationLogRepository) GetList(ctx context.Context, req dto.SearchOper
 	if req.Ip != "" {
 		db = db.Where("ip LIKE ?", "%"+req.Ip+"%")
 	}
+	if req.Country != "" {
+		db = db.Where("country = ?", strings.ToUpper(req.Country))
+	}
 	if req.Status != nil {
 		db = db.Where("status = ?", *req.Status)
 	}
@@ -72,8 +76,9 @@ func (r *OperationLogRepository) GetList(ctx context.Context, req dto.SearchOper
 	// 2. 然后执行分页查询获取当前页的数据
 	err := db.Limit(req.PageSize).
 		Offset(req.PageSize * (req.Page - 1)).
-		Preload("User").  // 使用 Preload 预加载关联的 User 信息
-		Order("id desc"). // 按 ID 降序排序，最新的日志在前面
+		Preload("User").         // 使用 Preload 预加载关联的 User 信息
+		Preload("Impersonator"). // 模拟登录期间的操作同时加载发起人
+		Order("id desc").        // 按 ID 降序排序，最新的日志在前面
 		Find(&list).Error
 
 	return list, total, err
@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/CIPFZ/gowebframe/internal/modules/system/model"
+	"gorm.io/gorm"
+)
+
+// IPasskeyRepository 通行密钥数据访问接口
+type IPasskeyRepository interface {
+	Create(ctx context.Context, passkey *model.SysUserPasskey) error
+	ListByUser(ctx context.Context, userID uint) ([]model.SysUserPasskey, error)
+	CountByUser(ctx context.Context, userID uint) (int64, error)
+	FindByID(ctx context.Context, id uint) (*model.SysUserPasskey, error)
+	// UpdateUsage 登录成功后记录签名计数、备份状态与使用时间
+	UpdateUsage(ctx context.Context, id uint, signCount uint32, backupState bool, at time.Time) error
+	// Delete 物理删除，吊销后同一凭证不能再用于登录
+	Delete(ctx context.Context, id uint) error
+}
+
+type PasskeyRepository struct {
+	db *gorm.DB
+}
+
+func NewPasskeyRepository(db *gorm.DB) IPasskeyRepository {
+	return &PasskeyRepository{db: db}
+}
+
+func (r *PasskeyRepository) Create(ctx context.Context, passkey *model.SysUserPasskey) error {
+	return r.db.WithContext(ctx).Create(passkey).Error
+}
+
+func (r *PasskeyRepository) ListByUser(ctx context.Context, userID uint) ([]model.SysUserPasskey, error) {
+	var list []model.SysUserPasskey
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("id").Find(&list).Error
+	return list, err
+}
+
+func (r *PasskeyRepository) CountByUser(ctx context.Context, userID uint) (int64, error) {
+	var total int64
+	err := r.db.WithContext(ctx).Model(&model.SysUserPasskey{}).Where("user_id = ?", userID).Count(&total).Error
+	return total, err
+}
+
+func (r *PasskeyRepository) FindByID(ctx context.Context, id uint) (*model.SysUserPasskey, error) {
+	var passkey model.SysUserPasskey
+	err := r.db.WithContext(ctx).First(&passkey, id).Error
+	return &passkey, err
+}
+
+func (r *PasskeyRepository) UpdateUsage(ctx context.Context, id uint, signCount uint32, backupState bool, at time.Time) error {
+	return r.db.WithContext(ctx).
+		Model(&model.SysUserPasskey{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{"sign_count": signCount, "backup_state": backupState, "last_used_at": at}).
+		Error
+}
+
+func (r *PasskeyRepository) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Unscoped().Delete(&model.SysUserPasskey{}, id).Error
+}
@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/CIPFZ/gowebframe/internal/modules/system/model"
+	"gorm.io/gorm"
+)
+
+// IPasswordResetRepository 找回密码令牌数据访问接口
+type IPasswordResetRepository interface {
+	// Replace 作废该用户尚未使用的令牌并保存新令牌，同一时间只有最新的链接有效
+	Replace(ctx context.Context, token *model.SysPasswordResetToken) error
+	// FindValid 查询未使用且未过期的令牌
+	FindValid(ctx context.Context, tokenHash string, now time.Time) (*model.SysPasswordResetToken, error)
+	// Consume 原子地标记令牌已使用，返回 false 表示已被使用或已过期
+	Consume(ctx context.Context, id uint, now time.Time) (bool, error)
+}
+
+type PasswordResetRepository struct {
+	db *gorm.DB
+}
+
+func NewPasswordResetRepository(db *gorm.DB) IPasswordResetRepository {
+	return &PasswordResetRepository{db: db}
+}
+
+func (r *PasswordResetRepository) Replace(ctx context.Context, token *model.SysPasswordResetToken) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.SysPasswordResetToken{}).
+			Where("user_id = ? AND used_at IS NULL", token.UserID).
+			Update("used_at", time.Now()).Error; err != nil {
+			return err
+		}
+		return tx.Create(token).Error
+	})
+}
+
+func (r *PasswordResetRepository) FindValid(ctx context.Context, tokenHash string, now time.Time) (*model.SysPasswordResetToken, error) {
+	var token model.SysPasswordResetToken
+	err := r.db.WithContext(ctx).
+		Where("token_hash = ? AND used_at IS NULL AND expires_at > ?", tokenHash, now).
+		First(&token).Error
+	return &token, err
+}
+
+func (r *PasswordResetRepository) Consume(ctx context.Context, id uint, now time.Time) (bool, error) {
+	res := r.db.WithContext(ctx).
+		Model(&model.SysPasswordResetToken{}).
+		Where("id = ? AND used_at IS NULL AND expires_at > ?", id, now).
+		Update("used_at", now)
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected == 1, nil
+}
@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/CIPFZ/gowebframe/internal/modules/system/model"
+	"gorm.io/gorm"
+)
+
+// IRefreshTokenRepository refresh token 数据访问接口
+type IRefreshTokenRepository interface {
+	Create(ctx context.Context, token *model.SysRefreshToken) error
+	FindByHash(ctx context.Context, hash string) (*model.SysRefreshToken, error)
+	// MarkRotated 原子地把 token 标记为已轮换，返回 false 表示它已被使用或吊销
+	MarkRotated(ctx context.Context, id uint, at time.Time) (bool, error)
+	RevokeFamily(ctx context.Context, familyID string, at time.Time) error
+	RevokeByUserID(ctx context.Context, userID uint, at time.Time) error
+	// RevokeByUserIDExcept 吊销用户除 keepFamilyID 外的全部令牌族
+	RevokeByUserIDExcept(ctx context.Context, userID uint, keepFamilyID string, at time.Time) error
+}
+
+type RefreshTokenRepository struct {
+	db *gorm.DB
+}
+
+func NewRefreshTokenRepository(db *gorm.DB) IRefreshTokenRepository {
+	return &RefreshTokenRepository{db: db}
+}
+
+func (r *RefreshTokenRepository) Create(ctx context.Context, token *model.SysRefreshToken) error {
+	return r.db.WithContext(ctx).Create(token).Error
+}
+
+func (r *RefreshTokenRepository) FindByHash(ctx context.Context, hash string) (*model.SysRefreshToken, error) {
+	var token model.SysRefreshToken
+	err := r.db.WithContext(ctx).Where("token_hash = ?", hash).First(&token).Error
+	return &token, err
+}
+
+func (r *RefreshTokenRepository) MarkRotated(ctx context.Context, id uint, at time.Time) (bool, error) {
+	res := r.db.WithContext(ctx).
+		Model(&model.SysRefreshToken{}).
+		Where("id = ? AND rotated_at IS NULL AND revoked_at IS NULL", id).
+		Update("rotated_at", at)
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected == 1, nil
+}
+
+func (r *RefreshTokenRepository) RevokeFamily(ctx context.Context, familyID string, at time.Time) error {
+	return r.db.WithContext(ctx).
+		Model(&model.SysRefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", at).
+		Error
+}
+
+func (r *RefreshTokenRepository) RevokeByUserID(ctx context.Context, userID uint, at time.Time) error {
+	return r.db.WithContext(ctx).
+		Model(&model.SysRefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", at).
+		Error
+}
+
+func (r *RefreshTokenRepository) RevokeByUserIDExcept(ctx context.Context, userID uint, keepFamilyID string, at time.Time) error {
+	return r.db.WithContext(ctx).
+		Model(&model.SysRefreshToken{}).
+		Where("user_id = ? AND family_id <> ? AND revoked_at IS NULL", userID, keepFamilyID).
+		Update("revoked_at", at).
+		Error
+}
@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/CIPFZ/gowebframe/internal/modules/system/dto"
+	"github.com/CIPFZ/gowebframe/internal/modules/system/model"
+	"gorm.io/gorm"
+)
+
+// IRegistrationRepository 自助注册 (邮箱验证令牌、审核队列) 数据访问接口
+type IRegistrationRepository interface {
+	// ReplaceVerifyToken 作废该用户尚未使用的验证令牌并保存新令牌
+	ReplaceVerifyToken(ctx context.Context, token *model.SysEmailVerifyToken) error
+	// ConsumeVerifyToken 原子地消费未使用且未过期的令牌，令牌无效时返回 gorm.ErrRecordNotFound
+	ConsumeVerifyToken(ctx context.Context, tokenHash string, now time.Time) (*model.SysEmailVerifyToken, error)
+	// ListApplications 按状态分页查询注册申请
+	ListApplications(ctx context.Context, req dto.SearchRegistrationReq) ([]model.SysUser, int64, error)
+	// TransitStatus 仅当用户处于 from 状态时更新为 to，返回 false 表示状态已变化 (重复审核)
+	TransitStatus(ctx context.Context, userID uint, from, to int) (bool, error)
+}
+
+type RegistrationRepository struct {
+	db *gorm.DB
+}
+
+func NewRegistrationRepository(db *gorm.DB) IRegistrationRepository {
+	return &RegistrationRepository{db: db}
+}
+
+func (r *RegistrationRepository) ReplaceVerifyToken(ctx context.Context, token *model.SysEmailVerifyToken) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.SysEmailVerifyToken{}).
+			Where("user_id = ? AND used_at IS NULL", token.UserID).
+			Update("used_at", time.Now()).Error; err != nil {
+			return err
+		}
+		return tx.Create(token).Error
+	})
+}
+
+func (r *RegistrationRepository) ConsumeVerifyToken(ctx context.Context, tokenHash string, now time.Time) (*model.SysEmailVerifyToken, error) {
+	var token model.SysEmailVerifyToken
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("token_hash = ? AND used_at IS NULL AND expires_at > ?", tokenHash, now).
+			First(&token).Error; err != nil {
+			return err
+		}
+		res := tx.Model(&model.SysEmailVerifyToken{}).
+			Where("id = ? AND used_at IS NULL", token.ID).
+			Update("used_at", now)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected != 1 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (r *RegistrationRepository) ListApplications(ctx context.Context, req dto.SearchRegistrationReq) ([]model.SysUser, int64, error) {
+	var list []model.SysUser
+	var total int64
+	db := r.db.WithContext(ctx).Model(&model.SysUser{}).Where("status = ?", req.Status)
+	if req.Username != "" {
+		db = db.Where("username LIKE ?", "%"+req.Username+"%")
+	}
+
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err := db.Scopes(req.Paginate()).Order("id asc").Find(&list).Error
+	return list, total, err
+}
+
+func (r *RegistrationRepository) TransitStatus(ctx context.Context, userID uint, from, to int) (bool, error) {
+	res := r.db.WithContext(ctx).
+		Model(&model.SysUser{}).
+		Where("id = ? AND status = ?", userID, from).
+		Update("status", to)
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected == 1, nil
+}
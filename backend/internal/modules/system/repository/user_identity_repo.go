@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/CIPFZ/gowebframe/internal/modules/system/model"
+	"gorm.io/gorm"
+)
+
+// IUserIdentityRepository 外部身份绑定数据访问接口
+type IUserIdentityRepository interface {
+	FindByProviderSubject(ctx context.Context, provider, subject string) (*model.SysUserIdentity, error)
+	Create(ctx context.Context, identity *model.SysUserIdentity) error
+	// CreateWithUser 事务创建用户 (含角色关联) 并绑定外部身份
+	CreateWithUser(ctx context.Context, user *model.SysUser, identity *model.SysUserIdentity) error
+	ListByProvider(ctx context.Context, provider string) ([]model.SysUserIdentity, error)
+	// ExistsByUser 用户是否绑定了外部身份
+	ExistsByUser(ctx context.Context, userID uint) (bool, error)
+}
+
+type UserIdentityRepository struct {
+	db *gorm.DB
+}
+
+func NewUserIdentityRepository(db *gorm.DB) IUserIdentityRepository {
+	return &UserIdentityRepository{db: db}
+}
+
+func (r *UserIdentityRepository) FindByProviderSubject(ctx context.Context, provider, subject string) (*model.SysUserIdentity, error) {
+	var identity model.SysUserIdentity
+	err := r.db.WithContext(ctx).
+		Where("provider = ? AND subject = ?", provider, subject).
+		First(&identity).Error
+	return &identity, err
+}
+
+func (r *UserIdentityRepository) Create(ctx context.Context, identity *model.SysUserIdentity) error {
+	return r.db.WithContext(ctx).Create(identity).Error
+}
+
+func (r *UserIdentityRepository) CreateWithUser(ctx context.Context, user *model.SysUser, identity *model.SysUserIdentity) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(user).Error; err != nil {
+			return err
+		}
+		identity.UserID = user.ID
+		return tx.Create(identity).Error
+	})
+}
+
+func (r *UserIdentityRepository) ListByProvider(ctx context.Context, provider string) ([]model.SysUserIdentity, error) {
+	var list []model.SysUserIdentity
+	err := r.db.WithContext(ctx).Where("provider = ?", provider).Order("id").Find(&list).Error
+	return list, err
+}
+
+func (r *UserIdentityRepository) ExistsByUser(ctx context.Context, userID uint) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&model.SysUserIdentity{}).Where("user_id = ?", userID).Count(&count).Error
+	return count > 0, err
+}
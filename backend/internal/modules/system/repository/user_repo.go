@@ -2,6 +2,8 @@ package repository
 
 import (
 	"context"
+	"time"
+
 	"github.com/CIPFZ/gowebframe/internal/modules/system/dto"
 	"github.com/CIPFZ/gowebframe/internal/modules/system/model"
 	"github.com/google/uuid"
@@ -13,6 +15,8 @@ type IUserRepository interface {
 	FindById(ctx context.Context, id uint) (*model.SysUser, error)
 	FindByUuid(ctx context.Context, uuid uuid.UUID) (*model.SysUser, error)
 	FindByUsername(ctx context.Context, username string) (*model.SysUser, error)
+	// FindByEmail 邮箱不唯一，返回最早创建的账号
+	FindByEmail(ctx context.Context, email string) (*model.SysUser, error)
 
 	GetList(ctx context.Context, req dto.SearchUserReq) ([]model.SysUser, int64, error)
 
@@ -22,7 +26,9 @@ type IUserRepository interface {
 
 	UpdateWithRoles(ctx context.Context, user *model.SysUser, req dto.UpdateUserReq) error
 	DeleteWithAssociations(ctx context.Context, id uint) error
-	ResetPassword(ctx context.Context, id uint, password string) error
+	// ChangePassword 更新密码哈希并记录修改时间，旧哈希写入历史，历史只保留最近 keepHistory 条
+	ChangePassword(ctx context.Context, id uint, password string, requireChange bool, keepHistory int) error
+	ListPasswordHistory(ctx context.Context, id uint, limit int) ([]model.SysUserPasswordHistory, error)
 }
 
 type UserRepository struct {
@@ -57,7 +63,20 @@ func (r *UserRepository) FindByUuid(ctx context.Context, uuid uuid.UUID) (*model
 // FindByUsername 根据用户名查询
 func (r *UserRepository) FindByUsername(ctx context.Context, username string) (*model.SysUser, error) {
 	var user model.SysUser
-	err := r.db.WithContext(ctx).Where("username = ?", username).First(&user).Error
+	err := r.db.WithContext(ctx).
+		Where("username = ?", username).
+		Preload("Authorities").
+		First(&user).Error
+	return &user, err
+}
+
+// FindByEmail 根据邮箱查询 (不区分大小写)
+func (r *UserRepository) FindByEmail(ctx context.Context, email string) (*model.SysUser, error) {
+	var user model.SysUser
+	err := r.db.WithContext(ctx).
+		Where("LOWER(email) = LOWER(?)", email).
+		Order("id").
+		First(&user).Error
 	return &user, err
 }
 
@@ -126,6 +145,9 @@ func (r *UserRepository) UpdateWithRoles(ctx context.Context, user *model.SysUse
 			"email":        req.Email,
 			"status":       req.Status,
 		}
+		if req.RequirePasswordChange != nil {
+			updMap["require_password_change"] = *req.RequirePasswordChange
+		}
 
 		// 2. 更新主表
 		if err := tx.Model(&user).Updates(updMap).Error; err != nil {
@@ -159,9 +181,52 @@ func (r *UserRepository) DeleteWithAssociations(ctx context.Context, id uint) er
 	})
 }
 
-// ResetPassword 重置密码
-func (r *UserRepository) ResetPassword(ctx context.Context, id uint, password string) error {
-	return r.db.WithContext(ctx).Model(&model.SysUser{}).Where("id = ?", id).Update("password", password).Error
+// ChangePassword 修改密码
+func (r *UserRepository) ChangePassword(ctx context.Context, id uint, password string, requireChange bool, keepHistory int) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var user model.SysUser
+		if err := tx.Select("id", "password").First(&user, id).Error; err != nil {
+			return err
+		}
+
+		// 1. 旧密码写入历史并裁剪
+		if keepHistory > 0 && user.Password != "" {
+			if err := tx.Create(&model.SysUserPasswordHistory{UserID: id, Password: user.Password}).Error; err != nil {
+				return err
+			}
+			var staleIDs []uint
+			if err := tx.Model(&model.SysUserPasswordHistory{}).
+				Where("user_id = ?", id).
+				Order("id desc").
+				Offset(keepHistory).
+				Pluck("id", &staleIDs).Error; err != nil {
+				return err
+			}
+			if len(staleIDs) > 0 {
+				if err := tx.Unscoped().Delete(&model.SysUserPasswordHistory{}, staleIDs).Error; err != nil {
+					return err
+				}
+			}
+		}
+
+		// 2. 更新密码
+		return tx.Model(&model.SysUser{}).Where("id = ?", id).Updates(map[string]interface{}{
+			"password":                password,
+			"password_changed_at":     time.Now(),
+			"require_password_change": requireChange,
+		}).Error
+	})
+}
+
+// ListPasswordHistory 最近使用过的密码哈希，按时间倒序
+func (r *UserRepository) ListPasswordHistory(ctx context.Context, id uint, limit int) ([]model.SysUserPasswordHistory, error) {
+	var list []model.SysUserPasswordHistory
+	err := r.db.WithContext(ctx).
+		Where("user_id = ?", id).
+		Order("id desc").
+		Limit(limit).
+		Find(&list).Error
+	return list, err
 }
 
 // UpdateColumn 实现
@@ -14,11 +14,13 @@ type SystemApis struct {
 	AuthorityApi *api.AuthorityApi
 	SysApiApi    *api.SysApiApi
 	ApiTokenApi  *api.ApiTokenApi
+	OauthApi     *api.OauthClientApi
 	CasbinApi    *api.CasbinApi
 	OpLogApi     *api.OperationLogApi
 	FileApi      *api.FileApi
 	StateApi     *api.StateApi
 	NoticeApi    *api.NoticeApi
+	LoginLogApi  *api.LoginLogApi
 }
 
 // SystemRouter 负责注册 system 模块的所有路由
@@ -57,10 +59,104 @@ func (s *SystemRouter) initPublicRoutes(public *gin.RouterGroup) {
 		// @Router /user/login [post]
 		userRouter.POST("login", s.apis.UserApi.Login)
 
+		// @Tags User
+		// @Summary 登录二次验证 (TOTP 验证码或恢复码)
+		// @Router /user/login/mfa [post]
+		userRouter.POST("login/mfa", s.apis.UserApi.LoginMfa)
+
+		// @Tags User
+		// @Summary 登录时绑定二次验证 (角色强制 MFA)
+		// @Router /user/login/mfa/setup [post]
+		userRouter.POST("login/mfa/setup", s.apis.UserApi.SetupLoginMfa)
+
+		// @Tags User
+		// @Summary 二次验证使用通行密钥时获取签名参数
+		// @Router /user/login/mfa/passkey [post]
+		userRouter.POST("login/mfa/passkey", s.apis.UserApi.BeginMfaPasskey)
+
+		// @Tags User
+		// @Summary 获取通行密钥免密码登录参数
+		// @Router /user/login/passkey/options [post]
+		userRouter.POST("login/passkey/options", s.apis.UserApi.BeginPasskeyLogin)
+
+		// @Tags User
+		// @Summary 通行密钥免密码登录
+		// @Router /user/login/passkey [post]
+		userRouter.POST("login/passkey", s.apis.UserApi.LoginPasskey)
+
+		// @Tags User
+		// @Summary 密码过期或被要求修改时设置新密码并登录
+		// @Router /user/login/password [post]
+		userRouter.POST("login/password", s.apis.UserApi.LoginPassword)
+
+		// @Tags User
+		// @Summary 申请找回密码 (发送重置邮件)
+		// @Router /user/password/forgot [post]
+		userRouter.POST("password/forgot", s.apis.UserApi.ForgotPassword)
+
+		// @Tags User
+		// @Summary 使用邮件令牌重置密码
+		// @Router /user/password/reset [post]
+		userRouter.POST("password/reset", s.apis.UserApi.ResetPasswordByToken)
+
 		// @Tags User
 		// @Summary 用户注册
 		// @Router /user/register [post]
 		userRouter.POST("register", s.apis.UserApi.Register)
+
+		// @Tags User
+		// @Summary 使用邮件令牌验证注册邮箱
+		// @Router /user/register/verify [post]
+		userRouter.POST("register/verify", s.apis.UserApi.VerifyRegistration)
+
+		// @Tags User
+		// @Summary 获取登录验证码
+		// @Router /user/captcha [post]
+		userRouter.POST("captcha", s.apis.UserApi.Captcha)
+
+		// @Tags User
+		// @Summary 刷新令牌 (refresh token 轮换)
+		// @Router /user/refresh [post]
+		userRouter.POST("refresh", s.apis.UserApi.Refresh)
+
+		// @Tags User
+		// @Summary 获取单点登录方式
+		// @Router /user/oidc/providers [get]
+		userRouter.GET("oidc/providers", s.apis.UserApi.OidcProviders)
+
+		// @Tags User
+		// @Summary 跳转到身份提供方授权页
+		// @Router /user/oidc/{provider}/authorize [get]
+		userRouter.GET("oidc/:provider/authorize", s.apis.UserApi.OidcAuthorize)
+
+		// @Tags User
+		// @Summary 身份提供方授权回调
+		// @Router /user/oidc/{provider}/callback [get]
+		userRouter.GET("oidc/:provider/callback", s.apis.UserApi.OidcCallback)
+
+		// @Tags User
+		// @Summary 使用单点登录票据换取 token
+		// @Router /user/oidc/exchange [post]
+		userRouter.POST("oidc/exchange", s.apis.UserApi.OidcExchange)
+	}
+
+	// OAuth2 客户端凭证模式，客户端以 client_id/client_secret 认证 (Basic 认证头或表单)
+	oauthRouter := public.Group("oauth")
+	{
+		// @Tags OAuth
+		// @Summary 客户端凭证换取访问令牌
+		// @Router /oauth/token [post]
+		oauthRouter.POST("token", s.apis.OauthApi.Token)
+
+		// @Tags OAuth
+		// @Summary 访问令牌内省
+		// @Router /oauth/introspect [post]
+		oauthRouter.POST("introspect", s.apis.OauthApi.Introspect)
+
+		// @Tags OAuth
+		// @Summary 吊销访问令牌
+		// @Router /oauth/revoke [post]
+		oauthRouter.POST("revoke", s.apis.OauthApi.Revoke)
 	}
 }
 
@@ -75,8 +171,10 @@ func (s *SystemRouter) initPrivateRoutes(private *gin.RouterGroup) {
 	s.initAuthorityRoutes(systemGroup)
 	s.initApiRoutes(systemGroup)
 	s.initApiTokenRoutes(systemGroup)
+	s.initOauthClientRoutes(systemGroup)
 	s.initCasbinRoutes(systemGroup)
 	s.initOperationLogRoutes(systemGroup)
+	s.initLoginLogRoutes(systemGroup)
 	s.initFileUploadRoutes(systemGroup)
 	s.initStateRoutes(systemGroup)
 	s.initNoticeRoutes(systemGroup)
@@ -93,15 +191,40 @@ func (s *SystemRouter) initUserRoutes(group *gin.RouterGroup) {
 		userRouter.PUT("info", s.apis.UserApi.UpdateSelfInfo)
 		userRouter.PUT("ui-config", s.apis.UserApi.UpdateUiConfig)
 		userRouter.POST("avatar", s.apis.UserApi.UploadAvatar)
+		userRouter.GET("sessions", s.apis.UserApi.GetSessions)
+		userRouter.POST("getUserSessions", s.apis.UserApi.GetUserSessions)
+		userRouter.POST("getRegistrationList", s.apis.UserApi.GetRegistrationList)
+		userRouter.GET("passkeys", s.apis.UserApi.GetPasskeys)
+		userRouter.POST("getUserPasskeys", s.apis.UserApi.GetUserPasskeys)
 
 		// --- "写" 操作 (统一应用操作日志中间件) ---
 		userWriteGroup := userRouter.Group("", middleware.OperationRecord(s.svcCtx))
 		{
-			userWriteGroup.POST("switchAuthority", s.apis.UserApi.SwitchAuthority)
 			userWriteGroup.POST("addUser", s.apis.UserApi.AddUser)
 			userWriteGroup.PUT("updateUser", s.apis.UserApi.UpdateUser)    // 建议: PUT
 			userWriteGroup.DELETE("deleteUser", s.apis.UserApi.DeleteUser) // 建议: DELETE
-			userWriteGroup.POST("resetPassword", s.apis.UserApi.ResetPassword)
+			userWriteGroup.POST("unlockUser", s.apis.UserApi.UnlockUser)
+			userWriteGroup.POST("kickSession", s.apis.UserApi.KickSession)
+			userWriteGroup.POST("kickUserSession", s.apis.UserApi.KickUserSession)
+			userWriteGroup.POST("approveRegistration", s.apis.UserApi.ApproveRegistration)
+			userWriteGroup.POST("rejectRegistration", s.apis.UserApi.RejectRegistration)
+			userWriteGroup.POST("revokeUserPasskey", s.apis.UserApi.RevokeUserPasskey)
+		}
+
+		// --- 敏感操作 (模拟登录期间禁止) ---
+		userSensitiveGroup := userRouter.Group("", middleware.OperationRecord(s.svcCtx), middleware.DenyImpersonation())
+		{
+			userSensitiveGroup.POST("impersonate", s.apis.UserApi.Impersonate)
+			userSensitiveGroup.POST("switchAuthority", s.apis.UserApi.SwitchAuthority)
+			userSensitiveGroup.POST("resetPassword", s.apis.UserApi.ResetPassword)
+			userSensitiveGroup.POST("changePassword", s.apis.UserApi.ChangePassword)
+			userSensitiveGroup.POST("mfa/setup", s.apis.UserApi.SetupMfa)
+			userSensitiveGroup.POST("mfa/enable", s.apis.UserApi.EnableMfa)
+			userSensitiveGroup.POST("mfa/disable", s.apis.UserApi.DisableMfa)
+			userSensitiveGroup.POST("mfa/recoveryCodes", s.apis.UserApi.RegenerateRecoveryCodes)
+			userSensitiveGroup.POST("passkey/options", s.apis.UserApi.BeginPasskeyRegistration)
+			userSensitiveGroup.POST("passkey/register", s.apis.UserApi.FinishPasskeyRegistration)
+			userSensitiveGroup.POST("passkey/revoke", s.apis.UserApi.RevokePasskey)
 		}
 	}
 }
@@ -165,19 +288,40 @@ func (s *SystemRouter) initApiTokenRoutes(group *gin.RouterGroup) {
 	{
 		apiTokenRouter.POST("getApiTokenList", s.apis.ApiTokenApi.GetApiTokenList)
 		apiTokenRouter.GET("detail", s.apis.ApiTokenApi.GetApiTokenDetail)
+		apiTokenRouter.GET("usage", s.apis.ApiTokenApi.GetApiTokenUsage)
 
-		apiTokenWriteGroup := apiTokenRouter.Group("", middleware.OperationRecord(s.svcCtx))
+		// 签发的令牌不随模拟登录结束而失效，模拟登录期间禁止任何写操作
+		apiTokenWriteGroup := apiTokenRouter.Group("", middleware.OperationRecord(s.svcCtx), middleware.DenyImpersonation())
 		{
 			apiTokenWriteGroup.POST("create", s.apis.ApiTokenApi.CreateApiToken)
 			apiTokenWriteGroup.PUT("update", s.apis.ApiTokenApi.UpdateApiToken)
 			apiTokenWriteGroup.DELETE("delete", s.apis.ApiTokenApi.DeleteApiToken)
 			apiTokenWriteGroup.POST("reset", s.apis.ApiTokenApi.ResetApiToken)
+			apiTokenWriteGroup.POST("rotate", s.apis.ApiTokenApi.RotateApiToken)
 			apiTokenWriteGroup.POST("enable", s.apis.ApiTokenApi.EnableApiToken)
 			apiTokenWriteGroup.POST("disable", s.apis.ApiTokenApi.DisableApiToken)
 		}
 	}
 }
 
+func (s *SystemRouter) initOauthClientRoutes(group *gin.RouterGroup) {
+	oauthClientRouter := group.Group("oauth-client")
+	{
+		oauthClientRouter.POST("getOauthClientList", s.apis.OauthApi.GetOauthClientList)
+
+		// 与 API Token 相同，模拟登录期间禁止签发或修改客户端凭证
+		oauthClientWriteGroup := oauthClientRouter.Group("", middleware.OperationRecord(s.svcCtx), middleware.DenyImpersonation())
+		{
+			oauthClientWriteGroup.POST("create", s.apis.OauthApi.CreateOauthClient)
+			oauthClientWriteGroup.PUT("update", s.apis.OauthApi.UpdateOauthClient)
+			oauthClientWriteGroup.DELETE("delete", s.apis.OauthApi.DeleteOauthClient)
+			oauthClientWriteGroup.POST("reset", s.apis.OauthApi.ResetOauthClientSecret)
+			oauthClientWriteGroup.POST("enable", s.apis.OauthApi.EnableOauthClient)
+			oauthClientWriteGroup.POST("disable", s.apis.OauthApi.DisableOauthClient)
+		}
+	}
+}
+
 // initCasbinRoutes 注册 Casbin 策略管理相关路由
 func (s *SystemRouter) initCasbinRoutes(group *gin.RouterGroup) {
 	casbinRouter := group.Group("casbin")
@@ -208,6 +352,15 @@ func (s *SystemRouter) initOperationLogRoutes(group *gin.RouterGroup) {
 	}
 }
 
+// initLoginLogRoutes 注册登录日志相关路由 (只读)
+func (s *SystemRouter) initLoginLogRoutes(group *gin.RouterGroup) {
+	loginLogRouter := group.Group("loginLog")
+	{
+		loginLogRouter.POST("getLoginLogList", s.apis.LoginLogApi.GetLoginLogList)         // 全部用户 (管理员)
+		loginLogRouter.POST("getSelfLoginLogList", s.apis.LoginLogApi.GetSelfLoginLogList) // 本人
+	}
+}
+
 // initFileUploadRoutes 注册文件上传相关路由
 func (s *SystemRouter) initFileUploadRoutes(group *gin.RouterGroup) {
 	group.POST("file/upload", s.apis.FileApi.Upload)
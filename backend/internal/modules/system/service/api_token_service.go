@@ -3,14 +3,20 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
+	"net/http"
+	"sort"
 	"strings"
 	"time"
 
+	logger "github.com/CIPFZ/gowebframe/internal/core/log"
 	tokenCore "github.com/CIPFZ/gowebframe/internal/core/token"
 	"github.com/CIPFZ/gowebframe/internal/modules/system/dto"
 	"github.com/CIPFZ/gowebframe/internal/modules/system/model"
 	"github.com/CIPFZ/gowebframe/internal/modules/system/repository"
 	"github.com/CIPFZ/gowebframe/internal/svc"
+	"github.com/CIPFZ/gowebframe/pkg/utils"
+	"go.uber.org/zap"
 	"gorm.io/gorm"
 )
 
@@ -21,8 +27,10 @@ type IApiTokenService interface {
 	UpdateApiToken(ctx context.Context, req dto.UpdateApiTokenReq) error
 	DeleteApiToken(ctx context.Context, req dto.DeleteApiTokenReq) error
 	ResetApiToken(ctx context.Context, id uint) (*dto.ApiTokenSecretResponse, error)
+	RotateApiToken(ctx context.Context, req dto.RotateApiTokenReq) (*dto.ApiTokenSecretResponse, error)
 	EnableApiToken(ctx context.Context, id uint) error
 	DisableApiToken(ctx context.Context, id uint) error
+	GetApiTokenUsage(ctx context.Context, req dto.ApiTokenUsageReq) (*dto.ApiTokenUsageResponse, error)
 }
 
 type ApiTokenService struct {
@@ -30,6 +38,11 @@ type ApiTokenService struct {
 	tokenRepo repository.IApiTokenRepository
 }
 
+var (
+	errApiTokenSignDisabled = errors.New("未配置 api_token_sign.secret，无法要求签名请求")
+	errRotationGraceInvalid = errors.New("宽限期格式错误或超过 720h")
+)
+
 func NewApiTokenService(svcCtx *svc.ServiceContext, tokenRepo repository.IApiTokenRepository) IApiTokenService {
 	return &ApiTokenService{
 		svcCtx:    svcCtx,
@@ -38,34 +51,47 @@ func NewApiTokenService(svcCtx *svc.ServiceContext, tokenRepo repository.IApiTok
 }
 
 func (s *ApiTokenService) CreateApiToken(ctx context.Context, createdBy uint, req dto.CreateApiTokenReq) (*dto.ApiTokenSecretResponse, error) {
+	if req.RequireSignature && s.signSecret() == "" {
+		return nil, errApiTokenSignDisabled
+	}
 	expiresAt, err := parseExpiresAt(req.ExpiresAt, req.NeverExpire)
 	if err != nil {
 		return nil, err
 	}
 
-	apis, err := s.resolveApis(ctx, req.ApiIds)
+	apis, scopes, err := s.resolveGrants(ctx, req.ApiIds, req.ApiGroups, req.PathScopes)
+	if err != nil {
+		return nil, err
+	}
+	allowedIPs, err := normalizeAllowedIPs(req.AllowedIPs)
 	if err != nil {
 		return nil, err
 	}
 
 	rawToken := tokenCore.GenerateRawToken()
 	token := &model.SysApiToken{
-		TokenHash:      tokenCore.HashToken(rawToken),
-		TokenPrefix:    buildTokenPrefix(rawToken),
-		Name:           strings.TrimSpace(req.Name),
-		Description:    strings.TrimSpace(req.Description),
-		ExpiresAt:      expiresAt,
-		MaxConcurrency: normalizeMaxConcurrency(req.MaxConcurrency),
-		Enabled:        true,
-		CreatedBy:      createdBy,
-		Apis:           apis,
+		TokenHash:        tokenCore.HashToken(rawToken),
+		TokenPrefix:      buildTokenPrefix(rawToken),
+		Name:             strings.TrimSpace(req.Name),
+		Description:      strings.TrimSpace(req.Description),
+		ExpiresAt:        expiresAt,
+		MaxConcurrency:   normalizeMaxConcurrency(req.MaxConcurrency),
+		QuotaPerMinute:   req.QuotaPerMinute,
+		QuotaPerDay:      req.QuotaPerDay,
+		QuotaPerMonth:    req.QuotaPerMonth,
+		AllowedIPs:       allowedIPs,
+		RequireSignature: req.RequireSignature,
+		Enabled:          true,
+		CreatedBy:        createdBy,
+		Apis:             apis,
+		Scopes:           scopes,
 	}
 
 	if err := s.tokenRepo.Create(ctx, token); err != nil {
 		return nil, err
 	}
 
-	return buildSecretResponse(token, rawToken), nil
+	return s.buildSecretResponse(token, rawToken), nil
 }
 
 func (s *ApiTokenService) GetApiTokenList(ctx context.Context, req dto.SearchApiTokenReq) ([]dto.ApiTokenResponse, int64, error) {
@@ -87,10 +113,19 @@ func (s *ApiTokenService) GetApiTokenDetail(ctx context.Context, id uint) (*dto.
 		return nil, err
 	}
 	resp := buildApiTokenResponse(token)
+
+	allApis, err := s.tokenRepo.ListAllApis(ctx)
+	if err != nil {
+		return nil, err
+	}
+	resp.EffectiveApis = buildApiItems(effectiveApis(token, allApis))
 	return &resp, nil
 }
 
 func (s *ApiTokenService) UpdateApiToken(ctx context.Context, req dto.UpdateApiTokenReq) error {
+	if req.RequireSignature && s.signSecret() == "" {
+		return errApiTokenSignDisabled
+	}
 	token, err := s.tokenRepo.FindByID(ctx, req.ID)
 	if err != nil {
 		return err
@@ -101,18 +136,35 @@ func (s *ApiTokenService) UpdateApiToken(ctx context.Context, req dto.UpdateApiT
 		return err
 	}
 
-	apis, err := s.resolveApis(ctx, req.ApiIds)
+	apis, scopes, err := s.resolveGrants(ctx, req.ApiIds, req.ApiGroups, req.PathScopes)
+	if err != nil {
+		return err
+	}
+	allowedIPs, err := normalizeAllowedIPs(req.AllowedIPs)
 	if err != nil {
 		return err
 	}
 
 	updates := map[string]interface{}{
-		"name":            strings.TrimSpace(req.Name),
-		"description":     strings.TrimSpace(req.Description),
-		"expires_at":      expiresAt,
-		"max_concurrency": normalizeMaxConcurrency(req.MaxConcurrency),
+		"name":              strings.TrimSpace(req.Name),
+		"description":       strings.TrimSpace(req.Description),
+		"expires_at":        expiresAt,
+		"max_concurrency":   normalizeMaxConcurrency(req.MaxConcurrency),
+		"quota_per_minute":  req.QuotaPerMinute,
+		"quota_per_day":     req.QuotaPerDay,
+		"quota_per_month":   req.QuotaPerMonth,
+		"allowed_ips":       allowedIPs,
+		"require_signature": req.RequireSignature,
 	}
-	return s.tokenRepo.UpdateWithAPIs(ctx, token, updates, apis)
+	// 过期时间变化后重新发送到期提醒
+	if !sameTime(token.ExpiresAt, expiresAt) {
+		updates["expiry_notice_sent_at"] = nil
+	}
+	if err := s.tokenRepo.UpdateWithAPIs(ctx, token, updates, apis, scopes); err != nil {
+		return err
+	}
+	s.invalidateTokenCache(ctx, token.ID)
+	return nil
 }
 
 func (s *ApiTokenService) DeleteApiToken(ctx context.Context, req dto.DeleteApiTokenReq) error {
@@ -124,7 +176,11 @@ func (s *ApiTokenService) DeleteApiToken(ctx context.Context, req dto.DeleteApiT
 	if len(ids) == 0 {
 		return errors.New("未选择要删除的 token")
 	}
-	return s.tokenRepo.DeleteByIDs(ctx, ids)
+	if err := s.tokenRepo.DeleteByIDs(ctx, ids); err != nil {
+		return err
+	}
+	s.invalidateTokenCache(ctx, ids...)
+	return nil
 }
 
 func (s *ApiTokenService) ResetApiToken(ctx context.Context, id uint) (*dto.ApiTokenSecretResponse, error) {
@@ -133,40 +189,299 @@ func (s *ApiTokenService) ResetApiToken(ctx context.Context, id uint) (*dto.ApiT
 		return nil, err
 	}
 
+	// 重置用于密钥泄露等场景，旧密钥 (包括轮换宽限期内的) 立即失效
 	rawToken := tokenCore.GenerateRawToken()
 	updates := map[string]interface{}{
-		"token_hash":   tokenCore.HashToken(rawToken),
-		"token_prefix": buildTokenPrefix(rawToken),
+		"token_hash":                  tokenCore.HashToken(rawToken),
+		"token_prefix":                buildTokenPrefix(rawToken),
+		"previous_token_hash":         "",
+		"previous_token_prefix":       "",
+		"previous_token_expires_at":   nil,
+		"previous_token_last_used_at": nil,
 	}
-	if err := s.tokenRepo.UpdateWithAPIs(ctx, token, updates, token.Apis); err != nil {
+	if err := s.tokenRepo.UpdateColumns(ctx, token.ID, updates); err != nil {
 		return nil, err
 	}
+	s.invalidateTokenCache(ctx, token.ID)
 
 	token.TokenHash = updates["token_hash"].(string)
 	token.TokenPrefix = updates["token_prefix"].(string)
-	return buildSecretResponse(token, rawToken), nil
+	token.PreviousTokenHash = ""
+	token.PreviousTokenPrefix = ""
+	token.PreviousTokenExpiresAt = nil
+	token.PreviousTokenLastUsedAt = nil
+	return s.buildSecretResponse(token, rawToken), nil
+}
+
+const (
+	defaultRotationGrace = 24 * time.Hour
+	// maxRotationGrace 旧密钥宽限期上限，轮换时指定与 api_token_rotation.grace 配置均不能超过
+	maxRotationGrace = 720 * time.Hour
+)
+
+// rotationGrace 旧密钥的宽限期：优先使用轮换时指定的值，其次为 api_token_rotation.grace
+func (s *ApiTokenService) rotationGrace(grace string) (time.Duration, error) {
+	if grace != "" {
+		d := utils.ParseDurationOr(grace, 0)
+		if d == 0 || d > maxRotationGrace {
+			return 0, errRotationGraceInvalid
+		}
+		return d, nil
+	}
+	if s.svcCtx.Config == nil {
+		return defaultRotationGrace, nil
+	}
+	return min(utils.ParseDurationOr(s.svcCtx.Config.ApiTokenRotation.Grace, defaultRotationGrace), maxRotationGrace), nil
+}
+
+// RotateApiToken 签发新密钥，旧密钥在宽限期内仍可使用，调用方可以逐步切换
+// 宽限期内再次轮换时，更早的密钥立即失效
+func (s *ApiTokenService) RotateApiToken(ctx context.Context, req dto.RotateApiTokenReq) (*dto.ApiTokenSecretResponse, error) {
+	token, err := s.tokenRepo.FindByID(ctx, req.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	grace, err := s.rotationGrace(req.Grace)
+	if err != nil {
+		return nil, err
+	}
+	graceUntil := time.Now().Add(grace)
+
+	rawToken := tokenCore.GenerateRawToken()
+	updates := map[string]interface{}{
+		"token_hash":                  tokenCore.HashToken(rawToken),
+		"token_prefix":                buildTokenPrefix(rawToken),
+		"previous_token_hash":         token.TokenHash,
+		"previous_token_prefix":       token.TokenPrefix,
+		"previous_token_expires_at":   graceUntil,
+		"previous_token_last_used_at": nil,
+	}
+	if err := s.tokenRepo.UpdateColumns(ctx, token.ID, updates); err != nil {
+		return nil, err
+	}
+	s.invalidateTokenCache(ctx, token.ID)
+
+	token.PreviousTokenHash = token.TokenHash
+	token.PreviousTokenPrefix = token.TokenPrefix
+	token.PreviousTokenExpiresAt = &graceUntil
+	token.PreviousTokenLastUsedAt = nil
+	token.TokenHash = updates["token_hash"].(string)
+	token.TokenPrefix = updates["token_prefix"].(string)
+	return s.buildSecretResponse(token, rawToken), nil
 }
 
 func (s *ApiTokenService) EnableApiToken(ctx context.Context, id uint) error {
-	return s.tokenRepo.UpdateColumns(ctx, id, map[string]interface{}{"enabled": true})
+	if err := s.tokenRepo.UpdateColumns(ctx, id, map[string]interface{}{"enabled": true}); err != nil {
+		return err
+	}
+	s.invalidateTokenCache(ctx, id)
+	return nil
 }
 
 func (s *ApiTokenService) DisableApiToken(ctx context.Context, id uint) error {
-	return s.tokenRepo.UpdateColumns(ctx, id, map[string]interface{}{"enabled": false})
+	if err := s.tokenRepo.UpdateColumns(ctx, id, map[string]interface{}{"enabled": false}); err != nil {
+		return err
+	}
+	s.invalidateTokenCache(ctx, id)
+	return nil
 }
 
-func (s *ApiTokenService) resolveApis(ctx context.Context, apiIDs []uint) ([]model.SysApi, error) {
-	if len(apiIDs) == 0 {
-		return nil, errors.New("请至少选择一个 API")
+// invalidateTokenCache 令牌变更已写库，通知鉴权缓存失效
+// 广播失败只影响其他实例，最多延迟一个缓存 TTL 生效，不回滚本次修改
+func (s *ApiTokenService) invalidateTokenCache(ctx context.Context, ids ...uint) {
+	if s.svcCtx.APITokenCache == nil {
+		return
+	}
+	if err := s.svcCtx.APITokenCache.Invalidate(ctx, ids...); err != nil {
+		logger.GetLogger(ctx).Warn("api_token_cache_invalidate_failed", zap.Uints("tokenIDs", ids), zap.Error(err))
+	}
+}
+
+// 单次查询的最大时间跨度，避免按小时补零生成过多的点
+const (
+	maxHourlyUsageRange = 7 * 24 * time.Hour
+	maxDailyUsageRange  = 366 * 24 * time.Hour
+)
+
+// GetApiTokenUsage 按小时或天返回令牌的调用时间序列与各接口汇总
+// 超过保留期的小时数据已合并为天数据，天粒度同时统计两种数据；小时粒度只包含尚未合并的数据
+func (s *ApiTokenService) GetApiTokenUsage(ctx context.Context, req dto.ApiTokenUsageReq) (*dto.ApiTokenUsageResponse, error) {
+	if _, err := s.tokenRepo.FindByID(ctx, req.ID); err != nil {
+		return nil, err
 	}
-	apis, err := s.tokenRepo.LoadApisByIDs(ctx, apiIDs)
+
+	granularity := req.Granularity
+	if granularity == "" {
+		granularity = model.UsageGranularityHour
+	}
+	truncate, maxRange, defaultRange := truncateUsageHour, maxHourlyUsageRange, 24*time.Hour
+	granularities := []string{model.UsageGranularityHour}
+	if granularity == model.UsageGranularityDay {
+		truncate, maxRange, defaultRange = truncateUsageDay, maxDailyUsageRange, 30*24*time.Hour
+		granularities = append(granularities, model.UsageGranularityDay)
+	}
+
+	end := time.Now()
+	if raw := strings.TrimSpace(req.End); raw != "" {
+		parsed, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return nil, errors.New("结束时间格式错误")
+		}
+		end = parsed
+	}
+	start := end.Add(-defaultRange)
+	if raw := strings.TrimSpace(req.Start); raw != "" {
+		parsed, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return nil, errors.New("开始时间格式错误")
+		}
+		start = parsed
+	}
+	if !start.Before(end) {
+		return nil, errors.New("开始时间必须早于结束时间")
+	}
+	if end.Sub(start) > maxRange {
+		return nil, errors.New("查询时间跨度过大")
+	}
+	// 对齐到周期边界，包含 end 所在的周期
+	start = truncate(start)
+	end = nextUsagePeriod(truncate(end.Add(-time.Nanosecond)), granularity)
+
+	rows, err := s.tokenRepo.FindUsage(ctx, req.ID, granularities, start, end)
 	if err != nil {
 		return nil, err
 	}
-	if len(apis) != len(apiIDs) {
-		return nil, errors.New("存在无效的 API 选择")
+
+	type apiKey struct{ method, path string }
+	buckets := make(map[int64]*model.SysApiTokenUsage)
+	apis := make(map[apiKey]*model.SysApiTokenUsage)
+	var apiOrder []apiKey
+	total := &model.SysApiTokenUsage{}
+	for i := range rows {
+		row := &rows[i]
+		period := truncate(row.PeriodStart).Unix()
+		if buckets[period] == nil {
+			buckets[period] = &model.SysApiTokenUsage{}
+		}
+		buckets[period].Merge(row)
+
+		key := apiKey{method: row.Method, path: row.Path}
+		if apis[key] == nil {
+			apis[key] = &model.SysApiTokenUsage{Method: row.Method, Path: row.Path}
+			apiOrder = append(apiOrder, key)
+		}
+		apis[key].Merge(row)
+		total.Merge(row)
+	}
+
+	resp := &dto.ApiTokenUsageResponse{
+		Granularity: granularity,
+		Start:       start.Format(time.RFC3339),
+		End:         end.Format(time.RFC3339),
+		Total:       buildUsageStats(total),
+		Series:      make([]dto.ApiTokenUsagePoint, 0),
+		Apis:        make([]dto.ApiTokenUsageApiItem, 0, len(apiOrder)),
+	}
+	for t := start; t.Before(end); t = nextUsagePeriod(t, granularity) {
+		point := dto.ApiTokenUsagePoint{Time: t.Format(time.RFC3339)}
+		if bucket := buckets[t.Unix()]; bucket != nil {
+			point.ApiTokenUsageStats = buildUsageStats(bucket)
+		}
+		resp.Series = append(resp.Series, point)
+	}
+	for _, key := range apiOrder {
+		resp.Apis = append(resp.Apis, dto.ApiTokenUsageApiItem{
+			Method:             key.method,
+			Path:               key.path,
+			ApiTokenUsageStats: buildUsageStats(apis[key]),
+		})
+	}
+	sort.SliceStable(resp.Apis, func(i, j int) bool {
+		return resp.Apis[i].Calls > resp.Apis[j].Calls
+	})
+	return resp, nil
+}
+
+// resolveGrants 校验勾选的接口与分组、路径规则，三者至少填写一项
+func (s *ApiTokenService) resolveGrants(ctx context.Context, apiIDs []uint, groups []string, pathScopes []dto.ApiTokenPathScope) ([]model.SysApi, []model.SysApiTokenScope, error) {
+	if len(apiIDs) == 0 && len(groups) == 0 && len(pathScopes) == 0 {
+		return nil, nil, errors.New("请至少选择一个 API、分组或路径规则")
+	}
+
+	var apis []model.SysApi
+	if len(apiIDs) > 0 {
+		var err error
+		apis, err = s.tokenRepo.LoadApisByIDs(ctx, apiIDs)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(apis) != len(apiIDs) {
+			return nil, nil, errors.New("存在无效的 API 选择")
+		}
+	}
+
+	scopes := make([]model.SysApiTokenScope, 0, len(groups)+len(pathScopes))
+	if len(groups) > 0 {
+		allApis, err := s.tokenRepo.ListAllApis(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+		known := make(map[string]bool)
+		for _, api := range allApis {
+			known[api.ApiGroup] = true
+		}
+		seen := make(map[string]bool)
+		for _, group := range groups {
+			group = strings.TrimSpace(group)
+			if !known[group] {
+				return nil, nil, fmt.Errorf("API 分组不存在: %s", group)
+			}
+			if seen[group] {
+				continue
+			}
+			seen[group] = true
+			scopes = append(scopes, model.SysApiTokenScope{Kind: model.ApiTokenScopeGroup, Value: group})
+		}
+	}
+	for _, scope := range pathScopes {
+		method := strings.ToUpper(strings.TrimSpace(scope.Method))
+		path := strings.TrimSpace(scope.Path)
+		if method != "*" && !allowedScopeMethods[method] {
+			return nil, nil, fmt.Errorf("不支持的请求方法: %s", scope.Method)
+		}
+		if !strings.HasPrefix(path, "/") {
+			return nil, nil, fmt.Errorf("路径规则必须以 / 开头: %s", scope.Path)
+		}
+		scopes = append(scopes, model.SysApiTokenScope{Kind: model.ApiTokenScopePath, Method: method, Value: path})
+	}
+	return apis, scopes, nil
+}
+
+var allowedScopeMethods = map[string]bool{
+	http.MethodGet: true, http.MethodPost: true, http.MethodPut: true, http.MethodPatch: true, http.MethodDelete: true,
+}
+
+// effectiveApis 令牌实际可访问的已登记接口：勾选的接口加上分组、路径规则命中的接口
+func effectiveApis(token *model.SysApiToken, allApis []model.SysApi) []model.SysApi {
+	granted := make(map[uint]bool, len(token.Apis))
+	for _, api := range token.Apis {
+		granted[api.ID] = true
+	}
+	result := make([]model.SysApi, 0, len(token.Apis))
+	for _, api := range allApis {
+		if granted[api.ID] {
+			result = append(result, api)
+			continue
+		}
+		for _, scope := range token.Scopes {
+			if scope.Matches(api.Method, api.Path, api.ApiGroup) {
+				result = append(result, api)
+				break
+			}
+		}
 	}
-	return apis, nil
+	return result
 }
 
 func parseExpiresAt(raw *string, neverExpire bool) (*time.Time, error) {
@@ -183,6 +498,28 @@ func parseExpiresAt(raw *string, neverExpire bool) (*time.Time, error) {
 	return &parsed, nil
 }
 
+// normalizeAllowedIPs 校验并规范化来源地址，按逗号拼接后存储
+func normalizeAllowedIPs(entries []string) (string, error) {
+	prefixes, err := tokenCore.ParseAllowedIPs(entries)
+	if err != nil {
+		return "", fmt.Errorf("IP 地址格式错误: %w", err)
+	}
+	values := make([]string, 0, len(prefixes))
+	seen := make(map[string]bool, len(prefixes))
+	for _, prefix := range prefixes {
+		value := prefix.String()
+		if !seen[value] {
+			seen[value] = true
+			values = append(values, value)
+		}
+	}
+	value := strings.Join(values, ",")
+	if len(value) > 1024 {
+		return "", errors.New("允许的 IP 范围过多")
+	}
+	return value, nil
+}
+
 func normalizeMaxConcurrency(value int) int {
 	if value <= 0 {
 		return 5
@@ -199,16 +536,31 @@ func buildTokenPrefix(raw string) string {
 
 func buildApiTokenResponse(token *model.SysApiToken) dto.ApiTokenResponse {
 	resp := dto.ApiTokenResponse{
-		ID:             token.ID,
-		TokenPrefix:    token.TokenPrefix,
-		Name:           token.Name,
-		Description:    token.Description,
-		NeverExpire:    token.ExpiresAt == nil,
-		MaxConcurrency: token.MaxConcurrency,
-		Enabled:        token.Enabled,
-		CreatedAt:      token.CreatedAt.Format(time.RFC3339),
-		CreatedBy:      token.CreatedBy,
-		Apis:           buildApiItems(token.Apis),
+		ID:               token.ID,
+		TokenPrefix:      token.TokenPrefix,
+		Name:             token.Name,
+		Description:      token.Description,
+		NeverExpire:      token.ExpiresAt == nil,
+		MaxConcurrency:   token.MaxConcurrency,
+		QuotaPerMinute:   token.QuotaPerMinute,
+		QuotaPerDay:      token.QuotaPerDay,
+		QuotaPerMonth:    token.QuotaPerMonth,
+		AllowedIPs:       token.AllowedIPList(),
+		RequireSignature: token.RequireSignature,
+		Enabled:          token.Enabled,
+		CreatedAt:        token.CreatedAt.Format(time.RFC3339),
+		CreatedBy:        token.CreatedBy,
+		Apis:             buildApiItems(token.Apis),
+		ApiGroups:        make([]string, 0),
+		PathScopes:       make([]dto.ApiTokenPathScope, 0),
+	}
+	for _, scope := range token.Scopes {
+		switch scope.Kind {
+		case model.ApiTokenScopeGroup:
+			resp.ApiGroups = append(resp.ApiGroups, scope.Value)
+		case model.ApiTokenScopePath:
+			resp.PathScopes = append(resp.PathScopes, dto.ApiTokenPathScope{Method: scope.Method, Path: scope.Value})
+		}
 	}
 	if token.ExpiresAt != nil {
 		value := token.ExpiresAt.Format(time.RFC3339)
@@ -218,17 +570,43 @@ func buildApiTokenResponse(token *model.SysApiToken) dto.ApiTokenResponse {
 		value := token.LastUsedAt.Format(time.RFC3339)
 		resp.LastUsedAt = &value
 	}
+	if token.PreviousSecretValid(time.Now()) {
+		resp.PreviousTokenPrefix = token.PreviousTokenPrefix
+		value := token.PreviousTokenExpiresAt.Format(time.RFC3339)
+		resp.PreviousTokenExpiresAt = &value
+		if token.PreviousTokenLastUsedAt != nil {
+			lastUsed := token.PreviousTokenLastUsedAt.Format(time.RFC3339)
+			resp.PreviousTokenLastUsedAt = &lastUsed
+		}
+	}
 	return resp
 }
 
-func buildSecretResponse(token *model.SysApiToken, rawToken string) *dto.ApiTokenSecretResponse {
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
+
+// buildSecretResponse 明文与签名密钥只在创建、重置、轮换时返回一次
+func (s *ApiTokenService) buildSecretResponse(token *model.SysApiToken, rawToken string) *dto.ApiTokenSecretResponse {
 	resp := buildApiTokenResponse(token)
 	return &dto.ApiTokenSecretResponse{
 		ApiTokenResponse: resp,
 		Token:            rawToken,
+		SigningSecret:    tokenCore.SigningSecret(s.signSecret(), tokenCore.HashToken(rawToken)),
 	}
 }
 
+// signSecret 派生签名密钥的服务端密钥，为空表示未开启签名请求
+func (s *ApiTokenService) signSecret() string {
+	if s.svcCtx.Config == nil {
+		return ""
+	}
+	return s.svcCtx.Config.ApiTokenSign.Secret
+}
+
 func buildApiItems(apis []model.SysApi) []dto.ApiSimpleItem {
 	items := make([]dto.ApiSimpleItem, 0, len(apis))
 	for _, api := range apis {
@@ -243,4 +621,34 @@ func buildApiItems(apis []model.SysApi) []dto.ApiSimpleItem {
 	return items
 }
 
+func buildUsageStats(u *model.SysApiTokenUsage) dto.ApiTokenUsageStats {
+	return dto.ApiTokenUsageStats{
+		Calls:    u.Calls,
+		Errors:   u.Errors,
+		BytesIn:  u.BytesIn,
+		BytesOut: u.BytesOut,
+		P50Ms:    u.P50Ms,
+		P95Ms:    u.P95Ms,
+	}
+}
+
+// 统计周期按服务器本地时区划分，与 core/apiusage 保持一致
+func truncateUsageHour(t time.Time) time.Time {
+	t = t.Local()
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, time.Local)
+}
+
+func truncateUsageDay(t time.Time) time.Time {
+	t = t.Local()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local)
+}
+
+// nextUsagePeriod 天粒度按日历天步进，避免夏令时切换导致错位
+func nextUsagePeriod(t time.Time, granularity string) time.Time {
+	if granularity == model.UsageGranularityDay {
+		return t.AddDate(0, 0, 1)
+	}
+	return t.Add(time.Hour)
+}
+
 var _ = gorm.ErrRecordNotFound
@@ -2,7 +2,9 @@ package service
 
 import (
 	"context"
+	"errors"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -152,6 +154,8 @@ func newApiTokenTestDB(t *testing.T) *gorm.DB {
 		&model.SysApi{},
 		&model.SysApiToken{},
 		&model.SysApiTokenApi{},
+		&model.SysApiTokenScope{},
+		&model.SysApiTokenUsage{},
 	); err != nil {
 		t.Fatalf("AutoMigrate() error = %v", err)
 	}
@@ -167,6 +171,105 @@ func newApiTokenTestDB(t *testing.T) *gorm.DB {
 	return gormDB
 }
 
+func TestApiTokenServiceRotateKeepsPreviousHashDuringGrace(t *testing.T) {
+	gormDB := newApiTokenTestDB(t)
+	if err := gormDB.Create(&model.SysApi{
+		Path:        "/api/v1/poetry/poem/list",
+		Method:      "GET",
+		ApiGroup:    "poetry",
+		Description: "List poems",
+	}).Error; err != nil {
+		t.Fatalf("seed sys api error = %v", err)
+	}
+
+	service := NewApiTokenService(
+		&svc.ServiceContext{DB: gormDB, Logger: zap.NewNop()},
+		repository.NewApiTokenRepository(gormDB),
+	)
+	created, err := service.CreateApiToken(context.Background(), 7, dto.CreateApiTokenReq{
+		Name:        "rotate-me",
+		ApiGroups:   []string{"poetry"},
+		NeverExpire: true,
+	})
+	if err != nil {
+		t.Fatalf("CreateApiToken() error = %v", err)
+	}
+
+	for _, grace := range []string{"721h", "31d", "2", "soon"} {
+		if _, err := service.RotateApiToken(context.Background(), dto.RotateApiTokenReq{ID: created.ID, Grace: grace}); !errors.Is(err, errRotationGraceInvalid) {
+			t.Fatalf("RotateApiToken(grace %q) error = %v, want %v", grace, err, errRotationGraceInvalid)
+		}
+	}
+
+	rotated, err := service.RotateApiToken(context.Background(), dto.RotateApiTokenReq{ID: created.ID, Grace: "2h"})
+	if err != nil {
+		t.Fatalf("RotateApiToken() error = %v", err)
+	}
+	if rotated.Token == created.Token || rotated.PreviousTokenPrefix != created.TokenPrefix || rotated.PreviousTokenExpiresAt == nil {
+		t.Fatalf("RotateApiToken() = %+v, want new token with previous prefix %s", rotated.ApiTokenResponse, created.TokenPrefix)
+	}
+
+	var stored model.SysApiToken
+	if err := gormDB.First(&stored, created.ID).Error; err != nil {
+		t.Fatalf("load rotated token error = %v", err)
+	}
+	if !tokenCore.VerifyToken(rotated.Token, stored.TokenHash) || !tokenCore.VerifyToken(created.Token, stored.PreviousTokenHash) {
+		t.Fatal("RotateApiToken() should store both the new and the previous hash")
+	}
+	if !stored.PreviousSecretValid(time.Now()) || stored.PreviousSecretValid(time.Now().Add(3*time.Hour)) {
+		t.Fatalf("previous secret grace deadline = %v, want about 2 hours", stored.PreviousTokenExpiresAt)
+	}
+
+	// 重置立即作废所有旧密钥
+	if _, err := service.ResetApiToken(context.Background(), created.ID); err != nil {
+		t.Fatalf("ResetApiToken() error = %v", err)
+	}
+	if err := gormDB.First(&stored, created.ID).Error; err != nil {
+		t.Fatalf("load reset token error = %v", err)
+	}
+	if stored.PreviousTokenHash != "" || stored.PreviousSecretValid(time.Now()) {
+		t.Fatalf("ResetApiToken() kept previous hash %q", stored.PreviousTokenHash)
+	}
+}
+
+func TestApiTokenServiceSigningSecretRequiresServerSecret(t *testing.T) {
+	gormDB := newApiTokenTestDB(t)
+	svcCtx := &svc.ServiceContext{DB: gormDB, Logger: zap.NewNop(), Config: &config.Config{}}
+	service := NewApiTokenService(svcCtx, repository.NewApiTokenRepository(gormDB))
+	if err := gormDB.Create(&model.SysApi{Path: "/api/v1/poetry/dynasty/list", Method: "GET", ApiGroup: "poetry"}).Error; err != nil {
+		t.Fatalf("seed sys api error = %v", err)
+	}
+	req := dto.CreateApiTokenReq{Name: "signed", ApiIds: []uint{1}, RequireSignature: true}
+
+	// 未配置服务端密钥时不能要求签名请求，也不下发签名密钥
+	if _, err := service.CreateApiToken(context.Background(), 1, req); err == nil {
+		t.Fatal("CreateApiToken() error = nil, want sign disabled error")
+	}
+	req.RequireSignature = false
+	resp, err := service.CreateApiToken(context.Background(), 1, req)
+	if err != nil || resp.SigningSecret != "" {
+		t.Fatalf("CreateApiToken() = %+v, %v, want no signing secret", resp, err)
+	}
+
+	svcCtx.Config.ApiTokenSign.Secret = "sign-secret"
+	req.Name, req.RequireSignature = "signed-2", true
+	resp, err = service.CreateApiToken(context.Background(), 1, req)
+	if err != nil {
+		t.Fatalf("CreateApiToken() error = %v", err)
+	}
+	var stored model.SysApiToken
+	if err := gormDB.First(&stored, resp.ID).Error; err != nil {
+		t.Fatalf("load stored api token error = %v", err)
+	}
+	// 签名密钥由服务端密钥派生，与库中的令牌哈希不同
+	if resp.SigningSecret == "" || resp.SigningSecret == stored.TokenHash {
+		t.Fatalf("signing secret = %q, want derived secret different from token hash", resp.SigningSecret)
+	}
+	if resp.SigningSecret != tokenCore.SigningSecret("sign-secret", stored.TokenHash) {
+		t.Fatal("signing secret does not match the derived secret")
+	}
+}
+
 func TestApiTokenServiceCreateRejectsExpiredTimeInPast(t *testing.T) {
 	gormDB := newApiTokenTestDB(t)
 	service := NewApiTokenService(
@@ -185,3 +288,169 @@ func TestApiTokenServiceCreateRejectsExpiredTimeInPast(t *testing.T) {
 		t.Fatal("CreateApiToken() error = nil, want validation error")
 	}
 }
+
+func TestApiTokenServiceUsageFillsSeriesAndMergesDaily(t *testing.T) {
+	gormDB := newApiTokenTestDB(t)
+	token := model.SysApiToken{TokenHash: "usage-hash", Name: "usage", Enabled: true}
+	if err := gormDB.Create(&token).Error; err != nil {
+		t.Fatalf("seed api token error = %v", err)
+	}
+
+	day := time.Date(2024, 5, 10, 0, 0, 0, 0, time.Local)
+	if err := gormDB.Create(&[]model.SysApiTokenUsage{
+		// 已合并的天数据与尚未合并的小时数据落在不同的天
+		{ApiTokenID: token.ID, Granularity: model.UsageGranularityDay, PeriodStart: day, Method: "GET", Path: "/a", Calls: 4, Errors: 1},
+		{ApiTokenID: token.ID, Granularity: model.UsageGranularityHour, PeriodStart: day.AddDate(0, 0, 2).Add(3 * time.Hour), Method: "GET", Path: "/a", Calls: 2},
+		{ApiTokenID: token.ID, Granularity: model.UsageGranularityHour, PeriodStart: day.AddDate(0, 0, 2).Add(5 * time.Hour), Method: "POST", Path: "/b", Calls: 1, BytesIn: 64},
+	}).Error; err != nil {
+		t.Fatalf("seed usage error = %v", err)
+	}
+
+	service := NewApiTokenService(
+		&svc.ServiceContext{DB: gormDB, Logger: zap.NewNop()},
+		repository.NewApiTokenRepository(gormDB),
+	)
+	resp, err := service.GetApiTokenUsage(context.Background(), dto.ApiTokenUsageReq{
+		ID:          token.ID,
+		Granularity: model.UsageGranularityDay,
+		Start:       day.Format(time.RFC3339),
+		End:         day.AddDate(0, 0, 3).Format(time.RFC3339),
+	})
+	if err != nil {
+		t.Fatalf("GetApiTokenUsage() error = %v", err)
+	}
+	if len(resp.Series) != 3 {
+		t.Fatalf("usage series = %#v, want 3 days", resp.Series)
+	}
+	if resp.Series[0].Calls != 4 || resp.Series[1].Calls != 0 || resp.Series[2].Calls != 3 {
+		t.Fatalf("usage series calls = %d/%d/%d, want 4/0/3", resp.Series[0].Calls, resp.Series[1].Calls, resp.Series[2].Calls)
+	}
+	if resp.Total.Calls != 7 || resp.Total.Errors != 1 {
+		t.Fatalf("usage total = %+v, want 7 calls and 1 error", resp.Total)
+	}
+	if len(resp.Apis) != 2 || resp.Apis[0].Path != "/a" || resp.Apis[0].Calls != 6 || resp.Apis[1].BytesIn != 64 {
+		t.Fatalf("usage apis = %#v, want /a first with 6 calls", resp.Apis)
+	}
+
+	// 小时粒度只统计尚未合并的数据
+	resp, err = service.GetApiTokenUsage(context.Background(), dto.ApiTokenUsageReq{
+		ID:    token.ID,
+		Start: day.Format(time.RFC3339),
+		End:   day.AddDate(0, 0, 3).Format(time.RFC3339),
+	})
+	if err != nil {
+		t.Fatalf("GetApiTokenUsage(hour) error = %v", err)
+	}
+	if len(resp.Series) != 72 || resp.Total.Calls != 3 || resp.Series[51].Calls != 2 {
+		t.Fatalf("hourly usage = %d points, %d calls, want 72 points and 3 calls", len(resp.Series), resp.Total.Calls)
+	}
+
+	if _, err := service.GetApiTokenUsage(context.Background(), dto.ApiTokenUsageReq{
+		ID:    token.ID,
+		Start: day.Format(time.RFC3339),
+		End:   day.AddDate(0, 1, 0).Format(time.RFC3339),
+	}); err == nil {
+		t.Fatal("GetApiTokenUsage() over hourly range error = nil, want error")
+	}
+}
+
+func TestApiTokenServiceResolvesScopedApis(t *testing.T) {
+	gormDB := newApiTokenTestDB(t)
+	apis := []model.SysApi{
+		{Path: "/api/v1/poetry/poem/list", Method: "GET", ApiGroup: "poetry"},
+		{Path: "/api/v1/poetry/poem/:id", Method: "DELETE", ApiGroup: "poetry-admin"},
+		{Path: "/api/v1/poetry/author/:id", Method: "GET", ApiGroup: "poetry-admin"},
+		{Path: "/api/v1/sys/user/list", Method: "GET", ApiGroup: "system"},
+	}
+	if err := gormDB.Create(&apis).Error; err != nil {
+		t.Fatalf("seed sys api error = %v", err)
+	}
+
+	service := NewApiTokenService(
+		&svc.ServiceContext{DB: gormDB, Logger: zap.NewNop()},
+		repository.NewApiTokenRepository(gormDB),
+	)
+	if _, err := service.CreateApiToken(context.Background(), 1, dto.CreateApiTokenReq{
+		Name:      "unknown-group",
+		ApiGroups: []string{"missing"},
+	}); err == nil {
+		t.Fatal("CreateApiToken() with unknown group error = nil, want error")
+	}
+
+	resp, err := service.CreateApiToken(context.Background(), 1, dto.CreateApiTokenReq{
+		Name:       "poetry-reader",
+		ApiGroups:  []string{"poetry"},
+		PathScopes: []dto.ApiTokenPathScope{{Method: "get", Path: "/api/v1/poetry/*"}},
+	})
+	if err != nil {
+		t.Fatalf("CreateApiToken() error = %v", err)
+	}
+	if len(resp.ApiGroups) != 1 || len(resp.PathScopes) != 1 || resp.PathScopes[0].Method != "GET" {
+		t.Fatalf("CreateApiToken() scopes = %v %v, want one group and one GET pattern", resp.ApiGroups, resp.PathScopes)
+	}
+
+	// 分组之后新增的接口自动生效
+	if err := gormDB.Create(&model.SysApi{Path: "/api/v1/poetry/dynasty/list", Method: "POST", ApiGroup: "poetry"}).Error; err != nil {
+		t.Fatalf("seed new sys api error = %v", err)
+	}
+	detail, err := service.GetApiTokenDetail(context.Background(), resp.ID)
+	if err != nil {
+		t.Fatalf("GetApiTokenDetail() error = %v", err)
+	}
+	var effective []string
+	for _, api := range detail.EffectiveApis {
+		effective = append(effective, api.Method+" "+api.Path)
+	}
+	// 按分组、路径排序
+	want := []string{"POST /api/v1/poetry/dynasty/list", "GET /api/v1/poetry/poem/list", "GET /api/v1/poetry/author/:id"}
+	if strings.Join(effective, ",") != strings.Join(want, ",") {
+		t.Fatalf("effective apis = %v, want %v", effective, want)
+	}
+
+	// 更新时整体替换授权范围
+	if err := service.UpdateApiToken(context.Background(), dto.UpdateApiTokenReq{
+		ID:     resp.ID,
+		Name:   "poetry-reader",
+		ApiIds: []uint{apis[3].ID},
+	}); err != nil {
+		t.Fatalf("UpdateApiToken() error = %v", err)
+	}
+	detail, err = service.GetApiTokenDetail(context.Background(), resp.ID)
+	if err != nil {
+		t.Fatalf("GetApiTokenDetail() error = %v", err)
+	}
+	if len(detail.ApiGroups) != 0 || len(detail.PathScopes) != 0 || len(detail.EffectiveApis) != 1 {
+		t.Fatalf("updated detail = %+v, want only the selected api", detail)
+	}
+}
+
+func TestApiTokenServiceNormalizesAllowedIPs(t *testing.T) {
+	gormDB := newApiTokenTestDB(t)
+	if err := gormDB.Create(&model.SysApi{Path: "/api/v1/poetry/poem/list", Method: "GET", ApiGroup: "poetry"}).Error; err != nil {
+		t.Fatalf("seed sys api error = %v", err)
+	}
+	service := NewApiTokenService(
+		&svc.ServiceContext{DB: gormDB, Logger: zap.NewNop()},
+		repository.NewApiTokenRepository(gormDB),
+	)
+
+	resp, err := service.CreateApiToken(context.Background(), 1, dto.CreateApiTokenReq{
+		Name:       "partner",
+		ApiGroups:  []string{"poetry"},
+		AllowedIPs: []string{"10.1.2.3/8", " 192.0.2.7 ", "10.0.0.0/8"},
+	})
+	if err != nil {
+		t.Fatalf("CreateApiToken() error = %v", err)
+	}
+	if strings.Join(resp.AllowedIPs, ",") != "10.0.0.0/8,192.0.2.7/32" {
+		t.Fatalf("CreateApiToken() allowed ips = %v, want normalized and deduplicated", resp.AllowedIPs)
+	}
+
+	if _, err := service.CreateApiToken(context.Background(), 1, dto.CreateApiTokenReq{
+		Name:       "partner",
+		ApiGroups:  []string{"poetry"},
+		AllowedIPs: []string{"10.0.0.0/40"},
+	}); err == nil {
+		t.Fatal("CreateApiToken() with invalid cidr error = nil, want error")
+	}
+}
@@ -0,0 +1,90 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/CIPFZ/gowebframe/internal/core/ldapauth"
+	"github.com/CIPFZ/gowebframe/internal/modules/system/model"
+	"github.com/CIPFZ/gowebframe/internal/modules/system/repository"
+	"github.com/CIPFZ/gowebframe/pkg/utils"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidCredentials   = errors.New("用户名或密码错误")
+	ErrAuthenticatorSkipped = errors.New("认证方式不适用于该用户")
+)
+
+// Authenticator 账号密码登录的认证方式，Login 按顺序尝试，第一个通过的生效
+type Authenticator interface {
+	Name() string
+	// Authenticate 校验用户名与密码并返回本地用户
+	// 不认识该用户时返回 ErrAuthenticatorSkipped；密码错误返回 ErrInvalidCredentials，
+	// 此时可一并返回找到的本地用户，用于记录登录失败事件
+	Authenticate(ctx context.Context, username, password string) (*model.SysUser, error)
+}
+
+// passwordAuthenticatorName 本地密码认证，只有该方式受密码有效期约束
+const passwordAuthenticatorName = "password"
+
+// passwordAuthenticator 本地账号 bcrypt 密码校验
+type passwordAuthenticator struct {
+	userRepo repository.IUserRepository
+}
+
+func NewPasswordAuthenticator(userRepo repository.IUserRepository) Authenticator {
+	return &passwordAuthenticator{userRepo: userRepo}
+}
+
+func (a *passwordAuthenticator) Name() string {
+	return passwordAuthenticatorName
+}
+
+func (a *passwordAuthenticator) Authenticate(ctx context.Context, username, password string) (*model.SysUser, error) {
+	// 预加载角色，用于判断是否强制二次验证
+	user, err := a.userRepo.FindByUsername(ctx, username)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrAuthenticatorSkipped
+		}
+		return nil, err
+	}
+	if !utils.BcryptCheck(password, user.Password) {
+		return user, ErrInvalidCredentials
+	}
+	return user, nil
+}
+
+// ldapAuthenticator 目录 (LDAP / AD) 绑定校验，通过后按配置开通或同步本地用户
+type ldapAuthenticator struct {
+	s      *UserService
+	client *ldapauth.Client
+}
+
+func (a *ldapAuthenticator) Name() string {
+	return ldapauth.Provider
+}
+
+func (a *ldapAuthenticator) Authenticate(ctx context.Context, username, password string) (*model.SysUser, error) {
+	entry, err := a.client.Authenticate(ctx, username, password)
+	if err != nil {
+		if errors.Is(err, ldapauth.ErrInvalidCredentials) {
+			return nil, ErrInvalidCredentials
+		}
+		return nil, err
+	}
+	return a.s.externalUser(ctx, ldapAccount(entry), a.client.Config().Provisioning)
+}
+
+func ldapAccount(entry *ldapauth.Entry) externalAccount {
+	return externalAccount{
+		Provider: ldapauth.Provider,
+		Subject:  entry.Username,
+		Username: entry.Username,
+		Email:    entry.Email,
+		Name:     entry.DisplayName,
+		Groups:   entry.Groups,
+	}
+}
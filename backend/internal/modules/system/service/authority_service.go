@@ -3,6 +3,8 @@ package service
 import (
 	"context"
 	"errors"
+	"slices"
+	"strings"
 
 	"github.com/CIPFZ/gowebframe/internal/modules/common"
 	"github.com/CIPFZ/gowebframe/internal/modules/system/dto"
@@ -71,6 +73,9 @@ func (s *AuthorityService) CreateAuthority(ctx context.Context, req dto.CreateAu
 		AuthorityName: req.AuthorityName,
 		ParentId:      req.ParentId,
 		DefaultRouter: req.DefaultRouter,
+		MfaRequired:   req.MfaRequired,
+
+		AllowedCountries: normalizeCountries(req.AllowedCountries),
 	}
 	return s.authRepo.Create(ctx, &auth)
 }
@@ -90,6 +95,9 @@ func (s *AuthorityService) UpdateAuthority(ctx context.Context, req dto.UpdateAu
 	updates := map[string]interface{}{
 		"authority_name": req.AuthorityName,
 		"default_router": req.DefaultRouter,
+		"mfa_required":   req.MfaRequired,
+
+		"allowed_countries": normalizeCountries(req.AllowedCountries),
 	}
 	// 构造一个只包含 ID 的对象用于 GORM 的 Where 条件
 	target := model.SysAuthority{AuthorityId: req.AuthorityId}
@@ -157,3 +165,15 @@ func (s *AuthorityService) buildAuthorityTree(list []model.SysAuthority) []model
 	}
 	return roots
 }
+
+// normalizeCountries 统一为大写 ISO 代码并去重
+func normalizeCountries(countries []string) model.CountryList {
+	var list model.CountryList
+	for _, c := range countries {
+		c = strings.ToUpper(strings.TrimSpace(c))
+		if c != "" && !slices.Contains(list, c) {
+			list = append(list, c)
+		}
+	}
+	return list
+}
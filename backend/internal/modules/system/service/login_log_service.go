@@ -0,0 +1,41 @@
+package service
+
+import (
+	"context"
+
+	"github.com/CIPFZ/gowebframe/internal/modules/system/dto"
+	"github.com/CIPFZ/gowebframe/internal/modules/system/model"
+	"github.com/CIPFZ/gowebframe/internal/modules/system/repository"
+	"github.com/CIPFZ/gowebframe/internal/svc"
+)
+
+// ILoginLogService 登录日志查询 (写入由 UserService 在登录流程中完成)
+type ILoginLogService interface {
+	// GetLoginLogList 管理员分页查询全部登录日志
+	GetLoginLogList(ctx context.Context, req dto.SearchLoginLogReq) ([]model.SysLoginLog, int64, error)
+	// GetSelfLoginLogList 分页查询本人的登录日志
+	GetSelfLoginLogList(ctx context.Context, userID uint, req dto.SearchLoginLogReq) ([]model.SysLoginLog, int64, error)
+}
+
+type LoginLogService struct {
+	svcCtx       *svc.ServiceContext
+	loginLogRepo repository.ILoginLogRepository
+}
+
+func NewLoginLogService(svcCtx *svc.ServiceContext, loginLogRepo repository.ILoginLogRepository) ILoginLogService {
+	return &LoginLogService{
+		svcCtx:       svcCtx,
+		loginLogRepo: loginLogRepo,
+	}
+}
+
+func (s *LoginLogService) GetLoginLogList(ctx context.Context, req dto.SearchLoginLogReq) ([]model.SysLoginLog, int64, error) {
+	return s.loginLogRepo.GetList(ctx, req)
+}
+
+func (s *LoginLogService) GetSelfLoginLogList(ctx context.Context, userID uint, req dto.SearchLoginLogReq) ([]model.SysLoginLog, int64, error) {
+	// 只能查询自己的记录，忽略请求中的用户条件
+	req.UserID = userID
+	req.Username = ""
+	return s.loginLogRepo.GetList(ctx, req)
+}
@@ -0,0 +1,389 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	logger "github.com/CIPFZ/gowebframe/internal/core/log"
+	tokenCore "github.com/CIPFZ/gowebframe/internal/core/token"
+	"github.com/CIPFZ/gowebframe/internal/modules/system/dto"
+	"github.com/CIPFZ/gowebframe/internal/modules/system/model"
+	"github.com/CIPFZ/gowebframe/internal/modules/system/repository"
+	"github.com/CIPFZ/gowebframe/internal/svc"
+	"github.com/CIPFZ/gowebframe/pkg/utils"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// OAuth2 错误，对应规范中的 error 字段
+var (
+	ErrOauthInvalidRequest       = errors.New("invalid_request")
+	ErrOauthInvalidClient        = errors.New("invalid_client")
+	ErrOauthUnsupportedGrantType = errors.New("unsupported_grant_type")
+	ErrOauthInvalidScope         = errors.New("invalid_scope")
+	ErrOauthUnauthorizedClient   = errors.New("unauthorized_client")
+)
+
+const (
+	oauthGrantClientCredentials = "client_credentials"
+	oauthClientIDPrefix         = "gwc_"
+	oauthClientSecretPrefix     = "gws_"
+	defaultOauthAccessTokenTTL  = time.Hour
+)
+
+type IOauthClientService interface {
+	CreateOauthClient(ctx context.Context, createdBy uint, req dto.CreateOauthClientReq) (*dto.OauthClientSecretResponse, error)
+	GetOauthClientList(ctx context.Context, req dto.SearchOauthClientReq) ([]dto.OauthClientResponse, int64, error)
+	UpdateOauthClient(ctx context.Context, req dto.UpdateOauthClientReq) error
+	DeleteOauthClient(ctx context.Context, req dto.DeleteOauthClientReq) error
+	ResetOauthClientSecret(ctx context.Context, id uint) (*dto.OauthClientSecretResponse, error)
+	EnableOauthClient(ctx context.Context, id uint) error
+	DisableOauthClient(ctx context.Context, id uint) error
+
+	IssueToken(ctx context.Context, req dto.OAuthTokenReq) (*dto.OAuthTokenResponse, error)
+	IntrospectToken(ctx context.Context, req dto.OAuthTokenActionReq) (*dto.OAuthIntrospectResponse, error)
+	RevokeToken(ctx context.Context, req dto.OAuthTokenActionReq) error
+}
+
+type OauthClientService struct {
+	svcCtx     *svc.ServiceContext
+	clientRepo repository.IOauthClientRepository
+}
+
+func NewOauthClientService(svcCtx *svc.ServiceContext, clientRepo repository.IOauthClientRepository) IOauthClientService {
+	return &OauthClientService{
+		svcCtx:     svcCtx,
+		clientRepo: clientRepo,
+	}
+}
+
+func (s *OauthClientService) CreateOauthClient(ctx context.Context, createdBy uint, req dto.CreateOauthClientReq) (*dto.OauthClientSecretResponse, error) {
+	scopes, err := s.normalizeScopes(ctx, req.Scopes)
+	if err != nil {
+		return nil, err
+	}
+
+	secret := newOauthClientSecret()
+	client := &model.SysOauthClient{
+		ClientID:       newOauthClientID(),
+		SecretHash:     tokenCore.HashToken(secret),
+		SecretPrefix:   buildTokenPrefix(secret),
+		Name:           strings.TrimSpace(req.Name),
+		Description:    strings.TrimSpace(req.Description),
+		Scopes:         scopes,
+		AccessTokenTTL: req.AccessTokenTTL,
+		Enabled:        true,
+		CreatedBy:      createdBy,
+	}
+	if err := s.clientRepo.Create(ctx, client); err != nil {
+		return nil, err
+	}
+	return &dto.OauthClientSecretResponse{OauthClientResponse: buildOauthClientResponse(client), ClientSecret: secret}, nil
+}
+
+func (s *OauthClientService) GetOauthClientList(ctx context.Context, req dto.SearchOauthClientReq) ([]dto.OauthClientResponse, int64, error) {
+	list, total, err := s.clientRepo.GetList(ctx, req)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	resp := make([]dto.OauthClientResponse, 0, len(list))
+	for i := range list {
+		resp = append(resp, buildOauthClientResponse(&list[i]))
+	}
+	return resp, total, nil
+}
+
+func (s *OauthClientService) UpdateOauthClient(ctx context.Context, req dto.UpdateOauthClientReq) error {
+	client, err := s.clientRepo.FindByID(ctx, req.ID)
+	if err != nil {
+		return err
+	}
+	scopes, err := s.normalizeScopes(ctx, req.Scopes)
+	if err != nil {
+		return err
+	}
+
+	// 缩小授权范围后已签发的访问令牌随之收窄 (见 oauthclient.Entry.Grant)
+	if err := s.clientRepo.UpdateColumns(ctx, client.ID, map[string]interface{}{
+		"name":             strings.TrimSpace(req.Name),
+		"description":      strings.TrimSpace(req.Description),
+		"scopes":           scopes,
+		"access_token_ttl": req.AccessTokenTTL,
+	}); err != nil {
+		return err
+	}
+	s.invalidateClientCache(ctx, client.ClientID)
+	return nil
+}
+
+func (s *OauthClientService) DeleteOauthClient(ctx context.Context, req dto.DeleteOauthClientReq) error {
+	ids := make([]uint, 0, len(req.IDs)+1)
+	if req.ID != 0 {
+		ids = append(ids, req.ID)
+	}
+	ids = append(ids, req.IDs...)
+	if len(ids) == 0 {
+		return errors.New("未选择要删除的客户端")
+	}
+	clients, err := s.clientRepo.FindByIDs(ctx, ids)
+	if err != nil {
+		return err
+	}
+	if err := s.clientRepo.DeleteByIDs(ctx, ids); err != nil {
+		return err
+	}
+	clientIDs := make([]string, 0, len(clients))
+	for _, client := range clients {
+		clientIDs = append(clientIDs, client.ClientID)
+	}
+	s.invalidateClientCache(ctx, clientIDs...)
+	return nil
+}
+
+// ResetOauthClientSecret 重新生成客户端密钥，旧密钥立即无法换取新令牌；已签发的访问令牌在有效期内仍可使用，需立即失效时禁用客户端
+func (s *OauthClientService) ResetOauthClientSecret(ctx context.Context, id uint) (*dto.OauthClientSecretResponse, error) {
+	client, err := s.clientRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	secret := newOauthClientSecret()
+	client.SecretHash = tokenCore.HashToken(secret)
+	client.SecretPrefix = buildTokenPrefix(secret)
+	if err := s.clientRepo.UpdateColumns(ctx, client.ID, map[string]interface{}{
+		"secret_hash":   client.SecretHash,
+		"secret_prefix": client.SecretPrefix,
+	}); err != nil {
+		return nil, err
+	}
+	return &dto.OauthClientSecretResponse{OauthClientResponse: buildOauthClientResponse(client), ClientSecret: secret}, nil
+}
+
+func (s *OauthClientService) EnableOauthClient(ctx context.Context, id uint) error {
+	return s.toggleOauthClient(ctx, id, true)
+}
+
+// DisableOauthClient 禁用后不能再换取令牌，已签发的访问令牌同时失效
+func (s *OauthClientService) DisableOauthClient(ctx context.Context, id uint) error {
+	return s.toggleOauthClient(ctx, id, false)
+}
+
+func (s *OauthClientService) toggleOauthClient(ctx context.Context, id uint, enabled bool) error {
+	client, err := s.clientRepo.FindByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.clientRepo.UpdateColumns(ctx, id, map[string]interface{}{"enabled": enabled}); err != nil {
+		return err
+	}
+	s.invalidateClientCache(ctx, client.ClientID)
+	return nil
+}
+
+// IssueToken 客户端凭证模式：校验客户端密钥与申请的分组后签发访问令牌
+// scope 为空时授予客户端允许的全部分组
+func (s *OauthClientService) IssueToken(ctx context.Context, req dto.OAuthTokenReq) (*dto.OAuthTokenResponse, error) {
+	if req.GrantType != oauthGrantClientCredentials {
+		return nil, ErrOauthUnsupportedGrantType
+	}
+	client, err := s.authenticateClient(ctx, req.ClientID, req.ClientSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	allowed := client.ScopeList()
+	granted := allowed
+	if requested := strings.Fields(req.Scope); len(requested) > 0 {
+		set := make(map[string]bool, len(allowed))
+		for _, group := range allowed {
+			set[group] = true
+		}
+		for _, group := range requested {
+			if !set[group] {
+				return nil, ErrOauthInvalidScope
+			}
+		}
+		granted = requested
+	}
+	scope := strings.Join(granted, " ")
+
+	ttl := s.accessTokenTTL(client)
+	accessToken, clientClaims, err := s.svcCtx.JWT.CreateClientToken(client.ClientID, scope, ttl)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.clientRepo.TouchLastUsedAt(ctx, client.ID, time.Now()); err != nil {
+		logger.GetLogger(ctx).Warn("oauth_client_touch_failed", zap.String("clientID", client.ClientID), zap.Error(err))
+	}
+	return &dto.OAuthTokenResponse{
+		AccessToken: accessToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   int(clientClaims.ExpiresAt.Sub(clientClaims.IssuedAt.Time).Seconds()),
+		Scope:       scope,
+	}, nil
+}
+
+// IntrospectToken 令牌内省 (RFC 7662)：客户端只能查询自己的令牌，其余情况一律返回 active=false
+func (s *OauthClientService) IntrospectToken(ctx context.Context, req dto.OAuthTokenActionReq) (*dto.OAuthIntrospectResponse, error) {
+	client, err := s.authenticateClient(ctx, req.ClientID, req.ClientSecret)
+	if err != nil {
+		return nil, err
+	}
+	if req.Token == "" {
+		return nil, ErrOauthInvalidRequest
+	}
+
+	inactive := &dto.OAuthIntrospectResponse{Active: false}
+	claims, err := s.svcCtx.JWT.ParseClientToken(req.Token)
+	if err != nil || claims.Subject != client.ClientID {
+		return inactive, nil
+	}
+	if s.svcCtx.JWT.IsBlacklist(ctx, req.Token) {
+		return inactive, nil
+	}
+	return &dto.OAuthIntrospectResponse{
+		Active:    true,
+		ClientID:  claims.Subject,
+		Scope:     claims.Scope,
+		TokenType: "Bearer",
+		Sub:       claims.Subject,
+		Iss:       claims.Issuer,
+		Jti:       claims.ID,
+		Exp:       claims.ExpiresAt.Unix(),
+		Iat:       claims.IssuedAt.Unix(),
+	}, nil
+}
+
+// RevokeToken 令牌吊销 (RFC 7009)：无效或已过期的令牌视为吊销成功；吊销其他客户端的令牌返回 unauthorized_client
+func (s *OauthClientService) RevokeToken(ctx context.Context, req dto.OAuthTokenActionReq) error {
+	client, err := s.authenticateClient(ctx, req.ClientID, req.ClientSecret)
+	if err != nil {
+		return err
+	}
+	if req.Token == "" {
+		return ErrOauthInvalidRequest
+	}
+
+	claims, err := s.svcCtx.JWT.ParseClientToken(req.Token)
+	if err != nil {
+		return nil
+	}
+	if claims.Subject != client.ClientID {
+		return ErrOauthUnauthorizedClient
+	}
+	// 黑名单记录保留到令牌过期为止
+	return s.svcCtx.JWT.SetBlacklist(ctx, req.Token, time.Until(claims.ExpiresAt.Time))
+}
+
+// authenticateClient 校验客户端凭证，客户端不存在、已禁用或密钥错误时统一返回 invalid_client
+func (s *OauthClientService) authenticateClient(ctx context.Context, clientID, secret string) (*model.SysOauthClient, error) {
+	if clientID == "" || secret == "" {
+		return nil, ErrOauthInvalidClient
+	}
+	client, err := s.clientRepo.FindByClientID(ctx, clientID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrOauthInvalidClient
+		}
+		return nil, err
+	}
+	if subtle.ConstantTimeCompare([]byte(tokenCore.HashToken(secret)), []byte(client.SecretHash)) != 1 || !client.Enabled {
+		return nil, ErrOauthInvalidClient
+	}
+	return client, nil
+}
+
+func (s *OauthClientService) accessTokenTTL(client *model.SysOauthClient) time.Duration {
+	if client.AccessTokenTTL > 0 {
+		return time.Duration(client.AccessTokenTTL) * time.Second
+	}
+	if s.svcCtx.Config != nil {
+		return utils.ParseDurationOr(s.svcCtx.Config.OAuth.AccessTokenTTL, defaultOauthAccessTokenTTL)
+	}
+	return defaultOauthAccessTokenTTL
+}
+
+// normalizeScopes 校验分组均已登记，去重排序后以空格拼接
+func (s *OauthClientService) normalizeScopes(ctx context.Context, scopes []string) (string, error) {
+	known, err := s.clientRepo.ListApiGroups(ctx)
+	if err != nil {
+		return "", err
+	}
+	knownSet := make(map[string]bool, len(known))
+	for _, group := range known {
+		knownSet[group] = true
+	}
+
+	seen := make(map[string]bool, len(scopes))
+	result := make([]string, 0, len(scopes))
+	for _, group := range scopes {
+		group = strings.TrimSpace(group)
+		if group == "" || seen[group] {
+			continue
+		}
+		if !knownSet[group] || strings.ContainsAny(group, " \t") {
+			return "", fmt.Errorf("API 分组不存在: %s", group)
+		}
+		seen[group] = true
+		result = append(result, group)
+	}
+	if len(result) == 0 {
+		return "", errors.New("请至少选择一个 API 分组")
+	}
+	sort.Strings(result)
+	return strings.Join(result, " "), nil
+}
+
+// invalidateClientCache 客户端变更已写库，通知各实例移除鉴权缓存；通知失败时其他实例在缓存 TTL 内生效
+func (s *OauthClientService) invalidateClientCache(ctx context.Context, clientIDs ...string) {
+	if s.svcCtx.OAuthClients == nil || len(clientIDs) == 0 {
+		return
+	}
+	if err := s.svcCtx.OAuthClients.Invalidate(ctx, clientIDs...); err != nil {
+		logger.GetLogger(ctx).Warn("oauth_client_cache_invalidate_failed", zap.Strings("clientIDs", clientIDs), zap.Error(err))
+	}
+}
+
+func buildOauthClientResponse(client *model.SysOauthClient) dto.OauthClientResponse {
+	resp := dto.OauthClientResponse{
+		ID:             client.ID,
+		ClientID:       client.ClientID,
+		SecretPrefix:   client.SecretPrefix,
+		Name:           client.Name,
+		Description:    client.Description,
+		Scopes:         client.ScopeList(),
+		AccessTokenTTL: client.AccessTokenTTL,
+		Enabled:        client.Enabled,
+		CreatedAt:      client.CreatedAt.Format(time.RFC3339),
+		CreatedBy:      client.CreatedBy,
+	}
+	if resp.Scopes == nil {
+		resp.Scopes = make([]string, 0)
+	}
+	if client.LastUsedAt != nil {
+		value := client.LastUsedAt.Format(time.RFC3339)
+		resp.LastUsedAt = &value
+	}
+	return resp
+}
+
+func newOauthClientID() string {
+	buf := make([]byte, 12)
+	_, _ = rand.Read(buf)
+	return oauthClientIDPrefix + hex.EncodeToString(buf)
+}
+
+func newOauthClientSecret() string {
+	buf := make([]byte, 32)
+	_, _ = rand.Read(buf)
+	return oauthClientSecretPrefix + base64.RawURLEncoding.EncodeToString(buf)
+}
@@ -0,0 +1,203 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/CIPFZ/gowebframe/internal/core/config"
+	"github.com/CIPFZ/gowebframe/internal/core/db"
+	"github.com/CIPFZ/gowebframe/internal/core/jwt"
+	"github.com/CIPFZ/gowebframe/internal/modules/system/dto"
+	"github.com/CIPFZ/gowebframe/internal/modules/system/model"
+	"github.com/CIPFZ/gowebframe/internal/modules/system/repository"
+	"github.com/CIPFZ/gowebframe/internal/svc"
+	"go.uber.org/zap"
+)
+
+func TestOauthClientServiceIssuesScopedClientToken(t *testing.T) {
+	service, svcCtx := newOauthClientTestService(t)
+	ctx := context.Background()
+
+	created, err := service.CreateOauthClient(ctx, 1, dto.CreateOauthClientReq{
+		Name:   "sync",
+		Scopes: []string{"poetry", "system-api", "poetry"},
+	})
+	if err != nil {
+		t.Fatalf("CreateOauthClient() error = %v", err)
+	}
+	if len(created.Scopes) != 2 {
+		t.Fatalf("CreateOauthClient() scopes = %v, want deduplicated groups", created.Scopes)
+	}
+
+	resp, err := service.IssueToken(ctx, dto.OAuthTokenReq{
+		GrantType:    "client_credentials",
+		Scope:        "poetry",
+		ClientID:     created.ClientID,
+		ClientSecret: created.ClientSecret,
+	})
+	if err != nil {
+		t.Fatalf("IssueToken() error = %v", err)
+	}
+	if resp.TokenType != "Bearer" || resp.Scope != "poetry" || resp.ExpiresIn != 3600 {
+		t.Fatalf("IssueToken() = %+v, want Bearer token scoped to poetry for 3600s", resp)
+	}
+
+	clientClaims, err := svcCtx.JWT.ParseClientToken(resp.AccessToken)
+	if err != nil {
+		t.Fatalf("ParseClientToken() error = %v", err)
+	}
+	if clientClaims.Subject != created.ClientID || clientClaims.Scope != "poetry" {
+		t.Fatalf("client claims = %+v, want subject %s and scope poetry", clientClaims, created.ClientID)
+	}
+	if _, err := svcCtx.JWT.ParseToken(resp.AccessToken); err == nil {
+		t.Fatal("ParseToken() accepted a client token as a login token")
+	}
+
+	// 客户端未单独设置有效期时取 oauth.access_token_ttl
+	svcCtx.Config = &config.Config{OAuth: config.OAuth{AccessTokenTTL: "2h"}}
+	resp, err = service.IssueToken(ctx, dto.OAuthTokenReq{
+		GrantType:    "client_credentials",
+		ClientID:     created.ClientID,
+		ClientSecret: created.ClientSecret,
+	})
+	if err != nil || resp.ExpiresIn != 7200 {
+		t.Fatalf("IssueToken() = %+v, %v, want 7200s from oauth.access_token_ttl", resp, err)
+	}
+}
+
+func TestOauthClientServiceRejectsInvalidTokenRequests(t *testing.T) {
+	service, _ := newOauthClientTestService(t)
+	ctx := context.Background()
+
+	created, err := service.CreateOauthClient(ctx, 1, dto.CreateOauthClientReq{Name: "sync", Scopes: []string{"poetry"}})
+	if err != nil {
+		t.Fatalf("CreateOauthClient() error = %v", err)
+	}
+
+	cases := []struct {
+		name string
+		req  dto.OAuthTokenReq
+		want error
+	}{
+		{"grant type", dto.OAuthTokenReq{GrantType: "password", ClientID: created.ClientID, ClientSecret: created.ClientSecret}, ErrOauthUnsupportedGrantType},
+		{"secret", dto.OAuthTokenReq{GrantType: "client_credentials", ClientID: created.ClientID, ClientSecret: "gws_wrong"}, ErrOauthInvalidClient},
+		{"scope", dto.OAuthTokenReq{GrantType: "client_credentials", Scope: "system-api", ClientID: created.ClientID, ClientSecret: created.ClientSecret}, ErrOauthInvalidScope},
+	}
+	for _, tc := range cases {
+		if _, err := service.IssueToken(ctx, tc.req); !errors.Is(err, tc.want) {
+			t.Fatalf("IssueToken(%s) error = %v, want %v", tc.name, err, tc.want)
+		}
+	}
+
+	if err := service.DisableOauthClient(ctx, created.ID); err != nil {
+		t.Fatalf("DisableOauthClient() error = %v", err)
+	}
+	_, err = service.IssueToken(ctx, dto.OAuthTokenReq{GrantType: "client_credentials", ClientID: created.ClientID, ClientSecret: created.ClientSecret})
+	if !errors.Is(err, ErrOauthInvalidClient) {
+		t.Fatalf("IssueToken() on disabled client error = %v, want %v", err, ErrOauthInvalidClient)
+	}
+}
+
+func TestOauthClientServiceRejectsUnknownScopes(t *testing.T) {
+	service, _ := newOauthClientTestService(t)
+
+	_, err := service.CreateOauthClient(context.Background(), 1, dto.CreateOauthClientReq{Name: "sync", Scopes: []string{"missing"}})
+	if err == nil {
+		t.Fatal("CreateOauthClient() error = nil, want unknown api group error")
+	}
+}
+
+func TestOauthClientServiceIntrospectAndRevoke(t *testing.T) {
+	service, _ := newOauthClientTestService(t)
+	ctx := context.Background()
+
+	owner, err := service.CreateOauthClient(ctx, 1, dto.CreateOauthClientReq{Name: "owner", Scopes: []string{"poetry"}})
+	if err != nil {
+		t.Fatalf("CreateOauthClient(owner) error = %v", err)
+	}
+	other, err := service.CreateOauthClient(ctx, 1, dto.CreateOauthClientReq{Name: "other", Scopes: []string{"poetry"}})
+	if err != nil {
+		t.Fatalf("CreateOauthClient(other) error = %v", err)
+	}
+
+	issued, err := service.IssueToken(ctx, dto.OAuthTokenReq{GrantType: "client_credentials", ClientID: owner.ClientID, ClientSecret: owner.ClientSecret})
+	if err != nil {
+		t.Fatalf("IssueToken() error = %v", err)
+	}
+	ownerReq := dto.OAuthTokenActionReq{Token: issued.AccessToken, ClientID: owner.ClientID, ClientSecret: owner.ClientSecret}
+	otherReq := dto.OAuthTokenActionReq{Token: issued.AccessToken, ClientID: other.ClientID, ClientSecret: other.ClientSecret}
+
+	info, err := service.IntrospectToken(ctx, ownerReq)
+	if err != nil {
+		t.Fatalf("IntrospectToken() error = %v", err)
+	}
+	if !info.Active || info.ClientID != owner.ClientID || info.Scope != "poetry" {
+		t.Fatalf("IntrospectToken() = %+v, want active token of %s", info, owner.ClientID)
+	}
+	// 其他客户端无法查询或吊销别人的令牌
+	if info, err := service.IntrospectToken(ctx, otherReq); err != nil || info.Active {
+		t.Fatalf("IntrospectToken(other) = %+v, %v, want inactive", info, err)
+	}
+	if err := service.RevokeToken(ctx, otherReq); !errors.Is(err, ErrOauthUnauthorizedClient) {
+		t.Fatalf("RevokeToken(other) error = %v, want %v", err, ErrOauthUnauthorizedClient)
+	}
+
+	if err := service.RevokeToken(ctx, ownerReq); err != nil {
+		t.Fatalf("RevokeToken() error = %v", err)
+	}
+	if info, err := service.IntrospectToken(ctx, ownerReq); err != nil || info.Active {
+		t.Fatalf("IntrospectToken() after revoke = %+v, %v, want inactive", info, err)
+	}
+	// 无效令牌按规范视为吊销成功
+	if err := service.RevokeToken(ctx, dto.OAuthTokenActionReq{Token: "garbage", ClientID: owner.ClientID, ClientSecret: owner.ClientSecret}); err != nil {
+		t.Fatalf("RevokeToken(garbage) error = %v, want nil", err)
+	}
+}
+
+func newOauthClientTestService(t *testing.T) (IOauthClientService, *svc.ServiceContext) {
+	t.Helper()
+
+	gormDB, err := db.InitDatabase(config.Database{
+		Driver: "sqlite3",
+		SQLite: config.SQLite{
+			Path:         filepath.Join(t.TempDir(), "oauthclient.db"),
+			MaxIdleConns: 1,
+			MaxOpenConns: 1,
+		},
+	}, zap.NewNop())
+	if err != nil {
+		t.Fatalf("InitDatabase() error = %v", err)
+	}
+	if err := gormDB.AutoMigrate(&model.SysApi{}, &model.SysOauthClient{}, &model.JwtBlacklist{}); err != nil {
+		t.Fatalf("AutoMigrate() error = %v", err)
+	}
+	sqlDB, err := gormDB.DB()
+	if err != nil {
+		t.Fatalf("DB() error = %v", err)
+	}
+	t.Cleanup(func() {
+		_ = sqlDB.Close()
+	})
+
+	for _, api := range []model.SysApi{
+		{Path: "/api/v1/poetry/dynasty/list", Method: "GET", ApiGroup: "poetry"},
+		{Path: "/api/v1/sys/api/getApiList", Method: "POST", ApiGroup: "system-api"},
+	} {
+		if err := gormDB.Create(&api).Error; err != nil {
+			t.Fatalf("seed sys api error = %v", err)
+		}
+	}
+
+	j, err := jwt.NewJWT(config.JWT{SigningKey: "test", Issuer: "test"}, zap.NewNop(), nil, gormDB)
+	if err != nil {
+		t.Fatalf("NewJWT() error = %v", err)
+	}
+	t.Cleanup(func() {
+		_ = j.Close(context.Background())
+	})
+
+	svcCtx := &svc.ServiceContext{DB: gormDB, Logger: zap.NewNop(), JWT: j}
+	return NewOauthClientService(svcCtx, repository.NewOauthClientRepository(gormDB)), svcCtx
+}
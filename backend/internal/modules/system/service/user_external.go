@@ -0,0 +1,160 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"slices"
+
+	"github.com/CIPFZ/gowebframe/internal/core/config"
+	logger "github.com/CIPFZ/gowebframe/internal/core/log"
+	"github.com/CIPFZ/gowebframe/internal/modules/system/dto"
+	"github.com/CIPFZ/gowebframe/internal/modules/system/model"
+	"github.com/CIPFZ/gowebframe/pkg/utils"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrAccountNotProvisioned = errors.New("该账号尚未开通，请联系管理员")
+	ErrNoAuthorityMapped     = errors.New("未分配角色，请联系管理员")
+	ErrUsernameTaken         = errors.New("用户名已被占用，请联系管理员绑定账号")
+)
+
+// externalAccount 外部身份源 (OIDC、LDAP) 认证通过的账号
+type externalAccount struct {
+	Provider string
+	Subject  string
+	Username string
+	Email    string
+	Name     string
+	Groups   []string
+}
+
+// externalUser 根据外部身份查找本地用户；未绑定时按配置自动开通，已绑定时按组映射同步角色
+func (s *UserService) externalUser(ctx context.Context, account externalAccount, policy config.Provisioning) (*model.SysUser, error) {
+	authorityIDs := mappedAuthorityIDs(policy.GroupMappings, account.Groups)
+
+	bound, err := s.identityRepo.FindByProviderSubject(ctx, account.Provider, account.Subject)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+		if !policy.AutoCreate {
+			return nil, ErrAccountNotProvisioned
+		}
+		if len(authorityIDs) == 0 && policy.DefaultAuthorityID != 0 {
+			authorityIDs = []uint{policy.DefaultAuthorityID}
+		}
+		return s.provisionExternalUser(ctx, account, authorityIDs)
+	}
+
+	user, err := s.userRepo.FindById(ctx, bound.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.syncExternalAuthorities(ctx, user, authorityIDs); err != nil {
+		return nil, err
+	}
+	return s.userRepo.FindById(ctx, user.ID)
+}
+
+// syncExternalAuthorities 组命中映射时以外部身份源为准同步角色；未命中任何映射时保持现有角色
+func (s *UserService) syncExternalAuthorities(ctx context.Context, user *model.SysUser, authorityIDs []uint) (bool, error) {
+	if len(authorityIDs) == 0 || sameAuthorities(user, authorityIDs) {
+		return false, nil
+	}
+	if err := s.userRepo.UpdateWithRoles(ctx, user, dto.UpdateUserReq{
+		ID:           user.ID,
+		NickName:     user.NickName,
+		AuthorityIds: authorityIDs,
+		Phone:        user.Phone,
+		Email:        user.Email,
+		Status:       user.Status,
+	}); err != nil {
+		return false, err
+	}
+	logger.GetLogger(ctx).Info("external_authorities_synced",
+		zap.Uint("userId", user.ID),
+		zap.Uints("authorityIds", authorityIDs),
+	)
+	return true, nil
+}
+
+// provisionExternalUser 首次登录时自动开通用户 (JIT)
+// 用户名与本地账号冲突时不自动绑定，改用 "用户名@提供方"，避免外部身份接管本地账号
+func (s *UserService) provisionExternalUser(ctx context.Context, account externalAccount, authorityIDs []uint) (*model.SysUser, error) {
+	if len(authorityIDs) == 0 {
+		return nil, ErrNoAuthorityMapped
+	}
+
+	username := account.Username
+	if _, err := s.userRepo.FindByUsername(ctx, username); err == nil {
+		username = account.Username + "@" + account.Provider
+		if _, err := s.userRepo.FindByUsername(ctx, username); err == nil {
+			return nil, ErrUsernameTaken
+		}
+	}
+
+	// 外部身份源的用户不使用本地密码，写入随机密码的哈希
+	hashPwd, err := utils.BcryptHash(rand.Text())
+	if err != nil {
+		return nil, err
+	}
+	nickName := account.Name
+	if nickName == "" {
+		nickName = account.Username
+	}
+	user := &model.SysUser{
+		UUID:        uuid.New(),
+		Username:    username,
+		Password:    hashPwd,
+		NickName:    nickName,
+		Avatar:      model.DefaultUserAvatar,
+		Email:       account.Email,
+		Status:      model.UserActive,
+		AuthorityID: authorityIDs[0],
+	}
+	for _, id := range authorityIDs {
+		user.Authorities = append(user.Authorities, model.SysAuthority{AuthorityId: id})
+	}
+
+	if err := s.identityRepo.CreateWithUser(ctx, user, &model.SysUserIdentity{
+		Provider: account.Provider,
+		Subject:  account.Subject,
+		Email:    account.Email,
+	}); err != nil {
+		return nil, err
+	}
+	logger.GetLogger(ctx).Info("external_user_provisioned",
+		zap.String("provider", account.Provider),
+		zap.String("username", username),
+		zap.Uints("authorityIds", authorityIDs),
+	)
+	return s.userRepo.FindById(ctx, user.ID)
+}
+
+// mappedAuthorityIDs 将外部组映射为角色ID (按配置顺序去重)
+func mappedAuthorityIDs(mappings []config.GroupMapping, groups []string) []uint {
+	var ids []uint
+	for _, m := range mappings {
+		if m.AuthorityID != 0 && slices.Contains(groups, m.Group) && !slices.Contains(ids, m.AuthorityID) {
+			ids = append(ids, m.AuthorityID)
+		}
+	}
+	return ids
+}
+
+func sameAuthorities(user *model.SysUser, ids []uint) bool {
+	if len(user.Authorities) != len(ids) {
+		return false
+	}
+	for _, auth := range user.Authorities {
+		if !slices.Contains(ids, auth.AuthorityId) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,89 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/CIPFZ/gowebframe/internal/modules/system/dto"
+	"github.com/CIPFZ/gowebframe/internal/modules/system/model"
+	"github.com/google/uuid"
+)
+
+func TestUserServiceImpersonate(t *testing.T) {
+	service, gormDB := newUserTestService(t, false)
+	seedAuthorities(t, gormDB, 1, model.DefaultUserAuthorityID)
+	admin := model.SysUser{UUID: uuid.New(), Username: "root", NickName: "Root", AuthorityID: 1, Status: model.UserActive}
+	if err := gormDB.Create(&admin).Error; err != nil {
+		t.Fatalf("seed admin error = %v", err)
+	}
+	if err := gormDB.Exec("INSERT INTO sys_user_authorities (user_id, authority_id) VALUES (?, 1)", admin.ID).Error; err != nil {
+		t.Fatalf("seed admin authority error = %v", err)
+	}
+	ctx := context.Background()
+
+	// alice 自己的登录会话
+	login, err := service.Login(ctx, dto.LoginReq{Username: "alice", Password: "Passw0rd!"})
+	if err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+
+	resp, err := service.Impersonate(ctx, admin.ID, dto.ImpersonateReq{UserID: 1, Reason: "排查问题"}, "127.0.0.1", "Mozilla/5.0 Chrome/120.0")
+	if err != nil {
+		t.Fatalf("Impersonate() error = %v", err)
+	}
+	if resp.RefreshToken != "" {
+		t.Fatal("Impersonate() issued a refresh token")
+	}
+	c, err := svcJWT(service).ParseToken(resp.Token)
+	if err != nil {
+		t.Fatalf("ParseToken() error = %v", err)
+	}
+	if c.UserID != 1 || c.ImpersonatorID != admin.ID || c.AuthorityId != model.DefaultUserAuthorityID {
+		t.Fatalf("claims = %+v, want alice impersonated by %d", c.BaseClaims, admin.ID)
+	}
+	if ttl := time.Until(c.ExpiresAt.Time); ttl > 30*time.Minute || ttl < 29*time.Minute {
+		t.Fatalf("impersonation token ttl = %v, want 30m", ttl)
+	}
+
+	// 未开启多点登录也不能踢掉用户本人的会话
+	sessions, err := service.ListSessions(ctx, 1, c.SessionID)
+	if err != nil || len(sessions) != 2 {
+		t.Fatalf("ListSessions() = %+v, %v, want 2 sessions", sessions, err)
+	}
+	for _, item := range sessions {
+		if item.Current != (item.ImpersonatorID == admin.ID) {
+			t.Fatalf("session %+v impersonator mismatch", item)
+		}
+	}
+
+	info := service.GetImpersonationInfo(ctx, admin.ID, c.SessionID)
+	if info.ImpersonatorName != "Root" || !info.ExpiresAt.Equal(time.UnixMilli(resp.ExpiresAt)) {
+		t.Fatalf("GetImpersonationInfo() = %+v", info)
+	}
+
+	// 退出模拟登录只结束模拟会话
+	if err := service.Logout(ctx, resp.Token, ""); err != nil {
+		t.Fatalf("Logout() error = %v", err)
+	}
+	if _, err := service.RefreshToken(ctx, login.RefreshToken); err != nil {
+		t.Fatalf("RefreshToken() after impersonation logout error = %v", err)
+	}
+
+	if _, err := service.Impersonate(ctx, admin.ID, dto.ImpersonateReq{UserID: admin.ID}, "", ""); !errors.Is(err, ErrImpersonateSelf) {
+		t.Fatalf("Impersonate(self) error = %v, want %v", err, ErrImpersonateSelf)
+	}
+	if _, err := service.Impersonate(ctx, 1, dto.ImpersonateReq{UserID: admin.ID}, "", ""); !errors.Is(err, ErrImpersonateSuperAdmin) {
+		t.Fatalf("Impersonate(super admin) error = %v, want %v", err, ErrImpersonateSuperAdmin)
+	}
+	if _, err := service.Impersonate(ctx, admin.ID, dto.ImpersonateReq{UserID: 404}, "", ""); !errors.Is(err, ErrImpersonateNotFound) {
+		t.Fatalf("Impersonate(missing) error = %v, want %v", err, ErrImpersonateNotFound)
+	}
+	if err := gormDB.Model(&model.SysUser{}).Where("id = 1").Update("status", model.UserInactive).Error; err != nil {
+		t.Fatalf("disable user error = %v", err)
+	}
+	if _, err := service.Impersonate(ctx, admin.ID, dto.ImpersonateReq{UserID: 1}, "", ""); !errors.Is(err, ErrUserDisabled) {
+		t.Fatalf("Impersonate(disabled) error = %v, want %v", err, ErrUserDisabled)
+	}
+}
@@ -0,0 +1,52 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/CIPFZ/gowebframe/internal/core/ldapauth"
+	logger "github.com/CIPFZ/gowebframe/internal/core/log"
+
+	"go.uber.org/zap"
+)
+
+// SyncLdapGroups 按目录中的组同步已绑定 LDAP 账号的角色，返回角色有变化的用户数
+// 目录中已不存在的账号只记录日志，不自动禁用
+func (s *UserService) SyncLdapGroups(ctx context.Context) (int, error) {
+	client := s.svcCtx.LDAP
+	if client == nil {
+		return 0, nil
+	}
+	log := logger.GetLogger(ctx)
+	mappings := client.Config().GroupMappings
+
+	identities, err := s.identityRepo.ListByProvider(ctx, ldapauth.Provider)
+	if err != nil {
+		return 0, err
+	}
+	updated := 0
+	for _, identity := range identities {
+		entry, err := client.Lookup(ctx, identity.Subject)
+		if err != nil {
+			if errors.Is(err, ldapauth.ErrUserNotFound) {
+				log.Warn("ldap_sync_user_missing", zap.Uint("userId", identity.UserID), zap.String("subject", identity.Subject))
+				continue
+			}
+			return updated, err
+		}
+		user, err := s.userRepo.FindById(ctx, identity.UserID)
+		if err != nil {
+			log.Warn("ldap_sync_user_load_failed", zap.Uint("userId", identity.UserID), zap.Error(err))
+			continue
+		}
+		changed, err := s.syncExternalAuthorities(ctx, user, mappedAuthorityIDs(mappings, entry.Groups))
+		if err != nil {
+			return updated, err
+		}
+		if changed {
+			updated++
+		}
+	}
+	log.Info("ldap_sync_finished", zap.Int("users", len(identities)), zap.Int("updated", updated))
+	return updated, nil
+}
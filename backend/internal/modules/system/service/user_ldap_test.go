@@ -0,0 +1,131 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/CIPFZ/gowebframe/internal/core/config"
+	"github.com/CIPFZ/gowebframe/internal/core/ldapauth"
+	"github.com/CIPFZ/gowebframe/internal/core/ldapauth/ldaptest"
+	"github.com/CIPFZ/gowebframe/internal/modules/system/dto"
+	"github.com/CIPFZ/gowebframe/internal/modules/system/model"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const ldapBobDN = "uid=bob,ou=people,dc=example,dc=org"
+
+func ldapBob(groups ...string) *ldaptest.Entry {
+	return &ldaptest.Entry{
+		DN:       ldapBobDN,
+		Password: "bob-secret",
+		Attributes: map[string][]string{
+			"uid":      {"bob"},
+			"cn":       {"Bob"},
+			"mail":     {"bob@example.org"},
+			"memberOf": groups,
+		},
+	}
+}
+
+// newLdapTestService 接入内嵌 LDAP 服务端的用户服务
+func newLdapTestService(t *testing.T, dir *ldaptest.Server) (IUserService, *gorm.DB) {
+	t.Helper()
+	service, gormDB := newUserTestService(t, true)
+	seedAuthorities(t, gormDB, 1, 2, 3)
+
+	us := service.(*UserService)
+	us.svcCtx.LDAP = ldapauth.New(config.LDAP{
+		Enabled: true,
+		URL:     dir.URL(),
+		BaseDN:  "dc=example,dc=org",
+		Provisioning: config.Provisioning{
+			AutoCreate:         true,
+			DefaultAuthorityID: model.DefaultUserAuthorityID,
+			GroupMappings: []config.GroupMapping{
+				{Group: "admins", AuthorityID: 1},
+				{Group: "ops", AuthorityID: 3},
+			},
+		},
+	}, zap.NewNop())
+	us.authenticators = append(us.authenticators, &ldapAuthenticator{s: us, client: us.svcCtx.LDAP})
+	return service, gormDB
+}
+
+func TestUserServiceLdapLoginProvisionsUser(t *testing.T) {
+	dir := ldaptest.NewServer(&ldaptest.Entry{DN: "dc=example,dc=org"}, ldapBob("cn=admins,ou=groups,dc=example,dc=org"))
+	defer dir.Close()
+	service, gormDB := newLdapTestService(t, dir)
+	ctx := context.Background()
+
+	// 本地账号仍使用 bcrypt 校验
+	if _, err := service.Login(ctx, dto.LoginReq{Username: "alice", Password: "Passw0rd!"}); err != nil {
+		t.Fatalf("Login() local user error = %v", err)
+	}
+
+	if _, err := service.Login(ctx, dto.LoginReq{Username: "bob", Password: "wrong"}); !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("Login() wrong ldap password error = %v, want %v", err, ErrInvalidCredentials)
+	}
+
+	resp, err := service.Login(ctx, dto.LoginReq{Username: "bob", Password: "bob-secret"})
+	if err != nil {
+		t.Fatalf("Login() ldap user error = %v", err)
+	}
+	claims, err := svcJWT(service).ParseToken(resp.Token)
+	if err != nil {
+		t.Fatalf("ParseToken() error = %v", err)
+	}
+	if claims.Username != "bob" || claims.AuthorityId != 1 {
+		t.Fatalf("token claims = %+v, want bob with authority 1", claims.BaseClaims)
+	}
+
+	var identity model.SysUserIdentity
+	if err := gormDB.Where("provider = ? AND subject = ?", ldapauth.Provider, "bob").First(&identity).Error; err != nil || identity.UserID != claims.UserID {
+		t.Fatalf("identity = %+v, %v, want bound to user %d", identity, err, claims.UserID)
+	}
+
+	// 再次登录不会重复开通
+	if _, err := service.Login(ctx, dto.LoginReq{Username: "bob", Password: "bob-secret"}); err != nil {
+		t.Fatalf("Login() second ldap login error = %v", err)
+	}
+	var count int64
+	gormDB.Model(&model.SysUser{}).Count(&count)
+	if count != 2 {
+		t.Fatalf("user count = %d, want 2", count)
+	}
+}
+
+func TestUserServiceSyncLdapGroups(t *testing.T) {
+	dir := ldaptest.NewServer(&ldaptest.Entry{DN: "dc=example,dc=org"}, ldapBob("cn=admins,ou=groups,dc=example,dc=org"))
+	defer dir.Close()
+	service, gormDB := newLdapTestService(t, dir)
+	ctx := context.Background()
+
+	resp, err := service.Login(ctx, dto.LoginReq{Username: "bob", Password: "bob-secret"})
+	if err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+	claims, _ := svcJWT(service).ParseToken(resp.Token)
+
+	// 目录中调整组后，定时同步更新 sys_user_authorities
+	dir.Add(ldapBob("cn=ops,ou=groups,dc=example,dc=org", "cn=admins,ou=groups,dc=example,dc=org"))
+	updated, err := service.SyncLdapGroups(ctx)
+	if err != nil || updated != 1 {
+		t.Fatalf("SyncLdapGroups() = %d, %v, want 1 updated", updated, err)
+	}
+	var authorityIDs []uint
+	gormDB.Table("sys_user_authorities").Where("user_id = ?", claims.UserID).Order("authority_id").Pluck("authority_id", &authorityIDs)
+	if len(authorityIDs) != 2 || authorityIDs[0] != 1 || authorityIDs[1] != 3 {
+		t.Fatalf("sys_user_authorities = %v, want [1 3]", authorityIDs)
+	}
+
+	// 无变化时不更新；目录中删除的账号跳过
+	if updated, err := service.SyncLdapGroups(ctx); err != nil || updated != 0 {
+		t.Fatalf("SyncLdapGroups() unchanged = %d, %v, want 0", updated, err)
+	}
+	dir.Remove(ldapBobDN)
+	if updated, err := service.SyncLdapGroups(ctx); err != nil || updated != 0 {
+		t.Fatalf("SyncLdapGroups() missing user = %d, %v, want 0", updated, err)
+	}
+}
@@ -0,0 +1,246 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/CIPFZ/gowebframe/internal/core/cache"
+	logger "github.com/CIPFZ/gowebframe/internal/core/log"
+	"github.com/CIPFZ/gowebframe/internal/modules/system/dto"
+	"github.com/CIPFZ/gowebframe/internal/modules/system/model"
+	"github.com/CIPFZ/gowebframe/pkg/errcode"
+
+	"go.uber.org/zap"
+)
+
+// 登录防爆破：按用户名、IP 统计失败次数 (密码与二次验证的失败均计入)
+// 失败次数达到 captcha.open_captcha 后需要验证码，同一账号达到 captcha.lock_threshold 后临时锁定；
+// 二次验证另按账号单独计数，连续失败 mfaMaxAttempts 次即锁定，重新输入密码不会重置
+const (
+	loginFailUserKey = "login_fail:user:"
+	loginFailIPKey   = "login_fail:ip:"
+	loginFailMfaKey  = "login_fail:mfa:"
+	loginLockKey     = "login_lock:"
+
+	defaultLoginFailWindow = time.Hour
+	defaultLockDuration    = 15 * time.Minute
+
+	loginGuardModule = "system-user"
+	loginPath        = "/user/login"
+	unlockUserPath   = "/sys/user/unlockUser"
+)
+
+// GenerateCaptcha 生成登录验证码，并告知当前客户端是否需要输入
+func (s *UserService) GenerateCaptcha(ctx context.Context, ip string) (*dto.CaptchaResponse, error) {
+	if s.captcha == nil {
+		return &dto.CaptchaResponse{}, nil
+	}
+	id, pic, err := s.captcha.Generate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &dto.CaptchaResponse{
+		CaptchaId:     id,
+		PicPath:       pic,
+		CaptchaLength: s.captcha.Length(),
+		OpenCaptcha:   s.captchaRequired(ctx, "", ip),
+	}, nil
+}
+
+// checkLoginGuard 校验账号锁定与验证码，未配置缓存时不做限制
+func (s *UserService) checkLoginGuard(ctx context.Context, req dto.LoginReq) error {
+	store := s.svcCtx.Cache
+	if store == nil {
+		return nil
+	}
+
+	if err := s.checkAccountLock(ctx, req.Username, req.IP, req.UserAgent); err != nil {
+		return err
+	}
+
+	if !s.captchaRequired(ctx, req.Username, req.IP) {
+		return nil
+	}
+	if req.Captcha == "" || req.CaptchaId == "" {
+		return errcode.CaptchaRequired
+	}
+	if !s.captcha.Verify(ctx, req.CaptchaId, req.Captcha) {
+		return errcode.CaptchaInvalid
+	}
+	return nil
+}
+
+// checkAccountLock 校验账号是否处于锁定期，未配置缓存时不做限制
+// 锁定记录的值为解锁时间，到期后在下一次登录时清除并记录解锁事件
+func (s *UserService) checkAccountLock(ctx context.Context, username, ip, agent string) error {
+	store := s.svcCtx.Cache
+	if store == nil {
+		return nil
+	}
+	val, err := store.Get(ctx, loginLockKey+username)
+	if err != nil {
+		return nil
+	}
+	until, _ := strconv.ParseInt(val, 10, 64)
+	if remaining := time.Until(time.Unix(until, 0)); remaining > 0 {
+		return errcode.AccountLocked.WithDetails(fmt.Sprintf("%d 分钟后重试", int(remaining.Minutes())+1))
+	}
+	_ = store.Del(ctx, loginLockKey+username)
+	s.recordLockEvent(ctx, "账号解锁", loginPath, 0, ip, agent,
+		fmt.Sprintf("username=%s reason=expired", username))
+	return nil
+}
+
+// captchaRequired open_captcha 为 0 时每次登录都需要验证码；否则用户名或 IP 的失败次数达到阈值时需要
+func (s *UserService) captchaRequired(ctx context.Context, username, ip string) bool {
+	if s.captcha == nil {
+		return false
+	}
+	threshold := int64(s.svcCtx.Config.Captcha.OpenCaptcha)
+	if threshold <= 0 {
+		return true
+	}
+	if username != "" && s.loginFailures(ctx, loginFailUserKey+username) >= threshold {
+		return true
+	}
+	return ip != "" && s.loginFailures(ctx, loginFailIPKey+ip) >= threshold
+}
+
+func (s *UserService) loginFailures(ctx context.Context, key string) int64 {
+	val, err := s.svcCtx.Cache.Get(ctx, key)
+	if err != nil {
+		return 0
+	}
+	n, _ := strconv.ParseInt(val, 10, 64)
+	return n
+}
+
+// recordLoginFailure 累加失败次数，账号失败次数达到锁定阈值时锁定账号
+func (s *UserService) recordLoginFailure(ctx context.Context, req dto.LoginReq, userID uint) {
+	store := s.svcCtx.Cache
+	if store == nil {
+		return
+	}
+	log := logger.GetLogger(ctx)
+	cfg := s.svcCtx.Config.Captcha
+	window := s.loginFailWindow()
+
+	if req.IP != "" {
+		if _, err := store.Incr(ctx, loginFailIPKey+req.IP, window); err != nil {
+			log.Error("login_fail_counter_failed", zap.Error(err))
+		}
+	}
+	fails, err := store.Incr(ctx, loginFailUserKey+req.Username, window)
+	if err != nil {
+		log.Error("login_fail_counter_failed", zap.Error(err))
+		return
+	}
+	if cfg.LockThreshold <= 0 || fails < int64(cfg.LockThreshold) {
+		return
+	}
+	s.lockAccount(ctx, req.Username, userID, req.IP, req.UserAgent, fails)
+}
+
+// recordMfaFailure 二次验证失败：计入账号与 IP 的登录失败次数，并按账号单独累计，达到 mfaMaxAttempts 时锁定账号
+func (s *UserService) recordMfaFailure(ctx context.Context, user *model.SysUser, ip, agent string) {
+	store := s.svcCtx.Cache
+	if store == nil {
+		return
+	}
+	s.recordLoginFailure(ctx, dto.LoginReq{Username: user.Username, IP: ip, UserAgent: agent}, user.ID)
+
+	fails, err := store.Incr(ctx, loginFailMfaKey+user.Username, s.loginFailWindow())
+	if err != nil {
+		logger.GetLogger(ctx).Error("login_fail_counter_failed", zap.Error(err))
+		return
+	}
+	if fails >= mfaMaxAttempts {
+		s.lockAccount(ctx, user.Username, user.ID, ip, agent, fails)
+	}
+}
+
+// lockAccount 锁定账号并清除失败计数，已处于锁定期时不重复记录
+func (s *UserService) lockAccount(ctx context.Context, username string, userID uint, ip, agent string, fails int64) {
+	store := s.svcCtx.Cache
+	log := logger.GetLogger(ctx)
+	duration := time.Duration(s.svcCtx.Config.Captcha.LockDuration) * time.Second
+	if duration <= 0 {
+		duration = defaultLockDuration
+	}
+	until := time.Now().Add(duration)
+	// 记录保留到锁定结束后一个统计窗口，便于到期时记录解锁事件
+	ok, err := store.SetNX(ctx, loginLockKey+username, strconv.FormatInt(until.Unix(), 10), duration+s.loginFailWindow())
+	if err != nil {
+		log.Error("login_lock_failed", zap.Error(err))
+		return
+	}
+	_ = store.Del(ctx, loginFailUserKey+username, loginFailMfaKey+username)
+	if !ok {
+		return
+	}
+	log.Warn("login_account_locked", zap.String("username", username), zap.Int64("failures", fails))
+	s.recordLockEvent(ctx, "账号锁定", loginPath, userID, ip, agent,
+		fmt.Sprintf("username=%s failures=%d until=%s", username, fails, until.Format(time.RFC3339)))
+}
+
+// loginFailWindow 失败次数的统计窗口
+func (s *UserService) loginFailWindow() time.Duration {
+	window := time.Duration(s.svcCtx.Config.Captcha.OpenCaptchaTimeOut) * time.Second
+	if window <= 0 {
+		window = defaultLoginFailWindow
+	}
+	return window
+}
+
+// clearLoginFailures 完成登录 (含二次验证) 后清除账号失败计数 (IP 计数保留到窗口结束)
+func (s *UserService) clearLoginFailures(ctx context.Context, username string) {
+	if s.svcCtx.Cache == nil {
+		return
+	}
+	_ = s.svcCtx.Cache.Del(ctx, loginFailUserKey+username, loginFailMfaKey+username)
+}
+
+// UnlockUser 管理员手动解除账号锁定
+func (s *UserService) UnlockUser(ctx context.Context, id uint, operatorID uint, ip string) error {
+	user, err := s.userRepo.FindById(ctx, id)
+	if err != nil {
+		return err
+	}
+	if s.svcCtx.Cache == nil {
+		return nil
+	}
+	if _, err := s.svcCtx.Cache.Get(ctx, loginLockKey+user.Username); err != nil {
+		if errors.Is(err, cache.ErrNotFound) {
+			return errors.New("该账号未被锁定")
+		}
+		return err
+	}
+	if err := s.svcCtx.Cache.Del(ctx, loginLockKey+user.Username, loginFailUserKey+user.Username, loginFailMfaKey+user.Username); err != nil {
+		return err
+	}
+	s.recordLockEvent(ctx, "账号解锁", unlockUserPath, operatorID, ip, "",
+		fmt.Sprintf("username=%s reason=manual", user.Username))
+	return nil
+}
+
+// recordLockEvent 账号锁定/解锁事件写入操作日志
+func (s *UserService) recordLockEvent(ctx context.Context, remark, path string, userID uint, ip, agent, body string) {
+	logger.GetLogger(ctx).Info("login_lock_event", zap.String("event", remark), zap.String("detail", body))
+	if s.svcCtx.AuditRecorder == nil {
+		return
+	}
+	s.svcCtx.AuditRecorder.Push(model.SysOperationLog{
+		Ip:     ip,
+		Method: "POST",
+		Path:   path,
+		Agent:  agent,
+		Status: 200,
+		Module: loginGuardModule,
+		Remark: remark,
+		Body:   body,
+		UserID: userID,
+	})
+}
@@ -0,0 +1,122 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/CIPFZ/gowebframe/internal/core/audit"
+	"github.com/CIPFZ/gowebframe/internal/core/cache"
+	"github.com/CIPFZ/gowebframe/internal/core/captcha"
+	"github.com/CIPFZ/gowebframe/internal/core/config"
+	"github.com/CIPFZ/gowebframe/internal/modules/system/dto"
+	"github.com/CIPFZ/gowebframe/internal/modules/system/model"
+	"github.com/CIPFZ/gowebframe/pkg/errcode"
+	"go.uber.org/zap"
+)
+
+func TestUserServiceLoginRequiresCaptchaThenLocks(t *testing.T) {
+	service, gormDB := newUserTestService(t, true)
+	if err := gormDB.AutoMigrate(&model.SysOperationLog{}); err != nil {
+		t.Fatalf("AutoMigrate() error = %v", err)
+	}
+	us := enableLoginGuard(t, service, config.Captcha{OpenCaptcha: 2, OpenCaptchaTimeOut: 60, LockThreshold: 4, LockDuration: 60})
+	recorder := audit.NewAuditRecorder(gormDB, zap.NewNop())
+	us.svcCtx.AuditRecorder = recorder
+	ctx := context.Background()
+
+	wrong := dto.LoginReq{Username: "alice", Password: "wrong", IP: "10.0.0.1"}
+	for i := 0; i < 2; i++ {
+		if _, err := service.Login(ctx, wrong); err == nil {
+			t.Fatal("Login() with wrong password error = nil")
+		}
+	}
+
+	// 达到 open_captcha 后必须携带验证码
+	if _, err := service.Login(ctx, dto.LoginReq{Username: "alice", Password: "Passw0rd!", IP: "10.0.0.1"}); !errors.Is(err, errcode.CaptchaRequired) {
+		t.Fatalf("Login() without captcha error = %v, want %v", err, errcode.CaptchaRequired)
+	}
+	// 同一 IP 尝试其它账号也需要验证码
+	resp, err := service.GenerateCaptcha(ctx, "10.0.0.1")
+	if err != nil || !resp.OpenCaptcha {
+		t.Fatalf("GenerateCaptcha() = %#v, %v, want openCaptcha", resp, err)
+	}
+
+	for i := 0; i < 2; i++ {
+		req := wrong
+		req.CaptchaId, req.Captcha = solveCaptcha(t, us)
+		if _, err := service.Login(ctx, req); err == nil {
+			t.Fatal("Login() with wrong password error = nil")
+		}
+	}
+
+	// 达到 lock_threshold 后即使密码正确也被拒绝
+	req := dto.LoginReq{Username: "alice", Password: "Passw0rd!", IP: "10.0.0.2"}
+	if _, err := service.Login(ctx, req); err == nil || !isErrCode(err, errcode.AccountLocked) {
+		t.Fatalf("Login() on locked account error = %v, want account locked", err)
+	}
+
+	if err := service.UnlockUser(ctx, 1, 99, "127.0.0.1"); err != nil {
+		t.Fatalf("UnlockUser() error = %v", err)
+	}
+	if _, err := service.Login(ctx, req); err != nil {
+		t.Fatalf("Login() after unlock error = %v", err)
+	}
+
+	if err := recorder.Close(ctx); err != nil {
+		t.Fatalf("AuditRecorder.Close() error = %v", err)
+	}
+	var remarks []string
+	if err := gormDB.Model(&model.SysOperationLog{}).Order("id").Pluck("remark", &remarks).Error; err != nil {
+		t.Fatalf("query operation logs error = %v", err)
+	}
+	if len(remarks) != 2 || remarks[0] != "账号锁定" || remarks[1] != "账号解锁" {
+		t.Fatalf("operation log remarks = %v, want lock then unlock", remarks)
+	}
+}
+
+func TestUserServiceLoginCaptchaAlwaysWhenOpenCaptchaZero(t *testing.T) {
+	service, _ := newUserTestService(t, true)
+	us := enableLoginGuard(t, service, config.Captcha{OpenCaptcha: 0})
+	ctx := context.Background()
+
+	req := dto.LoginReq{Username: "alice", Password: "Passw0rd!", CaptchaId: "missing", Captcha: "123456"}
+	if _, err := service.Login(ctx, req); !errors.Is(err, errcode.CaptchaInvalid) {
+		t.Fatalf("Login() with unknown captcha error = %v, want %v", err, errcode.CaptchaInvalid)
+	}
+	req.CaptchaId, req.Captcha = solveCaptcha(t, us)
+	if _, err := service.Login(ctx, req); err != nil {
+		t.Fatalf("Login() with captcha error = %v", err)
+	}
+}
+
+func enableLoginGuard(t *testing.T, service IUserService, cfg config.Captcha) *UserService {
+	t.Helper()
+	store := cache.NewMemoryStore()
+	t.Cleanup(func() { _ = store.Close(context.Background()) })
+
+	us := service.(*UserService)
+	us.svcCtx.Cache = store
+	us.svcCtx.Config.Captcha = cfg
+	us.captcha = captcha.New(store, cfg)
+	return us
+}
+
+// solveCaptcha 生成验证码并直接从缓存读取答案
+func solveCaptcha(t *testing.T, us *UserService) (string, string) {
+	t.Helper()
+	resp, err := us.GenerateCaptcha(context.Background(), "")
+	if err != nil {
+		t.Fatalf("GenerateCaptcha() error = %v", err)
+	}
+	answer, err := us.svcCtx.Cache.Get(context.Background(), "captcha:"+resp.CaptchaId)
+	if err != nil {
+		t.Fatalf("read captcha answer error = %v", err)
+	}
+	return resp.CaptchaId, answer
+}
+
+func isErrCode(err error, want *errcode.Error) bool {
+	var e *errcode.Error
+	return errors.As(err, &e) && e.Code == want.Code
+}
@@ -0,0 +1,126 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	logger "github.com/CIPFZ/gowebframe/internal/core/log"
+	"github.com/CIPFZ/gowebframe/internal/core/session"
+	"github.com/CIPFZ/gowebframe/internal/modules/system/model"
+	"github.com/CIPFZ/gowebframe/pkg/errcode"
+	"go.uber.org/zap"
+)
+
+const maxLoginLogReason = 255
+
+// loginSource 一次登录的认证方式与来源，签发 token 与记录登录日志时使用
+type loginSource struct {
+	Method    string // password、ldap、oidc:<provider>
+	Mfa       bool   // 是否通过了二次验证
+	IP        string
+	UserAgent string
+}
+
+func oidcLoginMethod(provider string) string {
+	return model.LoginMethodOidc + ":" + provider
+}
+
+// logLoginSuccess 记录成功登录；来自从未出现过的设备或网段时给用户发送站内通知
+func (s *UserService) logLoginSuccess(ctx context.Context, user *model.SysUser, src loginSource) {
+	if s.loginLogRepo == nil {
+		return
+	}
+	entry := s.newLoginLog(user.ID, user.Username, src)
+	entry.Success = true
+
+	history, err := s.loginLogRepo.History(ctx, user.ID, entry.Fingerprint, entry.IPRange)
+	if err != nil {
+		logger.GetLogger(ctx).Error("login_history_query_failed", zap.Uint("userID", user.ID), zap.Error(err))
+	}
+	// 首次登录没有可比较的历史，不视为新设备
+	entry.NewDevice = err == nil && history.HasSuccess && (!history.DeviceSeen || !history.IPRangeSeen)
+	s.saveLoginLog(ctx, entry)
+
+	if entry.NewDevice {
+		s.notifyUsers(ctx, 0, "新设备登录提醒", fmt.Sprintf(
+			"您的账号于 %s 在新的设备或网络登录。\n设备：%s\nIP：%s%s\n如非本人操作，请立即修改密码，并在「个人中心 - 登录会话」中下线该设备。",
+			entry.CreatedAt.Format("2006-01-02 15:04:05"), entry.Device, entry.Ip, locationSuffix(entry.GeoLocation),
+		), []uint{user.ID})
+	}
+}
+
+// logLoginFailure 记录失败的登录；user 为空时按账号查找本地用户，便于用户查看自己账号的失败记录
+func (s *UserService) logLoginFailure(ctx context.Context, user *model.SysUser, username string, src loginSource, cause error) {
+	if s.loginLogRepo == nil {
+		return
+	}
+	if user == nil && username != "" {
+		if found, err := s.userRepo.FindByUsername(ctx, username); err == nil {
+			user = found
+		}
+	}
+	var userID uint
+	if user != nil {
+		userID, username = user.ID, user.Username
+	}
+	entry := s.newLoginLog(userID, username, src)
+	entry.Reason = loginFailureReason(cause)
+	s.saveLoginLog(ctx, entry)
+}
+
+func (s *UserService) saveLoginLog(ctx context.Context, entry *model.SysLoginLog) {
+	if err := s.loginLogRepo.Create(ctx, entry); err != nil {
+		logger.GetLogger(ctx).Error("login_log_save_failed", zap.String("username", entry.Username), zap.Error(err))
+	}
+}
+
+func (s *UserService) newLoginLog(userID uint, username string, src loginSource) *model.SysLoginLog {
+	loc := s.svcCtx.GeoIP.Lookup(src.IP)
+	entry := &model.SysLoginLog{
+		UserID:      userID,
+		Username:    truncateRunes(username, 64),
+		Method:      src.Method,
+		Mfa:         src.Mfa,
+		Ip:          src.IP,
+		IPRange:     session.IPRange(src.IP),
+		Agent:       truncateRunes(src.UserAgent, 512),
+		Device:      truncateRunes(session.DeviceFromUserAgent(src.UserAgent), 128),
+		Fingerprint: session.DeviceFingerprint(src.UserAgent),
+		GeoLocation: model.GeoLocation{Country: loc.Country, Region: loc.Region, ASN: loc.ASN, ASOrg: loc.ASOrg},
+	}
+	entry.CreatedAt = time.Now()
+	return entry
+}
+
+// locationSuffix 通知中 IP 后附带的归属地，如 " (CN 浙江省)"
+func locationSuffix(loc model.GeoLocation) string {
+	place := strings.TrimSpace(loc.Country + " " + loc.Region)
+	if place == "" {
+		return ""
+	}
+	return " (" + place + ")"
+}
+
+// loginFailureReason 业务错误码只保留提示文案
+func loginFailureReason(err error) string {
+	if err == nil {
+		return ""
+	}
+	msg := err.Error()
+	var e *errcode.Error
+	if errors.As(err, &e) {
+		msg = e.Msg
+	}
+	return truncateRunes(msg, maxLoginLogReason)
+}
+
+func truncateRunes(s string, max int) string {
+	r := []rune(s)
+	if len(r) > max {
+		return string(r[:max])
+	}
+	return s
+}
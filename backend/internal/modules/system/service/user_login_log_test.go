@@ -0,0 +1,77 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/CIPFZ/gowebframe/internal/modules/system/dto"
+	"github.com/CIPFZ/gowebframe/internal/modules/system/model"
+	"github.com/CIPFZ/gowebframe/internal/modules/system/repository"
+)
+
+func TestUserServiceLoginLog(t *testing.T) {
+	service, gormDB := newUserTestService(t, true)
+	ctx := context.Background()
+	const (
+		chrome  = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) Chrome/120.0 Safari/537.36"
+		firefox = "Mozilla/5.0 (X11; Linux x86_64; rv:121.0) Gecko/20100101 Firefox/121.0"
+	)
+
+	login := func(password, ip, ua string) {
+		t.Helper()
+		_, _ = service.Login(ctx, dto.LoginReq{Username: "alice", Password: password, IP: ip, UserAgent: ua})
+	}
+	login("wrong", "10.0.0.1", chrome)      // 失败
+	login("Passw0rd!", "10.0.0.1", chrome)  // 首次成功登录，不算新设备
+	login("Passw0rd!", "10.0.0.99", chrome) // 同一设备同一网段
+	assertNotices(t, gormDB, 1, "新设备登录提醒", 0)
+	login("Passw0rd!", "10.0.0.2", firefox)   // 新设备
+	login("Passw0rd!", "192.168.1.5", chrome) // 新网段
+	// 不存在的账号也记录失败
+	_, _ = service.Login(ctx, dto.LoginReq{Username: "nobody", Password: "x", IP: "10.0.0.1", UserAgent: chrome})
+	assertNotices(t, gormDB, 1, "新设备登录提醒", 2)
+
+	var logs []model.SysLoginLog
+	if err := gormDB.Order("id").Find(&logs).Error; err != nil {
+		t.Fatalf("find login logs error = %v", err)
+	}
+	want := []struct {
+		userID    uint
+		success   bool
+		newDevice bool
+	}{
+		{1, false, false},
+		{1, true, false},
+		{1, true, false},
+		{1, true, true},
+		{1, true, true},
+		{0, false, false},
+	}
+	if len(logs) != len(want) {
+		t.Fatalf("login logs = %d, want %d", len(logs), len(want))
+	}
+	for i, w := range want {
+		got := logs[i]
+		if got.UserID != w.userID || got.Success != w.success || got.NewDevice != w.newDevice {
+			t.Fatalf("log[%d] = user=%d success=%v new=%v, want %+v", i, got.UserID, got.Success, got.NewDevice, w)
+		}
+	}
+	if logs[0].Method != model.LoginMethodPassword || logs[0].Reason == "" || logs[0].Fingerprint == "" {
+		t.Fatalf("failure log = %+v", logs[0])
+	}
+	if logs[4].IPRange != "192.168.1.0/24" || logs[5].Username != "nobody" {
+		t.Fatalf("logs = %+v / %+v", logs[4], logs[5])
+	}
+
+	// 普通用户只能查看自己的记录
+	logService := NewLoginLogService(nil, repository.NewLoginLogRepository(gormDB))
+	list, total, err := logService.GetSelfLoginLogList(ctx, 1, dto.SearchLoginLogReq{Username: "nobody"})
+	if err != nil || total != 5 || len(list) != 5 {
+		t.Fatalf("GetSelfLoginLogList() = %d, %d, %v, want 5", len(list), total, err)
+	}
+	failed := false
+	list, total, err = logService.GetLoginLogList(ctx, dto.SearchLoginLogReq{Success: &failed})
+	if err != nil || total != 2 || len(list) != 2 {
+		t.Fatalf("GetLoginLogList(failed) = %d, %d, %v, want 2", len(list), total, err)
+	}
+}
@@ -0,0 +1,47 @@
+package service
+
+import (
+	"context"
+
+	"github.com/CIPFZ/gowebframe/internal/core/geoip"
+	logger "github.com/CIPFZ/gowebframe/internal/core/log"
+	"github.com/CIPFZ/gowebframe/internal/modules/system/model"
+	"github.com/CIPFZ/gowebframe/pkg/errcode"
+	"go.uber.org/zap"
+)
+
+// loginRegionRestricted 用户拥有的任一角色配置了登录地区即视为受限
+func loginRegionRestricted(user *model.SysUser) bool {
+	if len(user.Authority.AllowedCountries) > 0 {
+		return true
+	}
+	for _, auth := range user.Authorities {
+		if len(auth.AllowedCountries) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// checkLoginRegion 登录 IP 所在国家/地区需同时满足用户各角色的限制
+// 内网地址不受限制；未配置 geoip 时无法判断，放行并记录错误日志，避免管理员被锁在系统外
+func (s *UserService) checkLoginRegion(ctx context.Context, user *model.SysUser, ip string) error {
+	if !loginRegionRestricted(user) || geoip.IsInternal(ip) {
+		return nil
+	}
+	if s.svcCtx.GeoIP == nil {
+		logger.GetLogger(ctx).Error("login_region_check_skipped", zap.Uint("userID", user.ID), zap.String("reason", "geoip not configured"))
+		return nil
+	}
+
+	country := s.svcCtx.GeoIP.Lookup(ip).Country
+	allowed := user.Authority.AllowedCountries.Allows(country)
+	for _, auth := range user.Authorities {
+		allowed = allowed && auth.AllowedCountries.Allows(country)
+	}
+	if !allowed || country == "" {
+		logger.GetLogger(ctx).Warn("login_region_forbidden", zap.Uint("userID", user.ID), zap.String("ip", ip), zap.String("country", country))
+		return errcode.LoginRegionForbidden
+	}
+	return nil
+}
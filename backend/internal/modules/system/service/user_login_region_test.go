@@ -0,0 +1,88 @@
+package service
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/CIPFZ/gowebframe/internal/core/config"
+	"github.com/CIPFZ/gowebframe/internal/core/geoip"
+	"github.com/CIPFZ/gowebframe/internal/core/geoip/geoiptest"
+	"github.com/CIPFZ/gowebframe/internal/modules/system/dto"
+	"github.com/CIPFZ/gowebframe/internal/modules/system/model"
+	"github.com/CIPFZ/gowebframe/pkg/errcode"
+	"go.uber.org/zap"
+)
+
+func TestUserServiceLoginRegion(t *testing.T) {
+	service, gormDB := newUserTestService(t, true)
+	ctx := context.Background()
+	if err := gormDB.Create(&model.SysAuthority{
+		AuthorityId:      model.DefaultUserAuthorityID,
+		AuthorityName:    "user",
+		AllowedCountries: model.CountryList{"CN", "SG"},
+	}).Error; err != nil {
+		t.Fatalf("seed authority error = %v", err)
+	}
+	if err := gormDB.Exec("INSERT INTO sys_user_authorities (user_id, authority_id) VALUES (1, ?)", model.DefaultUserAuthorityID).Error; err != nil {
+		t.Fatalf("seed user authority error = %v", err)
+	}
+
+	login := func(ip string) error {
+		_, err := service.Login(ctx, dto.LoginReq{Username: "alice", Password: "Passw0rd!", IP: ip, UserAgent: "Mozilla/5.0 Chrome/120.0"})
+		return err
+	}
+	// 未配置 geoip 时无法判断，不拦截
+	if err := login("1.2.3.4"); err != nil {
+		t.Fatalf("Login() without geoip error = %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "city.mmdb")
+	if err := geoiptest.Write(path,
+		geoiptest.Network{CIDR: "1.2.3.0/24", Country: "CN", Region: map[string]string{"en": "Zhejiang"}, ASN: 4134, ASOrg: "CHINANET"},
+		geoiptest.Network{CIDR: "5.6.7.0/24", Country: "US"},
+	); err != nil {
+		t.Fatalf("geoiptest.Write() error = %v", err)
+	}
+	resolver, err := geoip.New(config.GeoIP{DBPath: path}, zap.NewNop())
+	if err != nil {
+		t.Fatalf("geoip.New() error = %v", err)
+	}
+	t.Cleanup(func() { _ = resolver.Close(context.Background()) })
+	service.(*UserService).svcCtx.GeoIP = resolver
+
+	for _, tc := range []struct {
+		ip   string
+		want error
+	}{
+		{"1.2.3.4", nil},
+		{"192.168.0.8", nil}, // 内网地址不受限制
+		{"5.6.7.8", errcode.LoginRegionForbidden},
+		{"9.9.9.9", errcode.LoginRegionForbidden}, // 查不到归属地
+	} {
+		if err := login(tc.ip); err != tc.want {
+			t.Fatalf("Login(%s) error = %v, want %v", tc.ip, err, tc.want)
+		}
+	}
+
+	// 登录日志带上归属地，被拒绝的登录记录为失败
+	var logs []model.SysLoginLog
+	if err := gormDB.Where("ip IN ?", []string{"1.2.3.4", "5.6.7.8"}).Order("id").Find(&logs).Error; err != nil {
+		t.Fatalf("find login logs error = %v", err)
+	}
+	if len(logs) != 3 {
+		t.Fatalf("login logs = %d, want 3", len(logs))
+	}
+	if logs[0].Country != "" || logs[1].Country != "CN" || logs[1].Region != "Zhejiang" || logs[1].ASN != 4134 || !logs[1].Success {
+		t.Fatalf("logs = %+v / %+v", logs[0].GeoLocation, logs[1].GeoLocation)
+	}
+	if logs[2].Country != "US" || logs[2].Success || logs[2].Reason != errcode.LoginRegionForbidden.Msg {
+		t.Fatalf("forbidden log = %+v", logs[2])
+	}
+
+	list, total, err := NewLoginLogService(nil, service.(*UserService).loginLogRepo).
+		GetLoginLogList(ctx, dto.SearchLoginLogReq{Country: "us"})
+	if err != nil || total != 1 || len(list) != 1 {
+		t.Fatalf("GetLoginLogList(country) = %d, %d, %v, want 1", len(list), total, err)
+	}
+}
@@ -0,0 +1,355 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	corejwt "github.com/CIPFZ/gowebframe/internal/core/jwt"
+	logger "github.com/CIPFZ/gowebframe/internal/core/log"
+	"github.com/CIPFZ/gowebframe/internal/core/mfa"
+	"github.com/CIPFZ/gowebframe/internal/core/passkey"
+	"github.com/CIPFZ/gowebframe/internal/modules/system/dto"
+	"github.com/CIPFZ/gowebframe/internal/modules/system/model"
+	"github.com/CIPFZ/gowebframe/pkg/utils"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+const (
+	// 同一账号在统计窗口内允许的二次验证失败次数，超过后锁定账号
+	mfaMaxAttempts   = 5
+	defaultMfaIssuer = "go-web-frame"
+	// 已换取登录的挑战令牌 (按 jti)，保留到令牌过期
+	mfaChallengeUsedKey = "mfa_challenge_used:"
+)
+
+var (
+	ErrMfaCodeInvalid    = errors.New("验证码错误")
+	ErrMfaNotEnabled     = errors.New("未启用二次验证")
+	ErrMfaAlreadyEnabled = errors.New("已启用二次验证")
+	ErrMfaNotPending     = errors.New("请先获取二次验证密钥")
+	ErrMfaRequiredByRole = errors.New("当前角色要求启用二次验证，无法关闭")
+)
+
+// mfaRequiredByRole 用户拥有的任一角色要求 MFA 即视为强制
+func mfaRequiredByRole(user *model.SysUser) bool {
+	if user.Authority.MfaRequired {
+		return true
+	}
+	for _, auth := range user.Authorities {
+		if auth.MfaRequired {
+			return true
+		}
+	}
+	return false
+}
+
+// mfaChallenge 密码校验通过后判断是否需要二次验证；需要时返回挑战令牌而不签发 token
+func (s *UserService) mfaChallenge(ctx context.Context, user *model.SysUser, method string) (*dto.LoginResponse, bool, error) {
+	purpose := ""
+	methods := s.mfaMethods(ctx, user)
+	switch {
+	case len(methods) > 0:
+		purpose = corejwt.ChallengeMfaVerify
+	case mfaRequiredByRole(user):
+		purpose = corejwt.ChallengeMfaEnroll
+	default:
+		return nil, false, nil
+	}
+
+	token, expiresAt, err := s.svcCtx.JWT.CreateChallengeToken(user.ID, purpose, method)
+	if err != nil {
+		return nil, true, err
+	}
+	return &dto.LoginResponse{
+		MfaRequired:       true,
+		MfaEnrollRequired: purpose == corejwt.ChallengeMfaEnroll,
+		MfaToken:          token,
+		MfaExpiresAt:      expiresAt.UnixMilli(),
+		MfaMethods:        methods,
+	}, true, nil
+}
+
+// completeLogin 签发 token 对并登记会话
+func (s *UserService) completeLogin(ctx context.Context, user *model.SysUser, src loginSource) (*dto.LoginResponse, error) {
+	log := logger.GetLogger(ctx)
+	// 签发 access token + refresh token (开启一个新的令牌族，令牌族ID即会话ID)
+	sessionID := uuid.NewString()
+	resp, err := s.issueTokenPair(ctx, user, sessionID)
+	if err != nil {
+		log.Error("generate_token_failed", zap.Error(err))
+		return nil, errors.New("获取Token失败")
+	}
+
+	// 登记会话；关闭多点登录时踢掉该用户的其它会话
+	if err := s.startSession(ctx, user.ID, sessionID, src.IP, src.UserAgent, time.UnixMilli(resp.RefreshExpiresAt)); err != nil {
+		log.Error("session_create_failed", zap.Error(err))
+		return nil, errors.New("获取Token失败")
+	}
+	s.clearLoginFailures(ctx, user.Username)
+	s.logLoginSuccess(ctx, user, src)
+	return resp, nil
+}
+
+// LoginMfa 登录第二步：校验挑战令牌与验证码 (或通行密钥签名) 后签发 token
+// 角色强制 MFA 的首次登录在这里完成绑定，并返回恢复码
+func (s *UserService) LoginMfa(ctx context.Context, req dto.MfaLoginReq) (*dto.LoginResponse, error) {
+	challenge, err := s.svcCtx.JWT.ParseChallengeToken(req.MfaToken)
+	if err != nil {
+		return nil, err
+	}
+	user, err := s.userRepo.FindById(ctx, challenge.UserID)
+	if err != nil {
+		return nil, corejwt.ErrChallengeInvalid
+	}
+	if err := checkUserStatus(user); err != nil {
+		return nil, err
+	}
+	if err := s.checkAccountLock(ctx, user.Username, req.IP, req.UserAgent); err != nil {
+		return nil, err
+	}
+	// 两步之间可能更换了网络，按第二步的来源重新判断
+	if err := s.checkLoginRegion(ctx, user, req.IP); err != nil {
+		s.logLoginFailure(ctx, user, "", loginSource{Method: challenge.Method, Mfa: true, IP: req.IP, UserAgent: req.UserAgent}, err)
+		return nil, err
+	}
+
+	var recoveryCodes []string
+	switch {
+	case req.PasskeyCeremonyID != "" && challenge.Purpose == corejwt.ChallengeMfaVerify:
+		err = s.verifyMfaPasskey(ctx, user, req.PasskeyCeremonyID, req.PasskeyCredential)
+	case user.MfaEnabled:
+		err = s.verifySecondFactor(ctx, user, req.Code, req.RecoveryCode)
+	case challenge.Purpose == corejwt.ChallengeMfaEnroll:
+		recoveryCodes, err = s.EnableMfa(ctx, user.ID, req.Code)
+	default:
+		// 签发挑战后用户关闭了 MFA
+		return nil, corejwt.ErrChallengeInvalid
+	}
+	if err != nil {
+		if errors.Is(err, ErrMfaCodeInvalid) || errors.Is(err, passkey.ErrVerifyFailed) {
+			s.recordMfaFailure(ctx, user, req.IP, req.UserAgent)
+		}
+		s.logLoginFailure(ctx, user, "", loginSource{Method: challenge.Method, Mfa: true, IP: req.IP, UserAgent: req.UserAgent}, err)
+		return nil, err
+	}
+	if err := s.consumeChallenge(ctx, challenge); err != nil {
+		return nil, err
+	}
+
+	resp, err := s.completeLogin(ctx, user, loginSource{Method: challenge.Method, Mfa: true, IP: req.IP, UserAgent: req.UserAgent})
+	if err != nil {
+		return nil, err
+	}
+	resp.RecoveryCodes = recoveryCodes
+	return resp, nil
+}
+
+// consumeChallenge 挑战令牌只能换取一次登录，验证通过后按 jti 标记为已使用
+func (s *UserService) consumeChallenge(ctx context.Context, challenge *corejwt.ChallengeClaims) error {
+	if s.svcCtx.Cache == nil {
+		return nil
+	}
+	ttl := time.Second
+	if challenge.ExpiresAt != nil {
+		ttl = max(time.Until(challenge.ExpiresAt.Time), ttl)
+	}
+	ok, err := s.svcCtx.Cache.SetNX(ctx, mfaChallengeUsedKey+challenge.ID, "1", ttl)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return corejwt.ErrChallengeInvalid
+	}
+	return nil
+}
+
+// SetupMfaChallenge 角色强制 MFA 但尚未绑定时，凭挑战令牌获取待绑定密钥
+func (s *UserService) SetupMfaChallenge(ctx context.Context, mfaToken string) (*dto.MfaSetupResponse, error) {
+	challenge, err := s.svcCtx.JWT.ParseChallengeToken(mfaToken)
+	if err != nil {
+		return nil, err
+	}
+	if challenge.Purpose != corejwt.ChallengeMfaEnroll {
+		return nil, corejwt.ErrChallengeInvalid
+	}
+	return s.SetupMfa(ctx, challenge.UserID)
+}
+
+// SetupMfa 生成待绑定的 TOTP 密钥，验证通过 (EnableMfa) 前不生效
+func (s *UserService) SetupMfa(ctx context.Context, userID uint) (*dto.MfaSetupResponse, error) {
+	user, err := s.userRepo.FindById(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.MfaEnabled {
+		return nil, ErrMfaAlreadyEnabled
+	}
+
+	secret, err := mfa.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.mfaRepo.SavePendingSecret(ctx, userID, secret); err != nil {
+		return nil, err
+	}
+	return &dto.MfaSetupResponse{
+		Secret: secret,
+		URI:    mfa.ProvisioningURI(s.mfaIssuer(), user.Username, secret),
+	}, nil
+}
+
+// EnableMfa 校验待绑定密钥的验证码，启用 MFA 并返回一组新的恢复码
+func (s *UserService) EnableMfa(ctx context.Context, userID uint, code string) ([]string, error) {
+	user, err := s.userRepo.FindById(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.MfaEnabled {
+		return nil, ErrMfaAlreadyEnabled
+	}
+	if user.MfaSecret == "" {
+		return nil, ErrMfaNotPending
+	}
+
+	step, ok := mfa.Validate(user.MfaSecret, code, time.Now(), user.MfaLastStep)
+	if !ok {
+		return nil, ErrMfaCodeInvalid
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.mfaRepo.Enable(ctx, userID, step, hashes); err != nil {
+		return nil, err
+	}
+	logger.GetLogger(ctx).Info("mfa_enabled", zap.Uint("userId", userID))
+	return codes, nil
+}
+
+// DisableMfa 关闭 MFA，需提交当前验证码；角色强制 MFA 时不允许关闭
+func (s *UserService) DisableMfa(ctx context.Context, userID uint, code string) error {
+	user, err := s.userRepo.FindById(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !user.MfaEnabled {
+		return ErrMfaNotEnabled
+	}
+	if mfaRequiredByRole(user) {
+		return ErrMfaRequiredByRole
+	}
+	if err := s.verifyTotp(ctx, user, code); err != nil {
+		return err
+	}
+	if err := s.mfaRepo.Disable(ctx, userID); err != nil {
+		return err
+	}
+	logger.GetLogger(ctx).Info("mfa_disabled", zap.Uint("userId", userID))
+	return nil
+}
+
+// RegenerateRecoveryCodes 重新生成恢复码，旧恢复码全部作废
+func (s *UserService) RegenerateRecoveryCodes(ctx context.Context, userID uint, code string) ([]string, error) {
+	user, err := s.userRepo.FindById(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.MfaEnabled {
+		return nil, ErrMfaNotEnabled
+	}
+	if err := s.verifyTotp(ctx, user, code); err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.mfaRepo.ReplaceRecoveryCodes(ctx, userID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// verifySecondFactor 校验 TOTP 验证码或恢复码 (二选一)
+func (s *UserService) verifySecondFactor(ctx context.Context, user *model.SysUser, code, recoveryCode string) error {
+	if recoveryCode != "" {
+		return s.useRecoveryCode(ctx, user.ID, recoveryCode)
+	}
+	return s.verifyTotp(ctx, user, code)
+}
+
+// verifyTotp 校验验证码，并原子地记录时间步，同一验证码只能使用一次
+func (s *UserService) verifyTotp(ctx context.Context, user *model.SysUser, code string) error {
+	step, ok := mfa.Validate(user.MfaSecret, code, time.Now(), user.MfaLastStep)
+	if !ok {
+		return ErrMfaCodeInvalid
+	}
+	consumed, err := s.mfaRepo.ConsumeStep(ctx, user.ID, step)
+	if err != nil {
+		return err
+	}
+	if !consumed {
+		return ErrMfaCodeInvalid
+	}
+	return nil
+}
+
+func (s *UserService) useRecoveryCode(ctx context.Context, userID uint, recoveryCode string) error {
+	normalized := mfa.NormalizeRecoveryCode(recoveryCode)
+	codes, err := s.mfaRepo.ListUnusedRecoveryCodes(ctx, userID)
+	if err != nil {
+		return err
+	}
+	for _, item := range codes {
+		if !utils.BcryptCheck(normalized, item.CodeHash) {
+			continue
+		}
+		used, err := s.mfaRepo.UseRecoveryCode(ctx, item.ID, time.Now())
+		if err != nil {
+			return err
+		}
+		if !used {
+			return ErrMfaCodeInvalid
+		}
+		logger.GetLogger(ctx).Warn("mfa_recovery_code_used",
+			zap.Uint("userId", userID),
+			zap.Int("remaining", len(codes)-1),
+		)
+		return nil
+	}
+	return ErrMfaCodeInvalid
+}
+
+func (s *UserService) mfaIssuer() string {
+	if s.svcCtx.Config != nil {
+		if s.svcCtx.Config.System.Name != "" {
+			return s.svcCtx.Config.System.Name
+		}
+		if s.svcCtx.Config.JWT.Issuer != "" {
+			return s.svcCtx.Config.JWT.Issuer
+		}
+	}
+	return defaultMfaIssuer
+}
+
+// newRecoveryCodes 生成恢复码明文 (返回给用户) 及其哈希 (落库)
+func newRecoveryCodes() ([]string, []string, error) {
+	codes, err := mfa.GenerateRecoveryCodes()
+	if err != nil {
+		return nil, nil, err
+	}
+	hashes := make([]string, 0, len(codes))
+	for _, code := range codes {
+		h, err := utils.BcryptHash(mfa.NormalizeRecoveryCode(code))
+		if err != nil {
+			return nil, nil, err
+		}
+		hashes = append(hashes, h)
+	}
+	return codes, hashes, nil
+}
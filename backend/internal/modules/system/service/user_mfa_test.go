@@ -0,0 +1,192 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/CIPFZ/gowebframe/internal/core/config"
+	corejwt "github.com/CIPFZ/gowebframe/internal/core/jwt"
+	"github.com/CIPFZ/gowebframe/internal/core/mfa"
+	"github.com/CIPFZ/gowebframe/internal/modules/system/dto"
+	"github.com/CIPFZ/gowebframe/internal/modules/system/model"
+	"github.com/CIPFZ/gowebframe/pkg/errcode"
+)
+
+func TestUserServiceMfaLoginFlow(t *testing.T) {
+	service, _ := newUserTestService(t, true)
+	enableLoginGuard(t, service, config.Captcha{OpenCaptcha: 100})
+	ctx := context.Background()
+
+	setup, err := service.SetupMfa(ctx, 1)
+	if err != nil {
+		t.Fatalf("SetupMfa() error = %v", err)
+	}
+	// 启用时使用上一个时间窗口的验证码，登录时使用当前验证码，避免触发防重放
+	prev, _ := mfa.Code(setup.Secret, time.Now().Add(-30*time.Second))
+	recoveryCodes, err := service.EnableMfa(ctx, 1, prev)
+	if err != nil {
+		t.Fatalf("EnableMfa() error = %v", err)
+	}
+	if len(recoveryCodes) != mfa.RecoveryCodeCount {
+		t.Fatalf("EnableMfa() recovery codes = %d, want %d", len(recoveryCodes), mfa.RecoveryCodeCount)
+	}
+
+	challenge := func() string {
+		t.Helper()
+		login, err := service.Login(ctx, dto.LoginReq{Username: "alice", Password: "Passw0rd!"})
+		if err != nil {
+			t.Fatalf("Login() error = %v", err)
+		}
+		return login.MfaToken
+	}
+	login, err := service.Login(ctx, dto.LoginReq{Username: "alice", Password: "Passw0rd!"})
+	if err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+	if !login.MfaRequired || login.MfaToken == "" || login.Token != "" {
+		t.Fatalf("Login() = %#v, want only an MFA challenge", login)
+	}
+	if _, err := svcJWT(service).ParseToken(login.MfaToken); err == nil {
+		t.Fatal("ParseToken() accepted an MFA challenge token as access token")
+	}
+
+	if _, err := service.LoginMfa(ctx, dto.MfaLoginReq{MfaToken: login.MfaToken, Code: "000000"}); !errors.Is(err, ErrMfaCodeInvalid) {
+		t.Fatalf("LoginMfa() with wrong code error = %v, want %v", err, ErrMfaCodeInvalid)
+	}
+
+	code, _ := mfa.Code(setup.Secret, time.Now())
+	resp, err := service.LoginMfa(ctx, dto.MfaLoginReq{MfaToken: login.MfaToken, Code: code})
+	if err != nil {
+		t.Fatalf("LoginMfa() error = %v", err)
+	}
+	if resp.Token == "" || resp.RefreshToken == "" {
+		t.Fatalf("LoginMfa() = %#v, want token pair", resp)
+	}
+
+	// 挑战令牌只能换取一次登录，即使再次提供有效的恢复码
+	if _, err := service.LoginMfa(ctx, dto.MfaLoginReq{MfaToken: login.MfaToken, RecoveryCode: recoveryCodes[2]}); !errors.Is(err, corejwt.ErrChallengeInvalid) {
+		t.Fatalf("LoginMfa() reused challenge error = %v, want %v", err, corejwt.ErrChallengeInvalid)
+	}
+
+	// 同一验证码不能重复使用
+	if _, err := service.LoginMfa(ctx, dto.MfaLoginReq{MfaToken: challenge(), Code: code}); !errors.Is(err, ErrMfaCodeInvalid) {
+		t.Fatalf("LoginMfa() replay error = %v, want %v", err, ErrMfaCodeInvalid)
+	}
+
+	// 恢复码只能使用一次
+	if _, err := service.LoginMfa(ctx, dto.MfaLoginReq{MfaToken: challenge(), RecoveryCode: recoveryCodes[0]}); err != nil {
+		t.Fatalf("LoginMfa() with recovery code error = %v", err)
+	}
+	if _, err := service.LoginMfa(ctx, dto.MfaLoginReq{MfaToken: challenge(), RecoveryCode: recoveryCodes[0]}); !errors.Is(err, ErrMfaCodeInvalid) {
+		t.Fatalf("LoginMfa() reused recovery code error = %v, want %v", err, ErrMfaCodeInvalid)
+	}
+	// 不带短横线、小写输入同样有效
+	typed := strings.ToLower(strings.ReplaceAll(recoveryCodes[1], "-", ""))
+	if _, err := service.LoginMfa(ctx, dto.MfaLoginReq{MfaToken: challenge(), RecoveryCode: typed}); err != nil {
+		t.Fatalf("LoginMfa() with recovery code without dash error = %v", err)
+	}
+}
+
+func TestUserServiceMfaFailuresLockAccountAcrossChallenges(t *testing.T) {
+	service, _ := newUserTestService(t, true)
+	enableLoginGuard(t, service, config.Captcha{OpenCaptcha: 100})
+	ctx := context.Background()
+
+	setup, err := service.SetupMfa(ctx, 1)
+	if err != nil {
+		t.Fatalf("SetupMfa() error = %v", err)
+	}
+	code, _ := mfa.Code(setup.Secret, time.Now())
+	if _, err := service.EnableMfa(ctx, 1, code); err != nil {
+		t.Fatalf("EnableMfa() error = %v", err)
+	}
+
+	// 重新输入密码换取新的挑战令牌不会重置失败次数
+	for i := 0; i < mfaMaxAttempts; i++ {
+		login, err := service.Login(ctx, dto.LoginReq{Username: "alice", Password: "Passw0rd!"})
+		if err != nil {
+			t.Fatalf("Login() attempt %d error = %v", i, err)
+		}
+		if _, err := service.LoginMfa(ctx, dto.MfaLoginReq{MfaToken: login.MfaToken, Code: "000000"}); !errors.Is(err, ErrMfaCodeInvalid) {
+			t.Fatalf("LoginMfa() attempt %d error = %v, want %v", i, err, ErrMfaCodeInvalid)
+		}
+	}
+
+	if _, err := service.Login(ctx, dto.LoginReq{Username: "alice", Password: "Passw0rd!"}); !isErrCode(err, errcode.AccountLocked) {
+		t.Fatalf("Login() after mfa failures error = %v, want account locked", err)
+	}
+}
+
+func TestUserServiceMfaLockRejectsIssuedChallenge(t *testing.T) {
+	service, _ := newUserTestService(t, true)
+	enableLoginGuard(t, service, config.Captcha{OpenCaptcha: 100})
+	ctx := context.Background()
+
+	setup, err := service.SetupMfa(ctx, 1)
+	if err != nil {
+		t.Fatalf("SetupMfa() error = %v", err)
+	}
+	code, _ := mfa.Code(setup.Secret, time.Now().Add(-30*time.Second))
+	if _, err := service.EnableMfa(ctx, 1, code); err != nil {
+		t.Fatalf("EnableMfa() error = %v", err)
+	}
+
+	login, err := service.Login(ctx, dto.LoginReq{Username: "alice", Password: "Passw0rd!"})
+	if err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+	for i := 0; i < mfaMaxAttempts; i++ {
+		_, _ = service.LoginMfa(ctx, dto.MfaLoginReq{MfaToken: login.MfaToken, Code: "000000"})
+	}
+	// 锁定后即使验证码正确也被拒绝
+	code, _ = mfa.Code(setup.Secret, time.Now())
+	if _, err := service.LoginMfa(ctx, dto.MfaLoginReq{MfaToken: login.MfaToken, Code: code}); !isErrCode(err, errcode.AccountLocked) {
+		t.Fatalf("LoginMfa() on locked account error = %v, want account locked", err)
+	}
+}
+
+func TestUserServiceMfaEnforcedByAuthority(t *testing.T) {
+	service, gormDB := newUserTestService(t, true)
+	ctx := context.Background()
+
+	authority := model.SysAuthority{AuthorityId: model.DefaultUserAuthorityID, AuthorityName: "user", MfaRequired: true}
+	if err := gormDB.Create(&authority).Error; err != nil {
+		t.Fatalf("seed authority error = %v", err)
+	}
+	user := model.SysUser{}
+	user.ID = 1
+	if err := gormDB.Model(&user).Association("Authorities").Append(&authority); err != nil {
+		t.Fatalf("append authority error = %v", err)
+	}
+
+	login, err := service.Login(ctx, dto.LoginReq{Username: "alice", Password: "Passw0rd!"})
+	if err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+	if !login.MfaEnrollRequired || login.MfaToken == "" || login.Token != "" {
+		t.Fatalf("Login() = %#v, want MFA enrollment challenge", login)
+	}
+
+	setup, err := service.SetupMfaChallenge(ctx, login.MfaToken)
+	if err != nil {
+		t.Fatalf("SetupMfaChallenge() error = %v", err)
+	}
+	code, _ := mfa.Code(setup.Secret, time.Now())
+	resp, err := service.LoginMfa(ctx, dto.MfaLoginReq{MfaToken: login.MfaToken, Code: code})
+	if err != nil {
+		t.Fatalf("LoginMfa() error = %v", err)
+	}
+	if resp.Token == "" || len(resp.RecoveryCodes) != mfa.RecoveryCodeCount {
+		t.Fatalf("LoginMfa() = %#v, want token and recovery codes", resp)
+	}
+
+	if err := service.DisableMfa(ctx, 1, code); !errors.Is(err, ErrMfaRequiredByRole) {
+		t.Fatalf("DisableMfa() error = %v, want %v", err, ErrMfaRequiredByRole)
+	}
+	if _, err := service.SetupMfaChallenge(ctx, "not-a-token"); !errors.Is(err, corejwt.ErrChallengeInvalid) {
+		t.Fatalf("SetupMfaChallenge() error = %v, want %v", err, corejwt.ErrChallengeInvalid)
+	}
+}
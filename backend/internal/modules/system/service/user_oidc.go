@@ -0,0 +1,148 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/CIPFZ/gowebframe/internal/core/cache"
+	logger "github.com/CIPFZ/gowebframe/internal/core/log"
+	"github.com/CIPFZ/gowebframe/internal/core/oidc"
+	"github.com/CIPFZ/gowebframe/internal/modules/system/dto"
+
+	"go.uber.org/zap"
+)
+
+// 单点登录回调完成后，登录结果以一次性票据交给前端，避免 token 出现在重定向地址中
+const (
+	oidcTicketKey         = "oidc_ticket:"
+	oidcTicketExpiresTime = time.Minute
+)
+
+var (
+	ErrOidcDisabled       = errors.New("未启用单点登录")
+	ErrOidcTicketInvalid  = errors.New("登录票据已失效，请重新登录")
+	ErrOidcProviderDenied = errors.New("身份提供方拒绝了登录请求")
+)
+
+// OidcProviders 返回登录页可用的单点登录方式
+func (s *UserService) OidcProviders() []oidc.ProviderInfo {
+	if s.svcCtx.OIDC == nil {
+		return []oidc.ProviderInfo{}
+	}
+	return s.svcCtx.OIDC.Providers()
+}
+
+// OidcAuthURL 生成 IdP 授权地址 (授权码 + PKCE)
+// 同时返回本次授权的 state，由调用方绑定到发起登录的浏览器
+func (s *UserService) OidcAuthURL(ctx context.Context, provider, redirect string) (string, string, error) {
+	if s.svcCtx.OIDC == nil {
+		return "", "", ErrOidcDisabled
+	}
+	return s.svcCtx.OIDC.AuthURL(ctx, provider, redirect)
+}
+
+// OidcCallback 处理 IdP 回调：换取并校验 id_token，按需自动开通用户、同步角色，然后走正常登录流程
+// 返回一次性票据与前端跳转路径
+func (s *UserService) OidcCallback(ctx context.Context, req dto.OidcCallbackReq) (string, string, error) {
+	log := logger.GetLogger(ctx)
+	if s.svcCtx.OIDC == nil || s.svcCtx.Cache == nil {
+		return "", "", ErrOidcDisabled
+	}
+	cfg, ok := s.svcCtx.OIDC.Provider(req.Provider)
+	if !ok {
+		return "", "", oidc.ErrUnknownProvider
+	}
+	if req.Error != "" {
+		return "", "", fmt.Errorf("%w: %s %s", ErrOidcProviderDenied, req.Error, req.ErrorDescription)
+	}
+
+	identity, redirect, err := s.svcCtx.OIDC.Exchange(ctx, req.Provider, req.Code, req.State, req.BoundState)
+	if err != nil {
+		log.Warn("oidc_exchange_failed", zap.String("provider", req.Provider), zap.Error(err))
+		return "", "", err
+	}
+
+	src := loginSource{Method: oidcLoginMethod(identity.Provider), IP: req.IP, UserAgent: req.UserAgent}
+	user, err := s.externalUser(ctx, externalAccount{
+		Provider: identity.Provider,
+		Subject:  identity.Subject,
+		Username: identity.Username,
+		Email:    identity.Email,
+		Name:     identity.Name,
+		Groups:   identity.Groups,
+	}, cfg.Provisioning)
+	if err != nil {
+		log.Warn("oidc_login_rejected",
+			zap.String("provider", identity.Provider),
+			zap.String("subject", identity.Subject),
+			zap.Error(err),
+		)
+		s.logLoginFailure(ctx, nil, identity.Username, src, err)
+		return "", redirect, err
+	}
+	if err = checkUserStatus(user); err == nil {
+		err = s.checkLoginRegion(ctx, user, src.IP)
+	}
+	if err != nil {
+		s.logLoginFailure(ctx, user, "", src, err)
+		return "", redirect, err
+	}
+
+	// 与账号密码登录一致：启用或被角色强制 MFA 时仍需完成二次验证
+	resp, required, err := s.mfaChallenge(ctx, user, src.Method)
+	if err != nil {
+		log.Error("mfa_challenge_failed", zap.Error(err))
+		return "", redirect, errors.New("获取Token失败")
+	}
+	if !required {
+		if resp, err = s.completeLogin(ctx, user, src); err != nil {
+			return "", redirect, err
+		}
+	}
+
+	ticket, err := s.saveOidcTicket(ctx, resp)
+	if err != nil {
+		log.Error("oidc_ticket_save_failed", zap.Error(err))
+		return "", redirect, errors.New("获取Token失败")
+	}
+	log.Info("oidc_login_success",
+		zap.String("provider", identity.Provider),
+		zap.Uint("userId", user.ID),
+	)
+	return ticket, redirect, nil
+}
+
+// OidcExchangeTicket 前端使用一次性票据换取登录结果
+func (s *UserService) OidcExchangeTicket(ctx context.Context, ticket string) (*dto.LoginResponse, error) {
+	if s.svcCtx.Cache == nil {
+		return nil, ErrOidcDisabled
+	}
+	raw, err := s.svcCtx.Cache.GetDel(ctx, oidcTicketKey+ticket)
+	if err != nil {
+		if errors.Is(err, cache.ErrNotFound) {
+			return nil, ErrOidcTicketInvalid
+		}
+		return nil, err
+	}
+	var resp dto.LoginResponse
+	if err := json.Unmarshal([]byte(raw), &resp); err != nil {
+		return nil, ErrOidcTicketInvalid
+	}
+	return &resp, nil
+}
+
+func (s *UserService) saveOidcTicket(ctx context.Context, resp *dto.LoginResponse) (string, error) {
+	raw, err := json.Marshal(resp)
+	if err != nil {
+		return "", err
+	}
+	ticket := rand.Text()
+	if err := s.svcCtx.Cache.Set(ctx, oidcTicketKey+ticket, string(raw), oidcTicketExpiresTime); err != nil {
+		return "", err
+	}
+	return ticket, nil
+}
@@ -0,0 +1,165 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/CIPFZ/gowebframe/internal/core/cache"
+	"github.com/CIPFZ/gowebframe/internal/core/config"
+	"github.com/CIPFZ/gowebframe/internal/core/oidc"
+	"github.com/CIPFZ/gowebframe/internal/core/oidc/oidctest"
+	"github.com/CIPFZ/gowebframe/internal/modules/system/dto"
+	"github.com/CIPFZ/gowebframe/internal/modules/system/model"
+	"gorm.io/gorm"
+)
+
+// enableOidc 为测试服务接入进程内的模拟 IdP
+func enableOidc(t *testing.T, service IUserService, idp *oidctest.Server, provider config.OIDCProvider) {
+	t.Helper()
+	store := cache.NewMemoryStore()
+	t.Cleanup(func() { _ = store.Close(context.Background()) })
+
+	provider.Name = "mock"
+	provider.Issuer = idp.Issuer()
+	provider.ClientID = oidctest.ClientID
+	provider.ClientSecret = oidctest.ClientSecret
+	provider.RedirectURL = "http://app.local/api/v1/user/oidc/mock/callback"
+
+	us := service.(*UserService)
+	us.svcCtx.Cache = store
+	us.svcCtx.OIDC = oidc.NewManager(config.OIDC{Providers: []config.OIDCProvider{provider}}, store)
+}
+
+// oidcLogin 走完整的授权码流程并用票据换取登录结果
+func oidcLogin(t *testing.T, service IUserService, idp *oidctest.Server) (*dto.LoginResponse, error) {
+	t.Helper()
+	ctx := context.Background()
+	authURL, state, err := service.OidcAuthURL(ctx, "mock", "/welcome")
+	if err != nil {
+		t.Fatalf("OidcAuthURL() error = %v", err)
+	}
+	callback, err := idp.Authorize(authURL)
+	if err != nil {
+		t.Fatalf("Authorize() error = %v", err)
+	}
+	ticket, redirect, err := service.OidcCallback(ctx, dto.OidcCallbackReq{
+		Provider:   "mock",
+		Code:       callback.Query().Get("code"),
+		State:      callback.Query().Get("state"),
+		BoundState: state,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if redirect != "/welcome" {
+		t.Fatalf("OidcCallback() redirect = %q, want /welcome", redirect)
+	}
+	resp, err := service.OidcExchangeTicket(ctx, ticket)
+	if err != nil {
+		t.Fatalf("OidcExchangeTicket() error = %v", err)
+	}
+	// 票据只能使用一次
+	if _, err := service.OidcExchangeTicket(ctx, ticket); !errors.Is(err, ErrOidcTicketInvalid) {
+		t.Fatalf("OidcExchangeTicket() replay error = %v, want %v", err, ErrOidcTicketInvalid)
+	}
+	return resp, nil
+}
+
+func seedAuthorities(t *testing.T, gormDB *gorm.DB, ids ...uint) {
+	t.Helper()
+	for _, id := range ids {
+		if err := gormDB.Create(&model.SysAuthority{AuthorityId: id, AuthorityName: "role"}).Error; err != nil {
+			t.Fatalf("seed authority error = %v", err)
+		}
+	}
+}
+
+func TestUserServiceOidcProvisionsAndSyncsAuthorities(t *testing.T) {
+	service, gormDB := newUserTestService(t, true)
+	seedAuthorities(t, gormDB, 1, 2, 3)
+	idp := oidctest.NewServer()
+	defer idp.Close()
+	enableOidc(t, service, idp, config.OIDCProvider{Provisioning: config.Provisioning{
+		AutoCreate:         true,
+		DefaultAuthorityID: model.DefaultUserAuthorityID,
+		GroupMappings: []config.GroupMapping{
+			{Group: "admins", AuthorityID: 1},
+			{Group: "ops", AuthorityID: 3},
+		},
+	}})
+
+	// 用户名与本地账号 alice 冲突，不能绑定到已有账号
+	idp.SetUser(map[string]any{"sub": "sso-1", "preferred_username": "alice", "email": "alice@sso.example", "groups": []string{"ops", "admins"}})
+	resp, err := oidcLogin(t, service, idp)
+	if err != nil {
+		t.Fatalf("oidc login error = %v", err)
+	}
+	claims, err := svcJWT(service).ParseToken(resp.Token)
+	if err != nil {
+		t.Fatalf("ParseToken() error = %v", err)
+	}
+	if claims.Username != "alice@mock" || claims.UserID == 1 {
+		t.Fatalf("token claims = %+v, want provisioned user alice@mock", claims.BaseClaims)
+	}
+
+	var user model.SysUser
+	if err := gormDB.Preload("Authorities").First(&user, claims.UserID).Error; err != nil {
+		t.Fatalf("load provisioned user error = %v", err)
+	}
+	if user.AuthorityID != 1 || len(user.Authorities) != 2 || user.Email != "alice@sso.example" {
+		t.Fatalf("provisioned user = %+v, want authorities [1 3]", user)
+	}
+
+	// 再次登录：组变化后角色同步，不会重复开通
+	idp.SetUser(map[string]any{"sub": "sso-1", "preferred_username": "alice", "groups": []string{"ops"}})
+	resp, err = oidcLogin(t, service, idp)
+	if err != nil {
+		t.Fatalf("second oidc login error = %v", err)
+	}
+	claims, _ = svcJWT(service).ParseToken(resp.Token)
+	if claims.AuthorityId != 3 {
+		t.Fatalf("AuthorityId = %d, want 3 after sync", claims.AuthorityId)
+	}
+	var count int64
+	gormDB.Model(&model.SysUser{}).Count(&count)
+	if count != 2 {
+		t.Fatalf("user count = %d, want 2", count)
+	}
+
+	// 未命中映射的新用户使用默认角色
+	idp.SetUser(map[string]any{"sub": "sso-2", "email": "carol@sso.example"})
+	resp, err = oidcLogin(t, service, idp)
+	if err != nil {
+		t.Fatalf("default authority login error = %v", err)
+	}
+	claims, _ = svcJWT(service).ParseToken(resp.Token)
+	if claims.Username != "carol@sso.example" || claims.AuthorityId != model.DefaultUserAuthorityID {
+		t.Fatalf("token claims = %+v, want default authority", claims.BaseClaims)
+	}
+}
+
+func TestUserServiceOidcRejectsUnknownUserWithoutAutoCreate(t *testing.T) {
+	service, gormDB := newUserTestService(t, true)
+	idp := oidctest.NewServer()
+	defer idp.Close()
+	enableOidc(t, service, idp, config.OIDCProvider{})
+
+	idp.SetUser(map[string]any{"sub": "sso-1", "preferred_username": "dave"})
+	if _, err := oidcLogin(t, service, idp); !errors.Is(err, ErrAccountNotProvisioned) {
+		t.Fatalf("oidc login error = %v, want %v", err, ErrAccountNotProvisioned)
+	}
+
+	// 管理员绑定后可以登录到已有账号
+	if err := gormDB.Create(&model.SysUserIdentity{UserID: 1, Provider: "mock", Subject: "sso-1"}).Error; err != nil {
+		t.Fatalf("bind identity error = %v", err)
+	}
+	resp, err := oidcLogin(t, service, idp)
+	if err != nil {
+		t.Fatalf("oidc login error = %v", err)
+	}
+	claims, err := svcJWT(service).ParseToken(resp.Token)
+	if err != nil || claims.UserID != 1 {
+		t.Fatalf("ParseToken() = %+v, %v, want alice", claims, err)
+	}
+}
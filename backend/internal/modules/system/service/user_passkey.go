@@ -0,0 +1,381 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	corejwt "github.com/CIPFZ/gowebframe/internal/core/jwt"
+	logger "github.com/CIPFZ/gowebframe/internal/core/log"
+	"github.com/CIPFZ/gowebframe/internal/core/passkey"
+	"github.com/CIPFZ/gowebframe/internal/core/session"
+	"github.com/CIPFZ/gowebframe/internal/modules/system/dto"
+	"github.com/CIPFZ/gowebframe/internal/modules/system/model"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const maxPasskeysPerUser = 10
+
+// 二次验证方式，登录返回的 mfaMethods
+const (
+	mfaMethodTotp    = "totp"
+	mfaMethodPasskey = "passkey"
+)
+
+var (
+	ErrPasskeyDisabled = errors.New("未启用通行密钥")
+	ErrPasskeyNotFound = errors.New("通行密钥不存在")
+	ErrPasskeyLimit    = fmt.Errorf("每个账号最多注册 %d 个通行密钥", maxPasskeysPerUser)
+)
+
+// passkeyUser 将本地用户与其通行密钥适配为 webauthn.User
+// user handle 使用用户 UUID，不暴露自增 ID 与账号
+type passkeyUser struct {
+	user     *model.SysUser
+	passkeys []model.SysUserPasskey
+}
+
+func (u *passkeyUser) WebAuthnID() []byte {
+	id := u.user.UUID
+	return id[:]
+}
+
+func (u *passkeyUser) WebAuthnName() string {
+	return u.user.Username
+}
+
+func (u *passkeyUser) WebAuthnDisplayName() string {
+	if u.user.NickName != "" {
+		return u.user.NickName
+	}
+	return u.user.Username
+}
+
+func (u *passkeyUser) WebAuthnCredentials() []webauthn.Credential {
+	list := make([]webauthn.Credential, 0, len(u.passkeys))
+	for _, p := range u.passkeys {
+		list = append(list, toWebAuthnCredential(p))
+	}
+	return list
+}
+
+func toWebAuthnCredential(p model.SysUserPasskey) webauthn.Credential {
+	id, _ := base64.RawURLEncoding.DecodeString(p.CredentialID)
+	var transports []protocol.AuthenticatorTransport
+	for _, t := range strings.Split(p.Transports, ",") {
+		if t != "" {
+			transports = append(transports, protocol.AuthenticatorTransport(t))
+		}
+	}
+	var aaguid []byte
+	if parsed, err := uuid.Parse(p.AAGUID); err == nil {
+		aaguid = parsed[:]
+	}
+	return webauthn.Credential{
+		ID:              id,
+		PublicKey:       p.PublicKey,
+		AttestationType: p.AttestationType,
+		Transport:       transports,
+		Flags:           webauthn.CredentialFlags{BackupEligible: p.BackupEligible, BackupState: p.BackupState},
+		Authenticator:   webauthn.Authenticator{AAGUID: aaguid, SignCount: p.SignCount},
+	}
+}
+
+func (s *UserService) passkeyEnabled() bool {
+	return s.svcCtx.Passkey != nil && s.passkeyRepo != nil
+}
+
+func (s *UserService) loadPasskeyUser(ctx context.Context, user *model.SysUser) (*passkeyUser, error) {
+	list, err := s.passkeyRepo.ListByUser(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &passkeyUser{user: user, passkeys: list}, nil
+}
+
+// mfaMethods 用户可用的二次验证方式；注册了通行密钥即视为启用二次验证
+func (s *UserService) mfaMethods(ctx context.Context, user *model.SysUser) []string {
+	var methods []string
+	if user.MfaEnabled {
+		methods = append(methods, mfaMethodTotp)
+	}
+	if s.passkeyEnabled() {
+		count, err := s.passkeyRepo.CountByUser(ctx, user.ID)
+		if err != nil {
+			logger.GetLogger(ctx).Error("passkey_count_failed", zap.Uint("userId", user.ID), zap.Error(err))
+		}
+		if count > 0 {
+			methods = append(methods, mfaMethodPasskey)
+		}
+	}
+	return methods
+}
+
+// BeginPasskeyRegistration 生成注册参数，前端据此调用 navigator.credentials.create()
+func (s *UserService) BeginPasskeyRegistration(ctx context.Context, userID uint) (*dto.PasskeyOptionsResponse, error) {
+	if !s.passkeyEnabled() {
+		return nil, ErrPasskeyDisabled
+	}
+	user, err := s.userRepo.FindById(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	pu, err := s.loadPasskeyUser(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	if len(pu.passkeys) >= maxPasskeysPerUser {
+		return nil, ErrPasskeyLimit
+	}
+	creation, ceremonyID, err := s.svcCtx.Passkey.BeginRegistration(ctx, pu)
+	if err != nil {
+		return nil, err
+	}
+	return &dto.PasskeyOptionsResponse{CeremonyID: ceremonyID, Options: creation}, nil
+}
+
+// FinishPasskeyRegistration 校验注册结果并保存凭证
+func (s *UserService) FinishPasskeyRegistration(ctx context.Context, userID uint, req dto.PasskeyRegisterReq) (*model.SysUserPasskey, error) {
+	if !s.passkeyEnabled() {
+		return nil, ErrPasskeyDisabled
+	}
+	user, err := s.userRepo.FindById(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	pu, err := s.loadPasskeyUser(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	if len(pu.passkeys) >= maxPasskeysPerUser {
+		return nil, ErrPasskeyLimit
+	}
+	cred, err := s.svcCtx.Passkey.FinishRegistration(ctx, pu, req.CeremonyID, req.Credential)
+	if err != nil {
+		return nil, passkeyError(ctx, err)
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		name = session.DeviceFromUserAgent(req.UserAgent)
+	}
+	transports := make([]string, 0, len(cred.Transport))
+	for _, t := range cred.Transport {
+		transports = append(transports, string(t))
+	}
+	item := &model.SysUserPasskey{
+		UserID:          userID,
+		Name:            truncateRunes(name, 64),
+		CredentialID:    base64.RawURLEncoding.EncodeToString(cred.ID),
+		PublicKey:       cred.PublicKey,
+		AttestationType: cred.AttestationType,
+		Transports:      truncateRunes(strings.Join(transports, ","), 128),
+		SignCount:       cred.Authenticator.SignCount,
+		BackupEligible:  cred.Flags.BackupEligible,
+		BackupState:     cred.Flags.BackupState,
+	}
+	if aaguid, err := uuid.FromBytes(cred.Authenticator.AAGUID); err == nil {
+		item.AAGUID = aaguid.String()
+	}
+	if err := s.passkeyRepo.Create(ctx, item); err != nil {
+		return nil, err
+	}
+	logger.GetLogger(ctx).Info("passkey_registered", zap.Uint("userId", userID), zap.Uint("passkeyId", item.ID))
+	return item, nil
+}
+
+// ListPasskeys 用户已注册的通行密钥
+func (s *UserService) ListPasskeys(ctx context.Context, userID uint) ([]model.SysUserPasskey, error) {
+	if s.passkeyRepo == nil {
+		return nil, nil
+	}
+	return s.passkeyRepo.ListByUser(ctx, userID)
+}
+
+// RevokePasskey 用户删除自己的通行密钥
+func (s *UserService) RevokePasskey(ctx context.Context, userID, id uint) error {
+	item, err := s.findPasskey(ctx, id)
+	if err != nil {
+		return err
+	}
+	if item.UserID != userID {
+		return ErrPasskeyNotFound
+	}
+	return s.deletePasskey(ctx, item)
+}
+
+// RevokeUserPasskey 管理员吊销任意用户的通行密钥 (如设备丢失)，并通知该用户
+func (s *UserService) RevokeUserPasskey(ctx context.Context, id uint) error {
+	item, err := s.findPasskey(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.deletePasskey(ctx, item); err != nil {
+		return err
+	}
+	s.notifyUsers(ctx, 0, "通行密钥已被移除", fmt.Sprintf(
+		"管理员移除了您账号上的通行密钥「%s」，该设备将无法再用于登录。\n如有疑问，请联系管理员。", item.Name,
+	), []uint{item.UserID})
+	return nil
+}
+
+func (s *UserService) findPasskey(ctx context.Context, id uint) (*model.SysUserPasskey, error) {
+	if s.passkeyRepo == nil {
+		return nil, ErrPasskeyNotFound
+	}
+	item, err := s.passkeyRepo.FindByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPasskeyNotFound
+		}
+		return nil, err
+	}
+	return item, nil
+}
+
+func (s *UserService) deletePasskey(ctx context.Context, item *model.SysUserPasskey) error {
+	if err := s.passkeyRepo.Delete(ctx, item.ID); err != nil {
+		return err
+	}
+	logger.GetLogger(ctx).Info("passkey_revoked", zap.Uint("userId", item.UserID), zap.Uint("passkeyId", item.ID))
+	return nil
+}
+
+// BeginPasskeyLogin 免密码登录第一步：生成不限定账号的登录参数，由验证器选择凭证
+func (s *UserService) BeginPasskeyLogin(ctx context.Context) (*dto.PasskeyOptionsResponse, error) {
+	if !s.passkeyEnabled() {
+		return nil, ErrPasskeyDisabled
+	}
+	assertion, ceremonyID, err := s.svcCtx.Passkey.BeginLogin(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &dto.PasskeyOptionsResponse{CeremonyID: ceremonyID, Options: assertion}, nil
+}
+
+// LoginPasskey 免密码登录第二步：校验签名后直接签发 token
+// 免密码登录要求验证器验证了用户身份 (生物识别或 PIN)，同时满足"持有"与"知道/生物"两个因素，不再要求二次验证
+func (s *UserService) LoginPasskey(ctx context.Context, req dto.PasskeyLoginReq) (*dto.LoginResponse, error) {
+	src := loginSource{Method: model.LoginMethodPasskey, Mfa: true, IP: req.IP, UserAgent: req.UserAgent}
+	if !s.passkeyEnabled() {
+		return nil, ErrPasskeyDisabled
+	}
+	user, err := s.verifyPasskey(ctx, req.CeremonyID, req.Credential)
+	if err == nil {
+		err = checkUserStatus(user)
+	}
+	if err == nil {
+		err = s.checkLoginRegion(ctx, user, req.IP)
+	}
+	if err != nil {
+		s.logLoginFailure(ctx, user, "", src, err)
+		return nil, err
+	}
+	return s.completeLogin(ctx, user, src)
+}
+
+// BeginMfaPasskey 二次验证使用通行密钥：凭挑战令牌获取只包含该用户凭证的登录参数
+func (s *UserService) BeginMfaPasskey(ctx context.Context, mfaToken string) (*dto.PasskeyOptionsResponse, error) {
+	if !s.passkeyEnabled() {
+		return nil, ErrPasskeyDisabled
+	}
+	challenge, err := s.svcCtx.JWT.ParseChallengeToken(mfaToken)
+	if err != nil {
+		return nil, err
+	}
+	if challenge.Purpose != corejwt.ChallengeMfaVerify {
+		return nil, corejwt.ErrChallengeInvalid
+	}
+	user, err := s.userRepo.FindById(ctx, challenge.UserID)
+	if err != nil {
+		return nil, corejwt.ErrChallengeInvalid
+	}
+	if err := s.checkAccountLock(ctx, user.Username, "", ""); err != nil {
+		return nil, err
+	}
+	pu, err := s.loadPasskeyUser(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	if len(pu.passkeys) == 0 {
+		return nil, ErrPasskeyNotFound
+	}
+	assertion, ceremonyID, err := s.svcCtx.Passkey.BeginLogin(ctx, pu)
+	if err != nil {
+		return nil, err
+	}
+	return &dto.PasskeyOptionsResponse{CeremonyID: ceremonyID, Options: assertion}, nil
+}
+
+// verifyMfaPasskey 校验二次验证的通行密钥签名属于挑战令牌对应的用户
+func (s *UserService) verifyMfaPasskey(ctx context.Context, user *model.SysUser, ceremonyID string, credential []byte) error {
+	if !s.passkeyEnabled() {
+		return ErrPasskeyDisabled
+	}
+	signer, err := s.verifyPasskey(ctx, ceremonyID, credential)
+	if err != nil {
+		return err
+	}
+	if signer.ID != user.ID {
+		return passkey.ErrVerifyFailed
+	}
+	return nil
+}
+
+// verifyPasskey 校验签名并更新签名计数，返回凭证所属用户
+// 签名计数回退 (凭证可能被复制) 时拒绝登录，并保留原计数
+func (s *UserService) verifyPasskey(ctx context.Context, ceremonyID string, credential []byte) (*model.SysUser, error) {
+	signer, cred, err := s.svcCtx.Passkey.FinishLogin(ctx, ceremonyID, credential, func(userHandle []byte) (webauthn.User, error) {
+		id, err := uuid.FromBytes(userHandle)
+		if err != nil {
+			return nil, err
+		}
+		user, err := s.userRepo.FindByUuid(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		return s.loadPasskeyUser(ctx, user)
+	})
+	var user *model.SysUser
+	if pu, ok := signer.(*passkeyUser); ok {
+		user = pu.user
+	}
+	if err != nil {
+		if errors.Is(err, passkey.ErrCloneDetected) && user != nil {
+			logger.GetLogger(ctx).Warn("passkey_clone_detected",
+				zap.Uint("userId", user.ID),
+				zap.String("credentialId", base64.RawURLEncoding.EncodeToString(cred.ID)),
+			)
+		}
+		return user, passkeyError(ctx, err)
+	}
+
+	credentialID := base64.RawURLEncoding.EncodeToString(cred.ID)
+	for _, item := range signer.(*passkeyUser).passkeys {
+		if item.CredentialID != credentialID {
+			continue
+		}
+		if err := s.passkeyRepo.UpdateUsage(ctx, item.ID, cred.Authenticator.SignCount, cred.Flags.BackupState, time.Now()); err != nil {
+			logger.GetLogger(ctx).Error("passkey_usage_update_failed", zap.Uint("passkeyId", item.ID), zap.Error(err))
+		}
+		break
+	}
+	return user, nil
+}
+
+// passkeyError 协议层的详细错误只写日志，返回给前端统一的提示
+func passkeyError(ctx context.Context, err error) error {
+	if errors.Is(err, passkey.ErrVerifyFailed) {
+		logger.GetLogger(ctx).Warn("passkey_verify_failed", zap.Error(err))
+		return passkey.ErrVerifyFailed
+	}
+	return err
+}
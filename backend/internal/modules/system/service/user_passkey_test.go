@@ -0,0 +1,204 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/CIPFZ/gowebframe/internal/core/cache"
+	"github.com/CIPFZ/gowebframe/internal/core/config"
+	"github.com/CIPFZ/gowebframe/internal/core/passkey"
+	"github.com/CIPFZ/gowebframe/internal/core/passkey/passkeytest"
+	"github.com/CIPFZ/gowebframe/internal/modules/system/dto"
+	"github.com/CIPFZ/gowebframe/internal/modules/system/model"
+	"github.com/go-webauthn/webauthn/protocol"
+)
+
+const (
+	passkeyOrigin = "https://app.local"
+	passkeyUA     = "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0 Safari/537.36"
+)
+
+func enablePasskey(t *testing.T, service IUserService) *passkeytest.Authenticator {
+	t.Helper()
+	store := cache.NewMemoryStore()
+	t.Cleanup(func() { _ = store.Close(context.Background()) })
+	m, err := passkey.NewManager(config.Passkey{RPID: "app.local", RPOrigins: []string{passkeyOrigin}}, store)
+	if err != nil {
+		t.Fatalf("NewManager() error = %v", err)
+	}
+	service.(*UserService).svcCtx.Passkey = m
+	return passkeytest.New(passkeyOrigin)
+}
+
+func registerPasskey(t *testing.T, service IUserService, auth *passkeytest.Authenticator, userID uint, name string) *model.SysUserPasskey {
+	t.Helper()
+	ctx := context.Background()
+	options, err := service.BeginPasskeyRegistration(ctx, userID)
+	if err != nil {
+		t.Fatalf("BeginPasskeyRegistration() error = %v", err)
+	}
+	body, err := auth.Register(options.Options.(*protocol.CredentialCreation))
+	if err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	item, err := service.FinishPasskeyRegistration(ctx, userID, dto.PasskeyRegisterReq{CeremonyID: options.CeremonyID, Name: name, Credential: body, UserAgent: passkeyUA})
+	if err != nil {
+		t.Fatalf("FinishPasskeyRegistration() error = %v", err)
+	}
+	return item
+}
+
+func loginPasskey(service IUserService, auth *passkeytest.Authenticator) (*dto.LoginResponse, error) {
+	ctx := context.Background()
+	options, err := service.BeginPasskeyLogin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	body, err := auth.Login(options.Options.(*protocol.CredentialAssertion))
+	if err != nil {
+		return nil, err
+	}
+	return service.LoginPasskey(ctx, dto.PasskeyLoginReq{CeremonyID: options.CeremonyID, Credential: body, UserAgent: passkeyUA})
+}
+
+func TestUserServicePasskeyLogin(t *testing.T) {
+	service, gormDB := newUserTestService(t, true)
+	ctx := context.Background()
+
+	if _, err := service.BeginPasskeyLogin(ctx); !errors.Is(err, ErrPasskeyDisabled) {
+		t.Fatalf("BeginPasskeyLogin() without config error = %v, want %v", err, ErrPasskeyDisabled)
+	}
+	auth := enablePasskey(t, service)
+
+	item := registerPasskey(t, service, auth, 1, "")
+	if item.Name != "Chrome on macOS" || item.CredentialID == "" || len(item.PublicKey) == 0 {
+		t.Fatalf("FinishPasskeyRegistration() = %+v", item)
+	}
+
+	// 免密码登录：验证了用户身份的通行密钥不再要求二次验证
+	resp, err := loginPasskey(service, auth)
+	if err != nil {
+		t.Fatalf("LoginPasskey() error = %v", err)
+	}
+	if resp.Token == "" || resp.RefreshToken == "" || resp.MfaRequired {
+		t.Fatalf("LoginPasskey() = %#v, want token pair", resp)
+	}
+	var entry model.SysLoginLog
+	if err := gormDB.Order("id DESC").First(&entry).Error; err != nil {
+		t.Fatalf("query login log error = %v", err)
+	}
+	if !entry.Success || entry.Method != model.LoginMethodPasskey || !entry.Mfa || entry.UserID != 1 {
+		t.Fatalf("login log = %+v, want successful passkey login", entry)
+	}
+
+	list, err := service.ListPasskeys(ctx, 1)
+	if err != nil || len(list) != 1 {
+		t.Fatalf("ListPasskeys() = %+v, %v", list, err)
+	}
+	if list[0].SignCount != 1 || list[0].LastUsedAt == nil {
+		t.Fatalf("passkey after login = %+v, want sign count 1 and last used time", list[0])
+	}
+
+	// 账号被禁用后不能再用通行密钥登录
+	if err := gormDB.Model(&model.SysUser{}).Where("id = 1").Update("status", model.UserInactive).Error; err != nil {
+		t.Fatalf("disable user error = %v", err)
+	}
+	if _, err := loginPasskey(service, auth); !errors.Is(err, ErrUserDisabled) {
+		t.Fatalf("LoginPasskey() disabled user error = %v, want %v", err, ErrUserDisabled)
+	}
+}
+
+func TestUserServicePasskeyAsSecondFactor(t *testing.T) {
+	service, _ := newUserTestService(t, true)
+	ctx := context.Background()
+	auth := enablePasskey(t, service)
+	registerPasskey(t, service, auth, 1, "YubiKey")
+
+	// 注册通行密钥后，密码登录需要二次验证
+	login, err := service.Login(ctx, dto.LoginReq{Username: "alice", Password: "Passw0rd!"})
+	if err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+	if !login.MfaRequired || login.MfaEnrollRequired || !reflect.DeepEqual(login.MfaMethods, []string{mfaMethodPasskey}) {
+		t.Fatalf("Login() = %#v, want passkey MFA challenge", login)
+	}
+
+	// 安全密钥通常只验证在场，二次验证不要求验证用户身份
+	auth.UserVerified = false
+	options, err := service.BeginMfaPasskey(ctx, login.MfaToken)
+	if err != nil {
+		t.Fatalf("BeginMfaPasskey() error = %v", err)
+	}
+	assertion := options.Options.(*protocol.CredentialAssertion)
+	if len(assertion.Response.AllowedCredentials) != 1 {
+		t.Fatalf("allowCredentials = %d, want 1", len(assertion.Response.AllowedCredentials))
+	}
+	body, err := auth.Login(assertion)
+	if err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+	resp, err := service.LoginMfa(ctx, dto.MfaLoginReq{MfaToken: login.MfaToken, PasskeyCeremonyID: options.CeremonyID, PasskeyCredential: body})
+	if err != nil {
+		t.Fatalf("LoginMfa() error = %v", err)
+	}
+	if resp.Token == "" {
+		t.Fatalf("LoginMfa() = %#v, want token", resp)
+	}
+
+	// 挑战只能使用一次
+	if _, err := service.LoginMfa(ctx, dto.MfaLoginReq{MfaToken: login.MfaToken, PasskeyCeremonyID: options.CeremonyID, PasskeyCredential: body}); !errors.Is(err, passkey.ErrCeremonyInvalid) {
+		t.Fatalf("LoginMfa() replay error = %v, want %v", err, passkey.ErrCeremonyInvalid)
+	}
+
+	// 未验证用户身份的签名不能用于免密码登录
+	if _, err := loginPasskey(service, auth); !errors.Is(err, passkey.ErrVerifyFailed) {
+		t.Fatalf("LoginPasskey() without user verification error = %v, want %v", err, passkey.ErrVerifyFailed)
+	}
+}
+
+func TestUserServicePasskeyCloneAndRevoke(t *testing.T) {
+	service, gormDB := newUserTestService(t, true)
+	ctx := context.Background()
+	auth := enablePasskey(t, service)
+	item := registerPasskey(t, service, auth, 1, "MacBook")
+	clone := auth.Clone()
+
+	if _, err := loginPasskey(service, auth); err != nil {
+		t.Fatalf("LoginPasskey() error = %v", err)
+	}
+	// 复制出的凭证签名计数落后，拒绝登录且不覆盖计数
+	if _, err := loginPasskey(service, clone); !errors.Is(err, passkey.ErrCloneDetected) {
+		t.Fatalf("LoginPasskey() clone error = %v, want %v", err, passkey.ErrCloneDetected)
+	}
+	var stored model.SysUserPasskey
+	if err := gormDB.First(&stored, item.ID).Error; err != nil || stored.SignCount != 1 {
+		t.Fatalf("stored passkey = %+v, %v, want sign count 1", stored, err)
+	}
+	var failure model.SysLoginLog
+	if err := gormDB.Order("id DESC").First(&failure).Error; err != nil || failure.Success || failure.UserID != 1 {
+		t.Fatalf("login log = %+v, %v, want failed login for user 1", failure, err)
+	}
+
+	// 只能删除自己的通行密钥
+	if err := service.RevokePasskey(ctx, 2, item.ID); !errors.Is(err, ErrPasskeyNotFound) {
+		t.Fatalf("RevokePasskey() other user error = %v, want %v", err, ErrPasskeyNotFound)
+	}
+	if err := service.RevokeUserPasskey(ctx, item.ID); err != nil {
+		t.Fatalf("RevokeUserPasskey() error = %v", err)
+	}
+	assertNotices(t, gormDB, 1, "通行密钥已被移除", 1)
+	if err := service.RevokePasskey(ctx, 1, item.ID); !errors.Is(err, ErrPasskeyNotFound) {
+		t.Fatalf("RevokePasskey() after revoke error = %v, want %v", err, ErrPasskeyNotFound)
+	}
+	if _, err := loginPasskey(service, auth); !errors.Is(err, passkey.ErrVerifyFailed) {
+		t.Fatalf("LoginPasskey() after revoke error = %v, want %v", err, passkey.ErrVerifyFailed)
+	}
+
+	// 通行密钥全部移除后恢复为仅密码登录
+	login, err := service.Login(ctx, dto.LoginReq{Username: "alice", Password: "Passw0rd!"})
+	if err != nil || login.MfaRequired || login.Token == "" {
+		t.Fatalf("Login() = %#v, %v, want token without MFA", login, err)
+	}
+}
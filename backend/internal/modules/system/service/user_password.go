@@ -0,0 +1,137 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/CIPFZ/gowebframe/internal/core/config"
+	logger "github.com/CIPFZ/gowebframe/internal/core/log"
+	"github.com/CIPFZ/gowebframe/internal/core/password"
+	"github.com/CIPFZ/gowebframe/internal/modules/system/dto"
+	"github.com/CIPFZ/gowebframe/internal/modules/system/model"
+	"github.com/CIPFZ/gowebframe/pkg/errcode"
+	"github.com/CIPFZ/gowebframe/pkg/utils"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrOldPasswordInvalid    = errors.New("原密码错误")
+	ErrExternalPasswordLogin = errors.New("目录账号的密码请在目录服务中修改")
+)
+
+// passwordPolicy 按当前配置构建密码策略
+func (s *UserService) passwordPolicy() *password.Policy {
+	var cfg config.PasswordPolicy
+	if s.svcCtx.Config != nil {
+		cfg = s.svcCtx.Config.Password
+	}
+	return password.New(cfg)
+}
+
+// checkNewPassword 校验密码策略；user 不为空时同时检查当前密码与历史密码，禁止复用
+func (s *UserService) checkNewPassword(ctx context.Context, user *model.SysUser, username, newPassword string) error {
+	policy := s.passwordPolicy()
+	if err := policy.Validate(newPassword, username); err != nil {
+		var pe *password.PolicyError
+		if errors.As(err, &pe) {
+			return errcode.PasswordPolicyViolated.WithDetails(strings.Join(pe.Reasons, "；"))
+		}
+		return err
+	}
+	if user == nil || policy.HistoryCount() <= 0 {
+		return nil
+	}
+
+	// 当前密码计入历史：history_count 为 N 时再比较最近 N-1 次旧密码
+	hashes := []string{user.Password}
+	if policy.HistoryCount() > 1 {
+		history, err := s.userRepo.ListPasswordHistory(ctx, user.ID, policy.HistoryCount()-1)
+		if err != nil {
+			return err
+		}
+		for _, h := range history {
+			hashes = append(hashes, h.Password)
+		}
+	}
+	for _, h := range hashes {
+		if h != "" && utils.BcryptCheck(newPassword, h) {
+			return errcode.PasswordPolicyViolated.WithDetails(fmt.Sprintf("不能与最近 %d 次使用过的密码相同", policy.HistoryCount()))
+		}
+	}
+	return nil
+}
+
+// setPassword 加密并保存新密码，旧密码按 history_count 保留在历史中
+func (s *UserService) setPassword(ctx context.Context, userID uint, newPassword string, requireChange bool) error {
+	hashPwd, err := utils.BcryptHash(newPassword)
+	if err != nil {
+		return err
+	}
+	keep := s.passwordPolicy().HistoryCount() - 1
+	return s.userRepo.ChangePassword(ctx, userID, hashPwd, requireChange, max(keep, 0))
+}
+
+// passwordChangeRequired 管理员要求修改或密码已超过有效期
+func (s *UserService) passwordChangeRequired(user *model.SysUser) bool {
+	if user.RequirePasswordChange {
+		return true
+	}
+	// 升级前创建的用户没有修改时间，按创建时间计算
+	changedAt := user.CreatedAt
+	if user.PasswordChangedAt != nil {
+		changedAt = *user.PasswordChangedAt
+	}
+	return s.passwordPolicy().Expired(changedAt, time.Now())
+}
+
+// LoginWithNewPassword 密码过期或被要求修改时，校验当前密码并设置新密码后继续登录流程
+func (s *UserService) LoginWithNewPassword(ctx context.Context, req dto.PasswordExpiredReq) (*dto.LoginResponse, error) {
+	user, method, err := s.verifyLogin(ctx, dto.LoginReq{
+		Username:  req.Username,
+		Password:  req.Password,
+		Captcha:   req.Captcha,
+		CaptchaId: req.CaptchaId,
+		IP:        req.IP,
+		UserAgent: req.UserAgent,
+	})
+	if err != nil {
+		s.logLoginFailure(ctx, nil, req.Username, loginSource{Method: model.LoginMethodPassword, IP: req.IP, UserAgent: req.UserAgent}, err)
+		return nil, err
+	}
+	if method != passwordAuthenticatorName {
+		return nil, ErrExternalPasswordLogin
+	}
+
+	if err := s.checkNewPassword(ctx, user, user.Username, req.NewPassword); err != nil {
+		return nil, err
+	}
+	if err := s.setPassword(ctx, user.ID, req.NewPassword, false); err != nil {
+		return nil, err
+	}
+	// 旧密码签发的会话一并失效
+	if err := s.KickAllSessions(ctx, user.ID); err != nil {
+		logger.GetLogger(ctx).Warn("kick_sessions_after_password_change_failed", zap.Error(err))
+	}
+	return s.finishLogin(ctx, user, loginSource{Method: method, IP: req.IP, UserAgent: req.UserAgent})
+}
+
+// ChangePassword 修改本人密码，成功后踢出除当前会话外的全部会话
+func (s *UserService) ChangePassword(ctx context.Context, userID uint, currentSessionID string, req dto.ChangePasswordReq) error {
+	user, err := s.userRepo.FindById(ctx, userID)
+	if err != nil {
+		return errors.New("用户不存在")
+	}
+	if !utils.BcryptCheck(req.Password, user.Password) {
+		return ErrOldPasswordInvalid
+	}
+	if err := s.checkNewPassword(ctx, user, user.Username, req.NewPassword); err != nil {
+		return err
+	}
+	if err := s.setPassword(ctx, user.ID, req.NewPassword, false); err != nil {
+		return err
+	}
+	return s.revokeOtherSessions(ctx, user.ID, currentSessionID)
+}
@@ -0,0 +1,221 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"net/url"
+	"strings"
+	"time"
+
+	logger "github.com/CIPFZ/gowebframe/internal/core/log"
+	"github.com/CIPFZ/gowebframe/internal/core/mailer"
+	"github.com/CIPFZ/gowebframe/internal/modules/system/dto"
+	"github.com/CIPFZ/gowebframe/internal/modules/system/model"
+	"github.com/CIPFZ/gowebframe/pkg/errcode"
+	"github.com/CIPFZ/gowebframe/pkg/utils"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	passwordResetRateKey      = "pwd_reset_rate:"
+	defaultPasswordResetTTL   = 30 * time.Minute
+	defaultPasswordResetLimit = 3
+	defaultPasswordResetWin   = time.Hour
+	passwordResetTokenBytes   = 32
+)
+
+var (
+	ErrPasswordResetDisabled     = errors.New("未开启邮件找回密码")
+	ErrPasswordResetTokenInvalid = errors.New("重置链接无效或已过期")
+)
+
+// passwordResetEnabled 需要开启配置并具备发信、缓存 (限流) 与国际化模板
+func (s *UserService) passwordResetEnabled() bool {
+	return s.svcCtx.Config != nil && s.svcCtx.Config.PasswordReset.Enabled &&
+		s.svcCtx.Mailer != nil && s.svcCtx.Cache != nil && s.svcCtx.I18n != nil
+}
+
+// ForgotPassword 申请找回密码
+// 无论账号是否存在都返回成功，不泄露账号信息；邮件异步发送，避免响应时间差异
+func (s *UserService) ForgotPassword(ctx context.Context, req dto.ForgotPasswordReq) error {
+	if !s.passwordResetEnabled() {
+		return ErrPasswordResetDisabled
+	}
+	log := logger.GetLogger(ctx)
+	account := strings.TrimSpace(req.Account)
+	if err := s.checkPasswordResetRate(ctx, strings.ToLower(account)); err != nil {
+		return err
+	}
+
+	user, err := s.findResetUser(ctx, account)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			log.Error("password_reset_lookup_failed", zap.Error(err))
+		}
+		return nil
+	}
+	if user.Status != model.UserActive || user.Email == "" {
+		log.Info("password_reset_skipped", zap.Uint("userID", user.ID))
+		return nil
+	}
+	// LDAP / OIDC 开通的账号由外部身份源管理密码，不能在本地设置密码
+	external, err := s.identityRepo.ExistsByUser(ctx, user.ID)
+	if err != nil {
+		log.Error("password_reset_lookup_failed", zap.Error(err))
+		return nil
+	}
+	if external {
+		log.Info("password_reset_skipped", zap.Uint("userID", user.ID), zap.String("reason", "external_identity"))
+		return nil
+	}
+	// 按用户名申请时再以邮箱计数，防止交替使用用户名与邮箱绕过限流
+	if email := strings.ToLower(user.Email); email != strings.ToLower(account) {
+		if err := s.checkPasswordResetRate(ctx, email); err != nil {
+			return err
+		}
+	}
+
+	cfg := s.svcCtx.Config.PasswordReset
+	ttl := utils.ParseDurationOr(cfg.TokenTTL, defaultPasswordResetTTL)
+	raw, err := newPasswordResetToken()
+	if err != nil {
+		return err
+	}
+	if err := s.resetRepo.Replace(ctx, &model.SysPasswordResetToken{
+		UserID:    user.ID,
+		TokenHash: hashPasswordResetToken(raw),
+		ExpiresAt: time.Now().Add(ttl),
+		IP:        req.IP,
+	}); err != nil {
+		return err
+	}
+
+	data := map[string]interface{}{
+		"Name":    displayName(user),
+		"Link":    passwordResetLink(cfg.LinkURL, raw),
+		"Minutes": int(ttl.Minutes()),
+	}
+	s.sendMailAsync(ctx, user, mailer.Message{
+		To:      []string{user.Email},
+		Subject: s.svcCtx.I18n.Translate(req.Lang, "email.password_reset.subject", data),
+		Body:    s.svcCtx.I18n.Translate(req.Lang, "email.password_reset.body", data),
+	})
+	return nil
+}
+
+// ResetPasswordByToken 校验令牌后设置新密码，令牌只能使用一次
+func (s *UserService) ResetPasswordByToken(ctx context.Context, req dto.ResetPasswordByTokenReq) error {
+	if !s.passwordResetEnabled() {
+		return ErrPasswordResetDisabled
+	}
+	now := time.Now()
+	token, err := s.resetRepo.FindValid(ctx, hashPasswordResetToken(req.Token), now)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrPasswordResetTokenInvalid
+		}
+		return err
+	}
+	user, err := s.userRepo.FindById(ctx, token.UserID)
+	if err != nil {
+		return ErrPasswordResetTokenInvalid
+	}
+	// 申请后才绑定外部身份的账号同样不允许设置本地密码
+	external, err := s.identityRepo.ExistsByUser(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	if external {
+		return ErrPasswordResetTokenInvalid
+	}
+	// 策略校验失败不消耗令牌，用户可换个密码重试
+	if err := s.checkNewPassword(ctx, user, user.Username, req.NewPassword); err != nil {
+		return err
+	}
+	ok, err := s.resetRepo.Consume(ctx, token.ID, now)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrPasswordResetTokenInvalid
+	}
+
+	if err := s.setPassword(ctx, user.ID, req.NewPassword, false); err != nil {
+		return err
+	}
+	// 找回密码后解除登录锁定，旧会话全部失效
+	_ = s.svcCtx.Cache.Del(ctx, loginFailUserKey+user.Username, loginLockKey+user.Username)
+	return s.KickAllSessions(ctx, user.ID)
+}
+
+// checkPasswordResetRate 同一账号或邮箱在窗口内的申请次数限制
+func (s *UserService) checkPasswordResetRate(ctx context.Context, address string) error {
+	cfg := s.svcCtx.Config.PasswordReset
+	limit := defaultPasswordResetLimit
+	if cfg.RateLimit > 0 {
+		limit = cfg.RateLimit
+	}
+	window := utils.ParseDurationOr(cfg.RateWindow, defaultPasswordResetWin)
+	n, err := s.svcCtx.Cache.Incr(ctx, passwordResetRateKey+address, window)
+	if err != nil {
+		return err
+	}
+	if n > int64(limit) {
+		return errcode.TooManyRequests
+	}
+	return nil
+}
+
+func (s *UserService) findResetUser(ctx context.Context, account string) (*model.SysUser, error) {
+	if strings.Contains(account, "@") {
+		if user, err := s.userRepo.FindByEmail(ctx, account); err == nil {
+			return user, nil
+		}
+	}
+	return s.userRepo.FindByUsername(ctx, account)
+}
+
+func newPasswordResetToken() (string, error) {
+	buf := make([]byte, passwordResetTokenBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+func hashPasswordResetToken(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
+
+// passwordResetLink 拼接重置链接；前端使用 hash 路由时令牌放在 # 之后的查询参数中
+func passwordResetLink(base, token string) string {
+	u, err := url.Parse(base)
+	if err != nil || base == "" {
+		return token
+	}
+	if u.Fragment != "" {
+		sep := "?"
+		if strings.Contains(u.Fragment, "?") {
+			sep = "&"
+		}
+		u.Fragment += sep + "token=" + url.QueryEscape(token)
+		return u.String()
+	}
+	q := u.Query()
+	q.Set("token", token)
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+func displayName(user *model.SysUser) string {
+	if user.NickName != "" {
+		return user.NickName
+	}
+	return user.Username
+}
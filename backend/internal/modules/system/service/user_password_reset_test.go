@@ -0,0 +1,204 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/CIPFZ/gowebframe/internal/core/cache"
+	"github.com/CIPFZ/gowebframe/internal/core/config"
+	"github.com/CIPFZ/gowebframe/internal/core/i18n"
+	"github.com/CIPFZ/gowebframe/internal/core/ldapauth/ldaptest"
+	"github.com/CIPFZ/gowebframe/internal/core/mailer"
+	"github.com/CIPFZ/gowebframe/internal/core/mailer/mailertest"
+	"github.com/CIPFZ/gowebframe/internal/modules/system/dto"
+	"github.com/CIPFZ/gowebframe/internal/modules/system/model"
+	"github.com/CIPFZ/gowebframe/pkg/errcode"
+	"go.uber.org/zap"
+)
+
+func TestUserServicePasswordReset(t *testing.T) {
+	service, gormDB := newUserTestService(t, true)
+	srv := enablePasswordReset(t, service)
+	if err := gormDB.Model(&model.SysUser{}).Where("id = ?", 1).Update("email", "Alice@Example.com").Error; err != nil {
+		t.Fatalf("set email error = %v", err)
+	}
+	ctx := context.Background()
+
+	// 未知账号同样返回成功，但不发信
+	if err := service.ForgotPassword(ctx, dto.ForgotPasswordReq{Account: "nobody"}); err != nil {
+		t.Fatalf("ForgotPassword(unknown) error = %v", err)
+	}
+	if _, ok := srv.Wait(200 * time.Millisecond); ok {
+		t.Fatal("ForgotPassword(unknown) sent a message")
+	}
+
+	if err := service.ForgotPassword(ctx, dto.ForgotPasswordReq{Account: "alice@example.com", Lang: "zh-CN"}); err != nil {
+		t.Fatalf("ForgotPassword() error = %v", err)
+	}
+	msg, ok := srv.Wait(2 * time.Second)
+	if !ok {
+		t.Fatal("ForgotPassword() sent no message")
+	}
+	if len(msg.To) != 1 || msg.To[0] != "Alice@Example.com" || msg.Subject() != "重置密码" {
+		t.Fatalf("message to = %v subject = %q", msg.To, msg.Subject())
+	}
+	link := regexp.MustCompile(`http://app\.local/\S+`).FindString(msg.Body())
+	u, err := url.Parse(link)
+	if err != nil || !strings.Contains(msg.Body(), "Alice") {
+		t.Fatalf("message body = %q", msg.Body())
+	}
+	token := u.Query().Get("token")
+	if token == "" {
+		t.Fatalf("reset link %q has no token", link)
+	}
+
+	if err := service.ResetPasswordByToken(ctx, dto.ResetPasswordByTokenReq{Token: "bogus", NewPassword: "Reset#Pass1"}); !errors.Is(err, ErrPasswordResetTokenInvalid) {
+		t.Fatalf("ResetPasswordByToken(bogus) error = %v, want %v", err, ErrPasswordResetTokenInvalid)
+	}
+	// 策略校验失败不消耗令牌
+	if err := service.ResetPasswordByToken(ctx, dto.ResetPasswordByTokenReq{Token: token, NewPassword: "Passw0rd!"}); errCode(err) != errcode.PasswordPolicyViolated.Code {
+		t.Fatalf("ResetPasswordByToken(reused password) error = %v, want policy violation", err)
+	}
+	if err := service.ResetPasswordByToken(ctx, dto.ResetPasswordByTokenReq{Token: token, NewPassword: "Reset#Pass1"}); err != nil {
+		t.Fatalf("ResetPasswordByToken() error = %v", err)
+	}
+	if err := service.ResetPasswordByToken(ctx, dto.ResetPasswordByTokenReq{Token: token, NewPassword: "Other#Pass1"}); !errors.Is(err, ErrPasswordResetTokenInvalid) {
+		t.Fatalf("ResetPasswordByToken(used) error = %v, want %v", err, ErrPasswordResetTokenInvalid)
+	}
+	if _, err := service.Login(ctx, dto.LoginReq{Username: "alice", Password: "Reset#Pass1"}); err != nil {
+		t.Fatalf("Login() with new password error = %v", err)
+	}
+
+	// 用户名与邮箱共用一个计数，第二次申请后超出限制
+	if err := service.ForgotPassword(ctx, dto.ForgotPasswordReq{Account: "alice"}); err != nil {
+		t.Fatalf("ForgotPassword(username) error = %v", err)
+	}
+	if err := service.ForgotPassword(ctx, dto.ForgotPasswordReq{Account: "alice"}); errCode(err) != errcode.TooManyRequests.Code {
+		t.Fatalf("ForgotPassword() over limit error = %v, want %v", err, errcode.TooManyRequests)
+	}
+}
+
+func TestUserServicePasswordResetExpired(t *testing.T) {
+	service, gormDB := newUserTestService(t, true)
+	srv := enablePasswordReset(t, service)
+	if err := gormDB.Model(&model.SysUser{}).Where("id = ?", 1).Update("email", "alice@example.com").Error; err != nil {
+		t.Fatalf("set email error = %v", err)
+	}
+	service.(*UserService).svcCtx.Config.PasswordReset.TokenTTL = "10m"
+	ctx := context.Background()
+
+	if err := service.ForgotPassword(ctx, dto.ForgotPasswordReq{Account: "alice"}); err != nil {
+		t.Fatalf("ForgotPassword() error = %v", err)
+	}
+	msg, ok := srv.Wait(2 * time.Second)
+	if !ok {
+		t.Fatal("ForgotPassword() sent no message")
+	}
+	var stored model.SysPasswordResetToken
+	if err := gormDB.Where("user_id = ?", 1).First(&stored).Error; err != nil {
+		t.Fatalf("load reset token error = %v", err)
+	}
+	if ttl := time.Until(stored.ExpiresAt); ttl <= 9*time.Minute || ttl > 10*time.Minute {
+		t.Fatalf("reset token ttl = %v, want token_ttl 10m", ttl)
+	}
+	u, _ := url.Parse(regexp.MustCompile(`http://app\.local/\S+`).FindString(msg.Body()))
+	if err := gormDB.Model(&model.SysPasswordResetToken{}).Where("user_id = ?", 1).Update("expires_at", time.Now().Add(-time.Minute)).Error; err != nil {
+		t.Fatalf("expire token error = %v", err)
+	}
+	if err := service.ResetPasswordByToken(ctx, dto.ResetPasswordByTokenReq{Token: u.Query().Get("token"), NewPassword: "Reset#Pass1"}); !errors.Is(err, ErrPasswordResetTokenInvalid) {
+		t.Fatalf("ResetPasswordByToken(expired) error = %v, want %v", err, ErrPasswordResetTokenInvalid)
+	}
+
+	service.(*UserService).svcCtx.Config.PasswordReset.Enabled = false
+	if err := service.ForgotPassword(ctx, dto.ForgotPasswordReq{Account: "alice"}); !errors.Is(err, ErrPasswordResetDisabled) {
+		t.Fatalf("ForgotPassword() disabled error = %v, want %v", err, ErrPasswordResetDisabled)
+	}
+}
+
+func TestUserServicePasswordResetSkipsExternalUsers(t *testing.T) {
+	dir := ldaptest.NewServer(&ldaptest.Entry{DN: "dc=example,dc=org"}, ldapBob("cn=admins,ou=groups,dc=example,dc=org"))
+	defer dir.Close()
+	service, gormDB := newLdapTestService(t, dir)
+	srv := enablePasswordReset(t, service)
+	ctx := context.Background()
+
+	if _, err := service.Login(ctx, dto.LoginReq{Username: "bob", Password: "bob-secret"}); err != nil {
+		t.Fatalf("Login() ldap user error = %v", err)
+	}
+	var bob model.SysUser
+	if err := gormDB.Where("username = ?", "bob").First(&bob).Error; err != nil {
+		t.Fatalf("load ldap user error = %v", err)
+	}
+
+	// LDAP 开通的账号申请找回密码时同样返回成功，但不发信
+	for _, account := range []string{"bob", "bob@example.org"} {
+		if err := service.ForgotPassword(ctx, dto.ForgotPasswordReq{Account: account}); err != nil {
+			t.Fatalf("ForgotPassword(%s) error = %v", account, err)
+		}
+	}
+	if _, ok := srv.Wait(200 * time.Millisecond); ok {
+		t.Fatal("ForgotPassword() sent a message to an ldap user")
+	}
+
+	// 已签发的令牌在账号绑定外部身份后不能再设置本地密码
+	raw, err := newPasswordResetToken()
+	if err != nil {
+		t.Fatalf("newPasswordResetToken() error = %v", err)
+	}
+	if err := gormDB.Create(&model.SysPasswordResetToken{
+		UserID:    bob.ID,
+		TokenHash: hashPasswordResetToken(raw),
+		ExpiresAt: time.Now().Add(time.Hour),
+	}).Error; err != nil {
+		t.Fatalf("seed reset token error = %v", err)
+	}
+	if err := service.ResetPasswordByToken(ctx, dto.ResetPasswordByTokenReq{Token: raw, NewPassword: "Reset#Pass1"}); !errors.Is(err, ErrPasswordResetTokenInvalid) {
+		t.Fatalf("ResetPasswordByToken(ldap user) error = %v, want %v", err, ErrPasswordResetTokenInvalid)
+	}
+}
+
+func enablePasswordReset(t *testing.T, service IUserService) *mailertest.Server {
+	t.Helper()
+	srv := mailertest.NewServer()
+	t.Cleanup(srv.Close)
+	store := cache.NewMemoryStore()
+	t.Cleanup(func() { _ = store.Close(context.Background()) })
+	translator, err := i18n.NewI18n(config.I18n{Path: "../../../../configs/locales"}, zap.NewNop())
+	if err != nil {
+		t.Fatalf("NewI18n() error = %v", err)
+	}
+
+	us := service.(*UserService)
+	us.svcCtx.Mailer = mailer.New(config.Email{From: "noreply@example.com", Host: srv.Host(), Port: srv.Port()})
+	us.svcCtx.Cache = store
+	us.svcCtx.I18n = translator
+	us.svcCtx.Config.Password = config.PasswordPolicy{HistoryCount: 1}
+	us.svcCtx.Config.PasswordReset = config.PasswordReset{
+		Enabled:   true,
+		LinkURL:   "http://app.local/user/reset-password",
+		RateLimit: 2,
+	}
+	return srv
+}
+
+func TestPasswordResetLink(t *testing.T) {
+	tests := []struct {
+		base string
+		want string
+	}{
+		{"http://app.local/user/reset-password", "http://app.local/user/reset-password?token=abc"},
+		{"http://app.local/#/user/reset-password", "http://app.local/#/user/reset-password?token=abc"},
+		{"http://app.local/#/user/reset-password?lang=en", "http://app.local/#/user/reset-password?lang=en&token=abc"},
+		{"", "abc"},
+	}
+	for _, tt := range tests {
+		if got := passwordResetLink(tt.base, "abc"); got != tt.want {
+			t.Errorf("passwordResetLink(%q) = %q, want %q", tt.base, got, tt.want)
+		}
+	}
+}
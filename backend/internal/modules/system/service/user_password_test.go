@@ -0,0 +1,142 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/CIPFZ/gowebframe/internal/core/config"
+	corejwt "github.com/CIPFZ/gowebframe/internal/core/jwt"
+	"github.com/CIPFZ/gowebframe/internal/modules/system/dto"
+	"github.com/CIPFZ/gowebframe/internal/modules/system/model"
+	"github.com/CIPFZ/gowebframe/pkg/errcode"
+)
+
+func errCode(err error) int {
+	var e *errcode.Error
+	if errors.As(err, &e) {
+		return e.Code
+	}
+	return 0
+}
+
+func TestUserServicePasswordPolicyAndHistory(t *testing.T) {
+	service, _ := newUserTestService(t, true)
+	service.(*UserService).svcCtx.Config.Password = config.PasswordPolicy{
+		MinLength:        8,
+		MinClasses:       3,
+		CheckUsername:    true,
+		BlockedPasswords: []string{"password"},
+		HistoryCount:     3,
+	}
+	ctx := context.Background()
+
+	for _, pwd := range []string{"short1!", "Password#1", "bob-Bob-1"} {
+		if _, err := service.Register(ctx, dto.RegisterReq{Username: "bob", Password: pwd}); errCode(err) != errcode.PasswordPolicyViolated.Code {
+			t.Fatalf("Register(%q) error = %v, want policy violation", pwd, err)
+		}
+	}
+	if err := service.AddUser(ctx, dto.AddUserReq{Username: "carol", Password: "abc", AuthorityIds: []uint{2}}); errCode(err) != errcode.PasswordPolicyViolated.Code {
+		t.Fatalf("AddUser() error = %v, want policy violation", err)
+	}
+
+	// 当前密码与最近 2 次旧密码都不能复用
+	for _, pwd := range []string{"Second#Pass1", "Third#Pass1"} {
+		if err := service.ResetPassword(ctx, dto.ResetPasswordReq{ID: 1, Password: pwd}); err != nil {
+			t.Fatalf("ResetPassword(%q) error = %v", pwd, err)
+		}
+	}
+	for _, pwd := range []string{"Third#Pass1", "Second#Pass1", "Passw0rd!"} {
+		if err := service.ChangePassword(ctx, 1, "", dto.ChangePasswordReq{Password: "Third#Pass1", NewPassword: pwd}); errCode(err) != errcode.PasswordPolicyViolated.Code {
+			t.Fatalf("ChangePassword(%q) error = %v, want reuse rejected", pwd, err)
+		}
+	}
+	if err := service.ChangePassword(ctx, 1, "", dto.ChangePasswordReq{Password: "wrong", NewPassword: "Fourth#Pass1"}); !errors.Is(err, ErrOldPasswordInvalid) {
+		t.Fatalf("ChangePassword() wrong old password error = %v, want %v", err, ErrOldPasswordInvalid)
+	}
+	if err := service.ChangePassword(ctx, 1, "", dto.ChangePasswordReq{Password: "Third#Pass1", NewPassword: "Fourth#Pass1"}); err != nil {
+		t.Fatalf("ChangePassword() error = %v", err)
+	}
+	// 超出 history_count 的旧密码可以再次使用
+	if err := service.ChangePassword(ctx, 1, "", dto.ChangePasswordReq{Password: "Fourth#Pass1", NewPassword: "Passw0rd!"}); err != nil {
+		t.Fatalf("ChangePassword() to expired history entry error = %v", err)
+	}
+}
+
+func TestUserServiceChangePasswordRevokesOtherSessions(t *testing.T) {
+	service, _ := newUserTestService(t, true)
+	ctx := context.Background()
+
+	current, err := service.Login(ctx, dto.LoginReq{Username: "alice", Password: "Passw0rd!"})
+	if err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+	other, err := service.Login(ctx, dto.LoginReq{Username: "alice", Password: "Passw0rd!", IP: "10.0.0.2"})
+	if err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+	claims, err := svcJWT(service).ParseToken(current.Token)
+	if err != nil {
+		t.Fatalf("ParseToken() error = %v", err)
+	}
+
+	if err := service.ChangePassword(ctx, 1, claims.SessionID, dto.ChangePasswordReq{Password: "Passw0rd!", NewPassword: "Second#Pass1"}); err != nil {
+		t.Fatalf("ChangePassword() error = %v", err)
+	}
+
+	// 其它设备的会话与 refresh token 失效，当前会话保留
+	if _, err := service.RefreshToken(ctx, other.RefreshToken); !errors.Is(err, corejwt.ErrRefreshTokenInvalid) {
+		t.Fatalf("RefreshToken() for other session error = %v, want %v", err, corejwt.ErrRefreshTokenInvalid)
+	}
+	sessions, err := service.ListSessions(ctx, 1, claims.SessionID)
+	if err != nil {
+		t.Fatalf("ListSessions() error = %v", err)
+	}
+	if len(sessions) != 1 || !sessions[0].Current {
+		t.Fatalf("ListSessions() = %#v, want only the current session", sessions)
+	}
+	if _, err := service.RefreshToken(ctx, current.RefreshToken); err != nil {
+		t.Fatalf("RefreshToken() for current session error = %v", err)
+	}
+}
+
+func TestUserServiceForcedPasswordChange(t *testing.T) {
+	service, gormDB := newUserTestService(t, true)
+	service.(*UserService).svcCtx.Config.Password = config.PasswordPolicy{HistoryCount: 1, MaxAgeDays: 90}
+	ctx := context.Background()
+
+	// 管理员重置并要求下次登录修改
+	if err := service.ResetPassword(ctx, dto.ResetPasswordReq{ID: 1, Password: "Temp#Pass1", RequirePasswordChange: true}); err != nil {
+		t.Fatalf("ResetPassword() error = %v", err)
+	}
+	if _, err := service.Login(ctx, dto.LoginReq{Username: "alice", Password: "Temp#Pass1"}); !errors.Is(err, errcode.PasswordChangeRequired) {
+		t.Fatalf("Login() error = %v, want %v", err, errcode.PasswordChangeRequired)
+	}
+
+	req := dto.PasswordExpiredReq{Username: "alice", Password: "wrong", NewPassword: "Fresh#Pass1"}
+	if _, err := service.LoginWithNewPassword(ctx, req); !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("LoginWithNewPassword() wrong password error = %v, want %v", err, ErrInvalidCredentials)
+	}
+	req.Password = "Temp#Pass1"
+	req.NewPassword = "Temp#Pass1"
+	if _, err := service.LoginWithNewPassword(ctx, req); errCode(err) != errcode.PasswordPolicyViolated.Code {
+		t.Fatalf("LoginWithNewPassword() same password error = %v, want reuse rejected", err)
+	}
+	req.NewPassword = "Fresh#Pass1"
+	resp, err := service.LoginWithNewPassword(ctx, req)
+	if err != nil || resp.Token == "" {
+		t.Fatalf("LoginWithNewPassword() = %+v, %v, want token", resp, err)
+	}
+	if _, err := service.Login(ctx, dto.LoginReq{Username: "alice", Password: "Fresh#Pass1"}); err != nil {
+		t.Fatalf("Login() after change error = %v", err)
+	}
+
+	// 超过 max_age_days 后下次登录必须修改
+	if err := gormDB.Model(&model.SysUser{}).Where("id = ?", 1).Update("password_changed_at", time.Now().AddDate(0, 0, -91)).Error; err != nil {
+		t.Fatalf("age password error = %v", err)
+	}
+	if _, err := service.Login(ctx, dto.LoginReq{Username: "alice", Password: "Fresh#Pass1"}); !errors.Is(err, errcode.PasswordChangeRequired) {
+		t.Fatalf("Login() expired password error = %v, want %v", err, errcode.PasswordChangeRequired)
+	}
+}
@@ -0,0 +1,259 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/CIPFZ/gowebframe/internal/core/config"
+	logger "github.com/CIPFZ/gowebframe/internal/core/log"
+	"github.com/CIPFZ/gowebframe/internal/core/mailer"
+	"github.com/CIPFZ/gowebframe/internal/modules/system/dto"
+	"github.com/CIPFZ/gowebframe/internal/modules/system/model"
+	"github.com/CIPFZ/gowebframe/pkg/errcode"
+	"github.com/CIPFZ/gowebframe/pkg/utils"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultRegisterVerifyTTL   = 24 * time.Hour
+	defaultApproverAuthorityID = uint(1)
+)
+
+var (
+	ErrUserDisabled              = errors.New("此用户已经被禁用")
+	ErrRegistrationEmailRequired = errors.New("请填写邮箱，注册后需要验证邮箱")
+	ErrRegistrationEmailTaken    = errors.New("该邮箱已被注册")
+	ErrRegistrationMailerMissing = errors.New("未配置邮件服务，无法发送验证邮件")
+	ErrRegisterTokenInvalid      = errors.New("验证链接无效或已过期")
+	ErrRegistrationNotPending    = errors.New("该申请不存在或已审核")
+)
+
+// checkUserStatus 只有正常状态的用户可以登录或续期，注册流程中的账号返回对应提示
+func checkUserStatus(user *model.SysUser) error {
+	switch user.Status {
+	case model.UserActive:
+		return nil
+	case model.UserPendingVerify:
+		return errcode.AccountPendingVerify
+	case model.UserPendingApproval:
+		return errcode.AccountPendingApproval
+	case model.UserRejected:
+		return errcode.AccountRejected
+	default:
+		return ErrUserDisabled
+	}
+}
+
+// registrationMode 未配置时保持原有行为：注册即可用
+func (s *UserService) registrationMode() string {
+	if s.svcCtx.Config == nil || s.svcCtx.Config.Registration.Mode == "" {
+		return config.RegistrationOpen
+	}
+	return strings.ToLower(s.svcCtx.Config.Registration.Mode)
+}
+
+// registrationStatus 按注册模式校验请求并返回新用户的初始状态
+func (s *UserService) registrationStatus(ctx context.Context, req dto.RegisterReq) (int, error) {
+	switch s.registrationMode() {
+	case config.RegistrationOpen:
+		return model.UserActive, nil
+	case config.RegistrationApproval:
+		return model.UserPendingApproval, nil
+	case config.RegistrationEmail:
+		if s.svcCtx.Mailer == nil || s.svcCtx.I18n == nil {
+			return 0, ErrRegistrationMailerMissing
+		}
+		if strings.TrimSpace(req.Email) == "" {
+			return 0, ErrRegistrationEmailRequired
+		}
+		if _, err := s.userRepo.FindByEmail(ctx, req.Email); err == nil {
+			return 0, ErrRegistrationEmailTaken
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, err
+		}
+		return model.UserPendingVerify, nil
+	default:
+		// closed 以及无法识别的模式一律关闭，避免配置错误时意外开放注册
+		return 0, errcode.RegistrationClosed
+	}
+}
+
+// afterRegister 待验证账号发送验证邮件，待审核账号通知审核人；失败只记录日志，不影响注册结果
+func (s *UserService) afterRegister(ctx context.Context, user *model.SysUser, lang string) {
+	log := logger.GetLogger(ctx)
+	switch user.Status {
+	case model.UserPendingVerify:
+		if err := s.sendRegisterVerifyMail(ctx, user, lang); err != nil {
+			log.Error("register_verify_mail_failed", zap.Uint("userID", user.ID), zap.Error(err))
+		}
+	case model.UserPendingApproval:
+		s.notifyApprovers(ctx, 0, "新的注册申请待审核",
+			fmt.Sprintf("用户 %s 提交了注册申请，请在「用户管理 - 注册审核」中处理。", user.Username))
+	}
+}
+
+func (s *UserService) sendRegisterVerifyMail(ctx context.Context, user *model.SysUser, lang string) error {
+	cfg := s.svcCtx.Config.Registration
+	ttl := utils.ParseDurationOr(cfg.VerifyTTL, defaultRegisterVerifyTTL)
+	raw, err := newPasswordResetToken()
+	if err != nil {
+		return err
+	}
+	if err := s.regRepo.ReplaceVerifyToken(ctx, &model.SysEmailVerifyToken{
+		UserID:    user.ID,
+		Email:     user.Email,
+		TokenHash: hashPasswordResetToken(raw),
+		ExpiresAt: time.Now().Add(ttl),
+	}); err != nil {
+		return err
+	}
+
+	data := map[string]interface{}{
+		"Name":    displayName(user),
+		"Link":    passwordResetLink(cfg.VerifyURL, raw),
+		"Minutes": int(ttl.Minutes()),
+	}
+	s.sendMailAsync(ctx, user, mailer.Message{
+		To:      []string{user.Email},
+		Subject: s.svcCtx.I18n.Translate(lang, "email.register_verify.subject", data),
+		Body:    s.svcCtx.I18n.Translate(lang, "email.register_verify.body", data),
+	})
+	return nil
+}
+
+// VerifyRegistration 校验邮件中的令牌，完成邮箱验证后账号立即可用
+func (s *UserService) VerifyRegistration(ctx context.Context, req dto.RegisterVerifyReq) error {
+	token, err := s.regRepo.ConsumeVerifyToken(ctx, hashPasswordResetToken(req.Token), time.Now())
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrRegisterTokenInvalid
+		}
+		return err
+	}
+	ok, err := s.regRepo.TransitStatus(ctx, token.UserID, model.UserPendingVerify, model.UserActive)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrRegisterTokenInvalid
+	}
+	logger.GetLogger(ctx).Info("register_email_verified", zap.Uint("userID", token.UserID))
+	return nil
+}
+
+func (s *UserService) GetRegistrationList(ctx context.Context, req dto.SearchRegistrationReq) ([]model.SysUser, int64, error) {
+	if req.Status == 0 {
+		req.Status = model.UserPendingApproval
+	}
+	return s.regRepo.ListApplications(ctx, req)
+}
+
+// ApproveRegistration 审核通过：账号立即可用，并通知申请人与其他审核人
+func (s *UserService) ApproveRegistration(ctx context.Context, reviewerID uint, req dto.ReviewRegistrationReq) error {
+	user, err := s.reviewRegistration(ctx, req.ID, model.UserActive)
+	if err != nil {
+		return err
+	}
+	reviewer := s.userDisplayName(ctx, reviewerID)
+
+	content := "您的注册申请已审核通过，欢迎使用。"
+	if req.Reason != "" {
+		content += "\n审核意见：" + req.Reason
+	}
+	s.notifyUsers(ctx, reviewerID, "注册申请已通过", content, []uint{user.ID})
+	s.notifyApprovers(ctx, reviewerID, "注册申请已通过",
+		fmt.Sprintf("用户 %s 的注册申请已由 %s 审核通过。", user.Username, reviewer))
+	return nil
+}
+
+// RejectRegistration 审核拒绝：账号保持不可登录，申请人有邮箱时邮件告知审核意见
+func (s *UserService) RejectRegistration(ctx context.Context, reviewerID uint, req dto.ReviewRegistrationReq) error {
+	user, err := s.reviewRegistration(ctx, req.ID, model.UserRejected)
+	if err != nil {
+		return err
+	}
+	reviewer := s.userDisplayName(ctx, reviewerID)
+
+	if user.Email != "" && s.svcCtx.Mailer != nil && s.svcCtx.I18n != nil {
+		data := map[string]interface{}{"Name": displayName(user), "Reason": req.Reason}
+		s.sendMailAsync(ctx, user, mailer.Message{
+			To:      []string{user.Email},
+			Subject: s.svcCtx.I18n.Translate(req.Lang, "email.register_rejected.subject", data),
+			Body:    s.svcCtx.I18n.Translate(req.Lang, "email.register_rejected.body", data),
+		})
+	}
+	content := fmt.Sprintf("用户 %s 的注册申请已由 %s 拒绝。", user.Username, reviewer)
+	if req.Reason != "" {
+		content += "\n审核意见：" + req.Reason
+	}
+	s.notifyApprovers(ctx, reviewerID, "注册申请已拒绝", content)
+	return nil
+}
+
+// reviewRegistration 只处理待审核的申请，并发审核时只有一次生效
+func (s *UserService) reviewRegistration(ctx context.Context, userID uint, status int) (*model.SysUser, error) {
+	ok, err := s.regRepo.TransitStatus(ctx, userID, model.UserPendingApproval, status)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrRegistrationNotPending
+	}
+	user, err := s.userRepo.FindById(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	logger.GetLogger(ctx).Info("registration_reviewed", zap.Uint("userID", userID), zap.Int("status", status))
+	return user, nil
+}
+
+func (s *UserService) userDisplayName(ctx context.Context, reviewerID uint) string {
+	if reviewer, err := s.userRepo.FindById(ctx, reviewerID); err == nil {
+		return displayName(reviewer)
+	}
+	return fmt.Sprintf("#%d", reviewerID)
+}
+
+// notifyApprovers 向配置的审核角色下的所有用户发送站内通知
+func (s *UserService) notifyApprovers(ctx context.Context, creatorID uint, title, content string) {
+	ids := []uint{defaultApproverAuthorityID}
+	if s.svcCtx.Config != nil && len(s.svcCtx.Config.Registration.ApproverAuthorityIDs) > 0 {
+		ids = s.svcCtx.Config.Registration.ApproverAuthorityIDs
+	}
+	userIDs, err := s.noticeRepo.ListUserIDsByAuthorityIDs(ctx, ids)
+	if err != nil {
+		logger.GetLogger(ctx).Error("list_approvers_failed", zap.Error(err))
+		return
+	}
+	s.notifyUsers(ctx, creatorID, title, content, userIDs)
+}
+
+func (s *UserService) notifyUsers(ctx context.Context, creatorID uint, title, content string, userIDs []uint) {
+	if len(userIDs) == 0 {
+		return
+	}
+	notice := &model.SysNotice{
+		Title:      title,
+		Content:    content,
+		Level:      model.NoticeLevelInfo,
+		TargetType: model.NoticeTargetUsers,
+		CreatedBy:  creatorID,
+	}
+	if err := s.noticeRepo.CreateWithReceivers(ctx, notice, userIDs); err != nil {
+		logger.GetLogger(ctx).Error("user_notice_failed", zap.String("title", title), zap.Error(err))
+	}
+}
+
+// sendMailAsync 异步发信，不阻塞接口响应
+func (s *UserService) sendMailAsync(ctx context.Context, user *model.SysUser, msg mailer.Message) {
+	log := logger.GetLogger(ctx)
+	go func(ctx context.Context) {
+		if err := s.svcCtx.Mailer.Send(ctx, msg); err != nil {
+			log.Error("send_mail_failed", zap.Uint("userID", user.ID), zap.String("subject", msg.Subject), zap.Error(err))
+		}
+	}(context.WithoutCancel(ctx))
+}
@@ -0,0 +1,174 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/CIPFZ/gowebframe/internal/core/config"
+	"github.com/CIPFZ/gowebframe/internal/modules/system/dto"
+	"github.com/CIPFZ/gowebframe/internal/modules/system/model"
+	"github.com/CIPFZ/gowebframe/pkg/errcode"
+	"gorm.io/gorm"
+)
+
+func TestUserServiceRegistrationModes(t *testing.T) {
+	service, _ := newUserTestService(t, true)
+	cfg := &service.(*UserService).svcCtx.Config.Registration
+	ctx := context.Background()
+
+	// 未配置时保持注册即可用
+	user, err := service.Register(ctx, dto.RegisterReq{Username: "bob", Password: "Bob#Pass1"})
+	if err != nil || user.Status != model.UserActive {
+		t.Fatalf("Register() default mode = %+v, %v, want active user", user, err)
+	}
+
+	cfg.Mode = config.RegistrationClosed
+	if _, err := service.Register(ctx, dto.RegisterReq{Username: "carol", Password: "Carol#Pass1"}); !errors.Is(err, errcode.RegistrationClosed) {
+		t.Fatalf("Register() closed error = %v, want %v", err, errcode.RegistrationClosed)
+	}
+	cfg.Mode = "unknown"
+	if _, err := service.Register(ctx, dto.RegisterReq{Username: "carol", Password: "Carol#Pass1"}); !errors.Is(err, errcode.RegistrationClosed) {
+		t.Fatalf("Register() unknown mode error = %v, want %v", err, errcode.RegistrationClosed)
+	}
+	cfg.Mode = config.RegistrationEmail
+	if _, err := service.Register(ctx, dto.RegisterReq{Username: "carol", Password: "Carol#Pass1", Email: "carol@example.com"}); !errors.Is(err, ErrRegistrationMailerMissing) {
+		t.Fatalf("Register() email mode without mailer error = %v, want %v", err, ErrRegistrationMailerMissing)
+	}
+}
+
+func TestUserServiceRegistrationEmailVerify(t *testing.T) {
+	service, gormDB := newUserTestService(t, true)
+	srv := enablePasswordReset(t, service)
+	service.(*UserService).svcCtx.Config.Registration = config.Registration{
+		Mode:      config.RegistrationEmail,
+		VerifyURL: "http://app.local/#/user/register-verify",
+		VerifyTTL: "2h",
+	}
+	ctx := context.Background()
+
+	if _, err := service.Register(ctx, dto.RegisterReq{Username: "carol", Password: "Carol#Pass1"}); !errors.Is(err, ErrRegistrationEmailRequired) {
+		t.Fatalf("Register() without email error = %v, want %v", err, ErrRegistrationEmailRequired)
+	}
+	user, err := service.Register(ctx, dto.RegisterReq{Username: "carol", Password: "Carol#Pass1", Email: "carol@example.com", Lang: "en-US"})
+	if err != nil || user.Status != model.UserPendingVerify {
+		t.Fatalf("Register() = %+v, %v, want pending verify", user, err)
+	}
+	if _, err := service.Register(ctx, dto.RegisterReq{Username: "dave", Password: "Dave#Pass1", Email: "Carol@Example.com"}); !errors.Is(err, ErrRegistrationEmailTaken) {
+		t.Fatalf("Register() duplicate email error = %v, want %v", err, ErrRegistrationEmailTaken)
+	}
+	if _, err := service.Login(ctx, dto.LoginReq{Username: "carol", Password: "Carol#Pass1"}); !errors.Is(err, errcode.AccountPendingVerify) {
+		t.Fatalf("Login() before verify error = %v, want %v", err, errcode.AccountPendingVerify)
+	}
+
+	msg, ok := srv.Wait(2 * time.Second)
+	if !ok {
+		t.Fatal("Register() sent no verify message")
+	}
+	if msg.To[0] != "carol@example.com" || msg.Subject() != "Verify your email address" {
+		t.Fatalf("message to = %v subject = %q", msg.To, msg.Subject())
+	}
+	link, err := url.Parse(regexp.MustCompile(`http://app\.local/\S+`).FindString(msg.Body()))
+	if err != nil {
+		t.Fatalf("message body = %q", msg.Body())
+	}
+	fragment, err := url.Parse(link.Fragment)
+	if err != nil {
+		t.Fatalf("verify link = %q", link)
+	}
+	token := fragment.Query().Get("token")
+	var stored model.SysEmailVerifyToken
+	if err := gormDB.Where("user_id = ?", user.ID).First(&stored).Error; err != nil {
+		t.Fatalf("load verify token error = %v", err)
+	}
+	if ttl := time.Until(stored.ExpiresAt); ttl <= 119*time.Minute || ttl > 2*time.Hour {
+		t.Fatalf("verify token ttl = %v, want verify_ttl 2h", ttl)
+	}
+
+	if err := service.VerifyRegistration(ctx, dto.RegisterVerifyReq{Token: "bogus"}); !errors.Is(err, ErrRegisterTokenInvalid) {
+		t.Fatalf("VerifyRegistration(bogus) error = %v, want %v", err, ErrRegisterTokenInvalid)
+	}
+	if err := service.VerifyRegistration(ctx, dto.RegisterVerifyReq{Token: token}); err != nil {
+		t.Fatalf("VerifyRegistration() error = %v", err)
+	}
+	if err := service.VerifyRegistration(ctx, dto.RegisterVerifyReq{Token: token}); !errors.Is(err, ErrRegisterTokenInvalid) {
+		t.Fatalf("VerifyRegistration(used) error = %v, want %v", err, ErrRegisterTokenInvalid)
+	}
+	if _, err := service.Login(ctx, dto.LoginReq{Username: "carol", Password: "Carol#Pass1"}); err != nil {
+		t.Fatalf("Login() after verify error = %v", err)
+	}
+}
+
+func TestUserServiceRegistrationApproval(t *testing.T) {
+	service, gormDB := newUserTestService(t, true)
+	seedAuthorities(t, gormDB, 1, 2)
+	// alice 作为审核人
+	if err := gormDB.Exec("INSERT INTO sys_user_authorities (user_id, authority_id) VALUES (1, 1)").Error; err != nil {
+		t.Fatalf("seed approver error = %v", err)
+	}
+	service.(*UserService).svcCtx.Config.Registration = config.Registration{Mode: config.RegistrationApproval}
+	ctx := context.Background()
+
+	carol, err := service.Register(ctx, dto.RegisterReq{Username: "carol", Password: "Carol#Pass1"})
+	if err != nil || carol.Status != model.UserPendingApproval {
+		t.Fatalf("Register() = %+v, %v, want pending approval", carol, err)
+	}
+	dave, err := service.Register(ctx, dto.RegisterReq{Username: "dave", Password: "Dave#Pass1"})
+	if err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	if _, err := service.Login(ctx, dto.LoginReq{Username: "carol", Password: "Carol#Pass1"}); !errors.Is(err, errcode.AccountPendingApproval) {
+		t.Fatalf("Login() before approval error = %v, want %v", err, errcode.AccountPendingApproval)
+	}
+	assertNotices(t, gormDB, 1, "新的注册申请待审核", 2)
+
+	list, total, err := service.GetRegistrationList(ctx, dto.SearchRegistrationReq{})
+	if err != nil || total != 2 || len(list) != 2 || list[0].Username != "carol" {
+		t.Fatalf("GetRegistrationList() = %v, %d, %v", list, total, err)
+	}
+
+	if err := service.ApproveRegistration(ctx, 1, dto.ReviewRegistrationReq{ID: carol.ID}); err != nil {
+		t.Fatalf("ApproveRegistration() error = %v", err)
+	}
+	if err := service.RejectRegistration(ctx, 1, dto.ReviewRegistrationReq{ID: carol.ID}); !errors.Is(err, ErrRegistrationNotPending) {
+		t.Fatalf("RejectRegistration(approved) error = %v, want %v", err, ErrRegistrationNotPending)
+	}
+	if _, err := service.Login(ctx, dto.LoginReq{Username: "carol", Password: "Carol#Pass1"}); err != nil {
+		t.Fatalf("Login() after approval error = %v", err)
+	}
+	assertNotices(t, gormDB, carol.ID, "注册申请已通过", 1)
+	assertNotices(t, gormDB, 1, "注册申请已通过", 1)
+
+	if err := service.RejectRegistration(ctx, 1, dto.ReviewRegistrationReq{ID: dave.ID, Reason: "信息不完整"}); err != nil {
+		t.Fatalf("RejectRegistration() error = %v", err)
+	}
+	if _, err := service.Login(ctx, dto.LoginReq{Username: "dave", Password: "Dave#Pass1"}); !errors.Is(err, errcode.AccountRejected) {
+		t.Fatalf("Login() after reject error = %v, want %v", err, errcode.AccountRejected)
+	}
+	assertNotices(t, gormDB, 1, "注册申请已拒绝", 1)
+
+	if _, total, _ := service.GetRegistrationList(ctx, dto.SearchRegistrationReq{}); total != 0 {
+		t.Fatalf("GetRegistrationList() pending total = %d, want 0", total)
+	}
+	if list, total, _ := service.GetRegistrationList(ctx, dto.SearchRegistrationReq{Status: model.UserRejected}); total != 1 || list[0].ID != dave.ID {
+		t.Fatalf("GetRegistrationList(rejected) = %v, %d", list, total)
+	}
+}
+
+// assertNotices 校验用户收到指定标题的站内通知数量
+func assertNotices(t *testing.T, gormDB *gorm.DB, userID uint, title string, want int64) {
+	t.Helper()
+	var n int64
+	if err := gormDB.Table("sys_notice_receivers r").
+		Joins("JOIN sys_notices n ON n.id = r.notice_id").
+		Where("r.user_id = ? AND n.title = ?", userID, title).
+		Count(&n).Error; err != nil {
+		t.Fatalf("count notices error = %v", err)
+	}
+	if n != want {
+		t.Fatalf("user %d notices %q = %d, want %d", userID, title, n, want)
+	}
+}
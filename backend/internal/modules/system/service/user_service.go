@@ -6,14 +6,20 @@ import (
 	"errors"
 	"fmt"
 	"gorm.io/datatypes"
+	"sort"
 	"time"
 
+	"github.com/CIPFZ/gowebframe/internal/core/captcha"
 	"github.com/CIPFZ/gowebframe/internal/core/claims"
+	corejwt "github.com/CIPFZ/gowebframe/internal/core/jwt"
 	logger "github.com/CIPFZ/gowebframe/internal/core/log"
+	"github.com/CIPFZ/gowebframe/internal/core/oidc"
+	"github.com/CIPFZ/gowebframe/internal/core/session"
 	"github.com/CIPFZ/gowebframe/internal/modules/system/dto"
 	"github.com/CIPFZ/gowebframe/internal/modules/system/model"
 	"github.com/CIPFZ/gowebframe/internal/modules/system/repository"
 	"github.com/CIPFZ/gowebframe/internal/svc"
+	"github.com/CIPFZ/gowebframe/pkg/errcode"
 	"github.com/CIPFZ/gowebframe/pkg/utils"
 
 	"github.com/google/uuid"
@@ -24,13 +30,48 @@ import (
 type IUserService interface {
 	Register(ctx context.Context, req dto.RegisterReq) (*model.SysUser, error)
 	Login(ctx context.Context, req dto.LoginReq) (*dto.LoginResponse, error)
+	GenerateCaptcha(ctx context.Context, ip string) (*dto.CaptchaResponse, error)
+	UnlockUser(ctx context.Context, id uint, operatorID uint, ip string) error
+	LoginMfa(ctx context.Context, req dto.MfaLoginReq) (*dto.LoginResponse, error)
+	SetupMfaChallenge(ctx context.Context, mfaToken string) (*dto.MfaSetupResponse, error)
+	SetupMfa(ctx context.Context, userID uint) (*dto.MfaSetupResponse, error)
+	EnableMfa(ctx context.Context, userID uint, code string) ([]string, error)
+	DisableMfa(ctx context.Context, userID uint, code string) error
+	RegenerateRecoveryCodes(ctx context.Context, userID uint, code string) ([]string, error)
+	BeginPasskeyRegistration(ctx context.Context, userID uint) (*dto.PasskeyOptionsResponse, error)
+	FinishPasskeyRegistration(ctx context.Context, userID uint, req dto.PasskeyRegisterReq) (*model.SysUserPasskey, error)
+	ListPasskeys(ctx context.Context, userID uint) ([]model.SysUserPasskey, error)
+	RevokePasskey(ctx context.Context, userID, id uint) error
+	RevokeUserPasskey(ctx context.Context, id uint) error
+	BeginPasskeyLogin(ctx context.Context) (*dto.PasskeyOptionsResponse, error)
+	LoginPasskey(ctx context.Context, req dto.PasskeyLoginReq) (*dto.LoginResponse, error)
+	BeginMfaPasskey(ctx context.Context, mfaToken string) (*dto.PasskeyOptionsResponse, error)
+	OidcProviders() []oidc.ProviderInfo
+	OidcAuthURL(ctx context.Context, provider, redirect string) (authURL string, state string, err error)
+	OidcCallback(ctx context.Context, req dto.OidcCallbackReq) (ticket string, redirect string, err error)
+	OidcExchangeTicket(ctx context.Context, ticket string) (*dto.LoginResponse, error)
+	SyncLdapGroups(ctx context.Context) (int, error)
+	LoginWithNewPassword(ctx context.Context, req dto.PasswordExpiredReq) (*dto.LoginResponse, error)
+	ChangePassword(ctx context.Context, userID uint, currentSessionID string, req dto.ChangePasswordReq) error
+	ForgotPassword(ctx context.Context, req dto.ForgotPasswordReq) error
+	ResetPasswordByToken(ctx context.Context, req dto.ResetPasswordByTokenReq) error
+	VerifyRegistration(ctx context.Context, req dto.RegisterVerifyReq) error
+	GetRegistrationList(ctx context.Context, req dto.SearchRegistrationReq) ([]model.SysUser, int64, error)
+	ApproveRegistration(ctx context.Context, reviewerID uint, req dto.ReviewRegistrationReq) error
+	RejectRegistration(ctx context.Context, reviewerID uint, req dto.ReviewRegistrationReq) error
+	Impersonate(ctx context.Context, impersonatorID uint, req dto.ImpersonateReq, ip, userAgent string) (*dto.LoginResponse, error)
+	GetImpersonationInfo(ctx context.Context, impersonatorID uint, sessionID string) *dto.ImpersonationInfo
 	GetUserInfo(ctx context.Context, userUUID uuid.UUID) (*model.SysUser, error)
-	generateJwtToken(user *model.SysUser) (string, claims.CustomClaims, error)
-	Logout(ctx context.Context, token string) error
+	generateJwtToken(user *model.SysUser, sessionID string) (string, claims.CustomClaims, error)
+	RefreshToken(ctx context.Context, refreshToken string) (*dto.LoginResponse, error)
+	Logout(ctx context.Context, token string, refreshToken string) error
+	ListSessions(ctx context.Context, userID uint, currentSessionID string) ([]dto.SessionInfo, error)
+	KickSession(ctx context.Context, userID uint, sessionID string) error
+	KickAllSessions(ctx context.Context, userID uint) error
 	GetUserList(ctx context.Context, req dto.SearchUserReq) (list []model.SysUser, total int64, err error)
 	AddUser(ctx context.Context, req dto.AddUserReq) error
 	UpdateUser(ctx context.Context, req dto.UpdateUserReq) error
-	SwitchAuthority(ctx context.Context, uuid uuid.UUID, authorityId uint) (*dto.LoginResponse, error)
+	SwitchAuthority(ctx context.Context, uuid uuid.UUID, authorityId uint, sessionID string) (*dto.LoginResponse, error)
 	DeleteUser(ctx context.Context, id uint) error
 	ResetPassword(ctx context.Context, req dto.ResetPasswordReq) error
 	UpdateSelfInfo(ctx context.Context, uid uuid.UUID, req dto.UpdateSelfInfoReq) error
@@ -39,22 +80,56 @@ type IUserService interface {
 }
 
 type UserService struct {
-	svcCtx   *svc.ServiceContext
-	userRepo repository.IUserRepository
+	svcCtx       *svc.ServiceContext
+	userRepo     repository.IUserRepository
+	refreshRepo  repository.IRefreshTokenRepository
+	mfaRepo      repository.IMfaRepository
+	identityRepo repository.IUserIdentityRepository
+	resetRepo    repository.IPasswordResetRepository
+	regRepo      repository.IRegistrationRepository
+	noticeRepo   repository.INoticeRepository
+	loginLogRepo repository.ILoginLogRepository
+	passkeyRepo  repository.IPasskeyRepository
+	// authenticators 账号密码登录的认证方式，按顺序尝试
+	authenticators []Authenticator
+	captcha        *captcha.Captcha
 }
 
 // NewUserService 构造函数
 // 注意：这里我们传入 repo
-func NewUserService(svcCtx *svc.ServiceContext, userRepo repository.IUserRepository) IUserService {
-	return &UserService{
-		svcCtx:   svcCtx,
-		userRepo: userRepo,
+func NewUserService(svcCtx *svc.ServiceContext, userRepo repository.IUserRepository, refreshRepo repository.IRefreshTokenRepository, mfaRepo repository.IMfaRepository, identityRepo repository.IUserIdentityRepository, resetRepo repository.IPasswordResetRepository, regRepo repository.IRegistrationRepository, noticeRepo repository.INoticeRepository, loginLogRepo repository.ILoginLogRepository, passkeyRepo repository.IPasskeyRepository) IUserService {
+	s := &UserService{
+		svcCtx:       svcCtx,
+		userRepo:     userRepo,
+		refreshRepo:  refreshRepo,
+		mfaRepo:      mfaRepo,
+		identityRepo: identityRepo,
+		resetRepo:    resetRepo,
+		regRepo:      regRepo,
+		noticeRepo:   noticeRepo,
+		loginLogRepo: loginLogRepo,
+		passkeyRepo:  passkeyRepo,
 	}
+	// 登录验证码与失败计数依赖缓存，未配置时不启用
+	if svcCtx.Cache != nil && svcCtx.Config != nil {
+		s.captcha = captcha.New(svcCtx.Cache, svcCtx.Config.Captcha)
+	}
+	// 本地密码优先，目录认证作为补充
+	s.authenticators = []Authenticator{NewPasswordAuthenticator(userRepo)}
+	if svcCtx.LDAP != nil {
+		s.authenticators = append(s.authenticators, &ldapAuthenticator{s: s, client: svcCtx.LDAP})
+	}
+	return s
 }
 
 // Register 用户注册实现
 func (s *UserService) Register(ctx context.Context, req dto.RegisterReq) (*model.SysUser, error) {
 	log := logger.GetLogger(ctx)
+	// 1. 按注册模式确定初始状态
+	status, err := s.registrationStatus(ctx, req)
+	if err != nil {
+		return nil, err
+	}
 	_, searchErr := s.userRepo.FindByUsername(ctx, req.Username)
 
 	if searchErr == nil {
@@ -65,23 +140,28 @@ func (s *UserService) Register(ctx context.Context, req dto.RegisterReq) (*model
 		return nil, errors.New("系统内部错误")
 	}
 
-	// 2. 密码加密
+	// 2. 密码策略校验与加密
+	if err := s.checkNewPassword(ctx, nil, req.Username, req.Password); err != nil {
+		return nil, err
+	}
 	hashPwd, err := utils.BcryptHash(req.Password)
 	if err != nil {
 		return nil, fmt.Errorf("密码加密失败: %v", err)
 	}
 
 	// 3. 构建用户模型
+	now := time.Now()
 	newUser := model.SysUser{
-		Username:    req.Username,
-		Password:    hashPwd,
-		NickName:    req.NickName,
-		Phone:       req.Phone,
-		Email:       req.Email,
-		Avatar:      model.DefaultUserAvatar,
-		Status:      model.UserActive, // 默认正常
-		AuthorityID: model.DefaultUserAuthorityID,
-		UUID:        uuid.New(),
+		Username:          req.Username,
+		Password:          hashPwd,
+		PasswordChangedAt: &now,
+		NickName:          req.NickName,
+		Phone:             req.Phone,
+		Email:             req.Email,
+		Avatar:            model.DefaultUserAvatar,
+		Status:            status,
+		AuthorityID:       model.DefaultUserAuthorityID,
+		UUID:              uuid.New(),
 	}
 
 	// 4. 插入数据库
@@ -90,44 +170,336 @@ func (s *UserService) Register(ctx context.Context, req dto.RegisterReq) (*model
 		return nil, errors.New("注册失败，请稍后重试")
 	}
 
+	// 5. 发送验证邮件或通知管理员审核
+	s.afterRegister(ctx, &newUser, req.Lang)
 	return &newUser, nil
 }
 
 // Login 用户登录
 func (s *UserService) Login(ctx context.Context, req dto.LoginReq) (*dto.LoginResponse, error) {
+	user, method, err := s.verifyLogin(ctx, req)
+	if err != nil {
+		s.logLoginFailure(ctx, nil, req.Username, loginSource{Method: model.LoginMethodPassword, IP: req.IP, UserAgent: req.UserAgent}, err)
+		return nil, err
+	}
+
+	// 本地密码过期或被管理员要求修改时，需先调用 /user/login/password 设置新密码
+	if method == passwordAuthenticatorName && s.passwordChangeRequired(user) {
+		return nil, errcode.PasswordChangeRequired
+	}
+
+	return s.finishLogin(ctx, user, loginSource{Method: method, IP: req.IP, UserAgent: req.UserAgent})
+}
+
+// verifyLogin 账号锁定与验证码校验后依次尝试各认证方式 (本地密码、LDAP)，返回通过的认证方式
+func (s *UserService) verifyLogin(ctx context.Context, req dto.LoginReq) (*model.SysUser, string, error) {
+	if err := s.checkLoginGuard(ctx, req); err != nil {
+		return nil, "", err
+	}
+
+	user, method, err := s.authenticate(ctx, req)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if err := checkUserStatus(user); err != nil {
+		return nil, "", err
+	}
+	if err := s.checkLoginRegion(ctx, user, req.IP); err != nil {
+		return nil, "", err
+	}
+	return user, method, nil
+}
+
+// finishLogin 启用或被角色强制 MFA 时，只返回挑战令牌，验证通过后再签发 token
+func (s *UserService) finishLogin(ctx context.Context, user *model.SysUser, src loginSource) (*dto.LoginResponse, error) {
+	if resp, required, err := s.mfaChallenge(ctx, user, src.Method); required {
+		if err != nil {
+			logger.GetLogger(ctx).Error("mfa_challenge_failed", zap.Error(err))
+			return nil, errors.New("获取Token失败")
+		}
+		return resp, nil
+	}
+
+	return s.completeLogin(ctx, user, src)
+}
+
+// authenticate 按顺序尝试认证方式，全部未通过时记录登录失败
+func (s *UserService) authenticate(ctx context.Context, req dto.LoginReq) (*model.SysUser, string, error) {
+	log := logger.GetLogger(ctx)
+	var failedUserID uint
+	for _, a := range s.authenticators {
+		user, err := a.Authenticate(ctx, req.Username, req.Password)
+		if err == nil {
+			return user, a.Name(), nil
+		}
+		if user != nil && failedUserID == 0 {
+			failedUserID = user.ID
+		}
+		switch {
+		case errors.Is(err, ErrAuthenticatorSkipped), errors.Is(err, ErrInvalidCredentials):
+		case errors.Is(err, ErrAccountNotProvisioned), errors.Is(err, ErrNoAuthorityMapped), errors.Is(err, ErrUsernameTaken):
+			// 密码已通过校验，但无法对应到本地账号
+			log.Warn("login_account_unavailable", zap.String("authenticator", a.Name()), zap.Error(err))
+			return nil, "", err
+		default:
+			log.Error("login_authenticator_error", zap.String("authenticator", a.Name()), zap.Error(err))
+		}
+	}
+	log.Warn("login_failed", zap.String("username", req.Username))
+	s.recordLoginFailure(ctx, req, failedUserID)
+	return nil, "", ErrInvalidCredentials
+}
+
+// RefreshToken 使用 refresh token 换取新的 token 对
+// 每个 refresh token 只能使用一次；已轮换的 token 再次出现说明可能被盗用，整族吊销
+func (s *UserService) RefreshToken(ctx context.Context, refreshToken string) (*dto.LoginResponse, error) {
 	log := logger.GetLogger(ctx)
-	// 1. 查询用户 (这里不需要 Preload 太多关联表了，因为不返回用户信息，只需验证密码)
-	user, err := s.userRepo.FindByUsername(ctx, req.Username)
-	// 验证用户是否存在、密码比对、状态检查逻辑保持不变
+	if refreshToken == "" {
+		return nil, corejwt.ErrRefreshTokenInvalid
+	}
+
+	stored, err := s.refreshRepo.FindByHash(ctx, corejwt.HashRefreshToken(refreshToken))
 	if err != nil {
-		log.Error("login_failed_user_not_found", zap.Error(err), zap.String("username", req.Username))
-		return nil, errors.New("用户名或密码错误")
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, corejwt.ErrRefreshTokenInvalid
+		}
+		return nil, err
 	}
 
-	if !utils.BcryptCheck(req.Password, user.Password) {
-		return nil, errors.New("用户名或密码错误")
+	now := time.Now()
+	if stored.RevokedAt != nil {
+		return nil, corejwt.ErrRefreshTokenInvalid
+	}
+	if stored.RotatedAt != nil {
+		s.revokeFamilyOnReuse(ctx, stored)
+		return nil, corejwt.ErrRefreshTokenReused
+	}
+	if now.After(stored.ExpiresAt) {
+		return nil, corejwt.ErrRefreshTokenExpired
 	}
 
-	if user.Status == model.UserInactive {
-		return nil, errors.New("此用户已经被禁用")
+	// 并发刷新时只有一个请求能完成轮换，其余请求按重放处理
+	ok, err := s.refreshRepo.MarkRotated(ctx, stored.ID, now)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		s.revokeFamilyOnReuse(ctx, stored)
+		return nil, corejwt.ErrRefreshTokenReused
 	}
 
-	// 2. 签发 Token
-	token, c, err := s.generateJwtToken(user)
+	user, err := s.userRepo.FindById(ctx, stored.UserID)
 	if err != nil {
-		log.Error("generate_token_failed", zap.Error(err))
+		return nil, corejwt.ErrRefreshTokenInvalid
+	}
+	if err := checkUserStatus(user); err != nil {
+		_ = s.revokeSessions(ctx, stored.FamilyID)
+		return nil, err
+	}
+
+	// 会话已被踢出时不再允许续期
+	var sess *session.Session
+	if s.svcCtx.Sessions != nil {
+		sess, err = s.svcCtx.Sessions.Get(ctx, stored.FamilyID)
+		if err != nil {
+			if errors.Is(err, session.ErrSessionNotFound) {
+				_ = s.refreshRepo.RevokeFamily(ctx, stored.FamilyID, now)
+				return nil, corejwt.ErrRefreshTokenInvalid
+			}
+			return nil, err
+		}
+	}
+
+	resp, err := s.issueTokenPair(ctx, user, stored.FamilyID)
+	if err != nil {
+		log.Error("refresh_token_issue_failed", zap.Error(err))
 		return nil, errors.New("获取Token失败")
 	}
 
-	// 3. 只返回 Token 和 过期时间
+	if sess != nil {
+		sess.LastSeenAt = now
+		sess.ExpiresAt = time.UnixMilli(resp.RefreshExpiresAt)
+		if err := s.svcCtx.Sessions.Save(ctx, sess); err != nil {
+			log.Error("session_extend_failed", zap.Error(err))
+		}
+	}
+	return resp, nil
+}
+
+// startSession 登记新会话；未开启多点登录时吊销该用户的其它会话
+func (s *UserService) startSession(ctx context.Context, userID uint, sessionID, ip, userAgent string, expiresAt time.Time) error {
+	store := s.svcCtx.Sessions
+	if store == nil {
+		return nil
+	}
+
+	now := time.Now()
+	if err := store.Save(ctx, &session.Session{
+		ID:         sessionID,
+		UserID:     userID,
+		Device:     session.DeviceFromUserAgent(userAgent),
+		IP:         ip,
+		UserAgent:  userAgent,
+		CreatedAt:  now,
+		LastSeenAt: now,
+		ExpiresAt:  expiresAt,
+	}); err != nil {
+		return err
+	}
+
+	if s.svcCtx.Config == nil || s.svcCtx.Config.System.UseMultipoint {
+		return nil
+	}
+	return s.revokeOtherSessions(ctx, userID, sessionID)
+}
+
+// revokeOtherSessions 删除用户除 currentSessionID 外的全部会话，并吊销对应的 refresh token 令牌族
+func (s *UserService) revokeOtherSessions(ctx context.Context, userID uint, currentSessionID string) error {
+	if s.svcCtx.Sessions != nil {
+		sessions, err := s.svcCtx.Sessions.ListByUser(ctx, userID)
+		if err != nil {
+			return err
+		}
+		var others []string
+		for _, item := range sessions {
+			if item.ID != currentSessionID {
+				others = append(others, item.ID)
+			}
+		}
+		if len(others) > 0 {
+			if err := s.svcCtx.Sessions.Delete(ctx, others...); err != nil {
+				return err
+			}
+		}
+	}
+	return s.refreshRepo.RevokeByUserIDExcept(ctx, userID, currentSessionID, time.Now())
+}
+
+// revokeSessions 删除会话并吊销对应的 refresh token 令牌族
+func (s *UserService) revokeSessions(ctx context.Context, sessionIDs ...string) error {
+	if len(sessionIDs) == 0 {
+		return nil
+	}
+	if s.svcCtx.Sessions != nil {
+		if err := s.svcCtx.Sessions.Delete(ctx, sessionIDs...); err != nil {
+			return err
+		}
+	}
+	now := time.Now()
+	for _, id := range sessionIDs {
+		if err := s.refreshRepo.RevokeFamily(ctx, id, now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ListSessions 列出用户的登录会话
+func (s *UserService) ListSessions(ctx context.Context, userID uint, currentSessionID string) ([]dto.SessionInfo, error) {
+	if s.svcCtx.Sessions == nil {
+		return []dto.SessionInfo{}, nil
+	}
+	sessions, err := s.svcCtx.Sessions.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].LastSeenAt.After(sessions[j].LastSeenAt) })
+
+	list := make([]dto.SessionInfo, 0, len(sessions))
+	for _, item := range sessions {
+		list = append(list, dto.SessionInfo{
+			ID:         item.ID,
+			Device:     item.Device,
+			IP:         item.IP,
+			UserAgent:  item.UserAgent,
+			CreatedAt:  item.CreatedAt,
+			LastSeenAt: item.LastSeenAt,
+			ExpiresAt:  item.ExpiresAt,
+			Current:    item.ID == currentSessionID,
+
+			ImpersonatorID: item.ImpersonatorID,
+		})
+	}
+	return list, nil
+}
+
+// KickSession 踢出用户的某个会话，只能操作属于该用户的会话
+func (s *UserService) KickSession(ctx context.Context, userID uint, sessionID string) error {
+	if s.svcCtx.Sessions == nil {
+		return session.ErrSessionNotFound
+	}
+	sess, err := s.svcCtx.Sessions.Get(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	if sess.UserID != userID {
+		return session.ErrSessionNotFound
+	}
+	return s.revokeSessions(ctx, sessionID)
+}
+
+// KickAllSessions 踢出用户的全部会话
+func (s *UserService) KickAllSessions(ctx context.Context, userID uint) error {
+	if s.svcCtx.Sessions != nil {
+		sessions, err := s.svcCtx.Sessions.ListByUser(ctx, userID)
+		if err != nil {
+			return err
+		}
+		ids := make([]string, 0, len(sessions))
+		for _, item := range sessions {
+			ids = append(ids, item.ID)
+		}
+		if err := s.svcCtx.Sessions.Delete(ctx, ids...); err != nil {
+			return err
+		}
+	}
+	return s.refreshRepo.RevokeByUserID(ctx, userID, time.Now())
+}
+
+// revokeFamilyOnReuse 检测到重放时吊销整个令牌族，并删除对应会话 (令牌族 ID 即会话 ID)，
+// 已签发给攻击者的 access token 随之无法通过会话校验
+func (s *UserService) revokeFamilyOnReuse(ctx context.Context, stored *model.SysRefreshToken) {
+	logger.GetLogger(ctx).Warn("refresh_token_reuse_detected",
+		zap.Uint("userId", stored.UserID),
+		zap.String("familyId", stored.FamilyID),
+	)
+	if err := s.revokeSessions(ctx, stored.FamilyID); err != nil {
+		logger.GetLogger(ctx).Error("refresh_token_revoke_family_failed", zap.Error(err))
+	}
+}
+
+// issueTokenPair 签发 access token，并在指定令牌族下落库一个新的 refresh token
+func (s *UserService) issueTokenPair(ctx context.Context, user *model.SysUser, familyID string) (*dto.LoginResponse, error) {
+	token, c, err := s.generateJwtToken(user, familyID)
+	if err != nil {
+		return nil, err
+	}
+
+	rawRefresh, refreshHash, err := corejwt.NewRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+	refreshExpiresAt := time.Now().Add(s.svcCtx.JWT.RefreshExpiresTime())
+	if err := s.refreshRepo.Create(ctx, &model.SysRefreshToken{
+		UserID:    user.ID,
+		FamilyID:  familyID,
+		TokenHash: refreshHash,
+		ExpiresAt: refreshExpiresAt,
+	}); err != nil {
+		return nil, err
+	}
+
 	return &dto.LoginResponse{
-		Token:     token,
-		ExpiresAt: c.RegisteredClaims.ExpiresAt.Unix() * 1000,
+		Token:            token,
+		ExpiresAt:        c.RegisteredClaims.ExpiresAt.Unix() * 1000,
+		RefreshToken:     rawRefresh,
+		RefreshExpiresAt: refreshExpiresAt.Unix() * 1000,
 	}, nil
 }
 
 // generateJwtToken 内部辅助函数
-func (s *UserService) generateJwtToken(user *model.SysUser) (string, claims.CustomClaims, error) {
+func (s *UserService) generateJwtToken(user *model.SysUser, sessionID string) (string, claims.CustomClaims, error) {
 	// 构造 Claims
 	customClaims := s.svcCtx.JWT.CreateClaims(dto.BaseClaims{
 		UUID:        user.UUID,
@@ -135,6 +507,7 @@ func (s *UserService) generateJwtToken(user *model.SysUser) (string, claims.Cust
 		NickName:    user.NickName,
 		Username:    user.Username,
 		AuthorityId: user.AuthorityID,
+		SessionID:   sessionID,
 	})
 
 	token, err := s.svcCtx.JWT.CreateToken(customClaims)
@@ -157,10 +530,21 @@ func (s *UserService) GetUserInfo(ctx context.Context, userUUID uuid.UUID) (*mod
 }
 
 // Logout 用户登出实现
-func (s *UserService) Logout(ctx context.Context, token string) error {
+func (s *UserService) Logout(ctx context.Context, token string, refreshToken string) error {
 	log := logger.GetLogger(ctx)
 	j := s.svcCtx.JWT
 
+	// 0. 吊销 refresh token 所在的会话，防止登出后继续续期
+	if refreshToken != "" {
+		stored, err := s.refreshRepo.FindByHash(ctx, corejwt.HashRefreshToken(refreshToken))
+		if err == nil {
+			if revokeErr := s.revokeSessions(ctx, stored.FamilyID); revokeErr != nil {
+				log.Error("logout_revoke_session_failed", zap.Error(revokeErr))
+				return revokeErr
+			}
+		}
+	}
+
 	// 1. 解析 Token 以获取过期时间
 	// 我们不关心 ParseToken 是否报错（例如过期），因为如果它无效，登出目的已经达到了
 	c, err := j.ParseToken(token)
@@ -169,16 +553,23 @@ func (s *UserService) Logout(ctx context.Context, token string) error {
 		return nil
 	}
 
-	// 2. 计算剩余有效期
+	// 2. 删除 access token 所属会话
+	if c.SessionID != "" {
+		if revokeErr := s.revokeSessions(ctx, c.SessionID); revokeErr != nil {
+			log.Error("logout_revoke_session_failed", zap.Error(revokeErr))
+			return revokeErr
+		}
+	}
+
+	// 3. 计算剩余有效期
 	duration := c.ExpiresAt.Sub(time.Now())
 	if duration <= 0 {
 		return nil // 已经过期，不需要加入黑名单
 	}
 
-	// 3. 加入 Redis 黑名单
-	// 调用我们在 pkg/utils/jwt.go 中实现的 SetBlacklist
+	// 4. 加入 Redis 黑名单
 	if insertErr := j.SetBlacklist(ctx, token, duration); insertErr != nil {
-		log.Error("logout_blacklist_failed", zap.Error(err))
+		log.Error("logout_blacklist_failed", zap.Error(insertErr))
 		return insertErr
 	}
 
@@ -203,23 +594,29 @@ func (s *UserService) AddUser(ctx context.Context, req dto.AddUserReq) error {
 		return errors.New("请至少选择一个角色")
 	}
 
-	// 2. 加密密码
+	// 2. 密码策略校验与加密
+	if err := s.checkNewPassword(ctx, nil, req.Username, req.Password); err != nil {
+		return err
+	}
 	hashPwd, err := utils.BcryptHash(req.Password)
 	if err != nil {
 		return err
 	}
 
 	// 3. 构建用户
+	now := time.Now()
 	newUser := model.SysUser{
-		UUID:        uuid.New(),
-		Username:    req.Username,
-		Password:    hashPwd,
-		NickName:    req.NickName,
-		Avatar:      model.DefaultUserAvatar,
-		AuthorityID: req.AuthorityIds[0],
-		Phone:       req.Phone,
-		Email:       req.Email,
-		Status:      model.UserActive,
+		UUID:                  uuid.New(),
+		Username:              req.Username,
+		Password:              hashPwd,
+		PasswordChangedAt:     &now,
+		RequirePasswordChange: req.RequirePasswordChange,
+		NickName:              req.NickName,
+		Avatar:                model.DefaultUserAvatar,
+		AuthorityID:           req.AuthorityIds[0],
+		Phone:                 req.Phone,
+		Email:                 req.Email,
+		Status:                model.UserActive,
 	}
 
 	// 4. 处理多角色
@@ -247,7 +644,7 @@ func (s *UserService) UpdateUser(ctx context.Context, req dto.UpdateUserReq) err
 }
 
 // SwitchAuthority 切换角色
-func (s *UserService) SwitchAuthority(ctx context.Context, uuid uuid.UUID, authorityId uint) (*dto.LoginResponse, error) {
+func (s *UserService) SwitchAuthority(ctx context.Context, uuid uuid.UUID, authorityId uint, sessionID string) (*dto.LoginResponse, error) {
 	user, searchErr := s.userRepo.FindByUuid(ctx, uuid)
 	if searchErr != nil {
 		return nil, searchErr
@@ -273,8 +670,8 @@ func (s *UserService) SwitchAuthority(ctx context.Context, uuid uuid.UUID, autho
 	// 4. 更新内存对象以便签发 Token
 	user.AuthorityID = authorityId
 
-	// 5. 签发新 Token (因为 Token 里包含 AuthorityId，切换角色必须换 Token)
-	token, c, err := s.generateJwtToken(user)
+	// 5. 签发新 Token (因为 Token 里包含 AuthorityId，切换角色必须换 Token)，沿用当前会话
+	token, c, err := s.generateJwtToken(user, sessionID)
 	if err != nil {
 		return nil, err
 	}
@@ -287,16 +684,26 @@ func (s *UserService) SwitchAuthority(ctx context.Context, uuid uuid.UUID, autho
 
 // DeleteUser 删除用户
 func (s *UserService) DeleteUser(ctx context.Context, id uint) error {
-	return s.userRepo.DeleteWithAssociations(ctx, id)
+	if err := s.userRepo.DeleteWithAssociations(ctx, id); err != nil {
+		return err
+	}
+	return s.KickAllSessions(ctx, id)
 }
 
 // ResetPassword 重置密码
+// 重置后踢出该用户所有会话，旧设备需重新登录
 func (s *UserService) ResetPassword(ctx context.Context, req dto.ResetPasswordReq) error {
-	hashPwd, err := utils.BcryptHash(req.Password)
+	user, err := s.userRepo.FindById(ctx, req.ID)
 	if err != nil {
+		return errors.New("用户不存在")
+	}
+	if err := s.checkNewPassword(ctx, user, user.Username, req.Password); err != nil {
+		return err
+	}
+	if err := s.setPassword(ctx, user.ID, req.Password, req.RequirePasswordChange); err != nil {
 		return err
 	}
-	return s.userRepo.ResetPassword(ctx, req.ID, hashPwd)
+	return s.KickAllSessions(ctx, req.ID)
 }
 
 // UpdateSelfInfo 更新个人基础信息
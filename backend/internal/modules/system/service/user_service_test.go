@@ -0,0 +1,228 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/CIPFZ/gowebframe/internal/core/config"
+	"github.com/CIPFZ/gowebframe/internal/core/db"
+	corejwt "github.com/CIPFZ/gowebframe/internal/core/jwt"
+	"github.com/CIPFZ/gowebframe/internal/core/session"
+	"github.com/CIPFZ/gowebframe/internal/modules/system/dto"
+	"github.com/CIPFZ/gowebframe/internal/modules/system/model"
+	"github.com/CIPFZ/gowebframe/internal/modules/system/repository"
+	"github.com/CIPFZ/gowebframe/internal/svc"
+	"github.com/CIPFZ/gowebframe/pkg/utils"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+func TestUserServiceRefreshTokenRotates(t *testing.T) {
+	service, _ := newUserTestService(t, true)
+
+	login, err := service.Login(context.Background(), dto.LoginReq{Username: "alice", Password: "Passw0rd!"})
+	if err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+	if login.Token == "" || login.RefreshToken == "" {
+		t.Fatalf("Login() = %#v, want access and refresh token", login)
+	}
+
+	refreshed, err := service.RefreshToken(context.Background(), login.RefreshToken)
+	if err != nil {
+		t.Fatalf("RefreshToken() error = %v", err)
+	}
+	if refreshed.RefreshToken == login.RefreshToken {
+		t.Fatal("RefreshToken() returned the same refresh token, want rotation")
+	}
+
+	if _, err := service.RefreshToken(context.Background(), refreshed.RefreshToken); err != nil {
+		t.Fatalf("RefreshToken() with rotated token error = %v", err)
+	}
+}
+
+func TestUserServiceRefreshTokenReuseRevokesFamily(t *testing.T) {
+	service, gormDB := newUserTestService(t, true)
+
+	login, err := service.Login(context.Background(), dto.LoginReq{Username: "alice", Password: "Passw0rd!"})
+	if err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+	refreshed, err := service.RefreshToken(context.Background(), login.RefreshToken)
+	if err != nil {
+		t.Fatalf("RefreshToken() error = %v", err)
+	}
+
+	// 重放已轮换的旧 token
+	if _, err := service.RefreshToken(context.Background(), login.RefreshToken); !errors.Is(err, corejwt.ErrRefreshTokenReused) {
+		t.Fatalf("RefreshToken() replay error = %v, want %v", err, corejwt.ErrRefreshTokenReused)
+	}
+	// 同族的最新 token 也应被吊销
+	if _, err := service.RefreshToken(context.Background(), refreshed.RefreshToken); !errors.Is(err, corejwt.ErrRefreshTokenInvalid) {
+		t.Fatalf("RefreshToken() after reuse error = %v, want %v", err, corejwt.ErrRefreshTokenInvalid)
+	}
+
+	var active int64
+	if err := gormDB.Model(&model.SysRefreshToken{}).Where("revoked_at IS NULL").Count(&active).Error; err != nil {
+		t.Fatalf("count active refresh tokens error = %v", err)
+	}
+	if active != 0 {
+		t.Fatalf("active refresh tokens = %d, want 0", active)
+	}
+
+	// 会话一并删除，已签发的 access token 无法再通过会话校验
+	accessClaims, err := svcJWT(service).ParseToken(refreshed.Token)
+	if err != nil {
+		t.Fatalf("ParseToken() error = %v", err)
+	}
+	if _, err := service.(*UserService).svcCtx.Sessions.Get(context.Background(), accessClaims.SessionID); !errors.Is(err, session.ErrSessionNotFound) {
+		t.Fatalf("Sessions.Get() after reuse error = %v, want %v", err, session.ErrSessionNotFound)
+	}
+}
+
+func TestUserServiceLogoutRevokesRefreshToken(t *testing.T) {
+	service, _ := newUserTestService(t, true)
+
+	login, err := service.Login(context.Background(), dto.LoginReq{Username: "alice", Password: "Passw0rd!"})
+	if err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+	if err := service.Logout(context.Background(), login.Token, login.RefreshToken); err != nil {
+		t.Fatalf("Logout() error = %v", err)
+	}
+	if !svcJWT(service).IsBlacklist(context.Background(), login.Token) {
+		t.Fatal("IsBlacklist() = false after Logout without redis")
+	}
+	if _, err := service.RefreshToken(context.Background(), login.RefreshToken); !errors.Is(err, corejwt.ErrRefreshTokenInvalid) {
+		t.Fatalf("RefreshToken() after logout error = %v, want %v", err, corejwt.ErrRefreshTokenInvalid)
+	}
+}
+
+func TestUserServiceLoginWithoutMultipointRevokesOlderSessions(t *testing.T) {
+	service, _ := newUserTestService(t, false)
+	ctx := context.Background()
+
+	first, err := service.Login(ctx, dto.LoginReq{Username: "alice", Password: "Passw0rd!", UserAgent: "Mozilla/5.0 (Windows NT 10.0) Chrome/120.0"})
+	if err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+	second, err := service.Login(ctx, dto.LoginReq{Username: "alice", Password: "Passw0rd!", IP: "10.0.0.2"})
+	if err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+
+	if _, err := service.RefreshToken(ctx, first.RefreshToken); !errors.Is(err, corejwt.ErrRefreshTokenInvalid) {
+		t.Fatalf("RefreshToken() for revoked session error = %v, want %v", err, corejwt.ErrRefreshTokenInvalid)
+	}
+
+	sessions, err := service.ListSessions(ctx, 1, "")
+	if err != nil {
+		t.Fatalf("ListSessions() error = %v", err)
+	}
+	if len(sessions) != 1 || sessions[0].IP != "10.0.0.2" {
+		t.Fatalf("ListSessions() = %#v, want only the newest session", sessions)
+	}
+
+	if err := service.KickSession(ctx, 1, sessions[0].ID); err != nil {
+		t.Fatalf("KickSession() error = %v", err)
+	}
+	if _, err := service.RefreshToken(ctx, second.RefreshToken); !errors.Is(err, corejwt.ErrRefreshTokenInvalid) {
+		t.Fatalf("RefreshToken() after kick error = %v, want %v", err, corejwt.ErrRefreshTokenInvalid)
+	}
+}
+
+func TestUserServiceKickSessionRejectsOtherUsersSession(t *testing.T) {
+	service, _ := newUserTestService(t, true)
+	ctx := context.Background()
+
+	if _, err := service.Login(ctx, dto.LoginReq{Username: "alice", Password: "Passw0rd!"}); err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+	sessions, err := service.ListSessions(ctx, 1, "")
+	if err != nil || len(sessions) != 1 {
+		t.Fatalf("ListSessions() = %#v, %v, want one session", sessions, err)
+	}
+	if err := service.KickSession(ctx, 2, sessions[0].ID); !errors.Is(err, session.ErrSessionNotFound) {
+		t.Fatalf("KickSession() by other user error = %v, want %v", err, session.ErrSessionNotFound)
+	}
+}
+
+func newUserTestService(t *testing.T, multipoint bool) (IUserService, *gorm.DB) {
+	t.Helper()
+
+	gormDB, err := db.InitDatabase(config.Database{
+		Driver: "sqlite3",
+		SQLite: config.SQLite{
+			Path:         filepath.Join(t.TempDir(), "user.db"),
+			MaxIdleConns: 1,
+			MaxOpenConns: 1,
+		},
+	}, zap.NewNop())
+	if err != nil {
+		t.Fatalf("InitDatabase() error = %v", err)
+	}
+	if err := gormDB.AutoMigrate(
+		&model.SysAuthority{},
+		&model.SysUser{},
+		&model.SysRefreshToken{},
+		&model.SysSession{},
+		&model.JwtBlacklist{},
+		&model.SysUserRecoveryCode{},
+		&model.SysUserIdentity{},
+		&model.SysUserPasskey{},
+		&model.SysUserPasswordHistory{},
+		&model.SysPasswordResetToken{},
+		&model.SysEmailVerifyToken{},
+		&model.SysNotice{},
+		&model.SysNoticeReceiver{},
+		&model.SysLoginLog{},
+	); err != nil {
+		t.Fatalf("AutoMigrate() error = %v", err)
+	}
+
+	sqlDB, err := gormDB.DB()
+	if err != nil {
+		t.Fatalf("DB() error = %v", err)
+	}
+	t.Cleanup(func() {
+		_ = sqlDB.Close()
+	})
+
+	hashPwd, err := utils.BcryptHash("Passw0rd!")
+	if err != nil {
+		t.Fatalf("BcryptHash() error = %v", err)
+	}
+	if err := gormDB.Create(&model.SysUser{
+		UUID:        uuid.New(),
+		Username:    "alice",
+		Password:    hashPwd,
+		NickName:    "Alice",
+		AuthorityID: model.DefaultUserAuthorityID,
+		Status:      model.UserActive,
+	}).Error; err != nil {
+		t.Fatalf("seed user error = %v", err)
+	}
+
+	j, err := corejwt.NewJWT(config.JWT{SigningKey: "test", Issuer: "test"}, zap.NewNop(), nil, gormDB)
+	if err != nil {
+		t.Fatalf("NewJWT() error = %v", err)
+	}
+	t.Cleanup(func() {
+		_ = j.Close(context.Background())
+	})
+	svcCtx := &svc.ServiceContext{
+		Config:   &config.Config{System: config.System{UseMultipoint: multipoint}},
+		DB:       gormDB,
+		Logger:   zap.NewNop(),
+		JWT:      j,
+		Sessions: session.NewGormStore(gormDB),
+	}
+	return NewUserService(svcCtx, repository.NewUserRepository(gormDB), repository.NewRefreshTokenRepository(gormDB), repository.NewMfaRepository(gormDB), repository.NewUserIdentityRepository(gormDB), repository.NewPasswordResetRepository(gormDB), repository.NewRegistrationRepository(gormDB), repository.NewNoticeRepository(gormDB), repository.NewLoginLogRepository(gormDB), repository.NewPasskeyRepository(gormDB)), gormDB
+}
+
+func svcJWT(service IUserService) *corejwt.JWT {
+	return service.(*UserService).svcCtx.JWT
+}
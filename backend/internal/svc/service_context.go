@@ -6,11 +6,21 @@ import (
 	"sync"
 	"time"
 
+	"github.com/CIPFZ/gowebframe/internal/core/apiusage"
 	"github.com/CIPFZ/gowebframe/internal/core/audit"
+	"github.com/CIPFZ/gowebframe/internal/core/cache"
 	"github.com/CIPFZ/gowebframe/internal/core/config"
+	"github.com/CIPFZ/gowebframe/internal/core/geoip"
 	"github.com/CIPFZ/gowebframe/internal/core/i18n"
 	"github.com/CIPFZ/gowebframe/internal/core/jwt"
+	"github.com/CIPFZ/gowebframe/internal/core/ldapauth"
+	"github.com/CIPFZ/gowebframe/internal/core/mailer"
+	"github.com/CIPFZ/gowebframe/internal/core/oauthclient"
+	"github.com/CIPFZ/gowebframe/internal/core/oidc"
+	"github.com/CIPFZ/gowebframe/internal/core/passkey"
+	"github.com/CIPFZ/gowebframe/internal/core/session"
 	coretoken "github.com/CIPFZ/gowebframe/internal/core/token"
+	"github.com/CIPFZ/gowebframe/internal/core/tokencache"
 
 	"github.com/casbin/casbin/v2"
 	"github.com/gin-gonic/gin"
@@ -27,6 +37,13 @@ type ServiceContext struct {
 	Config             *config.Config
 	Viper              *viper.Viper
 	JWT                *jwt.JWT
+	Sessions           session.Store
+	Cache              cache.Store
+	OIDC               *oidc.Manager
+	LDAP               *ldapauth.Client
+	Mailer             mailer.Mailer
+	GeoIP              *geoip.Resolver
+	Passkey            *passkey.Manager
 	Logger             *zap.Logger
 	I18n               *i18n.Service
 	DB                 *gorm.DB
@@ -36,9 +53,13 @@ type ServiceContext struct {
 	Timer              time.Timer
 	ConcurrencyControl *singleflight.Group
 	CasbinEnforcer     *casbin.SyncedCachedEnforcer
-	APITokenLimiter    *coretoken.InMemoryLimiter
+	APITokenLimiter    coretoken.Limiter
+	APITokenQuota      coretoken.Quota
+	APITokenCache      *tokencache.Cache
+	OAuthClients       *oauthclient.Cache
 	lock               sync.RWMutex
 	AuditRecorder      *audit.AuditRecorder
+	ApiTokenUsage      *apiusage.Recorder
 	OSS                file.OSS
 }
 
@@ -46,5 +67,6 @@ func NewServiceContext() *ServiceContext {
 	return &ServiceContext{
 		ConcurrencyControl: &singleflight.Group{},
 		APITokenLimiter:    coretoken.NewInMemoryLimiter(),
+		APITokenQuota:      coretoken.NewInMemoryQuota(),
 	}
 }
@@ -0,0 +1,122 @@
+package apisign
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// 以下期望值由独立实现 (Python hmac/hashlib) 计算，用于固定签名格式，服务端与各语言客户端须保持一致
+const (
+	testBody         = `{"name":"tang"}`
+	testBodyHash     = "c658de6e4281ea8df5f351becbf86f58feef48a59156486f2acbbe098fce6f35"
+	testEmptyHash    = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+	testStringToSign = "POST\n/api/v1/poetry/dynasty\na=1&a=%2B&b=%E5%94%90+x\n1700000000\nnonce-1\n" + testBodyHash
+	testSignature    = "acdc0e854a43ba7c1fbab6ad919999d5cde09825c99bb1cd2ee3a6f7941acc82"
+)
+
+func TestBodyHash(t *testing.T) {
+	if got := BodyHash([]byte(testBody)); got != testBodyHash {
+		t.Fatalf("BodyHash() = %q, want %q", got, testBodyHash)
+	}
+	if got := BodyHash(nil); got != testEmptyHash {
+		t.Fatalf("BodyHash(nil) = %q, want %q", got, testEmptyHash)
+	}
+}
+
+func TestCanonicalQuery(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want string
+	}{
+		{"", ""},
+		{"b=2&a=1", "a=1&b=2"},
+		// 同名参数保持原有顺序，空格统一编码为 +，十六进制转义统一为大写
+		{"b=%e5%94%90%20x&a=1&a=%2b", "a=1&a=%2B&b=%E5%94%90+x"},
+		{"q=a+b", "q=a+b"},
+	}
+	for _, tt := range tests {
+		if got := CanonicalQuery(tt.raw); got != tt.want {
+			t.Errorf("CanonicalQuery(%q) = %q, want %q", tt.raw, got, tt.want)
+		}
+	}
+}
+
+func TestStringToSignAndSignature(t *testing.T) {
+	got := StringToSign("post", "/api/v1/poetry/dynasty", "b=%E5%94%90%20x&a=1&a=%2b", "1700000000", "nonce-1", testBodyHash)
+	if got != testStringToSign {
+		t.Fatalf("StringToSign() = %q, want %q", got, testStringToSign)
+	}
+	if got := StringToSign("GET", "", "", "1", "n", testEmptyHash); !strings.HasPrefix(got, "GET\n/\n\n") {
+		t.Fatalf("StringToSign() with empty path = %q, want path /", got)
+	}
+	if got := Signature("sign-secret", testStringToSign); got != testSignature {
+		t.Fatalf("Signature() = %q, want %q", got, testSignature)
+	}
+}
+
+func TestTransportSignsRequests(t *testing.T) {
+	const keyID, secret = "12", "sign-secret"
+	var gotBody string
+	var verified bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		gotBody = string(body)
+		if r.Header.Get(HeaderKeyID) != keyID {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		// 按服务端的方式重新计算签名：路径取转义形式，查询参数取原始串
+		stringToSign := StringToSign(r.Method, r.URL.EscapedPath(), r.URL.RawQuery,
+			r.Header.Get(HeaderTimestamp), r.Header.Get(HeaderNonce), BodyHash(body))
+		verified = Signature(secret, stringToSign) == r.Header.Get(HeaderSignature)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: &Transport{KeyID: keyID, Secret: secret}}
+	req, err := http.NewRequest(http.MethodPost, server.URL+"/api/v1/poetry/%E5%94%90%20shi?b=%E5%94%90%20x&a=1", strings.NewReader(testBody))
+	if err != nil {
+		t.Fatalf("NewRequest() error = %v", err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	_ = resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK || !verified {
+		t.Fatalf("signed request status = %d, verified = %v, want verified", resp.StatusCode, verified)
+	}
+	// 签名时读取了请求体，发出的请求仍须携带完整请求体
+	if gotBody != testBody {
+		t.Fatalf("server body = %q, want %q", gotBody, testBody)
+	}
+	// RoundTripper 不修改调用方的请求
+	if req.Header.Get(HeaderSignature) != "" {
+		t.Fatal("Transport modified the caller's request headers")
+	}
+}
+
+func TestSignBodyCanBeReread(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPut, "/api/v1/poetry/dynasty", strings.NewReader(testBody))
+	if err := Sign(req, "12", "sign-secret"); err != nil {
+		t.Fatalf("Sign() error = %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		body, err := req.GetBody()
+		if err != nil {
+			t.Fatalf("GetBody() error = %v", err)
+		}
+		data, _ := io.ReadAll(body)
+		if string(data) != testBody {
+			t.Fatalf("GetBody() read %d = %q, want %q", i, data, testBody)
+		}
+	}
+	data, _ := io.ReadAll(req.Body)
+	if string(data) != testBody {
+		t.Fatalf("Body = %q, want %q", data, testBody)
+	}
+}
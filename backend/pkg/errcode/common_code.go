@@ -8,6 +8,38 @@ var (
 	Unauthorized  = NewError(1003, "未授权，请登录")
 	AssessDenied  = NewError(1004, "权限不足")
 
+	// 登录防爆破
+	CaptchaRequired = NewError(1005, "请输入验证码")
+	CaptchaInvalid  = NewError(1006, "验证码错误")
+	AccountLocked   = NewError(1007, "账号已锁定，请稍后再试")
+
+	// 密码策略
+	PasswordChangeRequired = NewError(1008, "密码已过期或需要修改，请设置新密码")
+	PasswordPolicyViolated = NewError(1009, "密码不符合安全策略")
+	TooManyRequests        = NewError(1010, "请求过于频繁，请稍后再试")
+
+	// 自助注册
+	RegistrationClosed     = NewError(1011, "暂未开放注册")
+	AccountPendingVerify   = NewError(1012, "账号邮箱尚未验证，请查收验证邮件")
+	AccountPendingApproval = NewError(1013, "账号正在等待管理员审核")
+	AccountRejected        = NewError(1014, "注册申请未通过审核")
+
+	// 模拟登录
+	ImpersonationForbidden = NewError(1015, "模拟登录期间禁止此操作")
+
+	// 登录地区限制
+	LoginRegionForbidden = NewError(1016, "当前所在地区不允许登录")
+
+	// 签名请求
+	RequestTooLarge = NewError(1017, "请求体过大")
+
+	// 单点登录
+	SsoLoginFailed        = NewError(1018, "单点登录失败，请稍后重试")
+	SsoUnavailable        = NewError(1019, "该单点登录方式不可用")
+	SsoStateInvalid       = NewError(1020, "登录状态已失效，请重新登录")
+	SsoProviderDenied     = NewError(1021, "身份提供方拒绝了登录请求")
+	SsoAccountUnavailable = NewError(1022, "该账号无法通过单点登录，请联系管理员")
+
 	// 通用 CRUD 错误 (2000 ~ 2999)
 	CreateFailed       = NewError(2001, "创建失败")
 	UpdateFailed       = NewError(2002, "更新失败")
@@ -23,8 +23,12 @@ type PageResult struct {
 	PageSize int         `json:"pageSize"`
 }
 
+// ctxKeyCode 记录本次响应的业务码，供中间件在 c.Next() 之后读取
+const ctxKeyCode = "responseCode"
+
 // result 统一的内部响应处理
 func result(c *gin.Context, code int, msg string, data interface{}) {
+	c.Set(ctxKeyCode, code)
 	c.JSON(http.StatusOK, Response{
 		Code: code,
 		Msg:  msg,
@@ -32,6 +36,16 @@ func result(c *gin.Context, code int, msg string, data interface{}) {
 	})
 }
 
+// CodeFromContext 返回本次请求已写出的业务码；未经本包响应时 ok 为 false
+func CodeFromContext(c *gin.Context) (code int, ok bool) {
+	v, exists := c.Get(ctxKeyCode)
+	if !exists {
+		return 0, false
+	}
+	code, ok = v.(int)
+	return code, ok
+}
+
 // -------------------------------------------------------------------------
 // ✅ 成功响应类
 // -------------------------------------------------------------------------
@@ -28,3 +28,12 @@ func ParseDuration(d string) (time.Duration, error) {
 	dv, err := strconv.ParseInt(d, 10, 64)
 	return time.Duration(dv), err
 }
+
+// ParseDurationOr 解析时长配置 (如 "30m"、"7d")，为空、解析失败或不足 1 秒 (如漏写单位的纯数字) 时返回 def
+func ParseDurationOr(d string, def time.Duration) time.Duration {
+	dr, err := ParseDuration(d)
+	if err != nil || dr < time.Second {
+		return def
+	}
+	return dr
+}
@@ -10,6 +10,9 @@ const (
 	ctxKeyUserID      = "userId"
 	ctxKeyAuthorityID = "authorityId"
 	ctxKeyClaims      = "claims"
+	ctxKeySessionID   = "sessionId"
+
+	ctxKeyImpersonatorID = "impersonatorId"
 )
 
 // GetUserUUID 从 Context 中获取用户 UUID
@@ -41,3 +44,18 @@ func GetAuthorityId(c *gin.Context) uint {
 	}
 	return 0
 }
+
+// GetSessionID 从 Context 中获取当前登录会话 ID
+func GetSessionID(c *gin.Context) string {
+	return c.GetString(ctxKeySessionID)
+}
+
+// GetImpersonatorID 从 Context 中获取模拟登录发起人 ID，非模拟登录时为 0
+func GetImpersonatorID(c *gin.Context) uint {
+	if v, exists := c.Get(ctxKeyImpersonatorID); exists {
+		if id, ok := v.(uint); ok {
+			return id
+		}
+	}
+	return 0
+}
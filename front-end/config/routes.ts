@@ -18,6 +18,16 @@ export default [
         path: '/user/register-result',
         component: './user/register-result',
       },
+      {
+        name: 'register-verify',
+        path: '/user/register-verify',
+        component: './user/register-verify',
+      },
+      {
+        name: 'reset-password',
+        path: '/user/reset-password',
+        component: './user/reset-password',
+      },
       {
         path: '/user',
         redirect: '/user/login',
@@ -53,6 +63,11 @@ export default [
         component: './sys/api-token',
         hideInMenu: true,
       },
+      {
+        path: '/sys/oauth-client',
+        component: './sys/oauth-client',
+        hideInMenu: true,
+      },
       {
         path: '/plugin/project/:id',
         component: './plugin/project-detail',
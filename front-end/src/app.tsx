@@ -8,6 +8,7 @@ import {
   AvatarDropdown,
   AvatarName,
   Footer,
+  ImpersonationBanner,
   Question,
   SelectLang,
 } from '@/components';
@@ -22,6 +23,8 @@ import { isPublicPluginRoute } from '@/utils/plugin';
 
 const isDev = process.env.NODE_ENV === 'development' || process.env.CI;
 const loginPath = '/user/login';
+// 未登录可访问的用户页面
+const userPublicPaths = [loginPath, '/user/register', '/user/register-result', '/user/register-verify', '/user/reset-password'];
 
 // ✨ 定义关键业务状态码 (与后端 pkg/errcode 保持一致)
 const Code = {
@@ -80,7 +83,7 @@ export async function getInitialState(): Promise<{
 
   // 只有 (非登录页) 且 (有Token) 时才请求
   if (
-    ![loginPath, '/user/register', '/user/register-result'].includes(location.pathname) &&
+    !userPublicPaths.includes(location.pathname) &&
     token &&
     !isPublicRoute
   ) {
@@ -158,7 +161,7 @@ export const layout: RunTimeLayoutConfig = ({
       // 1. 未登录检查：
       // 如果没有 currentUser，且连 token 都没有，那必须去登录
       // (注意：如果 token 存在但 currentUser 为空，可能是接口 500 了，此时不跳登录，而是停留在当前页显示错误)
-      if (!initialState?.currentUser && !token && location.pathname !== loginPath && !isPublicRoute) {
+      if (!initialState?.currentUser && !token && !userPublicPaths.includes(location.pathname) && !isPublicRoute) {
         history.push(loginPath);
         return;
       }
@@ -207,6 +210,7 @@ export const layout: RunTimeLayoutConfig = ({
     childrenRender: (children) => {
       return (
         <>
+          <ImpersonationBanner />
           {children}
           <SettingDrawer
             disableUrlParams
@@ -0,0 +1,38 @@
+import { useModel } from '@umijs/max';
+import { Alert, Button } from 'antd';
+import dayjs from 'dayjs';
+import React from 'react';
+import { outLogin } from '@/services/api/user';
+import { exitImpersonation } from '@/utils/impersonation';
+
+// 模拟登录提示横幅：提醒当前以他人身份操作，并提供退出入口
+const ImpersonationBanner: React.FC = () => {
+  const { initialState } = useModel('@@initialState');
+  const { currentUser } = initialState || {};
+  const impersonation = currentUser?.impersonation;
+  if (!impersonation) {
+    return null;
+  }
+
+  return (
+    <Alert
+      banner
+      type="warning"
+      style={{ marginBottom: 16 }}
+      message={
+        <span>
+          {impersonation.impersonatorName} 正在以「{currentUser?.nickName || currentUser?.username}」的身份操作，
+          所有请求都会记录到操作日志
+          {impersonation.expiresAt && `，${dayjs(impersonation.expiresAt).format('HH:mm')} 自动失效`}
+        </span>
+      }
+      action={
+        <Button size="small" type="primary" onClick={() => exitImpersonation(outLogin)}>
+          退出模拟
+        </Button>
+      }
+    />
+  );
+};
+
+export default ImpersonationBanner;
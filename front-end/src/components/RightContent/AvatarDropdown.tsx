@@ -13,6 +13,7 @@ import React from 'react';
 import { flushSync } from 'react-dom';
 // 修正导入路径，确保你的 api 定义正确
 import { outLogin, switchAuthority } from '@/services/api/user';
+import { exitImpersonation, isImpersonating } from '@/utils/impersonation';
 import HeaderDropdown from '../HeaderDropdown';
 
 export type GlobalHeaderRightProps = {
@@ -55,6 +56,11 @@ export const AvatarDropdown: React.FC<GlobalHeaderRightProps> = ({
    * 退出登录逻辑
    */
   const loginOut = async () => {
+    // 模拟登录中退出只结束模拟，回到管理员身份
+    if (isImpersonating()) {
+      await exitImpersonation(outLogin);
+      return;
+    }
     try {
       await outLogin();
     } catch (error) {
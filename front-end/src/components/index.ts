@@ -6,8 +6,9 @@
  * 布局组件
  */
 import Footer from './Footer';
+import ImpersonationBanner from './ImpersonationBanner';
 import { Question, SelectLang } from './RightContent';
 import { AvatarDropdown, AvatarName } from './RightContent/AvatarDropdown';
 import RouterLayout from './RouterLayout'
 
-export { AvatarDropdown, AvatarName, Footer, Question, SelectLang, RouterLayout };
+export { AvatarDropdown, AvatarName, Footer, ImpersonationBanner, Question, SelectLang, RouterLayout };
@@ -26,4 +26,8 @@ describe('routes config', () => {
   it('contains sys api-token route fallback', () => {
     expect(hasRoutePath(routes as any[], '/sys/api-token')).toBe(true);
   });
+
+  it('contains sys oauth-client route fallback', () => {
+    expect(hasRoutePath(routes as any[], '/sys/oauth-client')).toBe(true);
+  });
 });
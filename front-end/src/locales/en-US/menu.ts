@@ -7,6 +7,8 @@
   'menu.login': 'Login',
   'menu.register': 'Register',
   'menu.register-result': 'Register Result',
+  'menu.register-verify': 'Verify Email',
+  'menu.reset-password': 'Reset Password',
   'menu.dashboard': 'Dashboard',
   'menu.dashboard.analysis': 'Analysis',
   'menu.dashboard.monitor': 'Monitor',
@@ -57,7 +59,9 @@
   'menu.system.menu': 'Menus',
   'menu.system.api': 'APIs',
   'menu.system.apiToken': 'API Tokens',
+  'menu.system.oauthClient': 'OAuth Clients',
   'menu.system.operation': 'Operation Logs',
+  'menu.system.loginLog': 'Login Logs',
   'menu.system.notice': 'Notices',
   'menu.plugin': 'Plugin Center',
   'menu.plugin.projectManagement': 'Plugin Projects',
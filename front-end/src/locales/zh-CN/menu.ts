@@ -7,6 +7,8 @@
   'menu.login': '登录',
   'menu.register': '注册',
   'menu.register-result': '注册结果',
+  'menu.register-verify': '验证邮箱',
+  'menu.reset-password': '重置密码',
   'menu.dashboard': '仪表盘',
   'menu.dashboard.analysis': '分析页',
   'menu.dashboard.monitor': '监控页',
@@ -57,7 +59,9 @@
   'menu.system.menu': '菜单管理',
   'menu.system.api': 'API 管理',
   'menu.system.apiToken': 'API Token',
+  'menu.system.oauthClient': 'OAuth 客户端',
   'menu.system.operation': '操作日志',
+  'menu.system.loginLog': '登录日志',
   'menu.system.notice': '通知公告',
   'menu.plugin': '插件中心',
   'menu.plugin.projectManagement': '插件项目管理',
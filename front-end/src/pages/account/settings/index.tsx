@@ -1,18 +1,30 @@
 import React, { useState, useEffect, useRef } from 'react'; // ✨ 引入 useEffect
 import { GridContent } from '@ant-design/pro-components';
-import { Menu, Typography, message, List } from 'antd';
+import { Menu, Typography, message, List, Button, Popconfirm, Alert } from 'antd';
 import {
+  ModalForm,
   ProCard,
   ProForm,
   ProFormText,
   ProFormTextArea,
+  ProTable,
 } from '@ant-design/pro-components';
 import { useModel } from '@umijs/max';
 import { createStyles } from 'antd-style';
-import { MobileOutlined, MailOutlined } from '@ant-design/icons';
+import { MobileOutlined, MailOutlined, PlusOutlined } from '@ant-design/icons';
+import dayjs from 'dayjs';
 import UploadImage from '@/components/Upload/UploadImage';
 // ✨ 引入 API
-import { updateSelfInfo } from '@/services/api/user';
+import {
+  beginPasskeyRegistration,
+  getPasskeys,
+  registerPasskey,
+  revokePasskey,
+  updateSelfInfo,
+} from '@/services/api/user';
+import { createPasskey, passkeySupported } from '@/utils/passkey';
+import { getSelfLoginLogList } from '@/services/api/loginLog';
+import { loginLogColumns, toLoginLogQuery, type LoginLogItem } from '@/pages/sys/login-log/components/columns';
 
 const { Title } = Typography;
 
@@ -225,16 +237,155 @@ const SecurityView: React.FC<{ currentUser: any }> = ({ currentUser }) => {
   );
 };
 
+// --- 子组件：登录记录 (LoginLogView) ---
+const LoginLogView: React.FC = () => (
+  <ProTable<LoginLogItem>
+    rowKey="ID"
+    search={false}
+    options={false}
+    request={async (params) => {
+      const res = await getSelfLoginLogList(toLoginLogQuery(params));
+      return {
+        data: res.data?.list || [],
+        success: res.code === 0,
+        total: res.data?.total || 0,
+      };
+    }}
+    columns={loginLogColumns(false)}
+    pagination={{ pageSize: 10 }}
+  />
+);
+
+// --- 子组件：通行密钥 (PasskeyView) ---
+type PasskeyItem = {
+  ID: number;
+  name: string;
+  backupEligible: boolean;
+  CreatedAt: string;
+  lastUsedAt?: string;
+};
+
+const PasskeyView: React.FC = () => {
+  const [list, setList] = useState<PasskeyItem[]>([]);
+  const [loading, setLoading] = useState(false);
+
+  const load = async () => {
+    setLoading(true);
+    try {
+      const res = await getPasskeys();
+      if (res.code === 0) {
+        setList(res.data || []);
+      }
+    } finally {
+      setLoading(false);
+    }
+  };
+
+  useEffect(() => {
+    load();
+  }, []);
+
+  // 名称为空时后端按当前设备命名
+  const handleAdd = async (values: { name?: string }) => {
+    const options = await beginPasskeyRegistration();
+    if (options.code !== 0) {
+      message.error(options.msg || '获取注册参数失败');
+      return false;
+    }
+    let credential;
+    try {
+      credential = await createPasskey(options.data.options);
+    } catch (error) {
+      // 用户取消或认证器不支持
+      console.log(error);
+      message.warning('已取消添加通行密钥');
+      return false;
+    }
+    const res = await registerPasskey({
+      ceremonyId: options.data.ceremonyId,
+      name: values.name?.trim(),
+      credential,
+    });
+    if (res.code === 0) {
+      message.success('通行密钥已添加');
+      load();
+      return true;
+    }
+    message.error(res.msg || '添加失败');
+    return false;
+  };
+
+  const handleRevoke = async (id: number) => {
+    const res = await revokePasskey({ id });
+    if (res.code === 0) {
+      message.success('已删除');
+      load();
+    } else {
+      message.error(res.msg || '删除失败');
+    }
+  };
+
+  return (
+    <>
+      {!passkeySupported() && (
+        <Alert type="warning" showIcon style={{ marginBottom: 16 }} message="当前浏览器不支持通行密钥" />
+      )}
+      <ModalForm<{ name?: string }>
+        title="添加通行密钥"
+        width="400px"
+        modalProps={{ destroyOnClose: true }}
+        trigger={
+          <Button type="primary" icon={<PlusOutlined />} disabled={!passkeySupported()}>
+            添加通行密钥
+          </Button>
+        }
+        onFinish={handleAdd}
+      >
+        <ProFormText
+          name="name"
+          label="名称"
+          placeholder="留空时按当前设备命名"
+          fieldProps={{ maxLength: 64 }}
+        />
+      </ModalForm>
+      <List
+        loading={loading}
+        itemLayout="horizontal"
+        dataSource={list}
+        locale={{ emptyText: '尚未添加通行密钥，添加后可免密码登录或用于二次验证' }}
+        renderItem={(item) => (
+          <List.Item
+            actions={[
+              <Popconfirm key="revoke" title="删除后将不能再使用该通行密钥登录" onConfirm={() => handleRevoke(item.ID)}>
+                <a>删除</a>
+              </Popconfirm>,
+            ]}
+          >
+            <List.Item.Meta
+              title={`${item.name}${item.backupEligible ? ' (可同步)' : ''}`}
+              description={`添加于 ${dayjs(item.CreatedAt).format('YYYY-MM-DD HH:mm')}，${
+                item.lastUsedAt ? `最近使用 ${dayjs(item.lastUsedAt).format('YYYY-MM-DD HH:mm')}` : '尚未使用'
+              }`}
+            />
+          </List.Item>
+        )}
+      />
+    </>
+  );
+};
+
 // --- 主页面 ---
 const Settings: React.FC = () => {
   const { initialState, setInitialState, refresh } = useModel('@@initialState'); // ✨ 获取 refresh 方法
   const currentUser = initialState?.currentUser;
 
-  const [initConfig, setInitConfig] = useState<'base' | 'security'>('base');
+  const [initConfig, setInitConfig] = useState<'base' | 'security' | 'passkey' | 'loginLog'>('base');
 
-  const menuMap: Record<'base' | 'security', string> = {
+  const menuMap: Record<'base' | 'security' | 'passkey' | 'loginLog', string> = {
     base: '基本设置',
     security: '安全设置',
+    passkey: '通行密钥',
+    loginLog: '登录记录',
   };
 
   const renderChildren = () => {
@@ -244,6 +395,10 @@ const Settings: React.FC = () => {
         return <BaseView currentUser={currentUser} refresh={refresh} />;
       case 'security':
         return <SecurityView currentUser={currentUser} />;
+      case 'passkey':
+        return <PasskeyView />;
+      case 'loginLog':
+        return <LoginLogView />;
       default:
         return null;
     }
@@ -260,7 +415,7 @@ const Settings: React.FC = () => {
           <Menu
             mode="inline"
             selectedKeys={[initConfig]}
-            onClick={({ key }) => setInitConfig(key as 'base' | 'security')}
+            onClick={({ key }) => setInitConfig(key as 'base' | 'security' | 'passkey' | 'loginLog')}
             style={{ border: 'none' }}
           >
             {(Object.keys(menuMap) as Array<keyof typeof menuMap>).map((item) => (
@@ -59,4 +59,13 @@ describe('ApiPermissionSummary', () => {
     expect(fullList.style.maxHeight).toBe('240px');
     expect(fullList.style.overflowY).toBe('auto');
   });
+
+  it('lists group and path scopes alongside selected apis', () => {
+    render(<ApiPermissionSummary apis={[]} scopeLabels={['分组: poetry', '[GET] /api/v1/poetry/*']} />);
+
+    expect(screen.queryByText('未授权')).toBeNull();
+    expect(screen.queryByTestId('api-permission-count-row')).toBeNull();
+    expect(screen.getByText('分组: poetry')).toBeTruthy();
+    expect(screen.getByText('[GET] /api/v1/poetry/*')).toBeTruthy();
+  });
 });
@@ -6,24 +6,37 @@ import { buildPermissionSummary } from '../helpers';
 
 type ApiPermissionSummaryProps = {
   apis?: ApiTokenItem['apis'];
+  // 分组与路径规则，见 formatScopeLabels
+  scopeLabels?: string[];
   maxVisible?: number;
 };
 
 export const ApiPermissionSummary: React.FC<ApiPermissionSummaryProps> = ({
   apis,
+  scopeLabels = [],
   maxVisible = 2,
 }) => {
   const summary = buildPermissionSummary(apis, maxVisible);
 
-  if (!summary.total) {
+  if (!summary.total && !scopeLabels.length) {
     return <Typography.Text type="secondary">未授权</Typography.Text>;
   }
 
   return (
     <Space direction="vertical" size={6} style={{ width: '100%' }}>
-      <div data-testid="api-permission-count-row">
-        <Tag color="geekblue">{`${summary.total} 个 API`}</Tag>
-      </div>
+      {summary.total > 0 ? (
+        <div data-testid="api-permission-count-row">
+          <Tag color="geekblue">{`${summary.total} 个 API`}</Tag>
+        </div>
+      ) : null}
+
+      {scopeLabels.map((label) => (
+        <div key={label}>
+          <Tag color="purple" style={{ marginInlineEnd: 0, maxWidth: '100%' }}>
+            {label}
+          </Tag>
+        </div>
+      ))}
 
       <Space
         direction="vertical"
@@ -0,0 +1,107 @@
+import React, { useEffect, useState } from 'react';
+import { Line } from '@ant-design/plots';
+import { Drawer, Empty, Segmented, Space, Spin, Statistic, Table, Tag, Typography } from 'antd';
+import type { ColumnsType } from 'antd/es/table';
+
+import { getApiTokenUsage, type ApiTokenItem, type ApiTokenUsage } from '@/services/api/apiToken';
+import { buildUsageChartData, formatBytes } from '../helpers';
+
+type Granularity = 'hour' | 'day';
+
+type ApiUsageRow = ApiTokenUsage['apis'][number];
+
+const apiColumns: ColumnsType<ApiUsageRow> = [
+  {
+    title: '接口',
+    dataIndex: 'path',
+    ellipsis: true,
+    render: (_, record) => (
+      <Space size={4}>
+        <Tag>{record.method}</Tag>
+        <Typography.Text ellipsis>{record.path}</Typography.Text>
+      </Space>
+    ),
+  },
+  { title: '调用', dataIndex: 'calls', width: 88 },
+  { title: '失败', dataIndex: 'errors', width: 72 },
+  { title: 'P50', dataIndex: 'p50Ms', width: 88, render: (value: number) => `${value.toFixed(1)} ms` },
+  { title: 'P95', dataIndex: 'p95Ms', width: 88, render: (value: number) => `${value.toFixed(1)} ms` },
+  {
+    title: '流量 (入/出)',
+    dataIndex: 'bytesOut',
+    width: 150,
+    render: (_, record) => `${formatBytes(record.bytesIn)} / ${formatBytes(record.bytesOut)}`,
+  },
+];
+
+// Token 用量：小时粒度为最近 24 小时，天粒度为最近 30 天
+export const ApiTokenUsageDrawer: React.FC<{
+  token?: ApiTokenItem;
+  onClose: () => void;
+}> = ({ token, onClose }) => {
+  const [granularity, setGranularity] = useState<Granularity>('hour');
+  const [usage, setUsage] = useState<ApiTokenUsage>();
+  const [loading, setLoading] = useState(false);
+
+  useEffect(() => {
+    if (!token) {
+      setUsage(undefined);
+      return;
+    }
+    setLoading(true);
+    getApiTokenUsage({ id: token.ID, granularity })
+      .then((res) => setUsage(res.code === 0 ? res.data : undefined))
+      .finally(() => setLoading(false));
+  }, [token, granularity]);
+
+  const total = usage?.total;
+  return (
+    <Drawer
+      title={token ? `用量统计 - ${token.name}` : '用量统计'}
+      width={860}
+      open={!!token}
+      onClose={onClose}
+      destroyOnClose
+    >
+      <Space direction="vertical" size="large" style={{ width: '100%' }}>
+        <Segmented<Granularity>
+          value={granularity}
+          onChange={setGranularity}
+          options={[
+            { label: '最近 24 小时', value: 'hour' },
+            { label: '最近 30 天', value: 'day' },
+          ]}
+        />
+        <Spin spinning={loading}>
+          {total && total.calls > 0 ? (
+            <Space direction="vertical" size="large" style={{ width: '100%' }}>
+              <Space size={48} wrap>
+                <Statistic title="调用次数" value={total.calls} />
+                <Statistic title="失败次数" value={total.errors} />
+                <Statistic title="P50" value={total.p50Ms} precision={1} suffix="ms" />
+                <Statistic title="P95" value={total.p95Ms} precision={1} suffix="ms" />
+                <Statistic title="流量 (入/出)" value={`${formatBytes(total.bytesIn)} / ${formatBytes(total.bytesOut)}`} />
+              </Space>
+              <Line
+                height={260}
+                data={buildUsageChartData(usage)}
+                xField="time"
+                yField="value"
+                colorField="type"
+              />
+              <Table<ApiUsageRow>
+                size="small"
+                rowKey={(record) => `${record.method} ${record.path}`}
+                columns={apiColumns}
+                dataSource={usage?.apis}
+                pagination={false}
+              />
+            </Space>
+          ) : (
+            <Empty description="该时间段内没有调用记录" />
+          )}
+        </Spin>
+      </Space>
+    </Drawer>
+  );
+};
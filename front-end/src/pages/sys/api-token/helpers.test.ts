@@ -2,9 +2,14 @@ import dayjs from 'dayjs';
 
 import {
   API_TOKEN_TABLE_LAYOUT,
+  buildApiGroupOptions,
   buildPermissionSummary,
+  buildUsageChartData,
   buildTokenFormInitialValues,
   buildTokenSubmitPayload,
+  formatBytes,
+  formatQuotaSummary,
+  formatScopeLabels,
 } from './helpers';
 
 describe('api-token helpers', () => {
@@ -27,6 +32,10 @@ describe('api-token helpers', () => {
     expect(buildTokenFormInitialValues()).toEqual({
       maxConcurrency: 5,
       apiIds: [],
+      apiGroups: [],
+      pathScopes: [],
+      allowedIps: [],
+      requireSignature: false,
     });
   });
 
@@ -49,11 +58,47 @@ describe('api-token helpers', () => {
       name: 'server-token',
       description: 'used by scripts',
       maxConcurrency: 8,
+      quotaPerMinute: 0,
+      quotaPerDay: 0,
+      quotaPerMonth: 0,
       expiresAt: expiresAt.toISOString(),
       apiIds: [2, 5],
+      apiGroups: [],
+      pathScopes: [],
+      allowedIps: [],
+      requireSignature: false,
     });
   });
 
+  it('submits scopes without blank path rows', () => {
+    const payload = buildTokenSubmitPayload({
+      name: 'poetry-reader',
+      apiGroups: ['poetry'],
+      pathScopes: [
+        { method: 'GET', path: ' /api/v1/poetry/* ' },
+        { method: '*', path: '' },
+      ],
+    });
+    expect(payload.apiGroups).toEqual(['poetry']);
+    expect(payload.pathScopes).toEqual([{ method: 'GET', path: '/api/v1/poetry/*' }]);
+    expect(buildTokenSubmitPayload({ name: 'ci', allowedIps: [' 10.0.0.0/8 ', ''] }).allowedIps).toEqual([
+      '10.0.0.0/8',
+    ]);
+    expect(formatScopeLabels(payload)).toEqual(['分组: poetry', '[GET] /api/v1/poetry/*']);
+    expect(buildApiGroupOptions([{ apiGroup: 'b' }, { apiGroup: 'a' }, { apiGroup: 'b' }, {}])).toEqual([
+      { label: 'a', value: 'a' },
+      { label: 'b', value: 'b' },
+    ]);
+  });
+
+  it('summarizes only the configured request quotas', () => {
+    expect(formatQuotaSummary({ quotaPerMinute: 60, quotaPerDay: 0, quotaPerMonth: 10000 })).toEqual([
+      '60/分',
+      '10000/月',
+    ]);
+    expect(formatQuotaSummary({})).toEqual([]);
+  });
+
   it('allocates more width to authorized apis than secondary metadata columns', () => {
     expect(API_TOKEN_TABLE_LAYOUT.apisWidth).toBeGreaterThan(API_TOKEN_TABLE_LAYOUT.statusWidth);
     expect(API_TOKEN_TABLE_LAYOUT.apisWidth).toBeGreaterThan(API_TOKEN_TABLE_LAYOUT.concurrencyWidth);
@@ -61,4 +106,24 @@ describe('api-token helpers', () => {
     expect(API_TOKEN_TABLE_LAYOUT.apisWidth).toBeGreaterThan(API_TOKEN_TABLE_LAYOUT.nameWidth);
     expect(API_TOKEN_TABLE_LAYOUT.scrollX).toBeGreaterThanOrEqual(1360);
   });
+
+  it('formats usage bytes and chart series', () => {
+    expect(formatBytes(512)).toBe('512 B');
+    expect(formatBytes(1536)).toBe('1.5 KB');
+    expect(formatBytes()).toBe('0 B');
+
+    const stats = { bytesIn: 0, bytesOut: 0, p50Ms: 0, p95Ms: 0 };
+    const data = buildUsageChartData({
+      granularity: 'day',
+      start: '2024-05-10T00:00:00+08:00',
+      end: '2024-05-11T00:00:00+08:00',
+      total: { ...stats, calls: 3, errors: 1 },
+      series: [{ ...stats, time: '2024-05-10T00:00:00+08:00', calls: 3, errors: 1 }],
+      apis: [],
+    });
+    expect(data.map((item) => [item.type, item.value])).toEqual([
+      ['调用', 3],
+      ['失败', 1],
+    ]);
+  });
 });
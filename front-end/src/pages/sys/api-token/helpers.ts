@@ -1,6 +1,6 @@
 import dayjs from 'dayjs';
 
-import type { ApiTokenItem } from '@/services/api/apiToken';
+import type { ApiTokenItem, ApiTokenPathScope, ApiTokenUsage } from '@/services/api/apiToken';
 import type { ApiTokenFormPayload } from '@/services/api/apiToken';
 
 export type ApiPermissionRecord = NonNullable<ApiTokenItem['apis']>[number];
@@ -9,8 +9,15 @@ export type TokenFormValues = {
   name?: string;
   description?: string;
   maxConcurrency?: number;
+  quotaPerMinute?: number;
+  quotaPerDay?: number;
+  quotaPerMonth?: number;
   expiresAt?: string;
   apiIds?: number[];
+  apiGroups?: string[];
+  pathScopes?: ApiTokenPathScope[];
+  allowedIps?: string[];
+  requireSignature?: boolean;
 };
 
 export type PermissionSummaryItem = {
@@ -27,16 +34,27 @@ export const API_TOKEN_TABLE_LAYOUT = {
   tokenPrefixWidth: 150,
   statusWidth: 88,
   concurrencyWidth: 76,
+  quotaWidth: 120,
   expiresAtWidth: 158,
   lastUsedWidth: 120,
   apisWidth: 360,
-  actionWidth: 220,
-  scrollX: 1360,
+  actionWidth: 320,
+  scrollX: 1580,
 } as const;
 
 export const formatApiLabel = (method?: string, path?: string) =>
   `[${method || 'GET'}] ${path || '/'}`;
 
+// 请求配额摘要，如 "60/分 · 1000/天"，未设置时返回空数组
+export const formatQuotaSummary = (token: Pick<ApiTokenItem, 'quotaPerMinute' | 'quotaPerDay' | 'quotaPerMonth'>) =>
+  [
+    [token.quotaPerMinute, '分'],
+    [token.quotaPerDay, '天'],
+    [token.quotaPerMonth, '月'],
+  ]
+    .filter(([limit]) => !!limit)
+    .map(([limit, unit]) => `${limit}/${unit}`);
+
 export const buildPermissionSummary = (
   apis: ApiTokenItem['apis'] = [],
   maxVisible = 2,
@@ -64,6 +82,10 @@ export const buildTokenFormInitialValues = (currentRow?: ApiTokenItem): TokenFor
     return {
       maxConcurrency: DEFAULT_MAX_CONCURRENCY,
       apiIds: [],
+      apiGroups: [],
+      pathScopes: [],
+      allowedIps: [],
+      requireSignature: false,
     };
   }
 
@@ -71,8 +93,15 @@ export const buildTokenFormInitialValues = (currentRow?: ApiTokenItem): TokenFor
     name: currentRow.name,
     description: currentRow.description,
     maxConcurrency: currentRow.maxConcurrency,
+    quotaPerMinute: currentRow.quotaPerMinute,
+    quotaPerDay: currentRow.quotaPerDay,
+    quotaPerMonth: currentRow.quotaPerMonth,
     expiresAt: currentRow.expiresAt,
     apiIds: (currentRow.apis || []).map((api) => api.ID),
+    apiGroups: currentRow.apiGroups || [],
+    pathScopes: currentRow.pathScopes || [],
+    allowedIps: currentRow.allowedIps || [],
+    requireSignature: !!currentRow.requireSignature,
   };
 };
 
@@ -84,6 +113,52 @@ export const buildTokenSubmitPayload = (
   name: values.name || '',
   description: values.description,
   maxConcurrency: values.maxConcurrency,
+  quotaPerMinute: values.quotaPerMinute || 0,
+  quotaPerDay: values.quotaPerDay || 0,
+  quotaPerMonth: values.quotaPerMonth || 0,
   expiresAt: values.expiresAt ? dayjs(values.expiresAt).toISOString() : undefined,
   apiIds: values.apiIds || [],
+  apiGroups: values.apiGroups || [],
+  // 忽略未填写路径的空行
+  pathScopes: (values.pathScopes || [])
+    .filter((scope) => scope.path?.trim())
+    .map((scope) => ({ method: scope.method || '*', path: scope.path.trim() })),
+  allowedIps: (values.allowedIps || []).map((ip) => ip.trim()).filter(Boolean),
+  requireSignature: !!values.requireSignature,
 });
+
+// 授权范围摘要：分组与路径规则的标签文案
+export const formatScopeLabels = (token: Pick<ApiTokenItem, 'apiGroups' | 'pathScopes'>) => [
+  ...(token.apiGroups || []).map((group) => `分组: ${group}`),
+  ...(token.pathScopes || []).map((scope) => `[${scope.method}] ${scope.path}`),
+];
+
+// API 分组选项，取自已登记的接口
+export const buildApiGroupOptions = (apis: Array<{ apiGroup?: string }>) =>
+  Array.from(new Set(apis.map((api) => api.apiGroup).filter((group): group is string => !!group)))
+    .sort()
+    .map((group) => ({ label: group, value: group }));
+
+// 字节数转为易读的单位
+export const formatBytes = (bytes = 0) => {
+  const units = ['B', 'KB', 'MB', 'GB', 'TB'];
+  let value = bytes;
+  let index = 0;
+  while (value >= 1024 && index < units.length - 1) {
+    value /= 1024;
+    index += 1;
+  }
+  return `${index === 0 ? value : value.toFixed(1)} ${units[index]}`;
+};
+
+// 用量折线图数据：调用与失败两条线
+export const buildUsageChartData = (usage?: ApiTokenUsage) => {
+  const format = usage?.granularity === 'day' ? 'MM-DD' : 'MM-DD HH:mm';
+  return (usage?.series || []).flatMap((point) => {
+    const time = dayjs(point.time).format(format);
+    return [
+      { time, type: '调用', value: point.calls },
+      { time, type: '失败', value: point.errors },
+    ];
+  });
+};
@@ -1,10 +1,14 @@
 import React, { useMemo, useRef, useState } from 'react';
 import {
   DrawerForm,
+  ModalForm,
   ProCard,
   ProForm,
   ProFormDateTimePicker,
   ProFormDigit,
+  ProFormList,
+  ProFormSelect,
+  ProFormSwitch,
   ProFormText,
   ProFormTextArea,
   ProTable,
@@ -12,6 +16,7 @@ import {
 import { PageContainer } from '@ant-design/pro-layout';
 import type { ActionType, ProColumns } from '@ant-design/pro-components';
 import {
+  BarChartOutlined,
   CheckCircleOutlined,
   DeleteOutlined,
   EditOutlined,
@@ -19,8 +24,9 @@ import {
   PlusOutlined,
   RedoOutlined,
   SafetyCertificateOutlined,
+  SyncOutlined,
 } from '@ant-design/icons';
-import { Button, Form, message, Modal, Popconfirm, Space, Tag, Typography } from 'antd';
+import { Button, Form, message, Modal, Popconfirm, Space, Tag, Tooltip, Typography } from 'antd';
 import dayjs from 'dayjs';
 
 import {
@@ -32,10 +38,12 @@ import {
   getApiTokenDetail,
   getApiTokenList,
   resetApiToken,
+  rotateApiToken,
   updateApiToken,
   type ApiTokenItem,
 } from '@/services/api/apiToken';
 import { ApiPermissionSummary } from './components/ApiPermissionSummary';
+import { ApiTokenUsageDrawer } from './components/ApiTokenUsageDrawer';
 import {
   ApiPermissionTransfer,
   type ApiPermissionOption,
@@ -44,6 +52,9 @@ import {
   API_TOKEN_TABLE_LAYOUT,
   buildTokenFormInitialValues,
   buildTokenSubmitPayload,
+  buildApiGroupOptions,
+  formatQuotaSummary,
+  formatScopeLabels,
   type TokenFormValues,
 } from './helpers';
 import './index.less';
@@ -53,7 +64,12 @@ const extractPlainToken = (res: API.CommonResponse): string | undefined => {
   return data.token || data.plainToken || data.accessToken || data.value;
 };
 
-const showPlainTokenModal = (token: string, title: string) => {
+const showPlainTokenModal = (
+  token: string,
+  title: string,
+  keyId?: number,
+  signingSecret?: string,
+) => {
   Modal.info({
     title,
     width: 640,
@@ -65,6 +81,17 @@ const showPlainTokenModal = (token: string, title: string) => {
         <Typography.Paragraph copyable code>
           {token}
         </Typography.Paragraph>
+        {keyId && signingSecret ? (
+          <>
+            <Typography.Paragraph type="secondary">
+              签名请求的 Key ID 为 <Typography.Text code>{keyId}</Typography.Text>
+              ，签名密钥如下（同样只展示一次），签名方式见 pkg/apisign。
+            </Typography.Paragraph>
+            <Typography.Paragraph copyable code>
+              {signingSecret}
+            </Typography.Paragraph>
+          </>
+        ) : null}
       </div>
     ),
   });
@@ -95,6 +122,8 @@ const ApiTokenPage: React.FC = () => {
   const [form] = Form.useForm<TokenFormValues>();
   const [drawerVisible, setDrawerVisible] = useState(false);
   const [currentRow, setCurrentRow] = useState<ApiTokenItem>();
+  const [usageRow, setUsageRow] = useState<ApiTokenItem>();
+  const [rotateRow, setRotateRow] = useState<ApiTokenItem>();
   const [apiOptions, setApiOptions] = useState<ApiPermissionOption[]>([]);
   const [apiOptionsLoading, setApiOptionsLoading] = useState(false);
   const [pageList, setPageList] = useState<ApiTokenItem[]>([]);
@@ -218,7 +247,12 @@ const ApiTokenPage: React.FC = () => {
       message.success('重置成功');
       const plainToken = extractPlainToken(res);
       if (plainToken) {
-        showPlainTokenModal(plainToken, 'Token 已重置，请复制新的明文 Token');
+        showPlainTokenModal(
+          plainToken,
+          'Token 已重置，请复制新的明文 Token',
+          res.data?.ID,
+          res.data?.signingSecret,
+        );
       }
       actionRef.current?.reload();
     } catch (error) {
@@ -226,6 +260,36 @@ const ApiTokenPage: React.FC = () => {
     }
   };
 
+  const handleRotate = async (values: { grace?: string }) => {
+    if (!rotateRow) {
+      return false;
+    }
+    try {
+      const res = await rotateApiToken({ id: rotateRow.ID, grace: values.grace?.trim() || undefined });
+      if (res.code !== 0) {
+        message.error(res.msg || '轮换失败');
+        return false;
+      }
+
+      message.success('轮换成功');
+      const plainToken = extractPlainToken(res);
+      if (plainToken) {
+        showPlainTokenModal(
+          plainToken,
+          'Token 已轮换，旧 Token 在宽限期内仍可使用',
+          res.data?.ID,
+          res.data?.signingSecret,
+        );
+      }
+      setRotateRow(undefined);
+      actionRef.current?.reload();
+      return true;
+    } catch (error) {
+      message.error('请求异常');
+      return false;
+    }
+  };
+
   const handleSubmit = async (values: TokenFormValues) => {
     const payload = buildTokenSubmitPayload(values, currentRow?.ID);
 
@@ -240,7 +304,12 @@ const ApiTokenPage: React.FC = () => {
       if (!currentRow?.ID) {
         const plainToken = extractPlainToken(res);
         if (plainToken) {
-          showPlainTokenModal(plainToken, 'Token 创建成功，请立即复制');
+          showPlainTokenModal(
+            plainToken,
+            'Token 创建成功，请立即复制',
+            res.data?.ID,
+            res.data?.signingSecret,
+          );
         }
       }
 
@@ -263,6 +332,16 @@ const ApiTokenPage: React.FC = () => {
         <Space direction="vertical" size={0}>
           <Typography.Text strong>{record.name}</Typography.Text>
           <Typography.Text type="secondary">{record.description || '未填写说明'}</Typography.Text>
+          {record.allowedIps?.length ? (
+            <Tooltip title={record.allowedIps.join(', ')}>
+              <Tag color="orange" style={{ marginTop: 4 }}>{`IP 限制 ${record.allowedIps.length} 条`}</Tag>
+            </Tooltip>
+          ) : null}
+          {record.requireSignature ? (
+            <Tag color="blue" style={{ marginTop: 4 }}>
+              仅签名请求
+            </Tag>
+          ) : null}
         </Space>
       ),
     },
@@ -278,6 +357,19 @@ const ApiTokenPage: React.FC = () => {
             {record.tokenPrefix}
           </span>
           <span className="tokenPrefixMeta">仅展示前缀，用于快速识别</span>
+          {record.previousTokenPrefix ? (
+            <Tooltip
+              title={`旧 Token 有效至 ${dayjs(record.previousTokenExpiresAt).format('YYYY-MM-DD HH:mm')}，${
+                record.previousTokenLastUsedAt
+                  ? `最近使用 ${dayjs(record.previousTokenLastUsedAt).format('YYYY-MM-DD HH:mm')}`
+                  : '轮换后未被使用'
+              }`}
+            >
+              <Tag color={record.previousTokenLastUsedAt ? 'warning' : 'default'}>
+                {`旧 ${record.previousTokenPrefix}`}
+              </Tag>
+            </Tooltip>
+          ) : null}
         </Space>
       ),
     },
@@ -286,7 +378,9 @@ const ApiTokenPage: React.FC = () => {
       dataIndex: 'apis',
       width: API_TOKEN_TABLE_LAYOUT.apisWidth,
       search: false,
-      render: (_, record) => <ApiPermissionSummary apis={record.apis} />,
+      render: (_, record) => (
+        <ApiPermissionSummary apis={record.apis} scopeLabels={formatScopeLabels(record)} />
+      ),
     },
     {
       title: '状态',
@@ -313,6 +407,24 @@ const ApiTokenPage: React.FC = () => {
       align: 'center',
       render: (_, record) => record.maxConcurrency || '-',
     },
+    {
+      title: '请求配额',
+      dataIndex: 'quotaPerMinute',
+      width: API_TOKEN_TABLE_LAYOUT.quotaWidth,
+      search: false,
+      render: (_, record) => {
+        const quotas = formatQuotaSummary(record);
+        return quotas.length ? (
+          <Space direction="vertical" size={0}>
+            {quotas.map((item) => (
+              <Typography.Text key={item}>{item}</Typography.Text>
+            ))}
+          </Space>
+        ) : (
+          '不限'
+        );
+      },
+    },
     {
       title: '过期时间',
       dataIndex: 'expiresAt',
@@ -343,6 +455,9 @@ const ApiTokenPage: React.FC = () => {
           <a onClick={() => openEditDrawer(record)}>
             <EditOutlined /> 编辑
           </a>
+          <a onClick={() => setUsageRow(record)}>
+            <BarChartOutlined /> 用量
+          </a>
           <Popconfirm
             title={record.enabled ? '确认禁用该 Token？' : '确认启用该 Token？'}
             onConfirm={() => handleToggleEnable(record)}
@@ -353,9 +468,12 @@ const ApiTokenPage: React.FC = () => {
               {record.enabled ? <PauseCircleOutlined /> : <CheckCircleOutlined />} {record.enabled ? '禁用' : '启用'}
             </a>
           </Popconfirm>
+          <a onClick={() => setRotateRow(record)}>
+            <SyncOutlined /> 轮换
+          </a>
           <Popconfirm
             title="确认重置 Token？"
-            description="重置后旧 Token 会立即失效。"
+            description="重置后旧 Token（包括轮换宽限期内的）会立即失效。"
             onConfirm={() => handleReset(record.ID)}
             okText="确认"
             cancelText="取消"
@@ -488,6 +606,28 @@ const ApiTokenPage: React.FC = () => {
           fieldProps={{ precision: 0 }}
           rules={[{ required: true, message: '请输入最大并发' }]}
         />
+        <ProFormDigit
+          name="quotaPerMinute"
+          label="每分钟请求数"
+          min={0}
+          fieldProps={{ precision: 0 }}
+          extra="0 或留空表示不限制，下同"
+        />
+        <ProFormDigit name="quotaPerDay" label="每日请求数" min={0} fieldProps={{ precision: 0 }} />
+        <ProFormDigit name="quotaPerMonth" label="每月请求数" min={0} fieldProps={{ precision: 0 }} />
+        <ProFormSelect
+          name="allowedIps"
+          label="允许的来源 IP"
+          mode="tags"
+          placeholder="输入 IP 或 CIDR 后回车，例如 10.0.0.0/8"
+          extra="留空表示不限制；其他地址使用该 Token 会被拒绝并记入操作日志"
+          fieldProps={{ tokenSeparators: [',', ' '], open: false }}
+        />
+        <ProFormSwitch
+          name="requireSignature"
+          label="要求签名请求"
+          extra="开启后只接受 HMAC-SHA256 签名的请求（时间戳 + nonce 防重放），拒绝直接携带 X-API-Token 的请求"
+        />
         <ProFormDateTimePicker
           name="expiresAt"
           label="过期时间"
@@ -497,11 +637,70 @@ const ApiTokenPage: React.FC = () => {
         <ProForm.Item
           name="apiIds"
           label="授权 API"
-          rules={[{ required: true, message: '请选择至少一个授权 API' }]}
+          extra="勾选的接口、API 分组与路径规则至少填写一项，三者取并集"
         >
           <ApiPermissionTransfer loading={apiOptionsLoading} options={apiOptions} />
         </ProForm.Item>
+        <ProFormSelect
+          name="apiGroups"
+          label="API 分组"
+          mode="multiple"
+          options={buildApiGroupOptions(apiOptions)}
+          placeholder="授权整个分组，分组内新增的接口自动生效"
+        />
+        <ProFormList
+          name="pathScopes"
+          label="路径规则"
+          creatorButtonProps={{ creatorButtonText: '添加路径规则' }}
+          creatorRecord={{ method: 'GET', path: '' }}
+          tooltip="与 Casbin 策略相同的 keyMatch2 语法，例如 /api/v1/poetry/* 或 /api/v1/poetry/poem/:id"
+        >
+          <ProForm.Group>
+            <ProFormSelect
+              name="method"
+              width="xs"
+              options={['*', 'GET', 'POST', 'PUT', 'PATCH', 'DELETE'].map((method) => ({
+                label: method === '*' ? '任意' : method,
+                value: method,
+              }))}
+              rules={[{ required: true, message: '请选择请求方法' }]}
+            />
+            <ProFormText
+              name="path"
+              width="lg"
+              placeholder="/api/v1/poetry/*"
+              rules={[
+                { required: true, message: '请输入路径规则' },
+                { pattern: /^\//, message: '路径规则必须以 / 开头' },
+              ]}
+            />
+          </ProForm.Group>
+        </ProFormList>
+        {currentRow?.effectiveApis ? (
+          <ProForm.Item label="生效的 API" extra="按当前已保存的授权计算，保存后刷新">
+            <ApiPermissionSummary apis={currentRow.effectiveApis} maxVisible={5} />
+          </ProForm.Item>
+        ) : null}
       </DrawerForm>
+
+      <ApiTokenUsageDrawer token={usageRow} onClose={() => setUsageRow(undefined)} />
+      <ModalForm<{ grace?: string }>
+        title={rotateRow ? `轮换 Token - ${rotateRow.name}` : '轮换 Token'}
+        width={480}
+        open={!!rotateRow}
+        modalProps={{ destroyOnClose: true, onCancel: () => setRotateRow(undefined) }}
+        onFinish={handleRotate}
+      >
+        <Typography.Paragraph type="secondary">
+          签发新的 Token，旧 Token 在宽限期内仍可使用，便于调用方逐步切换；期间使用旧 Token 的请求会记录最近使用时间。
+        </Typography.Paragraph>
+        <ProFormText
+          name="grace"
+          label="宽限期"
+          placeholder="如 36h、2d，留空使用系统默认"
+          extra="最长 720h"
+        />
+      </ModalForm>
     </PageContainer>
   );
 };
@@ -166,6 +166,8 @@ const PermissionDrawer: React.FC<PermissionDrawerProps> = ({ open, role, onClose
           authorityId: role.authorityId,
           authorityName: role.authorityName,
           defaultRouter: defaultRouter,
+          mfaRequired: role.mfaRequired,
+          allowedCountries: role.allowedCountries,
         });
 
         await Promise.all([p1, p2]);
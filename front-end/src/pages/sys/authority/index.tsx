@@ -5,9 +5,10 @@ import {
   ModalForm,
   ProFormText,
   ProFormDigit,
+  ProFormSelect,
 } from '@ant-design/pro-components';
 import type { ProColumns, ActionType } from '@ant-design/pro-components';
-import { Button, Space, message, Popconfirm } from 'antd';
+import { Button, Space, Tag, message, Popconfirm } from 'antd';
 import { PlusOutlined, SettingOutlined, CopyOutlined, EditOutlined, DeleteOutlined } from '@ant-design/icons';
 
 import { getAuthorityList, createAuthority, updateAuthority, deleteAuthority } from '@/services/api/authority';
@@ -19,6 +20,9 @@ export type AuthorityItem = {
   authorityName: string;
   parentId: number;
   defaultRouter: string;
+  mfaRequired?: boolean;
+  // 允许登录的国家/地区 (ISO 代码)，为空不限制
+  allowedCountries?: string[] | null;
   children: AuthorityItem[] | null;
 };
 
@@ -77,6 +81,8 @@ const AuthorityTableList: React.FC = () => {
     const data = {
       ...values,
       parentId: currentParentId,
+      // 表单中没有的字段保持原值，后端按整体覆盖更新
+      mfaRequired: currentRow?.mfaRequired,
       // 如果是更新，需要传原来的 authorityId
       authorityId: isUpdate ? currentRow?.authorityId : values.authorityId,
     };
@@ -101,6 +107,18 @@ const AuthorityTableList: React.FC = () => {
     { title: '角色ID', dataIndex: 'authorityId', width: 100, fixed: 'left' },
     { title: '角色名称', dataIndex: 'authorityName', width: 200 },
     { title: '默认路由', dataIndex: 'defaultRouter' },
+    {
+      title: '登录地区',
+      dataIndex: 'allowedCountries',
+      render: (_, record) =>
+        record.allowedCountries?.length ? (
+          <Space size={4} wrap>
+            {record.allowedCountries.map((c) => <Tag key={c}>{c}</Tag>)}
+          </Space>
+        ) : (
+          '不限'
+        ),
+    },
     {
       title: '操作',
       valueType: 'option',
@@ -178,6 +196,15 @@ const AuthorityTableList: React.FC = () => {
           placeholder="例如：管理员"
           rules={[{ required: true, message: '角色名称为必填项' }]}
         />
+        <ProFormSelect
+          name="allowedCountries"
+          label="允许登录的地区"
+          tooltip="按登录 IP 的归属国家/地区限制，填写 ISO 二位代码 (如 CN、US)；为空不限制，内网地址不受限制，需在后端配置 GeoIP 库"
+          placeholder="不限制"
+          mode="tags"
+          fieldProps={{ tokenSeparators: [',', ' '] }}
+          transform={(value: string[]) => ({ allowedCountries: (value || []).map((c) => c.trim().toUpperCase()) })}
+        />
       </ModalForm>
 
       {/* 权限设置抽屉 */}
@@ -0,0 +1,144 @@
+import React from 'react';
+import type { ProColumns } from '@ant-design/pro-components';
+import { Space, Tag, Tooltip } from 'antd';
+
+// 登录日志项类型定义
+export type LoginLogItem = {
+  ID: number;
+  CreatedAt: string;
+  userId: number;
+  username: string;
+  success: boolean;
+  method: string; // password、ldap、oidc:<provider>
+  mfa: boolean;
+  reason?: string;
+  ip: string;
+  agent: string;
+  device: string;
+  newDevice: boolean;
+  // IP 归属地 (后端配置 GeoIP 库后才有)
+  country?: string;
+  region?: string;
+  asn?: number;
+  asOrg?: string;
+};
+
+const methodText = (method: string) => {
+  if (method === 'password') return '密码';
+  if (method === 'ldap') return 'LDAP';
+  if (method?.startsWith('oidc:')) return `单点登录 (${method.slice(5)})`;
+  return method;
+};
+
+// 管理员页面与个人中心共用的列定义
+export const loginLogColumns = (showUser: boolean): ProColumns<LoginLogItem>[] => [
+  {
+    title: '用户名',
+    dataIndex: 'username',
+    width: 120,
+    ellipsis: true,
+    hideInTable: !showUser,
+    search: showUser ? undefined : false,
+  },
+  {
+    title: '日期',
+    dataIndex: 'created_at',
+    valueType: 'dateTimeRange',
+    hideInTable: true,
+    search: {
+      transform: (value) => ({ startDate: value[0], endDate: value[1] }),
+    },
+  },
+  {
+    title: '登录时间',
+    dataIndex: 'CreatedAt',
+    valueType: 'dateTime',
+    search: false,
+    width: 160,
+  },
+  {
+    title: '结果',
+    dataIndex: 'success',
+    width: 100,
+    valueEnum: {
+      true: { text: '成功', status: 'Success' },
+      false: { text: '失败', status: 'Error' },
+    },
+    render: (_, record) =>
+      record.success ? (
+        <Tag color="success">成功</Tag>
+      ) : (
+        <Tooltip title={record.reason}>
+          <Tag color="error">失败</Tag>
+        </Tooltip>
+      ),
+  },
+  {
+    title: '认证方式',
+    dataIndex: 'method',
+    width: 140,
+    valueEnum: {
+      password: { text: '密码' },
+      ldap: { text: 'LDAP' },
+      oidc: { text: '单点登录' },
+    },
+    render: (_, record) => (
+      <Space size={4}>
+        {methodText(record.method)}
+        {record.mfa && <Tag color="blue">MFA</Tag>}
+      </Space>
+    ),
+  },
+  {
+    title: '登录IP',
+    dataIndex: 'ip',
+    width: 140,
+    copyable: true,
+  },
+  {
+    title: '归属地',
+    dataIndex: 'country',
+    width: 140,
+    tooltip: '按国家/地区 ISO 代码搜索，如 CN',
+    render: (_, record) => (
+      <Tooltip title={record.asn ? `AS${record.asn} ${record.asOrg || ''}` : undefined}>
+        {[record.country, record.region].filter(Boolean).join(' ') || '-'}
+      </Tooltip>
+    ),
+  },
+  {
+    title: '设备',
+    dataIndex: 'device',
+    search: false,
+    ellipsis: true,
+    render: (_, record) => (
+      <Tooltip title={record.agent}>
+        <Space size={4}>
+          {record.device}
+          {record.newDevice && <Tag color="orange">新设备</Tag>}
+        </Space>
+      </Tooltip>
+    ),
+  },
+  {
+    title: '新设备',
+    dataIndex: 'newDevice',
+    hideInTable: true,
+    valueEnum: {
+      true: { text: '是' },
+      false: { text: '否' },
+    },
+  },
+];
+
+// ProTable 的查询参数转换为接口参数
+export const toLoginLogQuery = (params: any) => {
+  const { current, pageSize, success, newDevice, ...rest } = params;
+  return {
+    ...rest,
+    page: current,
+    pageSize,
+    success: success === undefined ? undefined : success === 'true',
+    newDevice: newDevice === undefined ? undefined : newDevice === 'true',
+  };
+};
@@ -0,0 +1,32 @@
+import React, { useRef } from 'react';
+import { PageContainer } from '@ant-design/pro-layout';
+import { ProTable } from '@ant-design/pro-components';
+import type { ActionType } from '@ant-design/pro-components';
+import { getLoginLogList } from '@/services/api/loginLog';
+import { loginLogColumns, toLoginLogQuery, type LoginLogItem } from './components/columns';
+
+const LoginLogTable: React.FC = () => {
+  const actionRef = useRef<ActionType>(null);
+
+  return (
+    <PageContainer title={false}>
+      <ProTable<LoginLogItem>
+        headerTitle={false}
+        actionRef={actionRef}
+        rowKey="ID"
+        request={async (params) => {
+          const res = await getLoginLogList(toLoginLogQuery(params));
+          return {
+            data: res.data?.list || [],
+            success: res.code === 0,
+            total: res.data?.total || 0,
+          };
+        }}
+        columns={loginLogColumns(true)}
+        scroll={{ x: 1240 }}
+      />
+    </PageContainer>
+  );
+};
+
+export default LoginLogTable;
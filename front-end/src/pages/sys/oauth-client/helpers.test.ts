@@ -0,0 +1,38 @@
+import {
+  buildOauthClientFormInitialValues,
+  buildOauthClientSubmitPayload,
+  formatAccessTokenTtl,
+} from './helpers';
+
+describe('oauth-client helpers', () => {
+  it('formats access token ttl with the largest whole unit', () => {
+    expect(formatAccessTokenTtl(0)).toBe('系统默认');
+    expect(formatAccessTokenTtl(7200)).toBe('2 小时');
+    expect(formatAccessTokenTtl(900)).toBe('15 分钟');
+    expect(formatAccessTokenTtl(45)).toBe('45 秒');
+  });
+
+  it('builds edit form values from an existing client', () => {
+    expect(
+      buildOauthClientFormInitialValues({
+        ID: 3,
+        clientId: 'gwc_abc',
+        secretPrefix: 'gws_abcd',
+        name: 'sync',
+        scopes: ['poetry'],
+        accessTokenTtl: 0,
+        enabled: true,
+      }),
+    ).toEqual({ name: 'sync', description: undefined, scopes: ['poetry'], accessTokenTtl: undefined });
+  });
+
+  it('falls back to the system ttl when the field is left empty', () => {
+    expect(buildOauthClientSubmitPayload({ name: 'sync', scopes: ['poetry'] }, 3)).toEqual({
+      id: 3,
+      name: 'sync',
+      description: undefined,
+      scopes: ['poetry'],
+      accessTokenTtl: 0,
+    });
+  });
+});
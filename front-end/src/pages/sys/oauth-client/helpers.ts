@@ -0,0 +1,46 @@
+import type { OauthClientFormPayload, OauthClientItem } from '@/services/api/oauthClient';
+
+export type OauthClientFormValues = {
+  name?: string;
+  description?: string;
+  scopes?: string[];
+  accessTokenTtl?: number;
+};
+
+// 访问令牌有效期展示，0 表示跟随系统配置 oauth.access_token_ttl
+export const formatAccessTokenTtl = (seconds?: number) => {
+  if (!seconds) {
+    return '系统默认';
+  }
+  if (seconds % 3600 === 0) {
+    return `${seconds / 3600} 小时`;
+  }
+  if (seconds % 60 === 0) {
+    return `${seconds / 60} 分钟`;
+  }
+  return `${seconds} 秒`;
+};
+
+export const buildOauthClientFormInitialValues = (currentRow?: OauthClientItem): OauthClientFormValues => {
+  if (!currentRow) {
+    return { scopes: [] };
+  }
+
+  return {
+    name: currentRow.name,
+    description: currentRow.description,
+    scopes: currentRow.scopes || [],
+    accessTokenTtl: currentRow.accessTokenTtl || undefined,
+  };
+};
+
+export const buildOauthClientSubmitPayload = (
+  values: OauthClientFormValues,
+  currentId?: number,
+): OauthClientFormPayload => ({
+  ...(currentId ? { id: currentId } : {}),
+  name: values.name || '',
+  description: values.description,
+  scopes: values.scopes || [],
+  accessTokenTtl: values.accessTokenTtl || 0,
+});
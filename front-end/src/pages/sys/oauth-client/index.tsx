@@ -0,0 +1,378 @@
+import React, { useRef, useState } from 'react';
+import {
+  ModalForm,
+  ProCard,
+  ProFormDigit,
+  ProFormSelect,
+  ProFormText,
+  ProFormTextArea,
+  ProTable,
+} from '@ant-design/pro-components';
+import { PageContainer } from '@ant-design/pro-layout';
+import type { ActionType, ProColumns } from '@ant-design/pro-components';
+import {
+  CheckCircleOutlined,
+  DeleteOutlined,
+  EditOutlined,
+  PauseCircleOutlined,
+  PlusOutlined,
+  RedoOutlined,
+} from '@ant-design/icons';
+import { Button, Form, message, Modal, Popconfirm, Space, Tag, Typography } from 'antd';
+import dayjs from 'dayjs';
+
+import { getApiOptions } from '@/services/api/apiToken';
+import {
+  createOauthClient,
+  deleteOauthClient,
+  disableOauthClient,
+  enableOauthClient,
+  getOauthClientList,
+  resetOauthClientSecret,
+  updateOauthClient,
+  type OauthClientItem,
+} from '@/services/api/oauthClient';
+import { buildApiGroupOptions } from '../api-token/helpers';
+import {
+  buildOauthClientFormInitialValues,
+  buildOauthClientSubmitPayload,
+  formatAccessTokenTtl,
+  type OauthClientFormValues,
+} from './helpers';
+
+const showClientSecretModal = (clientId: string, clientSecret: string, title: string) => {
+  Modal.info({
+    title,
+    width: 640,
+    content: (
+      <div>
+        <Typography.Paragraph type="secondary">
+          客户端密钥只展示一次，请立即复制并妥善保管。
+        </Typography.Paragraph>
+        <Typography.Paragraph>
+          client_id：<Typography.Text copyable code>{clientId}</Typography.Text>
+        </Typography.Paragraph>
+        <Typography.Paragraph>
+          client_secret：<Typography.Text copyable code>{clientSecret}</Typography.Text>
+        </Typography.Paragraph>
+        <Typography.Paragraph type="secondary">
+          使用 client_credentials 方式向 <Typography.Text code>POST /api/v1/oauth/token</Typography.Text>{' '}
+          换取访问令牌，调用接口时携带 <Typography.Text code>Authorization: Bearer &lt;access_token&gt;</Typography.Text>。
+        </Typography.Paragraph>
+      </div>
+    ),
+  });
+};
+
+const OauthClientPage: React.FC = () => {
+  const actionRef = useRef<ActionType>(null);
+  const [form] = Form.useForm<OauthClientFormValues>();
+  const [modalVisible, setModalVisible] = useState(false);
+  const [currentRow, setCurrentRow] = useState<OauthClientItem>();
+  const [groupOptions, setGroupOptions] = useState<{ label: string; value: string }[]>([]);
+  const [groupOptionsLoading, setGroupOptionsLoading] = useState(false);
+
+  const loadGroupOptions = async () => {
+    setGroupOptionsLoading(true);
+    try {
+      const res = await getApiOptions({ page: 1, pageSize: 9999 });
+      if (res.code !== 0) {
+        message.error(res.msg || '加载 API 分组失败');
+        return;
+      }
+      setGroupOptions(buildApiGroupOptions(res.data?.list || []));
+    } catch (error) {
+      message.error('加载 API 分组失败');
+    } finally {
+      setGroupOptionsLoading(false);
+    }
+  };
+
+  const openModal = (record?: OauthClientItem) => {
+    setCurrentRow(record);
+    form.setFieldsValue(buildOauthClientFormInitialValues(record));
+    setModalVisible(true);
+    if (!groupOptions.length) {
+      loadGroupOptions();
+    }
+  };
+
+  const handleDelete = async (id: number) => {
+    try {
+      const res = await deleteOauthClient({ id });
+      if (res.code !== 0) {
+        message.error(res.msg || '删除失败');
+        return;
+      }
+      message.success('删除成功');
+      actionRef.current?.reload();
+    } catch (error) {
+      message.error('请求异常');
+    }
+  };
+
+  const handleToggleEnable = async (record: OauthClientItem) => {
+    const request = record.enabled ? disableOauthClient : enableOauthClient;
+    try {
+      const res = await request({ id: record.ID });
+      if (res.code !== 0) {
+        message.error(res.msg || '状态更新失败');
+        return;
+      }
+      message.success(record.enabled ? '已禁用' : '已启用');
+      actionRef.current?.reload();
+    } catch (error) {
+      message.error('请求异常');
+    }
+  };
+
+  const handleReset = async (id: number) => {
+    try {
+      const res = await resetOauthClientSecret({ id });
+      if (res.code !== 0) {
+        message.error(res.msg || '重置失败');
+        return;
+      }
+      message.success('重置成功');
+      if (res.data?.clientSecret) {
+        showClientSecretModal(res.data.clientId, res.data.clientSecret, '密钥已重置，请复制新的客户端密钥');
+      }
+      actionRef.current?.reload();
+    } catch (error) {
+      message.error('请求异常');
+    }
+  };
+
+  const handleSubmit = async (values: OauthClientFormValues) => {
+    const payload = buildOauthClientSubmitPayload(values, currentRow?.ID);
+
+    try {
+      const res = currentRow?.ID ? await updateOauthClient(payload) : await createOauthClient(payload);
+      if (res.code !== 0) {
+        message.error(res.msg || '保存失败');
+        return false;
+      }
+
+      message.success(currentRow?.ID ? '更新成功' : '创建成功');
+      if (!currentRow?.ID && res.data?.clientSecret) {
+        showClientSecretModal(res.data.clientId, res.data.clientSecret, '客户端创建成功，请立即复制密钥');
+      }
+
+      setModalVisible(false);
+      actionRef.current?.reload();
+      return true;
+    } catch (error) {
+      message.error('请求异常');
+      return false;
+    }
+  };
+
+  const columns: ProColumns<OauthClientItem>[] = [
+    {
+      title: '名称',
+      dataIndex: 'name',
+      width: 180,
+      ellipsis: true,
+      render: (_, record) => (
+        <Space direction="vertical" size={0}>
+          <Typography.Text strong>{record.name}</Typography.Text>
+          <Typography.Text type="secondary">{record.description || '未填写说明'}</Typography.Text>
+        </Space>
+      ),
+    },
+    {
+      title: 'Client ID',
+      dataIndex: 'clientId',
+      width: 240,
+      search: false,
+      render: (_, record) => (
+        <Space direction="vertical" size={0}>
+          <Typography.Text copyable code>
+            {record.clientId}
+          </Typography.Text>
+          <Typography.Text type="secondary">{`密钥前缀 ${record.secretPrefix}`}</Typography.Text>
+        </Space>
+      ),
+    },
+    {
+      title: '授权分组',
+      dataIndex: 'scopes',
+      width: 260,
+      search: false,
+      render: (_, record) => (
+        <Space size={[4, 4]} wrap>
+          {(record.scopes || []).map((scope) => (
+            <Tag key={scope} color="blue">
+              {scope}
+            </Tag>
+          ))}
+        </Space>
+      ),
+    },
+    {
+      title: '令牌有效期',
+      dataIndex: 'accessTokenTtl',
+      width: 110,
+      search: false,
+      render: (_, record) => formatAccessTokenTtl(record.accessTokenTtl),
+    },
+    {
+      title: '状态',
+      dataIndex: 'enabled',
+      width: 88,
+      valueEnum: {
+        true: { text: '启用', status: 'Success' },
+        false: { text: '禁用', status: 'Default' },
+      },
+      render: (_, record) =>
+        record.enabled ? (
+          <Tag color="success" icon={<CheckCircleOutlined />}>
+            启用
+          </Tag>
+        ) : (
+          <Tag icon={<PauseCircleOutlined />}>禁用</Tag>
+        ),
+    },
+    {
+      title: '最近换取令牌',
+      dataIndex: 'lastUsedAt',
+      width: 140,
+      search: false,
+      render: (_, record) =>
+        record.lastUsedAt ? dayjs(record.lastUsedAt).format('YYYY-MM-DD HH:mm') : '暂无记录',
+    },
+    {
+      title: '操作',
+      dataIndex: 'option',
+      valueType: 'option',
+      width: 240,
+      render: (_, record) => (
+        <Space size="small" wrap>
+          <a onClick={() => openModal(record)}>
+            <EditOutlined /> 编辑
+          </a>
+          <Popconfirm
+            title={record.enabled ? '确认禁用该客户端？' : '确认启用该客户端？'}
+            description={record.enabled ? '禁用后已签发的访问令牌立即失效。' : undefined}
+            onConfirm={() => handleToggleEnable(record)}
+            okText="确认"
+            cancelText="取消"
+          >
+            <a>
+              {record.enabled ? <PauseCircleOutlined /> : <CheckCircleOutlined />} {record.enabled ? '禁用' : '启用'}
+            </a>
+          </Popconfirm>
+          <Popconfirm
+            title="确认重置客户端密钥？"
+            description="重置后旧密钥立即无法换取令牌，已签发的访问令牌在过期前仍然有效。"
+            onConfirm={() => handleReset(record.ID)}
+            okText="确认"
+            cancelText="取消"
+          >
+            <a>
+              <RedoOutlined /> 重置密钥
+            </a>
+          </Popconfirm>
+          <Popconfirm
+            title="确认删除该客户端？"
+            onConfirm={() => handleDelete(record.ID)}
+            okText="确认"
+            cancelText="取消"
+          >
+            <a style={{ color: '#ff4d4f' }}>
+              <DeleteOutlined /> 删除
+            </a>
+          </Popconfirm>
+        </Space>
+      ),
+    },
+  ];
+
+  return (
+    <PageContainer title={false}>
+      <ProCard bordered>
+        <ProTable<OauthClientItem>
+          actionRef={actionRef}
+          rowKey="ID"
+          headerTitle="OAuth2 客户端"
+          tooltip="供机器对机器调用的 client_credentials 客户端，可访问的接口为授权的 API 分组"
+          search={{ labelWidth: 'auto' }}
+          request={async (params) => {
+            const enabled =
+              params.enabled === undefined
+                ? undefined
+                : params.enabled === 'true' || params.enabled === true;
+
+            const res = await getOauthClientList({
+              page: params.current,
+              pageSize: params.pageSize,
+              name: params.name as string,
+              enabled,
+            });
+
+            return {
+              success: res.code === 0,
+              data: res.data?.list || [],
+              total: res.data?.total || 0,
+            };
+          }}
+          columns={columns}
+          scroll={{ x: 1260 }}
+          toolBarRender={() => [
+            <Button key="create" type="primary" onClick={() => openModal()}>
+              <PlusOutlined /> 新建客户端
+            </Button>,
+          ]}
+        />
+      </ProCard>
+
+      <ModalForm<OauthClientFormValues>
+        form={form}
+        title={currentRow ? '编辑客户端' : '新建客户端'}
+        width={560}
+        open={modalVisible}
+        onOpenChange={(open) => {
+          setModalVisible(open);
+          if (!open) {
+            setCurrentRow(undefined);
+            form.resetFields();
+          }
+        }}
+        onFinish={handleSubmit}
+        modalProps={{ destroyOnClose: true }}
+      >
+        <ProFormText
+          name="name"
+          label="名称"
+          placeholder="例如：数据同步服务"
+          rules={[{ required: true, message: '请输入客户端名称' }]}
+        />
+        <ProFormTextArea
+          name="description"
+          label="说明"
+          placeholder="填写客户端的用途、所属系统或负责人"
+          fieldProps={{ rows: 3, showCount: true, maxLength: 120 }}
+        />
+        <ProFormSelect
+          name="scopes"
+          label="授权分组"
+          mode="multiple"
+          options={groupOptions}
+          fieldProps={{ loading: groupOptionsLoading }}
+          extra="即 OAuth2 的 scope；换取令牌时可申请其中的一部分，不填则获得全部分组"
+          rules={[{ required: true, message: '请至少选择一个 API 分组' }]}
+        />
+        <ProFormDigit
+          name="accessTokenTtl"
+          label="令牌有效期（秒）"
+          min={60}
+          max={86400}
+          fieldProps={{ precision: 0 }}
+          placeholder="留空使用系统默认"
+        />
+      </ModalForm>
+    </PageContainer>
+  );
+};
+
+export default OauthClientPage;
@@ -2,7 +2,7 @@ import React, { useRef, useState } from 'react';
 import { PageContainer } from '@ant-design/pro-layout';
 import { ProTable } from '@ant-design/pro-components';
 import type { ProColumns, ActionType } from '@ant-design/pro-components';
-import { Button, Tag, Space, message, Popconfirm, Modal, Typography } from 'antd';
+import { Button, Tag, Space, message, Popconfirm, Modal, Tooltip, Typography } from 'antd';
 import { DeleteOutlined, EyeOutlined } from '@ant-design/icons';
 import { getOperationLogList, deleteOperationLogByIds } from '@/services/api/operationLog';
 
@@ -21,8 +21,16 @@ type OperationLogItem = {
   body: string;
   resp: string;
   user?: { nickName: string; userName: string };
+  // 模拟登录期间的操作记录发起人
+  impersonatorId?: number;
+  impersonator?: { nickName: string; userName: string };
   traceId?: string;
   error_msg?: string;
+  // IP 归属地 (后端配置 GeoIP 库后才有)
+  country?: string;
+  region?: string;
+  asn?: number;
+  asOrg?: string;
 };
 
 // 格式化 JSON 辅助函数
@@ -70,9 +78,16 @@ const OperationLogTable: React.FC = () => {
       width: 120, // 固定宽度
       ellipsis: true, // 超出显示省略号
       render: (_, record) => (
-        <Tag color="blue" style={{ maxWidth: '100%', overflow: 'hidden', textOverflow: 'ellipsis' }}>
-          {record.user?.nickName || record.user?.userName || 'Unknown'}
-        </Tag>
+        <Space direction="vertical" size={2} style={{ maxWidth: '100%' }}>
+          <Tag color="blue" style={{ maxWidth: '100%', overflow: 'hidden', textOverflow: 'ellipsis' }}>
+            {record.user?.nickName || record.user?.userName || 'Unknown'}
+          </Tag>
+          {!!record.impersonatorId && (
+            <Tag color="orange" style={{ maxWidth: '100%', overflow: 'hidden', textOverflow: 'ellipsis' }}>
+              模拟: {record.impersonator?.nickName || record.impersonator?.userName || record.impersonatorId}
+            </Tag>
+          )}
+        </Space>
       ),
     },
     {
@@ -150,6 +165,17 @@ const OperationLogTable: React.FC = () => {
       width: 120,
       copyable: true,
     },
+    {
+      title: '归属地',
+      dataIndex: 'country',
+      width: 140,
+      tooltip: '按国家/地区 ISO 代码搜索，如 CN',
+      render: (_, record) => (
+        <Tooltip title={record.asn ? `AS${record.asn} ${record.asOrg || ''}` : undefined}>
+          {[record.country, record.region].filter(Boolean).join(' ') || '-'}
+        </Tooltip>
+      ),
+    },
     {
       title: 'TraceID', // OTel 链路追踪
       dataIndex: 'traceId',
@@ -196,7 +222,7 @@ const OperationLogTable: React.FC = () => {
           };
         }}
         columns={columns}
-        scroll={{ x: 1300 }}
+        scroll={{ x: 1440 }}
         toolBarRender={() => [
           selectedRowKeys.length > 0 && (
             <Popconfirm
@@ -0,0 +1,83 @@
+import React, { useRef } from 'react';
+import { Drawer, Popconfirm, Tag, message } from 'antd';
+import { ProTable } from '@ant-design/pro-components';
+import type { ActionType, ProColumns } from '@ant-design/pro-components';
+
+import { getUserPasskeys, revokeUserPasskey } from '@/services/api/user';
+
+type PasskeyItem = {
+  ID: number;
+  name: string;
+  aaguid: string;
+  backupEligible: boolean;
+  signCount: number;
+  CreatedAt: string;
+  lastUsedAt?: string;
+};
+
+type Props = {
+  user?: API.UserInfo;
+  onClose: () => void;
+};
+
+// 用户的通行密钥：删除后以站内通知告知用户
+const PasskeyDrawer: React.FC<Props> = ({ user, onClose }) => {
+  const actionRef = useRef<ActionType>(null);
+
+  const handleRevoke = async (id: number) => {
+    const res = await revokeUserPasskey({ id });
+    if (res.code === 0) {
+      message.success('已删除');
+      actionRef.current?.reload();
+      return;
+    }
+    message.error(res.msg || '删除失败');
+  };
+
+  const columns: ProColumns<PasskeyItem>[] = [
+    { title: '名称', dataIndex: 'name' },
+    {
+      title: '类型',
+      dataIndex: 'backupEligible',
+      render: (_, record) =>
+        record.backupEligible ? <Tag color="blue">可同步</Tag> : <Tag>单设备</Tag>,
+    },
+    { title: '签名计数', dataIndex: 'signCount' },
+    { title: '添加时间', dataIndex: 'CreatedAt', valueType: 'dateTime' },
+    { title: '最近使用', dataIndex: 'lastUsedAt', valueType: 'dateTime' },
+    {
+      title: '操作',
+      valueType: 'option',
+      render: (_, record) => (
+        <Popconfirm title="确定删除该通行密钥?" onConfirm={() => handleRevoke(record.ID)}>
+          <a style={{ color: '#ff4d4f' }}>删除</a>
+        </Popconfirm>
+      ),
+    },
+  ];
+
+  return (
+    <Drawer
+      title={`「${user?.nickName || user?.username || ''}」的通行密钥`}
+      width={800}
+      open={!!user}
+      onClose={onClose}
+      destroyOnClose
+    >
+      <ProTable<PasskeyItem>
+        actionRef={actionRef}
+        rowKey="ID"
+        search={false}
+        options={false}
+        pagination={false}
+        request={async () => {
+          const res = await getUserPasskeys({ userId: user?.ID as number });
+          return { data: res.data || [], success: res.code === 0 };
+        }}
+        columns={columns}
+      />
+    </Drawer>
+  );
+};
+
+export default PasskeyDrawer;
@@ -0,0 +1,113 @@
+import React, { useRef, useState } from 'react';
+import { Drawer, Input, Modal, Segmented, Space, message } from 'antd';
+import { ProTable } from '@ant-design/pro-components';
+import type { ActionType, ProColumns } from '@ant-design/pro-components';
+
+import { approveRegistration, getRegistrationList, rejectRegistration } from '@/services/api/user';
+
+// 与后端 model.UserPendingApproval / UserRejected 保持一致
+const STATUS_PENDING_APPROVAL = 4;
+const STATUS_REJECTED = 5;
+
+type Props = {
+  open: boolean;
+  onClose: () => void;
+  // 审核通过后刷新用户列表
+  onApproved?: () => void;
+};
+
+// 注册审核队列：审核通过或拒绝，拒绝时可填写审核意见
+const RegistrationDrawer: React.FC<Props> = ({ open, onClose, onApproved }) => {
+  const actionRef = useRef<ActionType>(null);
+  const [status, setStatus] = useState<number>(STATUS_PENDING_APPROVAL);
+
+  const review = (record: API.UserInfo, approve: boolean) => {
+    let reason = '';
+    Modal.confirm({
+      title: `${approve ? '通过' : '拒绝'}「${record.username}」的注册申请`,
+      content: (
+        <Input.TextArea
+          rows={3}
+          maxLength={255}
+          placeholder={approve ? '审核意见 (可选)' : '拒绝原因 (将邮件告知申请人，可选)'}
+          onChange={(e) => {
+            reason = e.target.value;
+          }}
+        />
+      ),
+      okButtonProps: approve ? undefined : { danger: true },
+      onOk: async () => {
+        const body = { id: record.ID as number, reason };
+        const res = approve ? await approveRegistration(body) : await rejectRegistration(body);
+        if (res.code === 0) {
+          message.success(res.msg || '操作成功');
+          actionRef.current?.reload();
+          if (approve) {
+            onApproved?.();
+          }
+          return;
+        }
+        message.error(res.msg || '操作失败');
+      },
+    });
+  };
+
+  const columns: ProColumns<API.UserInfo>[] = [
+    { title: '用户名', dataIndex: 'username' },
+    { title: '昵称', dataIndex: 'nickName', search: false },
+    { title: '邮箱', dataIndex: 'email', search: false },
+    { title: '手机号', dataIndex: 'phone', search: false },
+    { title: '申请时间', dataIndex: 'CreatedAt', valueType: 'dateTime', search: false },
+    {
+      title: '操作',
+      valueType: 'option',
+      hideInTable: status !== STATUS_PENDING_APPROVAL,
+      render: (_, record) => (
+        <Space>
+          <a onClick={() => review(record, true)}>通过</a>
+          <a style={{ color: '#ff4d4f' }} onClick={() => review(record, false)}>
+            拒绝
+          </a>
+        </Space>
+      ),
+    },
+  ];
+
+  return (
+    <Drawer title="注册审核" width={900} open={open} onClose={onClose} destroyOnClose>
+      <ProTable<API.UserInfo>
+        actionRef={actionRef}
+        rowKey="ID"
+        search={{ labelWidth: 'auto' }}
+        params={{ status }}
+        request={async (params) => {
+          const res = await getRegistrationList({
+            page: params.current,
+            pageSize: params.pageSize,
+            username: params.username,
+            status: params.status,
+          });
+          return {
+            data: res.data?.list || [],
+            success: res.code === 0,
+            total: res.data?.total || 0,
+          };
+        }}
+        columns={columns}
+        toolBarRender={() => [
+          <Segmented
+            key="status"
+            value={status}
+            onChange={(value) => setStatus(value as number)}
+            options={[
+              { label: '待审核', value: STATUS_PENDING_APPROVAL },
+              { label: '已拒绝', value: STATUS_REJECTED },
+            ]}
+          />,
+        ]}
+      />
+    </Drawer>
+  );
+};
+
+export default RegistrationDrawer;
@@ -9,12 +9,15 @@ import {
   ProFormGroup,
 } from '@ant-design/pro-components';
 import type { ProColumns, ActionType } from '@ant-design/pro-components';
-import { Button, Space, message, Popconfirm, Tag, Avatar, Divider } from 'antd';
-import { PlusOutlined, UserOutlined, KeyOutlined, EditOutlined, DeleteOutlined } from '@ant-design/icons';
+import { Button, Space, message, Popconfirm, Tag, Avatar, Divider, Modal, Input } from 'antd';
+import { PlusOutlined, UserOutlined, KeyOutlined, EditOutlined, DeleteOutlined, AuditOutlined, UserSwitchOutlined, SafetyCertificateOutlined } from '@ant-design/icons';
 
 // 导入 API
-import { getUserList, addUser, updateUser, deleteUser, resetPassword } from '@/services/api/user';
+import { getUserList, addUser, updateUser, deleteUser, resetPassword, impersonate } from '@/services/api/user';
 import { getAuthorityList } from '@/services/api/authority';
+import { startImpersonation } from '@/utils/impersonation';
+import RegistrationDrawer from './components/RegistrationDrawer';
+import PasskeyDrawer from './components/PasskeyDrawer';
 
 type AuthorityTreeNode = {
   title: string;
@@ -35,6 +38,10 @@ const UserTableList: React.FC = () => {
   // 重置密码模态框
   const [pwdModalVisible, setPwdModalVisible] = useState<boolean>(false);
   const [pwdCurrentRow, setPwdCurrentRow] = useState<API.UserInfo>();
+  // 注册审核抽屉
+  const [registrationVisible, setRegistrationVisible] = useState<boolean>(false);
+  // 通行密钥抽屉
+  const [passkeyUser, setPasskeyUser] = useState<API.UserInfo>();
 
   // --- 操作处理 ---
 
@@ -68,6 +75,33 @@ const UserTableList: React.FC = () => {
     setPwdModalVisible(true);
   };
 
+  // 模拟登录：以该用户身份操作，填写的原因会写入审计日志
+  const handleImpersonate = (record: API.UserInfo) => {
+    let reason = '';
+    Modal.confirm({
+      title: `以「${record.nickName || record.username}」的身份登录`,
+      content: (
+        <Input.TextArea
+          rows={3}
+          maxLength={255}
+          placeholder="模拟原因 (可选)，模拟期间的所有请求都会记录到操作日志"
+          onChange={(e) => {
+            reason = e.target.value;
+          }}
+        />
+      ),
+      onOk: async () => {
+        const res = await impersonate({ userId: record.ID as number, reason });
+        if (res.code === 0) {
+          message.success('已切换身份，正在刷新...');
+          startImpersonation(res.data.token);
+          return;
+        }
+        message.error(res.msg || '模拟登录失败');
+      },
+    });
+  };
+
   // 提交用户表单 (新增/编辑)
   const handleFinish = async (values: any) => {
     const isUpdate = !!currentRow?.ID;
@@ -105,7 +139,8 @@ const UserTableList: React.FC = () => {
     try {
       const res = await resetPassword({
         id: pwdCurrentRow.ID,
-        password: values.password
+        password: values.password,
+        requirePasswordChange: !!values.requirePasswordChange,
       });
       if (res.code === 0) {
         message.success('密码重置成功');
@@ -184,6 +219,9 @@ const UserTableList: React.FC = () => {
       valueEnum: {
         1: { text: '正常', status: 'Success' },
         2: { text: '冻结', status: 'Error' },
+        3: { text: '待验证', status: 'Processing' },
+        4: { text: '待审核', status: 'Warning' },
+        5: { text: '已拒绝', status: 'Default' },
       },
       // ✨ 关键修改：使用 render 自定义渲染为 Tag
       render: (_, record) => {
@@ -191,6 +229,9 @@ const UserTableList: React.FC = () => {
         const statusMap: Record<number, { color: string; text: string }> = {
           1: { color: 'success', text: '正常' }, // 绿色胶囊
           2: { color: 'error', text: '冻结' },   // 红色胶囊
+          3: { color: 'processing', text: '待验证' },
+          4: { color: 'warning', text: '待审核' },
+          5: { color: 'default', text: '已拒绝' },
         };
 
         const current = statusMap[record.status] || { color: 'default', text: '未知' };
@@ -210,7 +251,7 @@ const UserTableList: React.FC = () => {
     {
       title: '操作',
       valueType: 'option',
-      width: 220,
+      width: 380,
       fixed: 'right',
       render: (_, record) => (
         <Space size="small">
@@ -220,6 +261,14 @@ const UserTableList: React.FC = () => {
           <a onClick={() => handleResetPwdClick(record)}>
             <KeyOutlined /> 重置密码
           </a>
+          <a onClick={() => setPasskeyUser(record)}>
+            <SafetyCertificateOutlined /> 通行密钥
+          </a>
+          {record.status === 1 && (
+            <a onClick={() => handleImpersonate(record)}>
+              <UserSwitchOutlined /> 模拟登录
+            </a>
+          )}
           <Popconfirm
             title="确定删除此用户?"
             description="删除后无法恢复"
@@ -263,12 +312,22 @@ const UserTableList: React.FC = () => {
         columns={columns}
         scroll={{ x: 1000 }}
         toolBarRender={() => [
+          <Button key="registration" onClick={() => setRegistrationVisible(true)}>
+            <AuditOutlined /> 注册审核
+          </Button>,
           <Button key="add" type="primary" onClick={handleAdd}>
             <PlusOutlined /> 新建用户
           </Button>,
         ]}
       />
 
+      <RegistrationDrawer
+        open={registrationVisible}
+        onClose={() => setRegistrationVisible(false)}
+        onApproved={() => actionRef.current?.reload()}
+      />
+      <PasskeyDrawer user={passkeyUser} onClose={() => setPasskeyUser(undefined)} />
+
       {/* --- 1. 用户信息表单 (新增/编辑) --- */}
       <ModalForm
         title={currentRow ? '编辑用户' : '新建用户'}
@@ -363,6 +422,11 @@ const UserTableList: React.FC = () => {
           checkedChildren="正常"
           unCheckedChildren="冻结"
         />
+
+        <ProFormSwitch
+          name="requirePasswordChange"
+          label="下次登录修改密码"
+        />
       </ModalForm>
 
       {/* --- 2. 重置密码模态框 --- */}
@@ -379,6 +443,11 @@ const UserTableList: React.FC = () => {
           label="新密码"
           rules={[{ required: true, message: '请输入新密码' }]}
         />
+        <ProFormSwitch
+          name="requirePasswordChange"
+          label="下次登录修改密码"
+          initialValue
+        />
       </ModalForm>
 
     </PageContainer>
@@ -1,6 +1,7 @@
-import {LockOutlined, UserOutlined} from '@ant-design/icons';
+import {KeyOutlined, LockOutlined, SafetyOutlined, UserOutlined} from '@ant-design/icons';
 import {
   LoginForm,
+  ModalForm,
   ProFormCheckbox,
   ProFormText,
 } from '@ant-design/pro-components';
@@ -11,12 +12,26 @@ import {
   useIntl,
   useModel,
 } from '@umijs/max';
-import { Alert, App, Tabs } from 'antd';
+import { Alert, App, Button, Divider, QRCode, Space, Tabs, Typography } from 'antd';
 import { createStyles } from 'antd-style';
-import React, { useState } from 'react';
+import React, { useEffect, useState } from 'react';
 import { flushSync } from 'react-dom';
 import { Footer } from '@/components';
-import { login } from '@/services/api/user';
+import {
+  beginMfaPasskey,
+  beginPasskeyLogin,
+  forgotPassword,
+  getCaptcha,
+  getOidcProviders,
+  login,
+  loginMfa,
+  loginPasskey,
+  loginPassword,
+  oidcAuthorizeUrl,
+  oidcExchange,
+  setupLoginMfa,
+} from '@/services/api/user';
+import { getPasskey, passkeySupported } from '@/utils/passkey';
 import Settings from '../../../../config/defaultSettings';
 
 const useStyles = createStyles(({ token }) => {
@@ -81,12 +96,49 @@ const LoginMessage: React.FC<{
   );
 };
 
+// 二次验证挑战：密码校验通过后由后端返回
+type MfaChallenge = {
+  token: string;
+  enroll: boolean;
+  methods: string[]; // 可用的验证方式：totp、passkey
+  secret?: string;
+  uri?: string;
+};
+
+// 登录验证码：失败次数达到阈值或后端要求时展示
+type CaptchaState = {
+  id: string;
+  pic: string;
+  length: number;
+  required: boolean;
+};
+
+// 单点登录方式
+type OidcProvider = {
+  name: string;
+  displayName: string;
+};
+
+// 后端返回的防爆破错误码
+const CAPTCHA_REQUIRED_CODE = 1005;
+const CAPTCHA_INVALID_CODE = 1006;
+// 密码过期或被管理员要求修改
+const PASSWORD_CHANGE_REQUIRED_CODE = 1008;
+// 大于该值的错误码 (验证码、账号锁定) 直接展示后端消息
+const UNAUTHORIZED_ERROR_CODE = 1004;
+
 const Login: React.FC = () => {
   const [userLoginState, setUserLoginState] = useState<API.LoginResult>({msg: "", code: -1});
   const [type, setType] = useState<string>('account');
+  const [mfa, setMfa] = useState<MfaChallenge>();
+  // 需要修改密码时保留已校验的账号密码，与新密码一起提交
+  const [passwordChange, setPasswordChange] = useState<{ username: string; password: string }>();
+  const [captcha, setCaptcha] = useState<CaptchaState>();
+  const [providers, setProviders] = useState<OidcProvider[]>([]);
+  const [forgotOpen, setForgotOpen] = useState(false);
   const { initialState, setInitialState } = useModel('@@initialState');
   const { styles } = useStyles();
-  const { message } = App.useApp();
+  const { message, modal } = App.useApp();
   const intl = useIntl();
 
   // 获取用户信息
@@ -102,34 +154,197 @@ const Login: React.FC = () => {
     }
   };
 
+  // 验证码只能使用一次，每次提交失败后都需要刷新
+  const loadCaptcha = async (required?: boolean) => {
+    const res = await getCaptcha();
+    if (res.code !== 0 || !res.data?.captchaId) {
+      return;
+    }
+    setCaptcha((prev) => ({
+      id: res.data.captchaId,
+      pic: res.data.picPath,
+      length: res.data.captchaLength,
+      required: !!required || !!res.data.openCaptcha || !!prev?.required,
+    }));
+  };
+
+  useEffect(() => {
+    loadCaptcha();
+    getOidcProviders().then((res) => {
+      if (res.code === 0 && Array.isArray(res.data)) {
+        setProviders(res.data);
+      }
+    });
+  }, []);
+
+  // 登录成功：保存 Token 并跳转
+  const finishLogin = async (data?: { token?: string; recoveryCodes?: string[] }) => {
+    message.success(
+      intl.formatMessage({
+        id: 'pages.login.success',
+        defaultMessage: '登录成功！',
+      }),
+    );
+    if (data?.token) {
+      localStorage.setItem('token', data.token);
+    }
+    // 获取用户具体信息
+    await fetchUserInfo();
+    const redirect = () => {
+      const urlParams = new URL(window.location.href).searchParams;
+      window.location.href = urlParams.get('redirect') || '/';
+    };
+    // 首次绑定二次验证时返回恢复码，只展示这一次
+    if (data?.recoveryCodes?.length) {
+      modal.info({
+        title: '请妥善保存恢复码',
+        content: (
+          <Typography.Paragraph copyable={{ text: data.recoveryCodes.join('\n') }}>
+            {data.recoveryCodes.map((code) => (
+              <div key={code}>{code}</div>
+            ))}
+          </Typography.Paragraph>
+        ),
+        onOk: redirect,
+      });
+      return;
+    }
+    redirect();
+  };
+
+  // 账号密码或单点登录成功：需要二次验证时切换到验证码输入，否则完成登录
+  const handleLoginResult = async (data: any) => {
+    if (data?.mfaRequired) {
+      const challenge: MfaChallenge = {
+        token: data.mfaToken,
+        enroll: !!data.mfaEnrollRequired,
+        methods: data.mfaMethods || [],
+      };
+      if (challenge.enroll) {
+        const setup = await setupLoginMfa({ mfaToken: challenge.token });
+        challenge.secret = setup.data?.secret;
+        challenge.uri = setup.data?.uri;
+      }
+      setUserLoginState({ msg: '', code: -1 });
+      setMfa(challenge);
+      return;
+    }
+    await finishLogin(data);
+  };
+
+  // 通行密钥签名：mfaToken 为空时免密码登录，否则作为二次验证
+  const handlePasskey = async (mfaToken?: string) => {
+    const options = mfaToken ? await beginMfaPasskey({ mfaToken }) : await beginPasskeyLogin();
+    if (options.code !== 0) {
+      setUserLoginState({ code: options.code, msg: options.msg });
+      return;
+    }
+    let credential;
+    try {
+      credential = await getPasskey(options.data.options);
+    } catch (error) {
+      // 用户取消或没有可用的通行密钥
+      console.log(error);
+      return;
+    }
+    const ceremonyId = options.data.ceremonyId;
+    const response = mfaToken
+      ? await loginMfa({ mfaToken, passkeyCeremonyId: ceremonyId, passkeyCredential: credential })
+      : await loginPasskey({ ceremonyId, credential });
+    if (response.code === 0) {
+      await finishLogin(response.data);
+      return;
+    }
+    // 通行密钥校验失败时直接展示后端消息
+    setUserLoginState({ code: Math.max(response.code, UNAUTHORIZED_ERROR_CODE + 1), msg: response.msg });
+  };
+
+  // 单点登录回调：地址中带有一次性票据或错误信息
+  useEffect(() => {
+    const urlParams = new URL(window.location.href).searchParams;
+    const ssoError = urlParams.get('ssoError');
+    if (ssoError) {
+      setUserLoginState({ code: UNAUTHORIZED_ERROR_CODE + 1, msg: ssoError });
+      return;
+    }
+    const ticket = urlParams.get('ticket');
+    if (!ticket) {
+      return;
+    }
+    // 票据只能使用一次，换取前先从地址栏移除
+    urlParams.delete('ticket');
+    window.history.replaceState(null, '', `${window.location.pathname}?${urlParams.toString()}`);
+    oidcExchange({ ticket }).then(async (response) => {
+      if (response.code === 0) {
+        await handleLoginResult(response.data);
+        return;
+      }
+      setUserLoginState({ code: UNAUTHORIZED_ERROR_CODE + 1, msg: response.msg });
+    });
+  }, []);
+
   // 处理表单提交 - 登录
-  const handleSubmit = async (values: API.LoginParams) => {
+  const handleSubmit = async (values: API.LoginParams & { code?: string; newPassword?: string }) => {
     try {
+      // 第二步：提交二次验证码 (6 位验证码或恢复码)
+      if (mfa) {
+        const input = (values.code || '').trim();
+        const isTotp = /^\d{6}$/.test(input);
+        const response = await loginMfa({
+          mfaToken: mfa.token,
+          code: isTotp ? input : undefined,
+          recoveryCode: isTotp ? undefined : input,
+        });
+        if (response.code === 0) {
+          await finishLogin(response.data);
+          return;
+        }
+        setUserLoginState({ code: response.code, msg: response.msg });
+        return;
+      }
+
+      // 设置新密码后继续登录
+      if (passwordChange) {
+        const response = await loginPassword({
+          ...passwordChange,
+          newPassword: values.newPassword || '',
+          captcha: values.captcha,
+          captchaId: captcha?.required ? captcha.id : undefined,
+        });
+        if (response.code === 0) {
+          setPasswordChange(undefined);
+          await handleLoginResult(response.data);
+          return;
+        }
+        setUserLoginState({ code: response.code, msg: response.msg });
+        if (captcha?.required) {
+          await loadCaptcha();
+        }
+        return;
+      }
+
       // 登录
-      const response = await login({ ...values, type });
+      const response = await login({
+        ...values,
+        type,
+        captchaId: captcha?.required ? captcha.id : undefined,
+      });
       // 判断是否登录成功
       if (response.code === 0) {
-        const defaultLoginSuccessMessage = intl.formatMessage({
-          id: 'pages.login.success',
-          defaultMessage: '登录成功！',
-        });
-        message.success(defaultLoginSuccessMessage);
-        // ✨✨✨ 关键修改：保存 Token ✨✨✨
-        // 假设后端返回结构是 response.data.token
-        const token = response.data?.token;
-        if (token) {
-          localStorage.setItem('token', token);
-        }
-        // 获取用户具体信息
-        await fetchUserInfo();
-        // 路由跳转
-        const urlParams = new URL(window.location.href).searchParams;
-        window.location.href = urlParams.get('redirect') || '/';
+        await handleLoginResult(response.data);
         return;
       }
       console.log(response);
+      if (response.code === PASSWORD_CHANGE_REQUIRED_CODE) {
+        setPasswordChange({ username: values.username || '', password: values.password || '' });
+      }
       // 如果失败去设置登录失败错误信息
       setUserLoginState({code: response.code, msg: response.msg})
+      const needCaptcha =
+        response.code === CAPTCHA_REQUIRED_CODE || response.code === CAPTCHA_INVALID_CODE;
+      if (needCaptcha || captcha?.required) {
+        await loadCaptcha(needCaptcha);
+      }
     } catch (error) {
       const defaultLoginFailureMessage = intl.formatMessage({
         id: 'pages.login.failure',
@@ -172,7 +387,7 @@ const Login: React.FC = () => {
             autoLogin: true,
           }}
           onFinish={async (values) => {
-            await handleSubmit(values as API.LoginParams);
+            await handleSubmit(values as API.LoginParams & { code?: string; newPassword?: string });
           }}
         >
           <Tabs
@@ -192,13 +407,100 @@ const Login: React.FC = () => {
           {/*如果登录失败显示*/}
           {userLoginState.code > 0 && (
             <LoginMessage
-              content={intl.formatMessage({
-                id: 'pages.login.accountLogin.errorMessage',
-                defaultMessage: '账户或密码错误',
-              })}
+              content={
+                userLoginState.code > UNAUTHORIZED_ERROR_CODE
+                  ? userLoginState.msg
+                  : intl.formatMessage({
+                      id: 'pages.login.accountLogin.errorMessage',
+                      defaultMessage: '账户或密码错误',
+                    })
+              }
             />
           )}
-          <>
+          {mfa ? (
+            <>
+              {mfa.enroll && mfa.uri && (
+                <Space direction="vertical" align="center" style={{ width: '100%', marginBottom: 16 }}>
+                  <Typography.Text>当前角色要求启用二次验证，请使用验证器 App 扫码绑定</Typography.Text>
+                  <QRCode value={mfa.uri} />
+                  <Typography.Text copyable>{mfa.secret}</Typography.Text>
+                </Space>
+              )}
+              <ProFormText
+                name="code"
+                fieldProps={{
+                  size: 'large',
+                  prefix: <SafetyOutlined />,
+                  autoComplete: 'one-time-code',
+                }}
+                placeholder={mfa.enroll ? '请输入验证器中的 6 位验证码' : '请输入 6 位验证码或恢复码'}
+                rules={[{ required: true, message: '请输入验证码！' }]}
+              />
+              {mfa.methods.includes('passkey') && passkeySupported() && (
+                <Button
+                  block
+                  icon={<KeyOutlined />}
+                  style={{ marginBottom: 24 }}
+                  onClick={() => handlePasskey(mfa.token)}
+                >
+                  使用通行密钥验证
+                </Button>
+              )}
+            </>
+          ) : passwordChange ? (
+            <>
+              <ProFormText.Password
+                name="newPassword"
+                fieldProps={{
+                  size: 'large',
+                  prefix: <LockOutlined />,
+                  autoComplete: 'new-password',
+                }}
+                placeholder="请输入新密码"
+                rules={[{ required: true, message: '请输入新密码！' }]}
+              />
+              <ProFormText.Password
+                name="confirmPassword"
+                dependencies={['newPassword']}
+                fieldProps={{
+                  size: 'large',
+                  prefix: <LockOutlined />,
+                  autoComplete: 'new-password',
+                }}
+                placeholder="请再次输入新密码"
+                rules={[
+                  { required: true, message: '请再次输入新密码！' },
+                  ({ getFieldValue }) => ({
+                    validator: (_, value) =>
+                      !value || getFieldValue('newPassword') === value
+                        ? Promise.resolve()
+                        : Promise.reject(new Error('两次输入的密码不一致')),
+                  }),
+                ]}
+              />
+              {captcha?.required && (
+                <ProFormText
+                  name="captcha"
+                  fieldProps={{
+                    size: 'large',
+                    prefix: <SafetyOutlined />,
+                    maxLength: captcha.length,
+                    addonAfter: (
+                      <img
+                        src={captcha.pic}
+                        alt="captcha"
+                        style={{ height: 38, cursor: 'pointer' }}
+                        onClick={() => loadCaptcha()}
+                      />
+                    ),
+                  }}
+                  placeholder="请输入验证码"
+                  rules={[{ required: true, message: '请输入验证码！' }]}
+                />
+              )}
+            </>
+          ) : (
+            <>
               <ProFormText
                 name="username"
                 fieldProps={{
@@ -243,7 +545,28 @@ const Login: React.FC = () => {
                   },
                 ]}
               />
+              {captcha?.required && (
+                <ProFormText
+                  name="captcha"
+                  fieldProps={{
+                    size: 'large',
+                    prefix: <SafetyOutlined />,
+                    maxLength: captcha.length,
+                    addonAfter: (
+                      <img
+                        src={captcha.pic}
+                        alt="captcha"
+                        style={{ height: 38, cursor: 'pointer' }}
+                        onClick={() => loadCaptcha()}
+                      />
+                    ),
+                  }}
+                  placeholder="请输入验证码"
+                  rules={[{ required: true, message: '请输入验证码！' }]}
+                />
+              )}
             </>
+          )}
           <div
             style={{
               marginBottom: 24,
@@ -259,6 +582,7 @@ const Login: React.FC = () => {
               style={{
                 float: 'right',
               }}
+              onClick={() => setForgotOpen(true)}
             >
               <FormattedMessage
                 id="pages.login.forgotPassword"
@@ -266,8 +590,56 @@ const Login: React.FC = () => {
               />
             </a>
           </div>
+          {!mfa && !passwordChange && (providers.length > 0 || passkeySupported()) && (
+            <>
+              <Divider plain>其他登录方式</Divider>
+              <Space direction="vertical" style={{ width: '100%', marginBottom: 24 }}>
+                {passkeySupported() && (
+                  <Button block icon={<KeyOutlined />} onClick={() => handlePasskey()}>
+                    通行密钥登录
+                  </Button>
+                )}
+                {providers.map((p) => (
+                  <Button
+                    key={p.name}
+                    block
+                    onClick={() => {
+                      const redirect = new URL(window.location.href).searchParams.get('redirect') || '/';
+                      window.location.href = oidcAuthorizeUrl(p.name, redirect);
+                    }}
+                  >
+                    {p.displayName}
+                  </Button>
+                ))}
+              </Space>
+            </>
+          )}
         </LoginForm>
       </div>
+      {/* 找回密码：无论账号是否存在都提示相同结果 */}
+      <ModalForm<{ account: string }>
+        title="找回密码"
+        width="400px"
+        open={forgotOpen}
+        onOpenChange={setForgotOpen}
+        modalProps={{ destroyOnClose: true }}
+        onFinish={async (values) => {
+          const res = await forgotPassword({ account: values.account });
+          if (res.code === 0) {
+            message.success(res.msg || '如果账号存在且已绑定邮箱，重置链接已发送');
+            return true;
+          }
+          message.error(res.msg || '发送失败，请稍后再试');
+          return false;
+        }}
+      >
+        <ProFormText
+          name="account"
+          label="用户名或邮箱"
+          placeholder="请输入用户名或绑定的邮箱"
+          rules={[{ required: true, message: '请输入用户名或邮箱！' }]}
+        />
+      </ModalForm>
       <Footer />
     </div>
   );
@@ -0,0 +1,69 @@
+import { Helmet, Link, useSearchParams } from '@umijs/max';
+import { Button, Result, Spin } from 'antd';
+import React, { useEffect, useRef, useState } from 'react';
+import { Footer } from '@/components';
+import { verifyRegistration } from '@/services/api/user';
+import Settings from '../../../../config/defaultSettings';
+
+// 注册验证邮件中的链接落地页：打开即提交令牌完成验证
+const RegisterVerify: React.FC = () => {
+  const [params] = useSearchParams();
+  const token = params.get('token') || '';
+  const [result, setResult] = useState<{ ok: boolean; msg: string }>();
+  // 令牌只能使用一次，避免重复渲染时重复提交
+  const submitted = useRef(false);
+
+  useEffect(() => {
+    if (submitted.current) {
+      return;
+    }
+    submitted.current = true;
+    if (!token) {
+      setResult({ ok: false, msg: '验证链接无效或已过期' });
+      return;
+    }
+    verifyRegistration({ token }).then((res) => {
+      setResult({
+        ok: res.code === 0,
+        msg: res.msg || (res.code === 0 ? '邮箱验证成功，请登录' : '验证失败'),
+      });
+    });
+  }, [token]);
+
+  return (
+    <div
+      style={{
+        display: 'flex',
+        flexDirection: 'column',
+        height: '100vh',
+        overflow: 'auto',
+      }}
+    >
+      <Helmet>
+        <title>
+          验证邮箱
+          {Settings.title && ` - ${Settings.title}`}
+        </title>
+      </Helmet>
+      <div style={{ flex: '1', padding: '32px 0' }}>
+        {result ? (
+          <Result
+            status={result.ok ? 'success' : 'warning'}
+            title={result.ok ? '邮箱验证成功' : '邮箱验证失败'}
+            subTitle={result.msg}
+            extra={
+              <Link to="/user/login">
+                <Button type="primary">返回登录</Button>
+              </Link>
+            }
+          />
+        ) : (
+          <Spin tip="正在验证..." size="large" fullscreen />
+        )}
+      </div>
+      <Footer />
+    </div>
+  );
+};
+
+export default RegisterVerify;
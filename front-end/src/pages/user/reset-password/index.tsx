@@ -0,0 +1,110 @@
+import { LockOutlined } from '@ant-design/icons';
+import { LoginForm, ProFormText } from '@ant-design/pro-components';
+import { Helmet, history, Link, useSearchParams } from '@umijs/max';
+import { Alert, App, Result, Button } from 'antd';
+import React, { useState } from 'react';
+import { Footer } from '@/components';
+import { resetPasswordByToken } from '@/services/api/user';
+import Settings from '../../../../config/defaultSettings';
+
+// 邮件中的重置链接落地页：校验令牌并设置新密码
+const ResetPassword: React.FC = () => {
+  const [params] = useSearchParams();
+  const token = params.get('token') || '';
+  const [error, setError] = useState<string>();
+  const [done, setDone] = useState(false);
+  const { message } = App.useApp();
+
+  const handleSubmit = async (values: { newPassword: string }) => {
+    const res = await resetPasswordByToken({ token, newPassword: values.newPassword });
+    if (res.code === 0) {
+      message.success(res.msg || '密码已重置，请重新登录');
+      setDone(true);
+      return;
+    }
+    setError(res.msg || '重置失败');
+  };
+
+  return (
+    <div
+      style={{
+        display: 'flex',
+        flexDirection: 'column',
+        height: '100vh',
+        overflow: 'auto',
+      }}
+    >
+      <Helmet>
+        <title>
+          重置密码
+          {Settings.title && ` - ${Settings.title}`}
+        </title>
+      </Helmet>
+      <div style={{ flex: '1', padding: '32px 0' }}>
+        {!token || done ? (
+          <Result
+            status={done ? 'success' : 'warning'}
+            title={done ? '密码已重置' : '重置链接无效'}
+            subTitle={done ? '请使用新密码重新登录' : '请在登录页重新申请找回密码'}
+            extra={
+              <Link to="/user/login">
+                <Button type="primary">返回登录</Button>
+              </Link>
+            }
+          />
+        ) : (
+          <LoginForm
+            contentStyle={{
+              minWidth: 280,
+              maxWidth: '75vw',
+            }}
+            logo={<img alt="logo" src="/logo.svg" />}
+            title="Go Web Frame"
+            subTitle="设置新密码"
+            submitter={{ searchConfig: { submitText: '重置密码' } }}
+            onFinish={async (values) => {
+              await handleSubmit(values as { newPassword: string });
+            }}
+          >
+            {error && <Alert style={{ marginBottom: 24 }} message={error} type="error" showIcon />}
+            <ProFormText.Password
+              name="newPassword"
+              fieldProps={{
+                size: 'large',
+                prefix: <LockOutlined />,
+                autoComplete: 'new-password',
+              }}
+              placeholder="请输入新密码"
+              rules={[{ required: true, message: '请输入新密码！' }]}
+            />
+            <ProFormText.Password
+              name="confirmPassword"
+              dependencies={['newPassword']}
+              fieldProps={{
+                size: 'large',
+                prefix: <LockOutlined />,
+                autoComplete: 'new-password',
+              }}
+              placeholder="请再次输入新密码"
+              rules={[
+                { required: true, message: '请再次输入新密码！' },
+                ({ getFieldValue }) => ({
+                  validator: (_, value) =>
+                    !value || getFieldValue('newPassword') === value
+                      ? Promise.resolve()
+                      : Promise.reject(new Error('两次输入的密码不一致')),
+                }),
+              ]}
+            />
+            <div style={{ marginBottom: 24 }}>
+              <a onClick={() => history.push('/user/login')}>返回登录</a>
+            </div>
+          </LoginForm>
+        )}
+      </div>
+      <Footer />
+    </div>
+  );
+};
+
+export default ResetPassword;
@@ -1,9 +1,35 @@
 import type { RequestOptions } from '@@/plugin-request/request';
 import type { RequestConfig } from '@umijs/max';
+import { getRequestInstance } from '@umijs/max';
+import { reloadHome, restoreImpersonator } from '@/utils/impersonation';
 
 const TOKEN_KEY = 'token';
 const HEADER_TOKEN_KEY = 'x-token';
-const HEADER_NEW_TOKEN_KEY = 'new-token';
+const REFRESH_URL = '/api/v1/user/refresh';
+const UNAUTHORIZED_CODE = 1003;
+
+// 并发请求同时过期时只发起一次刷新
+let refreshing: Promise<string | undefined> | null = null;
+
+const refreshAccessToken = () => {
+  if (!refreshing) {
+    // refresh token 存放在 httpOnly Cookie 中，由浏览器自动携带
+    refreshing = fetch(REFRESH_URL, { method: 'POST', credentials: 'include' })
+      .then((res) => res.json())
+      .then((body) => {
+        const token: string | undefined = body?.code === 0 ? body.data?.token : undefined;
+        if (token) {
+          localStorage.setItem(TOKEN_KEY, token);
+        }
+        return token;
+      })
+      .catch(() => undefined)
+      .finally(() => {
+        refreshing = null;
+      });
+  }
+  return refreshing;
+};
 
 export const errorConfig: RequestConfig = {
   timeout: 10000,
@@ -31,13 +57,33 @@ export const errorConfig: RequestConfig = {
     },
   ],
   responseInterceptors: [
-    (response) => {
-      const newToken =
-        response.headers instanceof Headers ? response.headers.get(HEADER_NEW_TOKEN_KEY) : null;
-      if (newToken) {
-        localStorage.setItem(TOKEN_KEY, newToken);
+    async (response: any) => {
+      const config = response.config || {};
+      if (
+        response.data?.code !== UNAUTHORIZED_CODE ||
+        config.__retried ||
+        config.url?.includes('/user/login') ||
+        config.url?.includes(REFRESH_URL)
+      ) {
+        return response;
+      }
+
+      // 模拟登录 token 不可续期：到期后回到管理员身份，不能用 Cookie 中管理员的 refresh token 重放原请求
+      if (restoreImpersonator()) {
+        reloadHome();
+        return response;
+      }
+
+      // access token 过期：用 refresh token 换取新的 token 对后重放一次原请求
+      const token = await refreshAccessToken();
+      if (!token) {
+        return response;
       }
-      return response;
+      return getRequestInstance().request({
+        ...config,
+        __retried: true,
+        headers: { ...(config.headers || {}), [HEADER_TOKEN_KEY]: token },
+      } as any);
     },
   ],
 };
@@ -1,5 +1,19 @@
 import { request } from '@umijs/max';
 
+// keyMatch2 路径规则，语法与 Casbin 策略相同；method 为 * 时匹配任意方法
+export type ApiTokenPathScope = {
+  method: string;
+  path: string;
+};
+
+type ApiTokenApiItem = {
+  ID: number;
+  path: string;
+  method: string;
+  apiGroup?: string;
+  description?: string;
+};
+
 export type ApiTokenItem = {
   ID: number;
   tokenPrefix: string;
@@ -7,15 +21,24 @@ export type ApiTokenItem = {
   description?: string;
   enabled: boolean;
   maxConcurrency?: number;
+  quotaPerMinute?: number;
+  quotaPerDay?: number;
+  quotaPerMonth?: number;
   expiresAt?: string;
   lastUsedAt?: string;
-  apis?: Array<{
-    ID: number;
-    path: string;
-    method: string;
-    apiGroup?: string;
-    description?: string;
-  }>;
+  // 允许的来源 IP 或 CIDR，为空表示不限制
+  allowedIps?: string[];
+  // 只接受 HMAC 签名请求，Key ID 为 Token 的 ID
+  requireSignature?: boolean;
+  // 轮换宽限期内的旧密钥，宽限期结束后不再返回
+  previousTokenPrefix?: string;
+  previousTokenExpiresAt?: string;
+  previousTokenLastUsedAt?: string;
+  apis?: ApiTokenApiItem[];
+  apiGroups?: string[];
+  pathScopes?: ApiTokenPathScope[];
+  // 合并勾选接口、分组与路径规则后实际可访问的接口，仅详情返回
+  effectiveApis?: ApiTokenApiItem[];
 };
 
 export type ApiTokenListParams = {
@@ -31,8 +54,40 @@ export type ApiTokenFormPayload = {
   name: string;
   description?: string;
   maxConcurrency?: number;
+  quotaPerMinute?: number;
+  quotaPerDay?: number;
+  quotaPerMonth?: number;
   expiresAt?: string;
   apiIds?: number[];
+  apiGroups?: string[];
+  pathScopes?: ApiTokenPathScope[];
+  allowedIps?: string[];
+  requireSignature?: boolean;
+};
+
+export type ApiTokenUsageStats = {
+  calls: number;
+  errors: number;
+  bytesIn: number;
+  bytesOut: number;
+  p50Ms: number;
+  p95Ms: number;
+};
+
+export type ApiTokenUsage = {
+  granularity: 'hour' | 'day';
+  start: string;
+  end: string;
+  total: ApiTokenUsageStats;
+  series: Array<ApiTokenUsageStats & { time: string }>;
+  apis: Array<ApiTokenUsageStats & { method: string; path: string }>;
+};
+
+export type ApiTokenUsageParams = {
+  id: number;
+  granularity?: 'hour' | 'day';
+  start?: string;
+  end?: string;
 };
 
 export async function getApiTokenList(body: ApiTokenListParams, options?: { [key: string]: any }) {
@@ -61,6 +116,14 @@ export async function getApiTokenDetail(params: { id: number }, options?: { [key
   });
 }
 
+export async function getApiTokenUsage(params: ApiTokenUsageParams, options?: { [key: string]: any }) {
+  return request<API.CommonResponse>('/api/v1/sys/api-token/usage', {
+    method: 'GET',
+    params,
+    ...(options || {}),
+  });
+}
+
 export async function updateApiToken(body: ApiTokenFormPayload, options?: { [key: string]: any }) {
   return request<API.CommonResponse>('/api/v1/sys/api-token/update', {
     method: 'PUT',
@@ -88,6 +151,16 @@ export async function resetApiToken(body: { id: number }, options?: { [key: stri
   });
 }
 
+// 轮换：签发新密钥，旧密钥在 grace (如 36h、2d，最长 720h) 内仍可使用 (不填使用系统默认)
+export async function rotateApiToken(body: { id: number; grace?: string }, options?: { [key: string]: any }) {
+  return request<API.CommonResponse>('/api/v1/sys/api-token/rotate', {
+    method: 'POST',
+    headers: { 'Content-Type': 'application/json' },
+    data: body,
+    ...(options || {}),
+  });
+}
+
 export async function enableApiToken(body: { id: number }, options?: { [key: string]: any }) {
   return request<API.CommonResponse>('/api/v1/sys/api-token/enable', {
     method: 'POST',
@@ -0,0 +1,21 @@
+import { request } from '@umijs/max';
+
+// 获取全部用户的登录日志 (管理员)
+export async function getLoginLogList(body: any, options?: { [key: string]: any }) {
+  return request<API.CommonResponse>('/api/v1/sys/loginLog/getLoginLogList', {
+    method: 'POST',
+    headers: { 'Content-Type': 'application/json' },
+    data: body,
+    ...(options || {}),
+  });
+}
+
+// 获取本人的登录日志
+export async function getSelfLoginLogList(body: any, options?: { [key: string]: any }) {
+  return request<API.CommonResponse>('/api/v1/sys/loginLog/getSelfLoginLogList', {
+    method: 'POST',
+    headers: { 'Content-Type': 'application/json' },
+    data: body,
+    ...(options || {}),
+  });
+}
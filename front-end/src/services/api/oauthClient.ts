@@ -0,0 +1,95 @@
+import { request } from '@umijs/max';
+
+export type OauthClientItem = {
+  ID: number;
+  clientId: string;
+  secretPrefix: string;
+  name: string;
+  description?: string;
+  // 允许申请的 API 分组，即 OAuth2 的 scope
+  scopes: string[];
+  // 访问令牌有效期 (秒)，0 表示使用系统默认
+  accessTokenTtl: number;
+  enabled: boolean;
+  lastUsedAt?: string;
+  createdAt?: string;
+};
+
+export type OauthClientListParams = {
+  page?: number;
+  pageSize?: number;
+  name?: string;
+  enabled?: boolean;
+};
+
+export type OauthClientFormPayload = {
+  id?: number;
+  name: string;
+  description?: string;
+  scopes: string[];
+  accessTokenTtl?: number;
+};
+
+export async function getOauthClientList(body: OauthClientListParams, options?: { [key: string]: any }) {
+  return request<API.CommonResponse>('/api/v1/sys/oauth-client/getOauthClientList', {
+    method: 'POST',
+    headers: { 'Content-Type': 'application/json' },
+    data: body,
+    ...(options || {}),
+  });
+}
+
+export async function createOauthClient(body: OauthClientFormPayload, options?: { [key: string]: any }) {
+  return request<API.CommonResponse>('/api/v1/sys/oauth-client/create', {
+    method: 'POST',
+    headers: { 'Content-Type': 'application/json' },
+    data: body,
+    ...(options || {}),
+  });
+}
+
+export async function updateOauthClient(body: OauthClientFormPayload, options?: { [key: string]: any }) {
+  return request<API.CommonResponse>('/api/v1/sys/oauth-client/update', {
+    method: 'PUT',
+    headers: { 'Content-Type': 'application/json' },
+    data: body,
+    ...(options || {}),
+  });
+}
+
+export async function deleteOauthClient(body: { id: number }, options?: { [key: string]: any }) {
+  return request<API.CommonResponse>('/api/v1/sys/oauth-client/delete', {
+    method: 'DELETE',
+    headers: { 'Content-Type': 'application/json' },
+    data: body,
+    ...(options || {}),
+  });
+}
+
+// 重置密钥：旧密钥立即失效，已签发的访问令牌在过期前仍然有效
+export async function resetOauthClientSecret(body: { id: number }, options?: { [key: string]: any }) {
+  return request<API.CommonResponse>('/api/v1/sys/oauth-client/reset', {
+    method: 'POST',
+    headers: { 'Content-Type': 'application/json' },
+    data: body,
+    ...(options || {}),
+  });
+}
+
+export async function enableOauthClient(body: { id: number }, options?: { [key: string]: any }) {
+  return request<API.CommonResponse>('/api/v1/sys/oauth-client/enable', {
+    method: 'POST',
+    headers: { 'Content-Type': 'application/json' },
+    data: body,
+    ...(options || {}),
+  });
+}
+
+export async function disableOauthClient(body: { id: number }, options?: { [key: string]: any }) {
+  return request<API.CommonResponse>('/api/v1/sys/oauth-client/disable', {
+    method: 'POST',
+    headers: { 'Content-Type': 'application/json' },
+    data: body,
+    ...(options || {}),
+  });
+}
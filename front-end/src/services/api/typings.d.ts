@@ -74,6 +74,8 @@ declare namespace API {
     password?: string;
     autoLogin?: boolean;
     type?: string;
+    captcha?: string;
+    captchaId?: string;
   };
   // 用户信息
   type UserInfo = {
@@ -88,7 +90,18 @@ declare namespace API {
     phone?: string;
     email?: string;
     status: number;
+    requirePasswordChange?: boolean;
+    passwordChangedAt?: string;
     settings?: any;
+    // 仅 getSelfInfo 在模拟登录时返回
+    impersonation?: Impersonation;
+  };
+
+  // 模拟登录信息
+  type Impersonation = {
+    impersonatorId: number;
+    impersonatorName: string;
+    expiresAt: string;
   };
 
   type CurrentUser = UserInfo;
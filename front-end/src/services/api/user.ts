@@ -12,6 +12,166 @@ export async function login(body: API.LoginParams, options?: { [key: string]: an
   });
 }
 
+/** 获取登录验证码 POST /api/v1/user/captcha */
+export async function getCaptcha(options?: { [key: string]: any }) {
+  return request<API.CommonResponse>('/api/v1/user/captcha', {
+    method: 'POST',
+    ...(options || {}),
+  });
+}
+
+/** 登录二次验证 POST /api/v1/user/login/mfa */
+export async function loginMfa(
+  body: {
+    mfaToken: string;
+    code?: string;
+    recoveryCode?: string;
+    passkeyCeremonyId?: string;
+    passkeyCredential?: any;
+  },
+  options?: { [key: string]: any },
+) {
+  return request<API.CommonResponse>('/api/v1/user/login/mfa', {
+    method: 'POST',
+    headers: {
+      'Content-Type': 'application/json',
+    },
+    data: body,
+    ...(options || {}),
+  });
+}
+
+/** 登录时绑定二次验证 (角色强制) POST /api/v1/user/login/mfa/setup */
+export async function setupLoginMfa(body: { mfaToken: string }, options?: { [key: string]: any }) {
+  return request<API.CommonResponse>('/api/v1/user/login/mfa/setup', {
+    method: 'POST',
+    headers: {
+      'Content-Type': 'application/json',
+    },
+    data: body,
+    ...(options || {}),
+  });
+}
+
+/** 获取二次验证的通行密钥挑战 POST /api/v1/user/login/mfa/passkey */
+export async function beginMfaPasskey(body: { mfaToken: string }, options?: { [key: string]: any }) {
+  return request<API.CommonResponse>('/api/v1/user/login/mfa/passkey', {
+    method: 'POST',
+    headers: {
+      'Content-Type': 'application/json',
+    },
+    data: body,
+    ...(options || {}),
+  });
+}
+
+/** 获取通行密钥登录挑战 POST /api/v1/user/login/passkey/options */
+export async function beginPasskeyLogin(options?: { [key: string]: any }) {
+  return request<API.CommonResponse>('/api/v1/user/login/passkey/options', {
+    method: 'POST',
+    ...(options || {}),
+  });
+}
+
+/** 通行密钥免密码登录 POST /api/v1/user/login/passkey */
+export async function loginPasskey(
+  body: { ceremonyId: string; credential: any },
+  options?: { [key: string]: any },
+) {
+  return request<API.CommonResponse>('/api/v1/user/login/passkey', {
+    method: 'POST',
+    headers: {
+      'Content-Type': 'application/json',
+    },
+    data: body,
+    ...(options || {}),
+  });
+}
+
+/** 密码过期或被要求修改时设置新密码并登录 POST /api/v1/user/login/password */
+export async function loginPassword(
+  body: { username: string; password: string; newPassword: string; captcha?: string; captchaId?: string },
+  options?: { [key: string]: any },
+) {
+  return request<API.CommonResponse>('/api/v1/user/login/password', {
+    method: 'POST',
+    headers: {
+      'Content-Type': 'application/json',
+    },
+    data: body,
+    ...(options || {}),
+  });
+}
+
+/** 修改本人密码 POST /api/v1/sys/user/changePassword */
+export async function changePassword(body: { password: string; newPassword: string }) {
+  return request<API.CommonResponse>('/api/v1/sys/user/changePassword', { method: 'POST', data: body });
+}
+
+/** 申请找回密码 (发送重置邮件) POST /api/v1/user/password/forgot */
+export async function forgotPassword(body: { account: string }, options?: { [key: string]: any }) {
+  return request<API.CommonResponse>('/api/v1/user/password/forgot', {
+    method: 'POST',
+    headers: {
+      'Content-Type': 'application/json',
+    },
+    data: body,
+    ...(options || {}),
+  });
+}
+
+/** 使用邮件中的令牌重置密码 POST /api/v1/user/password/reset */
+export async function resetPasswordByToken(
+  body: { token: string; newPassword: string },
+  options?: { [key: string]: any },
+) {
+  return request<API.CommonResponse>('/api/v1/user/password/reset', {
+    method: 'POST',
+    headers: {
+      'Content-Type': 'application/json',
+    },
+    data: body,
+    ...(options || {}),
+  });
+}
+
+/** 使用邮件中的令牌验证注册邮箱 POST /api/v1/user/register/verify */
+export async function verifyRegistration(body: { token: string }, options?: { [key: string]: any }) {
+  return request<API.CommonResponse>('/api/v1/user/register/verify', {
+    method: 'POST',
+    headers: {
+      'Content-Type': 'application/json',
+    },
+    data: body,
+    ...(options || {}),
+  });
+}
+
+/** 获取单点登录方式 GET /api/v1/user/oidc/providers */
+export async function getOidcProviders(options?: { [key: string]: any }) {
+  return request<API.CommonResponse>('/api/v1/user/oidc/providers', {
+    method: 'GET',
+    ...(options || {}),
+  });
+}
+
+/** 单点登录授权地址 (浏览器直接跳转) */
+export function oidcAuthorizeUrl(provider: string, redirect: string) {
+  return `/api/v1/user/oidc/${encodeURIComponent(provider)}/authorize?redirect=${encodeURIComponent(redirect)}`;
+}
+
+/** 使用单点登录票据换取 token POST /api/v1/user/oidc/exchange */
+export async function oidcExchange(body: { ticket: string }, options?: { [key: string]: any }) {
+  return request<API.CommonResponse>('/api/v1/user/oidc/exchange', {
+    method: 'POST',
+    headers: {
+      'Content-Type': 'application/json',
+    },
+    data: body,
+    ...(options || {}),
+  });
+}
+
 /** 退出登录接口 POST /api/v1/user/logout */
 export async function outLogin(options?: { [key: string]: any }) {
   return request<Record<string, any>>('/api/v1/sys/user/logout', {
@@ -59,8 +219,38 @@ export async function deleteUser(body: { id: number }) {
   return request('/api/v1/sys/user/deleteUser', { method: 'DELETE', data: body });
 }
 
+/** 注册申请列表 (默认待审核) POST /api/v1/sys/user/getRegistrationList */
+export async function getRegistrationList(body: { page?: number; pageSize?: number; username?: string; status?: number }) {
+  return request<API.CommonResponse>('/api/v1/sys/user/getRegistrationList', { method: 'POST', data: body });
+}
+
+/** 审核通过注册申请 POST /api/v1/sys/user/approveRegistration */
+export async function approveRegistration(body: { id: number; reason?: string }) {
+  return request<API.CommonResponse>('/api/v1/sys/user/approveRegistration', { method: 'POST', data: body });
+}
+
+/** 拒绝注册申请 POST /api/v1/sys/user/rejectRegistration */
+export async function rejectRegistration(body: { id: number; reason?: string }) {
+  return request<API.CommonResponse>('/api/v1/sys/user/rejectRegistration', { method: 'POST', data: body });
+}
+
+/** 管理员以指定用户身份登录 (模拟登录) POST /api/v1/sys/user/impersonate */
+export async function impersonate(body: { userId: number; reason?: string }) {
+  return request<API.CommonResponse>('/api/v1/sys/user/impersonate', { method: 'POST', data: body });
+}
+
+/** 查看指定用户的通行密钥 (管理员) POST /api/v1/sys/user/getUserPasskeys */
+export async function getUserPasskeys(body: { userId: number }) {
+  return request<API.CommonResponse>('/api/v1/sys/user/getUserPasskeys', { method: 'POST', data: body });
+}
+
+/** 删除指定用户的通行密钥 (管理员) POST /api/v1/sys/user/revokeUserPasskey */
+export async function revokeUserPasskey(body: { id: number }) {
+  return request<API.CommonResponse>('/api/v1/sys/user/revokeUserPasskey', { method: 'POST', data: body });
+}
+
 // 重置密码
-export async function resetPassword(body: { id: number, password: string }) {
+export async function resetPassword(body: { id: number, password: string, requirePasswordChange?: boolean }) {
   return request('/api/v1/sys/user/resetPassword', { method: 'POST', data: body });
 }
 
@@ -87,3 +277,23 @@ export async function updateUiConfig(body: API.UpdateUiConfigParams) {
     data: body,
   });
 }
+
+/** 本人的通行密钥 GET /api/v1/sys/user/passkeys */
+export async function getPasskeys() {
+  return request<API.CommonResponse>('/api/v1/sys/user/passkeys', { method: 'GET' });
+}
+
+/** 获取通行密钥注册参数 POST /api/v1/sys/user/passkey/options */
+export async function beginPasskeyRegistration() {
+  return request<API.CommonResponse>('/api/v1/sys/user/passkey/options', { method: 'POST' });
+}
+
+/** 完成通行密钥注册 POST /api/v1/sys/user/passkey/register */
+export async function registerPasskey(body: { ceremonyId: string; name?: string; credential: any }) {
+  return request<API.CommonResponse>('/api/v1/sys/user/passkey/register', { method: 'POST', data: body });
+}
+
+/** 删除本人的通行密钥 POST /api/v1/sys/user/passkey/revoke */
+export async function revokePasskey(body: { id: number }) {
+  return request<API.CommonResponse>('/api/v1/sys/user/passkey/revoke', { method: 'POST', data: body });
+}
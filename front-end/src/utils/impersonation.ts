@@ -0,0 +1,56 @@
+// src/utils/impersonation.ts
+// 模拟登录：管理员自己的 token 暂存起来，退出或到期后恢复
+
+const TOKEN_KEY = 'token';
+const IMPERSONATOR_TOKEN_KEY = 'impersonatorToken';
+
+export const isImpersonating = () => !!localStorage.getItem(IMPERSONATOR_TOKEN_KEY);
+
+// 菜单与动态路由都跟随当前用户，切换身份后整页刷新重新加载
+export const reloadHome = () => {
+  window.location.hash = '#/';
+  window.location.reload();
+};
+
+/**
+ * 开始模拟登录
+ * @param token 后端签发的模拟登录 token
+ */
+export const startImpersonation = (token: string) => {
+  const current = localStorage.getItem(TOKEN_KEY);
+  // 已在模拟中时保留最初管理员的 token
+  if (current && !isImpersonating()) {
+    localStorage.setItem(IMPERSONATOR_TOKEN_KEY, current);
+  }
+  localStorage.setItem(TOKEN_KEY, token);
+  reloadHome();
+};
+
+/**
+ * 恢复管理员自己的 token (不请求后端)
+ * @returns 是否处于模拟登录中
+ */
+export const restoreImpersonator = (): boolean => {
+  const origin = localStorage.getItem(IMPERSONATOR_TOKEN_KEY);
+  if (!origin) {
+    return false;
+  }
+  localStorage.setItem(TOKEN_KEY, origin);
+  localStorage.removeItem(IMPERSONATOR_TOKEN_KEY);
+  return true;
+};
+
+/**
+ * 结束模拟登录并回到管理员身份
+ * @param logout 注销模拟会话的请求，失败不影响恢复
+ */
+export const exitImpersonation = async (logout: () => Promise<any>) => {
+  try {
+    await logout();
+  } catch (error) {
+    console.error('Exit impersonation failed:', error);
+  }
+  if (restoreImpersonator()) {
+    reloadHome();
+  }
+};
@@ -0,0 +1,85 @@
+// src/utils/passkey.ts
+// 通行密钥：后端参数中的二进制字段为 base64url 字符串，与浏览器 WebAuthn API 的 ArrayBuffer 互转
+
+export const passkeySupported = () =>
+  typeof window !== 'undefined' && !!window.PublicKeyCredential && !!navigator.credentials;
+
+const toBuffer = (value: string): ArrayBuffer => {
+  const base64 = value.replace(/-/g, '+').replace(/_/g, '/');
+  const binary = atob(base64.padEnd(base64.length + ((4 - (base64.length % 4)) % 4), '='));
+  const bytes = new Uint8Array(binary.length);
+  for (let i = 0; i < binary.length; i += 1) {
+    bytes[i] = binary.charCodeAt(i);
+  }
+  return bytes.buffer;
+};
+
+const toBase64url = (buffer: ArrayBuffer | null | undefined): string | undefined => {
+  if (!buffer) {
+    return undefined;
+  }
+  let binary = '';
+  new Uint8Array(buffer).forEach((b) => {
+    binary += String.fromCharCode(b);
+  });
+  return btoa(binary).replace(/\+/g, '-').replace(/\//g, '_').replace(/=+$/, '');
+};
+
+const decodeDescriptors = (list?: any[]) =>
+  list?.map((item) => ({ ...item, id: toBuffer(item.id) }));
+
+/**
+ * 注册通行密钥
+ * @param options 后端返回的 {publicKey: ...}
+ * @returns 提交给后端的凭证
+ */
+export const createPasskey = async (options: any) => {
+  const { publicKey } = options;
+  const credential = (await navigator.credentials.create({
+    publicKey: {
+      ...publicKey,
+      challenge: toBuffer(publicKey.challenge),
+      user: { ...publicKey.user, id: toBuffer(publicKey.user.id) },
+      excludeCredentials: decodeDescriptors(publicKey.excludeCredentials),
+    },
+  })) as PublicKeyCredential;
+  const response = credential.response as AuthenticatorAttestationResponse;
+  return {
+    id: credential.id,
+    rawId: toBase64url(credential.rawId),
+    type: credential.type,
+    response: {
+      clientDataJSON: toBase64url(response.clientDataJSON),
+      attestationObject: toBase64url(response.attestationObject),
+      transports: response.getTransports?.() || [],
+    },
+  };
+};
+
+/**
+ * 使用通行密钥签名登录挑战
+ * @param options 后端返回的 {publicKey: ...}
+ * @returns 提交给后端的断言
+ */
+export const getPasskey = async (options: any) => {
+  const { publicKey } = options;
+  const credential = (await navigator.credentials.get({
+    publicKey: {
+      ...publicKey,
+      challenge: toBuffer(publicKey.challenge),
+      allowCredentials: decodeDescriptors(publicKey.allowCredentials),
+    },
+  })) as PublicKeyCredential;
+  const response = credential.response as AuthenticatorAssertionResponse;
+  return {
+    id: credential.id,
+    rawId: toBase64url(credential.rawId),
+    type: credential.type,
+    response: {
+      clientDataJSON: toBase64url(response.clientDataJSON),
+      authenticatorData: toBase64url(response.authenticatorData),
+      signature: toBase64url(response.signature),
+      userHandle: toBase64url(response.userHandle),
+    },
+  };
+};